@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/VeRJiL/go-template/internal/pkg/changelog"
+)
+
+func main() {
+	var (
+		file     = flag.String("file", "CHANGELOG.md", "Path to the Keep a Changelog formatted file")
+		from     = flag.String("from", "", "Only include versions after this version")
+		to       = flag.String("to", "", "Only include versions up to and including this version")
+		breaking = flag.Bool("breaking", false, "Exit with status 2 if any selected version has breaking changes")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "API Changelog Parser for Go Template\n\n")
+		fmt.Fprintf(os.Stderr, "Prints the changelog in the JSON format served by GET /api/changelog.\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  # Print all versions\n")
+		fmt.Fprintf(os.Stderr, "  %s\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Fail CI if anything since 1.0.0 breaks clients\n")
+		fmt.Fprintf(os.Stderr, "  %s -from=1.0.0 -breaking\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	versions, err := changelog.ParseFile(*file)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	filtered, err := changelog.Filter(versions, *from, *to)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ %v\n", err)
+		os.Exit(1)
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(map[string]interface{}{"versions": filtered}); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to encode changelog: %v\n", err)
+		os.Exit(1)
+	}
+
+	if *breaking {
+		for _, v := range filtered {
+			if len(v.BreakingChanges) > 0 {
+				fmt.Fprintf(os.Stderr, "⚠️  Version %s contains breaking changes\n", v.Version)
+				os.Exit(2)
+			}
+		}
+	}
+}
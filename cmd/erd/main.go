@@ -0,0 +1,61 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/VeRJiL/go-template/internal/pkg/generator"
+)
+
+func main() {
+	var (
+		specs  = flag.String("specs", "specs/*.yaml", "Glob pattern of entity spec files")
+		format = flag.String("format", generator.ERDFormatMermaid, "Output format: mermaid, plantuml or dbml")
+		output = flag.String("output", "docs/erd.mmd", "Output file path")
+	)
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Entity Relationship Diagram Generator for Go Template\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  # Generate a Mermaid diagram from specs/*.yaml\n")
+		fmt.Fprintf(os.Stderr, "  %s\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate a DBML schema\n")
+		fmt.Fprintf(os.Stderr, "  %s -format=dbml -output=docs/erd.dbml\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	configs, err := generator.LoadEntitySpecs(*specs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to load specs: %v\n", err)
+		os.Exit(1)
+	}
+
+	if len(configs) == 0 {
+		fmt.Fprintf(os.Stderr, "❌ No entity specs found matching %s\n", *specs)
+		os.Exit(1)
+	}
+
+	diagram, err := generator.RenderERD(*format, configs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to render diagram: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*output), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to create output directory: %v\n", err)
+		os.Exit(1)
+	}
+
+	if err := os.WriteFile(*output, []byte(diagram), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write diagram: %v\n", err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("🎉 Generated %s diagram for %d entities: %s\n", *format, len(configs), *output)
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/pkg/generator"
+)
+
+// runAlertRules implements the alert-rules subcommand, writing the
+// Prometheus rules for the slow query counter
+func runAlertRules(args []string) int {
+	flags := flag.NewFlagSet("alert-rules", flag.ExitOnError)
+	var (
+		namespace = flags.String("namespace", envOr("MONITORING_NAMESPACE", "go_template"), "Metric namespace (MONITORING_NAMESPACE)")
+		window    = flags.Duration("window", 5*time.Minute, "Window the slow query rate is computed over")
+		rate      = flags.Float64("rate", 0.1, "Slow queries per second, per database and operation, that fire the alert")
+		firing    = flags.Duration("for", 10*time.Minute, "How long the rate must stay above -rate before the alert fires")
+		output    = flags.String("output", "config/prometheus/recording_rules.yml", "Output file path")
+	)
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s alert-rules [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Generates Prometheus recording and alert rules for slow database queries\n\n")
+		fmt.Fprintf(os.Stderr, "Examples:\n")
+		fmt.Fprintf(os.Stderr, "  # Alert when a query type is slow more than once a second for 5 minutes\n")
+		fmt.Fprintf(os.Stderr, "  %s alert-rules -rate=1 -for=5m\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Options:\n")
+		flags.PrintDefaults()
+	}
+
+	flags.Parse(args)
+
+	rules, err := generator.RenderSlowQueryRules(generator.SlowQueryRulesConfig{
+		Namespace: *namespace,
+		Window:    *window,
+		Rate:      *rate,
+		For:       *firing,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to render rules: %v\n", err)
+		return 1
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*output), 0755); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to create output directory: %v\n", err)
+		return 1
+	}
+
+	if err := os.WriteFile(*output, []byte(rules), 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "❌ Failed to write rules: %v\n", err)
+		return 1
+	}
+
+	fmt.Printf("🎉 Generated slow query rules: %s\n", *output)
+	fmt.Println("💡 Add the file to rule_files in prometheus.yml to load it")
+	return 0
+}
+
+// envOr returns the environment variable key, or fallback when it is unset
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/VeRJiL/go-template/internal/pkg/generator"
@@ -14,6 +15,10 @@ import (
 )
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "alert-rules" {
+		os.Exit(runAlertRules(os.Args[2:]))
+	}
+
 	var (
 		entityName  = flag.String("entity", "", "Entity name (required)")
 		tableName   = flag.String("table", "", "Table name (defaults to snake_case of entity name)")
@@ -22,17 +27,25 @@ func main() {
 		cache       = flag.Bool("cache", true, "Enable caching")
 		generateAll = flag.Bool("all", false, "Generate entity, repository, service, handler, module, and tests")
 		genEntity   = flag.Bool("gen-entity", false, "Generate entity")
-		genRepo     = flag.Bool("gen-repo", false, "Generate repository")
+		genRepo     = flag.Bool("gen-repo", false, "Generate repository and its up and down migrations")
 		genService  = flag.Bool("gen-service", false, "Generate service")
-		genHandler  = flag.Bool("gen-handler", false, "Generate handler")
+		genHandler  = flag.Bool("gen-handler", false, "Generate handler and its OpenAPI spec fragment, and a gRPC server when GRPC_ENABLED=true")
 		genModule   = flag.Bool("gen-module", false, "Generate module")
+		genAdmin    = flag.Bool("gen-admin", false, "Generate admin panel routes under /admin/<entity>s with bulk operations, restricted to the roles holding every permission")
 		genTests    = flag.Bool("gen-tests", false, "Generate tests")
+		genIntTests = flag.Bool("gen-integration-tests", false, "Generate repository integration tests against a PostgreSQL container, run with go test -tags integration")
+		eventSource = flag.Bool("event-sourcing", false, "Record changes in an <entity>_events table and generate an event store")
 		packageName = flag.String("package", "github.com/VeRJiL/go-template", "Package name")
 		basePath    = flag.String("base-path", ".", "Base path for generation")
+		layoutName  = flag.String("layout", generator.LayoutStandard, "File layout: standard, flat or custom")
+		layoutFile  = flag.String("layout-config", "", "YAML path templates for the custom layout")
+		fieldSpec   = flag.String("fields", "", "Entity fields as comma separated name:type:constraints tuples, constraints separated by |")
+		relSpec     = flag.String("relation", "", "Relations as comma separated type:Entity pairs, e.g. ManyToMany:Tag. The related entity must be generated first")
 	)
 
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Usage: %s [options]\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "       %s alert-rules [options]\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Enterprise Code Generator for Go Template\n\n")
 		fmt.Fprintf(os.Stderr, "Examples:\n")
 		fmt.Fprintf(os.Stderr, "  # Generate complete module for Product entity\n")
@@ -41,6 +54,22 @@ func main() {
 		fmt.Fprintf(os.Stderr, "  %s -entity=Product -gen-entity -gen-repo\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "  # Generate with soft delete and custom table name\n")
 		fmt.Fprintf(os.Stderr, "  %s -entity=Product -table=products -soft-delete -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate with custom fields instead of name and description\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Article -fields=\"title:string:required|max=200,body:string,views:int,published_at:*time.Time\" -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate Article with a many-to-many relation to an already generated Tag\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Article -relation=ManyToMany:Tag -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate an event sourced Order with an order_events event store\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Order -event-sourcing -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate Product with a gRPC server and its proto next to the handler\n")
+		fmt.Fprintf(os.Stderr, "  GRPC_ENABLED=true %s -entity=Product -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate Product with admin panel routes under /admin/products\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Product -all -gen-admin\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate Product with dockertest integration tests for its repository\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Product -all -gen-integration-tests\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate into internal/ as a single package\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Product -layout=flat -all\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "  # Generate with path templates from a YAML file\n")
+		fmt.Fprintf(os.Stderr, "  %s -entity=Product -layout=custom -layout-config=layout.yaml -all\n\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "Options:\n")
 		flag.PrintDefaults()
 	}
@@ -60,33 +89,89 @@ func main() {
 	}
 
 	// Determine what to generate
-	if !*generateAll && !*genEntity && !*genRepo && !*genService && !*genHandler && !*genModule && !*genTests {
+	if !*generateAll && !*genEntity && !*genRepo && !*genService && !*genHandler && !*genModule && !*genAdmin && !*genTests && !*genIntTests {
 		fmt.Fprintf(os.Stderr, "Error: Must specify what to generate. Use -all or specific -gen-* flags\n\n")
 		flag.Usage()
 		os.Exit(1)
 	}
 
+	layout, err := selectLayout(*layoutName, *layoutFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	var fields []modules.FieldDefinition
+	validation := modules.ValidationConfig{
+		Required: []string{"name"},
+		Rules:    map[string]string{"name": "required,min=2,max=100"},
+	}
+	if *fieldSpec != "" {
+		fields, err = generator.ParseFields(*fieldSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			flag.Usage()
+			os.Exit(1)
+		}
+		validation = generator.FieldValidation(fields)
+	}
+
+	var relations []modules.RelationDefinition
+	if *relSpec != "" {
+		relations, err = generator.ParseRelations(*relSpec)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n\n", err)
+			flag.Usage()
+			os.Exit(1)
+		}
+	}
+
+	// Generate a gRPC server along with the handler when the app serves gRPC
+	grpcEnabled := false
+	if value := os.Getenv("GRPC_ENABLED"); value != "" {
+		grpcEnabled, err = strconv.ParseBool(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid GRPC_ENABLED %q: %v\n\n", value, err)
+			os.Exit(1)
+		}
+	}
+
+	// Version the tables when the app uses optimistic locking
+	optimisticLocking := false
+	if value := os.Getenv("DB_OPTIMISTIC_LOCKING"); value != "" {
+		optimisticLocking, err = strconv.ParseBool(value)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: invalid DB_OPTIMISTIC_LOCKING %q: %v\n\n", value, err)
+			os.Exit(1)
+		}
+	}
+
 	// Initialize logger
 	loggerInstance := logger.New("info", "text")
 
 	// Initialize generator
-	gen := generator.NewGenerator(loggerInstance, *basePath, *packageName)
+	// The entity's generate- Makefile target re-runs this command line
+	command := "go run ./cmd/generator " + generator.ShellCommand(os.Args[1:]...)
+	gen := generator.NewGenerator(loggerInstance, *basePath, *packageName, generator.WithLayout(layout), generator.WithCommand(command), generator.WithOptimisticLocking(optimisticLocking))
 
 	// Create entity config
 	config := modules.EntityConfig{
-		Name:       *entityName,
-		TableName:  *tableName,
-		SoftDelete: *softDelete,
-		Timestamps: *timestamps,
+		Name:          *entityName,
+		TableName:     *tableName,
+		SoftDelete:    *softDelete,
+		Timestamps:    *timestamps,
+		EventSourcing: *eventSource,
+		GRPC:          grpcEnabled,
+		Admin:         *genAdmin,
+		Fields:        fields,
+		Relations:     relations,
 		Cache: modules.CacheConfig{
 			Enabled: *cache,
 			TTL:     "1h",
 			Prefix:  strings.ToLower(*entityName),
 		},
-		Validation: modules.ValidationConfig{
-			Required: []string{"name"},
-			Rules:    map[string]string{"name": "required,min=2,max=100"},
-		},
+		Validation: validation,
 		Permissions: modules.PermissionConfig{
 			Create: []string{"admin", "user"},
 			Read:   []string{"admin", "user", "guest"},
@@ -96,6 +181,12 @@ func main() {
 		},
 	}
 
+	// Refuse relations to entities that were not generated yet
+	if err := generator.CheckRelations(layout, *basePath, config); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	fmt.Printf("🚀 Starting code generation for entity '%s'\n", *entityName)
 	fmt.Printf("📋 Configuration:\n")
 	fmt.Printf("   - Entity: %s\n", config.Name)
@@ -103,8 +194,19 @@ func main() {
 	fmt.Printf("   - Soft Delete: %v\n", config.SoftDelete)
 	fmt.Printf("   - Timestamps: %v\n", config.Timestamps)
 	fmt.Printf("   - Cache: %v\n", config.Cache.Enabled)
+	fmt.Printf("   - Event Sourcing: %v\n", config.EventSourcing)
+	fmt.Printf("   - Optimistic Locking: %v\n", optimisticLocking)
+	fmt.Printf("   - gRPC: %v\n", config.GRPC)
+	fmt.Printf("   - Admin: %v\n", config.Admin)
 	fmt.Printf("   - Package: %s\n", *packageName)
 	fmt.Printf("   - Base Path: %s\n", *basePath)
+	fmt.Printf("   - Layout: %s\n", *layoutName)
+	for _, field := range fields {
+		fmt.Printf("   - Field: %s %s %s\n", field.Name, field.Type, strings.Join(field.Constraints, "|"))
+	}
+	for _, relation := range relations {
+		fmt.Printf("   - Relation: %s %s\n", relation.Type, relation.Entity)
+	}
 	fmt.Println()
 
 	// Generate components
@@ -160,6 +262,17 @@ func main() {
 		}
 	}
 
+	// The module generates the admin handler along with the other components
+	if *genAdmin && !*generateAll && !*genModule {
+		fmt.Print("🛡️  Generating admin handler... ")
+		if err := gen.GenerateAdmin(config); err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			errors = append(errors, err)
+		} else {
+			fmt.Println("✅ Success")
+		}
+	}
+
 	if *generateAll || *genTests {
 		fmt.Print("🧪 Generating tests... ")
 		if err := gen.GenerateTests(config); err != nil {
@@ -170,6 +283,25 @@ func main() {
 		}
 	}
 
+	// Integration tests need Docker and the dockertest module, so -all leaves them out
+	if *genIntTests {
+		fmt.Print("🐳 Generating integration tests... ")
+		if err := gen.GenerateIntegrationTests(config); err != nil {
+			fmt.Printf("❌ Failed: %v\n", err)
+			errors = append(errors, err)
+		} else {
+			fmt.Println("✅ Success")
+		}
+	}
+
+	fmt.Print("🛠️  Updating Makefile... ")
+	if err := gen.GenerateMakefile(config); err != nil {
+		fmt.Printf("❌ Failed: %v\n", err)
+		errors = append(errors, err)
+	} else {
+		fmt.Println("✅ Success")
+	}
+
 	fmt.Println()
 
 	if len(errors) > 0 {
@@ -184,14 +316,35 @@ func main() {
 	fmt.Println()
 	fmt.Println("📋 Next steps:")
 	fmt.Println("   1. Review generated files and customize as needed")
-	fmt.Println("   2. Run database migrations")
+	fmt.Println("   2. Run database migrations (make migrate-up)")
 	fmt.Println("   3. Register the module in your application")
 	fmt.Println("   4. Run tests to verify functionality")
+	fmt.Printf("   5. Use the generated make targets, e.g. make test-%s\n", strings.ToLower(*entityName))
+	if *genIntTests {
+		fmt.Println("   6. Add dockertest (go get github.com/ory/dockertest/v3) and run the integration tests with go test -tags integration")
+	}
 	fmt.Println()
 	fmt.Println("💡 Example module registration:")
 	fmt.Printf("   registry.Register(modules.New%sModule())\n", *entityName)
 }
 
+// selectLayout returns the generator layout named by the -layout flag
+func selectLayout(name, file string) (generator.GeneratorLayout, error) {
+	switch name {
+	case generator.LayoutStandard:
+		return generator.StandardLayout{}, nil
+	case generator.LayoutFlat:
+		return generator.FlatLayout{}, nil
+	case generator.LayoutCustom:
+		if file == "" {
+			return nil, fmt.Errorf("-layout=custom requires -layout-config")
+		}
+		return generator.LoadCustomLayout(file)
+	default:
+		return nil, fmt.Errorf("unknown layout %q, use standard, flat or custom", name)
+	}
+}
+
 // toSnakeCase converts CamelCase to snake_case
 func toSnakeCase(str string) string {
 	var result strings.Builder
@@ -1,9 +1,12 @@
 package main
 
 import (
+	"errors"
+	"flag"
 	"log"
 
 	"github.com/VeRJiL/go-template/internal/app"
+	"github.com/VeRJiL/go-template/internal/config"
 )
 
 // @title Go Template API
@@ -27,13 +30,29 @@ import (
 // @description Type "Bearer" followed by a space and JWT token.
 
 func main() {
+	tlsCert := flag.String("tls-cert", "", "Path to a TLS certificate for manual HTTPS")
+	tlsKey := flag.String("tls-key", "", "Path to the TLS private key matching -tls-cert")
+	flag.Parse()
+
+	if (*tlsCert == "") != (*tlsKey == "") {
+		log.Fatal("--tls-cert and --tls-key must be provided together")
+	}
+
 	log.Println("🚀 Starting Go Template Application")
 
 	application, err := app.New()
+	var validationErr *config.ConfigValidationError
+	if errors.As(err, &validationErr) {
+		log.Fatalf("Invalid configuration:\n\n%s", validationErr.Table())
+	}
 	if err != nil {
 		log.Fatalf("Failed to create application: %v", err)
 	}
 
+	if *tlsCert != "" {
+		application.UseCertificate(*tlsCert, *tlsKey)
+	}
+
 	if err := application.Run(); err != nil {
 		log.Fatalf("Application error: %v", err)
 	}
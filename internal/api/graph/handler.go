@@ -0,0 +1,93 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/gin-gonic/gin"
+
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+)
+
+// ErrUnauthenticated is returned by operations that require a valid JWT
+var ErrUnauthenticated = errors.New("authentication required")
+
+type claimsContextKey struct{}
+
+// ClaimsFromContext returns the JWT claims of the caller
+func ClaimsFromContext(ctx context.Context) (*auth.Claims, bool) {
+	claims, ok := ctx.Value(claimsContextKey{}).(*auth.Claims)
+	return claims, ok
+}
+
+// NewHandler serves queries over HTTP and subscriptions over WebSocket.
+//
+// The caller is authenticated from the Authorization header or token query
+// parameter of the request (for WebSockets, the upgrade request), falling
+// back to the authToken/Authorization field of the connection_init payload.
+func NewHandler(users UserFinder, bus *eventbus.Bus, jwtService *auth.JWTService) gin.HandlerFunc {
+	srv := handler.New(NewExecutableSchema(Config{
+		Resolvers: &Resolver{users: users, bus: bus},
+	}))
+
+	srv.AddTransport(transport.Websocket{
+		KeepAlivePingInterval: 10 * time.Second,
+		InitFunc: func(ctx context.Context, payload transport.InitPayload) (context.Context, *transport.InitPayload, error) {
+			if _, ok := ClaimsFromContext(ctx); ok {
+				return ctx, &payload, nil
+			}
+
+			token := bearerToken(payload.Authorization())
+			if token == "" {
+				token, _ = payload["authToken"].(string)
+			}
+			if token == "" {
+				return nil, nil, ErrUnauthenticated
+			}
+
+			claims, err := jwtService.ValidateToken(token)
+			if err != nil {
+				return nil, nil, ErrUnauthenticated
+			}
+			return context.WithValue(ctx, claimsContextKey{}, claims), &payload, nil
+		},
+	})
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.Use(extension.Introspection{})
+
+	return func(c *gin.Context) {
+		token := bearerToken(c.GetHeader("Authorization"))
+		if token == "" {
+			// Browsers cannot set headers on WebSocket upgrades
+			token = c.Query("token")
+		}
+
+		if token != "" {
+			claims, err := jwtService.ValidateToken(token)
+			if err != nil {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+				return
+			}
+			ctx := context.WithValue(c.Request.Context(), claimsContextKey{}, claims)
+			c.Request = c.Request.WithContext(ctx)
+		}
+
+		srv.ServeHTTP(c.Writer, c.Request)
+	}
+}
+
+func bearerToken(header string) string {
+	token, found := strings.CutPrefix(header, "Bearer ")
+	if !found {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
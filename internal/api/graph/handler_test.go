@@ -0,0 +1,195 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+)
+
+type stubUserFinder struct {
+	users map[uuid.UUID]*entities.User
+}
+
+func (s *stubUserFinder) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
+	return s.users[id], nil
+}
+
+type wsMessage struct {
+	ID      string          `json:"id,omitempty"`
+	Type    string          `json:"type"`
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+func setupGraphQLServer(t *testing.T) (*httptest.Server, *eventbus.Bus, *auth.JWTService, *entities.User) {
+	gin.SetMode(gin.TestMode)
+
+	user := &entities.User{ID: uuid.New(), Email: "jane@example.com", FirstName: "Jane", LastName: "Roe", Role: "user", IsActive: true}
+	jwtService := auth.NewJWTService("test-secret", 3600)
+	bus := eventbus.New()
+
+	router := gin.New()
+	h := NewHandler(&stubUserFinder{users: map[uuid.UUID]*entities.User{user.ID: user}}, bus, jwtService)
+	router.GET("/graphql", h)
+	router.POST("/graphql", h)
+
+	server := httptest.NewServer(router)
+	t.Cleanup(server.Close)
+
+	return server, bus, jwtService, user
+}
+
+func dialGraphQL(t *testing.T, server *httptest.Server, header http.Header) *websocket.Conn {
+	dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/graphql"
+
+	conn, _, err := dialer.Dial(url, header)
+	require.NoError(t, err)
+	t.Cleanup(func() { conn.Close() })
+
+	return conn
+}
+
+func readMessage(t *testing.T, conn *websocket.Conn) (wsMessage, error) {
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+	for {
+		var msg wsMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			return msg, err
+		}
+		if msg.Type != "ping" && msg.Type != "pong" {
+			return msg, nil
+		}
+	}
+}
+
+func TestUserCreatedSubscription(t *testing.T) {
+	server, bus, jwtService, _ := setupGraphQLServer(t)
+
+	t.Run("should push created users to authenticated subscribers", func(t *testing.T) {
+		token, _, err := jwtService.GenerateToken(uuid.New(), "admin@example.com", "admin")
+		require.NoError(t, err)
+
+		conn := dialGraphQL(t, server, http.Header{"Authorization": {"Bearer " + token}})
+
+		require.NoError(t, conn.WriteJSON(wsMessage{Type: "connection_init"}))
+		ack, err := readMessage(t, conn)
+		require.NoError(t, err)
+		require.Equal(t, "connection_ack", ack.Type)
+
+		require.NoError(t, conn.WriteJSON(wsMessage{
+			ID:      "1",
+			Type:    "subscribe",
+			Payload: json.RawMessage(`{"query":"subscription { userCreated { id email } }"}`),
+		}))
+
+		require.Eventually(t, func() bool {
+			return bus.SubscriberCount(eventbus.UserCreated) == 1
+		}, 5*time.Second, 10*time.Millisecond)
+
+		created := &entities.User{ID: uuid.New(), Email: "new@example.com"}
+		bus.Publish(eventbus.UserCreated, created)
+
+		msg, err := readMessage(t, conn)
+		require.NoError(t, err)
+		assert.Equal(t, "next", msg.Type)
+		assert.Equal(t, "1", msg.ID)
+		assert.JSONEq(t,
+			`{"data":{"userCreated":{"id":"`+created.ID.String()+`","email":"new@example.com"}}}`,
+			string(msg.Payload))
+
+		require.NoError(t, conn.WriteJSON(wsMessage{ID: "1", Type: "complete"}))
+		require.Eventually(t, func() bool {
+			return bus.SubscriberCount(eventbus.UserCreated) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should accept token from connection_init payload", func(t *testing.T) {
+		token, _, err := jwtService.GenerateToken(uuid.New(), "admin@example.com", "admin")
+		require.NoError(t, err)
+
+		conn := dialGraphQL(t, server, nil)
+
+		require.NoError(t, conn.WriteJSON(wsMessage{
+			Type:    "connection_init",
+			Payload: json.RawMessage(`{"Authorization":"Bearer ` + token + `"}`),
+		}))
+		ack, err := readMessage(t, conn)
+		require.NoError(t, err)
+		assert.Equal(t, "connection_ack", ack.Type)
+	})
+
+	t.Run("should reject connections without a token", func(t *testing.T) {
+		conn := dialGraphQL(t, server, nil)
+
+		require.NoError(t, conn.WriteJSON(wsMessage{Type: "connection_init"}))
+		msg, err := readMessage(t, conn)
+
+		if err == nil {
+			assert.NotEqual(t, "connection_ack", msg.Type)
+		}
+	})
+
+	t.Run("should reject invalid upgrade tokens", func(t *testing.T) {
+		dialer := websocket.Dialer{Subprotocols: []string{"graphql-transport-ws"}}
+		url := "ws" + strings.TrimPrefix(server.URL, "http") + "/graphql?token=invalid"
+
+		_, resp, err := dialer.Dial(url, nil)
+
+		require.Error(t, err)
+		require.NotNil(t, resp)
+		assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+}
+
+func TestMeQuery(t *testing.T) {
+	server, _, jwtService, user := setupGraphQLServer(t)
+
+	query := func(token string) map[string]interface{} {
+		req, err := http.NewRequest(http.MethodPost, server.URL+"/graphql", bytes.NewBufferString(`{"query":"{ me { id email firstName } }"}`))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		var body map[string]interface{}
+		require.NoError(t, json.NewDecoder(resp.Body).Decode(&body))
+		return body
+	}
+
+	t.Run("should return the authenticated user", func(t *testing.T) {
+		token, _, err := jwtService.GenerateToken(user.ID, user.Email, user.Role)
+		require.NoError(t, err)
+
+		body := query(token)
+
+		assert.Equal(t, map[string]interface{}{
+			"me": map[string]interface{}{"id": user.ID.String(), "email": user.Email, "firstName": user.FirstName},
+		}, body["data"])
+	})
+
+	t.Run("should return an error without a token", func(t *testing.T) {
+		body := query("")
+
+		assert.NotEmpty(t, body["errors"])
+	})
+}
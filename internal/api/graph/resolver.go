@@ -0,0 +1,23 @@
+package graph
+
+//go:generate go run github.com/99designs/gqlgen generate
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+)
+
+// UserFinder loads users for the query resolvers
+type UserFinder interface {
+	GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
+}
+
+// Resolver is the root resolver shared by all GraphQL operations
+type Resolver struct {
+	users UserFinder
+	bus   *eventbus.Bus
+}
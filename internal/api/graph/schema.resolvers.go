@@ -0,0 +1,68 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver
+// implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.86
+
+import (
+	"context"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+)
+
+// Me is the resolver for the me field.
+func (r *queryResolver) Me(ctx context.Context) (*entities.User, error) {
+	claims, ok := ClaimsFromContext(ctx)
+	if !ok {
+		return nil, ErrUnauthenticated
+	}
+	return r.users.GetByID(ctx, claims.UserID)
+}
+
+// UserCreated is the resolver for the userCreated field.
+func (r *subscriptionResolver) UserCreated(ctx context.Context) (<-chan *entities.User, error) {
+	if _, ok := ClaimsFromContext(ctx); !ok {
+		return nil, ErrUnauthenticated
+	}
+
+	events, unsubscribe := r.bus.Subscribe(eventbus.UserCreated)
+	users := make(chan *entities.User, 1)
+
+	go func() {
+		defer close(users)
+		defer unsubscribe()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				user, ok := event.Payload.(*entities.User)
+				if !ok {
+					continue
+				}
+				select {
+				case users <- user:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return users, nil
+}
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// Subscription returns SubscriptionResolver implementation.
+func (r *Resolver) Subscription() SubscriptionResolver { return &subscriptionResolver{r} }
+
+type queryResolver struct{ *Resolver }
+type subscriptionResolver struct{ *Resolver }
@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// APIKeyHandler lets admins issue and revoke API keys
+type APIKeyHandler struct {
+	apiKeyService *auth.APIKeyService
+	logger        *logger.Logger
+}
+
+func NewAPIKeyHandler(apiKeyService *auth.APIKeyService, logger *logger.Logger) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+		logger:        logger,
+	}
+}
+
+// Create godoc
+// @Summary Create an API key
+// @Description Issue an API key for a user. The key is only returned in this response.
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.CreateAPIKeyRequest true "API key owner, name and scopes"
+// @Success 201 {object} auth.APIKey
+// @Failure 400 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys [post]
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	var req entities.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+		return
+	}
+
+	if req.UserID == uuid.Nil || req.Name == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "User ID and name are required"})
+		return
+	}
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Expiry must be in the future"})
+		return
+	}
+
+	key, err := h.apiKeyService.CreateAPIKey(c.Request.Context(), req.UserID, req.Name, req.Scopes, req.ExpiresAt)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create API key", "user_id", req.UserID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create API key"})
+		return
+	}
+
+	c.JSON(http.StatusCreated, key)
+}
+
+// Revoke godoc
+// @Summary Revoke an API key
+// @Description Revoke an API key, it stops authenticating within a minute on every instance
+// @Tags admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /admin/api-keys/{id} [delete]
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid API key ID"})
+		return
+	}
+
+	if err := h.apiKeyService.RevokeAPIKey(c.Request.Context(), id); err != nil {
+		if errors.Is(err, auth.ErrAPIKeyNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "API key not found"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to revoke API key", "api_key_id", id, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke API key"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
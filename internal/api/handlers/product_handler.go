@@ -51,7 +51,7 @@ func (h *ProductHandler) Create(c *gin.Context) {
 
 	result, err := h.service.Create(c.Request.Context(), &entity)
 	if err != nil {
-		h.logger.Error("Failed to create product", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create product", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to create product",
 			"message": err.Error(),
@@ -89,7 +89,7 @@ func (h *ProductHandler) GetByID(c *gin.Context) {
 
 	entity, err := h.service.GetByID(c.Request.Context(), uint(id))
 	if err != nil {
-		h.logger.Error("Failed to get product by ID", "error", err, "id", id)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to get product by ID", "error", err, "id", id)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Product not found",
 			"message": err.Error(),
@@ -138,7 +138,7 @@ func (h *ProductHandler) Update(c *gin.Context) {
 
 	result, err := h.service.Update(c.Request.Context(), uint(id), &entity)
 	if err != nil {
-		h.logger.Error("Failed to update product", "error", err, "id", id)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update product", "error", err, "id", id)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to update product",
 			"message": err.Error(),
@@ -175,7 +175,7 @@ func (h *ProductHandler) Delete(c *gin.Context) {
 	}
 
 	if err := h.service.Delete(c.Request.Context(), uint(id)); err != nil {
-		h.logger.Error("Failed to delete product", "error", err, "id", id)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to delete product", "error", err, "id", id)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Failed to delete product",
 			"message": err.Error(),
@@ -228,7 +228,7 @@ func (h *ProductHandler) List(c *gin.Context) {
 
 	entities, total, err := h.service.List(c.Request.Context(), filters)
 	if err != nil {
-		h.logger.Error("Failed to list products", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to list products", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to list products",
 			"message": err.Error(),
@@ -268,7 +268,7 @@ func (h *ProductHandler) FindByName(c *gin.Context) {
 
 	entity, err := h.service.FindByName(c.Request.Context(), name)
 	if err != nil {
-		h.logger.Error("Failed to find product by name", "error", err, "name", name)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to find product by name", "error", err, "name", name)
 		c.JSON(http.StatusNotFound, gin.H{
 			"error":   "Product not found",
 			"message": err.Error(),
@@ -304,7 +304,7 @@ func (h *ProductHandler) SearchByName(c *gin.Context) {
 
 	entities, err := h.service.SearchByName(c.Request.Context(), pattern)
 	if err != nil {
-		h.logger.Error("Failed to search products", "error", err, "pattern", pattern)
+		h.logger.WithContext(c.Request.Context()).Error("Failed to search products", "error", err, "pattern", pattern)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error":   "Failed to search products",
 			"message": err.Error(),
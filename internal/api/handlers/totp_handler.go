@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// TOTPHandler lets users enroll in and leave TOTP two-factor authentication
+type TOTPHandler struct {
+	totpService *auth.TOTPService
+	logger      *logger.Logger
+}
+
+func NewTOTPHandler(totpService *auth.TOTPService, logger *logger.Logger) *TOTPHandler {
+	return &TOTPHandler{
+		totpService: totpService,
+		logger:      logger,
+	}
+}
+
+// Enroll godoc
+// @Summary Start TOTP enrollment
+// @Description Generate a TOTP secret for the current user, enabled once confirmed with a first code within 10 minutes
+// @Tags auth
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} entities.TOTPEnrollmentResponse
+// @Failure 401 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/totp/enroll [post]
+func (h *TOTPHandler) Enroll(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	secret, qrCodeURL, err := h.totpService.GenerateSecret(c.Request.Context(), userID)
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to generate TOTP secret", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start TOTP enrollment"})
+		return
+	}
+
+	c.JSON(http.StatusOK, entities.TOTPEnrollmentResponse{Secret: secret, QRCodeURL: qrCodeURL})
+}
+
+// Confirm godoc
+// @Summary Confirm TOTP enrollment
+// @Description Enable TOTP for the current user with a first code of the secret generated by enroll
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.TOTPCodeRequest true "Code of the authenticator app"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/totp/confirm [post]
+func (h *TOTPHandler) Confirm(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var req entities.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+		return
+	}
+
+	err := h.totpService.EnableTOTP(c.Request.Context(), userID, req.Code)
+	switch {
+	case err == nil:
+		c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled"})
+	case errors.Is(err, auth.ErrInvalidTOTPCode):
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid TOTP code"})
+	case errors.Is(err, auth.ErrTOTPNotPending):
+		c.JSON(http.StatusConflict, gin.H{"error": "No pending TOTP enrollment, enroll again"})
+	default:
+		h.logger.WithContext(c.Request.Context()).Error("Failed to enable TOTP", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication"})
+	}
+}
+
+// Disable godoc
+// @Summary Disable TOTP
+// @Description Disable TOTP for the current user, who proves it is them with a current code
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param request body entities.TOTPCodeRequest true "Code of the authenticator app"
+// @Success 200 {object} map[string]string
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 409 {object} map[string]string
+// @Failure 500 {object} map[string]string
+// @Router /auth/totp/disable [post]
+func (h *TOTPHandler) Disable(c *gin.Context) {
+	userID := c.MustGet("user_id").(uuid.UUID)
+
+	var req entities.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Code is required"})
+		return
+	}
+
+	valid, err := h.totpService.VerifyTOTP(c.Request.Context(), userID, req.Code)
+	if errors.Is(err, auth.ErrTOTPNotEnabled) {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is not enabled"})
+		return
+	}
+	if err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to verify TOTP code", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+	if !valid {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid TOTP code"})
+		return
+	}
+
+	if err := h.totpService.DisableTOTP(c.Request.Context(), userID.String()); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to disable TOTP", "user_id", userID, "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
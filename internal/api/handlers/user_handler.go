@@ -1,20 +1,43 @@
 package handlers
 
 import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 
 	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
 	"github.com/VeRJiL/go-template/internal/domain/services"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/auth/oauth"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
 	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/middleware"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
+)
+
+const (
+	// oauthStateCookie holds the state a social login is started with until
+	// the provider redirects back with it
+	oauthStateCookie = "oauth_state"
+	oauthStateTTL    = 10 * time.Minute
 )
 
 type UserHandler struct {
-	userService *services.UserService
-	logger      *logger.Logger
+	userService    *services.UserService
+	sessionStore   session.Store
+	sessionTTL     time.Duration
+	sessionCookie  string
+	sessionService *auth.SessionService
+	eventBus       *eventbus.Bus
+	logger         *logger.Logger
 }
 
 func NewUserHandler(userService *services.UserService, logger *logger.Logger) *UserHandler {
@@ -24,6 +47,28 @@ func NewUserHandler(userService *services.UserService, logger *logger.Logger) *U
 	}
 }
 
+// SetSessionStore enables session-based auth: login creates a server-side
+// session carried in the cookieName cookie and logout deletes it.
+func (h *UserHandler) SetSessionStore(store session.Store, ttl time.Duration, cookieName string) {
+	if cookieName == "" {
+		cookieName = session.CookieName
+	}
+	h.sessionStore = store
+	h.sessionTTL = ttl
+	h.sessionCookie = cookieName
+}
+
+// SetSessionService lets logout destroy sessions authenticated by the
+// session cookie accepted alongside JWT
+func (h *UserHandler) SetSessionService(svc *auth.SessionService) {
+	h.sessionService = svc
+}
+
+// SetEventBus publishes user domain events, such as registrations, to the bus
+func (h *UserHandler) SetEventBus(bus *eventbus.Bus) {
+	h.eventBus = bus
+}
+
 // Create godoc
 // @Summary Register a new user
 // @Description Register a new user with email and password
@@ -38,7 +83,7 @@ func NewUserHandler(userService *services.UserService, logger *logger.Logger) *U
 func (h *UserHandler) Create(c *gin.Context) {
 	var req entities.CreateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request body", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
@@ -49,17 +94,31 @@ func (h *UserHandler) Create(c *gin.Context) {
 		return
 	}
 
-	user, err := h.userService.Create(c.Request.Context(), &req)
+	// Join the request transaction when the route is wrapped in one
+	ctx := c.Request.Context()
+	if tx, ok := middleware.GetTx(c); ok {
+		ctx = repositories.WithTx(ctx, tx)
+	}
+
+	user, err := h.userService.Create(ctx, &req)
 	if err != nil {
 		if err == services.ErrUserExists {
 			c.JSON(http.StatusConflict, gin.H{"error": "User already exists"})
 			return
 		}
-		h.logger.Error("Failed to create user", "error", err)
+		if errors.Is(err, services.ErrInvalidEmail) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to create user", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
 		return
 	}
 
+	if h.eventBus != nil {
+		h.eventBus.Publish(eventbus.UserCreated, user)
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"message": "User created successfully",
 		"user":    user,
@@ -112,6 +171,7 @@ func (h *UserHandler) GetByID(c *gin.Context) {
 // @Failure 400 {object} map[string]string
 // @Failure 403 {object} map[string]string
 // @Failure 404 {object} map[string]string
+// @Failure 409 {object} map[string]interface{}
 // @Router /users/{id} [put]
 func (h *UserHandler) Update(c *gin.Context) {
 	idParam := c.Param("id")
@@ -123,7 +183,7 @@ func (h *UserHandler) Update(c *gin.Context) {
 
 	var req entities.UpdateUserRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request body", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
@@ -143,7 +203,15 @@ func (h *UserHandler) Update(c *gin.Context) {
 			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
 			return
 		}
-		h.logger.Error("Failed to update user", "error", err)
+		var conflict *repositories.ConcurrentModificationError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error":           "User was modified by another request",
+				"current_version": conflict.CurrentVersion,
+			})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Failed to update user", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update user"})
 		return
 	}
@@ -205,10 +273,18 @@ func (h *UserHandler) Delete(c *gin.Context) {
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
 // @Param limit query int false "Number of items per page" default(10)
+// @Param after query string false "Cursor: list the users after this user ID instead of by page"
+// @Param before query string false "Cursor: list the users before this user ID instead of by page"
 // @Success 200 {object} map[string]interface{}
+// @Failure 400 {object} map[string]string
 // @Failure 500 {object} map[string]string
 // @Router /users/ [get]
 func (h *UserHandler) List(c *gin.Context) {
+	if c.Query("after") != "" || c.Query("before") != "" {
+		h.listWithCursor(c)
+		return
+	}
+
 	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
 	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
 
@@ -238,6 +314,49 @@ func (h *UserHandler) List(c *gin.Context) {
 	})
 }
 
+// listWithCursor lists users from the after or before cursor, which stays
+// fast at any depth unlike page offsets
+func (h *UserHandler) listWithCursor(c *gin.Context) {
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	opts := repositories.CursorPagination{Limit: limit}
+	for param, cursor := range map[string]**uuid.UUID{"after": &opts.After, "before": &opts.Before} {
+		value := c.Query(param)
+		if value == "" {
+			continue
+		}
+		id, err := uuid.Parse(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid " + param + " cursor"})
+			return
+		}
+		*cursor = &id
+	}
+	if opts.After != nil && opts.Before != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Use either the after or the before cursor"})
+		return
+	}
+
+	page, err := h.userService.ListWithCursor(c.Request.Context(), opts)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to list users"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users": page.Items,
+		"pagination": gin.H{
+			"limit":       limit,
+			"next_cursor": page.NextCursor,
+			"prev_cursor": page.PrevCursor,
+			"has_more":    page.HasMore,
+		},
+	})
+}
+
 // GET /api/v1/users/search
 func (h *UserHandler) Search(c *gin.Context) {
 	query := c.Query("q")
@@ -290,7 +409,7 @@ func (h *UserHandler) Search(c *gin.Context) {
 func (h *UserHandler) Login(c *gin.Context) {
 	var req entities.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Error("Invalid request body", "error", err)
+		h.logger.WithContext(c.Request.Context()).Error("Invalid request body", "error", err)
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
 		return
 	}
@@ -307,34 +426,246 @@ func (h *UserHandler) Login(c *gin.Context) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
 			return
 		}
-		h.logger.Error("Login failed", "error", err)
+		if err == services.ErrTOTPRequired {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "TOTP code required", "totp_required": true})
+			return
+		}
+		if err == auth.ErrInvalidTOTPCode {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid TOTP code", "totp_required": true})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Login failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+		return
+	}
+
+	// Lets middleware on the login route see who logged in
+	c.Set("user_id", response.User.ID)
+
+	h.respondLogin(c, response)
+}
+
+// OAuthRedirect godoc
+// @Summary Log in with an OAuth provider
+// @Description Redirect to the consent page of the provider, which redirects back to the callback
+// @Tags auth
+// @Param provider path string true "OAuth provider" Enums(google, github)
+// @Success 307
+// @Failure 404 {object} map[string]string
+// @Router /auth/{provider} [get]
+func (h *UserHandler) OAuthRedirect(c *gin.Context) {
+	state := make([]byte, 32)
+	if _, err := rand.Read(state); err != nil {
+		h.logger.WithContext(c.Request.Context()).Error("Failed to generate OAuth state", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
 		return
 	}
+	encodedState := base64.RawURLEncoding.EncodeToString(state)
+
+	authURL, err := h.userService.OAuthAuthURL(c.Param("provider"), encodedState)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown OAuth provider"})
+		return
+	}
+
+	c.SetCookie(oauthStateCookie, encodedState, int(oauthStateTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+	c.Redirect(http.StatusTemporaryRedirect, authURL)
+}
+
+// OAuthCallback godoc
+// @Summary OAuth login callback
+// @Description Log in the user of the provider account, creating it on first login
+// @Tags auth
+// @Produce json
+// @Param provider path string true "OAuth provider" Enums(google, github)
+// @Param code query string true "Authorization code"
+// @Param state query string true "State the login was started with"
+// @Success 200 {object} entities.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /auth/{provider}/callback [get]
+func (h *UserHandler) OAuthCallback(c *gin.Context) {
+	// The state is single use
+	state, _ := c.Cookie(oauthStateCookie)
+	c.SetCookie(oauthStateCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	if state == "" || subtle.ConstantTimeCompare([]byte(state), []byte(c.Query("state"))) != 1 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid OAuth state"})
+		return
+	}
+
+	if reason := c.Query("error"); reason != "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth login denied", "reason": reason})
+		return
+	}
+
+	code := c.Query("code")
+	if code == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Authorization code is required"})
+		return
+	}
+
+	response, err := h.userService.LoginWithOAuth(c.Request.Context(), c.Param("provider"), code)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrUnknownOAuthProvider):
+			c.JSON(http.StatusNotFound, gin.H{"error": "Unknown OAuth provider"})
+		case errors.Is(err, oauth.ErrEmailNotVerified):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "A verified email is required"})
+		case errors.Is(err, services.ErrOAuthTOTPEnabled):
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Log in with your password and TOTP code", "totp_required": true})
+		default:
+			h.logger.WithContext(c.Request.Context()).Warn("OAuth login failed", "provider", c.Param("provider"), "error", err)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "OAuth login failed"})
+		}
+		return
+	}
+
+	c.Set("user_id", response.User.ID)
+
+	h.respondLogin(c, response)
+}
+
+// respondLogin answers a successful login with a session when session-based
+// auth is enabled, or with the JWT tokens
+func (h *UserHandler) respondLogin(c *gin.Context, response *entities.LoginResponse) {
+	if h.sessionStore != nil {
+		sess, err := session.NewSession(response.User.ID.String(), map[string]interface{}{
+			"email": response.User.Email,
+			"role":  response.User.Role,
+		}, h.sessionTTL)
+		if err == nil {
+			err = h.sessionStore.Create(c.Request.Context(), sess)
+		}
+		if err != nil {
+			h.logger.WithContext(c.Request.Context()).Error("Failed to create session", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Login failed"})
+			return
+		}
+
+		c.SetCookie(h.sessionCookie, sess.ID, int(h.sessionTTL.Seconds()), "/", "", c.Request.TLS != nil, true)
+		c.JSON(http.StatusOK, gin.H{
+			"message":    "Login successful",
+			"session_id": sess.ID,
+			"user":       response.User,
+			"expires_at": sess.ExpiresAt,
+		})
+		return
+	}
 
 	c.JSON(http.StatusOK, gin.H{
-		"message":    "Login successful",
-		"token":      response.Token,
-		"user":       response.User,
-		"expires_at": response.ExpiresAt,
+		"message":       "Login successful",
+		"token":         response.Token,
+		"refresh_token": response.RefreshToken,
+		"user":          response.User,
+		"expires_at":    response.ExpiresAt,
 	})
 }
 
+// destroySession deletes the session the request was authenticated with
+// from the store that issued it and clears its cookie
+func (h *UserHandler) destroySession(c *gin.Context, sessionID string) error {
+	ctx := c.Request.Context()
+	switch {
+	case h.sessionStore != nil:
+		if err := h.sessionStore.Delete(ctx, sessionID); err != nil {
+			return err
+		}
+		c.SetCookie(h.sessionCookie, "", -1, "/", "", c.Request.TLS != nil, true)
+	case h.sessionService != nil:
+		if err := h.sessionService.Destroy(ctx, sessionID); err != nil {
+			return err
+		}
+		c.SetCookie(h.sessionService.CookieName(), "", -1, "/", "", c.Request.TLS != nil, true)
+	}
+	return nil
+}
+
+// Refresh godoc
+// @Summary Refresh access token
+// @Description Exchange a refresh token for a new access token and refresh token. Each refresh token can be used once.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param request body entities.RefreshTokenRequest true "Refresh token"
+// @Success 200 {object} entities.RefreshTokenResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Failure 503 {object} map[string]string
+// @Router /auth/refresh [post]
+func (h *UserHandler) Refresh(c *gin.Context) {
+	var req entities.RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Refresh token is required"})
+		return
+	}
+
+	response, err := h.userService.RefreshToken(c.Request.Context(), req.RefreshToken)
+	if err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) || errors.Is(err, auth.ErrTokenRevoked) ||
+			errors.Is(err, services.ErrUserNotFound) || errors.Is(err, services.ErrUserDisabled) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		if errors.Is(err, auth.ErrRevocationUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token refresh is unavailable"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Token refresh failed", "error", err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Token refresh failed"})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // Logout godoc
 // @Summary User logout
-// @Description Logout user and invalidate token
+// @Description Logout user, destroying the session or revoking the access token, and revoke the refresh token if given
 // @Tags auth
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param request body entities.LogoutRequest false "Refresh token to revoke"
 // @Success 200 {object} map[string]string
+// @Failure 401 {object} map[string]string
 // @Failure 500 {object} map[string]string
+// @Failure 503 {object} map[string]string
 // @Router /auth/logout [post]
 func (h *UserHandler) Logout(c *gin.Context) {
-	token := c.MustGet("token").(string)
+	// Requests authenticated with an API key carry no token to revoke
+	token := c.GetString("token")
+
+	// The body is optional; without one only the access token is revoked
+	var req entities.LogoutRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request body"})
+			return
+		}
+	}
+
+	if sessionID := c.GetString("session_id"); sessionID != "" {
+		if err := h.destroySession(c, sessionID); err != nil {
+			h.logger.WithContext(c.Request.Context()).Error("Failed to delete session", "error", err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Logout failed"})
+			return
+		}
+
+		// The session ID is not a JWT, there is no access token to revoke
+		token = ""
+	}
 
-	if err := h.userService.Logout(c.Request.Context(), token); err != nil {
-		h.logger.Error("Logout failed", "error", err)
+	if err := h.userService.Logout(c.Request.Context(), token, req.RefreshToken); err != nil {
+		if errors.Is(err, auth.ErrInvalidRefreshToken) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid refresh token"})
+			return
+		}
+		if errors.Is(err, auth.ErrRevocationUnavailable) {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Logout is unavailable"})
+			return
+		}
+		h.logger.WithContext(c.Request.Context()).Error("Logout failed", "error", err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Logout failed"})
 		return
 	}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+type corsPolicy struct {
+	allowAll         bool
+	origins          map[string]bool
+	subdomains       []originPattern
+	allowedMethods   string
+	allowedHeaders   string
+	exposedHeaders   string
+	allowCredentials bool
+	maxAge           string
+}
+
+// originPattern matches the subdomains of a wildcard origin such as
+// https://*.example.com
+type originPattern struct {
+	prefix string // scheme up to and including "://"
+	suffix string // "." followed by the parent domain and optional port
+}
+
+func (p originPattern) match(origin string) bool {
+	return len(origin) > len(p.prefix)+len(p.suffix) &&
+		strings.HasPrefix(origin, p.prefix) &&
+		strings.HasSuffix(origin, p.suffix)
+}
+
+// CORS answers cross-origin requests from the allowed origins. Origins are
+// matched exactly, case-insensitively, or against wildcard subdomain
+// patterns like https://*.example.com, and "*" allows any origin. The
+// matching origin is reflected rather than answered with "*" whenever
+// credentials are allowed or the allow-list is restricted, so caches vary
+// the response on Origin. Preflight requests are answered with 204 and may
+// be cached by the browser for MaxAge.
+func CORS(cfg config.CORSConfig) gin.HandlerFunc {
+	p := &corsPolicy{
+		origins:          make(map[string]bool),
+		allowedMethods:   strings.Join(cfg.AllowedMethods, ", "),
+		allowedHeaders:   strings.Join(cfg.AllowedHeaders, ", "),
+		exposedHeaders:   strings.Join(cfg.ExposedHeaders, ", "),
+		allowCredentials: cfg.AllowCredentials,
+	}
+	if cfg.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(cfg.MaxAge.Seconds()))
+	}
+
+	for _, origin := range cfg.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "":
+		case origin == "*":
+			p.allowAll = true
+		case strings.Contains(origin, "://*."):
+			prefix, suffix, _ := strings.Cut(origin, "*")
+			p.subdomains = append(p.subdomains, originPattern{prefix: prefix, suffix: suffix})
+		default:
+			p.origins[origin] = true
+		}
+	}
+
+	return p.handle
+}
+
+func (p *corsPolicy) handle(c *gin.Context) {
+	origin := c.GetHeader("Origin")
+	if origin == "" {
+		c.Next()
+		return
+	}
+
+	preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+	c.Writer.Header().Add("Vary", "Origin")
+	if preflight {
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Method")
+		c.Writer.Header().Add("Vary", "Access-Control-Request-Headers")
+	}
+
+	if !p.allowed(origin) {
+		// Without the allow headers the browser blocks the response
+		if preflight {
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Next()
+		return
+	}
+
+	if p.allowAll && !p.allowCredentials {
+		c.Header("Access-Control-Allow-Origin", "*")
+	} else {
+		c.Header("Access-Control-Allow-Origin", origin)
+	}
+	if p.allowCredentials {
+		c.Header("Access-Control-Allow-Credentials", "true")
+	}
+
+	if !preflight {
+		if p.exposedHeaders != "" {
+			c.Header("Access-Control-Expose-Headers", p.exposedHeaders)
+		}
+		c.Next()
+		return
+	}
+
+	c.Header("Access-Control-Allow-Methods", p.allowedMethods)
+	if p.allowedHeaders != "" {
+		c.Header("Access-Control-Allow-Headers", p.allowedHeaders)
+	} else if requested := c.GetHeader("Access-Control-Request-Headers"); requested != "" {
+		c.Header("Access-Control-Allow-Headers", requested)
+	}
+	if p.maxAge != "" {
+		c.Header("Access-Control-Max-Age", p.maxAge)
+	}
+
+	c.AbortWithStatus(http.StatusNoContent)
+}
+
+func (p *corsPolicy) allowed(origin string) bool {
+	if p.allowAll {
+		return true
+	}
+
+	origin = strings.ToLower(origin)
+	if p.origins[origin] {
+		return true
+	}
+
+	for _, pattern := range p.subdomains {
+		if pattern.match(origin) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,107 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+func newCORSRouter(cfg config.CORSConfig) *gin.Engine {
+	router := gin.New()
+	router.Use(CORS(cfg))
+	router.GET("/users", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func corsRequest(router *gin.Engine, method, origin string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, "/users", nil)
+	req.Header.Set("Origin", origin)
+	if method == http.MethodOptions {
+		req.Header.Set("Access-Control-Request-Method", http.MethodGet)
+	}
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestCORS(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	cfg := config.CORSConfig{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.example.org"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		ExposedHeaders:   []string{"X-Request-ID"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+
+	t.Run("should reflect an allowed origin with credentials", func(t *testing.T) {
+		w := corsRequest(newCORSRouter(cfg), http.MethodGet, "https://app.example.com")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
+		assert.Equal(t, "X-Request-ID", w.Header().Get("Access-Control-Expose-Headers"))
+		assert.Equal(t, "Origin", w.Header().Get("Vary"))
+	})
+
+	t.Run("should match wildcard subdomains but not the parent domain", func(t *testing.T) {
+		router := newCORSRouter(cfg)
+
+		w := corsRequest(router, http.MethodGet, "https://api.eu.example.org")
+		assert.Equal(t, "https://api.eu.example.org", w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = corsRequest(router, http.MethodGet, "https://example.org")
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = corsRequest(router, http.MethodGet, "http://api.example.org")
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+
+		w = corsRequest(router, http.MethodGet, "https://api.example.org.evil.com")
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+	})
+
+	t.Run("should not allow unknown origins", func(t *testing.T) {
+		w := corsRequest(newCORSRouter(cfg), http.MethodGet, "https://evil.com")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("should answer preflight requests with the cacheable policy", func(t *testing.T) {
+		w := corsRequest(newCORSRouter(cfg), http.MethodOptions, "https://app.example.com")
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+		assert.Equal(t, "https://app.example.com", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Equal(t, "GET, POST", w.Header().Get("Access-Control-Allow-Methods"))
+		assert.Equal(t, "Content-Type, Authorization", w.Header().Get("Access-Control-Allow-Headers"))
+		assert.Equal(t, "600", w.Header().Get("Access-Control-Max-Age"))
+	})
+
+	t.Run("should answer any origin with a wildcard without credentials", func(t *testing.T) {
+		router := newCORSRouter(config.CORSConfig{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+
+		w := corsRequest(router, http.MethodGet, "https://anywhere.dev")
+
+		assert.Equal(t, "*", w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+
+	t.Run("should pass requests without an origin through untouched", func(t *testing.T) {
+		w := corsRequest(newCORSRouter(cfg), http.MethodGet, "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, w.Header().Get("Access-Control-Allow-Origin"))
+		assert.Empty(t, w.Header().Get("Vary"))
+	})
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// maxLoggedBody bounds the request and response bodies logged with LOG_BODY
+const maxLoggedBody = 1024
+
+// redactedHeaders are logged without their value, since they carry credentials
+var redactedHeaders = map[string]bool{
+	"Authorization":       true,
+	"Proxy-Authorization": true,
+	"Cookie":              true,
+}
+
+// StructuredLogger logs one entry per request through log, with the request
+// ID set by RequestID. Server errors are logged at error level and client
+// errors at warn level. With cfg.LogHeaders the request headers are added,
+// credentials redacted, and with cfg.LogBody the first KB of the request and
+// response bodies.
+func StructuredLogger(log *logger.Logger, cfg *config.LoggingConfig) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		path := c.Request.URL.Path
+
+		var requestBody []byte
+		if cfg.LogBody && c.Request.Body != nil {
+			requestBody, _ = io.ReadAll(io.LimitReader(c.Request.Body, maxLoggedBody))
+			c.Request.Body = readCloser{io.MultiReader(bytes.NewReader(requestBody), c.Request.Body), c.Request.Body}
+		}
+
+		var body *bodyCapture
+		if cfg.LogBody {
+			body = &bodyCapture{ResponseWriter: c.Writer}
+			c.Writer = body
+		}
+
+		c.Next()
+
+		fields := map[string]interface{}{
+			"method":         c.Request.Method,
+			"path":           path,
+			"status":         c.Writer.Status(),
+			"latency_ms":     float64(time.Since(start).Microseconds()) / 1000,
+			"client_ip":      c.ClientIP(),
+			"user_agent":     c.Request.UserAgent(),
+			"request_bytes":  max(c.Request.ContentLength, 0),
+			"response_bytes": max(c.Writer.Size(), 0),
+		}
+		if cfg.LogHeaders {
+			fields["request_headers"] = loggedHeaders(c.Request.Header)
+		}
+		if cfg.LogBody {
+			fields["request_body"] = string(requestBody)
+			fields["response_body"] = body.buf.String()
+		}
+		if len(c.Errors) > 0 {
+			fields["errors"] = c.Errors.String()
+		}
+
+		entry := log.WithContext(c.Request.Context()).WithFields(fields)
+		switch status := c.Writer.Status(); {
+		case status >= http.StatusInternalServerError:
+			entry.Error("HTTP request")
+		case status >= http.StatusBadRequest:
+			entry.Warn("HTTP request")
+		default:
+			entry.Info("HTTP request")
+		}
+	}
+}
+
+// loggedHeaders flattens the headers, redacting credentials
+func loggedHeaders(header http.Header) map[string]string {
+	headers := make(map[string]string, len(header))
+	for name, values := range header {
+		if redactedHeaders[http.CanonicalHeaderKey(name)] {
+			headers[name] = "[REDACTED]"
+			continue
+		}
+		headers[name] = strings.Join(values, ", ")
+	}
+	return headers
+}
+
+// readCloser reads the buffered start of a body followed by the rest, and
+// closes the original body
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// bodyCapture keeps the first maxLoggedBody bytes of a response
+type bodyCapture struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *bodyCapture) Write(data []byte) (int, error) {
+	if room := maxLoggedBody - w.buf.Len(); room > 0 {
+		w.buf.Write(data[:min(room, len(data))])
+	}
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyCapture) WriteString(s string) (int, error) {
+	if room := maxLoggedBody - w.buf.Len(); room > 0 {
+		w.buf.WriteString(s[:min(room, len(s))])
+	}
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,101 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+func TestStructuredLogger(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// request serves one request and returns the log entry it produced
+	request := func(t *testing.T, cfg config.LoggingConfig, req *http.Request, status int) (map[string]interface{}, string) {
+		t.Helper()
+
+		var out bytes.Buffer
+		log := logger.New("debug", "json")
+		log.SetOutput(&out)
+
+		var handled string
+		router := gin.New()
+		router.Use(RequestID())
+		router.Use(StructuredLogger(log, &cfg))
+		router.Any("/users", func(c *gin.Context) {
+			body, _ := io.ReadAll(c.Request.Body)
+			handled = string(body)
+			c.String(status, strings.Repeat("r", 2000))
+		})
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, w.Header().Get(RequestIDHeader), entry["request_id"])
+		return entry, handled
+	}
+
+	t.Run("should log the request fields", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(`{"name":"alice"}`))
+		req.Header.Set("User-Agent", "test-agent")
+		req.RemoteAddr = "203.0.113.7:1234"
+
+		entry, _ := request(t, config.LoggingConfig{}, req, http.StatusCreated)
+
+		assert.Equal(t, "info", entry["level"])
+		assert.Equal(t, "POST", entry["method"])
+		assert.Equal(t, "/users", entry["path"])
+		assert.Equal(t, 201.0, entry["status"])
+		assert.Contains(t, entry, "latency_ms")
+		assert.Equal(t, "203.0.113.7", entry["client_ip"])
+		assert.Equal(t, "test-agent", entry["user_agent"])
+		assert.Equal(t, 16.0, entry["request_bytes"])
+		assert.Equal(t, 2000.0, entry["response_bytes"])
+		assert.NotContains(t, entry, "request_headers")
+		assert.NotContains(t, entry, "request_body")
+	})
+
+	t.Run("should log the level by status", func(t *testing.T) {
+		entry, _ := request(t, config.LoggingConfig{}, httptest.NewRequest(http.MethodGet, "/users", nil), http.StatusNotFound)
+		assert.Equal(t, "warning", entry["level"])
+
+		entry, _ = request(t, config.LoggingConfig{}, httptest.NewRequest(http.MethodGet, "/users", nil), http.StatusBadGateway)
+		assert.Equal(t, "error", entry["level"])
+	})
+
+	t.Run("should log headers with credentials redacted", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.Header.Set("Authorization", "Bearer secret-token")
+		req.Header.Add("Accept", "text/html")
+		req.Header.Add("Accept", "application/json")
+
+		entry, _ := request(t, config.LoggingConfig{LogHeaders: true}, req, http.StatusOK)
+
+		headers := entry["request_headers"].(map[string]interface{})
+		assert.Equal(t, "[REDACTED]", headers["Authorization"])
+		assert.Equal(t, "text/html, application/json", headers["Accept"])
+	})
+
+	t.Run("should log bodies truncated to 1 KB", func(t *testing.T) {
+		payload := strings.Repeat("q", 1500)
+		req := httptest.NewRequest(http.MethodPost, "/users", strings.NewReader(payload))
+
+		entry, handled := request(t, config.LoggingConfig{LogBody: true}, req, http.StatusOK)
+
+		assert.Equal(t, payload, handled, "the handler still reads the whole body")
+		assert.Equal(t, strings.Repeat("q", 1024), entry["request_body"])
+		assert.Equal(t, strings.Repeat("r", 1024), entry["response_body"])
+		assert.Equal(t, 2000.0, entry["response_bytes"])
+	})
+}
@@ -1,14 +1,17 @@
 package middleware
 
 import (
+	"bytes"
 	"net/http"
 	"strings"
 
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 
-	"github.com/VeRJiL/go-template/internal/config"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
-	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/features"
+	"github.com/VeRJiL/go-template/internal/pkg/listener"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
 )
 
 // AuthMiddleware validates JWT tokens
@@ -46,6 +49,178 @@ func AuthMiddleware(jwtService *auth.JWTService) gin.HandlerFunc {
 	}
 }
 
+// APIKeyAuth authenticates service to service calls with the API key in the
+// X-API-Key header, setting the same context values as AuthMiddleware
+func APIKeyAuth(svc *auth.APIKeyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rawKey := c.GetHeader("X-API-Key")
+		if rawKey == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "API key required"})
+			c.Abort()
+			return
+		}
+
+		key, err := svc.ValidateAPIKey(c.Request.Context(), rawKey)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid API key"})
+			c.Abort()
+			return
+		}
+
+		// Store user info in context
+		c.Set("user_id", key.UserID)
+		c.Set("user_email", key.Email)
+		c.Set("user_role", key.Role)
+		c.Set("api_key_id", key.ID)
+		c.Set("api_key_scopes", key.Scopes)
+
+		c.Next()
+	}
+}
+
+// SessionAuthMiddleware authenticates requests against the session store,
+// setting the same context values as AuthMiddleware. The session ID is read
+// from the cookieName cookie or the X-Session-ID header. Pass the Store and
+// CookieName of an auth.SessionService to authenticate its sessions.
+func SessionAuthMiddleware(store session.Store, cookieName string) gin.HandlerFunc {
+	if cookieName == "" {
+		cookieName = session.CookieName
+	}
+
+	return func(c *gin.Context) {
+		sessionID, err := c.Cookie(cookieName)
+		if err != nil || sessionID == "" {
+			sessionID = c.GetHeader("X-Session-ID")
+		}
+
+		if sessionID == "" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Session required"})
+			c.Abort()
+			return
+		}
+
+		sess, err := store.Get(c.Request.Context(), sessionID)
+		if err != nil || sess.IsExpired() {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			c.Abort()
+			return
+		}
+
+		userID, err := uuid.Parse(sess.UserID)
+		if err != nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			c.Abort()
+			return
+		}
+
+		email, _ := sess.Data["email"].(string)
+		role, _ := sess.Data["role"].(string)
+
+		// Store user info in context
+		c.Set("user_id", userID)
+		c.Set("user_email", email)
+		c.Set("user_role", role)
+		c.Set("token", sessionID)
+		c.Set("session_id", sessionID)
+
+		c.Next()
+	}
+}
+
+// AnyOf authenticates requests with the first of the auth middlewares that
+// accepts them, e.g. AnyOf(AuthMiddleware(jwt), SessionAuthMiddleware(store, "")) takes
+// a bearer token or a session cookie. Each middleware runs on a copy of the
+// request context whose response is held back, so a rejection does not
+// reach the client unless every middleware rejects the request, in which
+// case the first rejection is sent.
+func AnyOf(middlewares ...gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var rejection *heldResponse
+		for _, middleware := range middlewares {
+			held := &heldResponse{ResponseWriter: c.Writer, header: make(http.Header)}
+			probe := c.Copy()
+			probe.Writer = held
+
+			// The copy has no handlers, so a middleware calling Next returns
+			// straight away and one that responded has rejected the request
+			middleware(probe)
+
+			if held.Written() {
+				if rejection == nil {
+					rejection = held
+				}
+				continue
+			}
+
+			for key, value := range probe.Keys {
+				c.Set(key, value)
+			}
+			for name, values := range held.header {
+				c.Writer.Header()[name] = values
+			}
+			c.Request = probe.Request
+			c.Next()
+			return
+		}
+
+		if rejection == nil {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Authentication required"})
+			c.Abort()
+			return
+		}
+		for name, values := range rejection.header {
+			c.Writer.Header()[name] = values
+		}
+		c.Data(rejection.status, rejection.header.Get("Content-Type"), rejection.body.Bytes())
+		c.Abort()
+	}
+}
+
+// heldResponse records the response of an AnyOf candidate instead of
+// sending it
+type heldResponse struct {
+	gin.ResponseWriter
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (w *heldResponse) Header() http.Header {
+	return w.header
+}
+
+func (w *heldResponse) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+}
+
+func (w *heldResponse) WriteHeaderNow() {
+	w.WriteHeader(http.StatusOK)
+}
+
+func (w *heldResponse) Write(data []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.Write(data)
+}
+
+func (w *heldResponse) WriteString(s string) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	return w.body.WriteString(s)
+}
+
+func (w *heldResponse) Status() int {
+	return w.status
+}
+
+func (w *heldResponse) Size() int {
+	return w.body.Len()
+}
+
+func (w *heldResponse) Written() bool {
+	return w.status != 0
+}
+
 // RequireRole middleware for role-based access control
 func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -69,21 +244,27 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	}
 }
 
-// Logger middleware with structured logging
-func Logger(log *logger.Logger) gin.HandlerFunc {
-	return gin.LoggerWithWriter(gin.DefaultWriter)
+// RequireListener hides routes on every listener but the named one
+func RequireListener(name string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if listener.NameFromContext(c.Request.Context()) != name {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Not found"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
 }
 
-func CORS(cfg *config.ServerConfig) gin.HandlerFunc {
+// MaintenanceMode answers 503 while the maintenance_mode feature flag is
+// enabled, checked on every request so it follows flag reloads. The health
+// check stays up so load balancers keep the instance.
+func MaintenanceMode(flags *features.FeatureFlagManager) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		origin := c.Request.Header.Get("Origin")
-		c.Header("Access-Control-Allow-Origin", origin)
-		c.Header("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Header("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		c.Header("Access-Control-Allow-Credentials", "true")
-
-		if c.Request.Method == "OPTIONS" {
-			c.AbortWithStatus(http.StatusNoContent)
+		if flags.IsEnabled("maintenance_mode") && c.Request.URL.Path != "/health" {
+			c.Header("Retry-After", "300")
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "Service is under maintenance"})
 			return
 		}
 
@@ -0,0 +1,314 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+	"github.com/VeRJiL/go-template/internal/pkg/features"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
+)
+
+func TestMaintenanceMode(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(flags *features.FeatureFlagManager) *gin.Engine {
+		router := gin.New()
+		router.Use(MaintenanceMode(flags))
+		router.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+		router.GET("/users", func(c *gin.Context) { c.Status(http.StatusOK) })
+		return router
+	}
+
+	request := func(router *gin.Engine, path string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		return w
+	}
+
+	t.Run("should serve requests when maintenance mode is off", func(t *testing.T) {
+		router := newRouter(features.NewFeatureFlagManager(config.FeatureConfig{}, nil))
+
+		assert.Equal(t, http.StatusOK, request(router, "/users").Code)
+	})
+
+	t.Run("should reject requests but the health check in maintenance mode", func(t *testing.T) {
+		router := newRouter(features.NewFeatureFlagManager(config.FeatureConfig{MaintenanceMode: true}, nil))
+
+		w := request(router, "/users")
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "300", w.Header().Get("Retry-After"))
+
+		assert.Equal(t, http.StatusOK, request(router, "/health").Code)
+	})
+
+	t.Run("should follow reloaded flags", func(t *testing.T) {
+		t.Setenv("FEATURE_MAINTENANCE_MODE", "true")
+		flags := features.NewFeatureFlagManager(config.FeatureConfig{}, nil)
+		router := newRouter(flags)
+		assert.Equal(t, http.StatusOK, request(router, "/users").Code)
+
+		assert.NoError(t, flags.Reload(context.Background()))
+
+		assert.Equal(t, http.StatusServiceUnavailable, request(router, "/users").Code)
+	})
+}
+
+// apiKeyStore keeps API keys in memory
+type apiKeyStore map[uuid.UUID]auth.APIKey
+
+func (s apiKeyStore) CreateAPIKey(ctx context.Context, key *auth.APIKey) error {
+	s[key.ID] = *key
+	return nil
+}
+
+func (s apiKeyStore) GetAPIKey(ctx context.Context, id uuid.UUID) (*auth.APIKey, error) {
+	key, ok := s[id]
+	if !ok {
+		return nil, auth.ErrAPIKeyNotFound
+	}
+	key.Role = "user"
+	return &key, nil
+}
+
+func (s apiKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	key := s[id]
+	key.RevokedAt = &revokedAt
+	s[id] = key
+	return nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := auth.NewAPIKeyService(apiKeyStore{})
+	userID := uuid.New()
+	created, err := service.CreateAPIKey(context.Background(), userID, "ci", []string{"deploy"}, nil)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.Use(APIKeyAuth(service))
+	router.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"user_id": c.MustGet("user_id"),
+			"scopes":  c.MustGet("api_key_scopes"),
+		})
+	})
+
+	request := func(key string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should authenticate a valid key", func(t *testing.T) {
+		w := request(created.Key)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), userID.String())
+		assert.Contains(t, w.Body.String(), "deploy")
+	})
+
+	t.Run("should require a key", func(t *testing.T) {
+		w := request("")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "API key required")
+	})
+
+	t.Run("should reject invalid and revoked keys", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("gtk_invalid").Code)
+
+		require.NoError(t, service.RevokeAPIKey(context.Background(), created.ID))
+		assert.Equal(t, http.StatusUnauthorized, request(created.Key).Code)
+	})
+}
+
+func TestSessionAuthMiddlewareWithSessionService(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	service := auth.NewSessionService(containertest.NewRedis(t), config.SessionConfig{MaxAge: time.Hour, CookieName: "sid"})
+	userID := uuid.New()
+
+	router := gin.New()
+	router.Use(SessionAuthMiddleware(service.Store(), service.CookieName()))
+	router.GET("/me", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{
+			"user_id": c.MustGet("user_id"),
+			"role":    c.MustGet("user_role"),
+		})
+	})
+
+	request := func(sessionID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		if sessionID != "" {
+			req.AddCookie(&http.Cookie{Name: "sid", Value: sessionID})
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should authenticate a session cookie", func(t *testing.T) {
+		sessionID, err := service.CreateWithClaims(context.Background(), userID, "test@example.com", "admin")
+		require.NoError(t, err)
+
+		w := request(sessionID)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), userID.String())
+		assert.Contains(t, w.Body.String(), "admin")
+	})
+
+	t.Run("should require a session", func(t *testing.T) {
+		w := request("")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Session required")
+	})
+
+	t.Run("should reject unknown and destroyed sessions", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("unknown").Code)
+
+		sessionID, err := service.Create(context.Background(), userID)
+		require.NoError(t, err)
+		require.NoError(t, service.Destroy(context.Background(), sessionID))
+		assert.Equal(t, http.StatusUnauthorized, request(sessionID).Code)
+	})
+}
+
+func TestAnyOf(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtService := auth.NewJWTService("test-secret-key", 3600)
+	keyService := auth.NewAPIKeyService(apiKeyStore{})
+	userID := uuid.New()
+	token, _, err := jwtService.GenerateToken(userID, "test@example.com", "user")
+	require.NoError(t, err)
+	created, err := keyService.CreateAPIKey(context.Background(), userID, "ci", nil, nil)
+	require.NoError(t, err)
+
+	calls := 0
+	router := gin.New()
+	router.Use(AnyOf(AuthMiddleware(jwtService), APIKeyAuth(keyService)))
+	router.GET("/me", func(c *gin.Context) {
+		calls++
+		_, viaKey := c.Get("api_key_id")
+		c.JSON(http.StatusOK, gin.H{"user_id": c.MustGet("user_id"), "via_key": viaKey})
+	})
+
+	request := func(headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should accept the first middleware", func(t *testing.T) {
+		w := request(map[string]string{"Authorization": "Bearer " + token})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), userID.String())
+		assert.Contains(t, w.Body.String(), `"via_key":false`)
+	})
+
+	t.Run("should fall back to the next middleware", func(t *testing.T) {
+		w := request(map[string]string{"X-API-Key": created.Key})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), `"via_key":true`)
+	})
+
+	t.Run("should send the first rejection when every middleware rejects", func(t *testing.T) {
+		calls = 0
+		w := request(map[string]string{"Authorization": "Bearer invalid", "X-API-Key": "gtk_invalid"})
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid token")
+		assert.Equal(t, 0, calls)
+	})
+}
+
+// sessionStore keeps sessions in memory
+type sessionStore map[string]*session.Session
+
+func (s sessionStore) Create(ctx context.Context, sess *session.Session) error {
+	s[sess.ID] = sess
+	return nil
+}
+
+func (s sessionStore) Get(ctx context.Context, id string) (*session.Session, error) {
+	sess, ok := s[id]
+	if !ok {
+		return nil, session.ErrSessionNotFound
+	}
+	return sess, nil
+}
+
+func (s sessionStore) Update(ctx context.Context, sess *session.Session) error {
+	s[sess.ID] = sess
+	return nil
+}
+
+func (s sessionStore) Delete(ctx context.Context, id string) error {
+	delete(s, id)
+	return nil
+}
+
+func TestSessionAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := sessionStore{}
+	sess, err := session.NewSession(uuid.New().String(), map[string]interface{}{"role": "user"}, time.Hour)
+	require.NoError(t, err)
+	require.NoError(t, store.Create(context.Background(), sess))
+
+	router := gin.New()
+	router.Use(SessionAuthMiddleware(store, "sid"))
+	router.GET("/me", func(c *gin.Context) { c.String(http.StatusOK, c.GetString("session_id")) })
+
+	request := func(cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.AddCookie(cookie)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should read the configured cookie", func(t *testing.T) {
+		w := request(&http.Cookie{Name: "sid", Value: sess.ID})
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, sess.ID, w.Body.String())
+	})
+
+	t.Run("should ignore the default cookie", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request(&http.Cookie{Name: session.CookieName, Value: sess.ID}).Code)
+	})
+
+	t.Run("should reject unknown and expired sessions", func(t *testing.T) {
+		expired, err := session.NewSession(uuid.New().String(), nil, -time.Minute)
+		require.NoError(t, err)
+		require.NoError(t, store.Create(context.Background(), expired))
+
+		assert.Equal(t, http.StatusUnauthorized, request(&http.Cookie{Name: "sid", Value: "unknown"}).Code)
+		assert.Equal(t, http.StatusUnauthorized, request(&http.Cookie{Name: "sid", Value: expired.ID}).Code)
+	})
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// RequestIDHeader carries the request ID on requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs accepted from upstream
+const maxRequestIDLength = 128
+
+// RequestID tags every request with an ID, stored in the gin context under
+// "request_id", in the request context for logger.WithContext, and in the
+// X-Request-ID response header. A new UUID is generated unless the request
+// comes directly from one of trustedCIDRs with an X-Request-ID header of its
+// own, as a load balancer or gateway in front of the service would send.
+// Invalid CIDRs are ignored; the config validates them on load.
+func RequestID(trustedCIDRs ...string) gin.HandlerFunc {
+	var trusted []*net.IPNet
+	for _, cidr := range trustedCIDRs {
+		if _, network, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil {
+			trusted = append(trusted, network)
+		}
+	}
+
+	return func(c *gin.Context) {
+		requestID := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestID) || !fromNetworks(c.RemoteIP(), trusted) {
+			requestID = uuid.NewString()
+		}
+
+		c.Set("request_id", requestID)
+		c.Request = c.Request.WithContext(logger.ContextWithRequestID(c.Request.Context(), requestID))
+		c.Header(RequestIDHeader, requestID)
+
+		c.Next()
+	}
+}
+
+// fromNetworks reports whether the peer address is in one of the networks
+func fromNetworks(remoteIP string, networks []*net.IPNet) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// validRequestID accepts short IDs of printable ASCII, which keeps upstream
+// values from breaking log lines or response headers
+func validRequestID(requestID string) bool {
+	if requestID == "" || len(requestID) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] <= ' ' || requestID[i] > '~' {
+			return false
+		}
+	}
+	return true
+}
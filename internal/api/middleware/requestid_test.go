@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+func TestRequestID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	type seen struct{ gin, log string }
+	request := func(remoteAddr, header string) (*httptest.ResponseRecorder, seen) {
+		var ids seen
+		router := gin.New()
+		router.Use(RequestID("10.0.0.0/8", "not-a-cidr"))
+		router.GET("/users", func(c *gin.Context) {
+			ids.gin = c.GetString("request_id")
+			ids.log = logger.RequestIDFromContext(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+
+		req := httptest.NewRequest(http.MethodGet, "/users", nil)
+		req.RemoteAddr = remoteAddr
+		if header != "" {
+			req.Header.Set(RequestIDHeader, header)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w, ids
+	}
+
+	t.Run("should generate an ID and share it with the logger and response", func(t *testing.T) {
+		w, ids := request("203.0.113.7:1234", "")
+
+		requestID := w.Header().Get(RequestIDHeader)
+		_, err := uuid.Parse(requestID)
+		assert.NoError(t, err)
+		assert.Equal(t, requestID, ids.gin)
+		assert.Equal(t, requestID, ids.log)
+	})
+
+	t.Run("should generate a new ID per request", func(t *testing.T) {
+		first, _ := request("203.0.113.7:1234", "")
+		second, _ := request("203.0.113.7:1234", "")
+
+		assert.NotEqual(t, first.Header().Get(RequestIDHeader), second.Header().Get(RequestIDHeader))
+	})
+
+	t.Run("should keep the ID sent by a trusted upstream", func(t *testing.T) {
+		w, ids := request("10.1.2.3:1234", "lb-abc-123")
+
+		assert.Equal(t, "lb-abc-123", w.Header().Get(RequestIDHeader))
+		assert.Equal(t, "lb-abc-123", ids.log)
+	})
+
+	t.Run("should replace IDs from untrusted clients or of invalid form", func(t *testing.T) {
+		tests := []struct {
+			name       string
+			remoteAddr string
+			header     string
+		}{
+			{"untrusted client", "203.0.113.7:1234", "client-chosen"},
+			{"control characters", "10.1.2.3:1234", "abc\tdef"},
+			{"too long", "10.1.2.3:1234", strings.Repeat("a", maxRequestIDLength+1)},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				w, _ := request(tt.remoteAddr, tt.header)
+
+				_, err := uuid.Parse(w.Header().Get(RequestIDHeader))
+				assert.NoError(t, err)
+			})
+		}
+	})
+}
@@ -0,0 +1,383 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+const (
+	// ResponseCacheKeyPrefix starts the Redis keys of cached responses,
+	// cache:<method>:<path>:<query_hash>
+	ResponseCacheKeyPrefix = "cache:"
+
+	// ResponseCacheHeader is HIT on responses served from the cache and MISS
+	// on the others the cache saw
+	ResponseCacheHeader = "X-Cache"
+
+	// DefaultResponseCacheTTL is how long responses without a max-age are
+	// cached when PerformanceConfig.CacheDuration is not set
+	DefaultResponseCacheTTL = 5 * time.Minute
+
+	// maxCachedResponse bounds the body of a cached response, larger ones
+	// are served without being cached
+	maxCachedResponse = 1 << 20
+
+	responseCacheTimeout = time.Second
+	responseCacheScan    = 100
+)
+
+// uncachedHeaders are the response headers not replayed from the cache:
+// hop-by-hop headers and those describing the request being answered
+// rather than the response, like its ID and rate limit
+var uncachedHeaders = headerSet(
+	"Connection",
+	"Keep-Alive",
+	"Transfer-Encoding",
+	"Date",
+	"Retry-After",
+	"X-RateLimit-Limit",
+	"X-RateLimit-Remaining",
+	"X-RateLimit-Reset",
+	RequestIDHeader,
+	ResponseCacheHeader,
+)
+
+// headerSet returns a set of header names, keyed canonically as in
+// http.Header
+func headerSet(names ...string) map[string]bool {
+	set := make(map[string]bool, len(names))
+	for _, name := range names {
+		set[http.CanonicalHeaderKey(name)] = true
+	}
+	return set
+}
+
+// cachedResponse is a response stored by ResponseCache. An entry with Vary
+// and no status only names the request headers its variants are keyed by.
+type cachedResponse struct {
+	Status int         `json:"status,omitempty"`
+	Header http.Header `json:"header,omitempty"`
+	Body   []byte      `json:"body,omitempty"`
+	Vary   []string    `json:"vary,omitempty"`
+}
+
+// ResponseCache caches GET responses in Redis under
+// cache:<method>:<path>:<query_hash> when cfg.ResponseCaching is enabled.
+// The status code, headers and body of 200 responses are stored for the
+// max-age or s-maxage of their Cache-Control header, cfg.CacheDuration when
+// they have none, and not at all with no-store, private or a Set-Cookie
+// header. Responses with a Vary header are cached per value of the headers
+// it names, e.g. Accept and Accept-Language.
+//
+// Requests carrying credentials are only answered from the cache with
+// responses marked public or with an s-maxage, as a shared cache should.
+// Requests with Cache-Control: no-cache skip the lookup and no-store skips
+// the cache altogether. Redis errors let requests through uncached.
+func ResponseCache(client *redis.Client, cfg *config.PerformanceConfig) gin.HandlerFunc {
+	ttl := cfg.CacheDuration
+	if ttl <= 0 {
+		ttl = DefaultResponseCacheTTL
+	}
+
+	return func(c *gin.Context) {
+		if !cfg.ResponseCaching || c.Request.Method != http.MethodGet {
+			c.Next()
+			return
+		}
+
+		requestDirectives := parseCacheControl(c.Request.Header.Get("Cache-Control"))
+		if _, noStore := requestDirectives["no-store"]; noStore {
+			c.Next()
+			return
+		}
+
+		key := responseCacheKey(c.Request)
+		credentials := hasCredentials(c.Request)
+
+		if _, noCache := requestDirectives["no-cache"]; !noCache {
+			if cached := lookupResponse(c.Request.Context(), client, key, c.Request); cached != nil && (!credentials || sharedWithCredentials(cached.Header)) {
+				for name, values := range cached.Header {
+					if !uncachedHeaders[name] {
+						c.Writer.Header()[name] = values
+					}
+				}
+				c.Header(ResponseCacheHeader, "HIT")
+				c.Data(cached.Status, cached.Header.Get("Content-Type"), cached.Body)
+				c.Abort()
+				return
+			}
+		}
+
+		c.Header(ResponseCacheHeader, "MISS")
+		capture := &responseCapture{ResponseWriter: c.Writer}
+		c.Writer = capture
+
+		c.Next()
+
+		c.Writer = capture.ResponseWriter
+		if capture.Status() != http.StatusOK || capture.truncated {
+			return
+		}
+
+		header := capture.Header()
+		entryTTL, cacheable := responseTTL(header, ttl)
+		if !cacheable || (credentials && !sharedWithCredentials(header)) {
+			return
+		}
+
+		// Use a fresh context so a cancelled request still fills the cache
+		ctx, cancel := context.WithTimeout(context.Background(), responseCacheTimeout)
+		defer cancel()
+		storeResponse(ctx, client, key, c.Request, &cachedResponse{
+			Status: capture.Status(),
+			Header: cachedHeader(header),
+			Body:   capture.buf.Bytes(),
+		}, entryTTL)
+	}
+}
+
+// NewResponseCacheFlushHandler deletes the cached responses whose key
+// follows cache: with the prefix query parameter, which may hold Redis
+// glob patterns, e.g. ?prefix=GET:/api/v1/users. Without one every cached
+// response is deleted.
+func NewResponseCacheFlushHandler(client *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		pattern := ResponseCacheKeyPrefix + c.Query("prefix") + "*"
+
+		deleted, err := flushResponses(c.Request.Context(), client, pattern)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to flush the response cache"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"pattern": pattern,
+			"deleted": deleted,
+		})
+	}
+}
+
+// flushResponses deletes the keys matching pattern in batches as it scans
+func flushResponses(ctx context.Context, client *redis.Client, pattern string) (int64, error) {
+	var deleted int64
+	keys := make([]string, 0, responseCacheScan)
+	flush := func() error {
+		if len(keys) == 0 {
+			return nil
+		}
+		n, err := client.Del(ctx, keys...).Result()
+		deleted += n
+		keys = keys[:0]
+		return err
+	}
+
+	iter := client.Scan(ctx, 0, pattern, responseCacheScan).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+		if len(keys) == responseCacheScan {
+			if err := flush(); err != nil {
+				return deleted, err
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return deleted, err
+	}
+	return deleted, flush()
+}
+
+// responseCacheKey keys a request by method, path and the SHA-256 of its
+// query, whose parameters are sorted so their order does not matter
+func responseCacheKey(r *http.Request) string {
+	query := sha256.Sum256([]byte(r.URL.Query().Encode()))
+	return ResponseCacheKeyPrefix + r.Method + ":" + r.URL.Path + ":" + hex.EncodeToString(query[:])
+}
+
+// variantKey keys the variant of a response for the values the request has
+// for the headers named by Vary
+func variantKey(key string, r *http.Request, vary []string) string {
+	h := sha256.New()
+	for _, name := range vary {
+		h.Write([]byte(name))
+		h.Write([]byte{0})
+		h.Write([]byte(strings.Join(r.Header.Values(name), ",")))
+		h.Write([]byte{0})
+	}
+	return key + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// lookupResponse returns the cached response for the request, following
+// the entry's Vary to its variant, or nil on a miss
+func lookupResponse(ctx context.Context, client *redis.Client, key string, r *http.Request) *cachedResponse {
+	ctx, cancel := context.WithTimeout(ctx, responseCacheTimeout)
+	defer cancel()
+
+	cached := getResponse(ctx, client, key)
+	if cached != nil && len(cached.Vary) > 0 {
+		cached = getResponse(ctx, client, variantKey(key, r, cached.Vary))
+	}
+	if cached == nil || cached.Status == 0 {
+		return nil
+	}
+	return cached
+}
+
+func getResponse(ctx context.Context, client *redis.Client, key string) *cachedResponse {
+	data, err := client.Get(ctx, key).Bytes()
+	if err != nil {
+		return nil
+	}
+	var cached cachedResponse
+	if err := json.Unmarshal(data, &cached); err != nil {
+		return nil
+	}
+	return &cached
+}
+
+// storeResponse caches the response, as a variant when it has a Vary header
+func storeResponse(ctx context.Context, client *redis.Client, key string, r *http.Request, response *cachedResponse, ttl time.Duration) {
+	vary := varyHeaders(response.Header)
+	if len(vary) > 0 {
+		index, err := json.Marshal(cachedResponse{Vary: vary})
+		if err != nil {
+			return
+		}
+		client.Set(ctx, key, index, ttl)
+		key = variantKey(key, r, vary)
+	}
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return
+	}
+	client.Set(ctx, key, data, ttl)
+}
+
+// responseTTL returns how long a response may be cached according to its
+// headers, ttl when they do not say
+func responseTTL(header http.Header, ttl time.Duration) (time.Duration, bool) {
+	if header.Get("Set-Cookie") != "" {
+		return 0, false
+	}
+	for _, name := range varyHeaders(header) {
+		if name == "*" {
+			return 0, false
+		}
+	}
+
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	for _, directive := range []string{"no-store", "no-cache", "private"} {
+		if _, ok := directives[directive]; ok {
+			return 0, false
+		}
+	}
+
+	for _, directive := range []string{"s-maxage", "max-age"} {
+		value, ok := directives[directive]
+		if !ok {
+			continue
+		}
+		seconds, err := strconv.Atoi(value)
+		if err != nil || seconds <= 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	return ttl, true
+}
+
+// sharedWithCredentials reports whether a response to a request with
+// credentials may be shared with other requests
+func sharedWithCredentials(header http.Header) bool {
+	directives := parseCacheControl(header.Get("Cache-Control"))
+	_, public := directives["public"]
+	_, sharedMaxAge := directives["s-maxage"]
+	return public || sharedMaxAge
+}
+
+// hasCredentials reports whether the request is authenticated with a
+// token, an API key or a session cookie
+func hasCredentials(r *http.Request) bool {
+	return r.Header.Get("Authorization") != "" || r.Header.Get("X-API-Key") != "" || r.Header.Get("Cookie") != ""
+}
+
+// parseCacheControl returns the lowercased directives of a Cache-Control
+// header with their unquoted values
+func parseCacheControl(value string) map[string]string {
+	directives := make(map[string]string)
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		if name == "" {
+			continue
+		}
+		directives[strings.ToLower(name)] = strings.Trim(arg, `"`)
+	}
+	return directives
+}
+
+// varyHeaders returns the canonical names of the request headers listed by
+// the response's Vary headers
+func varyHeaders(header http.Header) []string {
+	var names []string
+	for _, value := range header.Values("Vary") {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				names = append(names, http.CanonicalHeaderKey(name))
+			}
+		}
+	}
+	return names
+}
+
+// cachedHeader copies the response headers that are replayed from the cache
+func cachedHeader(header http.Header) http.Header {
+	cached := make(http.Header, len(header))
+	for name, values := range header {
+		if !uncachedHeaders[name] {
+			cached[name] = append([]string(nil), values...)
+		}
+	}
+	return cached
+}
+
+// responseCapture copies the body written to the client, giving up past
+// maxCachedResponse bytes
+type responseCapture struct {
+	gin.ResponseWriter
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (w *responseCapture) Write(data []byte) (int, error) {
+	w.capture(len(data), func() { w.buf.Write(data) })
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseCapture) WriteString(s string) (int, error) {
+	w.capture(len(s), func() { w.buf.WriteString(s) })
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *responseCapture) capture(n int, write func()) {
+	if w.truncated {
+		return
+	}
+	if w.buf.Len()+n > maxCachedResponse {
+		w.truncated = true
+		w.buf.Reset()
+		return
+	}
+	write()
+}
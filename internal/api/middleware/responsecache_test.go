@@ -0,0 +1,243 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func TestResponseTTL(t *testing.T) {
+	tests := []struct {
+		name      string
+		header    http.Header
+		ttl       time.Duration
+		cacheable bool
+	}{
+		{"should use the default without Cache-Control", http.Header{}, time.Minute, true},
+		{"should use max-age", http.Header{"Cache-Control": {"public, max-age=30"}}, 30 * time.Second, true},
+		{"should prefer s-maxage", http.Header{"Cache-Control": {"max-age=30, s-maxage=60"}}, time.Minute, true},
+		{"should skip no-store", http.Header{"Cache-Control": {"no-store"}}, 0, false},
+		{"should skip private", http.Header{"Cache-Control": {"private, max-age=30"}}, 0, false},
+		{"should skip a zero max-age", http.Header{"Cache-Control": {"max-age=0"}}, 0, false},
+		{"should skip Set-Cookie", http.Header{"Set-Cookie": {"session=abc"}}, 0, false},
+		{"should skip Vary: *", http.Header{"Vary": {"*"}}, 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ttl, cacheable := responseTTL(tt.header, time.Minute)
+
+			assert.Equal(t, tt.cacheable, cacheable)
+			if tt.cacheable {
+				assert.Equal(t, tt.ttl, ttl)
+			}
+		})
+	}
+}
+
+func TestResponseCacheKey(t *testing.T) {
+	t.Run("should key by method, path and query hash", func(t *testing.T) {
+		key := responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?page=2", nil))
+
+		assert.True(t, strings.HasPrefix(key, "cache:GET:/products:"))
+		assert.Len(t, strings.TrimPrefix(key, "cache:GET:/products:"), 64)
+	})
+
+	t.Run("should ignore the order of query parameters", func(t *testing.T) {
+		assert.Equal(t,
+			responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?page=2&limit=10", nil)),
+			responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?limit=10&page=2", nil)))
+		assert.NotEqual(t,
+			responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?page=2", nil)),
+			responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?page=3", nil)))
+	})
+}
+
+func TestResponseCache(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := containertest.NewRedis(t)
+	cfg := &config.PerformanceConfig{ResponseCaching: true, CacheDuration: time.Minute}
+
+	newRouter := func(handler gin.HandlerFunc) (*gin.Engine, *int) {
+		calls := 0
+		router := gin.New()
+		router.Use(ResponseCache(client, cfg))
+		router.GET("/products", func(c *gin.Context) {
+			calls++
+			handler(c)
+		})
+		router.DELETE("/admin/cache/flush", NewResponseCacheFlushHandler(client))
+		return router, &calls
+	}
+
+	get := func(router *gin.Engine, headers map[string]string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/products?page=1", nil)
+		for name, value := range headers {
+			req.Header.Set(name, value)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should replay the status, headers and body", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.Header("ETag", `"v1"`)
+			c.JSON(http.StatusOK, gin.H{"products": []string{"book"}})
+		})
+
+		first := get(router, nil)
+		second := get(router, nil)
+
+		assert.Equal(t, 1, *calls)
+		assert.Equal(t, "MISS", first.Header().Get(ResponseCacheHeader))
+		assert.Equal(t, "HIT", second.Header().Get(ResponseCacheHeader))
+		assert.Equal(t, http.StatusOK, second.Code)
+		assert.Equal(t, `"v1"`, second.Header().Get("ETag"))
+		assert.Equal(t, first.Header().Get("Content-Type"), second.Header().Get("Content-Type"))
+		assert.JSONEq(t, first.Body.String(), second.Body.String())
+	})
+
+	t.Run("should not replay per-request headers", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		requests := 0
+		router, _ := newRouter(func(c *gin.Context) {
+			requests++
+			c.Header(RequestIDHeader, fmt.Sprintf("request-%d", requests))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(100-requests))
+			c.JSON(http.StatusOK, gin.H{"products": []string{"book"}})
+		})
+
+		get(router, nil)
+		second := get(router, nil)
+
+		assert.Equal(t, "HIT", second.Header().Get(ResponseCacheHeader))
+		assert.Empty(t, second.Header().Get(RequestIDHeader))
+		assert.Empty(t, second.Header().Get("X-RateLimit-Remaining"))
+	})
+
+	t.Run("should respect no-store and max-age", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.Header("Cache-Control", "no-store")
+			c.String(http.StatusOK, "fresh")
+		})
+		get(router, nil)
+		get(router, nil)
+		assert.Equal(t, 2, *calls)
+
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, _ = newRouter(func(c *gin.Context) {
+			c.Header("Cache-Control", "max-age=30")
+			c.String(http.StatusOK, "fresh")
+		})
+		get(router, nil)
+		ttl, err := client.TTL(context.Background(), responseCacheKey(httptest.NewRequest(http.MethodGet, "/products?page=1", nil))).Result()
+		require.NoError(t, err)
+		assert.InDelta(t, 30, ttl.Seconds(), 1)
+	})
+
+	t.Run("should not cache errors", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.String(http.StatusServiceUnavailable, "down")
+		})
+
+		get(router, nil)
+		get(router, nil)
+
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("should cache a variant per Accept-Language", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.Header("Vary", "Accept, Accept-Language")
+			c.String(http.StatusOK, "hello in "+c.GetHeader("Accept-Language"))
+		})
+
+		assert.Equal(t, "hello in en", get(router, map[string]string{"Accept-Language": "en"}).Body.String())
+		assert.Equal(t, "hello in de", get(router, map[string]string{"Accept-Language": "de"}).Body.String())
+
+		w := get(router, map[string]string{"Accept-Language": "de"})
+		assert.Equal(t, "HIT", w.Header().Get(ResponseCacheHeader))
+		assert.Equal(t, "hello in de", w.Body.String())
+		assert.Equal(t, 2, *calls)
+	})
+
+	t.Run("should only share public responses with authenticated requests", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.String(http.StatusOK, "profile")
+		})
+		auth := map[string]string{"Authorization": "Bearer token"}
+		get(router, auth)
+		get(router, auth)
+		assert.Equal(t, 2, *calls)
+
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls = newRouter(func(c *gin.Context) {
+			c.Header("Cache-Control", "public, max-age=60")
+			c.String(http.StatusOK, "catalog")
+		})
+		get(router, auth)
+		get(router, auth)
+		assert.Equal(t, 1, *calls)
+	})
+
+	t.Run("should flush the entries matching the prefix", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router, calls := newRouter(func(c *gin.Context) {
+			c.String(http.StatusOK, "products")
+		})
+		get(router, nil)
+		require.NoError(t, client.Set(context.Background(), "cache:GET:/orders:abc", "{}", time.Minute).Err())
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/admin/cache/flush?prefix=GET:/products", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Deleted int `json:"deleted"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		assert.Equal(t, 1, body.Deleted)
+
+		get(router, nil)
+		assert.Equal(t, 2, *calls)
+		exists, err := client.Exists(context.Background(), "cache:GET:/orders:abc").Result()
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), exists)
+	})
+
+	t.Run("should pass requests through when disabled", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+		router := gin.New()
+		router.Use(ResponseCache(client, &config.PerformanceConfig{}))
+		calls := 0
+		router.GET("/products", func(c *gin.Context) {
+			calls++
+			c.String(http.StatusOK, "products")
+		})
+
+		for i := 0; i < 2; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+		}
+
+		assert.Equal(t, 2, calls)
+	})
+}
@@ -0,0 +1,58 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+)
+
+func TestConfigHistoryRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret-key-that-is-long-enough", 3600)
+
+	history := config.NewConfigChangeHistory(config.ConfigHistorySize)
+	history.Record(config.ConfigChange{Field: "Features.MaintenanceMode", OldValue: "false", NewValue: "true"})
+
+	router := gin.New()
+	SetupRoutes(router, &Dependencies{
+		JWTService:    jwtService,
+		ConfigHistory: history,
+		Config:        &config.Config{},
+	})
+
+	request := func(role string) *httptest.ResponseRecorder {
+		token, _, err := jwtService.GenerateToken(uuid.New(), role+"@example.com", role)
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodGet, "/admin/config/history", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should serve the config changes to admins", func(t *testing.T) {
+		w := request("admin")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Changes []config.ConfigChange `json:"changes"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Changes, 1)
+		assert.Equal(t, "Features.MaintenanceMode", body.Changes[0].Field)
+	})
+
+	t.Run("should forbid other roles", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("user").Code)
+	})
+}
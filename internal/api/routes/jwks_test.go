@@ -0,0 +1,69 @@
+package routes
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+)
+
+func TestJWKSRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	dir := t.TempDir()
+	privateFile, publicFile := filepath.Join(dir, "jwt.key"), filepath.Join(dir, "jwt.pub")
+	require.NoError(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	require.NoError(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600))
+
+	request := func(jwtService *auth.JWTService) *httptest.ResponseRecorder {
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{JWTService: jwtService, Config: &config.Config{}})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/.well-known/jwks.json", nil))
+		return w
+	}
+
+	t.Run("should serve the public keys of RSA signing", func(t *testing.T) {
+		jwtService, err := auth.NewRSAJWTService("RS256", privateFile, publicFile, 3600)
+		require.NoError(t, err)
+
+		w := request(jwtService)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+		var body struct {
+			Keys []struct {
+				Kty string `json:"kty"`
+				Kid string `json:"kid"`
+			} `json:"keys"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Keys, 1)
+		assert.Equal(t, "RSA", body.Keys[0].Kty)
+		assert.Equal(t, jwtService.KeySets()[0].KID, body.Keys[0].Kid)
+	})
+
+	t.Run("should not serve keys for HMAC signing", func(t *testing.T) {
+		w := request(auth.NewJWTService("test-secret-key-that-is-long-enough", 3600))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,63 @@
+package routes
+
+import (
+	"math"
+	"net/http"
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/VeRJiL/go-template/internal/api/middleware"
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// maxProfileDuration caps CPU profiles requested with ?duration=
+const maxProfileDuration = 5 * time.Minute
+
+// pprofEnabled reports whether the profiling endpoints may be served.
+// They are never exposed in release or production mode.
+func pprofEnabled(cfg *config.ServerConfig) bool {
+	return cfg.EnablePprof && cfg.Mode != gin.ReleaseMode && cfg.Mode != "production"
+}
+
+// registerPprof serves net/http/pprof under /debug/pprof for admins only
+func registerPprof(router *gin.RouterGroup, authMiddleware gin.HandlerFunc) {
+	debug := router.Group("/debug/pprof", authMiddleware, middleware.RequireRole("admin"))
+
+	debug.GET("/*profile", func(c *gin.Context) {
+		switch c.Param("profile") {
+		case "/cmdline":
+			pprof.Cmdline(c.Writer, c.Request)
+		case "/profile":
+			cpuProfile(c)
+		case "/symbol":
+			pprof.Symbol(c.Writer, c.Request)
+		case "/trace":
+			pprof.Trace(c.Writer, c.Request)
+		default:
+			// Index serves both the listing and named profiles such as /heap
+			pprof.Index(c.Writer, c.Request)
+		}
+	})
+	debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+}
+
+// cpuProfile captures a CPU profile and returns it as an attachment. The
+// length is taken from ?duration=30s, falling back to pprof's ?seconds=.
+func cpuProfile(c *gin.Context) {
+	if value := c.Query("duration"); value != "" {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 || duration > maxProfileDuration {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "duration must be a positive duration up to " + maxProfileDuration.String()})
+			return
+		}
+
+		query := c.Request.URL.Query()
+		query.Set("seconds", strconv.Itoa(int(math.Ceil(duration.Seconds()))))
+		c.Request.URL.RawQuery = query.Encode()
+	}
+
+	pprof.Profile(c.Writer, c.Request)
+}
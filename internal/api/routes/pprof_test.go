@@ -0,0 +1,134 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/listener"
+)
+
+func TestPprofRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret-key-that-is-long-enough", 3600)
+
+	newRouter := func(mode string) *gin.Engine {
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{
+			JWTService: jwtService,
+			Config: &config.Config{
+				Server: config.ServerConfig{EnablePprof: true, Mode: mode},
+			},
+		})
+		return router
+	}
+
+	tokenFor := func(role string) string {
+		token, _, err := jwtService.GenerateToken(uuid.New(), role+"@example.com", role)
+		require.NoError(t, err)
+		return token
+	}
+
+	request := func(router *gin.Engine, url, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	router := newRouter("development")
+	adminToken := tokenFor("admin")
+
+	t.Run("should serve the profile index to admins", func(t *testing.T) {
+		w := request(router, "/debug/pprof/", adminToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "goroutine")
+	})
+
+	t.Run("should serve named profiles to admins", func(t *testing.T) {
+		w := request(router, "/debug/pprof/heap?debug=1", adminToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Body.String(), "heap profile")
+	})
+
+	t.Run("should require authentication", func(t *testing.T) {
+		w := request(router, "/debug/pprof/", "")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should forbid non-admin users", func(t *testing.T) {
+		w := request(router, "/debug/pprof/", tokenFor("user"))
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+	})
+
+	t.Run("should not exist in release mode", func(t *testing.T) {
+		w := request(newRouter(gin.ReleaseMode), "/debug/pprof/", adminToken)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should capture a CPU profile for the requested duration", func(t *testing.T) {
+		w := request(router, "/debug/pprof/profile?duration=1s", adminToken)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "application/octet-stream", w.Header().Get("Content-Type"))
+		assert.Contains(t, w.Header().Get("Content-Disposition"), "attachment")
+		assert.NotEmpty(t, w.Body.Bytes())
+	})
+
+	t.Run("should reject invalid profile durations", func(t *testing.T) {
+		assert.Equal(t, http.StatusBadRequest, request(router, "/debug/pprof/profile?duration=forever", adminToken).Code)
+		assert.Equal(t, http.StatusBadRequest, request(router, "/debug/pprof/profile?duration=1h", adminToken).Code)
+	})
+}
+
+func TestAdminRoutesPriorityPort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret-key-that-is-long-enough", 3600)
+
+	router := gin.New()
+	SetupRoutes(router, &Dependencies{
+		JWTService: jwtService,
+		Config: &config.Config{
+			Server: config.ServerConfig{EnablePprof: true, Mode: "development", PriorityPortEnabled: true},
+		},
+	})
+
+	token, _, err := jwtService.GenerateToken(uuid.New(), "admin@example.com", "admin")
+	require.NoError(t, err)
+
+	request := func(name, url string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		listener.WithName(name, router).ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should serve admin routes on the priority port", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(listener.Priority, "/debug/pprof/").Code)
+	})
+
+	t.Run("should hide admin routes on the public port", func(t *testing.T) {
+		assert.Equal(t, http.StatusNotFound, request(listener.Public, "/debug/pprof/").Code)
+	})
+
+	t.Run("should serve health checks on both ports", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request(listener.Public, "/health").Code)
+		assert.Equal(t, http.StatusOK, request(listener.Priority, "/health").Code)
+	})
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
@@ -11,47 +12,197 @@ import (
 	"github.com/VeRJiL/go-template/internal/api/handlers"
 	"github.com/VeRJiL/go-template/internal/api/middleware"
 	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/database/postgres"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/listener"
 	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
 	_ "github.com/VeRJiL/go-template/docs/swagger"
 )
 
 type Dependencies struct {
-	UserHandler *handlers.UserHandler
-	JWTService  *auth.JWTService
-	Logger      *logger.Logger
-	Config      *config.Config
+	UserHandler       *handlers.UserHandler
+	TOTPHandler       *handlers.TOTPHandler // nil disables two-factor enrollment
+	GraphQLHandler    gin.HandlerFunc
+	ChangelogHandler  gin.HandlerFunc
+	BlockedIPsHandler gin.HandlerFunc
+	// LoginAnomalyDetector runs around the login handler when set
+	LoginAnomalyDetector gin.HandlerFunc
+	// MetricsHandler serves the Prometheus metrics for scraping
+	MetricsHandler gin.HandlerFunc
+	// MetricsSummaryHandler serves per-route latency percentiles
+	MetricsSummaryHandler gin.HandlerFunc
+	// CircuitBreakersHandler serves the state of outbound HTTP breakers
+	CircuitBreakersHandler gin.HandlerFunc
+	// SLOHandler serves the error budgets of the HTTP service level objectives
+	SLOHandler gin.HandlerFunc
+	// ResponseCacheFlushHandler deletes cached GET responses
+	ResponseCacheFlushHandler gin.HandlerFunc
+	// StorageQuotasHandler serves the owners storing the most bytes
+	StorageQuotasHandler gin.HandlerFunc
+	// StorageFileHandler serves files of the local storage driver behind
+	// signed temporary URLs
+	StorageFileHandler gin.HandlerFunc
+	// ConfigHistory holds the recent runtime config changes served to admins
+	ConfigHistory *config.ConfigChangeHistory
+	// Database is reported by /health when set
+	Database     *postgres.ManagedDB
+	TxMiddleware gin.HandlerFunc
+	JWTService   *auth.JWTService
+	SessionStore session.Store
+	// SessionService lets Redis session cookies authenticate alongside
+	// bearer tokens when session-based auth is off
+	SessionService *auth.SessionService
+	// APIKeyService lets service to service calls authenticate with the
+	// X-API-Key header
+	APIKeyService *auth.APIKeyService
+	// APIKeyHandler issues and revokes API keys (admin only)
+	APIKeyHandler *handlers.APIKeyHandler
+	Logger        *logger.Logger
+	Config        *config.Config
 }
 
 // SetupRoutes configures all application routes
 func SetupRoutes(router *gin.Engine, deps *Dependencies) {
 	// Health check endpoint
-	router.GET("/health", healthCheck)
+	router.GET("/health", newHealthCheck(deps.Database))
 
 	// Swagger documentation
 	if deps.Config.Server.EnableSwagger {
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
+	// API changelog, filterable with ?from=1.0.0&to=1.2.0
+	if deps.ChangelogHandler != nil {
+		router.GET("/api/changelog", deps.ChangelogHandler)
+	}
+
+	// GraphQL queries over HTTP, subscriptions over WebSocket (GET upgrade)
+	if deps.Config.Server.EnableGraphQL && deps.GraphQLHandler != nil {
+		router.GET("/graphql", deps.GraphQLHandler)
+		router.POST("/graphql", deps.GraphQLHandler)
+	}
+
+	// Public keys of the RSA token signing keys
+	if deps.JWTService != nil && deps.JWTService.UsesRSA() {
+		router.GET("/.well-known/jwks.json", newJWKSHandler(deps.JWTService))
+	}
+
+	// Session-based auth replaces JWT validation when enabled, otherwise a
+	// session cookie is accepted as an alternative to the bearer token. API
+	// keys are accepted either way.
+	alternatives := []gin.HandlerFunc{middleware.AuthMiddleware(deps.JWTService)}
+	if deps.Config.Auth.SessionBasedAuth && deps.SessionStore != nil {
+		alternatives[0] = middleware.SessionAuthMiddleware(deps.SessionStore, deps.Config.Auth.Session.CookieName)
+	} else if deps.SessionService != nil {
+		alternatives = append(alternatives, middleware.SessionAuthMiddleware(deps.SessionService.Store(), deps.SessionService.CookieName()))
+	}
+	if deps.APIKeyService != nil {
+		alternatives = append(alternatives, middleware.APIKeyAuth(deps.APIKeyService))
+	}
+	authMiddleware := alternatives[0]
+	if len(alternatives) > 1 {
+		authMiddleware = middleware.AnyOf(alternatives...)
+	}
+
+	// With a priority port, admin routes are only served on it
+	adminRoutes := router.Group("")
+	if deps.Config.Server.PriorityPortEnabled {
+		adminRoutes.Use(middleware.RequireListener(listener.Priority))
+	}
+
+	// Prometheus scrape endpoint, on the priority port when there is one
+	if deps.MetricsHandler != nil {
+		adminRoutes.GET(deps.Config.Monitoring.Prometheus.MetricsPath, deps.MetricsHandler)
+	}
+
+	// Profiling endpoints (admin only, never in release mode)
+	if pprofEnabled(&deps.Config.Server) {
+		registerPprof(adminRoutes, authMiddleware)
+	}
+
+	admin := adminRoutes.Group("/admin", authMiddleware, middleware.RequireRole("admin"))
+
+	// Abuse detection blocklist (admin only)
+	if deps.BlockedIPsHandler != nil {
+		admin.GET("/abuse/blocked-ips", deps.BlockedIPsHandler)
+	}
+
+	// Latency percentiles dashboard (admin only)
+	if deps.MetricsSummaryHandler != nil {
+		admin.GET("/metrics/summary", deps.MetricsSummaryHandler)
+	}
+
+	// Outbound circuit breaker states (admin only)
+	if deps.CircuitBreakersHandler != nil {
+		admin.GET("/circuit-breakers", deps.CircuitBreakersHandler)
+	}
+
+	// SLO error budgets (admin only)
+	if deps.SLOHandler != nil {
+		admin.GET("/slo", deps.SLOHandler)
+	}
+
+	// Storage usage of the heaviest users and tenants (admin only)
+	if deps.StorageQuotasHandler != nil {
+		admin.GET("/storage/quotas", deps.StorageQuotasHandler)
+	}
+
+	// Cached response invalidation, ?prefix=GET:/api/v1/users (admin only)
+	if deps.ResponseCacheFlushHandler != nil {
+		admin.DELETE("/cache/flush", deps.ResponseCacheFlushHandler)
+	}
+
+	// API key management (admin only)
+	if deps.APIKeyHandler != nil {
+		admin.POST("/api-keys", deps.APIKeyHandler.Create)
+		admin.DELETE("/api-keys/:id", deps.APIKeyHandler.Revoke)
+	}
+
+	// Recent runtime config changes (admin only)
+	if deps.ConfigHistory != nil {
+		admin.GET("/config/history", newConfigHistoryHandler(deps.ConfigHistory))
+	}
+
+	// Local storage files behind signed temporary URLs
+	if deps.Config.Storage.Provider == "local" && deps.StorageFileHandler != nil {
+		router.GET(strings.TrimSuffix(deps.Config.Storage.Local.URLPrefix, "/")+"/*path", deps.StorageFileHandler)
+	}
+
 	// API v1 routes
 	v1 := router.Group("/api/v1")
 	{
 		// Authentication routes (public)
 		auth := v1.Group("/auth")
 		{
-			auth.POST("/register", deps.UserHandler.Create)
-			auth.POST("/login", deps.UserHandler.Login)
+			auth.POST("/register", withTx(deps.TxMiddleware, deps.UserHandler.Create)...)
+			auth.POST("/login", withMiddleware(deps.LoginAnomalyDetector, deps.UserHandler.Login)...)
+			auth.POST("/refresh", deps.UserHandler.Refresh)
+
+			// Social login, the provider redirects back to the callback
+			if deps.Config.Features.SocialLogin {
+				auth.GET("/:provider", deps.UserHandler.OAuthRedirect)
+				auth.GET("/:provider/callback", withMiddleware(deps.LoginAnomalyDetector, deps.UserHandler.OAuthCallback)...)
+			}
 
 			// Protected auth routes
-			protected := auth.Use(middleware.AuthMiddleware(deps.JWTService))
+			protected := auth.Use(authMiddleware)
 			{
 				protected.POST("/logout", deps.UserHandler.Logout)
 				protected.GET("/me", deps.UserHandler.GetProfile)
+
+				// Two-factor authentication, enabled once enrollment is
+				// confirmed with a first code
+				if deps.TOTPHandler != nil {
+					protected.POST("/totp/enroll", deps.TOTPHandler.Enroll)
+					protected.POST("/totp/confirm", deps.TOTPHandler.Confirm)
+					protected.POST("/totp/disable", deps.TOTPHandler.Disable)
+				}
 			}
 		}
 
 		// User management routes (protected)
-		users := v1.Group("/users").Use(middleware.AuthMiddleware(deps.JWTService))
+		users := v1.Group("/users").Use(authMiddleware)
 		{
 			users.GET("/", deps.UserHandler.List)         // List all users
 			users.GET("/search", deps.UserHandler.Search) // Search users
@@ -62,12 +213,62 @@ func SetupRoutes(router *gin.Engine, deps *Dependencies) {
 	}
 }
 
-// healthCheck returns the application health status
-func healthCheck(c *gin.Context) {
-	c.JSON(http.StatusOK, gin.H{
-		"status":    "ok",
-		"service":   "go-template",
-		"version":   "1.0.0",
-		"timestamp": time.Now().UTC().Format(time.RFC3339),
-	})
+// withTx prepends the per-request transaction middleware when one is configured
+func withTx(txMiddleware gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+	return withMiddleware(txMiddleware, handler)
+}
+
+// withMiddleware runs middleware before handler unless it is nil
+func withMiddleware(middleware gin.HandlerFunc, handler gin.HandlerFunc) []gin.HandlerFunc {
+	if middleware == nil {
+		return []gin.HandlerFunc{handler}
+	}
+	return []gin.HandlerFunc{middleware, handler}
+}
+
+// newHealthCheck returns the application health status, answering 503
+// while the database, when given, is unhealthy
+func newHealthCheck(db *postgres.ManagedDB) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Always report the current status, never a cached one
+		c.Header("Cache-Control", "no-store")
+
+		body := gin.H{
+			"status":    "ok",
+			"service":   "go-template",
+			"version":   "1.0.0",
+			"timestamp": time.Now().UTC().Format(time.RFC3339),
+		}
+		if db == nil {
+			c.JSON(http.StatusOK, body)
+			return
+		}
+
+		status := db.HealthStatus()
+		body["database"] = status
+		if !status.Healthy {
+			body["status"] = "unhealthy"
+			c.JSON(http.StatusServiceUnavailable, body)
+			return
+		}
+		c.JSON(http.StatusOK, body)
+	}
+}
+
+// newConfigHistoryHandler serves the recent config changes, oldest first
+func newConfigHistoryHandler(history *config.ConfigChangeHistory) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"changes": history.Changes()})
+	}
+}
+
+func newJWKSHandler(jwtService *auth.JWTService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := jwtService.JWKS()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to encode key set"})
+			return
+		}
+		c.Data(http.StatusOK, "application/json", body)
+	}
 }
@@ -0,0 +1,206 @@
+package routes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/api/handlers"
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
+	"github.com/VeRJiL/go-template/internal/domain/services"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/auth/oauth"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// fakeOAuthProvider accepts the code "valid" for its account
+type fakeOAuthProvider struct {
+	account *oauth.OAuthUser
+}
+
+func (p *fakeOAuthProvider) GetAuthURL(state string) string {
+	return "https://provider.example.com/authorize?state=" + url.QueryEscape(state)
+}
+
+func (p *fakeOAuthProvider) ExchangeCode(ctx context.Context, code string) (*oauth.OAuthUser, error) {
+	if code != "valid" {
+		return nil, errors.New("invalid_grant")
+	}
+	return p.account, nil
+}
+
+// memoryUserRepository implements the lookups social login makes
+type memoryUserRepository struct {
+	repositories.UserRepository
+	users  map[uuid.UUID]*entities.User
+	linked map[string]uuid.UUID
+}
+
+func (r *memoryUserRepository) Create(ctx context.Context, user *entities.User) error {
+	r.users[user.ID] = user
+	return nil
+}
+
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			return user, nil
+		}
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *memoryUserRepository) GetByOAuth(ctx context.Context, provider, subject string) (*entities.User, error) {
+	if id, ok := r.linked[provider+"/"+subject]; ok {
+		return r.users[id], nil
+	}
+	return nil, errors.New("user not found")
+}
+
+func (r *memoryUserRepository) SetOAuth(ctx context.Context, id uuid.UUID, provider, subject string) error {
+	r.linked[provider+"/"+subject] = id
+	return nil
+}
+
+func TestSocialLoginRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret-key-that-is-long-enough", 3600)
+
+	newRouter := func(repo *memoryUserRepository, account *oauth.OAuthUser) *gin.Engine {
+		userService := services.NewUserService(repo, jwtService)
+		userService.SetOAuthProviders(map[string]oauth.OAuthProvider{"google": &fakeOAuthProvider{account: account}})
+
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{
+			UserHandler: handlers.NewUserHandler(userService, logger.New("error", "json")),
+			JWTService:  jwtService,
+			Config:      &config.Config{Features: config.FeatureConfig{SocialLogin: true}},
+		})
+		return router
+	}
+	newRepo := func() *memoryUserRepository {
+		return &memoryUserRepository{users: make(map[uuid.UUID]*entities.User), linked: make(map[string]uuid.UUID)}
+	}
+	account := &oauth.OAuthUser{Provider: "google", Subject: "1234", Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"}
+
+	// login starts a login and returns the state cookie set with it
+	login := func(t *testing.T, router *gin.Engine) *http.Cookie {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/google", nil))
+		require.Equal(t, http.StatusTemporaryRedirect, w.Code)
+
+		cookies := w.Result().Cookies()
+		require.Len(t, cookies, 1)
+		assert.Equal(t, "oauth_state", cookies[0].Name)
+		assert.True(t, cookies[0].HttpOnly)
+
+		redirect, err := url.Parse(w.Header().Get("Location"))
+		require.NoError(t, err)
+		assert.Equal(t, "provider.example.com", redirect.Host)
+		assert.Equal(t, cookies[0].Value, redirect.Query().Get("state"))
+		return cookies[0]
+	}
+	callback := func(router *gin.Engine, query string, cookie *http.Cookie) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/google/callback?"+query, nil)
+		if cookie != nil {
+			req.AddCookie(cookie)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should create and link the user on first login", func(t *testing.T) {
+		repo := newRepo()
+		router := newRouter(repo, account)
+		state := login(t, router)
+
+		w := callback(router, "code=valid&state="+state.Value, state)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Contains(t, w.Body.String(), `"token"`)
+		require.Len(t, repo.users, 1)
+		user, err := repo.GetByOAuth(context.Background(), "google", "1234")
+		require.NoError(t, err)
+		assert.Equal(t, "jane@example.com", user.Email)
+		assert.Equal(t, "Jane", user.FirstName)
+		assert.NotEmpty(t, user.Password)
+	})
+
+	t.Run("should link an existing user by email", func(t *testing.T) {
+		repo := newRepo()
+		existing := &entities.User{Email: "jane@example.com", FirstName: "Jane", LastName: "Smith"}
+		existing.BeforeCreate()
+		repo.users[existing.ID] = existing
+		router := newRouter(repo, account)
+		state := login(t, router)
+
+		w := callback(router, "code=valid&state="+state.Value, state)
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Len(t, repo.users, 1)
+		assert.Equal(t, existing.ID, repo.linked["google/1234"])
+	})
+
+	t.Run("should reject users with two-factor authentication", func(t *testing.T) {
+		repo := newRepo()
+		existing := &entities.User{Email: "jane@example.com", TOTPEnabled: true}
+		existing.BeforeCreate()
+		repo.users[existing.ID] = existing
+		router := newRouter(repo, account)
+		state := login(t, router)
+
+		w := callback(router, "code=valid&state="+state.Value, state)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "totp_required")
+	})
+
+	t.Run("should reject a callback without the state it started with", func(t *testing.T) {
+		router := newRouter(newRepo(), account)
+		state := login(t, router)
+
+		assert.Equal(t, http.StatusBadRequest, callback(router, "code=valid&state=forged", state).Code)
+		assert.Equal(t, http.StatusBadRequest, callback(router, "code=valid&state="+state.Value, nil).Code)
+	})
+
+	t.Run("should reject an invalid code", func(t *testing.T) {
+		router := newRouter(newRepo(), account)
+		state := login(t, router)
+
+		assert.Equal(t, http.StatusUnauthorized, callback(router, "code=invalid&state="+state.Value, state).Code)
+	})
+
+	t.Run("should not know other providers", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(newRepo(), account).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/twitter", nil))
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+
+	t.Run("should keep the profile route protected", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		newRouter(newRepo(), account).ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil))
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+	})
+
+	t.Run("should not route social login unless enabled", func(t *testing.T) {
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{JWTService: jwtService, Config: &config.Config{}})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/auth/google", nil))
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,53 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+)
+
+func TestStorageFileRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(provider string) *gin.Engine {
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{
+			JWTService: auth.NewJWTService("test-secret-key-that-is-long-enough", 3600),
+			StorageFileHandler: func(c *gin.Context) {
+				c.String(http.StatusOK, c.Param("path"))
+			},
+			Config: &config.Config{
+				Storage: config.StorageConfig{
+					Provider: provider,
+					Local:    config.LocalStorageConfig{URLPrefix: "/uploads/"},
+				},
+			},
+		})
+		return router
+	}
+
+	request := func(router *gin.Engine, url string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		return w
+	}
+
+	t.Run("should serve files under the URL prefix with local storage", func(t *testing.T) {
+		w := request(newRouter("local"), "/uploads/docs/report.pdf?expires=1&sig=abc")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "/docs/report.pdf", w.Body.String())
+	})
+
+	t.Run("should not serve files with other providers", func(t *testing.T) {
+		w := request(newRouter("s3"), "/uploads/docs/report.pdf")
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -0,0 +1,126 @@
+package routes
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/api/handlers"
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// memoryTOTPStore keeps the enabled TOTP secrets of users
+type memoryTOTPStore map[uuid.UUID]string
+
+func (m memoryTOTPStore) GetTOTP(ctx context.Context, userID uuid.UUID) (string, bool, error) {
+	secret, ok := m[userID]
+	return secret, ok, nil
+}
+
+func (m memoryTOTPStore) SetTOTP(ctx context.Context, userID uuid.UUID, secret string, enabled bool) error {
+	if !enabled {
+		delete(m, userID)
+		return nil
+	}
+	m[userID] = secret
+	return nil
+}
+
+func TestTOTPRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtService := auth.NewJWTService("test-secret-key-that-is-long-enough", 3600)
+	userID := uuid.New()
+
+	newRouter := func(store memoryTOTPStore) *gin.Engine {
+		totpService := auth.NewTOTPService("go-template", store)
+
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{
+			UserHandler: handlers.NewUserHandler(nil, logger.New("error", "json")),
+			TOTPHandler: handlers.NewTOTPHandler(totpService, logger.New("error", "json")),
+			JWTService:  jwtService,
+			Config:      &config.Config{},
+		})
+		return router
+	}
+	post := func(t *testing.T, router *gin.Engine, path, body string) *httptest.ResponseRecorder {
+		token, _, err := jwtService.GenerateToken(userID, "jane@example.com", "user")
+		require.NoError(t, err)
+
+		req := httptest.NewRequest(http.MethodPost, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+	enabled := func(t *testing.T) (memoryTOTPStore, string) {
+		key, err := totp.Generate(totp.GenerateOpts{Issuer: "go-template", AccountName: userID.String()})
+		require.NoError(t, err)
+		return memoryTOTPStore{userID: key.Secret()}, key.Secret()
+	}
+
+	t.Run("should require authentication", func(t *testing.T) {
+		for _, path := range []string{"/api/v1/auth/totp/enroll", "/api/v1/auth/totp/confirm", "/api/v1/auth/totp/disable"} {
+			w := httptest.NewRecorder()
+			newRouter(memoryTOTPStore{}).ServeHTTP(w, httptest.NewRequest(http.MethodPost, path, nil))
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code, path)
+		}
+	})
+
+	t.Run("should disable TOTP with a current code", func(t *testing.T) {
+		store, secret := enabled(t)
+		code, err := totp.GenerateCode(secret, time.Now())
+		require.NoError(t, err)
+
+		w := post(t, newRouter(store), "/api/v1/auth/totp/disable", `{"code":"`+code+`"}`)
+
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+		assert.Empty(t, store)
+	})
+
+	t.Run("should not disable TOTP with a wrong code", func(t *testing.T) {
+		store, _ := enabled(t)
+
+		w := post(t, newRouter(store), "/api/v1/auth/totp/disable", `{"code":"000000x"}`)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Len(t, store, 1)
+	})
+
+	t.Run("should report TOTP that is not enabled", func(t *testing.T) {
+		w := post(t, newRouter(memoryTOTPStore{}), "/api/v1/auth/totp/disable", `{"code":"123456"}`)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+
+	t.Run("should require a code", func(t *testing.T) {
+		store, _ := enabled(t)
+
+		assert.Equal(t, http.StatusBadRequest, post(t, newRouter(store), "/api/v1/auth/totp/confirm", `{}`).Code)
+		assert.Equal(t, http.StatusBadRequest, post(t, newRouter(store), "/api/v1/auth/totp/disable", `{}`).Code)
+	})
+
+	t.Run("should not route enrollment without a handler", func(t *testing.T) {
+		router := gin.New()
+		SetupRoutes(router, &Dependencies{
+			UserHandler: handlers.NewUserHandler(nil, logger.New("error", "json")),
+			JWTService:  jwtService,
+			Config:      &config.Config{},
+		})
+
+		assert.Equal(t, http.StatusNotFound, post(t, router, "/api/v1/auth/totp/enroll", "").Code)
+	})
+}
@@ -2,16 +2,30 @@ package app
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
+	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 
 	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/net/http2"
 	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 
+	"github.com/VeRJiL/go-template/internal/api/graph"
 	"github.com/VeRJiL/go-template/internal/api/handlers"
 	"github.com/VeRJiL/go-template/internal/api/middleware"
 	"github.com/VeRJiL/go-template/internal/api/routes"
@@ -20,32 +34,78 @@ import (
 	redisRepo "github.com/VeRJiL/go-template/internal/database/redis"
 	"github.com/VeRJiL/go-template/internal/domain/repositories"
 	"github.com/VeRJiL/go-template/internal/domain/services"
+	"github.com/VeRJiL/go-template/internal/pkg/alerting"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/auth/oauth"
+	"github.com/VeRJiL/go-template/internal/pkg/bootstrap"
+	"github.com/VeRJiL/go-template/internal/pkg/changelog"
+	"github.com/VeRJiL/go-template/internal/pkg/circuitbreaker"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+	"github.com/VeRJiL/go-template/internal/pkg/features"
+	"github.com/VeRJiL/go-template/internal/pkg/listener"
 	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+	pkgmiddleware "github.com/VeRJiL/go-template/internal/pkg/middleware"
+	"github.com/VeRJiL/go-template/internal/pkg/monitoring"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+	_ "github.com/VeRJiL/go-template/internal/pkg/storage/drivers" // registers the storage drivers
+	tlsutil "github.com/VeRJiL/go-template/internal/pkg/tls"
 )
 
+// database is the connection of the stores outside the repositories.
+// *sql.DB and *postgres.ManagedDB implement it.
+type database interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+	PingContext(ctx context.Context) error
+	Close() error
+}
+
 type App struct {
 	config      *config.Config
-	db          *sql.DB
+	db          database                 // managedDB, or with DB_REPLICAS the pool's primary
+	dbPool      *postgres.ConnectionPool // set with DB_REPLICAS
+	managedDB   *postgres.ManagedDB      // set without DB_REPLICAS, health checked and reconnected
 	redisClient *redis.Client
+	mongoClient *mongo.Client
 	router      *gin.Engine
 	server      *http.Server
+	httpServer  *http.Server
+	grpcServer  *grpc.Server      // nil unless GRPC_ENABLED
+	tlsConfig   *tls.Config       // shared by the HTTPS and gRPC servers
+	certManager *autocert.Manager // nil unless HTTPS_DOMAIN is set
 	jwtService  *auth.JWTService
+	eventBus    *eventbus.Bus
+	broker      *messagebroker.Manager // nil unless MESSAGE_BROKER_ENABLED
+	monitor     *monitoring.PrometheusMonitor
+	sloTracker  *monitoring.SLOTracker     // nil when monitoring is off
+	latency     *monitoring.LatencyAlerter // nil unless LATENCY_ALERT_THRESHOLD_MS is set
+	storage     *storage.Manager           // nil when no disk could be set up
+	sessions    session.Store              // nil unless SESSION_BASED_AUTH
+	features    *features.FeatureFlagManager
+	configLog   *config.ConfigChangeHistory // settings changed by reloads
 	logger      *logger.Logger
+
+	// priorityServer serves internal services on SERVER_PRIORITY_PORT
+	priorityServer   *http.Server
+	stopHealthAlerts context.CancelFunc
+	stopUserEvents   func()
+	stopSLOTracking  context.CancelFunc
+	stopLatencyAlert context.CancelFunc
+	lokiClient       *logger.LokiPushClient
+	logFile          io.Closer
 }
 
 func New() (*App, error) {
-	cfg, err := config.Load()
+	cfg, err := loadConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	log := logger.New(cfg.Logging.Level, cfg.Logging.Format)
-
-	app := &App{
-		config: cfg,
-		logger: log,
-	}
+	app := &App{config: cfg}
+	app.logger = app.newLogger()
 
 	// Initialize dependencies
 	if err := app.initDependencies(); err != nil {
@@ -58,11 +118,21 @@ func New() (*App, error) {
 }
 
 func (a *App) initDependencies() error {
-	db, err := postgres.NewConnection(&a.config.Database)
-	if err != nil {
-		return err
+	if len(a.config.Database.Replicas) > 0 {
+		pool, err := postgres.NewConnectionPool(&a.config.Database, postgres.WithPoolLogger(a.logger))
+		if err != nil {
+			return err
+		}
+		a.dbPool = pool
+		a.db = pool.DB
+	} else {
+		db, err := postgres.NewManagedConnection(&a.config.Database, postgres.WithManagedLogger(a.logger))
+		if err != nil {
+			return err
+		}
+		a.managedDB = db
+		a.db = db
 	}
-	a.db = db
 
 	a.redisClient = redis.NewClient(&redis.Options{
 		Addr:         a.config.Redis.Host + ":" + a.config.Redis.Port,
@@ -83,14 +153,267 @@ func (a *App) initDependencies() error {
 		a.logger.Info("Redis connection established successfully")
 	}
 
-	a.jwtService = auth.NewJWTService(
-		a.config.Auth.JWT.Secret,
-		int(a.config.Auth.JWT.Expiration.Seconds()),
-	)
+	if err := a.initJWTService(); err != nil {
+		return err
+	}
+	if a.config.Auth.JWT.RefreshExpiration > 0 {
+		a.jwtService.SetRefreshExpiration(a.config.Auth.JWT.RefreshExpiration)
+	}
+	if a.redisClient != nil {
+		a.jwtService.SetRevocationStore(a.redisClient)
+	}
+
+	a.features = features.NewFeatureFlagManager(a.config.Features, a.redisClient)
+	a.configLog = config.NewConfigChangeHistory(config.ConfigHistorySize)
+	a.features.TrackChanges(a.configLog, a.logger)
+	if a.config.Features.Store == "redis" {
+		if err := a.features.Reload(ctx); err != nil {
+			a.logger.Warn("Feature flags unavailable in Redis, using environment", "error", err)
+		}
+	}
+
+	if a.config.Auth.SessionBasedAuth && a.config.Auth.Session.Driver == "mongodb" {
+		mongoCfg := a.config.MongoDB
+		clientOpts := options.Client().
+			ApplyURI(mongoCfg.URI).
+			SetMaxPoolSize(uint64(mongoCfg.MaxPoolSize)).
+			SetMinPoolSize(uint64(mongoCfg.MinPoolSize)).
+			SetConnectTimeout(mongoCfg.ConnectTimeout).
+			SetServerSelectionTimeout(mongoCfg.ServerSelectionTimeout)
+
+		client, err := mongo.Connect(ctx, clientOpts)
+		if err != nil {
+			return err
+		}
+		a.mongoClient = client
+	}
+
+	if a.config.Auth.SessionBasedAuth {
+		if err := a.initSessionStore(); err != nil {
+			return err
+		}
+	}
+
+	if err := a.initMonitor(); err != nil {
+		return err
+	}
+
+	if a.config.MessageBroker.Enabled {
+		if err := a.initMessageBroker(); err != nil {
+			return err
+		}
+	}
+
+	if manager, err := storage.NewManager(&a.config.Storage); err != nil {
+		a.logger.Warn("Storage unavailable, file routes disabled", "provider", a.config.Storage.Provider, "error", err)
+	} else {
+		a.storage = manager
+		a.initStorageQuotas()
+		a.initStorageDeduplication()
+	}
 
 	return nil
 }
 
+// initMonitor creates the Prometheus monitor recording the HTTP metrics.
+// With MONITORING_ENABLED off, or another provider, it records nothing.
+func (a *App) initMonitor() error {
+	cfg := a.config.Monitoring
+	monitor, err := monitoring.NewPrometheusMonitor(&monitoring.Config{
+		Enabled:            a.monitoringEnabled(),
+		Namespace:          cfg.Prometheus.Namespace,
+		MetricsPath:        cfg.Prometheus.MetricsPath,
+		SlowQueryThreshold: cfg.Prometheus.SlowQueryThreshold,
+	})
+	if err != nil {
+		return err
+	}
+	monitor.SetLogger(a.logger)
+	a.monitor = monitor
+
+	// Any route may be wrapped in a canary router, so its counter is always
+	// exported
+	a.registerMetrics(pkgmiddleware.CanaryRequests)
+
+	if a.monitoringEnabled() {
+		slo := a.config.Monitoring.SLO
+		a.sloTracker = monitoring.NewSLOTracker(monitoring.SLOConfig{
+			AvailabilityTarget: slo.AvailabilityTarget,
+			LatencyTarget:      slo.LatencyTarget,
+			LatencyPercentile:  slo.LatencyPercentile,
+			Windows:            slo.Windows,
+			Interval:           slo.Interval,
+		}, monitor.GetGatherer(), a.logger)
+	}
+
+	if alert := a.config.Monitoring.LatencyAlert; alert.Enabled {
+		if !a.monitoringEnabled() {
+			a.logger.Warn("Latency alerts need Prometheus monitoring, disabled")
+			return nil
+		}
+		a.latency = monitoring.NewLatencyAlerter(monitoring.LatencyAlerterConfig{
+			Threshold:  alert.Threshold,
+			Interval:   alert.Interval,
+			Cooldown:   alert.Cooldown,
+			WebhookURL: alert.WebhookURL,
+		}, monitor.GetGatherer(), a.redisClient, a.logger)
+	}
+	return nil
+}
+
+// initSessionStore sets up the session store SESSION_BASED_AUTH logs users
+// in with. Without it the app fails to start, unless SESSION_FALLBACK_TO_JWT
+// lets it authenticate with JWT instead.
+func (a *App) initSessionStore() error {
+	store, err := a.newSessionStore()
+	if err == nil {
+		a.sessions = store
+		return nil
+	}
+
+	if !a.config.Auth.SessionFallbackToJWT {
+		return fmt.Errorf("session store %q unavailable: %w", a.config.Auth.Session.Driver, err)
+	}
+	a.logger.Warn("Session store unavailable, falling back to JWT auth as SESSION_FALLBACK_TO_JWT is set",
+		"driver", a.config.Auth.Session.Driver, "error", err)
+	return nil
+}
+
+// newSessionStore builds the session store used when SESSION_BASED_AUTH is enabled
+func (a *App) newSessionStore() (session.Store, error) {
+	opts := session.Options{
+		Redis: a.redisClient,
+		DB:    a.db,
+	}
+	if a.mongoClient != nil {
+		opts.Mongo = a.mongoClient.Database(a.config.MongoDB.Database)
+	}
+
+	store, err := session.NewSessionStore(a.config.Auth.Session.Driver, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if mongoStore, ok := store.(*session.MongoStore); ok {
+		if err := mongoStore.EnsureIndexes(context.Background()); err != nil {
+			return nil, err
+		}
+	}
+
+	return store, nil
+}
+
+// newEmailValidator builds the registration email validator, loading the
+// disposable domain blocklist when one is configured
+func (a *App) newEmailValidator() *auth.EmailValidator {
+	var blocklist map[string]struct{}
+	if path := a.config.Auth.Account.DisposableEmailBlocklist; path != "" {
+		domains, err := auth.LoadDisposableDomains(path)
+		if err != nil {
+			a.logger.Warn("Disposable email blocklist unavailable", "path", path, "error", err)
+		} else {
+			blocklist = domains
+		}
+	}
+
+	return auth.NewEmailValidator(nil, blocklist)
+}
+
+// newOAuthProviders returns the social login providers with a client ID,
+// keyed by the name of their /auth/:provider route
+func (a *App) newOAuthProviders() map[string]oauth.OAuthProvider {
+	providers := make(map[string]oauth.OAuthProvider)
+	if google := a.config.External.Google; google.ClientID != "" {
+		providers["google"] = oauth.NewGoogleProvider(oauth.Config{
+			ClientID:     google.ClientID,
+			ClientSecret: google.ClientSecret,
+			RedirectURL:  google.RedirectURL,
+		}, oauth.WithHTTPClient(circuitbreaker.NewHTTPClient("google", a.circuitBreakerConfig())))
+	}
+	if github := a.config.External.GitHub; github.ClientID != "" {
+		providers["github"] = oauth.NewGitHubProvider(oauth.Config{
+			ClientID:     github.ClientID,
+			ClientSecret: github.ClientSecret,
+			RedirectURL:  github.RedirectURL,
+		}, oauth.WithHTTPClient(circuitbreaker.NewHTTPClient("github", a.circuitBreakerConfig())))
+	}
+	return providers
+}
+
+func (a *App) abuseDetectorConfig() pkgmiddleware.AbuseDetectorConfig {
+	cfg := a.config.Security.Abuse
+	return pkgmiddleware.AbuseDetectorConfig{
+		ErrorRateThreshold:     cfg.ErrorRateThreshold,
+		ErrorRateWindow:        cfg.ErrorRateWindow,
+		SamePathPerMinute:      cfg.SamePathPerMinute,
+		DistinctPathsPerSecond: cfg.DistinctPathsPerSecond,
+		BlockDuration:          cfg.BlockDuration,
+	}
+}
+
+func (a *App) circuitBreakerConfig() circuitbreaker.CircuitBreakerConfig {
+	cfg := a.config.External.CircuitBreaker
+	return circuitbreaker.CircuitBreakerConfig{
+		FailureThreshold:      cfg.FailureThreshold,
+		SuccessThreshold:      cfg.SuccessThreshold,
+		Timeout:               cfg.Timeout,
+		MaxConcurrentRequests: cfg.MaxConcurrentRequests,
+	}
+}
+
+// loadConfig reads the file named by CONFIG_FILE under the environment
+// variables, or only the environment when it is unset
+func loadConfig() (*config.Config, error) {
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		return config.LoadFromFile(path)
+	}
+	return config.Load()
+}
+
+// newLogger writes to the output LOG_OUTPUT selects and also pushes to Loki
+// when LOG_FORMAT=loki and LOKI_URL is set, labelling the stream with the
+// application name and server mode
+func (a *App) newLogger() *logger.Logger {
+	cfg := a.config.Logging
+
+	output, logFile := logger.NewOutput(&cfg)
+	a.logFile = logFile
+
+	if cfg.Format == logger.FormatLoki && cfg.LokiURL != "" {
+		a.lokiClient = logger.NewLokiPushClient(logger.LokiConfig{
+			URL: cfg.LokiURL,
+			Labels: map[string]string{
+				"app": a.config.App.Name,
+				"env": a.config.Server.Mode,
+			},
+			BatchSize: cfg.LokiBatchSize,
+			BatchWait: cfg.LokiBatchWait,
+		})
+		output = io.MultiWriter(output, a.lokiClient)
+	}
+
+	log := logger.New(cfg.Level, cfg.Format)
+	log.SetOutput(output)
+	return log
+}
+
+// newResponseSizeLimiter returns nil when no response size limit is configured
+func (a *App) newResponseSizeLimiter() gin.HandlerFunc {
+	cfg := a.config.Server
+	if cfg.MaxResponseSize <= 0 && len(cfg.ResponseSizeLimits) == 0 {
+		return nil
+	}
+
+	opts := []pkgmiddleware.ResponseSizeOption{
+		pkgmiddleware.WithTruncationMode(pkgmiddleware.TruncationMode(cfg.ResponseTruncationMode)),
+	}
+	for contentType, limit := range cfg.ResponseSizeLimits {
+		opts = append(opts, pkgmiddleware.WithContentTypeLimit(contentType, limit))
+	}
+
+	return pkgmiddleware.NewResponseSizeLimiter(cfg.MaxResponseSize, opts...)
+}
+
 func (a *App) setupRouter() {
 	if a.config.Server.Mode == "production" {
 		gin.SetMode(gin.ReleaseMode)
@@ -99,11 +422,59 @@ func (a *App) setupRouter() {
 	a.router = gin.New()
 
 	a.router.Use(gin.Recovery())
-	a.router.Use(middleware.Logger(a.logger))
-	a.router.Use(middleware.CORS(&a.config.Server))
+	a.router.Use(middleware.RequestID(a.config.Logging.RequestIDTrustedCIDRs...))
+	// Blocked clients are turned away before any other work, rate limiting included
+	if a.config.Security.Abuse.Enabled && a.redisClient != nil {
+		a.router.Use(pkgmiddleware.NewAbuseDetector(a.redisClient, a.abuseDetectorConfig()))
+	}
+	// Under high load low-priority requests are dropped before doing any work
+	if shedding := a.config.Server.LoadShedding; shedding.Enabled {
+		a.router.Use(pkgmiddleware.NewLoadShedder(shedding.CPUThreshold, shedding.MemoryThreshold,
+			pkgmiddleware.WithPriorityTokens(a.jwtService),
+			pkgmiddleware.WithTrustedPriorityNetworks(shedding.TrustedCIDRs...)))
+		a.registerMetrics(pkgmiddleware.LoadSheddingRate)
+	}
+	if maxPerIP := a.config.Server.MaxConnectionsPerIP; maxPerIP > 0 {
+		a.router.Use(pkgmiddleware.NewConnectionLimiter(maxPerIP))
+	}
+	if a.config.Logging.LogRequests {
+		a.router.Use(middleware.StructuredLogger(a.logger, &a.config.Logging))
+	}
+	a.router.Use(a.monitor.GinMiddleware())
+	a.router.Use(middleware.MaintenanceMode(a.features))
+	if a.config.Server.EnableCORS {
+		a.router.Use(middleware.CORS(a.config.Security.CORS))
+	}
 	a.router.Use(middleware.Security())
+	// Multi-tenant module repositories scope their queries to this tenant
+	a.router.Use(pkgmiddleware.NewTenant())
+	if limiter := a.newResponseSizeLimiter(); limiter != nil {
+		a.router.Use(limiter)
+	}
+	if budget := a.config.Server.MemoryBudget; budget.Enabled {
+		a.router.Use(pkgmiddleware.NewMemoryBudget(budget.MaxBytes, pkgmiddleware.WithBudgetSampleRate(budget.SampleRate)))
+	}
+	if a.config.Server.EnableHTTP2 && len(a.config.Server.HTTP2PushPaths) > 0 {
+		a.router.Use(pkgmiddleware.NewHTTP2Push(a.config.Server.HTTP2PushPaths))
+	}
+	// GET responses are cached in Redis, flushed from /admin/cache/flush
+	var responseCacheFlushHandler gin.HandlerFunc
+	if a.config.Performance.ResponseCaching && a.redisClient != nil {
+		a.router.Use(middleware.ResponseCache(a.redisClient, &a.config.Performance))
+		responseCacheFlushHandler = middleware.NewResponseCacheFlushHandler(a.redisClient)
+	}
 
-	userRepo := postgres.NewUserRepository(a.db)
+	// Queries feed the database metrics and the slow query log
+	var userDB postgres.Executor
+	if a.dbPool != nil {
+		a.dbPool.SetQueryRecorder(a.monitor)
+		userDB = a.dbPool
+	} else {
+		timeoutDB := a.managedDB.WithTimeout(a.config.Database.QueryTimeout, a.logger)
+		timeoutDB.SetQueryRecorder(a.monitor)
+		userDB = timeoutDB
+	}
+	userRepo := postgres.NewUserRepository(userDB)
 
 	var userCacheRepo repositories.UserCacheRepository
 	if a.redisClient != nil {
@@ -112,17 +483,196 @@ func (a *App) setupRouter() {
 
 	userService := services.NewUserService(userRepo, a.jwtService)
 	userService.SetCacheRepository(userCacheRepo)
+	totpService := auth.NewTOTPService(a.config.App.Name, userRepo)
+	userService.SetTOTPService(totpService)
+	// Enrollment keeps the secrets awaiting their first code in Redis
+	var totpHandler *handlers.TOTPHandler
+	if a.redisClient != nil {
+		totpService.SetPendingStore(a.redisClient)
+		totpHandler = handlers.NewTOTPHandler(totpService, a.logger)
+	}
+	if a.config.Features.EmailVerification {
+		userService.SetEmailValidator(a.newEmailValidator())
+	}
+	if a.config.Features.SocialLogin {
+		userService.SetOAuthProviders(a.newOAuthProviders())
+	}
+
+	a.eventBus = eventbus.New()
+	if a.broker != nil {
+		a.publishUserEvents()
+	}
 
 	userHandler := handlers.NewUserHandler(userService, a.logger)
+	userHandler.SetEventBus(a.eventBus)
+
+	if a.sessions != nil {
+		userHandler.SetSessionStore(a.sessions, a.config.Auth.Session.MaxAge, a.config.Auth.Session.CookieName)
+	}
+
+	apiKeyService := auth.NewAPIKeyService(auth.NewPostgresAPIKeyStore(a.db))
+
+	var sessionService *auth.SessionService
+	if a.redisClient != nil {
+		sessionService = auth.NewSessionService(a.redisClient, a.config.Auth.Session)
+		userHandler.SetSessionService(sessionService)
+	}
+
+	var changelogHandler gin.HandlerFunc
+	if versions, err := changelog.ParseFile(a.config.App.ChangelogPath); err != nil {
+		a.logger.Warn("Changelog unavailable, /api/changelog disabled", "error", err)
+	} else {
+		changelogHandler = changelog.NewHandler(versions)
+	}
+
+	var blockedIPsHandler gin.HandlerFunc
+	if a.config.Security.Abuse.Enabled && a.redisClient != nil {
+		blockedIPsHandler = pkgmiddleware.NewBlockedIPsHandler(a.redisClient)
+	}
+
+	var loginAnomalyDetector gin.HandlerFunc
+	if a.redisClient != nil {
+		detector, err := pkgmiddleware.LoginAnomalyDetector(a.redisClient, pkgmiddleware.LoginAnomalyConfig{
+			GeoIP2DatabasePath:    a.config.Security.GeoIP2DatabasePath,
+			BlockSuspiciousLogins: a.config.Security.BlockSuspiciousLogins,
+			Logger:                a.logger,
+		})
+		if err != nil {
+			a.logger.Warn("Login anomaly detection disabled", "error", err)
+		} else {
+			loginAnomalyDetector = detector
+			a.registerMetrics(pkgmiddleware.AnomalousLogins)
+		}
+	}
 
 	routes.SetupRoutes(a.router, &routes.Dependencies{
-		UserHandler: userHandler,
-		JWTService:  a.jwtService,
-		Logger:      a.logger,
-		Config:      a.config,
+		UserHandler:               userHandler,
+		GraphQLHandler:            graph.NewHandler(userService, a.eventBus, a.jwtService),
+		ChangelogHandler:          changelogHandler,
+		BlockedIPsHandler:         blockedIPsHandler,
+		LoginAnomalyDetector:      loginAnomalyDetector,
+		MetricsHandler:            a.metricsHandler(),
+		MetricsSummaryHandler:     a.metricsSummaryHandler(),
+		SLOHandler:                a.sloHandler(),
+		StorageFileHandler:        a.storageFileHandler(),
+		StorageQuotasHandler:      a.storageQuotasHandler(),
+		CircuitBreakersHandler:    circuitbreaker.NewHandler(circuitbreaker.DefaultRegistry),
+		ResponseCacheFlushHandler: responseCacheFlushHandler,
+		ConfigHistory:             a.configLog,
+		Database:                  a.managedDB,
+		TxMiddleware:              pkgmiddleware.NewDBTransaction(a.db, sql.LevelDefault),
+		JWTService:                a.jwtService,
+		SessionStore:              a.sessions,
+		SessionService:            sessionService,
+		APIKeyService:             apiKeyService,
+		APIKeyHandler:             handlers.NewAPIKeyHandler(apiKeyService, a.logger),
+		TOTPHandler:               totpHandler,
+		Logger:                    a.logger,
+		Config:                    a.config,
 	})
 }
 
+// registerMetrics exports collectors of other packages with the monitor's
+// metrics
+func (a *App) registerMetrics(collectors ...prometheus.Collector) {
+	if err := a.monitor.Register(collectors...); err != nil {
+		a.logger.Warn("Failed to register metrics", "error", err)
+	}
+}
+
+// metricsHandler serves the Prometheus metrics, nil when monitoring is off
+func (a *App) metricsHandler() gin.HandlerFunc {
+	if !a.monitoringEnabled() {
+		return nil
+	}
+	return gin.WrapH(a.monitor.GetHandler())
+}
+
+// metricsSummaryHandler serves the latency percentiles, nil when
+// monitoring is off
+func (a *App) metricsSummaryHandler() gin.HandlerFunc {
+	if !a.monitoringEnabled() {
+		return nil
+	}
+	return a.monitor.SummaryHandler
+}
+
+// sloHandler serves the SLO error budgets, nil when monitoring is off
+func (a *App) sloHandler() gin.HandlerFunc {
+	if a.sloTracker == nil {
+		return nil
+	}
+	return a.sloTracker.Handler
+}
+
+// storageFileHandler serves the signed temporary URLs of the local disk,
+// nil unless it is configured without encryption
+func (a *App) storageFileHandler() gin.HandlerFunc {
+	if a.storage == nil {
+		return nil
+	}
+	if server, ok := a.storage.Disk("local").(storage.SignedFileServer); ok {
+		return server.ServeSignedFile
+	}
+	return nil
+}
+
+// initStorageQuotas limits the bytes each owner may store when
+// STORAGE_QUOTA_MB is set. Usage is kept in Redis, so quotas stay off
+// without it.
+func (a *App) initStorageQuotas() {
+	limit := a.config.Storage.QuotaBytes
+	if limit <= 0 {
+		return
+	}
+	if a.redisClient == nil {
+		a.logger.Warn("Redis unavailable, storage quotas disabled")
+		return
+	}
+	a.storage.SetQuotaManager(storage.NewQuotaManager(a.redisClient, limit))
+}
+
+// initStorageDeduplication keeps the index of stored content hashes in
+// Redis, letting PutDeduped store identical content once. It stays off
+// without Redis.
+func (a *App) initStorageDeduplication() {
+	if a.redisClient == nil {
+		a.logger.Warn("Redis unavailable, storage deduplication disabled")
+		return
+	}
+	a.storage.SetDeduplicationIndex(a.redisClient)
+}
+
+// storageQuotasHandler serves the owners storing the most bytes, nil when
+// quotas are disabled
+func (a *App) storageQuotasHandler() gin.HandlerFunc {
+	if a.storage == nil || a.storage.Quotas() == nil {
+		return nil
+	}
+	return storage.NewQuotaHandler(a.storage.Quotas())
+}
+
+func (a *App) monitoringEnabled() bool {
+	return a.config.Monitoring.Enable && a.config.Monitoring.Provider == "prometheus"
+}
+
+// UseCertificate configures a manually provisioned certificate, overriding
+// TLS_CERT_FILE and TLS_KEY_FILE. Used for domains Let's Encrypt cannot reach.
+func (a *App) UseCertificate(certFile, keyFile string) {
+	a.config.Server.TLSCertFile = certFile
+	a.config.Server.TLSKeyFile = keyFile
+}
+
+// Handler returns the HTTP handler serving the application routes
+func (a *App) Handler() http.Handler {
+	return a.router
+}
+
+// Events returns the bus domain events such as user creation are published on
+func (a *App) Events() *eventbus.Bus {
+	return a.eventBus
+}
+
 func (a *App) Run() error {
 	a.server = &http.Server{
 		Addr:         a.config.Server.Host + ":" + a.config.Server.Port,
@@ -132,9 +682,50 @@ func (a *App) Run() error {
 		IdleTimeout:  a.config.Server.IdleTimeout,
 	}
 
+	if err := a.configureTLS(); err != nil {
+		return err
+	}
+
+	var priorityListener net.Listener
+	if a.config.Server.PriorityPortEnabled {
+		a.configurePriorityServer()
+
+		ln, err := listener.Listen(context.Background(), a.priorityServer.Addr, a.config.Server.PrioritySocketPriority)
+		if err != nil {
+			return err
+		}
+		priorityListener = ln
+	}
+
+	var grpcListener net.Listener
+	if a.config.GRPC.Enabled {
+		if err := a.configureGRPC(); err != nil {
+			return err
+		}
+
+		ln, err := net.Listen("tcp", ":"+a.config.GRPC.Port)
+		if err != nil {
+			return fmt.Errorf("failed to listen for gRPC on port %s: %w", a.config.GRPC.Port, err)
+		}
+		grpcListener = ln
+	}
+
+	a.startHealthAlerts()
+	a.startSLOTracking()
+	a.startLatencyAlerts()
+
 	g, ctx := errgroup.WithContext(context.Background())
 
 	g.Go(func() error {
+		if a.server.TLSConfig != nil {
+			a.logger.Info("Starting HTTPS server", "address", a.server.Addr)
+			a.logger.Info("🔒 Server running at: https://"+a.server.Addr)
+			if err := a.server.ListenAndServeTLS("", ""); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		}
+
 		a.logger.Info("Starting HTTP server", "address", a.server.Addr)
 		a.logger.Info("🌐 Server running at: http://"+a.server.Addr)
 		if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
@@ -143,34 +734,372 @@ func (a *App) Run() error {
 		return nil
 	})
 
+	if a.priorityServer != nil {
+		g.Go(func() error {
+			a.logger.Info("Starting priority HTTP server", "address", a.priorityServer.Addr)
+			var err error
+			if a.priorityServer.TLSConfig != nil {
+				err = a.priorityServer.ServeTLS(priorityListener, "", "")
+			} else {
+				err = a.priorityServer.Serve(priorityListener)
+			}
+			if err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+	}
+
+	if a.grpcServer != nil {
+		g.Go(func() error {
+			a.logger.Info("Starting gRPC server", "address", grpcListener.Addr().String())
+			return a.grpcServer.Serve(grpcListener)
+		})
+	}
+
+	if a.httpServer != nil {
+		g.Go(func() error {
+			a.logger.Info("Starting HTTP redirect server", "address", a.httpServer.Addr)
+			if err := a.httpServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				return err
+			}
+			return nil
+		})
+	}
+
 	g.Go(func() error {
 		sigChan := make(chan os.Signal, 1)
-		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+		signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-		select {
-		case sig := <-sigChan:
-			a.logger.Info("Received shutdown signal", "signal", sig)
-			return a.shutdown()
-		case <-ctx.Done():
-			return ctx.Err()
+		for {
+			select {
+			case sig := <-sigChan:
+				if sig == syscall.SIGHUP {
+					a.reloadFeatures(ctx)
+					continue
+				}
+				a.logger.Info("Received shutdown signal", "signal", sig)
+				return a.shutdown()
+			case <-ctx.Done():
+				return ctx.Err()
+			}
 		}
 	})
 
 	return g.Wait()
 }
 
+// reloadFeatures swaps in the current feature flags without a restart
+func (a *App) reloadFeatures(ctx context.Context) {
+	if err := a.features.Reload(ctx); err != nil {
+		a.logger.Error("Failed to reload feature flags", "error", err)
+		return
+	}
+	a.logger.Info("Feature flags reloaded", "maintenance_mode", a.features.IsEnabled("maintenance_mode"))
+}
+
+// configurePriorityServer adds a second server on the priority port sharing
+// the router. Each port gets its own token bucket, so a flood on the public
+// port cannot use up the rate limit of internal services.
+func (a *App) configurePriorityServer() {
+	a.server.Handler = a.listenerHandler(listener.Public, a.config.Security.RateLimit.Global)
+
+	a.priorityServer = &http.Server{
+		Addr:         a.config.Server.Host + ":" + a.config.Server.PriorityPort,
+		Handler:      a.listenerHandler(listener.Priority, a.config.Security.RateLimit.Priority),
+		ReadTimeout:  a.config.Server.ReadTimeout,
+		WriteTimeout: a.config.Server.WriteTimeout,
+		IdleTimeout:  a.config.Server.IdleTimeout,
+		TLSConfig:    a.server.TLSConfig,
+	}
+}
+
+// listenerHandler tags requests with the listener name and limits them to
+// rate requests per second; a zero rate does not limit
+func (a *App) listenerHandler(name string, rate int) http.Handler {
+	var bucket *listener.TokenBucket
+	if rate > 0 {
+		bucket = listener.NewTokenBucket(float64(rate), rate)
+	}
+	return listener.WithName(name, bucket.Limit(a.router))
+}
+
+// initJWTService creates the JWT service, signing with the RSA key pair for
+// the RS algorithms and with the secret for the HS ones
+func (a *App) initJWTService() error {
+	cfg := a.config.Auth.JWT
+	expiration := int(cfg.Expiration.Seconds())
+
+	if !strings.HasPrefix(cfg.Algorithm, "RS") {
+		jwtService, err := auth.NewHMACJWTService(cfg.Algorithm, cfg.Secret, expiration)
+		if err != nil {
+			return err
+		}
+		a.jwtService = jwtService
+		return nil
+	}
+
+	jwtService, err := auth.NewRSAJWTService(cfg.Algorithm, cfg.PrivateKeyFile, cfg.PublicKeyFile, expiration)
+	if err != nil {
+		return err
+	}
+	if cfg.PreviousPublicKeyFile != "" {
+		if err := jwtService.AcceptRetiringKey(cfg.PreviousPublicKeyFile, cfg.KeyRotationWindow); err != nil {
+			return err
+		}
+	}
+	a.jwtService = jwtService
+	return nil
+}
+
+// loadTLS builds the certificate source of the server settings once: the
+// manual certificate, or Let's Encrypt with HTTPS_DOMAIN. The HTTPS servers
+// and the gRPC server share the result, so every port serves the same
+// certificate. tlsConfig stays nil when neither is configured.
+func (a *App) loadTLS() error {
+	if a.tlsConfig != nil {
+		return nil
+	}
+
+	cfg := &a.config.Server
+
+	switch {
+	case cfg.TLSCertFile != "" && cfg.TLSKeyFile != "":
+		tlsConfig, err := tlsutil.NewStaticConfig(cfg.TLSCertFile, cfg.TLSKeyFile)
+		if err != nil {
+			return err
+		}
+		a.tlsConfig = tlsConfig
+
+	case cfg.HTTPSDomain != "":
+		manager, err := tlsutil.NewAutoCertManager(cfg.HTTPSDomain, cfg.TLSCacheDir)
+		if err != nil {
+			return err
+		}
+		a.certManager = manager
+		a.tlsConfig = manager.TLSConfig()
+	}
+
+	return nil
+}
+
+// GRPCServerOptions returns the options that make a gRPC server present the
+// HTTPS certificate, or none when HTTPS is off
+func (a *App) GRPCServerOptions() ([]grpc.ServerOption, error) {
+	if err := a.loadTLS(); err != nil {
+		return nil, err
+	}
+	if a.tlsConfig == nil {
+		return nil, nil
+	}
+	return []grpc.ServerOption{tlsutil.GRPCServerOption(a.tlsConfig)}, nil
+}
+
+// configureGRPC builds the gRPC server for GRPC_PORT with the GRPC_* limits
+// and the HTTPS certificate. It serves the standard health checking service.
+func (a *App) configureGRPC() error {
+	opts, err := a.GRPCServerOptions()
+	if err != nil {
+		return err
+	}
+
+	server, err := bootstrap.NewGRPCServer(&a.config.GRPC, opts...)
+	if err != nil {
+		return err
+	}
+	healthpb.RegisterHealthServer(server, health.NewServer())
+
+	a.grpcServer = server
+	return nil
+}
+
+// configureTLS switches the server to HTTPS when a certificate source is
+// configured. With HTTPS_DOMAIN set, certificates come from Let's Encrypt and
+// port 80 answers ACME challenges and redirects everything else to HTTPS.
+// With a manual certificate, ENABLE_HTTP2 serves HTTP/2 and
+// HTTP_REDIRECT_PORT redirects plain HTTP to HTTPS.
+func (a *App) configureTLS() error {
+	if err := a.loadTLS(); err != nil {
+		return err
+	}
+
+	cfg := &a.config.Server
+
+	switch {
+	case a.certManager != nil:
+		a.server.Addr = cfg.Host + ":443"
+		a.server.TLSConfig = a.tlsConfig
+		a.httpServer = &http.Server{
+			Addr:         cfg.Host + ":80",
+			Handler:      a.certManager.HTTPHandler(tlsutil.RedirectHandler()),
+			ReadTimeout:  cfg.ReadTimeout,
+			WriteTimeout: cfg.WriteTimeout,
+			IdleTimeout:  cfg.IdleTimeout,
+		}
+
+	case a.tlsConfig != nil:
+		// http2.ConfigureServer adds h2 to the server's NextProtos, so it
+		// gets a copy rather than the config shared with gRPC
+		a.server.TLSConfig = a.tlsConfig.Clone()
+
+		if cfg.EnableHTTP2 {
+			if err := http2.ConfigureServer(a.server, &http2.Server{IdleTimeout: cfg.IdleTimeout}); err != nil {
+				return err
+			}
+		}
+		if cfg.HTTPRedirectPort != "" {
+			a.httpServer = &http.Server{
+				Addr:         cfg.Host + ":" + cfg.HTTPRedirectPort,
+				Handler:      tlsutil.RedirectToPort(cfg.Port),
+				ReadTimeout:  cfg.ReadTimeout,
+				WriteTimeout: cfg.WriteTimeout,
+				IdleTimeout:  cfg.IdleTimeout,
+			}
+		}
+	}
+
+	return nil
+}
+
+// startHealthAlerts checks the database and Redis in the background and
+// alerts Slack or PagerDuty when one of them starts failing
+func (a *App) startHealthAlerts() {
+	cfg := a.config.Monitoring.HealthAlert
+	if !cfg.Enabled {
+		return
+	}
+
+	checker := alerting.NewHealthChecker(cfg.CheckInterval, cfg.CheckTimeout)
+	checker.AddCheck("database", a.db.PingContext)
+	if a.redisClient != nil {
+		checker.AddCheck("redis", func(ctx context.Context) error {
+			return a.redisClient.Ping(ctx).Err()
+		})
+	}
+
+	notifier := alerting.NewNotifier(alerting.NotifierConfig{
+		SlackWebhookURL:       cfg.SlackWebhookURL,
+		PagerDutyRoutingKey:   cfg.PagerDutyRoutingKey,
+		AlertDebounceInterval: cfg.AlertDebounceInterval,
+		Source:                a.config.App.Name,
+	}, a.logger)
+	notifier.SetHTTPClients(
+		circuitbreaker.NewHTTPClient("slack", a.circuitBreakerConfig()),
+		circuitbreaker.NewHTTPClient("pagerduty", a.circuitBreakerConfig()),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopHealthAlerts = cancel
+
+	go notifier.Subscribe(ctx, checker.Subscribe())
+	go checker.Start(ctx)
+}
+
+// startSLOTracking samples the HTTP metrics every SLO_EVALUATION_INTERVAL,
+// warning when an error budget starts burning too fast
+func (a *App) startSLOTracking() {
+	if a.sloTracker == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopSLOTracking = cancel
+
+	go a.sloTracker.Start(ctx)
+}
+
+// startLatencyAlerts checks the p99 latency every LATENCY_ALERT_INTERVAL,
+// alerting when it exceeds LATENCY_ALERT_THRESHOLD_MS
+func (a *App) startLatencyAlerts() {
+	if a.latency == nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopLatencyAlert = cancel
+
+	go a.latency.Start(ctx)
+}
+
 func (a *App) shutdown() error {
 	a.logger.Info("Shutting down application...")
 
 	ctx, cancel := context.WithTimeout(context.Background(), a.config.Server.ShutdownTimeout)
 	defer cancel()
 
-	if err := a.server.Shutdown(ctx); err != nil {
-		a.logger.Error("Failed to shutdown HTTP server", "error", err)
+	// The servers drain in parallel within the same shutdown timeout
+	drain, drainCtx := errgroup.WithContext(ctx)
+	drain.Go(func() error {
+		if err := a.server.Shutdown(drainCtx); err != nil {
+			a.logger.Error("Failed to shutdown HTTP server", "error", err)
+			return err
+		}
+		return nil
+	})
+	if a.priorityServer != nil {
+		drain.Go(func() error {
+			if err := a.priorityServer.Shutdown(drainCtx); err != nil {
+				a.logger.Error("Failed to shutdown priority HTTP server", "error", err)
+				return err
+			}
+			return nil
+		})
+	}
+	if a.grpcServer != nil {
+		drain.Go(func() error {
+			stopped := make(chan struct{})
+			go func() {
+				a.grpcServer.GracefulStop()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+			case <-drainCtx.Done():
+				// Cut off the RPCs still running past the timeout
+				a.grpcServer.Stop()
+				a.logger.Error("Failed to shutdown gRPC server gracefully", "error", drainCtx.Err())
+			}
+			return nil
+		})
+	}
+	if err := drain.Wait(); err != nil {
 		return err
 	}
 
-	if a.db != nil {
+	// The redirect server also answers ACME challenges, so it is stopped last
+	// to let in-flight certificate validations complete.
+	if a.httpServer != nil {
+		if err := a.httpServer.Shutdown(ctx); err != nil {
+			a.logger.Error("Failed to shutdown HTTP redirect server", "error", err)
+			return err
+		}
+	}
+
+	if a.stopHealthAlerts != nil {
+		a.stopHealthAlerts()
+	}
+
+	if a.stopSLOTracking != nil {
+		a.stopSLOTracking()
+	}
+
+	if a.stopLatencyAlert != nil {
+		a.stopLatencyAlert()
+	}
+
+	if a.stopUserEvents != nil {
+		a.stopUserEvents()
+	}
+	if a.broker != nil {
+		if err := a.broker.Close(); err != nil {
+			a.logger.Error("Failed to close message broker", "error", err)
+		}
+	}
+
+	if a.dbPool != nil {
+		a.dbPool.Close()
+	} else if a.db != nil {
 		a.db.Close()
 	}
 
@@ -178,6 +1107,18 @@ func (a *App) shutdown() error {
 		a.redisClient.Close()
 	}
 
+	if a.mongoClient != nil {
+		a.mongoClient.Disconnect(ctx)
+	}
+
 	a.logger.Info("Application shutdown complete")
+
+	// Last, so the lines above are pushed and written too
+	if a.lokiClient != nil {
+		a.lokiClient.Close()
+	}
+	if a.logFile != nil {
+		a.logFile.Close()
+	}
 	return nil
 }
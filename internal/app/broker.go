@@ -0,0 +1,84 @@
+package app
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/eventbus"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker/drivers"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker/events"
+)
+
+// initMessageBroker connects the broker drivers MESSAGE_BROKER_DRIVER and
+// the sink and dead-letter settings select
+func (a *App) initMessageBroker() error {
+	cfg, err := a.messageBrokerConfig()
+	if err != nil {
+		return err
+	}
+
+	broker, err := messagebroker.NewManager(cfg)
+	if err != nil {
+		return err
+	}
+	if a.redisClient != nil {
+		broker.SetIdempotencyStore(messagebroker.NewIdempotencyStore(a.redisClient))
+	}
+
+	a.broker = broker
+	a.registerMetrics(drivers.ConsumerConcurrency)
+	a.logger.Info("Message broker connected", "driver", cfg.Driver)
+	return nil
+}
+
+// messageBrokerConfig converts the application settings to the broker's
+// own config types, which carry the same JSON field names
+func (a *App) messageBrokerConfig() (*messagebroker.MessageBrokerConfig, error) {
+	data, err := json.Marshal(a.config.MessageBroker)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg messagebroker.MessageBrokerConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// publishUserEvents forwards users created on the event bus to the broker,
+// where consumers such as the welcome email job pick them up
+func (a *App) publishUserEvents() {
+	created, unsubscribe := a.eventBus.Subscribe(eventbus.UserCreated)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	a.stopUserEvents = func() {
+		cancel()
+		unsubscribe()
+	}
+
+	publisher := events.NewUserEventPublisher(a.broker)
+	go func() {
+		for event := range created {
+			user, ok := event.Payload.(*entities.User)
+			if !ok {
+				continue
+			}
+
+			err := publisher.PublishUserCreated(ctx, events.UserCreatedData{
+				UserEventData: events.UserEventData{Email: user.Email},
+				Role:          user.Role,
+			})
+			if err != nil {
+				a.logger.Error("Failed to publish user created event", "email", user.Email, "error", err)
+			}
+		}
+	}()
+}
+
+// Broker returns the message broker, or nil when MESSAGE_BROKER_ENABLED is off
+func (a *App) Broker() *messagebroker.Manager {
+	return a.broker
+}
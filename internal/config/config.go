@@ -1,8 +1,11 @@
 package config
 
 import (
+	"errors"
 	"fmt"
+	"net"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -34,14 +37,18 @@ type Config struct {
 	Notification  NotificationConfig
 	ELK           ELKConfig
 	GRPC          GRPCConfig
+	Bootstrap     BootstrapConfig
+	Secrets       SecretsConfig
 }
 
 type AppConfig struct {
-	Name        string
-	Version     string
-	Description string
-	Author      string
-	License     string
+	Name          string
+	Version       string
+	Description   string
+	Author        string
+	License       string
+	ChangelogPath string
+	Profile       string // CONFIG_PROFILE the configuration was loaded with
 }
 
 type ServerConfig struct {
@@ -56,7 +63,54 @@ type ServerConfig struct {
 	EnablePprof     bool
 	EnableMetrics   bool
 	EnableSwagger   bool
+	EnableGraphQL   bool
 	EnableCORS      bool
+	HTTPSDomain     string
+	TLSCacheDir     string
+	TLSCertFile     string
+	TLSKeyFile      string
+
+	// HTTP/2 over TLS, pushing the assets listed per route pattern
+	EnableHTTP2    bool
+	HTTP2PushPaths map[string][]string
+
+	// Plain HTTP port redirecting to HTTPS when serving a TLS certificate
+	HTTPRedirectPort string
+
+	// Response size limiting; a zero MaxResponseSize disables it
+	MaxResponseSize        int64
+	ResponseTruncationMode string
+	ResponseSizeLimits     map[string]int64
+
+	LoadShedding LoadSheddingConfig
+	MemoryBudget MemoryBudgetConfig
+
+	// Requests one client IP may have in flight; zero disables the limit
+	MaxConnectionsPerIP int
+
+	// A second listener for internal services, served with a higher socket
+	// priority and its own rate limit; admin routes move to it
+	PriorityPortEnabled    bool
+	PriorityPort           string
+	PrioritySocketPriority int
+}
+
+// LoadSheddingConfig holds the system usage percentages above which
+// low-priority requests start being dropped
+type LoadSheddingConfig struct {
+	Enabled         bool
+	CPUThreshold    float64
+	MemoryThreshold float64
+	// TrustedCIDRs are the networks whose X-Priority header is honoured
+	TrustedCIDRs []string
+}
+
+// MemoryBudgetConfig holds the heap growth a request may cause before it is
+// failed with 507, checked on one request in SampleRate
+type MemoryBudgetConfig struct {
+	Enabled    bool
+	MaxBytes   uint64
+	SampleRate int
 }
 
 type DatabaseConfig struct {
@@ -73,6 +127,30 @@ type DatabaseConfig struct {
 	QueryTimeout    time.Duration
 	AutoMigrate     bool
 	MigrationPath   string
+	// OptimisticLocking makes the generator add a version column to entity
+	// tables, incremented by every UPDATE
+	OptimisticLocking bool
+	// Replicas are reached with the primary's credentials and database.
+	// SELECT queries are spread over the healthy ones by weight.
+	Replicas []ReplicaConfig
+	// ReplicaHealthCheckInterval is how often every server is pinged
+	ReplicaHealthCheckInterval time.Duration
+	// ReplicaFailureThreshold is the number of consecutive failed pings
+	// taking a server out of rotation until a ping succeeds again
+	ReplicaFailureThreshold int
+	// HealthCheck is how often a managed connection is pinged, and how many
+	// consecutive failed pings make it reconnect
+	HealthCheck HealthCheckConfig
+}
+
+// ReplicaConfig is a database server next to the primary. Replicas that are
+// not ReadOnly can be promoted and take the writes while the primary is
+// unhealthy.
+type ReplicaConfig struct {
+	Host     string
+	Port     string
+	Weight   int
+	ReadOnly bool
 }
 
 type RedisConfig struct {
@@ -115,10 +193,14 @@ type ElasticConfig struct {
 }
 
 type AuthConfig struct {
-	JWT      JWTConfig
-	Session  SessionConfig
-	Password PasswordConfig
-	Account  AccountConfig
+	JWT              JWTConfig
+	Session          SessionConfig
+	Password         PasswordConfig
+	Account          AccountConfig
+	SessionBasedAuth bool
+	// SessionFallbackToJWT starts the app with JWT auth when the session
+	// store cannot be set up, instead of failing
+	SessionFallbackToJWT bool
 }
 
 type JWTConfig struct {
@@ -127,14 +209,23 @@ type JWTConfig struct {
 	RefreshExpiration time.Duration
 	Issuer            string
 	Algorithm         string
+	PrivateKeyFile    string
+	PublicKeyFile     string
+
+	// Public key of the RSA key pair used before the current one, accepted
+	// for KeyRotationWindow after startup
+	PreviousPublicKeyFile string
+	KeyRotationWindow     time.Duration
 }
 
 type SessionConfig struct {
-	Secret   string
-	MaxAge   time.Duration
-	Secure   bool
-	HTTPOnly bool
-	SameSite string
+	Driver     string
+	Secret     string
+	MaxAge     time.Duration
+	Secure     bool
+	HTTPOnly   bool
+	SameSite   string
+	CookieName string
 }
 
 type PasswordConfig struct {
@@ -151,13 +242,22 @@ type AccountConfig struct {
 	LockoutDuration           time.Duration
 	PasswordResetExpiry       time.Duration
 	EmailVerificationRequired bool
+	DisposableEmailBlocklist  string
 }
 
 type SecurityConfig struct {
 	RateLimit RateLimitConfig
+	Abuse     AbuseDetectionConfig
 	IP        IPSecurityConfig
 	Headers   SecurityHeadersConfig
 	CSRF      CSRFConfig
+	CORS      CORSConfig
+	// GeoIP2DatabasePath is a MaxMind database used to report the country
+	// of logins from unusual IPs
+	GeoIP2DatabasePath string
+	// BlockSuspiciousLogins rejects logins from IPs the user does not
+	// usually log in from
+	BlockSuspiciousLogins bool
 }
 
 type RateLimitConfig struct {
@@ -165,6 +265,19 @@ type RateLimitConfig struct {
 	Auth   int
 	API    int
 	Public int
+	// Priority is the requests per second allowed on the priority port;
+	// Global applies to the public port while it is enabled
+	Priority int
+}
+
+// AbuseDetectionConfig holds the request patterns that get a client IP blocked
+type AbuseDetectionConfig struct {
+	Enabled                bool
+	ErrorRateThreshold     float64
+	ErrorRateWindow        int
+	SamePathPerMinute      int
+	DistinctPathsPerSecond int
+	BlockDuration          time.Duration
 }
 
 type IPSecurityConfig struct {
@@ -186,6 +299,19 @@ type CSRFConfig struct {
 	HTTPOnly bool
 }
 
+// CORSConfig controls which cross-origin browser requests are answered
+type CORSConfig struct {
+	// Exact origins, wildcard subdomains like https://*.example.com, or "*"
+	AllowedOrigins []string
+	AllowedMethods []string
+	// Empty allows whatever headers a preflight request asks for
+	AllowedHeaders   []string
+	ExposedHeaders   []string
+	AllowCredentials bool
+	// How long browsers may cache a preflight response
+	MaxAge time.Duration
+}
+
 type LoggingConfig struct {
 	Level       string
 	Format      string
@@ -198,6 +324,15 @@ type LoggingConfig struct {
 	LogRequests bool
 	LogHeaders  bool
 	LogBody     bool
+
+	// RequestIDTrustedCIDRs are the upstream networks, such as load
+	// balancers, whose X-Request-ID header is kept instead of generating one
+	RequestIDTrustedCIDRs []string
+
+	// Pushing to Loki, used with LOG_FORMAT=loki
+	LokiURL       string
+	LokiBatchSize int
+	LokiBatchWait time.Duration
 }
 
 type EmailConfig struct {
@@ -244,14 +379,19 @@ type StorageConfig struct {
 	BackblazeB2      BackblazeB2Config
 	GCS              GCSConfig
 	Azure            AzureConfig
+	Encryption       StorageEncryptionConfig
+	Scanner          ScannerConfig
+	ChunkSize        int64 // Part size of chunked uploads in bytes
+	QuotaBytes       int64 // Bytes each user or tenant may store, 0 disables quotas
 	MaxUploadSizeMB  int
 	AllowedFileTypes []string
 	UploadPath       string
 }
 
 type LocalStorageConfig struct {
-	Path      string
-	URLPrefix string
+	Path       string
+	URLPrefix  string
+	SigningKey string // HMAC key of temporary URLs, random per process when empty
 }
 
 type S3Config struct {
@@ -300,10 +440,45 @@ type AzureConfig struct {
 	Container string
 }
 
+// StorageEncryptionConfig enables AES-256-GCM encryption of stored files.
+// The master key comes from KMSEncryptedKey when set, otherwise KeyFile.
+type StorageEncryptionConfig struct {
+	Enabled         bool
+	KeyFile         string
+	KMSEncryptedKey string
+	KMSRegion       string
+}
+
+// ScannerConfig configures the virus scan of uploaded files
+type ScannerConfig struct {
+	Enabled          bool
+	Engine           string // clamav or virustotal
+	ClamAVSocket     string // unix socket path, or host:port for TCP
+	VirusTotalAPIKey string
+	Timeout          time.Duration
+}
+
 type ExternalConfig struct {
 	Stripe StripeConfig
 	Google GoogleConfig
+	GitHub GitHubConfig
 	Social SocialConfig
+	// CircuitBreaker applies to every outbound HTTP client of the app
+	CircuitBreaker CircuitBreakerConfig
+}
+
+// CircuitBreakerConfig holds when outbound HTTP calls to a failing service
+// stop being sent and when they are tried again
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the consecutive failures that open the breaker
+	FailureThreshold int
+	// SuccessThreshold is the consecutive successful trial requests that
+	// close it again
+	SuccessThreshold int
+	// Timeout is how long the breaker stays open before trial requests
+	Timeout time.Duration
+	// MaxConcurrentRequests caps requests in flight, 0 for no limit
+	MaxConcurrentRequests int
 }
 
 type StripeConfig struct {
@@ -321,6 +496,13 @@ type GoogleConfig struct {
 	AnalyticsID  string
 }
 
+// GitHubConfig is the OAuth application used for GitHub social login
+type GitHubConfig struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
 type SocialConfig struct {
 	TwitterAPIKey    string
 	TwitterAPISecret string
@@ -337,17 +519,50 @@ type LegacyNotificationConfig struct {
 }
 
 type MonitoringConfig struct {
-	Enable     bool
-	Provider   string
-	Prometheus PrometheusConfig
-	DataDog    DataDogConfig
-	NewRelic   NewRelicConfig
-	Sentry     SentryConfig
+	Enable       bool
+	Provider     string
+	Prometheus   PrometheusConfig
+	DataDog      DataDogConfig
+	NewRelic     NewRelicConfig
+	Sentry       SentryConfig
+	LatencyAlert LatencyAlertConfig
+	HealthAlert  HealthAlertConfig
+	SLO          SLOConfig
+}
+
+type LatencyAlertConfig struct {
+	Enabled    bool
+	Threshold  time.Duration
+	Interval   time.Duration
+	Cooldown   time.Duration
+	WebhookURL string
+}
+
+// HealthAlertConfig controls alerts for failing health checks
+type HealthAlertConfig struct {
+	Enabled               bool
+	SlackWebhookURL       string
+	PagerDutyRoutingKey   string
+	AlertDebounceInterval time.Duration
+	CheckInterval         time.Duration
+	CheckTimeout          time.Duration
+}
+
+// SLOConfig holds the service level objectives error budgets are tracked for
+type SLOConfig struct {
+	AvailabilityTarget float64
+	LatencyTarget      time.Duration
+	LatencyPercentile  float64
+	Windows            []time.Duration
+	Interval           time.Duration
 }
 
 type PrometheusConfig struct {
 	Namespace   string
 	MetricsPath string
+	// SlowQueryThreshold is the duration past which database operations are
+	// counted and logged as slow, zero disabling it
+	SlowQueryThreshold time.Duration
 }
 
 type DataDogConfig struct {
@@ -381,6 +596,11 @@ type FeatureConfig struct {
 	FileUpload        bool
 	ImageProcessing   bool
 	ContentModeration bool
+
+	// Store is where reloads read the flags from: "env", or "redis" to
+	// override them with the fields of the RedisKey hash
+	Store    string
+	RedisKey string
 }
 
 type DevelopmentConfig struct {
@@ -413,6 +633,14 @@ type PerformanceConfig struct {
 	AssetCacheDuration time.Duration
 	GzipCompression    bool
 	AssetMinification  bool
+	Warming            WarmingConfig
+}
+
+// WarmingConfig controls which caches are pre-populated at startup
+type WarmingConfig struct {
+	Enabled    bool
+	Entities   []string
+	MaxRecords int
 }
 
 type BackupConfig struct {
@@ -454,12 +682,30 @@ type CustomConfig struct {
 
 // MessageBrokerConfig holds configuration for message brokers
 type MessageBrokerConfig struct {
-	Enabled  bool               `json:"enabled" mapstructure:"enabled"`
-	Driver   string             `json:"driver" mapstructure:"driver"`
-	RabbitMQ *RabbitMQConfig    `json:"rabbitmq,omitempty" mapstructure:"rabbitmq"`
-	Kafka    *KafkaConfig       `json:"kafka,omitempty" mapstructure:"kafka"`
-	Redis    *RedisPubSubConfig `json:"redis,omitempty" mapstructure:"redis"`
-	Retry    *RetryConfig       `json:"retry,omitempty" mapstructure:"retry"`
+	Enabled     bool               `json:"enabled" mapstructure:"enabled"`
+	Driver      string             `json:"driver" mapstructure:"driver"`
+	RabbitMQ    *RabbitMQConfig    `json:"rabbitmq,omitempty" mapstructure:"rabbitmq"`
+	Kafka       *KafkaConfig       `json:"kafka,omitempty" mapstructure:"kafka"`
+	Redis       *RedisPubSubConfig `json:"redis,omitempty" mapstructure:"redis"`
+	Retry       *RetryConfig       `json:"retry,omitempty" mapstructure:"retry"`
+	TopicPrefix string             `json:"topic_prefix" mapstructure:"topic_prefix"`
+	Sink        *SinkConfig        `json:"sink,omitempty" mapstructure:"sink"`
+	DeadLetter  *DeadLetterConfig  `json:"dead_letter,omitempty" mapstructure:"dead_letter"`
+}
+
+// DeadLetterConfig routes messages that failed all their retries to a
+// dead-letter topic, published by Driver or else the driver they failed on
+type DeadLetterConfig struct {
+	Enabled bool   `json:"enabled" mapstructure:"enabled"`
+	Topic   string `json:"topic" mapstructure:"topic"`
+	Driver  string `json:"driver,omitempty" mapstructure:"driver"`
+}
+
+// SinkConfig selects the brokers critical messages are written to at once
+// and how many must accept each write: all, majority or any
+type SinkConfig struct {
+	Drivers []string `json:"drivers" mapstructure:"drivers"`
+	Quorum  string   `json:"quorum" mapstructure:"quorum"`
 }
 
 // RabbitMQConfig holds RabbitMQ-specific configuration
@@ -477,6 +723,7 @@ type RabbitMQConfig struct {
 	PrefetchCount     int           `json:"prefetch_count" mapstructure:"prefetch_count"`
 	Durable           bool          `json:"durable" mapstructure:"durable"`
 	AutoDelete        bool          `json:"auto_delete" mapstructure:"auto_delete"`
+	MaxMessageBytes   int           `json:"max_message_bytes" mapstructure:"max_message_bytes"`
 }
 
 // KafkaConfig holds Kafka-specific configuration
@@ -496,24 +743,37 @@ type KafkaConfig struct {
 	EnableAutoCommit   bool          `json:"enable_auto_commit" mapstructure:"enable_auto_commit"`
 	AutoCommitInterval time.Duration `json:"auto_commit_interval" mapstructure:"auto_commit_interval"`
 	InitialOffset      string        `json:"initial_offset" mapstructure:"initial_offset"`
+	MaxMessageBytes    int           `json:"max_message_bytes" mapstructure:"max_message_bytes"`
 	SASL               *SASLConfig   `json:"sasl,omitempty" mapstructure:"sasl"`
 	TLS                *TLSConfig    `json:"tls,omitempty" mapstructure:"tls"`
+	SchemaRegistryURL  string        `json:"schema_registry_url" mapstructure:"schema_registry_url"`
 }
 
 // RedisPubSubConfig holds Redis Pub/Sub configuration
 type RedisPubSubConfig struct {
-	Host           string        `json:"host" mapstructure:"host"`
-	Port           int           `json:"port" mapstructure:"port"`
-	Password       string        `json:"password" mapstructure:"password"`
-	DB             int           `json:"db" mapstructure:"db"`
-	PoolSize       int           `json:"pool_size" mapstructure:"pool_size"`
-	MinIdleConns   int           `json:"min_idle_conns" mapstructure:"min_idle_conns"`
-	MaxRetries     int           `json:"max_retries" mapstructure:"max_retries"`
-	ConnectTimeout time.Duration `json:"connect_timeout" mapstructure:"connect_timeout"`
-	ReadTimeout    time.Duration `json:"read_timeout" mapstructure:"read_timeout"`
-	WriteTimeout   time.Duration `json:"write_timeout" mapstructure:"write_timeout"`
-	IdleTimeout    time.Duration `json:"idle_timeout" mapstructure:"idle_timeout"`
-	TLS            *TLSConfig    `json:"tls,omitempty" mapstructure:"tls"`
+	Host            string                 `json:"host" mapstructure:"host"`
+	Port            int                    `json:"port" mapstructure:"port"`
+	Password        string                 `json:"password" mapstructure:"password"`
+	DB              int                    `json:"db" mapstructure:"db"`
+	PoolSize        int                    `json:"pool_size" mapstructure:"pool_size"`
+	MinIdleConns    int                    `json:"min_idle_conns" mapstructure:"min_idle_conns"`
+	MaxRetries      int                    `json:"max_retries" mapstructure:"max_retries"`
+	ConnectTimeout  time.Duration          `json:"connect_timeout" mapstructure:"connect_timeout"`
+	ReadTimeout     time.Duration          `json:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration          `json:"write_timeout" mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration          `json:"idle_timeout" mapstructure:"idle_timeout"`
+	MaxMessageBytes int                    `json:"max_message_bytes" mapstructure:"max_message_bytes"`
+	TLS             *TLSConfig             `json:"tls,omitempty" mapstructure:"tls"`
+	Consumer        *ConsumerScalingConfig `json:"consumer,omitempty" mapstructure:"consumer"`
+}
+
+// ConsumerScalingConfig bounds the number of concurrent handlers a
+// subscription scales between, based on its processing lag
+type ConsumerScalingConfig struct {
+	MinConcurrency int           `json:"min_concurrency" mapstructure:"min_concurrency"`
+	MaxConcurrency int           `json:"max_concurrency" mapstructure:"max_concurrency"`
+	ScaleInterval  time.Duration `json:"scale_interval" mapstructure:"scale_interval"`
+	ScaleUpLag     int           `json:"scale_up_lag" mapstructure:"scale_up_lag"`
 }
 
 // RetryConfig holds retry configuration for failed messages/jobs
@@ -582,6 +842,20 @@ type ELKConfig struct {
 	BatchWait   string   `json:"batch_wait" mapstructure:"batch_wait"`
 }
 
+// BootstrapConfig holds settings of the enterprise module bootstrap
+type BootstrapConfig struct {
+	// DrainTimeout is how long each module gets to finish in-flight work on
+	// shutdown before it is shut down anyway
+	DrainTimeout time.Duration `json:"drain_timeout" mapstructure:"drain_timeout"`
+}
+
+// SecretsConfig selects the secrets manager $SECRET:<key> configuration
+// values are fetched from
+type SecretsConfig struct {
+	Provider string // aws-ssm, vault or gcp-secret-manager; empty to disable
+	Prefix   string // prepended to every key, e.g. /myapp/prod/
+}
+
 // GRPCConfig holds gRPC server configuration
 type GRPCConfig struct {
 	Enabled               bool              `json:"enabled" mapstructure:"enabled"`
@@ -594,6 +868,7 @@ type GRPCConfig struct {
 	KeepAliveTime         time.Duration     `json:"keep_alive_time" mapstructure:"keep_alive_time"`
 	KeepAliveTimeout      time.Duration     `json:"keep_alive_timeout" mapstructure:"keep_alive_timeout"`
 	TLS                   *GRPCTLSConfig    `json:"tls,omitempty" mapstructure:"tls"`
+	MTLS                  *GRPCMTLSConfig   `json:"mtls,omitempty" mapstructure:"mtls"`
 	Reflection            bool              `json:"reflection" mapstructure:"reflection"`
 	Gateway               GRPCGatewayConfig `json:"gateway" mapstructure:"gateway"`
 }
@@ -605,6 +880,15 @@ type GRPCTLSConfig struct {
 	KeyFile  string `json:"key_file" mapstructure:"key_file"`
 }
 
+// GRPCMTLSConfig holds the client certificate used for mutual TLS on
+// outbound gRPC calls
+type GRPCMTLSConfig struct {
+	Enable   bool   `json:"enable" mapstructure:"enable"`
+	CertFile string `json:"cert_file" mapstructure:"cert_file"`
+	KeyFile  string `json:"key_file" mapstructure:"key_file"`
+	CAFile   string `json:"ca_file" mapstructure:"ca_file"`
+}
+
 // GRPCGatewayConfig holds gRPC-Gateway configuration
 type GRPCGatewayConfig struct {
 	Enabled bool   `json:"enabled" mapstructure:"enabled"`
@@ -774,18 +1058,33 @@ type SendGridConfig struct {
 	Timeout     int    `json:"timeout" mapstructure:"timeout"`
 }
 
+// Load reads the configuration from environment variables, loading a .env
+// file into the environment first when there is one. When CONFIG_PROFILE is
+// set it loads that profile, see LoadProfile.
 func Load() (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if profile := os.Getenv("CONFIG_PROFILE"); profile != "" {
+		return loadProfile(profile)
+	}
+	return load()
+}
+
+func load() (*Config, error) {
 	if err := godotenv.Load(); err != nil {
 		fmt.Println("No .env file found, using environment variables")
 	}
 
 	config := &Config{
 		App: AppConfig{
-			Name:        getEnv("APP_NAME", "Go Template"),
-			Version:     getEnv("APP_VERSION", "1.0.0"),
-			Description: getEnv("APP_DESCRIPTION", "Professional Go application template"),
-			Author:      getEnv("APP_AUTHOR", "Your Company"),
-			License:     getEnv("APP_LICENSE", "MIT"),
+			Name:          getEnv("APP_NAME", "Go Template"),
+			Version:       getEnv("APP_VERSION", "1.0.0"),
+			Description:   getEnv("APP_DESCRIPTION", "Professional Go application template"),
+			Author:        getEnv("APP_AUTHOR", "Your Company"),
+			License:       getEnv("APP_LICENSE", "MIT"),
+			ChangelogPath: getEnv("CHANGELOG_PATH", "CHANGELOG.md"),
+			Profile:       getEnv("CONFIG_PROFILE", ""),
 		},
 		Server: ServerConfig{
 			Host:            getEnv("SERVER_HOST", "localhost"),
@@ -799,7 +1098,38 @@ func Load() (*Config, error) {
 			EnablePprof:     getEnvAsBool("ENABLE_PPROF", true),
 			EnableMetrics:   getEnvAsBool("ENABLE_METRICS", true),
 			EnableSwagger:   getEnvAsBool("ENABLE_SWAGGER", true),
+			EnableGraphQL:   getEnvAsBool("ENABLE_GRAPHQL", true),
 			EnableCORS:      getEnvAsBool("ENABLE_CORS", true),
+			HTTPSDomain:     getEnv("HTTPS_DOMAIN", ""),
+			TLSCacheDir:     getEnv("TLS_CACHE_DIR", "./certs"),
+			TLSCertFile:     getEnv("TLS_CERT_FILE", ""),
+			TLSKeyFile:      getEnv("TLS_KEY_FILE", ""),
+
+			EnableHTTP2:      getEnvAsBool("ENABLE_HTTP2", false),
+			HTTP2PushPaths:   getEnvAsPathMap("HTTP2_PUSH_PATHS", ""),
+			HTTPRedirectPort: getEnv("HTTP_REDIRECT_PORT", ""),
+
+			MaxResponseSize:        getEnvAsInt64("MAX_RESPONSE_SIZE", 0) * 1024 * 1024, // Convert MB to bytes
+			ResponseTruncationMode: getEnv("RESPONSE_TRUNCATION_MODE", "truncate"),
+			ResponseSizeLimits:     getEnvAsSizeMap("RESPONSE_SIZE_LIMITS", ""),
+
+			PriorityPortEnabled:    getEnvAsBool("SERVER_PRIORITY_PORT_ENABLED", false),
+			PriorityPort:           getEnv("SERVER_PRIORITY_PORT", "8081"),
+			PrioritySocketPriority: getEnvAsInt("SERVER_PRIORITY_SOCKET_PRIORITY", 6),
+
+			LoadShedding: LoadSheddingConfig{
+				Enabled:         getEnvAsBool("LOAD_SHEDDING_ENABLED", false),
+				CPUThreshold:    getEnvAsFloat64("LOAD_SHEDDING_CPU_THRESHOLD", 50),
+				MemoryThreshold: getEnvAsFloat64("LOAD_SHEDDING_MEMORY_THRESHOLD", 80),
+				TrustedCIDRs:    getEnvAsStringSlice("LOAD_SHEDDING_TRUSTED_CIDRS", ""),
+			},
+			MemoryBudget: MemoryBudgetConfig{
+				Enabled:    getEnvAsBool("MEMORY_BUDGET_ENABLED", false),
+				MaxBytes:   uint64(getEnvAsInt64("MEMORY_BUDGET_MAX_SIZE", 256)) * 1024 * 1024, // Convert MB to bytes
+				SampleRate: getEnvAsInt("MEMORY_BUDGET_SAMPLE_RATE", 100),
+			},
+
+			MaxConnectionsPerIP: getEnvAsInt("MAX_CONNECTIONS_PER_IP", 0),
 		},
 		Database: DatabaseConfig{
 			Driver:          getEnv("DB_DRIVER", "postgres"),
@@ -815,6 +1145,17 @@ func Load() (*Config, error) {
 			QueryTimeout:    getEnvAsDuration("DB_QUERY_TIMEOUT", 30*time.Second),
 			AutoMigrate:     getEnvAsBool("DB_AUTO_MIGRATE", false),
 			MigrationPath:   getEnv("DB_MIGRATION_PATH", "./migrations/postgres"),
+
+			OptimisticLocking: getEnvAsBool("DB_OPTIMISTIC_LOCKING", false),
+
+			Replicas:                   getEnvAsReplicas("DB_REPLICAS", ""),
+			ReplicaHealthCheckInterval: getEnvAsDuration("DB_REPLICA_HEALTH_CHECK_INTERVAL", 10*time.Second),
+			ReplicaFailureThreshold:    getEnvAsInt("DB_REPLICA_FAILURE_THRESHOLD", 3),
+			HealthCheck: HealthCheckConfig{
+				Interval: getEnvAsDuration("DB_HEALTH_CHECK_INTERVAL", 30*time.Second),
+				Timeout:  getEnvAsDuration("DB_HEALTH_CHECK_TIMEOUT", 5*time.Second),
+				Retries:  getEnvAsInt("DB_HEALTH_CHECK_RETRIES", 3),
+			},
 		},
 		Redis: RedisConfig{
 			Host:         getEnv("REDIS_HOST", "localhost"),
@@ -862,13 +1203,20 @@ func Load() (*Config, error) {
 			RefreshExpiration: getEnvAsDuration("JWT_REFRESH_EXPIRATION_HOURS", 168*time.Hour),
 			Issuer:            getEnv("JWT_ISSUER", "go-template"),
 			Algorithm:         getEnv("JWT_ALGORITHM", "HS256"),
+			PrivateKeyFile:    getEnv("JWT_PRIVATE_KEY_FILE", ""),
+			PublicKeyFile:     getEnv("JWT_PUBLIC_KEY_FILE", ""),
+
+			PreviousPublicKeyFile: getEnv("JWT_PREVIOUS_PUBLIC_KEY_FILE", ""),
+			KeyRotationWindow:     getEnvAsDuration("JWT_KEY_ROTATION_WINDOW", 24*time.Hour),
 		},
 		Session: SessionConfig{
-			Secret:   getEnv("SESSION_SECRET", "your-session-secret"),
-			MaxAge:   getEnvAsDuration("SESSION_MAX_AGE_HOURS", 24*time.Hour),
-			Secure:   getEnvAsBool("SESSION_SECURE", false),
-			HTTPOnly: getEnvAsBool("SESSION_HTTP_ONLY", true),
-			SameSite: getEnv("SESSION_SAME_SITE", "lax"),
+			Driver:     getEnv("APPLICATION_SESSION_DRIVER", "redis"),
+			Secret:     getEnv("SESSION_SECRET", "your-session-secret"),
+			MaxAge:     getEnvAsDuration("SESSION_MAX_AGE_HOURS", 24*time.Hour),
+			Secure:     getEnvAsBool("SESSION_SECURE", false),
+			HTTPOnly:   getEnvAsBool("SESSION_HTTP_ONLY", true),
+			SameSite:   getEnv("SESSION_SAME_SITE", "lax"),
+			CookieName: getEnv("SESSION_COOKIE_NAME", "session_id"),
 		},
 		Password: PasswordConfig{
 			MinLength:        getEnvAsInt("PASSWORD_MIN_LENGTH", 8),
@@ -883,7 +1231,10 @@ func Load() (*Config, error) {
 			LockoutDuration:           getEnvAsDuration("LOCKOUT_DURATION_MINUTES", 30*time.Minute),
 			PasswordResetExpiry:       getEnvAsDuration("PASSWORD_RESET_EXPIRY_MINUTES", 30*time.Minute),
 			EmailVerificationRequired: getEnvAsBool("EMAIL_VERIFICATION_REQUIRED", false),
+			DisposableEmailBlocklist:  getEnv("DISPOSABLE_EMAIL_BLOCKLIST", ""),
 		},
+		SessionBasedAuth:     getEnvAsBool("SESSION_BASED_AUTH", false),
+		SessionFallbackToJWT: getEnvAsBool("SESSION_FALLBACK_TO_JWT", false),
 	}
 
 	// Load Security configuration
@@ -893,12 +1244,24 @@ func Load() (*Config, error) {
 			Auth:   getEnvAsInt("RATE_LIMIT_AUTH", 10),
 			API:    getEnvAsInt("RATE_LIMIT_API", 100),
 			Public: getEnvAsInt("RATE_LIMIT_PUBLIC", 50),
+
+			Priority: getEnvAsInt("RATE_LIMIT_PRIORITY", 5000),
+		},
+		Abuse: AbuseDetectionConfig{
+			Enabled:                getEnvAsBool("ABUSE_DETECTION_ENABLED", false),
+			ErrorRateThreshold:     getEnvAsFloat64("ABUSE_ERROR_RATE_THRESHOLD", 0.5),
+			ErrorRateWindow:        getEnvAsInt("ABUSE_ERROR_RATE_WINDOW", 100),
+			SamePathPerMinute:      getEnvAsInt("ABUSE_SAME_PATH_PER_MINUTE", 1000),
+			DistinctPathsPerSecond: getEnvAsInt("ABUSE_DISTINCT_PATHS_PER_SECOND", 20),
+			BlockDuration:          getEnvAsDuration("ABUSE_BLOCK_DURATION", 15*time.Minute),
 		},
 		IP: IPSecurityConfig{
 			EnableWhitelist: getEnvAsBool("ENABLE_IP_WHITELIST", false),
 			WhitelistedIPs:  getEnvAsStringSlice("WHITELISTED_IPS", "127.0.0.1,::1"),
 			BlockedIPs:      getEnvAsStringSlice("BLOCKED_IPS", ""),
 		},
+		GeoIP2DatabasePath:    getEnv("GEOIP2_DATABASE_PATH", ""),
+		BlockSuspiciousLogins: getEnvAsBool("BLOCK_SUSPICIOUS_LOGINS", false),
 		Headers: SecurityHeadersConfig{
 			Enable:     getEnvAsBool("ENABLE_SECURITY_HEADERS", true),
 			EnableHSTS: getEnvAsBool("ENABLE_HSTS", true),
@@ -910,14 +1273,51 @@ func Load() (*Config, error) {
 			Secure:   getEnvAsBool("CSRF_SECURE", false),
 			HTTPOnly: getEnvAsBool("CSRF_HTTP_ONLY", true),
 		},
+		CORS: CORSConfig{
+			AllowedOrigins:   getEnvAsStringSlice("CORS_ALLOWED_ORIGINS", "*"),
+			AllowedMethods:   getEnvAsStringSlice("CORS_ALLOWED_METHODS", "GET,POST,PUT,PATCH,DELETE,OPTIONS"),
+			AllowedHeaders:   getEnvAsStringSlice("CORS_ALLOWED_HEADERS", "Content-Type,Authorization"),
+			ExposedHeaders:   getEnvAsStringSlice("CORS_EXPOSED_HEADERS", ""),
+			AllowCredentials: getEnvAsBool("CORS_ALLOW_CREDENTIALS", false),
+			MaxAge:           getEnvAsDuration("CORS_MAX_AGE", 12*time.Hour),
+		},
+	}
+
+	// Load Email configuration
+	config.Email = EmailConfig{
+		Provider:    getEnv("EMAIL_PROVIDER", ""),
+		FromAddress: getEnv("EMAIL_FROM_ADDRESS", ""),
+		FromName:    getEnv("EMAIL_FROM_NAME", config.App.Name),
+		SMTP: SMTPConfig{
+			Host:     getEnv("SMTP_HOST", ""),
+			Port:     getEnvAsInt("SMTP_PORT", 587),
+			Username: getEnv("SMTP_USERNAME", ""),
+			Password: getEnv("SMTP_PASSWORD", ""),
+			UseTLS:   getEnvAsBool("SMTP_USE_TLS", true),
+		},
+		SendGrid: SimpleSendGridConfig{
+			APIKey: getEnv("SENDGRID_API_KEY", ""),
+		},
+		Mailgun: MailgunConfig{
+			APIKey:  getEnv("MAILGUN_API_KEY", ""),
+			Domain:  getEnv("MAILGUN_DOMAIN", ""),
+			BaseURL: getEnv("MAILGUN_BASE_URL", "https://api.mailgun.net/v3"),
+		},
+		AWSSES: AWSSESConfig{
+			Region:    getEnv("AWS_SES_REGION", ""),
+			AccessKey: getEnv("AWS_SES_ACCESS_KEY", ""),
+			SecretKey: getEnv("AWS_SES_SECRET_KEY", ""),
+		},
+		TemplateDir: getEnv("EMAIL_TEMPLATE_DIR", ""),
 	}
 
 	// Load Storage configuration
 	config.Storage = StorageConfig{
 		Provider: getEnv("STORAGE_PROVIDER", "local"),
 		Local: LocalStorageConfig{
-			Path:      getEnv("LOCAL_STORAGE_PATH", "./uploads"),
-			URLPrefix: getEnv("LOCAL_STORAGE_URL_PREFIX", "/uploads"),
+			Path:       getEnv("LOCAL_STORAGE_PATH", "./uploads"),
+			URLPrefix:  getEnv("LOCAL_STORAGE_URL_PREFIX", "/uploads"),
+			SigningKey: getEnv("LOCAL_STORAGE_SIGNING_KEY", ""),
 		},
 		S3: S3Config{
 			Region:         getEnv("AWS_S3_REGION", "us-east-1"),
@@ -959,35 +1359,61 @@ func Load() (*Config, error) {
 			Key:       getEnv("AZURE_STORAGE_KEY", ""),
 			Container: getEnv("AZURE_STORAGE_CONTAINER", ""),
 		},
+		Encryption: StorageEncryptionConfig{
+			Enabled:         getEnvAsBool("STORAGE_ENCRYPTION_ENABLED", false),
+			KeyFile:         getEnv("STORAGE_ENCRYPTION_KEY_FILE", "./certs/storage.key"),
+			KMSEncryptedKey: getEnv("STORAGE_ENCRYPTION_KMS_KEY", ""),
+			KMSRegion:       getEnv("STORAGE_ENCRYPTION_KMS_REGION", "us-east-1"),
+		},
+		Scanner: ScannerConfig{
+			Enabled:          getEnvAsBool("STORAGE_SCANNER_ENABLED", false),
+			Engine:           getEnv("STORAGE_SCANNER_ENGINE", "clamav"),
+			ClamAVSocket:     getEnv("CLAMAV_SOCKET", "/var/run/clamav/clamd.ctl"),
+			VirusTotalAPIKey: getEnv("VIRUSTOTAL_API_KEY", ""),
+			Timeout:          getEnvAsDuration("STORAGE_SCANNER_TIMEOUT", 60*time.Second),
+		},
+		ChunkSize:        getEnvAsInt64("STORAGE_CHUNK_SIZE", 5) * 1024 * 1024, // Convert MB to bytes
+		QuotaBytes:       getEnvAsInt64("STORAGE_QUOTA_MB", 0) * 1024 * 1024,   // Convert MB to bytes
 		MaxUploadSizeMB:  getEnvAsInt("MAX_UPLOAD_SIZE_MB", 50),
 		AllowedFileTypes: getEnvAsStringSlice("ALLOWED_FILE_TYPES", "jpg,jpeg,png,gif,pdf,doc,docx,txt"),
 		UploadPath:       getEnv("UPLOAD_PATH", "uploads"),
 	}
 
 	// Load Feature flags
-	config.Features = FeatureConfig{
-		UserRegistration:  getEnvAsBool("FEATURE_USER_REGISTRATION", true),
-		EmailVerification: getEnvAsBool("FEATURE_EMAIL_VERIFICATION", false),
-		TwoFactorAuth:     getEnvAsBool("FEATURE_TWO_FACTOR_AUTH", false),
-		SocialLogin:       getEnvAsBool("FEATURE_SOCIAL_LOGIN", false),
-		APIRateLimiting:   getEnvAsBool("FEATURE_API_RATE_LIMITING", true),
-		MaintenanceMode:   getEnvAsBool("FEATURE_MAINTENANCE_MODE", false),
-		Payments:          getEnvAsBool("FEATURE_PAYMENTS", false),
-		Subscriptions:     getEnvAsBool("FEATURE_SUBSCRIPTIONS", false),
-		Invoicing:         getEnvAsBool("FEATURE_INVOICING", false),
-		FileUpload:        getEnvAsBool("FEATURE_FILE_UPLOAD", true),
-		ImageProcessing:   getEnvAsBool("FEATURE_IMAGE_PROCESSING", false),
-		ContentModeration: getEnvAsBool("FEATURE_CONTENT_MODERATION", false),
-	}
+	config.Features = loadFeatures()
 
 	// Load Message Broker configuration
 	config.MessageBroker = MessageBrokerConfig{
-		Enabled: getEnvAsBool("MESSAGE_BROKER_ENABLED", false),
-		Driver:  getEnv("MESSAGE_BROKER_DRIVER", "redis"),
+		Enabled:     getEnvAsBool("MESSAGE_BROKER_ENABLED", false),
+		Driver:      getEnv("MESSAGE_BROKER_DRIVER", "redis"),
+		TopicPrefix: getEnv("MESSAGE_BROKER_TOPIC_PREFIX", strings.ToLower(strings.ReplaceAll(config.App.Name, " ", "-"))+"."+config.Server.Mode+"."),
+	}
+
+	// Multi-sink writes for critical messages; listed drivers are configured
+	// alongside the default one
+	sinkDrivers := getEnvAsStringSlice("MESSAGE_BROKER_SINK_DRIVERS", "")
+	if len(sinkDrivers) > 0 {
+		config.MessageBroker.Sink = &SinkConfig{
+			Drivers: sinkDrivers,
+			Quorum:  getEnv("MESSAGE_BROKER_SINK_QUORUM", "majority"),
+		}
+	}
+	// Dead-letter routing; a driver override is configured like a sink driver
+	if getEnvAsBool("MESSAGE_BROKER_DLQ_ENABLED", false) {
+		config.MessageBroker.DeadLetter = &DeadLetterConfig{
+			Enabled: true,
+			Topic:   getEnv("MESSAGE_BROKER_DLQ_TOPIC", "dead-letters"),
+			Driver:  getEnv("MESSAGE_BROKER_DLQ_DRIVER", ""),
+		}
+	}
+	usesBroker := func(driver string) bool {
+		dlq := config.MessageBroker.DeadLetter
+		return config.MessageBroker.Enabled && (config.MessageBroker.Driver == driver || slices.Contains(sinkDrivers, driver) ||
+			dlq != nil && dlq.Driver == driver)
 	}
 
 	// RabbitMQ configuration
-	if config.MessageBroker.Driver == "rabbitmq" && config.MessageBroker.Enabled {
+	if usesBroker("rabbitmq") {
 		config.MessageBroker.RabbitMQ = &RabbitMQConfig{
 			URL:               getEnv("RABBITMQ_URL", ""),
 			Host:              getEnv("RABBITMQ_HOST", "localhost"),
@@ -1002,11 +1428,12 @@ func Load() (*Config, error) {
 			PrefetchCount:     getEnvAsInt("RABBITMQ_PREFETCH_COUNT", 10),
 			Durable:           getEnvAsBool("RABBITMQ_DURABLE", true),
 			AutoDelete:        getEnvAsBool("RABBITMQ_AUTO_DELETE", false),
+			MaxMessageBytes:   getEnvAsInt("RABBITMQ_MAX_MESSAGE_BYTES", 1024*1024), // 1MB
 		}
 	}
 
 	// Kafka configuration
-	if config.MessageBroker.Driver == "kafka" && config.MessageBroker.Enabled {
+	if usesBroker("kafka") {
 		config.MessageBroker.Kafka = &KafkaConfig{
 			Brokers:            getEnvAsStringSlice("KAFKA_BROKERS", "localhost:9092"),
 			GroupID:            getEnv("KAFKA_GROUP_ID", "go-template-consumer-group"),
@@ -1023,6 +1450,8 @@ func Load() (*Config, error) {
 			EnableAutoCommit:   getEnvAsBool("KAFKA_ENABLE_AUTO_COMMIT", true),
 			AutoCommitInterval: getEnvAsDuration("KAFKA_AUTO_COMMIT_INTERVAL", 1*time.Second),
 			InitialOffset:      getEnv("KAFKA_INITIAL_OFFSET", "newest"),
+			MaxMessageBytes:    getEnvAsInt("KAFKA_MAX_MESSAGE_BYTES", 1024*1024), // 1MB, Kafka's default message.max.bytes
+			SchemaRegistryURL:  getEnv("KAFKA_SCHEMA_REGISTRY_URL", ""),
 		}
 
 		// SASL configuration for Kafka
@@ -1048,19 +1477,20 @@ func Load() (*Config, error) {
 	}
 
 	// Redis Pub/Sub configuration
-	if config.MessageBroker.Driver == "redis" && config.MessageBroker.Enabled {
+	if usesBroker("redis") {
 		config.MessageBroker.Redis = &RedisPubSubConfig{
-			Host:           getEnv("MESSAGE_BROKER_REDIS_HOST", config.Redis.Host),
-			Port:           getEnvAsInt("MESSAGE_BROKER_REDIS_PORT", 6379),
-			Password:       getEnv("MESSAGE_BROKER_REDIS_PASSWORD", config.Redis.Password),
-			DB:             getEnvAsInt("MESSAGE_BROKER_REDIS_DB", 1), // Different DB than cache
-			PoolSize:       getEnvAsInt("MESSAGE_BROKER_REDIS_POOL_SIZE", 10),
-			MinIdleConns:   getEnvAsInt("MESSAGE_BROKER_REDIS_MIN_IDLE_CONNS", 3),
-			MaxRetries:     getEnvAsInt("MESSAGE_BROKER_REDIS_MAX_RETRIES", 3),
-			ConnectTimeout: getEnvAsDuration("MESSAGE_BROKER_REDIS_CONNECT_TIMEOUT", 5*time.Second),
-			ReadTimeout:    getEnvAsDuration("MESSAGE_BROKER_REDIS_READ_TIMEOUT", 3*time.Second),
-			WriteTimeout:   getEnvAsDuration("MESSAGE_BROKER_REDIS_WRITE_TIMEOUT", 3*time.Second),
-			IdleTimeout:    getEnvAsDuration("MESSAGE_BROKER_REDIS_IDLE_TIMEOUT", 300*time.Second),
+			Host:            getEnv("MESSAGE_BROKER_REDIS_HOST", config.Redis.Host),
+			Port:            getEnvAsInt("MESSAGE_BROKER_REDIS_PORT", 6379),
+			Password:        getEnv("MESSAGE_BROKER_REDIS_PASSWORD", config.Redis.Password),
+			DB:              getEnvAsInt("MESSAGE_BROKER_REDIS_DB", 1), // Different DB than cache
+			PoolSize:        getEnvAsInt("MESSAGE_BROKER_REDIS_POOL_SIZE", 10),
+			MinIdleConns:    getEnvAsInt("MESSAGE_BROKER_REDIS_MIN_IDLE_CONNS", 3),
+			MaxRetries:      getEnvAsInt("MESSAGE_BROKER_REDIS_MAX_RETRIES", 3),
+			ConnectTimeout:  getEnvAsDuration("MESSAGE_BROKER_REDIS_CONNECT_TIMEOUT", 5*time.Second),
+			ReadTimeout:     getEnvAsDuration("MESSAGE_BROKER_REDIS_READ_TIMEOUT", 3*time.Second),
+			WriteTimeout:    getEnvAsDuration("MESSAGE_BROKER_REDIS_WRITE_TIMEOUT", 3*time.Second),
+			IdleTimeout:     getEnvAsDuration("MESSAGE_BROKER_REDIS_IDLE_TIMEOUT", 300*time.Second),
+			MaxMessageBytes: getEnvAsInt("MESSAGE_BROKER_REDIS_MAX_MESSAGE_BYTES", 1024*1024), // 1MB
 		}
 
 		// TLS configuration for Redis
@@ -1073,6 +1503,16 @@ func Load() (*Config, error) {
 				InsecureSkipVerify: getEnvAsBool("MESSAGE_BROKER_REDIS_TLS_INSECURE_SKIP_VERIFY", false),
 			}
 		}
+
+		// Lag based consumer scaling for concurrent subscriptions
+		if getEnvAsBool("MESSAGE_BROKER_REDIS_CONSUMER_AUTOSCALE", false) {
+			config.MessageBroker.Redis.Consumer = &ConsumerScalingConfig{
+				MinConcurrency: getEnvAsInt("MESSAGE_BROKER_REDIS_CONSUMER_MIN_CONCURRENCY", 1),
+				MaxConcurrency: getEnvAsInt("MESSAGE_BROKER_REDIS_CONSUMER_MAX_CONCURRENCY", 16),
+				ScaleInterval:  getEnvAsDuration("MESSAGE_BROKER_REDIS_CONSUMER_SCALE_INTERVAL", 5*time.Second),
+				ScaleUpLag:     getEnvAsInt("MESSAGE_BROKER_REDIS_CONSUMER_SCALE_UP_LAG", 100),
+			}
+		}
 	}
 
 	// Retry configuration
@@ -1233,6 +1673,12 @@ func Load() (*Config, error) {
 		LogRequests: getEnvAsBool("LOG_REQUESTS", true),
 		LogHeaders:  getEnvAsBool("LOG_HEADERS", false),
 		LogBody:     getEnvAsBool("LOG_BODY", false),
+
+		RequestIDTrustedCIDRs: getEnvAsStringSlice("REQUEST_ID_TRUSTED_CIDRS", ""),
+
+		LokiURL:       getEnv("LOKI_URL", ""),
+		LokiBatchSize: getEnvAsInt("LOKI_BATCH_SIZE", 100),
+		LokiBatchWait: getEnvAsDuration("LOKI_BATCH_WAIT", time.Second),
 	}
 
 	// Load Monitoring configuration
@@ -1242,6 +1688,76 @@ func Load() (*Config, error) {
 		Prometheus: PrometheusConfig{
 			Namespace:   getEnv("MONITORING_NAMESPACE", strings.ToLower(strings.ReplaceAll(config.App.Name, " ", "_"))),
 			MetricsPath: getEnv("MONITORING_METRICS_PATH", "/metrics"),
+
+			SlowQueryThreshold: getEnvAsDuration("MONITORING_SLOW_QUERY_THRESHOLD", 500*time.Millisecond),
+		},
+		Sentry: SentryConfig{
+			DSN:         getEnv("SENTRY_DSN", ""),
+			Environment: getEnv("SENTRY_ENVIRONMENT", config.Server.Mode),
+			Release:     getEnv("SENTRY_RELEASE", config.App.Version),
+			Debug:       getEnvAsBool("SENTRY_DEBUG", false),
+		},
+		LatencyAlert: LatencyAlertConfig{
+			Enabled:    getEnvAsInt("LATENCY_ALERT_THRESHOLD_MS", 0) > 0,
+			Threshold:  time.Duration(getEnvAsInt("LATENCY_ALERT_THRESHOLD_MS", 0)) * time.Millisecond,
+			Interval:   getEnvAsDuration("LATENCY_ALERT_INTERVAL", 60*time.Second),
+			Cooldown:   getEnvAsDuration("LATENCY_ALERT_COOLDOWN", 10*time.Minute),
+			WebhookURL: getEnv("ALERT_WEBHOOK_URL", ""),
+		},
+		HealthAlert: HealthAlertConfig{
+			SlackWebhookURL:       getEnv("SLACK_WEBHOOK_URL", ""),
+			PagerDutyRoutingKey:   getEnv("PAGERDUTY_ROUTING_KEY", ""),
+			AlertDebounceInterval: getEnvAsDuration("ALERT_DEBOUNCE_INTERVAL", 5*time.Minute),
+			CheckInterval:         getEnvAsDuration("HEALTH_CHECK_INTERVAL", 30*time.Second),
+			CheckTimeout:          getEnvAsDuration("HEALTH_CHECK_TIMEOUT", 5*time.Second),
+		},
+		SLO: SLOConfig{
+			AvailabilityTarget: getEnvAsFloat64("SLO_AVAILABILITY_TARGET", 0.999),
+			LatencyTarget:      getEnvAsDuration("SLO_LATENCY_TARGET", 500*time.Millisecond),
+			LatencyPercentile:  getEnvAsFloat64("SLO_LATENCY_PERCENTILE", 0.99),
+			Windows:            getEnvAsDurations("SLO_WINDOWS", "1h,24h,7d"),
+			Interval:           getEnvAsDuration("SLO_EVALUATION_INTERVAL", time.Minute),
+		},
+	}
+	config.Monitoring.HealthAlert.Enabled = config.Monitoring.HealthAlert.SlackWebhookURL != "" ||
+		config.Monitoring.HealthAlert.PagerDutyRoutingKey != ""
+
+	// Load external services configuration
+	config.External = ExternalConfig{
+		Google: GoogleConfig{
+			ClientID:     getEnv("GOOGLE_CLIENT_ID", ""),
+			ClientSecret: getEnv("GOOGLE_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("GOOGLE_REDIRECT_URL", ""),
+			AnalyticsID:  getEnv("GOOGLE_ANALYTICS_ID", ""),
+		},
+		GitHub: GitHubConfig{
+			ClientID:     getEnv("GITHUB_CLIENT_ID", ""),
+			ClientSecret: getEnv("GITHUB_CLIENT_SECRET", ""),
+			RedirectURL:  getEnv("GITHUB_REDIRECT_URL", ""),
+		},
+		CircuitBreaker: CircuitBreakerConfig{
+			FailureThreshold:      getEnvAsInt("CIRCUIT_BREAKER_FAILURE_THRESHOLD", 5),
+			SuccessThreshold:      getEnvAsInt("CIRCUIT_BREAKER_SUCCESS_THRESHOLD", 2),
+			Timeout:               getEnvAsDuration("CIRCUIT_BREAKER_TIMEOUT", 30*time.Second),
+			MaxConcurrentRequests: getEnvAsInt("CIRCUIT_BREAKER_MAX_CONCURRENT_REQUESTS", 0),
+		},
+	}
+
+	// Load performance configuration
+	config.Performance = PerformanceConfig{
+		ResponseCaching:    getEnvAsBool("ENABLE_RESPONSE_CACHING", true),
+		CacheStrategy:      getEnv("CACHE_STRATEGY", "redis"),
+		CacheDuration:      getEnvAsDuration("DEFAULT_CACHE_DURATION", 5*time.Minute),
+		QueryCache:         getEnvAsBool("ENABLE_QUERY_CACHE", true),
+		ConnectionPooling:  getEnvAsBool("ENABLE_CONNECTION_POOLING", true),
+		PreparedStatements: getEnvAsBool("ENABLE_PREPARED_STATEMENTS", true),
+		AssetCacheDuration: getEnvAsDuration("STATIC_ASSET_CACHE_DURATION", 24*time.Hour),
+		GzipCompression:    getEnvAsBool("ENABLE_GZIP_COMPRESSION", true),
+		AssetMinification:  getEnvAsBool("ENABLE_ASSET_MINIFICATION", true),
+		Warming: WarmingConfig{
+			Enabled:    getEnvAsBool("CACHE_WARMING_ENABLED", false),
+			Entities:   getEnvAsStringSlice("CACHE_WARMING_ENTITIES", "users"),
+			MaxRecords: getEnvAsInt("CACHE_WARMING_MAX_RECORDS", 1000),
 		},
 	}
 
@@ -1287,6 +1803,32 @@ func Load() (*Config, error) {
 		}
 	}
 
+	// Load gRPC client mTLS configuration
+	if getEnvAsBool("GRPC_MTLS_ENABLED", false) {
+		config.GRPC.MTLS = &GRPCMTLSConfig{
+			Enable:   true,
+			CertFile: getEnv("GRPC_MTLS_CERT_FILE", "./certs/client.crt"),
+			KeyFile:  getEnv("GRPC_MTLS_KEY_FILE", "./certs/client.key"),
+			CAFile:   getEnv("GRPC_MTLS_CA_FILE", "./certs/ca.crt"),
+		}
+	}
+
+	// Load module bootstrap configuration
+	config.Bootstrap = BootstrapConfig{
+		DrainTimeout: getEnvAsDuration("MODULE_DRAIN_TIMEOUT", 10*time.Second),
+	}
+
+	// Load secrets manager configuration
+	config.Secrets = SecretsConfig{
+		Provider: getEnv("SECRETS_PROVIDER", ""),
+		Prefix:   getEnv("SECRETS_PREFIX", ""),
+	}
+
+	// Replace $SECRET:<key> references before the values are validated
+	if err := resolveSecrets(config); err != nil {
+		return nil, err
+	}
+
 	// Validate configuration
 	if err := validateConfig(config); err != nil {
 		return nil, fmt.Errorf("configuration validation failed: %w", err)
@@ -1295,37 +1837,338 @@ func Load() (*Config, error) {
 	return config, nil
 }
 
+// LoadFeatures reads the feature flags again for reloading at runtime.
+// FEATURE_ variables in the .env file are read into the environment again
+// first, overriding earlier values, so flags can be flipped by editing it.
+func LoadFeatures() FeatureConfig {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	if values, err := godotenv.Read(); err == nil {
+		for key, value := range values {
+			if strings.HasPrefix(key, "FEATURE_") {
+				os.Setenv(key, value)
+			}
+		}
+	}
+
+	return loadFeatures()
+}
+
+func loadFeatures() FeatureConfig {
+	return FeatureConfig{
+		UserRegistration:  getEnvAsBool("FEATURE_USER_REGISTRATION", true),
+		EmailVerification: getEnvAsBool("FEATURE_EMAIL_VERIFICATION", false),
+		TwoFactorAuth:     getEnvAsBool("FEATURE_TWO_FACTOR_AUTH", false),
+		SocialLogin:       getEnvAsBool("FEATURE_SOCIAL_LOGIN", false),
+		APIRateLimiting:   getEnvAsBool("FEATURE_API_RATE_LIMITING", true),
+		MaintenanceMode:   getEnvAsBool("FEATURE_MAINTENANCE_MODE", false),
+		Payments:          getEnvAsBool("FEATURE_PAYMENTS", false),
+		Subscriptions:     getEnvAsBool("FEATURE_SUBSCRIPTIONS", false),
+		Invoicing:         getEnvAsBool("FEATURE_INVOICING", false),
+		FileUpload:        getEnvAsBool("FEATURE_FILE_UPLOAD", true),
+		ImageProcessing:   getEnvAsBool("FEATURE_IMAGE_PROCESSING", false),
+		ContentModeration: getEnvAsBool("FEATURE_CONTENT_MODERATION", false),
+		Store:             getEnv("FEATURE_FLAG_STORE", "env"),
+		RedisKey:          getEnv("FEATURE_FLAG_REDIS_KEY", "feature_flags"),
+	}
+}
+
+// validateConfig checks the configuration as a whole and reports every
+// violation at once in a ConfigValidationError
 func validateConfig(config *Config) error {
-	// Validate required fields
-	if config.Auth.JWT.Secret == "your-secret-key" {
-		return fmt.Errorf("JWT_SECRET must be changed from default value")
+	errs := jwtViolations(config.Auth.JWT)
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
 	}
 
-	if len(config.Auth.JWT.Secret) < 32 {
-		return fmt.Errorf("JWT_SECRET must be at least 32 characters long")
+	if jwt := config.Auth.JWT; jwt.RefreshExpiration <= jwt.Expiration {
+		fail("JWT_REFRESH_EXPIRATION_HOURS (%s) must be longer than JWT_EXPIRATION_HOURS (%s)", jwt.RefreshExpiration, jwt.Expiration)
 	}
 
 	if config.Database.Password == "password" && config.Server.Mode == "production" {
-		return fmt.Errorf("database password must be changed from default value in production")
+		fail("database password must be changed from default value in production")
+	}
+
+	for i, replica := range config.Database.Replicas {
+		if replica.Weight < 1 {
+			fail("DB_REPLICAS entry %d (%s) must have a weight of at least 1, got %d", i+1, replica.Host, replica.Weight)
+		}
+	}
+	if len(config.Database.Replicas) > 0 && config.Database.ReplicaFailureThreshold < 1 {
+		fail("DB_REPLICA_FAILURE_THRESHOLD must be at least 1, got %d", config.Database.ReplicaFailureThreshold)
+	}
+	if config.Database.HealthCheck.Retries < 1 {
+		fail("DB_HEALTH_CHECK_RETRIES must be at least 1, got %d", config.Database.HealthCheck.Retries)
+	}
+
+	if breaker := config.External.CircuitBreaker; breaker.FailureThreshold < 1 || breaker.SuccessThreshold < 1 {
+		fail("CIRCUIT_BREAKER_FAILURE_THRESHOLD (%d) and CIRCUIT_BREAKER_SUCCESS_THRESHOLD (%d) must be at least 1",
+			breaker.FailureThreshold, breaker.SuccessThreshold)
+	}
+
+	if slo := config.Monitoring.SLO; slo.AvailabilityTarget <= 0 || slo.AvailabilityTarget >= 1 || slo.LatencyPercentile <= 0 || slo.LatencyPercentile >= 1 {
+		fail("SLO_AVAILABILITY_TARGET (%g) and SLO_LATENCY_PERCENTILE (%g) must be between 0 and 1",
+			slo.AvailabilityTarget, slo.LatencyPercentile)
+	}
+	if len(config.Monitoring.SLO.Windows) == 0 {
+		fail("SLO_WINDOWS must list at least one window")
+	}
+
+	if redis := config.Redis; redis.PoolSize < redis.MinIdleConns {
+		fail("REDIS_POOL_SIZE (%d) must be at least REDIS_MIN_IDLE_CONNS (%d)", redis.PoolSize, redis.MinIdleConns)
 	}
 
 	if config.Server.Mode == "production" && !config.Security.Headers.Enable {
-		return fmt.Errorf("security headers must be enabled in production")
+		fail("security headers must be enabled in production")
+	}
+
+	if config.Features.SocialLogin {
+		google, github := config.External.Google, config.External.GitHub
+		if google.ClientID == "" && github.ClientID == "" {
+			fail("FEATURE_SOCIAL_LOGIN requires GOOGLE_CLIENT_ID or GITHUB_CLIENT_ID")
+		}
+		if google.ClientID != "" && (google.ClientSecret == "" || google.RedirectURL == "") {
+			fail("GOOGLE_CLIENT_SECRET and GOOGLE_REDIRECT_URL are required with GOOGLE_CLIENT_ID")
+		}
+		if github.ClientID != "" && (github.ClientSecret == "" || github.RedirectURL == "") {
+			fail("GITHUB_CLIENT_SECRET and GITHUB_REDIRECT_URL are required with GITHUB_CLIENT_ID")
+		}
+	}
+
+	if store := config.Features.Store; store != "env" && store != "redis" {
+		fail("FEATURE_FLAG_STORE must be env or redis, got %q", store)
+	}
+
+	if cors := config.Security.CORS; cors.AllowCredentials && slices.Contains(cors.AllowedOrigins, "*") {
+		fail("CORS_ALLOWED_ORIGINS cannot contain * while CORS_ALLOW_CREDENTIALS is true")
+	}
+
+	if (config.Server.TLSCertFile == "") != (config.Server.TLSKeyFile == "") {
+		fail("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+	if config.Server.EnableHTTP2 && (config.Server.TLSCertFile == "" || config.Server.TLSKeyFile == "") {
+		fail("ENABLE_HTTP2 requires TLS_CERT_FILE and TLS_KEY_FILE")
+	}
+	if port := config.Server.HTTPRedirectPort; port != "" {
+		if config.Server.TLSCertFile == "" {
+			fail("HTTP_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE")
+		}
+		if port == config.Server.Port {
+			fail("HTTP_REDIRECT_PORT must differ from SERVER_PORT")
+		}
+	}
+
+	if scanner := config.Storage.Scanner; scanner.Enabled {
+		switch scanner.Engine {
+		case "clamav":
+			if scanner.ClamAVSocket == "" {
+				fail("CLAMAV_SOCKET is required for the clamav scanner")
+			}
+		case "virustotal":
+			if scanner.VirusTotalAPIKey == "" {
+				fail("VIRUSTOTAL_API_KEY is required for the virustotal scanner")
+			}
+		default:
+			fail("STORAGE_SCANNER_ENGINE must be clamav or virustotal, got %q", scanner.Engine)
+		}
+	}
+
+	if config.Storage.MaxUploadSizeMB <= 0 {
+		fail("MAX_UPLOAD_SIZE_MB must be positive, got %d", config.Storage.MaxUploadSizeMB)
+	}
+
+	if email := config.Email; email.Provider != "" {
+		switch email.Provider {
+		case "smtp":
+			if email.SMTP.Host == "" {
+				fail("SMTP_HOST is required for the smtp email provider")
+			}
+			if email.SMTP.Port < 1 || email.SMTP.Port > 65535 {
+				fail("SMTP_PORT must be between 1 and 65535, got %d", email.SMTP.Port)
+			}
+		case "sendgrid":
+			if email.SendGrid.APIKey == "" {
+				fail("SENDGRID_API_KEY is required for the sendgrid email provider")
+			}
+		case "mailgun":
+			if email.Mailgun.APIKey == "" || email.Mailgun.Domain == "" {
+				fail("MAILGUN_API_KEY and MAILGUN_DOMAIN are required for the mailgun email provider")
+			}
+		case "ses":
+			if email.AWSSES.Region == "" {
+				fail("AWS_SES_REGION is required for the ses email provider")
+			}
+		default:
+			fail("EMAIL_PROVIDER must be smtp, sendgrid, mailgun or ses, got %q", email.Provider)
+		}
+
+		if email.FromAddress == "" {
+			fail("EMAIL_FROM_ADDRESS is required when EMAIL_PROVIDER is set")
+		}
+
+		var templateErrs *ConfigValidationError
+		if err := ValidateEmailTemplates(&config.Email); errors.As(err, &templateErrs) {
+			errs = append(errs, templateErrs.Errors()...)
+		} else if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	if email := config.Notification.Email; email.Enabled {
+		switch {
+		case email.SMTP != nil:
+			if email.SMTP.Host == "" {
+				fail("NOTIFICATION_SMTP_HOST is required for the smtp email provider")
+			}
+			if email.SMTP.Port < 1 || email.SMTP.Port > 65535 {
+				fail("NOTIFICATION_SMTP_PORT must be between 1 and 65535, got %d", email.SMTP.Port)
+			}
+		case email.SendGrid != nil:
+			if email.SendGrid.APIKey == "" {
+				fail("NOTIFICATION_SENDGRID_API_KEY is required for the sendgrid email provider")
+			}
+			if email.SendGrid.FromEmail == "" {
+				fail("NOTIFICATION_SENDGRID_FROM_EMAIL is required for the sendgrid email provider")
+			}
+		default:
+			fail("NOTIFICATION_EMAIL_PROVIDER must be smtp or sendgrid, got %q", email.Provider)
+		}
+	}
+
+	if sink := config.MessageBroker.Sink; sink != nil && sink.Quorum != "all" && sink.Quorum != "majority" && sink.Quorum != "any" {
+		fail("MESSAGE_BROKER_SINK_QUORUM must be all, majority or any, got %q", sink.Quorum)
+	}
+
+	if dlq := config.MessageBroker.DeadLetter; dlq != nil && dlq.Driver != "" && !slices.Contains([]string{"rabbitmq", "kafka", "redis"}, dlq.Driver) {
+		fail("MESSAGE_BROKER_DLQ_DRIVER must be rabbitmq, kafka or redis, got %q", dlq.Driver)
+	}
+
+	if config.Server.PriorityPortEnabled && config.Server.PriorityPort == config.Server.Port {
+		fail("SERVER_PRIORITY_PORT must differ from SERVER_PORT")
+	}
+
+	if shedding := config.Server.LoadShedding; shedding.Enabled &&
+		(shedding.CPUThreshold <= 0 || shedding.CPUThreshold >= 100 || shedding.MemoryThreshold <= 0 || shedding.MemoryThreshold >= 100) {
+		fail("LOAD_SHEDDING_CPU_THRESHOLD and LOAD_SHEDDING_MEMORY_THRESHOLD must be between 0 and 100")
+	}
+
+	if budget := config.Server.MemoryBudget; budget.Enabled && (budget.MaxBytes == 0 || budget.SampleRate < 1) {
+		fail("MEMORY_BUDGET_MAX_SIZE and MEMORY_BUDGET_SAMPLE_RATE must be positive")
+	}
+
+	for _, cidr := range config.Server.LoadShedding.TrustedCIDRs {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			fail("LOAD_SHEDDING_TRUSTED_CIDRS contains an invalid CIDR %q", cidr)
+		}
+	}
+
+	for _, cidr := range config.Logging.RequestIDTrustedCIDRs {
+		if _, _, err := net.ParseCIDR(strings.TrimSpace(cidr)); err != nil {
+			fail("REQUEST_ID_TRUSTED_CIDRS contains an invalid CIDR %q", cidr)
+		}
 	}
 
+	if mode := config.Server.ResponseTruncationMode; mode != "truncate" && mode != "reject" {
+		fail("RESPONSE_TRUNCATION_MODE must be truncate or reject, got %q", mode)
+	}
+
+	if config.App.Profile == ProductionProfile {
+		errs = append(errs, productionViolations(config)...)
+	}
+
+	errs = append(errs, negativeDurations(config)...)
+
+	if len(errs) > 0 {
+		return &ConfigValidationError{errs: errs}
+	}
 	return nil
 }
 
+// productionViolations applies the stricter rules of the production
+// profile: no default credentials, TLS served and metrics exposed
+func productionViolations(config *Config) []error {
+	var violations []error
+	fail := func(format string, args ...interface{}) {
+		violations = append(violations, fmt.Errorf(format, args...))
+	}
+
+	if config.Database.Password == "password" {
+		fail("DB_PASSWORD must be changed from its default in the production profile")
+	}
+	if broker := config.MessageBroker; broker.Enabled && broker.Driver == "rabbitmq" && broker.RabbitMQ != nil && broker.RabbitMQ.Password == "guest" {
+		fail("RABBITMQ_PASSWORD must be changed from its default in the production profile")
+	}
+	if config.Server.TLSCertFile == "" && config.Server.HTTPSDomain == "" {
+		fail("the production profile requires TLS: set TLS_CERT_FILE and TLS_KEY_FILE, or HTTPS_DOMAIN")
+	}
+	if !config.Server.EnableMetrics {
+		fail("ENABLE_METRICS must be true in the production profile")
+	}
+
+	return violations
+}
+
+// ErrInvalidJWTConfig wraps every violation reported by jwtViolations
+var ErrInvalidJWTConfig = errors.New("invalid JWT configuration")
+
+// jwtSecretLengths are the minimum secret sizes, in bytes, of the HMAC
+// algorithms: a secret as long as the hash output
+var jwtSecretLengths = map[string]int{
+	"HS256": 32,
+	"HS384": 48,
+	"HS512": 64,
+}
+
+// jwtRSAAlgorithms sign with the private key file and verify with the
+// public key file
+var jwtRSAAlgorithms = []string{"RS256", "RS384", "RS512"}
+
+// jwtViolations checks the secret or key files JWT_ALGORITHM requires and
+// reports every violation, each wrapping ErrInvalidJWTConfig
+func jwtViolations(jwt JWTConfig) []error {
+	var violations []error
+
+	if minLength, ok := jwtSecretLengths[jwt.Algorithm]; ok {
+		if jwt.Secret == "your-secret-key" {
+			violations = append(violations, fmt.Errorf("JWT_SECRET must be changed from default value"))
+		}
+		if len(jwt.Secret) < minLength {
+			violations = append(violations, fmt.Errorf("JWT_SECRET must be at least %d bytes long for %s, got %d", minLength, jwt.Algorithm, len(jwt.Secret)))
+		}
+	} else if slices.Contains(jwtRSAAlgorithms, jwt.Algorithm) {
+		for _, key := range []struct{ env, path string }{
+			{"JWT_PRIVATE_KEY_FILE", jwt.PrivateKeyFile},
+			{"JWT_PUBLIC_KEY_FILE", jwt.PublicKeyFile},
+		} {
+			if key.path == "" {
+				violations = append(violations, fmt.Errorf("%s is required for %s", key.env, jwt.Algorithm))
+				continue
+			}
+			if _, err := os.Stat(key.path); err != nil {
+				violations = append(violations, fmt.Errorf("%s %q is not readable: %w", key.env, key.path, err))
+			}
+		}
+	} else {
+		violations = append(violations, fmt.Errorf("JWT_ALGORITHM %q is not supported, expected one of HS256, HS384, HS512, RS256, RS384 or RS512", jwt.Algorithm))
+	}
+
+	for i, violation := range violations {
+		violations[i] = fmt.Errorf("%w: %w", ErrInvalidJWTConfig, violation)
+	}
+	return violations
+}
+
 // Helper functions for environment variable parsing
 func getEnv(key, defaultValue string) string {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		return value
 	}
 	return defaultValue
 }
 
 func getEnvAsInt(key string, defaultValue int) int {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intVal, err := strconv.Atoi(value); err == nil {
 			return intVal
 		}
@@ -1334,7 +2177,7 @@ func getEnvAsInt(key string, defaultValue int) int {
 }
 
 func getEnvAsInt64(key string, defaultValue int64) int64 {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if intVal, err := strconv.ParseInt(value, 10, 64); err == nil {
 			return intVal
 		}
@@ -1343,7 +2186,7 @@ func getEnvAsInt64(key string, defaultValue int64) int64 {
 }
 
 func getEnvAsBool(key string, defaultValue bool) bool {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if boolVal, err := strconv.ParseBool(value); err == nil {
 			return boolVal
 		}
@@ -1352,7 +2195,7 @@ func getEnvAsBool(key string, defaultValue bool) bool {
 }
 
 func getEnvAsDuration(key string, defaultValue time.Duration) time.Duration {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		// Try to parse as seconds first
 		if seconds, err := strconv.Atoi(value); err == nil {
 			return time.Duration(seconds) * time.Second
@@ -1373,8 +2216,105 @@ func getEnvAsStringSlice(key, defaultValue string) []string {
 	return strings.Split(value, ",")
 }
 
+// getEnvAsDurations parses durations separated by commas, accepting a d
+// suffix for days (e.g. "1h,24h,7d"). Malformed or non-positive entries are
+// skipped.
+func getEnvAsDurations(key, defaultValue string) []time.Duration {
+	var durations []time.Duration
+	for _, entry := range getEnvAsStringSlice(key, defaultValue) {
+		entry = strings.TrimSpace(entry)
+
+		var duration time.Duration
+		if days, ok := strings.CutSuffix(entry, "d"); ok {
+			n, err := strconv.Atoi(days)
+			if err != nil {
+				continue
+			}
+			duration = time.Duration(n) * 24 * time.Hour
+		} else {
+			parsed, err := time.ParseDuration(entry)
+			if err != nil {
+				continue
+			}
+			duration = parsed
+		}
+
+		if duration > 0 {
+			durations = append(durations, duration)
+		}
+	}
+	return durations
+}
+
+// getEnvAsReplicas parses "host:port[:weight[:ro]]" entries separated by
+// commas. The weight defaults to 1 and ro marks a read-only replica.
+// Malformed entries are skipped.
+func getEnvAsReplicas(key, defaultValue string) []ReplicaConfig {
+	var replicas []ReplicaConfig
+	for _, entry := range getEnvAsStringSlice(key, defaultValue) {
+		parts := strings.Split(strings.TrimSpace(entry), ":")
+		if len(parts) < 2 || len(parts) > 4 || parts[0] == "" || parts[1] == "" {
+			continue
+		}
+
+		replica := ReplicaConfig{Host: parts[0], Port: parts[1], Weight: 1}
+		if len(parts) > 2 {
+			weight, err := strconv.Atoi(parts[2])
+			if err != nil {
+				continue
+			}
+			replica.Weight = weight
+		}
+		if len(parts) > 3 {
+			if parts[3] != "ro" {
+				continue
+			}
+			replica.ReadOnly = true
+		}
+		replicas = append(replicas, replica)
+	}
+	return replicas
+}
+
+// getEnvAsSizeMap parses "key:MB" pairs separated by commas into byte sizes.
+// Malformed pairs are skipped.
+func getEnvAsSizeMap(key, defaultValue string) map[string]int64 {
+	sizes := make(map[string]int64)
+	for _, pair := range getEnvAsStringSlice(key, defaultValue) {
+		name, size, ok := strings.Cut(strings.TrimSpace(pair), ":")
+		if !ok {
+			continue
+		}
+		megabytes, err := strconv.ParseInt(strings.TrimSpace(size), 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[strings.TrimSpace(name)] = megabytes * 1024 * 1024
+	}
+	return sizes
+}
+
+// getEnvAsPathMap parses "key=path|path" pairs separated by commas, e.g.
+// "/=/static/app.css|/static/app.js". Pairs without paths are skipped.
+func getEnvAsPathMap(key, defaultValue string) map[string][]string {
+	paths := make(map[string][]string)
+	for _, pair := range getEnvAsStringSlice(key, defaultValue) {
+		name, list, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			continue
+		}
+		name = strings.TrimSpace(name)
+		for _, path := range strings.Split(list, "|") {
+			if path = strings.TrimSpace(path); path != "" {
+				paths[name] = append(paths[name], path)
+			}
+		}
+	}
+	return paths
+}
+
 func getEnvAsFloat64(key string, defaultValue float64) float64 {
-	if value := os.Getenv(key); value != "" {
+	if value := lookupEnv(key); value != "" {
 		if floatVal, err := strconv.ParseFloat(value, 64); err == nil {
 			return floatVal
 		}
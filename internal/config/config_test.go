@@ -1,8 +1,12 @@
 package config
 
 import (
+	"errors"
 	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -102,6 +106,88 @@ func TestGetEnvAsBool(t *testing.T) {
 	}
 }
 
+func TestGetEnvAsReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected []ReplicaConfig
+	}{
+		{"Host and port", "replica:5432", []ReplicaConfig{{Host: "replica", Port: "5432", Weight: 1}}},
+		{"Weighted read-only", "replica-eu:5432:3:ro", []ReplicaConfig{{Host: "replica-eu", Port: "5432", Weight: 3, ReadOnly: true}}},
+		{"Several", "a:5432:2, b:5433", []ReplicaConfig{
+			{Host: "a", Port: "5432", Weight: 2},
+			{Host: "b", Port: "5433", Weight: 1},
+		}},
+		{"Malformed skipped", "nohost,a:5432:heavy,b:5432:1:rw,c:5432", []ReplicaConfig{{Host: "c", Port: "5432", Weight: 1}}},
+		{"Empty value", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				os.Setenv("TEST_REPLICAS", tt.envValue)
+				defer os.Unsetenv("TEST_REPLICAS")
+			}
+
+			result := getEnvAsReplicas("TEST_REPLICAS", "")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetEnvAsDurations(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected []time.Duration
+	}{
+		{"Go durations", "30m,1h", []time.Duration{30 * time.Minute, time.Hour}},
+		{"Days", "1d, 7d", []time.Duration{24 * time.Hour, 7 * 24 * time.Hour}},
+		{"Malformed skipped", "weekly,0h,-1h,xd,2h", []time.Duration{2 * time.Hour}},
+		{"Empty value", "", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				os.Setenv("TEST_DURATIONS", tt.envValue)
+				defer os.Unsetenv("TEST_DURATIONS")
+			}
+
+			result := getEnvAsDurations("TEST_DURATIONS", "")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
+func TestGetEnvAsPathMap(t *testing.T) {
+	tests := []struct {
+		name     string
+		envValue string
+		expected map[string][]string
+	}{
+		{"Several paths", "/=/static/app.css|/static/app.js", map[string][]string{"/": {"/static/app.css", "/static/app.js"}}},
+		{"Several routes", "/ = /static/app.css, /users/:id=/static/users.css", map[string][]string{
+			"/":          {"/static/app.css"},
+			"/users/:id": {"/static/users.css"},
+		}},
+		{"Malformed skipped", "/static/app.css,/docs=,/=/static/app.css", map[string][]string{"/": {"/static/app.css"}}},
+		{"Empty value", "", map[string][]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envValue != "" {
+				os.Setenv("TEST_PATH_MAP", tt.envValue)
+				defer os.Unsetenv("TEST_PATH_MAP")
+			}
+
+			result := getEnvAsPathMap("TEST_PATH_MAP", "")
+			assert.Equal(t, tt.expected, result)
+		})
+	}
+}
+
 func TestConfigValidation(t *testing.T) {
 	// Set required environment variables (must be at least 32 characters)
 	os.Setenv("JWT_SECRET", "test-secret-key-for-testing-123456789")
@@ -118,4 +204,207 @@ func TestConfigValidation(t *testing.T) {
 	assert.NotEmpty(t, config.App.Version)
 	assert.NotZero(t, config.Server.Port)
 	assert.NotEmpty(t, config.Database.Driver)
-}
\ No newline at end of file
+}
+
+func TestLoadFromFile(t *testing.T) {
+	writeFile := func(t *testing.T, name, content string) string {
+		path := filepath.Join(t.TempDir(), name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+		return path
+	}
+
+	yamlFile := `
+app:
+  name: From YAML
+server:
+  port: 9100
+read-timeout: 45s
+jwt:
+  secret: yaml-secret-key-for-testing-123456789
+whitelisted_ips:
+  - 10.0.0.1
+  - 10.0.0.2
+`
+
+	t.Run("should read settings from a YAML file", func(t *testing.T) {
+		config, err := LoadFromFile(writeFile(t, "config.yaml", yamlFile))
+		require.NoError(t, err)
+
+		assert.Equal(t, "From YAML", config.App.Name)
+		assert.Equal(t, "9100", config.Server.Port)
+		assert.Equal(t, 45*time.Second, config.Server.ReadTimeout)
+		assert.Equal(t, "yaml-secret-key-for-testing-123456789", config.Auth.JWT.Secret)
+		assert.Equal(t, []string{"10.0.0.1", "10.0.0.2"}, config.Security.IP.WhitelistedIPs)
+	})
+
+	t.Run("should read settings from a TOML file", func(t *testing.T) {
+		config, err := LoadFromFile(writeFile(t, "config.toml", `
+[app]
+name = "From TOML"
+
+[server]
+port = 9200
+
+[jwt]
+secret = "toml-secret-key-for-testing-123456789"
+`))
+		require.NoError(t, err)
+
+		assert.Equal(t, "From TOML", config.App.Name)
+		assert.Equal(t, "9200", config.Server.Port)
+	})
+
+	t.Run("should prefer environment variables over the file", func(t *testing.T) {
+		t.Setenv("APP_NAME", "From Env")
+
+		config, err := LoadFromFile(writeFile(t, "config.yml", yamlFile))
+		require.NoError(t, err)
+
+		assert.Equal(t, "From Env", config.App.Name)
+		assert.Equal(t, "9100", config.Server.Port)
+	})
+
+	t.Run("should fall back to defaults for settings missing from both", func(t *testing.T) {
+		config, err := LoadFromFile(writeFile(t, "config.yaml", yamlFile))
+		require.NoError(t, err)
+
+		assert.Equal(t, "localhost", config.Server.Host)
+	})
+
+	t.Run("should not leak file settings into later loads", func(t *testing.T) {
+		_, err := LoadFromFile(writeFile(t, "config.yaml", yamlFile))
+		require.NoError(t, err)
+
+		t.Setenv("JWT_SECRET", "test-secret-key-for-testing-123456789")
+		config, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, "Go Template", config.App.Name)
+	})
+
+	t.Run("should reject unsupported and missing files", func(t *testing.T) {
+		_, err := LoadFromFile(writeFile(t, "config.json", `{}`))
+		assert.Error(t, err)
+
+		_, err = LoadFromFile(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestLoadProfile(t *testing.T) {
+	// writeProfile writes the env files into a new working directory. Their
+	// keys are set empty in the environment first, so loading .env cannot
+	// leave them set for later tests.
+	writeProfile := func(t *testing.T, files map[string]string) {
+		dir := t.TempDir()
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+			for _, line := range strings.Split(content, "\n") {
+				if key, _, ok := strings.Cut(line, "="); ok {
+					t.Setenv(key, "")
+				}
+			}
+		}
+		t.Chdir(dir)
+	}
+
+	files := map[string]string{
+		".env":         "JWT_SECRET=test-secret-key-for-testing-123456789\nAPP_NAME=From Env File\nSERVER_HOST=0.0.0.0",
+		".env.staging": "APP_NAME=From Staging\nSERVER_PORT=9300",
+	}
+
+	t.Run("should layer the profile over .env over the environment", func(t *testing.T) {
+		writeProfile(t, files)
+		t.Setenv("SERVER_HOST", "from-environment")
+		t.Setenv("APP_AUTHOR", "From Environment")
+
+		config, err := LoadProfile("staging")
+		require.NoError(t, err)
+
+		assert.Equal(t, "From Staging", config.App.Name)
+		assert.Equal(t, "9300", config.Server.Port)
+		assert.Equal(t, "0.0.0.0", config.Server.Host)
+		assert.Equal(t, "From Environment", config.App.Author)
+		assert.Equal(t, "staging", config.App.Profile)
+	})
+
+	t.Run("should load the profile named by CONFIG_PROFILE", func(t *testing.T) {
+		writeProfile(t, files)
+		t.Setenv("CONFIG_PROFILE", "staging")
+
+		config, err := Load()
+		require.NoError(t, err)
+
+		assert.Equal(t, "From Staging", config.App.Name)
+	})
+
+	t.Run("should fail when the profile file is missing", func(t *testing.T) {
+		writeProfile(t, files)
+
+		_, err := LoadProfile("qa")
+		assert.ErrorContains(t, err, ".env.qa")
+	})
+
+	t.Run("should validate the production profile strictly", func(t *testing.T) {
+		writeProfile(t, map[string]string{
+			".env":            "JWT_SECRET=test-secret-key-for-testing-123456789",
+			".env.production": "ENABLE_METRICS=false",
+		})
+
+		_, err := LoadProfile(ProductionProfile)
+
+		assert.ErrorContains(t, err, "DB_PASSWORD must be changed")
+		assert.ErrorContains(t, err, "ENABLE_METRICS must be true")
+	})
+}
+
+// validateJWT joins the JWT violations as validateConfig reports them
+func validateJWT(jwt JWTConfig) error {
+	return errors.Join(jwtViolations(jwt)...)
+}
+
+func TestValidateJWT(t *testing.T) {
+	t.Run("should require secrets as long as the HMAC hash", func(t *testing.T) {
+		secret48 := strings.Repeat("s", 48)
+
+		assert.NoError(t, validateJWT(JWTConfig{Algorithm: "HS256", Secret: strings.Repeat("s", 32)}))
+		assert.NoError(t, validateJWT(JWTConfig{Algorithm: "HS384", Secret: secret48}))
+
+		err := validateJWT(JWTConfig{Algorithm: "HS512", Secret: secret48})
+		assert.ErrorIs(t, err, ErrInvalidJWTConfig)
+		assert.ErrorContains(t, err, "at least 64 bytes long for HS512, got 48")
+	})
+
+	t.Run("should reject the default secret", func(t *testing.T) {
+		err := validateJWT(JWTConfig{Algorithm: "HS256", Secret: "your-secret-key"})
+
+		assert.ErrorContains(t, err, "changed from default value")
+		assert.ErrorContains(t, err, "at least 32 bytes")
+	})
+
+	t.Run("should require existing key files for RSA", func(t *testing.T) {
+		dir := t.TempDir()
+		privateKey := filepath.Join(dir, "private.pem")
+		publicKey := filepath.Join(dir, "public.pem")
+		require.NoError(t, os.WriteFile(privateKey, []byte("key"), 0o600))
+		require.NoError(t, os.WriteFile(publicKey, []byte("key"), 0o600))
+
+		assert.NoError(t, validateJWT(JWTConfig{Algorithm: "RS256", PrivateKeyFile: privateKey, PublicKeyFile: publicKey}))
+
+		err := validateJWT(JWTConfig{Algorithm: "RS384"})
+		assert.ErrorIs(t, err, ErrInvalidJWTConfig)
+		assert.ErrorContains(t, err, "JWT_PRIVATE_KEY_FILE is required for RS384")
+		assert.ErrorContains(t, err, "JWT_PUBLIC_KEY_FILE is required for RS384")
+
+		err = validateJWT(JWTConfig{Algorithm: "RS512", PrivateKeyFile: privateKey, PublicKeyFile: filepath.Join(dir, "missing.pem")})
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.ErrorContains(t, err, "JWT_PUBLIC_KEY_FILE")
+	})
+
+	t.Run("should reject unsupported algorithms", func(t *testing.T) {
+		err := validateJWT(JWTConfig{Algorithm: "none", Secret: strings.Repeat("s", 64)})
+
+		assert.ErrorIs(t, err, ErrInvalidJWTConfig)
+		assert.ErrorContains(t, err, `JWT_ALGORITHM "none" is not supported`)
+	})
+}
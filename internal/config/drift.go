@@ -0,0 +1,139 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ConfigHistorySize is how many changes ConfigChangeHistory keeps
+const ConfigHistorySize = 20
+
+// redactedValue replaces the values of secret fields in config changes
+const redactedValue = "***"
+
+// redactedFields are the fields whose values never appear in config changes
+var redactedFields = []string{
+	"Auth.JWT.Secret",
+	"Database.Password",
+}
+
+// ConfigChange is a field whose value changed when the configuration was
+// reloaded
+type ConfigChange struct {
+	Field     string    `json:"field"`
+	OldValue  string    `json:"old_value"`
+	NewValue  string    `json:"new_value"`
+	ChangedAt time.Time `json:"changed_at"`
+}
+
+// DiffConfig compares two snapshots of a configuration struct, e.g. Config
+// or FeatureConfig, and returns a change for each field that differs, named
+// by its path below prefix such as "Features.MaintenanceMode". Secret fields
+// are reported with their values redacted.
+func DiffConfig(prefix string, old, new interface{}) []ConfigChange {
+	var changes []ConfigChange
+	diffValues(prefix, reflect.ValueOf(old), reflect.ValueOf(new), time.Now(), &changes)
+	return changes
+}
+
+func diffValues(field string, old, new reflect.Value, now time.Time, changes *[]ConfigChange) {
+	if old.Kind() == reflect.Pointer && new.Kind() == reflect.Pointer && !old.IsNil() && !new.IsNil() {
+		old, new = old.Elem(), new.Elem()
+	}
+
+	if old.Kind() == reflect.Struct && old.Type() == new.Type() && !isLeaf(old.Type()) {
+		for i := 0; i < old.NumField(); i++ {
+			structField := old.Type().Field(i)
+			if !structField.IsExported() {
+				continue
+			}
+			diffValues(joinField(field, structField.Name), old.Field(i), new.Field(i), now, changes)
+		}
+		return
+	}
+
+	if reflect.DeepEqual(old.Interface(), new.Interface()) {
+		return
+	}
+
+	change := ConfigChange{
+		Field:     field,
+		OldValue:  formatConfigValue(old),
+		NewValue:  formatConfigValue(new),
+		ChangedAt: now,
+	}
+	if slices.Contains(redactedFields, field) {
+		change.OldValue = redactedValue
+		change.NewValue = redactedValue
+	}
+	*changes = append(*changes, change)
+}
+
+// isLeaf reports whether a struct type is compared as a whole, like
+// time.Time, rather than field by field
+func isLeaf(t reflect.Type) bool {
+	return t.Implements(reflect.TypeOf((*fmt.Stringer)(nil)).Elem())
+}
+
+func joinField(prefix, name string) string {
+	if prefix == "" {
+		return name
+	}
+	return prefix + "." + name
+}
+
+func formatConfigValue(v reflect.Value) string {
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "<nil>"
+		}
+		v = v.Elem()
+	}
+	return fmt.Sprintf("%v", v.Interface())
+}
+
+// ConfigChangeHistory keeps the most recent configuration changes in a
+// circular buffer, for operators auditing runtime config changes
+type ConfigChangeHistory struct {
+	mu      sync.RWMutex
+	changes []ConfigChange
+	next    int
+	full    bool
+}
+
+// NewConfigChangeHistory creates a history keeping the last size changes
+func NewConfigChangeHistory(size int) *ConfigChangeHistory {
+	if size <= 0 {
+		size = ConfigHistorySize
+	}
+	return &ConfigChangeHistory{changes: make([]ConfigChange, size)}
+}
+
+// Record adds changes to the history, dropping the oldest ones once it is
+// full
+func (h *ConfigChangeHistory) Record(changes ...ConfigChange) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, change := range changes {
+		h.changes[h.next] = change
+		h.next = (h.next + 1) % len(h.changes)
+		if h.next == 0 {
+			h.full = true
+		}
+	}
+}
+
+// Changes returns the recorded changes, oldest first
+func (h *ConfigChangeHistory) Changes() []ConfigChange {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	if !h.full {
+		return slices.Clone(h.changes[:h.next])
+	}
+	return append(slices.Clone(h.changes[h.next:]), h.changes[:h.next]...)
+}
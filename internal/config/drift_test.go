@@ -0,0 +1,99 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDiffConfig(t *testing.T) {
+	t.Run("should report changed fields by path", func(t *testing.T) {
+		old := Config{}
+		old.Server.Port = "8080"
+		old.Server.ReadTimeout = 10 * time.Second
+		old.Features.MaintenanceMode = false
+		updated := old
+		updated.Server.Port = "9090"
+		updated.Server.ReadTimeout = 30 * time.Second
+		updated.Features.MaintenanceMode = true
+
+		changes := DiffConfig("", old, updated)
+
+		require.Len(t, changes, 3)
+		assert.Equal(t, "Server.Port", changes[0].Field)
+		assert.Equal(t, "8080", changes[0].OldValue)
+		assert.Equal(t, "9090", changes[0].NewValue)
+		assert.Equal(t, "Server.ReadTimeout", changes[1].Field)
+		assert.Equal(t, "10s", changes[1].OldValue)
+		assert.Equal(t, "30s", changes[1].NewValue)
+		assert.Equal(t, "Features.MaintenanceMode", changes[2].Field)
+		assert.Equal(t, "true", changes[2].NewValue)
+	})
+
+	t.Run("should prefix the fields of a section", func(t *testing.T) {
+		changes := DiffConfig("Features", FeatureConfig{}, FeatureConfig{Payments: true})
+
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Features.Payments", changes[0].Field)
+	})
+
+	t.Run("should redact secrets", func(t *testing.T) {
+		old := Config{}
+		old.Auth.JWT.Secret = "old-secret"
+		old.Database.Password = "old-password"
+		updated := old
+		updated.Auth.JWT.Secret = "new-secret"
+		updated.Database.Password = "new-password"
+
+		changes := DiffConfig("", &old, &updated)
+
+		require.Len(t, changes, 2)
+		for _, change := range changes {
+			assert.Equal(t, "***", change.OldValue, change.Field)
+			assert.Equal(t, "***", change.NewValue, change.Field)
+		}
+	})
+
+	t.Run("should report nothing for equal snapshots", func(t *testing.T) {
+		cfg := Config{}
+		cfg.Security.CORS.AllowedOrigins = []string{"https://example.com"}
+
+		assert.Empty(t, DiffConfig("", cfg, cfg))
+	})
+}
+
+func TestConfigChangeHistory(t *testing.T) {
+	change := func(i int) ConfigChange {
+		return ConfigChange{Field: fmt.Sprintf("Field%d", i)}
+	}
+
+	t.Run("should return the changes oldest first", func(t *testing.T) {
+		history := NewConfigChangeHistory(3)
+		history.Record(change(1), change(2))
+
+		assert.Equal(t, []ConfigChange{change(1), change(2)}, history.Changes())
+	})
+
+	t.Run("should keep only the most recent changes", func(t *testing.T) {
+		history := NewConfigChangeHistory(3)
+		for i := 1; i <= 5; i++ {
+			history.Record(change(i))
+		}
+
+		assert.Equal(t, []ConfigChange{change(3), change(4), change(5)}, history.Changes())
+	})
+
+	t.Run("should default to the last 20 changes", func(t *testing.T) {
+		history := NewConfigChangeHistory(0)
+		for i := 1; i <= 25; i++ {
+			history.Record(change(i))
+		}
+
+		changes := history.Changes()
+		require.Len(t, changes, ConfigHistorySize)
+		assert.Equal(t, change(6), changes[0])
+	})
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml/v2"
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	// loadMu serializes loading so fileValues belongs to one load at a time
+	loadMu sync.Mutex
+
+	// fileValues holds the settings of the config file being loaded, keyed
+	// by environment variable name
+	fileValues map[string]string
+)
+
+// LoadFromFile loads the configuration like Load, taking settings missing
+// from the environment from a YAML (.yaml, .yml) or TOML (.toml) file before
+// falling back to the built-in defaults. Nested keys are joined with
+// underscores and upper-cased to the environment variable they set, so
+//
+//	server:
+//	  port: 9000
+//	jwt:
+//	  secret: ...
+//
+// sets SERVER_PORT and JWT_SECRET. Lists become comma separated values.
+func LoadFromFile(path string) (*Config, error) {
+	values, err := readConfigFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	fileValues = values
+	defer func() { fileValues = nil }()
+
+	return load()
+}
+
+// readConfigFile parses the file into environment variable values
+func readConfigFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+	}
+
+	var tree map[string]interface{}
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q, use .yaml, .yml or .toml", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	values := make(map[string]string)
+	flattenConfig("", tree, values)
+	return values, nil
+}
+
+// flattenConfig stores the scalars of a parsed config file under their
+// environment variable names
+func flattenConfig(prefix string, node interface{}, values map[string]string) {
+	switch node := node.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(node))
+		for key := range node {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			name := strings.ToUpper(strings.NewReplacer("-", "_", ".", "_").Replace(key))
+			if prefix != "" {
+				name = prefix + "_" + name
+			}
+			flattenConfig(name, node[key], values)
+		}
+	case []interface{}:
+		items := make([]string, len(node))
+		for i, item := range node {
+			items[i] = fmt.Sprint(item)
+		}
+		values[prefix] = strings.Join(items, ",")
+	case nil:
+		values[prefix] = ""
+	default:
+		values[prefix] = fmt.Sprint(node)
+	}
+}
+
+// lookupEnv returns the value from the profile being loaded, then the
+// environment variable, falling back to the value from the config file
+// being loaded
+func lookupEnv(key string) string {
+	if value := profileValues[key]; value != "" {
+		return value
+	}
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fileValues[key]
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+
+	"github.com/joho/godotenv"
+)
+
+// ProductionProfile is the profile validated with the stricter production
+// rules
+const ProductionProfile = "production"
+
+// profileValues holds the settings of the .env and .env.<profile> files of
+// the profile being loaded, which take precedence over the environment
+var profileValues map[string]string
+
+// LoadProfile loads the configuration like Load with the settings of .env
+// and then .env.<env> (e.g. .env.production) on top of the environment:
+// values in .env.<env> override those in .env, which override environment
+// variables. The profile file must exist; .env is optional.
+//
+// Load calls LoadProfile when CONFIG_PROFILE is set in the environment.
+func LoadProfile(env string) (*Config, error) {
+	loadMu.Lock()
+	defer loadMu.Unlock()
+
+	return loadProfile(env)
+}
+
+func loadProfile(env string) (*Config, error) {
+	values, err := readProfile(env)
+	if err != nil {
+		return nil, err
+	}
+
+	profileValues = values
+	defer func() { profileValues = nil }()
+
+	return load()
+}
+
+// readProfile merges .env and .env.<env>, the latter winning
+func readProfile(env string) (map[string]string, error) {
+	values, err := godotenv.Read(".env")
+	if errors.Is(err, fs.ErrNotExist) {
+		values = make(map[string]string)
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	path := ".env." + env
+	profile, err := godotenv.Read(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config profile %s: %w", path, err)
+	}
+	maps.Copy(values, profile)
+
+	values["CONFIG_PROFILE"] = env
+	return values, nil
+}
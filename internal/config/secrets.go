@@ -0,0 +1,36 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/config/secrets"
+)
+
+// secretsTimeout bounds fetching the secrets referenced by the configuration
+const secretsTimeout = 30 * time.Second
+
+// newSecretsProvider creates the provider secrets are fetched from,
+// replaced in tests
+var newSecretsProvider = secrets.NewProvider
+
+// resolveSecrets replaces the $SECRET:<key> values of config with secrets
+// from the provider named by SECRETS_PROVIDER
+func resolveSecrets(config *Config) error {
+	if config.Secrets.Provider == "" {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretsTimeout)
+	defer cancel()
+
+	provider, err := newSecretsProvider(ctx, config.Secrets.Provider, config.Secrets.Prefix)
+	if err != nil {
+		return fmt.Errorf("failed to create secrets provider: %w", err)
+	}
+	if err := secrets.ResolveSecrets(ctx, config, provider); err != nil {
+		return fmt.Errorf("failed to resolve configuration secrets: %w", err)
+	}
+	return nil
+}
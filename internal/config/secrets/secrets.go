@@ -0,0 +1,141 @@
+// Package secrets replaces $SECRET:<key> references in the configuration
+// with values fetched from a secrets manager
+package secrets
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ReferencePrefix marks a configuration value to fetch from the secrets
+// manager, e.g. DB_PASSWORD=$SECRET:database/password
+const ReferencePrefix = "$SECRET:"
+
+// Providers the configuration can name in SECRETS_PROVIDER
+const (
+	ProviderAWSSSM           = "aws-ssm"
+	ProviderVault            = "vault"
+	ProviderGCPSecretManager = "gcp-secret-manager"
+)
+
+var (
+	// ErrSecretNotFound is returned when a referenced secret does not exist
+	ErrSecretNotFound = errors.New("secret not found")
+	// ErrUnsupportedProvider is returned by NewProvider for providers that
+	// are not implemented
+	ErrUnsupportedProvider = errors.New("unsupported secrets provider")
+)
+
+// Provider fetches secrets by key from a secrets manager
+type Provider interface {
+	// GetSecrets returns the value of every key, failing with
+	// ErrSecretNotFound when one does not exist
+	GetSecrets(ctx context.Context, keys []string) (map[string]string, error)
+}
+
+// NewProvider creates the provider named by SECRETS_PROVIDER, reading keys
+// under prefix
+func NewProvider(ctx context.Context, name, prefix string) (Provider, error) {
+	switch name {
+	case ProviderAWSSSM:
+		return NewAWSSSMProvider(ctx, prefix)
+	case ProviderVault, ProviderGCPSecretManager:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedProvider, name)
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnsupportedProvider, name)
+	}
+}
+
+// ResolveSecrets replaces every string field, map value and slice element
+// of cfg, a pointer to a struct, holding a $SECRET:<key> reference with the
+// secret's value. The secrets are fetched from provider in one call.
+func ResolveSecrets(ctx context.Context, cfg interface{}, provider Provider) error {
+	root := reflect.ValueOf(cfg)
+	if root.Kind() != reflect.Ptr || root.IsNil() {
+		return fmt.Errorf("secrets can only be resolved in a non-nil pointer, got %T", cfg)
+	}
+
+	var refs []reference
+	collectReferences(root, &refs)
+	if len(refs) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(refs))
+	seen := make(map[string]bool, len(refs))
+	for _, ref := range refs {
+		if !seen[ref.key] {
+			seen[ref.key] = true
+			keys = append(keys, ref.key)
+		}
+	}
+
+	values, err := provider.GetSecrets(ctx, keys)
+	if err != nil {
+		return fmt.Errorf("failed to fetch secrets: %w", err)
+	}
+
+	for _, ref := range refs {
+		value, ok := values[ref.key]
+		if !ok {
+			return fmt.Errorf("%w: %s", ErrSecretNotFound, ref.key)
+		}
+		ref.set(value)
+	}
+	return nil
+}
+
+// reference is a value holding a $SECRET:<key> reference, replaced with set
+type reference struct {
+	key string
+	set func(value string)
+}
+
+// collectReferences walks v, recording the strings holding references
+func collectReferences(v reflect.Value, refs *[]reference) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if !v.IsNil() {
+			collectReferences(v.Elem(), refs)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if v.Type().Field(i).IsExported() {
+				collectReferences(v.Field(i), refs)
+			}
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			collectReferences(v.Index(i), refs)
+		}
+	case reflect.Map:
+		if v.Type().Elem().Kind() != reflect.String {
+			for _, key := range v.MapKeys() {
+				collectReferences(v.MapIndex(key), refs)
+			}
+			return
+		}
+		for _, key := range v.MapKeys() {
+			if secretKey, ok := referenceKey(v.MapIndex(key).String()); ok {
+				m, key := v, key
+				*refs = append(*refs, reference{key: secretKey, set: func(value string) {
+					m.SetMapIndex(key, reflect.ValueOf(value).Convert(m.Type().Elem()))
+				}})
+			}
+		}
+	case reflect.String:
+		if secretKey, ok := referenceKey(v.String()); ok && v.CanSet() {
+			field := v
+			*refs = append(*refs, reference{key: secretKey, set: field.SetString})
+		}
+	}
+}
+
+// referenceKey returns the key of a $SECRET:<key> reference
+func referenceKey(value string) (string, bool) {
+	key, ok := strings.CutPrefix(value, ReferencePrefix)
+	return key, ok && key != ""
+}
@@ -0,0 +1,97 @@
+package secrets
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// mapProvider serves secrets from a map, counting its calls
+type mapProvider struct {
+	secrets map[string]string
+	calls   [][]string
+}
+
+func (p *mapProvider) GetSecrets(ctx context.Context, keys []string) (map[string]string, error) {
+	p.calls = append(p.calls, keys)
+	values := make(map[string]string, len(keys))
+	for _, key := range keys {
+		if value, ok := p.secrets[key]; ok {
+			values[key] = value
+		}
+	}
+	return values, nil
+}
+
+type databaseSettings struct {
+	Host     string
+	Password string
+}
+
+type testConfig struct {
+	Database databaseSettings
+	Cache    *databaseSettings
+	Tokens   []string
+	Headers  map[string]string
+	Port     int
+	private  string
+}
+
+func TestResolveSecrets(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should replace references in fields, pointers, slices and maps", func(t *testing.T) {
+		provider := &mapProvider{secrets: map[string]string{"db/password": "s3cret", "api/token": "t0ken"}}
+		cfg := &testConfig{
+			Database: databaseSettings{Host: "localhost", Password: "$SECRET:db/password"},
+			Cache:    &databaseSettings{Password: "$SECRET:db/password"},
+			Tokens:   []string{"plain", "$SECRET:api/token"},
+			Headers:  map[string]string{"Authorization": "$SECRET:api/token"},
+			private:  "$SECRET:db/password",
+		}
+
+		require.NoError(t, ResolveSecrets(ctx, cfg, provider))
+
+		assert.Equal(t, "localhost", cfg.Database.Host)
+		assert.Equal(t, "s3cret", cfg.Database.Password)
+		assert.Equal(t, "s3cret", cfg.Cache.Password)
+		assert.Equal(t, []string{"plain", "t0ken"}, cfg.Tokens)
+		assert.Equal(t, "t0ken", cfg.Headers["Authorization"])
+		assert.Equal(t, "$SECRET:db/password", cfg.private)
+		require.Len(t, provider.calls, 1)
+		assert.ElementsMatch(t, []string{"db/password", "api/token"}, provider.calls[0])
+	})
+
+	t.Run("should not call the provider without references", func(t *testing.T) {
+		provider := &mapProvider{}
+
+		require.NoError(t, ResolveSecrets(ctx, &testConfig{Database: databaseSettings{Password: "plain"}}, provider))
+
+		assert.Empty(t, provider.calls)
+	})
+
+	t.Run("should fail on missing secrets", func(t *testing.T) {
+		cfg := &testConfig{Database: databaseSettings{Password: "$SECRET:db/password"}}
+
+		err := ResolveSecrets(ctx, cfg, &mapProvider{})
+
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+		assert.ErrorContains(t, err, "db/password")
+	})
+
+	t.Run("should require a pointer", func(t *testing.T) {
+		assert.Error(t, ResolveSecrets(ctx, testConfig{}, &mapProvider{}))
+	})
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, name := range []string{ProviderVault, ProviderGCPSecretManager, "keychain"} {
+		t.Run("should reject "+name, func(t *testing.T) {
+			_, err := NewProvider(context.Background(), name, "")
+
+			assert.ErrorIs(t, err, ErrUnsupportedProvider)
+		})
+	}
+}
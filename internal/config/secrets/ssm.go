@@ -0,0 +1,122 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+)
+
+const (
+	// ssmBatchSize is the most names GetParameters accepts in one call
+	ssmBatchSize = 10
+	// ssmCacheTTL is how long fetched parameters are served from the cache
+	ssmCacheTTL = 5 * time.Minute
+)
+
+// ssmAPI is the part of the SSM client the provider uses
+type ssmAPI interface {
+	GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error)
+}
+
+// AWSSSMProvider fetches secrets from AWS Systems Manager Parameter Store,
+// decrypting SecureString parameters. The key of a reference is appended to
+// the prefix to name the parameter, e.g. /myapp/prod/ and database/password
+// read /myapp/prod/database/password. Parameters are cached for 5 minutes.
+type AWSSSMProvider struct {
+	client ssmAPI
+	prefix string
+	now    func() time.Time
+	cache  map[string]cachedSecret
+	mu     sync.Mutex
+}
+
+type cachedSecret struct {
+	value   string
+	expires time.Time
+}
+
+// NewAWSSSMProvider creates a Parameter Store provider with the default AWS
+// credentials and region of the environment
+func NewAWSSSMProvider(ctx context.Context, prefix string) (*AWSSSMProvider, error) {
+	cfg, err := awsconfig.LoadDefaultConfig(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	return newAWSSSMProvider(ssm.NewFromConfig(cfg), prefix), nil
+}
+
+func newAWSSSMProvider(client ssmAPI, prefix string) *AWSSSMProvider {
+	return &AWSSSMProvider{
+		client: client,
+		prefix: prefix,
+		now:    time.Now,
+		cache:  make(map[string]cachedSecret),
+	}
+}
+
+// GetSecrets returns the parameters named by keys, fetching those not
+// cached with GetParameters in batches of ten
+func (p *AWSSSMProvider) GetSecrets(ctx context.Context, keys []string) (map[string]string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := p.now()
+	values := make(map[string]string, len(keys))
+	byName := make(map[string]string)
+	var names []string
+	for _, key := range keys {
+		if cached, ok := p.cache[key]; ok && now.Before(cached.expires) {
+			values[key] = cached.value
+			continue
+		}
+		name := p.parameterName(key)
+		if _, queued := byName[name]; !queued {
+			names = append(names, name)
+		}
+		byName[name] = key
+	}
+
+	var missing []string
+	for start := 0; start < len(names); start += ssmBatchSize {
+		batch := names[start:min(start+ssmBatchSize, len(names))]
+		out, err := p.client.GetParameters(ctx, &ssm.GetParametersInput{
+			Names:          batch,
+			WithDecryption: aws.Bool(true),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get SSM parameters: %w", err)
+		}
+
+		for _, parameter := range out.Parameters {
+			key, ok := byName[aws.ToString(parameter.Name)]
+			if !ok {
+				continue
+			}
+			value := aws.ToString(parameter.Value)
+			values[key] = value
+			p.cache[key] = cachedSecret{value: value, expires: now.Add(ssmCacheTTL)}
+		}
+		missing = append(missing, out.InvalidParameters...)
+	}
+
+	if len(missing) > 0 {
+		sort.Strings(missing)
+		return nil, fmt.Errorf("%w: %s", ErrSecretNotFound, strings.Join(missing, ", "))
+	}
+	return values, nil
+}
+
+// parameterName joins the prefix and key into a parameter name
+func (p *AWSSSMProvider) parameterName(key string) string {
+	if p.prefix == "" {
+		return key
+	}
+	return strings.TrimSuffix(p.prefix, "/") + "/" + strings.TrimPrefix(key, "/")
+}
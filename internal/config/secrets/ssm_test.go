@@ -0,0 +1,110 @@
+package secrets
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/ssm"
+	"github.com/aws/aws-sdk-go-v2/service/ssm/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSSM serves parameters from a map, recording the names of each call
+type fakeSSM struct {
+	parameters map[string]string
+	calls      [][]string
+	err        error
+}
+
+func (f *fakeSSM) GetParameters(ctx context.Context, params *ssm.GetParametersInput, optFns ...func(*ssm.Options)) (*ssm.GetParametersOutput, error) {
+	f.calls = append(f.calls, params.Names)
+	if f.err != nil {
+		return nil, f.err
+	}
+
+	out := &ssm.GetParametersOutput{}
+	for _, name := range params.Names {
+		if value, ok := f.parameters[name]; ok {
+			out.Parameters = append(out.Parameters, types.Parameter{Name: aws.String(name), Value: aws.String(value)})
+			continue
+		}
+		out.InvalidParameters = append(out.InvalidParameters, name)
+	}
+	return out, nil
+}
+
+func TestAWSSSMProvider(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should read parameters under the prefix", func(t *testing.T) {
+		client := &fakeSSM{parameters: map[string]string{"/app/prod/db/password": "s3cret"}}
+		provider := newAWSSSMProvider(client, "/app/prod/")
+
+		values, err := provider.GetSecrets(ctx, []string{"db/password"})
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"db/password": "s3cret"}, values)
+		assert.Equal(t, [][]string{{"/app/prod/db/password"}}, client.calls)
+	})
+
+	t.Run("should fetch in batches of ten", func(t *testing.T) {
+		client := &fakeSSM{parameters: map[string]string{}}
+		var keys []string
+		for i := 0; i < 25; i++ {
+			key := string(rune('a' + i))
+			client.parameters[key] = key + "-value"
+			keys = append(keys, key)
+		}
+		provider := newAWSSSMProvider(client, "")
+
+		values, err := provider.GetSecrets(ctx, keys)
+
+		require.NoError(t, err)
+		assert.Len(t, values, 25)
+		require.Len(t, client.calls, 3)
+		assert.Len(t, client.calls[0], 10)
+		assert.Len(t, client.calls[2], 5)
+	})
+
+	t.Run("should cache parameters for five minutes", func(t *testing.T) {
+		client := &fakeSSM{parameters: map[string]string{"token": "t0ken"}}
+		provider := newAWSSSMProvider(client, "")
+		now := time.Now()
+		provider.now = func() time.Time { return now }
+
+		_, err := provider.GetSecrets(ctx, []string{"token"})
+		require.NoError(t, err)
+		now = now.Add(4 * time.Minute)
+		values, err := provider.GetSecrets(ctx, []string{"token"})
+		require.NoError(t, err)
+		assert.Equal(t, "t0ken", values["token"])
+		assert.Len(t, client.calls, 1)
+
+		now = now.Add(2 * time.Minute)
+		_, err = provider.GetSecrets(ctx, []string{"token"})
+		require.NoError(t, err)
+		assert.Len(t, client.calls, 2)
+	})
+
+	t.Run("should report missing parameters", func(t *testing.T) {
+		provider := newAWSSSMProvider(&fakeSSM{parameters: map[string]string{}}, "/app")
+
+		_, err := provider.GetSecrets(ctx, []string{"b", "a"})
+
+		assert.ErrorIs(t, err, ErrSecretNotFound)
+		assert.ErrorContains(t, err, "/app/a, /app/b")
+	})
+
+	t.Run("should fail when SSM fails", func(t *testing.T) {
+		errSSM := errors.New("throttled")
+		provider := newAWSSSMProvider(&fakeSSM{err: errSSM}, "")
+
+		_, err := provider.GetSecrets(ctx, []string{"token"})
+
+		assert.ErrorIs(t, err, errSSM)
+	})
+}
@@ -0,0 +1,51 @@
+package config
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config/secrets"
+)
+
+// staticSecrets serves secrets from a map
+type staticSecrets map[string]string
+
+func (s staticSecrets) GetSecrets(ctx context.Context, keys []string) (map[string]string, error) {
+	return s, nil
+}
+
+func TestLoadResolvesSecrets(t *testing.T) {
+	t.Setenv("JWT_SECRET", "$SECRET:jwt/secret")
+	t.Setenv("DB_PASSWORD", "$SECRET:db/password")
+	t.Setenv("SECRETS_PROVIDER", secrets.ProviderAWSSSM)
+	t.Setenv("SECRETS_PREFIX", "/app/prod/")
+
+	var provider, prefix string
+	restore := newSecretsProvider
+	newSecretsProvider = func(ctx context.Context, name, keyPrefix string) (secrets.Provider, error) {
+		provider, prefix = name, keyPrefix
+		return staticSecrets{"jwt/secret": "test-secret-key-for-testing-123456789", "db/password": "s3cret"}, nil
+	}
+	t.Cleanup(func() { newSecretsProvider = restore })
+
+	t.Run("should replace references with secrets before validating", func(t *testing.T) {
+		cfg, err := Load()
+
+		require.NoError(t, err)
+		assert.Equal(t, "aws-ssm", provider)
+		assert.Equal(t, "/app/prod/", prefix)
+		assert.Equal(t, "test-secret-key-for-testing-123456789", cfg.Auth.JWT.Secret)
+		assert.Equal(t, "s3cret", cfg.Database.Password)
+	})
+
+	t.Run("should fail on unknown secrets", func(t *testing.T) {
+		t.Setenv("REDIS_PASSWORD", "$SECRET:redis/password")
+
+		_, err := Load()
+
+		assert.ErrorIs(t, err, secrets.ErrSecretNotFound)
+	})
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// ConfigValidationError reports every violation found while validating the
+// configuration, so all of them can be fixed in one go
+type ConfigValidationError struct {
+	errs []error
+}
+
+func (e *ConfigValidationError) Error() string {
+	messages := make([]string, len(e.errs))
+	for i, err := range e.errs {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d configuration errors: %s", len(e.errs), strings.Join(messages, "; "))
+}
+
+// Errors returns the violations in the order they were found
+func (e *ConfigValidationError) Errors() []error {
+	return e.errs
+}
+
+// Unwrap lets errors.Is and errors.As match any of the violations
+func (e *ConfigValidationError) Unwrap() []error {
+	return e.errs
+}
+
+// Table formats the violations as a numbered table for printing on startup
+// failure
+func (e *ConfigValidationError) Table() string {
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tCONFIGURATION ERROR")
+	for i, err := range e.errs {
+		fmt.Fprintf(w, "%d\t%s\n", i+1, err)
+	}
+	w.Flush()
+	return sb.String()
+}
+
+// ValidateEmailTemplates parses every *.html file in the template directory
+// and reports each one that fails as a ConfigValidationError, so a broken
+// template stops startup instead of the first email rendered with it. An
+// empty TemplateDir is not checked.
+func ValidateEmailTemplates(cfg *EmailConfig) error {
+	if cfg.TemplateDir == "" {
+		return nil
+	}
+
+	info, err := os.Stat(cfg.TemplateDir)
+	if err != nil {
+		return fmt.Errorf("EMAIL_TEMPLATE_DIR cannot be read: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("EMAIL_TEMPLATE_DIR %s is not a directory", cfg.TemplateDir)
+	}
+
+	files, err := filepath.Glob(filepath.Join(cfg.TemplateDir, "*.html"))
+	if err != nil {
+		return fmt.Errorf("failed to list email templates: %w", err)
+	}
+
+	var errs []error
+	for _, file := range files {
+		if _, err := template.ParseFiles(file); err != nil {
+			errs = append(errs, fmt.Errorf("invalid email template: %w", err))
+		}
+	}
+
+	if len(errs) > 0 {
+		return &ConfigValidationError{errs: errs}
+	}
+	return nil
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// negativeDurations reports the time.Duration settings below zero, named by
+// their path in Config. Zero is allowed since several settings use it for
+// unset or disabled.
+func negativeDurations(config *Config) []error {
+	var errs []error
+	var walk func(path string, v reflect.Value)
+	walk = func(path string, v reflect.Value) {
+		switch {
+		case v.Type() == durationType:
+			if d := time.Duration(v.Int()); d < 0 {
+				errs = append(errs, fmt.Errorf("%s must not be negative, got %s", path, d))
+			}
+		case v.Kind() == reflect.Pointer:
+			if !v.IsNil() {
+				walk(path, v.Elem())
+			}
+		case v.Kind() == reflect.Struct:
+			for i := 0; i < v.NumField(); i++ {
+				if field := v.Type().Field(i); field.IsExported() {
+					walk(strings.TrimPrefix(path+"."+field.Name, "."), v.Field(i))
+				}
+			}
+		case v.Kind() == reflect.Slice:
+			for i := 0; i < v.Len(); i++ {
+				walk(path+"["+strconv.Itoa(i)+"]", v.Index(i))
+			}
+		}
+	}
+
+	walk("", reflect.ValueOf(config).Elem())
+	return errs
+}
@@ -0,0 +1,256 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfig(t *testing.T) {
+	os.Setenv("JWT_SECRET", "test-secret-key-for-testing-123456789")
+	defer os.Unsetenv("JWT_SECRET")
+
+	valid, err := Load()
+	require.NoError(t, err)
+
+	t.Run("should accept the defaults", func(t *testing.T) {
+		config := *valid
+
+		assert.NoError(t, validateConfig(&config))
+	})
+
+	t.Run("should report every violation at once", func(t *testing.T) {
+		config := *valid
+		config.Auth.JWT.Secret = "short"
+		config.Auth.JWT.RefreshExpiration = config.Auth.JWT.Expiration
+		config.Redis.PoolSize = 2
+		config.Redis.MinIdleConns = 5
+		config.Storage.MaxUploadSizeMB = 0
+		config.Redis.DialTimeout = -time.Second
+
+		err := validateConfig(&config)
+
+		var validationErr *ConfigValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Errors(), 5)
+		assert.ErrorIs(t, err, ErrInvalidJWTConfig)
+		assert.ErrorContains(t, err, "5 configuration errors")
+		assert.ErrorContains(t, err, "JWT_REFRESH_EXPIRATION_HOURS (24h0m0s) must be longer than JWT_EXPIRATION_HOURS (24h0m0s)")
+		assert.ErrorContains(t, err, "REDIS_POOL_SIZE (2) must be at least REDIS_MIN_IDLE_CONNS (5)")
+		assert.ErrorContains(t, err, "MAX_UPLOAD_SIZE_MB must be positive, got 0")
+		assert.ErrorContains(t, err, "Redis.DialTimeout must not be negative, got -1s")
+	})
+
+	t.Run("should check the settings of the email provider", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			email NotificationEmailConfig
+			want  []string
+		}{
+			{
+				name:  "smtp",
+				email: NotificationEmailConfig{Enabled: true, Provider: "smtp", SMTP: &SMTPConfig{Port: 70000}},
+				want:  []string{"NOTIFICATION_SMTP_HOST is required", "NOTIFICATION_SMTP_PORT must be between 1 and 65535, got 70000"},
+			},
+			{
+				name:  "sendgrid",
+				email: NotificationEmailConfig{Enabled: true, Provider: "sendgrid", SendGrid: &SendGridConfig{}},
+				want:  []string{"NOTIFICATION_SENDGRID_API_KEY is required", "NOTIFICATION_SENDGRID_FROM_EMAIL is required"},
+			},
+			{
+				name:  "unknown provider",
+				email: NotificationEmailConfig{Enabled: true, Provider: "pigeon"},
+				want:  []string{`NOTIFICATION_EMAIL_PROVIDER must be smtp or sendgrid, got "pigeon"`},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				config := *valid
+				config.Notification.Email = tt.email
+
+				err := validateConfig(&config)
+
+				for _, want := range tt.want {
+					assert.ErrorContains(t, err, want)
+				}
+			})
+		}
+	})
+
+	t.Run("should check the settings of EMAIL_PROVIDER", func(t *testing.T) {
+		tests := []struct {
+			name  string
+			email EmailConfig
+			want  []string
+		}{
+			{
+				name:  "smtp",
+				email: EmailConfig{Provider: "smtp", SMTP: SMTPConfig{Port: 0}},
+				want:  []string{"SMTP_HOST is required", "SMTP_PORT must be between 1 and 65535, got 0", "EMAIL_FROM_ADDRESS is required"},
+			},
+			{
+				name:  "sendgrid",
+				email: EmailConfig{Provider: "sendgrid", FromAddress: "noreply@example.com"},
+				want:  []string{"SENDGRID_API_KEY is required"},
+			},
+			{
+				name:  "mailgun",
+				email: EmailConfig{Provider: "mailgun", FromAddress: "noreply@example.com", Mailgun: MailgunConfig{APIKey: "key"}},
+				want:  []string{"MAILGUN_API_KEY and MAILGUN_DOMAIN are required"},
+			},
+			{
+				name:  "unknown provider",
+				email: EmailConfig{Provider: "pigeon", FromAddress: "noreply@example.com"},
+				want:  []string{`EMAIL_PROVIDER must be smtp, sendgrid, mailgun or ses, got "pigeon"`},
+			},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				config := *valid
+				config.Email = tt.email
+
+				err := validateConfig(&config)
+
+				for _, want := range tt.want {
+					assert.ErrorContains(t, err, want)
+				}
+			})
+		}
+	})
+
+	t.Run("should report each broken email template", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.html"), []byte(`<p>Hi {{.Name}}</p>`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "reset.html"), []byte(`<p>{{.Link</p>`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "invoice.html"), []byte(`{{if .Paid}}paid`), 0o644))
+
+		config := *valid
+		config.Email = EmailConfig{Provider: "sendgrid", FromAddress: "noreply@example.com", SendGrid: SimpleSendGridConfig{APIKey: "key"}, TemplateDir: dir}
+
+		err := validateConfig(&config)
+
+		var validationErr *ConfigValidationError
+		require.ErrorAs(t, err, &validationErr)
+		require.Len(t, validationErr.Errors(), 2)
+		assert.ErrorContains(t, err, "invoice.html")
+		assert.ErrorContains(t, err, "reset.html")
+	})
+
+	t.Run("should check the service level objectives", func(t *testing.T) {
+		config := *valid
+		config.Monitoring.SLO.AvailabilityTarget = 99.9
+		config.Monitoring.SLO.Windows = nil
+
+		err := validateConfig(&config)
+
+		assert.ErrorContains(t, err, "SLO_AVAILABILITY_TARGET (99.9) and SLO_LATENCY_PERCENTILE (0.99) must be between 0 and 1")
+		assert.ErrorContains(t, err, "SLO_WINDOWS must list at least one window")
+	})
+
+	t.Run("should require an OAuth client for social login", func(t *testing.T) {
+		config := *valid
+		config.Features.SocialLogin = true
+		config.External.Google = GoogleConfig{}
+		config.External.GitHub = GitHubConfig{}
+
+		assert.ErrorContains(t, validateConfig(&config), "FEATURE_SOCIAL_LOGIN requires GOOGLE_CLIENT_ID or GITHUB_CLIENT_ID")
+
+		config.External.GitHub = GitHubConfig{ClientID: "client"}
+		assert.ErrorContains(t, validateConfig(&config), "GITHUB_CLIENT_SECRET and GITHUB_REDIRECT_URL are required with GITHUB_CLIENT_ID")
+
+		config.External.GitHub = GitHubConfig{ClientID: "client", ClientSecret: "secret", RedirectURL: "http://localhost:8080/api/v1/auth/github/callback"}
+		assert.NoError(t, validateConfig(&config))
+	})
+
+	t.Run("should require a certificate for HTTP/2 and the HTTPS redirect", func(t *testing.T) {
+		config := *valid
+		config.Server.EnableHTTP2 = true
+		config.Server.HTTPRedirectPort = config.Server.Port
+
+		err := validateConfig(&config)
+
+		assert.ErrorContains(t, err, "ENABLE_HTTP2 requires TLS_CERT_FILE and TLS_KEY_FILE")
+		assert.ErrorContains(t, err, "HTTP_REDIRECT_PORT requires TLS_CERT_FILE and TLS_KEY_FILE")
+		assert.ErrorContains(t, err, "HTTP_REDIRECT_PORT must differ from SERVER_PORT")
+
+		config.Server.TLSCertFile, config.Server.TLSKeyFile = "server.crt", "server.key"
+		config.Server.HTTPRedirectPort = "8000"
+		assert.NoError(t, validateConfig(&config))
+	})
+
+	t.Run("should check the settings of the virus scanner", func(t *testing.T) {
+		config := *valid
+		config.Storage.Scanner = ScannerConfig{Enabled: true, Engine: "virustotal"}
+		assert.ErrorContains(t, validateConfig(&config), "VIRUSTOTAL_API_KEY is required for the virustotal scanner")
+
+		config.Storage.Scanner = ScannerConfig{Enabled: true, Engine: "clamav"}
+		assert.ErrorContains(t, validateConfig(&config), "CLAMAV_SOCKET is required for the clamav scanner")
+
+		config.Storage.Scanner = ScannerConfig{Enabled: true, Engine: "sophos"}
+		assert.ErrorContains(t, validateConfig(&config), `STORAGE_SCANNER_ENGINE must be clamav or virustotal, got "sophos"`)
+
+		config.Storage.Scanner = ScannerConfig{Enabled: true, Engine: "clamav", ClamAVSocket: "/var/run/clamav/clamd.ctl"}
+		assert.NoError(t, validateConfig(&config))
+	})
+
+	t.Run("should apply the production rules to the production profile", func(t *testing.T) {
+		config := *valid
+		config.Server.EnableMetrics = false
+
+		assert.NoError(t, validateConfig(&config))
+
+		config.App.Profile = ProductionProfile
+		err := validateConfig(&config)
+
+		assert.ErrorContains(t, err, "DB_PASSWORD must be changed from its default in the production profile")
+		assert.ErrorContains(t, err, "the production profile requires TLS")
+		assert.ErrorContains(t, err, "ENABLE_METRICS must be true in the production profile")
+
+		config.Database.Password = "s3cret"
+		config.Server.TLSCertFile, config.Server.TLSKeyFile = "server.crt", "server.key"
+		config.Server.EnableMetrics = true
+		assert.NoError(t, validateConfig(&config))
+	})
+
+	t.Run("should format the violations as a table", func(t *testing.T) {
+		err := &ConfigValidationError{errs: []error{
+			assert.AnError,
+			os.ErrNotExist,
+		}}
+
+		lines := strings.Split(strings.TrimSpace(err.Table()), "\n")
+
+		require.Len(t, lines, 3)
+		assert.Equal(t, "#  CONFIGURATION ERROR", lines[0])
+		assert.Equal(t, "1  "+assert.AnError.Error(), lines[1])
+		assert.Equal(t, "2  file does not exist", lines[2])
+	})
+}
+
+func TestValidateEmailTemplates(t *testing.T) {
+	t.Run("should accept templates that parse", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "welcome.html"), []byte(`{{define "body"}}Hi {{.Name}}{{end}}`), 0o644))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte(`{{`), 0o644))
+
+		assert.NoError(t, ValidateEmailTemplates(&EmailConfig{TemplateDir: dir}))
+	})
+
+	t.Run("should skip an unset directory", func(t *testing.T) {
+		assert.NoError(t, ValidateEmailTemplates(&EmailConfig{}))
+	})
+
+	t.Run("should reject a missing directory", func(t *testing.T) {
+		err := ValidateEmailTemplates(&EmailConfig{TemplateDir: filepath.Join(t.TempDir(), "missing")})
+
+		assert.ErrorIs(t, err, os.ErrNotExist)
+		assert.ErrorContains(t, err, "EMAIL_TEMPLATE_DIR cannot be read")
+	})
+}
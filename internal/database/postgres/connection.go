@@ -16,7 +16,12 @@ func NewConnection(cfg *config.DatabaseConfig) (*sql.DB, error) {
 		cfg.Host, cfg.Port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode,
 	)
 
-	db, err := sql.Open("postgres", dsn)
+	driver := cfg.Driver
+	if driver == "" {
+		driver = "postgres"
+	}
+
+	db, err := sql.Open(driver, dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -26,6 +31,7 @@ func NewConnection(cfg *config.DatabaseConfig) (*sql.DB, error) {
 	db.SetConnMaxLifetime(time.Hour)
 
 	if err := db.Ping(); err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
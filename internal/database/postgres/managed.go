@@ -0,0 +1,288 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const (
+	defaultManagedInterval = 30 * time.Second
+	defaultManagedRetries  = 3
+)
+
+// HealthStatus is the health of a managed connection as the /health
+// endpoint reports it
+type HealthStatus struct {
+	Healthy             bool      `json:"healthy"`
+	ConsecutiveFailures int       `json:"consecutive_failures"`
+	Reconnects          int       `json:"reconnects"`
+	LastCheck           time.Time `json:"last_check,omitempty"`
+	LastError           string    `json:"last_error,omitempty"`
+	OpenConnections     int       `json:"open_connections"`
+	InUse               int       `json:"in_use"`
+}
+
+// ManagedOption configures a ManagedDB
+type ManagedOption func(*ManagedDB)
+
+// WithManagedLogger logs failed pings and reconnections
+func WithManagedLogger(logger *logger.Logger) ManagedOption {
+	return func(m *ManagedDB) {
+		m.logger = logger
+	}
+}
+
+// ManagedDB is a connection pinged in the background every
+// HealthCheck.Interval. After HealthCheck.Retries consecutive failed pings
+// it reconnects, replacing the embedded *sql.DB with a new pool.
+//
+// The query methods read the current pool under a lock; hold on to the
+// ManagedDB rather than its *sql.DB, which is closed on reconnection.
+type ManagedDB struct {
+	*sql.DB
+
+	cfg     *config.DatabaseConfig
+	logger  *logger.Logger
+	connect func(*config.DatabaseConfig) (*sql.DB, error)
+
+	interval time.Duration
+	timeout  time.Duration
+	retries  int
+
+	mu         sync.RWMutex
+	failures   int
+	reconnects int
+	lastCheck  time.Time
+	lastErr    error
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewManagedConnection connects like NewConnection and starts health
+// checking the connection. Close it to stop the health checks.
+func NewManagedConnection(cfg *config.DatabaseConfig, opts ...ManagedOption) (*ManagedDB, error) {
+	m := &ManagedDB{
+		cfg:      cfg,
+		connect:  NewConnection,
+		interval: cfg.HealthCheck.Interval,
+		timeout:  cfg.HealthCheck.Timeout,
+		retries:  cfg.HealthCheck.Retries,
+		done:     make(chan struct{}),
+	}
+	if m.interval <= 0 {
+		m.interval = defaultManagedInterval
+	}
+	if m.timeout <= 0 {
+		m.timeout = healthCheckTimeout
+	}
+	if m.retries <= 0 {
+		m.retries = defaultManagedRetries
+	}
+
+	for _, opt := range opts {
+		opt(m)
+	}
+
+	db, err := m.connect(cfg)
+	if err != nil {
+		return nil, err
+	}
+	m.DB = db
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancel = cancel
+	go m.monitor(ctx)
+
+	return m, nil
+}
+
+// ExecContext executes a query without returning rows
+func (m *ManagedDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return m.current().ExecContext(ctx, query, args...)
+}
+
+// Exec executes a query without returning rows
+func (m *ManagedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return m.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext executes a query returning rows
+func (m *ManagedDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return m.current().QueryContext(ctx, query, args...)
+}
+
+// Query executes a query returning rows
+func (m *ManagedDB) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return m.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext executes a query returning at most one row
+func (m *ManagedDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return m.current().QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow executes a query returning at most one row
+func (m *ManagedDB) QueryRow(query string, args ...interface{}) *sql.Row {
+	return m.QueryRowContext(context.Background(), query, args...)
+}
+
+// BeginTx starts a transaction
+func (m *ManagedDB) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return m.current().BeginTx(ctx, opts)
+}
+
+// Begin starts a transaction
+func (m *ManagedDB) Begin() (*sql.Tx, error) {
+	return m.BeginTx(context.Background(), nil)
+}
+
+// PingContext checks the database can be reached
+func (m *ManagedDB) PingContext(ctx context.Context) error {
+	return m.current().PingContext(ctx)
+}
+
+// Ping checks the database can be reached
+func (m *ManagedDB) Ping() error {
+	return m.PingContext(context.Background())
+}
+
+// WithTimeout bounds each query run on m with timeout, like NewTimeoutDB.
+// The queries follow m across reconnections; only they go through the
+// TimeoutDB, so use m itself for anything else.
+func (m *ManagedDB) WithTimeout(timeout time.Duration, logger *logger.Logger) *TimeoutDB {
+	t := NewTimeoutDB(m.current(), timeout, logger)
+	t.conn = m
+	return t
+}
+
+// IsHealthy reports whether fewer than HealthCheck.Retries pings in a row
+// have failed
+func (m *ManagedDB) IsHealthy() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.failures < m.retries
+}
+
+// HealthStatus reports the result of the last health checks
+func (m *ManagedDB) HealthStatus() HealthStatus {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	stats := m.DB.Stats()
+	status := HealthStatus{
+		Healthy:             m.failures < m.retries,
+		ConsecutiveFailures: m.failures,
+		Reconnects:          m.reconnects,
+		LastCheck:           m.lastCheck,
+		OpenConnections:     stats.OpenConnections,
+		InUse:               stats.InUse,
+	}
+	if m.lastErr != nil {
+		status.LastError = m.lastErr.Error()
+	}
+	return status
+}
+
+// Reconnect opens a new pool with NewConnection and closes the old one once
+// it is replaced. The old pool stays in use when the new one cannot connect.
+func (m *ManagedDB) Reconnect() error {
+	db, err := m.connect(m.cfg)
+	if err != nil {
+		return fmt.Errorf("failed to reconnect: %w", err)
+	}
+
+	m.mu.Lock()
+	old := m.DB
+	m.DB = db
+	m.failures = 0
+	m.lastErr = nil
+	m.reconnects++
+	m.mu.Unlock()
+
+	// Close waits for the queries already running on the old pool
+	if err := old.Close(); err != nil && m.logger != nil {
+		m.logger.Warn("Failed to close the replaced database pool", "error", err)
+	}
+	return nil
+}
+
+// Close stops the health checks and closes the connection
+func (m *ManagedDB) Close() error {
+	m.cancel()
+	<-m.done
+	return m.current().Close()
+}
+
+func (m *ManagedDB) current() *sql.DB {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.DB
+}
+
+// monitor pings the database each interval until ctx is cancelled
+func (m *ManagedDB) monitor(ctx context.Context) {
+	defer close(m.done)
+
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkHealth(ctx)
+		}
+	}
+}
+
+func (m *ManagedDB) checkHealth(ctx context.Context) {
+	pingCtx, cancel := context.WithTimeout(ctx, m.timeout)
+	err := m.current().PingContext(pingCtx)
+	cancel()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	m.mu.Lock()
+	m.lastCheck = time.Now()
+	m.lastErr = err
+	if err == nil {
+		if m.failures >= m.retries && m.logger != nil {
+			m.logger.Info("Database connection recovered")
+		}
+		m.failures = 0
+		m.mu.Unlock()
+		return
+	}
+	m.failures++
+	failures := m.failures
+	m.mu.Unlock()
+
+	if m.logger != nil {
+		m.logger.Warn("Database health check failed", "failures", failures, "error", err)
+	}
+	if failures < m.retries {
+		return
+	}
+
+	if err := m.Reconnect(); err != nil {
+		if m.logger != nil {
+			m.logger.Error("Database reconnection failed", "error", err)
+		}
+		return
+	}
+	if m.logger != nil {
+		m.logger.Info("Database reconnected", "after_failures", failures)
+	}
+}
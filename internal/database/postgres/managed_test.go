@@ -0,0 +1,115 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+func newTestManagedDB(t *testing.T, interval time.Duration) (*ManagedDB, string) {
+	t.Helper()
+
+	host := "managed-" + strings.ReplaceAll(t.Name(), "/", "-")
+	db, err := NewManagedConnection(&config.DatabaseConfig{
+		Driver: "pooltest",
+		Host:   host,
+		Port:   "5432",
+		HealthCheck: config.HealthCheckConfig{
+			Interval: interval,
+			Timeout:  time.Second,
+			Retries:  2,
+		},
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+
+	return db, host + ":5432"
+}
+
+func TestManagedDB(t *testing.T) {
+	t.Run("should fail when the database cannot be reached", func(t *testing.T) {
+		setServerDown("unreachable:5432", true)
+		defer setServerDown("unreachable:5432", false)
+
+		_, err := NewManagedConnection(&config.DatabaseConfig{Driver: "pooltest", Host: "unreachable", Port: "5432"})
+
+		assert.ErrorContains(t, err, "failed to ping database")
+	})
+
+	t.Run("should turn unhealthy after consecutive failed pings", func(t *testing.T) {
+		db, addr := newTestManagedDB(t, 10*time.Millisecond)
+		assert.True(t, db.IsHealthy())
+
+		setServerDown(addr, true)
+		defer setServerDown(addr, false)
+		assert.Eventually(t, func() bool {
+			return !db.IsHealthy()
+		}, time.Second, 5*time.Millisecond)
+
+		status := db.HealthStatus()
+		assert.False(t, status.Healthy)
+		assert.GreaterOrEqual(t, status.ConsecutiveFailures, 2)
+		assert.NotEmpty(t, status.LastError)
+
+		setServerDown(addr, false)
+		assert.Eventually(t, db.IsHealthy, time.Second, 5*time.Millisecond)
+		assert.Empty(t, db.HealthStatus().LastError)
+	})
+
+	t.Run("should reconnect when the pool keeps failing", func(t *testing.T) {
+		db, _ := newTestManagedDB(t, 10*time.Millisecond)
+		var connects atomic.Int32
+		db.mu.Lock()
+		db.connect = func(cfg *config.DatabaseConfig) (*sql.DB, error) {
+			connects.Add(1)
+			return NewConnection(cfg)
+		}
+		broken := db.DB
+		db.mu.Unlock()
+
+		// A closed pool fails every ping until it is replaced
+		require.NoError(t, broken.Close())
+
+		assert.Eventually(t, func() bool {
+			return db.HealthStatus().Reconnects == 1
+		}, time.Second, 5*time.Millisecond)
+		assert.Equal(t, int32(1), connects.Load())
+		assert.True(t, db.IsHealthy())
+		assert.NotSame(t, broken, db.current())
+		assert.NoError(t, db.PingContext(context.Background()))
+	})
+
+	t.Run("should keep the old pool when reconnecting fails", func(t *testing.T) {
+		db, addr := newTestManagedDB(t, time.Hour)
+		pool := db.current()
+
+		setServerDown(addr, true)
+		err := db.Reconnect()
+		setServerDown(addr, false)
+
+		assert.ErrorContains(t, err, "failed to reconnect")
+		assert.Same(t, pool, db.current())
+		assert.Zero(t, db.HealthStatus().Reconnects)
+	})
+	t.Run("should run timed queries on the current pool", func(t *testing.T) {
+		db, _ := newTestManagedDB(t, time.Hour)
+		timeoutDB := db.WithTimeout(time.Second, nil)
+		old := db.current()
+
+		require.NoError(t, db.Reconnect())
+		require.NoError(t, old.Close())
+
+		var server string
+		require.NoError(t, timeoutDB.QueryRowContext(context.Background(), "SELECT server").Scan(&server))
+		assert.NoError(t, NewUserRepository(timeoutDB).Delete(context.Background(), uuid.New()))
+	})
+}
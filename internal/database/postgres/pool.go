@@ -0,0 +1,391 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net"
+	"regexp"
+	"sync"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const (
+	defaultHealthCheckInterval = 10 * time.Second
+	defaultFailureThreshold    = 3
+	healthCheckTimeout         = 5 * time.Second
+)
+
+// readOnlyQuery matches plain SELECTs. Row locking reads are left to the
+// primary, since replicas cannot take the locks.
+var (
+	readOnlyQuery = regexp.MustCompile(`(?is)^\s*(?:(?:--[^\n]*\n|/\*.*?\*/)\s*)*\(?\s*SELECT\b`)
+	lockingRead   = regexp.MustCompile(`(?i)\bFOR\s+(?:NO\s+KEY\s+)?(?:UPDATE|SHARE|KEY\s+SHARE)\b`)
+)
+
+// PoolOption configures a ConnectionPool
+type PoolOption func(*ConnectionPool)
+
+// WithPoolLogger logs servers leaving and rejoining the rotation, and
+// timed out queries
+func WithPoolLogger(logger *logger.Logger) PoolOption {
+	return func(p *ConnectionPool) {
+		p.logger = logger
+	}
+}
+
+// poolNode is one database server of the pool
+type poolNode struct {
+	*TimeoutDB
+	addr     string
+	weight   int
+	readOnly bool
+
+	// Guarded by the pool's mutex
+	failures int
+	healthy  bool
+	current  int // smooth weighted round-robin state
+}
+
+// ConnectionPool spreads queries over a primary and its replicas. SELECT
+// queries go to the healthy replicas in weighted round-robin, falling back
+// to the primary when none is healthy. Everything else goes to the primary
+// or, while it is unhealthy, to the first healthy replica that is not read
+// only.
+//
+// Every server is pinged in the background and leaves the rotation after
+// ReplicaFailureThreshold consecutive failures, rejoining on the first ping
+// that succeeds. The embedded *sql.DB is the primary, so methods the pool
+// does not route, such as PrepareContext, always run there.
+type ConnectionPool struct {
+	*sql.DB
+
+	primary  *poolNode
+	replicas []*poolNode
+	logger   *logger.Logger
+
+	interval  time.Duration
+	threshold int
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewConnectionPool connects to the primary and the replicas of cfg and
+// starts health checking them. The primary must be reachable; replicas that
+// are not start out of rotation. Close the pool to stop the health checks.
+func NewConnectionPool(cfg *config.DatabaseConfig, opts ...PoolOption) (*ConnectionPool, error) {
+	p := &ConnectionPool{
+		interval:  cfg.ReplicaHealthCheckInterval,
+		threshold: cfg.ReplicaFailureThreshold,
+		done:      make(chan struct{}),
+	}
+	if p.interval <= 0 {
+		p.interval = defaultHealthCheckInterval
+	}
+	if p.threshold <= 0 {
+		p.threshold = defaultFailureThreshold
+	}
+
+	for _, opt := range opts {
+		opt(p)
+	}
+
+	primary, err := p.open(cfg, cfg.Host, cfg.Port)
+	if err != nil {
+		return nil, err
+	}
+	if err := primary.Ping(); err != nil {
+		primary.Close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+	p.DB = primary
+	p.primary = p.newNode(cfg, primary, cfg.Host, cfg.Port, 1, false)
+
+	for _, replica := range cfg.Replicas {
+		db, err := p.open(cfg, replica.Host, replica.Port)
+		if err != nil {
+			p.closeAll()
+			return nil, err
+		}
+
+		node := p.newNode(cfg, db, replica.Host, replica.Port, max(replica.Weight, 1), replica.ReadOnly)
+		if err := db.Ping(); err != nil {
+			node.healthy = false
+			node.failures = p.threshold
+			if p.logger != nil {
+				p.logger.Warn("Database replica unavailable, starting out of rotation", "replica", node.addr, "error", err)
+			}
+		}
+		p.replicas = append(p.replicas, node)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	p.cancel = cancel
+	go p.monitor(ctx)
+
+	return p, nil
+}
+
+func (p *ConnectionPool) open(cfg *config.DatabaseConfig, host, port string) (*sql.DB, error) {
+	dsn := fmt.Sprintf(
+		"host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
+		host, port, cfg.User, cfg.Password, cfg.Database, cfg.SSLMode,
+	)
+
+	driver := cfg.Driver
+	if driver == "" {
+		driver = "postgres"
+	}
+
+	db, err := sql.Open(driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open database %s: %w", net.JoinHostPort(host, port), err)
+	}
+
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(time.Hour)
+
+	return db, nil
+}
+
+func (p *ConnectionPool) newNode(cfg *config.DatabaseConfig, db *sql.DB, host, port string, weight int, readOnly bool) *poolNode {
+	return &poolNode{
+		TimeoutDB: NewTimeoutDB(db, cfg.QueryTimeout, p.logger),
+		addr:      net.JoinHostPort(host, port),
+		weight:    weight,
+		readOnly:  readOnly,
+		healthy:   true,
+	}
+}
+
+// ExecContext executes a query without returning rows on the writer
+func (p *ConnectionPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.writer().ExecContext(ctx, query, args...)
+}
+
+// Exec executes a query without returning rows on the writer
+func (p *ConnectionPool) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return p.ExecContext(context.Background(), query, args...)
+}
+
+// QueryContext runs SELECT queries on a replica and others on the writer
+func (p *ConnectionPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	return p.route(query).QueryContext(ctx, query, args...)
+}
+
+// Query runs SELECT queries on a replica and others on the writer
+func (p *ConnectionPool) Query(query string, args ...interface{}) (*Rows, error) {
+	return p.QueryContext(context.Background(), query, args...)
+}
+
+// QueryRowContext runs SELECT queries on a replica and others on the writer
+func (p *ConnectionPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return p.route(query).QueryRowContext(ctx, query, args...)
+}
+
+// QueryRow runs SELECT queries on a replica and others on the writer
+func (p *ConnectionPool) QueryRow(query string, args ...interface{}) *Row {
+	return p.QueryRowContext(context.Background(), query, args...)
+}
+
+// BeginTx starts a transaction on the writer. Its queries, reads included,
+// all run there.
+func (p *ConnectionPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error) {
+	return p.writer().BeginTx(ctx, opts)
+}
+
+// Begin starts a transaction on the writer
+func (p *ConnectionPool) Begin() (*sql.Tx, error) {
+	return p.BeginTx(context.Background(), nil)
+}
+
+// PingContext checks the writer can be reached
+func (p *ConnectionPool) PingContext(ctx context.Context) error {
+	return p.writer().PingContext(ctx)
+}
+
+// Ping checks the writer can be reached
+func (p *ConnectionPool) Ping() error {
+	return p.PingContext(context.Background())
+}
+
+// HealthyReplicas returns the addresses of the replicas in rotation
+func (p *ConnectionPool) HealthyReplicas() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var healthy []string
+	for _, node := range p.replicas {
+		if node.healthy {
+			healthy = append(healthy, node.addr)
+		}
+	}
+	return healthy
+}
+
+// SetQueryRecorder reports every query, whichever server runs it, to
+// recorder. Call it before the pool serves queries.
+func (p *ConnectionPool) SetQueryRecorder(recorder QueryRecorder) {
+	p.primary.SetQueryRecorder(recorder)
+	for _, node := range p.replicas {
+		node.SetQueryRecorder(recorder)
+	}
+}
+
+// Close stops the health checks and closes every connection
+func (p *ConnectionPool) Close() error {
+	p.cancel()
+	<-p.done
+	return p.closeAll()
+}
+
+func (p *ConnectionPool) closeAll() error {
+	var firstErr error
+	if p.DB != nil {
+		firstErr = p.DB.Close()
+	}
+	for _, node := range p.replicas {
+		if err := node.DB.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// route picks the server for a query by whether it only reads
+func (p *ConnectionPool) route(query string) *TimeoutDB {
+	if isReadOnlyQuery(query) {
+		return p.reader()
+	}
+	return p.writer()
+}
+
+// reader picks a healthy replica by smooth weighted round-robin, which
+// interleaves the picks instead of sending a replica its whole weight in a
+// row. Without healthy replicas reads go to the writer.
+func (p *ConnectionPool) reader() *TimeoutDB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var best *poolNode
+	total := 0
+	for _, node := range p.replicas {
+		if !node.healthy {
+			continue
+		}
+		node.current += node.weight
+		total += node.weight
+		if best == nil || node.current > best.current {
+			best = node
+		}
+	}
+
+	if best == nil {
+		return p.writerLocked()
+	}
+	best.current -= total
+	return best.TimeoutDB
+}
+
+func (p *ConnectionPool) writer() *TimeoutDB {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.writerLocked()
+}
+
+// writerLocked returns the primary, or while it is unhealthy the first
+// healthy replica that can take writes. With neither the primary is
+// returned so the caller gets its error.
+func (p *ConnectionPool) writerLocked() *TimeoutDB {
+	if p.primary.healthy {
+		return p.primary.TimeoutDB
+	}
+	for _, node := range p.replicas {
+		if node.healthy && !node.readOnly {
+			return node.TimeoutDB
+		}
+	}
+	return p.primary.TimeoutDB
+}
+
+// monitor pings every server each interval until ctx is cancelled
+func (p *ConnectionPool) monitor(ctx context.Context) {
+	defer close(p.done)
+
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.checkHealth(ctx)
+		}
+	}
+}
+
+func (p *ConnectionPool) checkHealth(ctx context.Context) {
+	nodes := append([]*poolNode{p.primary}, p.replicas...)
+
+	var wg sync.WaitGroup
+	errs := make([]error, len(nodes))
+	for i, node := range nodes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			pingCtx, cancel := context.WithTimeout(ctx, min(p.interval, healthCheckTimeout))
+			defer cancel()
+			errs[i] = node.DB.PingContext(pingCtx)
+		}()
+	}
+	wg.Wait()
+
+	if ctx.Err() != nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, node := range nodes {
+		p.record(node, errs[i])
+	}
+}
+
+// record updates a server's health with the result of a ping
+func (p *ConnectionPool) record(node *poolNode, err error) {
+	if err == nil {
+		node.failures = 0
+		if !node.healthy {
+			node.healthy = true
+			node.current = 0
+			if p.logger != nil {
+				p.logger.Info("Database server recovered, back in rotation", "server", node.addr)
+			}
+		}
+		return
+	}
+
+	node.failures++
+	if node.healthy && node.failures >= p.threshold {
+		node.healthy = false
+		if p.logger != nil {
+			p.logger.Warn("Database server unhealthy, removed from rotation", "server", node.addr, "failures", node.failures, "error", err)
+		}
+	}
+}
+
+// isReadOnlyQuery reports whether a query is a SELECT that can run on a
+// replica. Functions with side effects called from a SELECT, such as
+// nextval, cannot be told apart; run those in a transaction.
+func isReadOnlyQuery(query string) bool {
+	return readOnlyQuery.MatchString(query) && !lockingRead.MatchString(query)
+}
@@ -0,0 +1,247 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// fakeServers is the state of the servers behind the pooltest driver,
+// keyed by host:port
+var fakeServers = struct {
+	sync.Mutex
+	down map[string]bool
+	hits map[string]int
+}{down: map[string]bool{}, hits: map[string]int{}}
+
+func init() {
+	sql.Register("pooltest", fakeDriver{})
+}
+
+func setServerDown(addr string, down bool) {
+	fakeServers.Lock()
+	defer fakeServers.Unlock()
+	fakeServers.down[addr] = down
+}
+
+// serve records a statement on addr, failing while it is down
+func serve(addr string) error {
+	fakeServers.Lock()
+	defer fakeServers.Unlock()
+	if fakeServers.down[addr] {
+		return errors.New("connection refused")
+	}
+	fakeServers.hits[addr]++
+	return nil
+}
+
+type fakeDriver struct{}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	var host, port string
+	for _, field := range strings.Fields(dsn) {
+		key, value, _ := strings.Cut(field, "=")
+		switch key {
+		case "host":
+			host = value
+		case "port":
+			port = value
+		}
+	}
+	return &fakeConn{addr: net.JoinHostPort(host, port)}, nil
+}
+
+type fakeConn struct{ addr string }
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c *fakeConn) Close() error              { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error) { return fakeTx{}, serve(c.addr) }
+
+func (c *fakeConn) Ping(ctx context.Context) error {
+	fakeServers.Lock()
+	defer fakeServers.Unlock()
+	if fakeServers.down[c.addr] {
+		return driver.ErrBadConn
+	}
+	return nil
+}
+
+func (c *fakeConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return driver.RowsAffected(1), serve(c.addr)
+}
+
+func (c *fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	if err := serve(c.addr); err != nil {
+		return nil, err
+	}
+	return &fakeRows{addr: c.addr}, nil
+}
+
+type fakeTx struct{}
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+// fakeRows returns a single row naming the server that ran the query
+type fakeRows struct {
+	addr string
+	done bool
+}
+
+func (r *fakeRows) Columns() []string { return []string{"server"} }
+func (r *fakeRows) Close() error      { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.addr
+	return nil
+}
+
+func newTestPool(t *testing.T, interval time.Duration, replicas ...config.ReplicaConfig) *ConnectionPool {
+	t.Helper()
+
+	pool, err := NewConnectionPool(&config.DatabaseConfig{
+		Driver:                     "pooltest",
+		Host:                       "primary-" + strings.ReplaceAll(t.Name(), "/", "-"),
+		Port:                       "5432",
+		Replicas:                   replicas,
+		ReplicaHealthCheckInterval: interval,
+		ReplicaFailureThreshold:    2,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() { pool.Close() })
+
+	return pool
+}
+
+func servedBy(t *testing.T, pool *ConnectionPool, query string) string {
+	t.Helper()
+
+	var server string
+	require.NoError(t, pool.QueryRowContext(context.Background(), query).Scan(&server))
+	return server
+}
+
+func TestConnectionPool(t *testing.T) {
+	t.Run("should spread reads over replicas by weight", func(t *testing.T) {
+		pool := newTestPool(t, time.Hour,
+			config.ReplicaConfig{Host: "weighted-a", Port: "5432", Weight: 3, ReadOnly: true},
+			config.ReplicaConfig{Host: "weighted-b", Port: "5432", Weight: 1, ReadOnly: true},
+		)
+
+		counts := map[string]int{}
+		for i := 0; i < 8; i++ {
+			counts[servedBy(t, pool, "SELECT name FROM users")]++
+		}
+
+		assert.Equal(t, map[string]int{"weighted-a:5432": 6, "weighted-b:5432": 2}, counts)
+	})
+
+	t.Run("should send writes and locking reads to the primary", func(t *testing.T) {
+		pool := newTestPool(t, time.Hour, config.ReplicaConfig{Host: "writes-replica", Port: "5432", Weight: 1, ReadOnly: true})
+		primary := pool.primary.addr
+
+		assert.Equal(t, primary, servedBy(t, pool, "INSERT INTO users (name) VALUES ('a') RETURNING id"))
+		assert.Equal(t, primary, servedBy(t, pool, "SELECT id FROM users WHERE id = $1 FOR UPDATE"))
+		assert.Equal(t, "writes-replica:5432", servedBy(t, pool, "\n\t-- list users\n\tSELECT id FROM users"))
+	})
+
+	t.Run("should take a replica out of rotation after consecutive failures", func(t *testing.T) {
+		pool := newTestPool(t, 10*time.Millisecond,
+			config.ReplicaConfig{Host: "flaky", Port: "5432", Weight: 1, ReadOnly: true},
+			config.ReplicaConfig{Host: "steady", Port: "5432", Weight: 1, ReadOnly: true},
+		)
+		require.ElementsMatch(t, []string{"flaky:5432", "steady:5432"}, pool.HealthyReplicas())
+
+		setServerDown("flaky:5432", true)
+		defer setServerDown("flaky:5432", false)
+		assert.Eventually(t, func() bool {
+			return len(pool.HealthyReplicas()) == 1
+		}, time.Second, 5*time.Millisecond)
+
+		for i := 0; i < 4; i++ {
+			assert.Equal(t, "steady:5432", servedBy(t, pool, "SELECT 1"))
+		}
+
+		setServerDown("flaky:5432", false)
+		assert.Eventually(t, func() bool {
+			return len(pool.HealthyReplicas()) == 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should start unreachable replicas out of rotation", func(t *testing.T) {
+		setServerDown("offline:5432", true)
+		defer setServerDown("offline:5432", false)
+
+		pool := newTestPool(t, time.Hour, config.ReplicaConfig{Host: "offline", Port: "5432", Weight: 1, ReadOnly: true})
+
+		assert.Empty(t, pool.HealthyReplicas())
+		assert.Equal(t, pool.primary.addr, servedBy(t, pool, "SELECT 1"))
+	})
+
+	t.Run("should fail writes over to a replica that is not read only", func(t *testing.T) {
+		pool := newTestPool(t, 10*time.Millisecond,
+			config.ReplicaConfig{Host: "reader", Port: "5432", Weight: 1, ReadOnly: true},
+			config.ReplicaConfig{Host: "standby", Port: "5432", Weight: 1},
+		)
+
+		setServerDown(pool.primary.addr, true)
+		defer setServerDown(pool.primary.addr, false)
+		assert.Eventually(t, func() bool {
+			_, err := pool.ExecContext(context.Background(), "UPDATE users SET name = 'b'")
+			return err == nil
+		}, time.Second, 5*time.Millisecond)
+
+		assert.Equal(t, "standby:5432", servedBy(t, pool, "DELETE FROM users RETURNING id"))
+	})
+
+	t.Run("should fail when the primary is unreachable", func(t *testing.T) {
+		setServerDown("down-primary:5432", true)
+		defer setServerDown("down-primary:5432", false)
+
+		_, err := NewConnectionPool(&config.DatabaseConfig{Driver: "pooltest", Host: "down-primary", Port: "5432"})
+
+		assert.ErrorContains(t, err, "failed to ping database")
+	})
+}
+
+func TestIsReadOnlyQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{"SELECT * FROM users", true},
+		{"  select id from users where id = $1", true},
+		{"/* report */ SELECT count(*) FROM orders", true},
+		{"(SELECT 1) UNION (SELECT 2)", true},
+		{"SELECT * FROM users FOR UPDATE", false},
+		{"SELECT * FROM jobs FOR NO KEY UPDATE SKIP LOCKED", false},
+		{"SELECT * FROM users FOR SHARE", false},
+		{"INSERT INTO users (id) VALUES ($1)", false},
+		{"WITH deleted AS (DELETE FROM users RETURNING *) SELECT * FROM deleted", false},
+		{"UPDATE users SET selected = true", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.want, isReadOnlyQuery(tt.query))
+		})
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"os"
 	"testing"
 	"time"
 
@@ -14,6 +15,7 @@ import (
 
 	"github.com/VeRJiL/go-template/internal/config"
 	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
 )
 
 type PostgresTestSuite struct {
@@ -59,7 +61,7 @@ func (suite *PostgresTestSuite) SetupSuite() {
 	suite.createUsersTable()
 
 	// Initialize repository
-	suite.repository = &userRepository{db: suite.db}
+	suite.repository = &userRepository{db: NewTimeoutDB(suite.db, 0, nil)}
 }
 
 func (suite *PostgresTestSuite) TearDownSuite() {
@@ -105,8 +107,13 @@ func (suite *PostgresTestSuite) createUsersTable() {
 			last_name VARCHAR(100) NOT NULL,
 			role VARCHAR(20) NOT NULL DEFAULT 'user' CHECK (role IN ('admin', 'user')),
 			is_active BOOLEAN NOT NULL DEFAULT true,
+			totp_secret VARCHAR(64),
+			totp_enabled BOOLEAN NOT NULL DEFAULT false,
+			oauth_provider VARCHAR(20),
+			oauth_sub VARCHAR(255),
 			created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
-			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW()
+			updated_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+			version INT NOT NULL DEFAULT 0
 		);
 
 		CREATE INDEX IF NOT EXISTS idx_users_email ON users(email);
@@ -226,7 +233,7 @@ func TestNewConnection(t *testing.T) {
 func TestNewUserRepository(t *testing.T) {
 	t.Run("should create user repository", func(t *testing.T) {
 		db := &sql.DB{} // Mock DB
-		repo := NewUserRepository(db)
+		repo := NewUserRepository(NewTimeoutDB(db, 0, nil))
 
 		assert.NotNil(t, repo)
 		assert.IsType(t, &userRepository{}, repo)
@@ -431,6 +438,69 @@ func (suite *PostgresTestSuite) TestUserRepository_Update() {
 		assert.Nil(t, updatedUser)
 		assert.Contains(t, err.Error(), "user not found")
 	})
+
+	suite.T().Run("should increment the version", func(t *testing.T) {
+		user := suite.createTestUserWithEmail("versioned@example.com")
+		require.NoError(t, suite.repository.Create(context.Background(), user))
+
+		newFirstName := "Jane"
+		updatedUser, err := suite.repository.Update(context.Background(), user.ID, &entities.UpdateUserRequest{FirstName: &newFirstName})
+		require.NoError(t, err)
+		assert.Equal(t, 1, updatedUser.Version)
+
+		updatedUser, err = suite.repository.Update(context.Background(), user.ID, &entities.UpdateUserRequest{FirstName: &newFirstName})
+		require.NoError(t, err)
+		assert.Equal(t, 2, updatedUser.Version)
+	})
+
+	suite.T().Run("should update the expected version", func(t *testing.T) {
+		user := suite.createTestUserWithEmail("expected@example.com")
+		require.NoError(t, suite.repository.Create(context.Background(), user))
+
+		newFirstName := "Jane"
+		expectedVersion := 0
+		updatedUser, err := suite.repository.Update(context.Background(), user.ID, &entities.UpdateUserRequest{
+			FirstName:       &newFirstName,
+			ExpectedVersion: &expectedVersion,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, newFirstName, updatedUser.FirstName)
+		assert.Equal(t, 1, updatedUser.Version)
+	})
+
+	suite.T().Run("should reject a stale expected version", func(t *testing.T) {
+		user := suite.createTestUserWithEmail("stale@example.com")
+		require.NoError(t, suite.repository.Create(context.Background(), user))
+
+		newFirstName := "Jane"
+		_, err := suite.repository.Update(context.Background(), user.ID, &entities.UpdateUserRequest{FirstName: &newFirstName})
+		require.NoError(t, err)
+
+		staleVersion := 0
+		updatedUser, err := suite.repository.Update(context.Background(), user.ID, &entities.UpdateUserRequest{
+			FirstName:       &newFirstName,
+			ExpectedVersion: &staleVersion,
+		})
+
+		assert.Nil(t, updatedUser)
+		assert.ErrorIs(t, err, repositories.ErrConcurrentModification)
+		var conflict *repositories.ConcurrentModificationError
+		require.ErrorAs(t, err, &conflict)
+		assert.Equal(t, 1, conflict.CurrentVersion)
+	})
+
+	suite.T().Run("should report a missing user with an expected version", func(t *testing.T) {
+		newFirstName := "Jane"
+		expectedVersion := 0
+		updatedUser, err := suite.repository.Update(context.Background(), uuid.New(), &entities.UpdateUserRequest{
+			FirstName:       &newFirstName,
+			ExpectedVersion: &expectedVersion,
+		})
+
+		assert.Nil(t, updatedUser)
+		assert.Contains(t, err.Error(), "user not found")
+	})
 }
 
 func (suite *PostgresTestSuite) TestUserRepository_Delete() {
@@ -530,6 +600,83 @@ func (suite *PostgresTestSuite) TestUserRepository_List() {
 	})
 }
 
+func (suite *PostgresTestSuite) TestUserRepository_ListWithCursor() {
+	ctx := context.Background()
+
+	// createUsers creates five active users, newest first in the result
+	createUsers := func(t *testing.T) []*entities.User {
+		_, err := suite.db.Exec("DELETE FROM users")
+		require.NoError(t, err)
+
+		start := time.Now().Add(-time.Hour)
+		users := make([]*entities.User, 5)
+		for i := range users {
+			user := suite.createTestUser()
+			user.CreatedAt = start.Add(time.Duration(i) * time.Minute)
+			require.NoError(t, suite.repository.Create(ctx, user))
+			users[len(users)-1-i] = user
+		}
+		return users
+	}
+	ids := func(users []*entities.User) []uuid.UUID {
+		result := make([]uuid.UUID, len(users))
+		for i, user := range users {
+			result[i] = user.ID
+		}
+		return result
+	}
+
+	suite.T().Run("should page forward from the cursor", func(t *testing.T) {
+		users := createUsers(t)
+
+		first, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, ids(users[:2]), ids(first.Items))
+		assert.True(t, first.HasMore)
+		assert.Nil(t, first.PrevCursor)
+		require.NotNil(t, first.NextCursor)
+
+		second, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{After: first.NextCursor, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, ids(users[2:4]), ids(second.Items))
+		assert.Equal(t, users[2].ID, *second.PrevCursor)
+
+		last, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{After: second.NextCursor, Limit: 2})
+		require.NoError(t, err)
+		assert.Equal(t, ids(users[4:]), ids(last.Items))
+		assert.False(t, last.HasMore)
+		assert.Nil(t, last.NextCursor)
+	})
+
+	suite.T().Run("should page backward from the cursor", func(t *testing.T) {
+		users := createUsers(t)
+
+		page, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{Before: &users[4].ID, Limit: 2})
+		require.NoError(t, err)
+
+		assert.Equal(t, ids(users[2:4]), ids(page.Items))
+		assert.True(t, page.HasMore)
+		assert.Equal(t, users[2].ID, *page.PrevCursor)
+		assert.Equal(t, users[3].ID, *page.NextCursor)
+	})
+
+	suite.T().Run("should order ascending", func(t *testing.T) {
+		users := createUsers(t)
+
+		page, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{After: &users[4].ID, Limit: 10, Direction: "asc"})
+		require.NoError(t, err)
+
+		assert.Equal(t, []uuid.UUID{users[3].ID, users[2].ID, users[1].ID, users[0].ID}, ids(page.Items))
+		assert.False(t, page.HasMore)
+	})
+
+	suite.T().Run("should reject an unknown order column", func(t *testing.T) {
+		_, err := suite.repository.ListWithCursor(ctx, repositories.CursorPagination{OrderBy: "created_at; DROP TABLE users"})
+
+		assert.ErrorContains(t, err, "invalid user cursor")
+	})
+}
+
 func (suite *PostgresTestSuite) TestUserRepository_Search() {
 	suite.T().Run("should search users by query", func(t *testing.T) {
 		// Create users with different names
@@ -579,6 +726,70 @@ func (suite *PostgresTestSuite) TestUserRepository_Search() {
 	})
 }
 
+func (suite *PostgresTestSuite) TestUserRepository_FullTextSearch() {
+	ctx := context.Background()
+	for _, user := range []*entities.User{
+		{ID: uuid.New(), Email: "john.doe@example.com", Password: "hash", FirstName: "John", LastName: "Doe", Role: "user", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: uuid.New(), Email: "jane.smith@example.com", Password: "hash", FirstName: "Jane", LastName: "Smith", Role: "user", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: uuid.New(), Email: "jane.doe@example.com", Password: "hash", FirstName: "Jane", LastName: "Doe", Role: "user", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+		{ID: uuid.New(), Email: "bob.johnson@example.com", Password: "hash", FirstName: "Bob", LastName: "Johnson", Role: "admin", IsActive: true, CreatedAt: time.Now(), UpdatedAt: time.Now()},
+	} {
+		require.NoError(suite.T(), suite.repository.Create(ctx, user))
+	}
+
+	migrate := func(name string) {
+		migration, err := os.ReadFile("../../../migrations/postgres/" + name)
+		require.NoError(suite.T(), err)
+		_, err = suite.db.Exec(string(migration))
+		require.NoError(suite.T(), err)
+	}
+
+	suite.T().Run("should fall back to ILIKE without the search_vector column", func(t *testing.T) {
+		repository := &userRepository{db: NewTimeoutDB(suite.db, 0, nil)}
+
+		users, total, err := repository.FullTextSearch(ctx, "john", 0, 10)
+
+		require.NoError(t, err)
+		assert.Len(t, users, 2) // John Doe and Bob Johnson
+		assert.Equal(t, 2, total)
+	})
+
+	migrate("004_add_users_search_vector.up.sql")
+	defer migrate("004_add_users_search_vector.down.sql")
+
+	suite.T().Run("should match whole words", func(t *testing.T) {
+		repository := &userRepository{db: NewTimeoutDB(suite.db, 0, nil)}
+
+		users, total, err := repository.FullTextSearch(ctx, "john", 0, 10)
+
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "Doe", users[0].LastName)
+	})
+
+	suite.T().Run("should support web search syntax", func(t *testing.T) {
+		repository := &userRepository{db: NewTimeoutDB(suite.db, 0, nil)}
+
+		users, total, err := repository.FullTextSearch(ctx, "jane -smith", 0, 10)
+
+		require.NoError(t, err)
+		require.Len(t, users, 1)
+		assert.Equal(t, 1, total)
+		assert.Equal(t, "jane.doe@example.com", users[0].Email)
+	})
+
+	suite.T().Run("should page the matches", func(t *testing.T) {
+		repository := &userRepository{db: NewTimeoutDB(suite.db, 0, nil)}
+
+		users, total, err := repository.FullTextSearch(ctx, "doe", 1, 1)
+
+		require.NoError(t, err)
+		assert.Len(t, users, 1)
+		assert.Equal(t, 2, total)
+	})
+}
+
 func TestPostgresTestSuite(t *testing.T) {
 	// Skip if running in short mode
 	if testing.Short() {
@@ -0,0 +1,273 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// queryOperators are the comparison operators Where accepts
+var queryOperators = map[string]bool{
+	"=":     true,
+	"!=":    true,
+	"<":     true,
+	"<=":    true,
+	">":     true,
+	">=":    true,
+	"LIKE":  true,
+	"ILIKE": true,
+}
+
+type predicate struct {
+	fields []string
+	op     string
+	value  interface{}
+}
+
+// seek compares a row of fields with those of the row whose key column
+// holds key
+type seek struct {
+	fields []string
+	op     string
+	key    string
+	value  interface{}
+}
+
+type ordering struct {
+	field string
+	dir   string
+}
+
+// QueryBuilder builds parameterized SELECT queries over one table. Field
+// names are checked against the table's known columns and quoted, and values
+// are always bound as arguments, so callers may pass user input for both.
+// The first invalid field or operator is reported by Err and stops Build
+// from producing a query.
+type QueryBuilder struct {
+	table   string
+	columns []string
+	known   map[string]bool
+
+	where   []predicate
+	seeks   []seek
+	orderBy []ordering
+	limit   int
+	offset  int
+	err     error
+}
+
+// NewQueryBuilder creates a builder selecting columns from table. Only the
+// given columns may be filtered and ordered on.
+func NewQueryBuilder(table string, columns ...string) *QueryBuilder {
+	known := make(map[string]bool, len(columns))
+	for _, column := range columns {
+		known[column] = true
+	}
+
+	return &QueryBuilder{
+		table:   table,
+		columns: columns,
+		known:   known,
+	}
+}
+
+// Where adds a condition joined to the others with AND. A nil value with
+// "=" or "!=" compares with IS NULL or IS NOT NULL.
+func (b *QueryBuilder) Where(field, op string, value interface{}) *QueryBuilder {
+	return b.WhereAny([]string{field}, op, value)
+}
+
+// WhereAny adds a condition that holds when any of the fields matches, e.g.
+// a search over several text columns
+func (b *QueryBuilder) WhereAny(fields []string, op string, value interface{}) *QueryBuilder {
+	op = strings.ToUpper(strings.TrimSpace(op))
+	if op == "<>" {
+		op = "!="
+	}
+
+	switch {
+	case len(fields) == 0:
+		b.fail(fmt.Errorf("condition has no fields"))
+	case !queryOperators[op]:
+		b.fail(fmt.Errorf("unsupported operator %q", op))
+	case value == nil && op != "=" && op != "!=":
+		b.fail(fmt.Errorf("operator %s cannot compare with NULL", op))
+	}
+	for _, field := range fields {
+		b.checkField(field)
+	}
+
+	b.where = append(b.where, predicate{fields: fields, op: op, value: value})
+	return b
+}
+
+// Seek adds a keyset pagination condition: the fields of a row must
+// compare with op ("<" or ">") to the fields of the row whose key column
+// equals value, as in ("created_at", "id") > (SELECT ... WHERE "id" = $1).
+// No row matches when no row has that key.
+func (b *QueryBuilder) Seek(fields []string, op, key string, value interface{}) *QueryBuilder {
+	switch {
+	case len(fields) == 0:
+		b.fail(fmt.Errorf("seek has no fields"))
+	case op != "<" && op != ">":
+		b.fail(fmt.Errorf("unsupported seek operator %q", op))
+	case value == nil:
+		b.fail(fmt.Errorf("seek key cannot be NULL"))
+	}
+	for _, field := range fields {
+		b.checkField(field)
+	}
+	b.checkField(key)
+
+	b.seeks = append(b.seeks, seek{fields: fields, op: op, key: key, value: value})
+	return b
+}
+
+// OrderBy adds a sort key; dir is "asc" or "desc"
+func (b *QueryBuilder) OrderBy(field, dir string) *QueryBuilder {
+	b.checkField(field)
+
+	dir = strings.ToUpper(strings.TrimSpace(dir))
+	if dir == "" {
+		dir = "ASC"
+	}
+	if dir != "ASC" && dir != "DESC" {
+		b.fail(fmt.Errorf("unsupported sort direction %q", dir))
+	}
+
+	b.orderBy = append(b.orderBy, ordering{field: field, dir: dir})
+	return b
+}
+
+// Limit caps the number of rows; zero means no limit
+func (b *QueryBuilder) Limit(n int) *QueryBuilder {
+	b.limit = n
+	return b
+}
+
+// Offset skips the first n rows
+func (b *QueryBuilder) Offset(n int) *QueryBuilder {
+	b.offset = n
+	return b
+}
+
+// Err returns the first invalid field, operator or sort direction given to
+// the builder
+func (b *QueryBuilder) Err() error {
+	return b.err
+}
+
+// Build returns the SELECT query and its arguments. It returns an empty
+// query when Err is set.
+func (b *QueryBuilder) Build() (query string, args []interface{}) {
+	if b.err != nil {
+		return "", nil
+	}
+
+	columns := make([]string, len(b.columns))
+	for i, column := range b.columns {
+		columns[i] = pq.QuoteIdentifier(column)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT %s FROM %s", strings.Join(columns, ", "), pq.QuoteIdentifier(b.table))
+	args = b.writeWhere(&sb, args)
+
+	if len(b.orderBy) > 0 {
+		keys := make([]string, len(b.orderBy))
+		for i, o := range b.orderBy {
+			keys[i] = pq.QuoteIdentifier(o.field) + " " + o.dir
+		}
+		sb.WriteString(" ORDER BY " + strings.Join(keys, ", "))
+	}
+
+	if b.limit > 0 {
+		args = append(args, b.limit)
+		fmt.Fprintf(&sb, " LIMIT $%d", len(args))
+	}
+	if b.offset > 0 {
+		args = append(args, b.offset)
+		fmt.Fprintf(&sb, " OFFSET $%d", len(args))
+	}
+
+	return sb.String(), args
+}
+
+// BuildCount returns a query counting the rows the conditions match,
+// ignoring ordering, limit and offset
+func (b *QueryBuilder) BuildCount() (query string, args []interface{}) {
+	if b.err != nil {
+		return "", nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "SELECT COUNT(*) FROM %s", pq.QuoteIdentifier(b.table))
+	args = b.writeWhere(&sb, args)
+
+	return sb.String(), args
+}
+
+func (b *QueryBuilder) writeWhere(sb *strings.Builder, args []interface{}) []interface{} {
+	if len(b.where) == 0 && len(b.seeks) == 0 {
+		return args
+	}
+
+	conditions := make([]string, len(b.where), len(b.where)+len(b.seeks))
+	for i, p := range b.where {
+		var placeholder string
+		if p.value != nil {
+			args = append(args, p.value)
+			placeholder = fmt.Sprintf("$%d", len(args))
+		}
+
+		alternatives := make([]string, len(p.fields))
+		for j, field := range p.fields {
+			alternatives[j] = condition(pq.QuoteIdentifier(field), p.op, placeholder)
+		}
+
+		conditions[i] = strings.Join(alternatives, " OR ")
+		if len(alternatives) > 1 {
+			conditions[i] = "(" + conditions[i] + ")"
+		}
+	}
+
+	for _, s := range b.seeks {
+		args = append(args, s.value)
+
+		fields := make([]string, len(s.fields))
+		for i, field := range s.fields {
+			fields[i] = pq.QuoteIdentifier(field)
+		}
+		row := strings.Join(fields, ", ")
+
+		conditions = append(conditions, fmt.Sprintf("(%s) %s (SELECT %s FROM %s WHERE %s = $%d)",
+			row, s.op, row, pq.QuoteIdentifier(b.table), pq.QuoteIdentifier(s.key), len(args)))
+	}
+
+	sb.WriteString(" WHERE " + strings.Join(conditions, " AND "))
+	return args
+}
+
+// condition renders one comparison; an empty placeholder compares with NULL
+func condition(column, op, placeholder string) string {
+	if placeholder == "" {
+		if op == "=" {
+			return column + " IS NULL"
+		}
+		return column + " IS NOT NULL"
+	}
+	return column + " " + op + " " + placeholder
+}
+
+func (b *QueryBuilder) checkField(field string) {
+	if !b.known[field] {
+		b.fail(fmt.Errorf("unknown column %q", field))
+	}
+}
+
+func (b *QueryBuilder) fail(err error) {
+	if b.err == nil {
+		b.err = err
+	}
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder(t *testing.T) {
+	newBuilder := func() *QueryBuilder {
+		return NewQueryBuilder("users", "id", "email", "first_name", "last_name", "deleted_at", "created_at")
+	}
+
+	t.Run("should build a select over the quoted columns", func(t *testing.T) {
+		query, args := newBuilder().Build()
+
+		assert.Equal(t, `SELECT "id", "email", "first_name", "last_name", "deleted_at", "created_at" FROM "users"`, query)
+		assert.Empty(t, args)
+	})
+
+	t.Run("should bind condition values and pagination as arguments", func(t *testing.T) {
+		query, args := NewQueryBuilder("users", "id", "email", "created_at").
+			Where("email", "=", "a@example.com").
+			WhereAny([]string{"email", "id"}, "ilike", "%a%").
+			OrderBy("created_at", "desc").
+			Limit(10).
+			Offset(20).
+			Build()
+
+		assert.Equal(t, `SELECT "id", "email", "created_at" FROM "users" WHERE "email" = $1 AND ("email" ILIKE $2 OR "id" ILIKE $2) ORDER BY "created_at" DESC LIMIT $3 OFFSET $4`, query)
+		assert.Equal(t, []interface{}{"a@example.com", "%a%", 10, 20}, args)
+	})
+
+	t.Run("should compare nil values with IS NULL", func(t *testing.T) {
+		query, args := newBuilder().
+			Where("deleted_at", "=", nil).
+			Where("email", "<>", nil).
+			Where("first_name", "=", "Ada").
+			Build()
+
+		assert.Equal(t, `SELECT "id", "email", "first_name", "last_name", "deleted_at", "created_at" FROM "users" WHERE "deleted_at" IS NULL AND "email" IS NOT NULL AND "first_name" = $1`, query)
+		assert.Equal(t, []interface{}{"Ada"}, args)
+	})
+
+	t.Run("should count matching rows without ordering or pagination", func(t *testing.T) {
+		query, args := newBuilder().
+			Where("email", "LIKE", "%@example.com").
+			OrderBy("created_at", "asc").
+			Limit(5).
+			BuildCount()
+
+		assert.Equal(t, `SELECT COUNT(*) FROM "users" WHERE "email" LIKE $1`, query)
+		assert.Equal(t, []interface{}{"%@example.com"}, args)
+	})
+
+	t.Run("should seek past the row with a key", func(t *testing.T) {
+		query, args := NewQueryBuilder("users", "id", "created_at").
+			Where("created_at", ">=", "2024-01-01").
+			Seek([]string{"created_at", "id"}, "<", "id", "0b6f").
+			OrderBy("created_at", "desc").
+			Limit(21).
+			Build()
+
+		assert.Equal(t, `SELECT "id", "created_at" FROM "users" WHERE "created_at" >= $1 AND ("created_at", "id") < (SELECT "created_at", "id" FROM "users" WHERE "id" = $2) ORDER BY "created_at" DESC LIMIT $3`, query)
+		assert.Equal(t, []interface{}{"2024-01-01", "0b6f", 21}, args)
+	})
+
+	t.Run("should reject invalid input", func(t *testing.T) {
+		tests := []struct {
+			name    string
+			builder *QueryBuilder
+			err     string
+		}{
+			{"unknown column", newBuilder().Where("password_hash", "=", "x"), `unknown column "password_hash"`},
+			{"injected column", newBuilder().Where("email = '' OR 1=1 --", "=", "x"), "unknown column"},
+			{"injected sort column", newBuilder().OrderBy("created_at; DROP TABLE users", "asc"), "unknown column"},
+			{"unsupported operator", newBuilder().Where("email", "= '' OR 1=1 --", "x"), "unsupported operator"},
+			{"sort direction", newBuilder().OrderBy("email", "sideways"), "unsupported sort direction"},
+			{"null ordering", newBuilder().Where("deleted_at", ">", nil), "cannot compare with NULL"},
+			{"seek operator", newBuilder().Seek([]string{"created_at"}, ">=", "id", "x"), "unsupported seek operator"},
+			{"seek column", newBuilder().Seek([]string{"password_hash"}, ">", "id", "x"), "unknown column"},
+			{"null seek key", newBuilder().Seek([]string{"created_at"}, ">", "id", nil), "seek key cannot be NULL"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				query, args := tt.builder.Build()
+
+				assert.ErrorContains(t, tt.builder.Err(), tt.err)
+				assert.Empty(t, query)
+				assert.Nil(t, args)
+			})
+		}
+	})
+
+	t.Run("should keep the first error", func(t *testing.T) {
+		builder := newBuilder().Where("nope", "=", 1).OrderBy("email", "sideways")
+
+		assert.ErrorContains(t, builder.Err(), `unknown column "nope"`)
+	})
+}
@@ -0,0 +1,218 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// TenantSetting is the session variable RLS policies compare tenant_id against
+	TenantSetting = "app.tenant_id"
+
+	// TenantPolicyName is the name of the policy created by EnableRLS
+	TenantPolicyName = "tenant_isolation"
+
+	// rlsAdminRole bypasses row level security for admin queries
+	rlsAdminRole = "postgres"
+)
+
+// ErrNoTenant is returned when a tenant scoped operation has no tenant in its context
+var ErrNoTenant = errors.New("no tenant in context")
+
+type tenantContextKey struct{}
+
+// WithTenant returns a context carrying the tenant ID used for RLS
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantContextKey{}, tenantID)
+}
+
+// TenantFromContext returns the tenant ID stored by WithTenant
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenantID, ok := ctx.Value(tenantContextKey{}).(string)
+	return tenantID, ok && tenantID != ""
+}
+
+// RLSManager isolates tenant rows with Postgres row level security policies
+type RLSManager struct {
+	db *sql.DB
+}
+
+// NewRLSManager creates a new RLS manager
+func NewRLSManager(db *sql.DB) *RLSManager {
+	return &RLSManager{db: db}
+}
+
+// EnableRLS enables row level security on a table and restricts every row to
+// the tenant set in app.tenant_id. The table must have a uuid tenant_id column.
+// FORCE is used so the policy also applies to the table owner.
+func (m *RLSManager) EnableRLS(ctx context.Context, tableName string) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range RLSStatements(tableName) {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return fmt.Errorf("failed to enable row level security on %s: %w", tableName, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// BeginTenantTx starts a transaction scoped to the tenant in ctx. The setting
+// is transaction local, so it never leaks to other users of a pooled connection.
+func (m *RLSManager) BeginTenantTx(ctx context.Context) (*sql.Tx, error) {
+	tenantID, ok := TenantFromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", TenantSetting, tenantID); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to set tenant: %w", err)
+	}
+
+	return tx, nil
+}
+
+// WithTenantTx runs fn in a tenant scoped transaction and commits it when fn succeeds
+func (m *RLSManager) WithTenantTx(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := m.BeginTenantTx(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// TenantDB returns an Executor running every query in a transaction scoped
+// to the tenant in its context, for repositories of RLS enabled tables
+func (m *RLSManager) TenantDB() *TenantDB {
+	return &TenantDB{rls: m}
+}
+
+// TenantDB runs each statement in its own tenant scoped transaction, so the
+// RLS policies only let it see and write the rows of the tenant in the
+// context. Statements without a tenant fail with ErrNoTenant. Queries
+// returning rows commit once the rows are read or closed, single row
+// queries once scanned.
+type TenantDB struct {
+	rls *RLSManager
+}
+
+var _ Executor = (*TenantDB)(nil)
+
+// ExecContext executes a query without returning rows
+func (d *TenantDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	var result sql.Result
+	err := d.rls.WithTenantTx(ctx, func(tx *sql.Tx) error {
+		var err error
+		result, err = tx.ExecContext(ctx, query, args...)
+		return err
+	})
+	return result, err
+}
+
+// QueryContext executes a query that returns rows
+func (d *TenantDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	tx, err := d.rls.BeginTenantTx(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.QueryContext(ctx, query, args...)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	return &Rows{
+		Rows: rows,
+		release: func(err error) {
+			endTenantTx(tx, err)
+		},
+	}, nil
+}
+
+// QueryRowContext executes a query that returns at most one row. Errors are
+// deferred until Scan.
+func (d *TenantDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	tx, err := d.rls.BeginTenantTx(ctx)
+	if err != nil {
+		return &Row{err: err, done: func(err error) error { return err }}
+	}
+
+	return &Row{
+		Row: tx.QueryRowContext(ctx, query, args...),
+		done: func(err error) error {
+			// A query that found no rows still commits
+			if err != nil && !errors.Is(err, sql.ErrNoRows) {
+				tx.Rollback()
+				return err
+			}
+			if commitErr := tx.Commit(); commitErr != nil {
+				return fmt.Errorf("failed to commit tenant transaction: %w", commitErr)
+			}
+			return err
+		},
+	}
+}
+
+// endTenantTx commits the transaction of a query, or rolls it back when
+// reading its rows failed
+func endTenantTx(tx *sql.Tx, err error) {
+	if err != nil {
+		tx.Rollback()
+		return
+	}
+	tx.Commit()
+}
+
+// DisableRLS runs fn as the admin role so it sees rows of every tenant.
+// Use it only for administrative queries such as reporting or migrations.
+func (m *RLSManager) DisableRLS(ctx context.Context, fn func(*sql.Tx) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+pq.QuoteIdentifier(rlsAdminRole)); err != nil {
+		return fmt.Errorf("failed to switch to admin role: %w", err)
+	}
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// RLSStatements returns the SQL that enables tenant isolation on a table.
+// A missing app.tenant_id matches no rows instead of raising an error.
+func RLSStatements(tableName string) []string {
+	table := pq.QuoteIdentifier(tableName)
+	condition := fmt.Sprintf("tenant_id = NULLIF(current_setting('%s', true), '')::uuid", TenantSetting)
+
+	return []string{
+		fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", table),
+		fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", TenantPolicyName, table),
+		fmt.Sprintf("CREATE POLICY %s ON %s USING (%s) WITH CHECK (%s)", TenantPolicyName, table, condition, condition),
+	}
+}
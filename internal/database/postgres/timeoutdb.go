@@ -0,0 +1,225 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// TimeoutDB bounds every query with a deadline. When the deadline expires
+// mid-query lib/pq sends a cancel request, which Postgres handles like
+// pg_cancel_backend, so the statement stops running on the server as well.
+type TimeoutDB struct {
+	*sql.DB
+	conn     queryer
+	timeout  time.Duration
+	logger   *logger.Logger
+	recorder QueryRecorder
+}
+
+// queryer runs the queries of a TimeoutDB: its *sql.DB, or the ManagedDB
+// it bounds
+type queryer interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// QueryRecorder is told the outcome and duration of each query once it
+// completes. *monitoring.PrometheusMonitor implements it.
+type QueryRecorder interface {
+	RecordDBQuery(ctx context.Context, database, operation, status, query string, duration time.Duration)
+}
+
+// NewTimeoutDB wraps db so each query runs with the given timeout. A zero
+// timeout leaves queries unbounded.
+func NewTimeoutDB(db *sql.DB, timeout time.Duration, logger *logger.Logger) *TimeoutDB {
+	return &TimeoutDB{
+		DB:      db,
+		conn:    db,
+		timeout: timeout,
+		logger:  logger,
+	}
+}
+
+// SetQueryRecorder reports every query to recorder
+func (t *TimeoutDB) SetQueryRecorder(recorder QueryRecorder) {
+	t.recorder = recorder
+}
+
+// ExecContext executes a query without returning rows
+func (t *TimeoutDB) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	queryCtx, cancel := t.withTimeout(ctx)
+	defer cancel()
+
+	start := time.Now()
+	result, err := t.conn.ExecContext(queryCtx, query, args...)
+	err = t.checkTimeout(queryCtx, err, query, start)
+	t.record(ctx, query, start, err)
+	return result, err
+}
+
+// QueryContext executes a query that returns rows. The deadline also covers
+// reading the rows, since they are streamed from the same context, and ends
+// when the rows are closed.
+func (t *TimeoutDB) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	queryCtx, cancel := t.withTimeout(ctx)
+
+	start := time.Now()
+	rows, err := t.conn.QueryContext(queryCtx, query, args...)
+	if err != nil {
+		cancel()
+		err = t.checkTimeout(queryCtx, err, query, start)
+		t.record(ctx, query, start, err)
+		return nil, err
+	}
+	return &Rows{
+		Rows: rows,
+		release: func(err error) {
+			defer cancel()
+			t.record(ctx, query, start, err)
+		},
+	}, nil
+}
+
+// QueryRowContext executes a query that returns at most one row. Errors are
+// deferred until Scan, which reports and logs a timeout like QueryContext
+// and ends the deadline.
+func (t *TimeoutDB) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	queryCtx, cancel := t.withTimeout(ctx)
+
+	start := time.Now()
+	return &Row{
+		Row: t.conn.QueryRowContext(queryCtx, query, args...),
+		done: func(err error) error {
+			defer cancel()
+			err = t.checkTimeout(queryCtx, err, query, start)
+			t.record(ctx, query, start, err)
+			return err
+		},
+	}
+}
+
+// withTimeout bounds ctx by the query timeout, when there is one
+func (t *TimeoutDB) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if t.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, t.timeout)
+}
+
+// Rows are the result of a query run with a deadline. Closing them, or
+// reading past the last row, which closes them too, ends the deadline.
+type Rows struct {
+	*sql.Rows
+	release func(err error)
+	once    sync.Once
+}
+
+// Next prepares the next row for Scan, ending the deadline after the last
+func (r *Rows) Next() bool {
+	if r.Rows.Next() {
+		return true
+	}
+	r.end()
+	return false
+}
+
+// Close closes the rows and ends the deadline
+func (r *Rows) Close() error {
+	err := r.Rows.Close()
+	r.end()
+	return err
+}
+
+// end releases the rows once, with the error that ended them, if any
+func (r *Rows) end() {
+	r.once.Do(func() { r.release(r.Rows.Err()) })
+}
+
+// Row is the result of a single row query run with a deadline
+type Row struct {
+	*sql.Row
+	err  error // set instead of Row when the query could not be run
+	done func(err error) error
+}
+
+// Scan copies the row into dest and ends the deadline
+func (r *Row) Scan(dest ...interface{}) error {
+	if r.err != nil {
+		return r.done(r.err)
+	}
+	return r.done(r.Row.Scan(dest...))
+}
+
+// Err reports the error of running the query, deferred like Scan's
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.Row.Err()
+}
+
+// unboundedRows wraps the rows of a query run without a deadline
+func unboundedRows(rows *sql.Rows, err error) (*Rows, error) {
+	if err != nil {
+		return nil, err
+	}
+	return &Rows{Rows: rows, release: func(error) {}}, nil
+}
+
+// unboundedRow wraps the row of a query run without a deadline
+func unboundedRow(row *sql.Row) *Row {
+	return &Row{Row: row, done: func(err error) error { return err }}
+}
+
+// checkTimeout reports queries cut short by the timeout as
+// context.DeadlineExceeded, wrapping the driver's cancel error, and logs
+// them. Only the query template is logged; arguments may hold user data.
+func (t *TimeoutDB) checkTimeout(ctx context.Context, err error, query string, start time.Time) error {
+	if err == nil || t.timeout <= 0 || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return err
+	}
+
+	if t.logger != nil {
+		t.logger.Warn("Query timed out",
+			"query", query,
+			"elapsed", time.Since(start),
+			"timeout", t.timeout,
+			"error", err)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return err
+	}
+	return fmt.Errorf("%w: %w", context.DeadlineExceeded, err)
+}
+
+// record reports a completed query to the recorder. A query that found no
+// rows succeeded.
+func (t *TimeoutDB) record(ctx context.Context, query string, start time.Time, err error) {
+	if t.recorder == nil {
+		return
+	}
+
+	status := "success"
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		status = "error"
+	}
+	t.recorder.RecordDBQuery(ctx, "postgres", queryOperation(query), status, query, time.Since(start))
+}
+
+// queryOperation is the statement keyword of query, such as SELECT
+func queryOperation(query string) string {
+	fields := strings.Fields(strings.TrimLeft(query, "( \t\r\n"))
+	if len(fields) == 0 {
+		return "UNKNOWN"
+	}
+	return strings.ToUpper(fields[0])
+}
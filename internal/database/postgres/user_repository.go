@@ -4,7 +4,9 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"slices"
 	"strings"
+	"sync/atomic"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
@@ -14,20 +16,54 @@ import (
 )
 
 type userRepository struct {
-	db *sql.DB
+	db Executor
+
+	// searchVector is set once the search_vector column has been found
+	searchVector atomic.Bool
 }
 
-func NewUserRepository(db *sql.DB) repositories.UserRepository {
+func NewUserRepository(db Executor) repositories.UserRepository {
 	return &userRepository{db: db}
 }
 
+// Executor is implemented by *TimeoutDB, *ConnectionPool and *TenantDB. A
+// *sql.DB is used through NewTimeoutDB, with a zero timeout to leave queries
+// unbounded.
+type Executor interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row
+}
+
+// conn returns the request transaction when there is one, so writes join it
+func (r *userRepository) conn(ctx context.Context) Executor {
+	if tx, ok := repositories.TxFromContext(ctx); ok {
+		return txExecutor{tx}
+	}
+	return r.db
+}
+
+// txExecutor runs queries in the request transaction, bounded by the
+// request's own context
+type txExecutor struct {
+	*sql.Tx
+}
+
+func (t txExecutor) QueryContext(ctx context.Context, query string, args ...interface{}) (*Rows, error) {
+	return unboundedRows(t.Tx.QueryContext(ctx, query, args...))
+}
+
+func (t txExecutor) QueryRowContext(ctx context.Context, query string, args ...interface{}) *Row {
+	return unboundedRow(t.Tx.QueryRowContext(ctx, query, args...))
+}
+
 func (r *userRepository) Create(ctx context.Context, user *entities.User) error {
 	query := `
 		INSERT INTO users (id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 	`
 
-	_, err := r.db.ExecContext(ctx, query,
+	_, err := r.conn(ctx).ExecContext(ctx, query,
 		user.ID,
 		user.Email,
 		user.Password,
@@ -51,12 +87,12 @@ func (r *userRepository) Create(ctx context.Context, user *entities.User) error
 
 func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, role, is_active, totp_enabled, created_at, updated_at, version
 		FROM users WHERE id = $1 AND is_active = true
 	`
 
 	user := &entities.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -64,8 +100,10 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.U
 		&user.LastName,
 		&user.Role,
 		&user.IsActive,
+		&user.TOTPEnabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -80,12 +118,12 @@ func (r *userRepository) GetByID(ctx context.Context, id uuid.UUID) (*entities.U
 
 func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entities.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at
+		SELECT id, email, password_hash, first_name, last_name, role, is_active, totp_enabled, created_at, updated_at, version
 		FROM users WHERE email = $1
 	`
 
 	user := &entities.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, email).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -93,8 +131,10 @@ func (r *userRepository) GetByEmail(ctx context.Context, email string) (*entitie
 		&user.LastName,
 		&user.Role,
 		&user.IsActive,
+		&user.TOTPEnabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err == sql.ErrNoRows {
@@ -137,21 +177,37 @@ func (r *userRepository) Update(ctx context.Context, id uuid.UUID, updates *enti
 	}
 
 	if len(setParts) == 0 {
-		return r.GetByID(ctx, id)
+		user, err := r.GetByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+		if updates.ExpectedVersion != nil && *updates.ExpectedVersion != user.Version {
+			return nil, &repositories.ConcurrentModificationError{CurrentVersion: user.Version}
+		}
+		return user, nil
 	}
 
-	setParts = append(setParts, fmt.Sprintf("updated_at = NOW()"))
+	setParts = append(setParts, fmt.Sprintf("updated_at = NOW()"), "version = version + 1")
+
+	where := fmt.Sprintf("id = $%d", argIndex)
+	args = append(args, id)
+	argIndex++
+
+	// Only update the version the client read, so concurrent updates of it
+	// fail instead of overwriting each other
+	if updates.ExpectedVersion != nil {
+		where += fmt.Sprintf(" AND version = $%d", argIndex)
+		args = append(args, *updates.ExpectedVersion)
+	}
 
 	query := fmt.Sprintf(`
 		UPDATE users SET %s
-		WHERE id = $%d
-		RETURNING id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at
-	`, strings.Join(setParts, ", "), argIndex)
-
-	args = append(args, id)
+		WHERE %s
+		RETURNING id, email, password_hash, first_name, last_name, role, is_active, totp_enabled, created_at, updated_at, version
+	`, strings.Join(setParts, ", "), where)
 
 	user := &entities.User{}
-	err := r.db.QueryRowContext(ctx, query, args...).Scan(
+	err := r.conn(ctx).QueryRowContext(ctx, query, args...).Scan(
 		&user.ID,
 		&user.Email,
 		&user.Password,
@@ -159,11 +215,16 @@ func (r *userRepository) Update(ctx context.Context, id uuid.UUID, updates *enti
 		&user.LastName,
 		&user.Role,
 		&user.IsActive,
+		&user.TOTPEnabled,
 		&user.CreatedAt,
 		&user.UpdatedAt,
+		&user.Version,
 	)
 
 	if err == sql.ErrNoRows {
+		if updates.ExpectedVersion != nil {
+			return nil, r.versionConflict(ctx, id)
+		}
 		return nil, fmt.Errorf("user not found")
 	}
 	if err != nil {
@@ -173,10 +234,24 @@ func (r *userRepository) Update(ctx context.Context, id uuid.UUID, updates *enti
 	return user, nil
 }
 
+// versionConflict explains why an update expecting a version matched no
+// row: the user does not exist or is at another version
+func (r *userRepository) versionConflict(ctx context.Context, id uuid.UUID) error {
+	var version int
+	err := r.conn(ctx).QueryRowContext(ctx, `SELECT version FROM users WHERE id = $1`, id).Scan(&version)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("user not found")
+	}
+	if err != nil {
+		return err
+	}
+	return &repositories.ConcurrentModificationError{CurrentVersion: version}
+}
+
 func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	query := `UPDATE users SET is_active = false, updated_at = NOW() WHERE id = $1`
 
-	result, err := r.db.ExecContext(ctx, query, id)
+	result, err := r.conn(ctx).ExecContext(ctx, query, id)
 	if err != nil {
 		return err
 	}
@@ -193,82 +268,313 @@ func (r *userRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return nil
 }
 
-func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, int, error) {
-	// Get total count
-	var total int
-	countQuery := `SELECT COUNT(*) FROM users WHERE is_active = true`
-	err := r.db.QueryRowContext(ctx, countQuery).Scan(&total)
+func (r *userRepository) GetTOTP(ctx context.Context, id uuid.UUID) (string, bool, error) {
+	query := `SELECT totp_secret, totp_enabled FROM users WHERE id = $1`
+
+	var secret sql.NullString
+	var enabled bool
+	err := r.conn(ctx).QueryRowContext(ctx, query, id).Scan(&secret, &enabled)
+
+	if err == sql.ErrNoRows {
+		return "", false, fmt.Errorf("user not found")
+	}
 	if err != nil {
-		return nil, 0, err
+		return "", false, err
 	}
 
-	// Get users
+	return secret.String, enabled, nil
+}
+
+func (r *userRepository) SetTOTP(ctx context.Context, id uuid.UUID, secret string, enabled bool) error {
+	query := `UPDATE users SET totp_secret = $1, totp_enabled = $2, updated_at = NOW() WHERE id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, sql.NullString{String: secret, Valid: secret != ""}, enabled, id)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *userRepository) GetByOAuth(ctx context.Context, provider, subject string) (*entities.User, error) {
 	query := `
-		SELECT id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at
-		FROM users 
-		WHERE is_active = true
-		ORDER BY created_at DESC
-		LIMIT $1 OFFSET $2
+		SELECT id, email, password_hash, first_name, last_name, role, is_active, totp_enabled, created_at, updated_at, version
+		FROM users WHERE oauth_provider = $1 AND oauth_sub = $2
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, limit, offset)
+	user := &entities.User{}
+	err := r.conn(ctx).QueryRowContext(ctx, query, provider, subject).Scan(
+		&user.ID,
+		&user.Email,
+		&user.Password,
+		&user.FirstName,
+		&user.LastName,
+		&user.Role,
+		&user.IsActive,
+		&user.TOTPEnabled,
+		&user.CreatedAt,
+		&user.UpdatedAt,
+		&user.Version,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("user not found")
+	}
 	if err != nil {
-		return nil, 0, err
+		return nil, err
 	}
-	defer rows.Close()
 
-	var users []*entities.User
-	for rows.Next() {
-		user := &entities.User{}
-		err := rows.Scan(
-			&user.ID,
-			&user.Email,
-			&user.Password,
-			&user.FirstName,
-			&user.LastName,
-			&user.Role,
-			&user.IsActive,
-			&user.CreatedAt,
-			&user.UpdatedAt,
-		)
-		if err != nil {
-			return nil, 0, err
-		}
-		users = append(users, user)
+	return user, nil
+}
+
+func (r *userRepository) SetOAuth(ctx context.Context, id uuid.UUID, provider, subject string) error {
+	query := `UPDATE users SET oauth_provider = $1, oauth_sub = $2, updated_at = NOW() WHERE id = $3`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, provider, subject, id)
+	if err != nil {
+		return err
 	}
 
-	return users, total, nil
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("user not found")
+	}
+
+	return nil
+}
+
+func (r *userRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, int, error) {
+	active := true
+	return r.Filter(ctx, repositories.FilterOptions{IsActive: &active, Offset: offset, Limit: limit})
 }
 
 func (r *userRepository) Search(ctx context.Context, query string, offset, limit int) ([]*entities.User, int, error) {
-	searchPattern := "%" + query + "%"
+	active := true
+	return r.Filter(ctx, repositories.FilterOptions{Search: query, IsActive: &active, Offset: offset, Limit: limit})
+}
 
-	// Get total count
-	var total int
-	countQuery := `
-		SELECT COUNT(*) FROM users 
-		WHERE is_active = true 
-		AND (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)
-	`
-	err := r.db.QueryRowContext(ctx, countQuery, searchPattern).Scan(&total)
+// FullTextSearch matches the search_vector column, a tsvector of the names
+// and email, against query with websearch_to_tsquery, best matches first.
+// Until the column's migration has run it falls back to Search.
+func (r *userRepository) FullTextSearch(ctx context.Context, query string, offset, limit int) ([]*entities.User, int, error) {
+	hasVector, err := r.hasSearchVector(ctx)
 	if err != nil {
 		return nil, 0, err
 	}
+	if !hasVector {
+		return r.Search(ctx, query, offset, limit)
+	}
+
+	const match = `is_active = true AND search_vector @@ websearch_to_tsquery('english', $1)`
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM users WHERE ` + match
+	if err := r.conn(ctx).QueryRowContext(ctx, countQuery, query).Scan(&total); err != nil {
+		return nil, 0, err
+	}
 
-	// Get users
-	searchQuery := `
-		SELECT id, email, password_hash, first_name, last_name, role, is_active, created_at, updated_at
-		FROM users 
-		WHERE is_active = true 
-		AND (first_name ILIKE $1 OR last_name ILIKE $1 OR email ILIKE $1)
-		ORDER BY created_at DESC
+	// LIMIT NULL returns every row, like the builder without a limit
+	var limitArg interface{}
+	if limit > 0 {
+		limitArg = limit
+	}
+	selectQuery := fmt.Sprintf(`
+		SELECT %s FROM users WHERE %s
+		ORDER BY ts_rank(search_vector, websearch_to_tsquery('english', $1)) DESC, created_at DESC
 		LIMIT $2 OFFSET $3
+	`, strings.Join(userColumns, ", "), match)
+
+	users, err := r.scanUsers(r.conn(ctx).QueryContext(ctx, selectQuery, query, limitArg, max(offset, 0)))
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return users, total, nil
+}
+
+// hasSearchVector reports whether the users table has the search_vector
+// column. Only its presence is remembered, so the migration can run while
+// the application is up.
+func (r *userRepository) hasSearchVector(ctx context.Context) (bool, error) {
+	if r.searchVector.Load() {
+		return true, nil
+	}
+
+	var exists bool
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'search_vector'
+		)
 	`
+	if err := r.conn(ctx).QueryRowContext(ctx, query).Scan(&exists); err != nil {
+		return false, fmt.Errorf("failed to check for the users search_vector column: %w", err)
+	}
+
+	if exists {
+		r.searchVector.Store(true)
+	}
+	return exists, nil
+}
+
+// userColumns are the users columns read into entities.User, in scan order
+var userColumns = []string{
+	"id", "email", "password_hash", "first_name", "last_name", "role",
+	"is_active", "totp_enabled", "created_at", "updated_at", "version",
+}
 
-	rows, err := r.db.QueryContext(ctx, searchQuery, searchPattern, limit, offset)
+// userSearchColumns are the columns FilterOptions.Search matches
+var userSearchColumns = []string{"first_name", "last_name", "email"}
+
+func (r *userRepository) Filter(ctx context.Context, opts repositories.FilterOptions) ([]*entities.User, int, error) {
+	builder := NewQueryBuilder("users", userColumns...)
+
+	if opts.Search != "" {
+		builder.WhereAny(userSearchColumns, "ILIKE", "%"+opts.Search+"%")
+	}
+	if opts.Role != "" {
+		builder.Where("role", "=", opts.Role)
+	}
+	if opts.IsActive != nil {
+		builder.Where("is_active", "=", *opts.IsActive)
+	}
+	if opts.CreatedAfter != nil {
+		builder.Where("created_at", ">=", *opts.CreatedAfter)
+	}
+	if opts.CreatedBefore != nil {
+		builder.Where("created_at", "<", *opts.CreatedBefore)
+	}
+
+	sortBy, sortOrder := opts.SortBy, opts.SortOrder
+	if sortBy == "" {
+		sortBy = "created_at"
+	}
+	if sortOrder == "" {
+		sortOrder = "desc"
+	}
+	builder.OrderBy(sortBy, sortOrder).Limit(opts.Limit).Offset(opts.Offset)
+
+	if err := builder.Err(); err != nil {
+		return nil, 0, fmt.Errorf("invalid user filter: %w", err)
+	}
+
+	// Get total count
+	var total int
+	countQuery, countArgs := builder.BuildCount()
+	if err := r.conn(ctx).QueryRowContext(ctx, countQuery, countArgs...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	// Get users
+	users, err := r.queryUsers(ctx, builder)
 	if err != nil {
 		return nil, 0, err
 	}
+
+	return users, total, nil
+}
+
+// defaultCursorLimit is the page size of ListWithCursor when none is given
+const defaultCursorLimit = 20
+
+func (r *userRepository) ListWithCursor(ctx context.Context, opts repositories.CursorPagination) (*repositories.CursorPage, error) {
+	orderBy, direction := opts.OrderBy, strings.ToLower(opts.Direction)
+	if orderBy == "" {
+		orderBy = "created_at"
+	}
+	if direction == "" {
+		direction = "desc"
+	}
+	if direction != "asc" && direction != "desc" {
+		return nil, fmt.Errorf("invalid user cursor: unsupported sort direction %q", opts.Direction)
+	}
+	limit := opts.Limit
+	if limit <= 0 {
+		limit = defaultCursorLimit
+	}
+
+	// Paging backwards walks the order in reverse from the cursor, and the
+	// page is flipped back afterwards
+	backward := opts.Before != nil
+	ascending := (direction == "asc") != backward
+	seekOp, scanDirection := "<", "desc"
+	if ascending {
+		seekOp, scanDirection = ">", "asc"
+	}
+
+	builder := NewQueryBuilder("users", userColumns...).Where("is_active", "=", true)
+	keyset := []string{orderBy, "id"}
+	switch {
+	case backward:
+		builder.Seek(keyset, seekOp, "id", *opts.Before)
+	case opts.After != nil:
+		builder.Seek(keyset, seekOp, "id", *opts.After)
+	}
+	// One row past the limit tells whether there is another page
+	builder.OrderBy(orderBy, scanDirection).OrderBy("id", scanDirection).Limit(limit + 1)
+
+	if err := builder.Err(); err != nil {
+		return nil, fmt.Errorf("invalid user cursor: %w", err)
+	}
+
+	users, err := r.queryUsers(ctx, builder)
+	if err != nil {
+		return nil, err
+	}
+
+	page := &repositories.CursorPage{HasMore: len(users) > limit}
+	if page.HasMore {
+		users = users[:limit]
+	}
+	if backward {
+		slices.Reverse(users)
+	}
+	page.Items = users
+	if len(users) == 0 {
+		return page, nil
+	}
+
+	first, last := users[0].ID, users[len(users)-1].ID
+	if backward {
+		page.NextCursor = &last
+		if page.HasMore {
+			page.PrevCursor = &first
+		}
+	} else {
+		if page.HasMore {
+			page.NextCursor = &last
+		}
+		if opts.After != nil {
+			page.PrevCursor = &first
+		}
+	}
+	return page, nil
+}
+
+// queryUsers runs the builder's query and scans the users it returns
+func (r *userRepository) queryUsers(ctx context.Context, builder *QueryBuilder) ([]*entities.User, error) {
+	query, args := builder.Build()
+	return r.scanUsers(r.conn(ctx).QueryContext(ctx, query, args...))
+}
+
+// scanUsers scans the users of a query selecting userColumns
+func (r *userRepository) scanUsers(rows *Rows, err error) ([]*entities.User, error) {
+	if err != nil {
+		return nil, err
+	}
 	defer rows.Close()
 
 	var users []*entities.User
@@ -282,14 +588,16 @@ func (r *userRepository) Search(ctx context.Context, query string, offset, limit
 			&user.LastName,
 			&user.Role,
 			&user.IsActive,
+			&user.TOTPEnabled,
 			&user.CreatedAt,
 			&user.UpdatedAt,
+			&user.Version,
 		)
 		if err != nil {
-			return nil, 0, err
+			return nil, err
 		}
 		users = append(users, user)
 	}
 
-	return users, total, nil
+	return users, rows.Err()
 }
@@ -15,6 +15,10 @@ type User struct {
 	Role      string    `json:"role" db:"role" validate:"required,oneof=admin user"`
 	IsActive  bool      `json:"is_active" db:"is_active"`
 
+	// TOTPEnabled requires a TOTP code on login; the secret itself is only
+	// read through the repository's GetTOTP
+	TOTPEnabled bool `json:"totp_enabled" db:"totp_enabled"`
+
 	// Image fields
 	Avatar         *string `json:"avatar,omitempty" db:"avatar"`                   // URL to avatar image
 	AvatarPath     *string `json:"avatar_path,omitempty" db:"avatar_path"`         // Storage path
@@ -22,6 +26,9 @@ type User struct {
 
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+
+	// Version is incremented by every update, see UpdateUserRequest
+	Version int `json:"version" db:"version"`
 }
 
 type CreateUserRequest struct {
@@ -37,17 +44,57 @@ type UpdateUserRequest struct {
 	LastName  *string `json:"last_name,omitempty" validate:"omitempty,min=1"`
 	Role      *string `json:"role,omitempty" validate:"omitempty,oneof=admin user"`
 	IsActive  *bool   `json:"is_active,omitempty"`
+
+	// ExpectedVersion rejects the update when the user is no longer at this
+	// version, i.e. was updated since it was read
+	ExpectedVersion *int `json:"expected_version,omitempty" validate:"omitempty,min=0"`
 }
 
 type LoginRequest struct {
 	Email    string `json:"email" validate:"required,email"`
 	Password string `json:"password" validate:"required"`
+	// TOTPCode is required for users with two-factor authentication enabled
+	TOTPCode string `json:"totp_code,omitempty"`
 }
 
 type LoginResponse struct {
-	Token     string    `json:"token"`
-	User      User      `json:"user"`
-	ExpiresAt time.Time `json:"expires_at"`
+	Token        string    `json:"token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	User         User      `json:"user"`
+	ExpiresAt    time.Time `json:"expires_at"`
+}
+
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type RefreshTokenResponse struct {
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+// LogoutRequest optionally carries the refresh token to revoke on logout
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// TOTPCodeRequest carries a code of the user's authenticator app
+type TOTPCodeRequest struct {
+	Code string `json:"code" validate:"required"`
+}
+
+// TOTPEnrollmentResponse is the secret to confirm with a first code, and
+// its otpauth:// URL to render as a QR code
+type TOTPEnrollmentResponse struct {
+	Secret    string `json:"secret"`
+	QRCodeURL string `json:"qr_code_url"`
+}
+
+type CreateAPIKeyRequest struct {
+	UserID    uuid.UUID  `json:"user_id" validate:"required"`
+	Name      string     `json:"name" validate:"required,max=100"`
+	Scopes    []string   `json:"scopes,omitempty"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
 }
 
 func (u *User) BeforeCreate() {
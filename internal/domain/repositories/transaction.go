@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+)
+
+type txContextKey struct{}
+
+// WithTx returns a context carrying a transaction that repositories should
+// run their queries in instead of opening their own connection
+func WithTx(ctx context.Context, tx *sql.Tx) context.Context {
+	return context.WithValue(ctx, txContextKey{}, tx)
+}
+
+// TxFromContext returns the transaction stored by WithTx
+func TxFromContext(ctx context.Context) (*sql.Tx, bool) {
+	tx, ok := ctx.Value(txContextKey{}).(*sql.Tx)
+	return tx, ok && tx != nil
+}
@@ -2,19 +2,106 @@ package repositories
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/VeRJiL/go-template/internal/domain/entities"
 	"github.com/google/uuid"
 )
 
+// ErrConcurrentModification is returned by Update when the user was changed
+// since the version the request expected
+var ErrConcurrentModification = errors.New("concurrent modification")
+
+// ConcurrentModificationError is the ErrConcurrentModification of an update,
+// carrying the version the user is at
+type ConcurrentModificationError struct {
+	CurrentVersion int
+}
+
+func (e *ConcurrentModificationError) Error() string {
+	return fmt.Sprintf("%s: user is at version %d", ErrConcurrentModification, e.CurrentVersion)
+}
+
+func (e *ConcurrentModificationError) Unwrap() error {
+	return ErrConcurrentModification
+}
+
 type UserRepository interface {
 	Create(ctx context.Context, user *entities.User) error
 	GetByID(ctx context.Context, id uuid.UUID) (*entities.User, error)
 	GetByEmail(ctx context.Context, email string) (*entities.User, error)
+	// Update increments the user's version. When updates has an
+	// ExpectedVersion it fails with a *ConcurrentModificationError unless the
+	// user is still at that version.
 	Update(ctx context.Context, id uuid.UUID, updates *entities.UpdateUserRequest) (*entities.User, error)
 	Delete(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, offset, limit int) ([]*entities.User, int, error)
 	Search(ctx context.Context, query string, offset, limit int) ([]*entities.User, int, error)
+	// FullTextSearch matches the active users against a web search style
+	// query ("jane -smith", "\"jane doe\"") and ranks them by relevance
+	FullTextSearch(ctx context.Context, query string, offset, limit int) ([]*entities.User, int, error)
+	Filter(ctx context.Context, opts FilterOptions) ([]*entities.User, int, error)
+	// ListWithCursor pages through the active users from a cursor instead of
+	// an offset, which stays fast however deep the page
+	ListWithCursor(ctx context.Context, opts CursorPagination) (*CursorPage, error)
+
+	// GetTOTP and SetTOTP store the user's TOTP secret and whether two-factor
+	// authentication is enabled
+	GetTOTP(ctx context.Context, id uuid.UUID) (secret string, enabled bool, err error)
+	SetTOTP(ctx context.Context, id uuid.UUID, secret string, enabled bool) error
+
+	// GetByOAuth returns the user linked to an account at an OAuth provider,
+	// which SetOAuth links
+	GetByOAuth(ctx context.Context, provider, subject string) (*entities.User, error)
+	SetOAuth(ctx context.Context, id uuid.UUID, provider, subject string) error
+}
+
+// FilterOptions selects and orders users for Filter. Zero values leave the
+// listing unfiltered.
+type FilterOptions struct {
+	// Search matches the first name, last name or email, case insensitively
+	Search   string
+	Role     string
+	IsActive *bool
+
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+
+	// SortBy is a users column, created_at by default; SortOrder is asc or
+	// desc, desc by default
+	SortBy    string
+	SortOrder string
+
+	Offset int
+	Limit  int
+}
+
+// CursorPagination selects a page of users relative to the user a cursor
+// points at. With neither After nor Before set it selects the first page.
+type CursorPagination struct {
+	// After selects the users following this one, Before those preceding it
+	After  *uuid.UUID
+	Before *uuid.UUID
+	Limit  int
+
+	// OrderBy is a users column, created_at by default, with the ID breaking
+	// ties; Direction is asc or desc, desc by default
+	OrderBy   string
+	Direction string
+}
+
+// CursorPage is a page of users with the cursors of the adjacent pages
+type CursorPage struct {
+	Items []*entities.User
+	// NextCursor and PrevCursor are passed as After and Before to get the
+	// following and preceding pages; nil when there is no such page
+	NextCursor *uuid.UUID
+	PrevCursor *uuid.UUID
+	// HasMore reports whether more users follow the page in the direction
+	// it was requested: after it, or before it when paging with Before
+	HasMore bool
 }
 
 type UserCacheRepository interface {
@@ -3,8 +3,11 @@ package services
 import (
 	"context"
 	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -12,18 +15,30 @@ import (
 	"github.com/VeRJiL/go-template/internal/domain/entities"
 	"github.com/VeRJiL/go-template/internal/domain/repositories"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/auth/oauth"
 )
 
 var (
 	ErrUserNotFound       = errors.New("user not found")
 	ErrUserExists         = errors.New("user already exists")
 	ErrInvalidCredentials = errors.New("invalid credentials")
+	ErrInvalidEmail       = errors.New("invalid email address")
+	ErrTOTPRequired       = errors.New("TOTP code required")
+	ErrUserDisabled       = errors.New("user account is disabled")
+
+	ErrUnknownOAuthProvider = errors.New("unknown OAuth provider")
+	// ErrOAuthTOTPEnabled is returned on social login for users with
+	// two-factor authentication enabled, who log in with a TOTP code
+	ErrOAuthTOTPEnabled = errors.New("social login is unavailable with two-factor authentication enabled")
 )
 
 type UserService struct {
-	userRepo      repositories.UserRepository
-	userCacheRepo repositories.UserCacheRepository
-	jwtService    *auth.JWTService
+	userRepo       repositories.UserRepository
+	userCacheRepo  repositories.UserCacheRepository
+	jwtService     *auth.JWTService
+	emailValidator *auth.EmailValidator
+	totpService    *auth.TOTPService
+	oauthProviders map[string]oauth.OAuthProvider
 }
 
 func NewUserService(
@@ -40,7 +55,33 @@ func (s *UserService) SetCacheRepository(cacheRepo repositories.UserCacheReposit
 	s.userCacheRepo = cacheRepo
 }
 
+// SetEmailValidator enables email domain checks on registration
+func (s *UserService) SetEmailValidator(validator *auth.EmailValidator) {
+	s.emailValidator = validator
+}
+
+// SetTOTPService enables login with TOTP two-factor authentication for
+// users that enabled it
+func (s *UserService) SetTOTPService(totpService *auth.TOTPService) {
+	s.totpService = totpService
+}
+
+// SetOAuthProviders enables social login with the providers, keyed by the
+// name used in the /auth/:provider routes
+func (s *UserService) SetOAuthProviders(providers map[string]oauth.OAuthProvider) {
+	s.oauthProviders = providers
+}
+
 func (s *UserService) Create(ctx context.Context, req *entities.CreateUserRequest) (*entities.User, error) {
+	if s.emailValidator != nil {
+		if err := s.emailValidator.Validate(ctx, req.Email); err != nil {
+			if errors.Is(err, auth.ErrEmailLookupFailed) {
+				return nil, fmt.Errorf("failed to validate email: %w", err)
+			}
+			return nil, fmt.Errorf("%w: %w", ErrInvalidEmail, err)
+		}
+	}
+
 	existingUser, _ := s.userRepo.GetByEmail(ctx, req.Email)
 	if existingUser != nil {
 		return nil, ErrUserExists
@@ -142,22 +183,201 @@ func (s *UserService) Login(ctx context.Context, req *entities.LoginRequest) (*e
 		return nil, errors.New("user account is disabled")
 	}
 
+	if user.TOTPEnabled {
+		if err := s.verifyTOTP(ctx, user, req.TOTPCode); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.issueTokens(user)
+}
+
+// OAuthAuthURL returns the consent page of provider, which redirects back
+// with state
+func (s *UserService) OAuthAuthURL(provider, state string) (string, error) {
+	p, ok := s.oauthProviders[provider]
+	if !ok {
+		return "", ErrUnknownOAuthProvider
+	}
+	return p.GetAuthURL(state), nil
+}
+
+// LoginWithOAuth exchanges the code provider redirected back with for the
+// user's account, and logs in the user linked to it. Users are otherwise
+// found by the verified email of the account and linked to it, or created
+// with an unusable password.
+func (s *UserService) LoginWithOAuth(ctx context.Context, provider, code string) (*entities.LoginResponse, error) {
+	p, ok := s.oauthProviders[provider]
+	if !ok {
+		return nil, ErrUnknownOAuthProvider
+	}
+
+	account, err := p.ExchangeCode(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.findOrCreateOAuthUser(ctx, provider, account)
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("user account is disabled")
+	}
+
+	if user.TOTPEnabled {
+		return nil, ErrOAuthTOTPEnabled
+	}
+
+	return s.issueTokens(user)
+}
+
+func (s *UserService) findOrCreateOAuthUser(ctx context.Context, provider string, account *oauth.OAuthUser) (*entities.User, error) {
+	if user, err := s.userRepo.GetByOAuth(ctx, provider, account.Subject); err == nil {
+		return user, nil
+	}
+
+	user, err := s.userRepo.GetByEmail(ctx, account.Email)
+	if err != nil {
+		user, err = s.createOAuthUser(ctx, account)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.userRepo.SetOAuth(ctx, user.ID, provider, account.Subject); err != nil {
+		return nil, fmt.Errorf("failed to link %s account: %w", provider, err)
+	}
+
+	return user, nil
+}
+
+// createOAuthUser creates the user of an account, with a random password
+// no one knows so that it can only log in through the provider
+func (s *UserService) createOAuthUser(ctx context.Context, account *oauth.OAuthUser) (*entities.User, error) {
+	password := make([]byte, 32)
+	if _, err := rand.Read(password); err != nil {
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(hex.EncodeToString(password)), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	firstName := account.FirstName
+	if firstName == "" {
+		firstName, _, _ = strings.Cut(account.Email, "@")
+	}
+
+	user := &entities.User{
+		Email:     account.Email,
+		Password:  string(hashedPassword),
+		FirstName: firstName,
+		LastName:  account.LastName,
+	}
+	user.BeforeCreate()
+
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to create user: %w", err)
+	}
+
+	s.invalidateUserListCache(ctx)
+
+	return user, nil
+}
+
+// issueTokens returns the access and refresh tokens of a logged in user
+func (s *UserService) issueTokens(user *entities.User) (*entities.LoginResponse, error) {
 	token, expiresAt, err := s.jwtService.GenerateToken(user.ID, user.Email, user.Role)
 	if err != nil {
 		return nil, fmt.Errorf("failed to generate token: %w", err)
 	}
 
+	refreshToken, err := s.jwtService.GenerateRefreshToken(user.ID, user.Email, user.Role)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+	}
+
 	return &entities.LoginResponse{
-		Token:     token,
-		User:      *user,
-		ExpiresAt: expiresAt,
+		Token:        token,
+		RefreshToken: refreshToken,
+		User:         *user,
+		ExpiresAt:    expiresAt,
+	}, nil
+}
+
+// verifyTOTP fails closed: users with TOTP enabled cannot log in without a
+// TOTP service to check their code
+func (s *UserService) verifyTOTP(ctx context.Context, user *entities.User, code string) error {
+	if code == "" {
+		return ErrTOTPRequired
+	}
+
+	if s.totpService == nil {
+		return errors.New("two-factor authentication is not configured")
+	}
+
+	valid, err := s.totpService.VerifyTOTP(ctx, user.ID, code)
+	if err != nil {
+		return fmt.Errorf("failed to verify TOTP code: %w", err)
+	}
+	if !valid {
+		return auth.ErrInvalidTOTPCode
+	}
+
+	return nil
+}
+
+// RefreshToken rotates a refresh token into a new access and refresh token
+func (s *UserService) RefreshToken(ctx context.Context, refreshToken string) (*entities.RefreshTokenResponse, error) {
+	token, newRefreshToken, err := s.jwtService.RotateRefreshToken(ctx, refreshToken, s.refreshSubject)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entities.RefreshTokenResponse{
+		Token:        token,
+		RefreshToken: newRefreshToken,
 	}, nil
 }
 
-func (s *UserService) Logout(ctx context.Context, token string) error {
+// refreshSubject reloads the user a refresh token was issued to, so that
+// deactivated users cannot rotate their tokens and role changes apply
+func (s *UserService) refreshSubject(ctx context.Context, userID uuid.UUID) (string, string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", "", ErrUserNotFound
+	}
+	if !user.IsActive {
+		return "", "", ErrUserDisabled
+	}
+
+	return user.Email, user.Role, nil
+}
+
+// Logout revokes the access token and, when given, the refresh token
+func (s *UserService) Logout(ctx context.Context, token, refreshToken string) error {
+	if token != "" {
+		if err := s.jwtService.RevokeToken(ctx, token); err != nil {
+			return err
+		}
+	}
+
+	if refreshToken != "" {
+		return s.jwtService.RevokeRefreshToken(ctx, refreshToken)
+	}
+
 	return nil
 }
 
+// ListWithCursor pages through the active users from a cursor. Pages are
+// not cached, since every cursor is a different page.
+func (s *UserService) ListWithCursor(ctx context.Context, opts repositories.CursorPagination) (*repositories.CursorPage, error) {
+	return s.userRepo.ListWithCursor(ctx, opts)
+}
+
 func (s *UserService) Search(ctx context.Context, query string, offset, limit int) ([]*entities.User, int, error) {
 	return s.userRepo.Search(ctx, query, offset, limit)
 }
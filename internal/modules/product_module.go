@@ -42,6 +42,17 @@ func (m *ProductModule) Version() string {
 	return m.version
 }
 
+// MinBootstrapVersion returns the oldest bootstrap version the module runs on
+func (m *ProductModule) MinBootstrapVersion() string {
+	return "1.0.0"
+}
+
+// MaxBootstrapVersion returns the newest bootstrap version the module runs
+// on, empty for any
+func (m *ProductModule) MaxBootstrapVersion() string {
+	return ""
+}
+
 // Dependencies returns the module dependencies
 func (m *ProductModule) Dependencies() []string {
 	return m.dependencies
@@ -50,23 +61,38 @@ func (m *ProductModule) Dependencies() []string {
 // RegisterServices registers module services with the container
 func (m *ProductModule) RegisterServices(cont *container.Container) error {
 	// Register repository
-	cont.RegisterSingleton("productRepository", func(c *container.Container) interface{} {
-		db := c.MustGet("db").(*sql.DB)
-		return repositories.NewProductRepository(db)
+	container.Provide(cont, "productRepository", func() (repositories.ProductRepository, error) {
+		db, err := container.Resolve[*sql.DB](cont, "db")
+		if err != nil {
+			return nil, err
+		}
+		return repositories.NewProductRepository(db), nil
 	})
 
 	// Register service
-	cont.RegisterSingleton("productService", func(c *container.Container) interface{} {
-		repo := c.MustGet("productRepository").(repositories.ProductRepository)
-		logger := c.MustGet("logger").(*logger.Logger)
-		return services.NewProductService(repo, logger)
+	container.Provide(cont, "productService", func() (services.ProductService, error) {
+		repo, err := container.Resolve[repositories.ProductRepository](cont, "productRepository")
+		if err != nil {
+			return nil, err
+		}
+		logger, err := container.Resolve[*logger.Logger](cont, "logger")
+		if err != nil {
+			return nil, err
+		}
+		return services.NewProductService(repo, logger), nil
 	})
 
 	// Register handler
-	cont.RegisterSingleton("productHandler", func(c *container.Container) interface{} {
-		service := c.MustGet("productService").(services.ProductService)
-		logger := c.MustGet("logger").(*logger.Logger)
-		return handlers.NewProductHandler(service, logger)
+	container.Provide(cont, "productHandler", func() (*handlers.ProductHandler, error) {
+		service, err := container.Resolve[services.ProductService](cont, "productService")
+		if err != nil {
+			return nil, err
+		}
+		logger, err := container.Resolve[*logger.Logger](cont, "logger")
+		if err != nil {
+			return nil, err
+		}
+		return handlers.NewProductHandler(service, logger), nil
 	})
 
 	return nil
@@ -74,7 +100,10 @@ func (m *ProductModule) RegisterServices(cont *container.Container) error {
 
 // RegisterRoutes registers module routes
 func (m *ProductModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Dependencies) error {
-	handler := deps.Container.MustGet("productHandler").(*handlers.ProductHandler)
+	handler, err := container.Resolve[*handlers.ProductHandler](deps.Container, "productHandler")
+	if err != nil {
+		return err
+	}
 
 	productGroup := router.Group("/products")
 	{
@@ -114,6 +143,12 @@ func (m *ProductModule) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// Drain waits for the module's in-flight work before shutdown
+func (m *ProductModule) Drain(ctx context.Context) error {
+	// Add waiting on background work here
+	return nil
+}
+
 // Shutdown gracefully shuts down the module
 func (m *ProductModule) Shutdown(ctx context.Context) error {
 	// Add module cleanup logic here
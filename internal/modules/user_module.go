@@ -7,6 +7,7 @@ import (
 	"github.com/gin-gonic/gin"
 
 	"github.com/VeRJiL/go-template/internal/api/handlers"
+	"github.com/VeRJiL/go-template/internal/config"
 	"github.com/VeRJiL/go-template/internal/database/postgres"
 	"github.com/VeRJiL/go-template/internal/database/redis"
 	"github.com/VeRJiL/go-template/internal/domain/repositories"
@@ -45,6 +46,17 @@ func (m *UserModule) Version() string {
 	return m.version
 }
 
+// MinBootstrapVersion returns the oldest bootstrap version the module runs on
+func (m *UserModule) MinBootstrapVersion() string {
+	return "1.0.0"
+}
+
+// MaxBootstrapVersion returns the newest bootstrap version the module runs
+// on, empty for any
+func (m *UserModule) MaxBootstrapVersion() string {
+	return ""
+}
+
 // Dependencies returns the module dependencies
 func (m *UserModule) Dependencies() []string {
 	return m.dependencies
@@ -53,41 +65,62 @@ func (m *UserModule) Dependencies() []string {
 // RegisterServices registers user module services with the container
 func (m *UserModule) RegisterServices(c *container.Container) error {
 	// Register user repository
-	c.RegisterSingleton("userRepository", func(container *container.Container) interface{} {
-		db := container.MustGet("db").(*sql.DB)
-		return postgres.NewUserRepository(db)
+	container.Provide(c, "userRepository", func() (repositories.UserRepository, error) {
+		db, err := container.Resolve[*sql.DB](c, "db")
+		if err != nil {
+			return nil, err
+		}
+		return postgres.NewUserRepository(postgres.NewTimeoutDB(db, 0, nil)), nil
 	})
 
 	// Register user cache repository (optional)
-	c.RegisterSingleton("userCacheRepository", func(container *container.Container) interface{} {
-		redisClient, err := container.Get("redis")
-		if err != nil {
+	container.Provide(c, "userCacheRepository", func() (repositories.UserCacheRepository, error) {
+		redisClient, err := container.Resolve[*redisLib.Client](c, "redis")
+		if err != nil || redisClient == nil {
 			// Redis not available, return nil
-			return nil
+			return nil, nil
 		}
-		return redis.NewUserCacheRepository(redisClient.(*redisLib.Client))
+		return redis.NewUserCacheRepository(redisClient), nil
 	})
 
 	// Register user service
-	c.RegisterSingleton("userService", func(container *container.Container) interface{} {
-		userRepo := container.MustGet("userRepository").(repositories.UserRepository)
-		jwtService := container.MustGet("jwtService").(*auth.JWTService)
+	container.Provide(c, "userService", func() (*services.UserService, error) {
+		userRepo, err := container.Resolve[repositories.UserRepository](c, "userRepository")
+		if err != nil {
+			return nil, err
+		}
+		jwtService, err := container.Resolve[*auth.JWTService](c, "jwtService")
+		if err != nil {
+			return nil, err
+		}
 
 		userService := services.NewUserService(userRepo, jwtService)
 
+		issuer := "go-template"
+		if cfg, err := container.Resolve[*config.Config](c, "config"); err == nil && cfg != nil {
+			issuer = cfg.App.Name
+		}
+		userService.SetTOTPService(auth.NewTOTPService(issuer, userRepo))
+
 		// Set cache repository if available
-		if cacheRepo, err := container.Get("userCacheRepository"); err == nil && cacheRepo != nil {
-			userService.SetCacheRepository(cacheRepo.(repositories.UserCacheRepository))
+		if cacheRepo, err := container.Resolve[repositories.UserCacheRepository](c, "userCacheRepository"); err == nil && cacheRepo != nil {
+			userService.SetCacheRepository(cacheRepo)
 		}
 
-		return userService
+		return userService, nil
 	})
 
 	// Register user handler
-	c.RegisterSingleton("userHandler", func(container *container.Container) interface{} {
-		userService := container.MustGet("userService").(*services.UserService)
-		logger := container.MustGet("logger").(*logger.Logger)
-		return handlers.NewUserHandler(userService, logger)
+	container.Provide(c, "userHandler", func() (*handlers.UserHandler, error) {
+		userService, err := container.Resolve[*services.UserService](c, "userService")
+		if err != nil {
+			return nil, err
+		}
+		logger, err := container.Resolve[*logger.Logger](c, "logger")
+		if err != nil {
+			return nil, err
+		}
+		return handlers.NewUserHandler(userService, logger), nil
 	})
 
 	// Register user messaging service (for events and notifications)
@@ -101,7 +134,10 @@ func (m *UserModule) RegisterServices(c *container.Container) error {
 
 // RegisterRoutes registers user module routes
 func (m *UserModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Dependencies) error {
-	userHandler := deps.Container.MustGet("userHandler").(*handlers.UserHandler)
+	userHandler, err := container.Resolve[*handlers.UserHandler](deps.Container, "userHandler")
+	if err != nil {
+		return err
+	}
 
 	// Create users group
 	usersGroup := router.Group("/users")
@@ -126,6 +162,7 @@ func (m *UserModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Depen
 	authGroup := router.Group("/auth")
 	{
 		authGroup.POST("/login", userHandler.Login)
+		authGroup.POST("/refresh", userHandler.Refresh)
 		authGroup.POST("/logout", userHandler.Logout)
 	}
 
@@ -249,6 +286,14 @@ func (m *UserModule) Initialize(ctx context.Context) error {
 	return nil
 }
 
+// Drain waits for the user module's in-flight work before shutdown
+func (m *UserModule) Drain(ctx context.Context) error {
+	// Add waiting on module-specific background work here
+	// For example: outstanding event handlers, queued emails, etc.
+
+	return nil
+}
+
 // Shutdown gracefully shuts down the user module
 func (m *UserModule) Shutdown(ctx context.Context) error {
 	if m.logger != nil {
@@ -277,6 +322,7 @@ func (m *UserModule) GetModuleInfo() modules.ModuleInfo {
 			{Method: "DELETE", Path: "/users/:id", Handler: "Delete", Auth: true, Permissions: []string{"admin"}},
 			{Method: "POST", Path: "/auth/register", Handler: "Register", Auth: false},
 			{Method: "POST", Path: "/auth/login", Handler: "Login", Auth: false},
+			{Method: "POST", Path: "/auth/refresh", Handler: "Refresh", Auth: false},
 			{Method: "POST", Path: "/auth/logout", Handler: "Logout", Auth: true},
 			{Method: "GET", Path: "/users/profile", Handler: "GetProfile", Auth: true},
 		},
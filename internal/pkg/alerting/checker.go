@@ -0,0 +1,160 @@
+package alerting
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCheckInterval = 30 * time.Second
+	defaultCheckTimeout  = 5 * time.Second
+	subscriberBuffer     = 64
+)
+
+// CheckFunc reports a dependency as unhealthy by returning an error
+type CheckFunc func(ctx context.Context) error
+
+// CheckResult is the outcome of one run of a named health check
+type CheckResult struct {
+	Check     string    `json:"check"`
+	Healthy   bool      `json:"healthy"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// HealthChecker runs named checks on an interval and publishes every result
+// to its subscribers
+type HealthChecker struct {
+	interval time.Duration
+	timeout  time.Duration
+
+	mu          sync.RWMutex
+	checks      map[string]CheckFunc
+	subscribers []chan CheckResult
+}
+
+// NewHealthChecker creates a health checker. Each check gets timeout to
+// complete before it is reported unhealthy.
+func NewHealthChecker(interval, timeout time.Duration) *HealthChecker {
+	if interval <= 0 {
+		interval = defaultCheckInterval
+	}
+	if timeout <= 0 {
+		timeout = defaultCheckTimeout
+	}
+
+	return &HealthChecker{
+		interval: interval,
+		timeout:  timeout,
+		checks:   make(map[string]CheckFunc),
+	}
+}
+
+// AddCheck registers a check under name, replacing any previous one
+func (h *HealthChecker) AddCheck(name string, check CheckFunc) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.checks[name] = check
+}
+
+// Subscribe returns a channel receiving every check result. The channel is
+// closed when Start returns. Results are dropped for subscribers whose
+// buffer is full rather than blocking the checks.
+func (h *HealthChecker) Subscribe() <-chan CheckResult {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	ch := make(chan CheckResult, subscriberBuffer)
+	h.subscribers = append(h.subscribers, ch)
+	return ch
+}
+
+// Start runs all checks immediately and then every interval until the
+// context is cancelled
+func (h *HealthChecker) Start(ctx context.Context) {
+	defer h.closeSubscribers()
+
+	ticker := time.NewTicker(h.interval)
+	defer ticker.Stop()
+
+	for {
+		results := h.RunChecks(ctx)
+		// Checks cut short by shutdown would all read as failures
+		if ctx.Err() != nil {
+			return
+		}
+		h.publish(results)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// RunChecks runs every check once, concurrently, and returns the results
+// sorted by check name
+func (h *HealthChecker) RunChecks(ctx context.Context) []CheckResult {
+	h.mu.RLock()
+	checks := make(map[string]CheckFunc, len(h.checks))
+	for name, check := range h.checks {
+		checks[name] = check
+	}
+	h.mu.RUnlock()
+
+	results := make([]CheckResult, 0, len(checks))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check CheckFunc) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, h.timeout)
+			defer cancel()
+
+			result := CheckResult{Check: name, Healthy: true, CheckedAt: time.Now()}
+			if err := check(checkCtx); err != nil {
+				result.Healthy = false
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			results = append(results, result)
+			mu.Unlock()
+		}(name, check)
+	}
+	wg.Wait()
+
+	sort.Slice(results, func(i, j int) bool { return results[i].Check < results[j].Check })
+	return results
+}
+
+func (h *HealthChecker) publish(results []CheckResult) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	for _, ch := range h.subscribers {
+		for _, result := range results {
+			select {
+			case ch <- result:
+			default:
+			}
+		}
+	}
+}
+
+func (h *HealthChecker) closeSubscribers() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for _, ch := range h.subscribers {
+		close(ch)
+	}
+	h.subscribers = nil
+}
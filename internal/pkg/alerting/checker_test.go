@@ -0,0 +1,63 @@
+package alerting
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthChecker(t *testing.T) {
+	t.Run("should report each check's result", func(t *testing.T) {
+		checker := NewHealthChecker(time.Hour, time.Second)
+		checker.AddCheck("redis", func(ctx context.Context) error { return errors.New("connection refused") })
+		checker.AddCheck("database", func(ctx context.Context) error { return nil })
+
+		results := checker.RunChecks(context.Background())
+
+		require.Len(t, results, 2)
+		assert.Equal(t, "database", results[0].Check)
+		assert.True(t, results[0].Healthy)
+		assert.Equal(t, "redis", results[1].Check)
+		assert.False(t, results[1].Healthy)
+		assert.Equal(t, "connection refused", results[1].Error)
+	})
+
+	t.Run("should fail checks that exceed the timeout", func(t *testing.T) {
+		checker := NewHealthChecker(time.Hour, 10*time.Millisecond)
+		checker.AddCheck("slow", func(ctx context.Context) error {
+			<-ctx.Done()
+			return ctx.Err()
+		})
+
+		results := checker.RunChecks(context.Background())
+
+		require.Len(t, results, 1)
+		assert.False(t, results[0].Healthy)
+	})
+
+	t.Run("should publish results and close subscribers on stop", func(t *testing.T) {
+		checker := NewHealthChecker(10*time.Millisecond, time.Second)
+		checker.AddCheck("database", func(ctx context.Context) error { return nil })
+		results := checker.Subscribe()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		stopped := make(chan struct{})
+		go func() {
+			checker.Start(ctx)
+			close(stopped)
+		}()
+
+		result := <-results
+		assert.Equal(t, "database", result.Check)
+		assert.True(t, result.Healthy)
+
+		cancel()
+		<-stopped
+		for range results {
+		}
+	})
+}
@@ -0,0 +1,234 @@
+package alerting
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const (
+	// DefaultAlertDebounceInterval is used when no debounce interval is set
+	DefaultAlertDebounceInterval = 5 * time.Minute
+
+	defaultPagerDutyURL = "https://events.pagerduty.com/v2/enqueue"
+	defaultAlertSource  = "go-template"
+)
+
+// NotifierConfig holds the alert destinations. Slack and PagerDuty are each
+// skipped when not configured.
+type NotifierConfig struct {
+	SlackWebhookURL       string        `json:"slack_webhook_url" mapstructure:"slack_webhook_url"`
+	PagerDutyRoutingKey   string        `json:"pagerduty_routing_key" mapstructure:"pagerduty_routing_key"`
+	PagerDutyURL          string        `json:"pagerduty_url" mapstructure:"pagerduty_url"`
+	AlertDebounceInterval time.Duration `json:"alert_debounce_interval" mapstructure:"alert_debounce_interval"`
+	Source                string        `json:"source" mapstructure:"source"`
+}
+
+// Notifier turns health check results into alerts. It alerts when a check
+// goes from healthy to unhealthy and sends a recovery notification when it
+// comes back; results that do not change a check's state are ignored.
+//
+// A check that fails again within AlertDebounceInterval of its last alert is
+// not re-alerted, so flapping checks do not page repeatedly. If it is still
+// unhealthy once the interval has passed, the alert is sent then.
+type Notifier struct {
+	config          NotifierConfig
+	slackClient     *http.Client
+	pagerDutyClient *http.Client
+	logger          *logger.Logger
+	now             func() time.Time
+
+	mu     sync.Mutex
+	checks map[string]*checkState
+}
+
+// checkState tracks the alerting state of one named check
+type checkState struct {
+	healthy        bool
+	unhealthySince time.Time
+	alerted        bool
+	lastAlert      time.Time
+}
+
+// alertKind is the notification a result triggers
+type alertKind int
+
+const (
+	alertNone alertKind = iota
+	alertFailure
+	alertRecovery
+)
+
+// NewNotifier creates a notifier for the configured destinations
+func NewNotifier(config NotifierConfig, log *logger.Logger) *Notifier {
+	if config.PagerDutyURL == "" {
+		config.PagerDutyURL = defaultPagerDutyURL
+	}
+	if config.AlertDebounceInterval <= 0 {
+		config.AlertDebounceInterval = DefaultAlertDebounceInterval
+	}
+	if config.Source == "" {
+		config.Source = defaultAlertSource
+	}
+
+	httpClient := &http.Client{Timeout: 10 * time.Second}
+	return &Notifier{
+		config:          config,
+		slackClient:     httpClient,
+		pagerDutyClient: httpClient,
+		logger:          log,
+		now:             time.Now,
+		checks:          make(map[string]*checkState),
+	}
+}
+
+// SetHTTPClients overrides the clients alerts are sent to Slack and
+// PagerDuty with, such as clients behind a circuit breaker per service
+func (n *Notifier) SetHTTPClients(slack, pagerDuty *http.Client) {
+	n.slackClient = slack
+	n.pagerDutyClient = pagerDuty
+}
+
+// Subscribe handles results until the channel is closed or the context is
+// cancelled
+func (n *Notifier) Subscribe(ctx context.Context, results <-chan CheckResult) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case result, ok := <-results:
+			if !ok {
+				return
+			}
+			n.Observe(ctx, result)
+		}
+	}
+}
+
+// Observe records one check result and sends any notification it triggers
+func (n *Notifier) Observe(ctx context.Context, result CheckResult) {
+	kind, downtime := n.transition(result)
+
+	switch kind {
+	case alertFailure:
+		n.logger.Warn("Health check failed", "check", result.Check, "error", result.Error)
+		n.sendSlack(ctx, fmt.Sprintf(":red_circle: Health check *%s* is failing: %s", result.Check, result.Error))
+		n.sendPagerDuty(ctx, "trigger", result)
+	case alertRecovery:
+		n.logger.Info("Health check recovered", "check", result.Check, "downtime", downtime)
+		n.sendSlack(ctx, fmt.Sprintf(":large_green_circle: Health check *%s* recovered after %s", result.Check, downtime.Round(time.Second)))
+		n.sendPagerDuty(ctx, "resolve", result)
+	}
+}
+
+// transition updates the check state and decides which notification to send
+func (n *Notifier) transition(result CheckResult) (alertKind, time.Duration) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	now := n.now()
+	state, seen := n.checks[result.Check]
+	if !seen {
+		// Checks are assumed healthy until they report otherwise
+		state = &checkState{healthy: true}
+		n.checks[result.Check] = state
+	}
+
+	if result.Healthy {
+		if state.healthy {
+			return alertNone, 0
+		}
+
+		state.healthy = true
+		downtime := now.Sub(state.unhealthySince)
+		if !state.alerted {
+			return alertNone, 0
+		}
+		state.alerted = false
+		return alertRecovery, downtime
+	}
+
+	if state.healthy {
+		state.healthy = false
+		state.unhealthySince = now
+	}
+	if state.alerted {
+		return alertNone, 0
+	}
+
+	if !state.lastAlert.IsZero() && now.Sub(state.lastAlert) < n.config.AlertDebounceInterval {
+		n.logger.Debug("Health check alert debounced", "check", result.Check)
+		return alertNone, 0
+	}
+
+	state.alerted = true
+	state.lastAlert = now
+	return alertFailure, 0
+}
+
+func (n *Notifier) sendSlack(ctx context.Context, text string) {
+	if n.config.SlackWebhookURL == "" {
+		return
+	}
+
+	if err := n.post(ctx, n.slackClient, n.config.SlackWebhookURL, map[string]interface{}{"text": text}); err != nil {
+		n.logger.Error("Failed to send Slack alert", "error", err)
+	}
+}
+
+// sendPagerDuty triggers or resolves an incident through the Events API v2.
+// The dedup key ties a recovery to the incident its failure opened.
+func (n *Notifier) sendPagerDuty(ctx context.Context, action string, result CheckResult) {
+	if n.config.PagerDutyRoutingKey == "" {
+		return
+	}
+
+	event := map[string]interface{}{
+		"routing_key":  n.config.PagerDutyRoutingKey,
+		"event_action": action,
+		"dedup_key":    n.config.Source + ":" + result.Check,
+	}
+	if action == "trigger" {
+		event["payload"] = map[string]interface{}{
+			"summary":   fmt.Sprintf("Health check %s is failing: %s", result.Check, result.Error),
+			"source":    n.config.Source,
+			"severity":  "critical",
+			"component": result.Check,
+			"timestamp": result.CheckedAt.UTC().Format(time.RFC3339),
+		}
+	}
+
+	if err := n.post(ctx, n.pagerDutyClient, n.config.PagerDutyURL, event); err != nil {
+		n.logger.Error("Failed to send PagerDuty event", "action", action, "error", err)
+	}
+}
+
+func (n *Notifier) post(ctx context.Context, client *http.Client, url string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
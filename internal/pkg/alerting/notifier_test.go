@@ -0,0 +1,223 @@
+package alerting
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// webhookRecorder is a fake Slack or PagerDuty endpoint that records payloads
+type webhookRecorder struct {
+	mu       sync.Mutex
+	payloads []map[string]interface{}
+	server   *httptest.Server
+}
+
+func newWebhookRecorder(t *testing.T) *webhookRecorder {
+	t.Helper()
+
+	recorder := &webhookRecorder{}
+	recorder.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		recorder.mu.Lock()
+		recorder.payloads = append(recorder.payloads, payload)
+		recorder.mu.Unlock()
+
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(recorder.server.Close)
+
+	return recorder
+}
+
+func (r *webhookRecorder) Payloads() []map[string]interface{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]map[string]interface{}(nil), r.payloads...)
+}
+
+// fakeClock lets tests move time forward between results
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time { return c.now }
+
+func (c *fakeClock) Advance(d time.Duration) { c.now = c.now.Add(d) }
+
+func newTestNotifier(t *testing.T) (*Notifier, *webhookRecorder, *webhookRecorder, *fakeClock) {
+	t.Helper()
+
+	slack := newWebhookRecorder(t)
+	pagerDuty := newWebhookRecorder(t)
+	clock := &fakeClock{now: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+
+	notifier := NewNotifier(NotifierConfig{
+		SlackWebhookURL:       slack.server.URL,
+		PagerDutyRoutingKey:   "routing-key",
+		PagerDutyURL:          pagerDuty.server.URL,
+		AlertDebounceInterval: 5 * time.Minute,
+		Source:                "api-1",
+	}, logger.New("error", "text"))
+	notifier.now = clock.Now
+
+	return notifier, slack, pagerDuty, clock
+}
+
+func healthy(check string) CheckResult {
+	return CheckResult{Check: check, Healthy: true}
+}
+
+func unhealthy(check string) CheckResult {
+	return CheckResult{Check: check, Healthy: false, Error: "connection refused"}
+}
+
+func TestNotifier(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should alert when a check becomes unhealthy", func(t *testing.T) {
+		notifier, slack, pagerDuty, _ := newTestNotifier(t)
+
+		notifier.Observe(ctx, healthy("database"))
+		notifier.Observe(ctx, unhealthy("database"))
+
+		require.Len(t, slack.Payloads(), 1)
+		assert.Contains(t, slack.Payloads()[0]["text"], "database")
+		assert.Contains(t, slack.Payloads()[0]["text"], "connection refused")
+
+		require.Len(t, pagerDuty.Payloads(), 1)
+		event := pagerDuty.Payloads()[0]
+		assert.Equal(t, "trigger", event["event_action"])
+		assert.Equal(t, "routing-key", event["routing_key"])
+		assert.Equal(t, "api-1:database", event["dedup_key"])
+		assert.Equal(t, "critical", event["payload"].(map[string]interface{})["severity"])
+	})
+
+	t.Run("should not alert again on sustained failure", func(t *testing.T) {
+		notifier, slack, pagerDuty, clock := newTestNotifier(t)
+
+		notifier.Observe(ctx, unhealthy("redis"))
+		for i := 0; i < 5; i++ {
+			clock.Advance(3 * time.Minute)
+			notifier.Observe(ctx, unhealthy("redis"))
+		}
+
+		assert.Len(t, slack.Payloads(), 1)
+		assert.Len(t, pagerDuty.Payloads(), 1)
+	})
+
+	t.Run("should not notify for healthy checks", func(t *testing.T) {
+		notifier, slack, pagerDuty, _ := newTestNotifier(t)
+
+		notifier.Observe(ctx, healthy("database"))
+		notifier.Observe(ctx, healthy("database"))
+
+		assert.Empty(t, slack.Payloads())
+		assert.Empty(t, pagerDuty.Payloads())
+	})
+
+	t.Run("should send recovery and resolve the incident", func(t *testing.T) {
+		notifier, slack, pagerDuty, clock := newTestNotifier(t)
+
+		notifier.Observe(ctx, unhealthy("database"))
+		clock.Advance(2 * time.Minute)
+		notifier.Observe(ctx, healthy("database"))
+
+		require.Len(t, slack.Payloads(), 2)
+		assert.Contains(t, slack.Payloads()[1]["text"], "recovered after 2m0s")
+
+		require.Len(t, pagerDuty.Payloads(), 2)
+		resolve := pagerDuty.Payloads()[1]
+		assert.Equal(t, "resolve", resolve["event_action"])
+		assert.Equal(t, "api-1:database", resolve["dedup_key"])
+	})
+
+	t.Run("should debounce a check that fails again soon after an alert", func(t *testing.T) {
+		notifier, slack, _, clock := newTestNotifier(t)
+
+		notifier.Observe(ctx, unhealthy("database"))
+		clock.Advance(time.Minute)
+		notifier.Observe(ctx, healthy("database"))
+		clock.Advance(time.Minute)
+		notifier.Observe(ctx, unhealthy("database"))
+		clock.Advance(time.Minute)
+		notifier.Observe(ctx, healthy("database"))
+
+		// Alert and recovery only; the second failure and its recovery are debounced
+		assert.Len(t, slack.Payloads(), 2)
+	})
+
+	t.Run("should alert once a debounced failure outlasts the interval", func(t *testing.T) {
+		notifier, slack, _, clock := newTestNotifier(t)
+
+		notifier.Observe(ctx, unhealthy("database"))
+		clock.Advance(time.Minute)
+		notifier.Observe(ctx, healthy("database"))
+		clock.Advance(time.Minute)
+		notifier.Observe(ctx, unhealthy("database"))
+		assert.Len(t, slack.Payloads(), 2)
+
+		clock.Advance(4 * time.Minute)
+		notifier.Observe(ctx, unhealthy("database"))
+
+		assert.Len(t, slack.Payloads(), 3)
+	})
+
+	t.Run("should track checks independently", func(t *testing.T) {
+		notifier, slack, _, _ := newTestNotifier(t)
+
+		notifier.Observe(ctx, unhealthy("database"))
+		notifier.Observe(ctx, unhealthy("redis"))
+
+		assert.Len(t, slack.Payloads(), 2)
+	})
+
+	t.Run("should skip PagerDuty without a routing key", func(t *testing.T) {
+		slack := newWebhookRecorder(t)
+		pagerDuty := newWebhookRecorder(t)
+		notifier := NewNotifier(NotifierConfig{
+			SlackWebhookURL: slack.server.URL,
+			PagerDutyURL:    pagerDuty.server.URL,
+		}, logger.New("error", "text"))
+
+		notifier.Observe(ctx, unhealthy("database"))
+
+		assert.Len(t, slack.Payloads(), 1)
+		assert.Empty(t, pagerDuty.Payloads())
+	})
+
+	t.Run("should consume results from a health checker", func(t *testing.T) {
+		notifier, slack, _, _ := newTestNotifier(t)
+
+		checker := NewHealthChecker(time.Hour, time.Second)
+		checker.AddCheck("database", func(ctx context.Context) error { return assert.AnError })
+		results := checker.Subscribe()
+
+		ctx, cancel := context.WithCancel(ctx)
+		done := make(chan struct{})
+		go func() {
+			notifier.Subscribe(ctx, results)
+			close(done)
+		}()
+		go checker.Start(ctx)
+
+		assert.Eventually(t, func() bool { return len(slack.Payloads()) == 1 }, time.Second, 10*time.Millisecond)
+
+		cancel()
+		<-done
+	})
+}
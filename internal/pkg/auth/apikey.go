@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// apiKeyPrefix starts every API key so leaked keys are easy to spot
+	apiKeyPrefix = "gtk"
+	// apiKeySecretBytes is the entropy of the secret part of a key
+	apiKeySecretBytes = 32
+	// apiKeyCacheTTL bounds how long a validated key is trusted without
+	// checking the store again, sparing a bcrypt comparison per request. A
+	// key revoked on another instance stays valid here for at most that long.
+	apiKeyCacheTTL = time.Minute
+)
+
+var (
+	// ErrInvalidAPIKey is returned when a key is malformed, unknown or does
+	// not match its hash
+	ErrInvalidAPIKey = errors.New("invalid API key")
+
+	// ErrAPIKeyExpired is returned when validating a key past its expiry
+	ErrAPIKeyExpired = errors.New("API key expired")
+
+	// ErrAPIKeyRevoked is returned when validating a revoked key
+	ErrAPIKeyRevoked = errors.New("API key revoked")
+
+	// ErrAPIKeyNotFound is returned by stores for unknown keys
+	ErrAPIKeyNotFound = errors.New("API key not found")
+)
+
+// APIKey is a long-lived credential identifying a user in service to
+// service calls
+type APIKey struct {
+	ID        uuid.UUID  `json:"id"`
+	UserID    uuid.UUID  `json:"user_id"`
+	Name      string     `json:"name"`
+	Scopes    []string   `json:"scopes"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+
+	// Key is the raw key, only set on the key returned by CreateAPIKey as it
+	// cannot be recovered from its hash
+	Key string `json:"key,omitempty"`
+	// KeyHash is the bcrypt hash of the key's secret
+	KeyHash string `json:"-"`
+	// Email and Role are those of the owner, loaded by the store
+	Email string `json:"-"`
+	Role  string `json:"-"`
+}
+
+// HasScope reports whether the key was granted the scope
+func (k *APIKey) HasScope(scope string) bool {
+	return slices.Contains(k.Scopes, scope)
+}
+
+// APIKeyStore persists API keys
+type APIKeyStore interface {
+	CreateAPIKey(ctx context.Context, key *APIKey) error
+	// GetAPIKey returns the key with its owner's email and role, or
+	// ErrAPIKeyNotFound
+	GetAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error
+}
+
+type cachedAPIKey struct {
+	key      *APIKey
+	cachedAt time.Time
+}
+
+// APIKeyService issues, validates and revokes API keys. Keys have the form
+// gtk_<key ID>_<secret>; only a bcrypt hash of the secret is stored.
+type APIKeyService struct {
+	store APIKeyStore
+
+	mu    sync.Mutex
+	cache map[[sha256.Size]byte]cachedAPIKey
+	now   func() time.Time
+}
+
+// NewAPIKeyService creates an API key service over the store
+func NewAPIKeyService(store APIKeyStore) *APIKeyService {
+	return &APIKeyService{
+		store: store,
+		cache: make(map[[sha256.Size]byte]cachedAPIKey),
+		now:   time.Now,
+	}
+}
+
+// CreateAPIKey issues a key for the user. The returned key carries the raw
+// key in Key, which is shown once and never stored.
+func (s *APIKeyService) CreateAPIKey(ctx context.Context, userID uuid.UUID, name string, scopes []string, expiresAt *time.Time) (*APIKey, error) {
+	if expiresAt != nil && !expiresAt.After(s.now()) {
+		return nil, fmt.Errorf("API key expiry must be in the future")
+	}
+
+	secret := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate API key: %w", err)
+	}
+	encodedSecret := hex.EncodeToString(secret)
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(encodedSecret), bcrypt.DefaultCost)
+	if err != nil {
+		return nil, fmt.Errorf("failed to hash API key: %w", err)
+	}
+
+	if scopes == nil {
+		scopes = []string{}
+	}
+	key := &APIKey{
+		ID:        uuid.New(),
+		UserID:    userID,
+		Name:      name,
+		Scopes:    scopes,
+		ExpiresAt: expiresAt,
+		CreatedAt: s.now(),
+		KeyHash:   string(hash),
+	}
+	if err := s.store.CreateAPIKey(ctx, key); err != nil {
+		return nil, fmt.Errorf("failed to store API key: %w", err)
+	}
+
+	key.Key = fmt.Sprintf("%s_%s_%s", apiKeyPrefix, strings.ReplaceAll(key.ID.String(), "-", ""), encodedSecret)
+	return key, nil
+}
+
+// ValidateAPIKey returns the key matching raw, with its owner's email and
+// role, unless it is invalid, expired or revoked
+func (s *APIKeyService) ValidateAPIKey(ctx context.Context, raw string) (*APIKey, error) {
+	digest := sha256.Sum256([]byte(raw))
+	if key, ok := s.cached(digest); ok {
+		if err := s.checkUsable(key); err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+
+	id, secret, err := parseAPIKey(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	key, err := s.store.GetAPIKey(ctx, id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return nil, ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load API key: %w", err)
+	}
+
+	if err := bcrypt.CompareHashAndPassword([]byte(key.KeyHash), []byte(secret)); err != nil {
+		return nil, ErrInvalidAPIKey
+	}
+	if err := s.checkUsable(key); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[digest] = cachedAPIKey{key: key, cachedAt: s.now()}
+	s.mu.Unlock()
+
+	return key, nil
+}
+
+// RevokeAPIKey revokes the key, which stops validating right away on this
+// instance and within a minute on the others
+func (s *APIKeyService) RevokeAPIKey(ctx context.Context, keyID uuid.UUID) error {
+	if err := s.store.RevokeAPIKey(ctx, keyID, s.now()); err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for digest, entry := range s.cache {
+		if entry.key.ID == keyID {
+			delete(s.cache, digest)
+		}
+	}
+	return nil
+}
+
+// cached returns the key validated for digest within the cache TTL
+func (s *APIKeyService) cached(digest [sha256.Size]byte) (*APIKey, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry, ok := s.cache[digest]
+	if !ok {
+		return nil, false
+	}
+	if s.now().Sub(entry.cachedAt) > apiKeyCacheTTL {
+		delete(s.cache, digest)
+		return nil, false
+	}
+	return entry.key, true
+}
+
+func (s *APIKeyService) checkUsable(key *APIKey) error {
+	if key.RevokedAt != nil {
+		return ErrAPIKeyRevoked
+	}
+	if key.ExpiresAt != nil && !key.ExpiresAt.After(s.now()) {
+		return ErrAPIKeyExpired
+	}
+	return nil
+}
+
+// parseAPIKey splits a raw key into its ID and secret
+func parseAPIKey(raw string) (uuid.UUID, string, error) {
+	parts := strings.Split(raw, "_")
+	if len(parts) != 3 || parts[0] != apiKeyPrefix || parts[2] == "" {
+		return uuid.Nil, "", ErrInvalidAPIKey
+	}
+
+	id, err := uuid.Parse(parts[1])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidAPIKey
+	}
+	return id, parts[2], nil
+}
@@ -0,0 +1,91 @@
+package auth
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Querier runs the queries of a PostgresAPIKeyStore. *sql.DB implements it,
+// as does postgres.ManagedDB.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// PostgresAPIKeyStore keeps API keys in the api_keys table
+type PostgresAPIKeyStore struct {
+	db Querier
+}
+
+func NewPostgresAPIKeyStore(db Querier) *PostgresAPIKeyStore {
+	return &PostgresAPIKeyStore{db: db}
+}
+
+func (s *PostgresAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	query := `
+		INSERT INTO api_keys (id, user_id, name, key_hash, scopes, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)`
+
+	_, err := s.db.ExecContext(ctx, query, key.ID, key.UserID, key.Name, key.KeyHash, pq.Array(key.Scopes), key.ExpiresAt, key.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create API key: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresAPIKeyStore) GetAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error) {
+	query := `
+		SELECT k.id, k.user_id, k.name, k.key_hash, k.scopes, k.expires_at, k.revoked_at, k.created_at, u.email, u.role
+		FROM api_keys k
+		JOIN users u ON u.id = k.user_id
+		WHERE k.id = $1 AND u.is_active = true`
+
+	var key APIKey
+	var expiresAt, revokedAt sql.NullTime
+	err := s.db.QueryRowContext(ctx, query, id).Scan(
+		&key.ID, &key.UserID, &key.Name, &key.KeyHash, pq.Array(&key.Scopes),
+		&expiresAt, &revokedAt, &key.CreatedAt, &key.Email, &key.Role,
+	)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrAPIKeyNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get API key: %w", err)
+	}
+
+	if expiresAt.Valid {
+		key.ExpiresAt = &expiresAt.Time
+	}
+	if revokedAt.Valid {
+		key.RevokedAt = &revokedAt.Time
+	}
+
+	return &key, nil
+}
+
+func (s *PostgresAPIKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	query := `UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, id, revokedAt)
+	if err != nil {
+		return fmt.Errorf("failed to revoke API key: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrAPIKeyNotFound
+	}
+
+	return nil
+}
@@ -0,0 +1,141 @@
+package auth
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// memoryAPIKeyStore keeps API keys in memory, owned by users with role
+type memoryAPIKeyStore struct {
+	keys map[uuid.UUID]APIKey
+	role string
+	gets int
+}
+
+func newMemoryAPIKeyStore(role string) *memoryAPIKeyStore {
+	return &memoryAPIKeyStore{keys: make(map[uuid.UUID]APIKey), role: role}
+}
+
+func (m *memoryAPIKeyStore) CreateAPIKey(ctx context.Context, key *APIKey) error {
+	m.keys[key.ID] = *key
+	return nil
+}
+
+func (m *memoryAPIKeyStore) GetAPIKey(ctx context.Context, id uuid.UUID) (*APIKey, error) {
+	m.gets++
+	key, ok := m.keys[id]
+	if !ok {
+		return nil, ErrAPIKeyNotFound
+	}
+	key.Email = "service@example.com"
+	key.Role = m.role
+	return &key, nil
+}
+
+func (m *memoryAPIKeyStore) RevokeAPIKey(ctx context.Context, id uuid.UUID, revokedAt time.Time) error {
+	key, ok := m.keys[id]
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	key.RevokedAt = &revokedAt
+	m.keys[id] = key
+	return nil
+}
+
+func TestAPIKeyService(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should validate a created key without storing it", func(t *testing.T) {
+		store := newMemoryAPIKeyStore("admin")
+		service := NewAPIKeyService(store)
+		userID := uuid.New()
+
+		created, err := service.CreateAPIKey(ctx, userID, "billing", []string{"invoices:read"}, nil)
+		require.NoError(t, err)
+
+		assert.True(t, strings.HasPrefix(created.Key, "gtk_"))
+		stored := store.keys[created.ID]
+		assert.Empty(t, stored.Key)
+		assert.NotContains(t, created.Key, stored.KeyHash)
+
+		key, err := service.ValidateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, userID, key.UserID)
+		assert.Equal(t, "admin", key.Role)
+		assert.True(t, key.HasScope("invoices:read"))
+		assert.False(t, key.HasScope("invoices:write"))
+	})
+
+	t.Run("should reject malformed, unknown and tampered keys", func(t *testing.T) {
+		service := NewAPIKeyService(newMemoryAPIKeyStore("user"))
+		created, err := service.CreateAPIKey(ctx, uuid.New(), "billing", nil, nil)
+		require.NoError(t, err)
+
+		for _, raw := range []string{
+			"",
+			"not-a-key",
+			"gtk_" + strings.ReplaceAll(uuid.NewString(), "-", "") + "_secret",
+			created.Key[:len(created.Key)-1] + "x",
+		} {
+			_, err := service.ValidateAPIKey(ctx, raw)
+			assert.ErrorIs(t, err, ErrInvalidAPIKey, raw)
+		}
+	})
+
+	t.Run("should reject expired keys", func(t *testing.T) {
+		service := NewAPIKeyService(newMemoryAPIKeyStore("user"))
+		expiresAt := time.Now().Add(time.Hour)
+		created, err := service.CreateAPIKey(ctx, uuid.New(), "billing", nil, &expiresAt)
+		require.NoError(t, err)
+
+		_, err = service.ValidateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+
+		service.now = func() time.Time { return expiresAt.Add(time.Second) }
+		_, err = service.ValidateAPIKey(ctx, created.Key)
+		assert.ErrorIs(t, err, ErrAPIKeyExpired)
+
+		past := time.Now().Add(-time.Hour)
+		_, err = service.CreateAPIKey(ctx, uuid.New(), "billing", nil, &past)
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject revoked keys right away", func(t *testing.T) {
+		service := NewAPIKeyService(newMemoryAPIKeyStore("user"))
+		created, err := service.CreateAPIKey(ctx, uuid.New(), "billing", nil, nil)
+		require.NoError(t, err)
+		_, err = service.ValidateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+
+		require.NoError(t, service.RevokeAPIKey(ctx, created.ID))
+
+		_, err = service.ValidateAPIKey(ctx, created.Key)
+		assert.ErrorIs(t, err, ErrAPIKeyRevoked)
+	})
+
+	t.Run("should cache validated keys for a minute", func(t *testing.T) {
+		store := newMemoryAPIKeyStore("user")
+		service := NewAPIKeyService(store)
+		now := time.Now()
+		service.now = func() time.Time { return now }
+		created, err := service.CreateAPIKey(ctx, uuid.New(), "billing", nil, nil)
+		require.NoError(t, err)
+
+		for i := 0; i < 3; i++ {
+			_, err := service.ValidateAPIKey(ctx, created.Key)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 1, store.gets)
+
+		now = now.Add(apiKeyCacheTTL + time.Second)
+		_, err = service.ValidateAPIKey(ctx, created.Key)
+		require.NoError(t, err)
+		assert.Equal(t, 2, store.gets)
+	})
+}
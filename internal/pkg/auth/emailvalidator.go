@@ -0,0 +1,113 @@
+package auth
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultMXLookupTimeout bounds the DNS lookup made for each email
+const DefaultMXLookupTimeout = 3 * time.Second
+
+var (
+	ErrInvalidEmailFormat = errors.New("invalid email format")
+	ErrNoMailServer       = errors.New("email domain does not accept mail")
+	ErrDisposableEmail    = errors.New("disposable email addresses are not allowed")
+	ErrEmailLookupFailed  = errors.New("email domain lookup failed")
+)
+
+var emailPattern = regexp.MustCompile(`^[a-zA-Z0-9.!#$%&'*+/=?^_{|}~-]+@[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?(?:\.[a-zA-Z0-9](?:[a-zA-Z0-9-]{0,61}[a-zA-Z0-9])?)+$`)
+
+// MXResolver looks up the mail exchangers of a domain. *net.Resolver
+// implements it.
+type MXResolver interface {
+	LookupMX(ctx context.Context, name string) ([]*net.MX, error)
+}
+
+// EmailValidator checks that an email address is well formed, that its
+// domain accepts mail and that it is not a disposable address
+type EmailValidator struct {
+	resolver  MXResolver
+	timeout   time.Duration
+	blocklist map[string]struct{}
+}
+
+// NewEmailValidator creates an email validator. A nil resolver uses
+// net.DefaultResolver and a nil blocklist allows every domain.
+func NewEmailValidator(resolver MXResolver, blocklist map[string]struct{}) *EmailValidator {
+	if resolver == nil {
+		resolver = net.DefaultResolver
+	}
+
+	return &EmailValidator{
+		resolver:  resolver,
+		timeout:   DefaultMXLookupTimeout,
+		blocklist: blocklist,
+	}
+}
+
+// Validate returns ErrInvalidEmailFormat, ErrDisposableEmail or
+// ErrNoMailServer for addresses that cannot be used, and
+// ErrEmailLookupFailed when DNS could not answer
+func (v *EmailValidator) Validate(ctx context.Context, email string) error {
+	if len(email) > 254 || !emailPattern.MatchString(email) {
+		return ErrInvalidEmailFormat
+	}
+
+	domain := strings.ToLower(email[strings.LastIndex(email, "@")+1:])
+
+	if _, blocked := v.blocklist[domain]; blocked {
+		return ErrDisposableEmail
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, v.timeout)
+	defer cancel()
+
+	records, err := v.resolver.LookupMX(ctx, domain)
+	if err != nil {
+		var dnsErr *net.DNSError
+		if errors.As(err, &dnsErr) && dnsErr.IsNotFound {
+			return ErrNoMailServer
+		}
+		return fmt.Errorf("%w: %w", ErrEmailLookupFailed, err)
+	}
+
+	// A single "." record is a null MX: the domain explicitly accepts no mail
+	if len(records) == 0 || (len(records) == 1 && records[0].Host == ".") {
+		return ErrNoMailServer
+	}
+
+	return nil
+}
+
+// LoadDisposableDomains reads a blocklist with one domain per line. Blank
+// lines and lines starting with # are ignored.
+func LoadDisposableDomains(path string) (map[string]struct{}, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open disposable email blocklist: %w", err)
+	}
+	defer file.Close()
+
+	domains := make(map[string]struct{})
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		domains[strings.ToLower(line)] = struct{}{}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read disposable email blocklist: %w", err)
+	}
+
+	return domains, nil
+}
@@ -0,0 +1,111 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeResolver answers MX lookups from a fixed table
+type fakeResolver struct {
+	records map[string][]*net.MX
+	err     error
+	lookups []string
+}
+
+func (r *fakeResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	r.lookups = append(r.lookups, name)
+	if r.err != nil {
+		return nil, r.err
+	}
+	records, ok := r.records[name]
+	if !ok {
+		return nil, &net.DNSError{Err: "no such host", Name: name, IsNotFound: true}
+	}
+	return records, nil
+}
+
+// slowResolver blocks until the lookup context is done
+type slowResolver struct{}
+
+func (slowResolver) LookupMX(ctx context.Context, name string) ([]*net.MX, error) {
+	<-ctx.Done()
+	return nil, &net.DNSError{Err: ctx.Err().Error(), Name: name, IsTimeout: true}
+}
+
+func TestEmailValidator(t *testing.T) {
+	resolver := &fakeResolver{records: map[string][]*net.MX{
+		"example.com":    {{Host: "mail.example.com.", Pref: 10}},
+		"mailinator.com": {{Host: "mail.mailinator.com.", Pref: 10}},
+		"nomail.com":     {{Host: ".", Pref: 0}},
+	}}
+	validator := NewEmailValidator(resolver, map[string]struct{}{"mailinator.com": {}})
+	ctx := context.Background()
+
+	t.Run("should accept addresses with mail servers", func(t *testing.T) {
+		assert.NoError(t, validator.Validate(ctx, "john@Example.com"))
+	})
+
+	t.Run("should reject malformed addresses without a lookup", func(t *testing.T) {
+		lookups := len(resolver.lookups)
+
+		for _, email := range []string{"", "john", "john@", "@example.com", "john@example", "john doe@example.com"} {
+			assert.ErrorIs(t, validator.Validate(ctx, email), ErrInvalidEmailFormat, email)
+		}
+		assert.Len(t, resolver.lookups, lookups)
+	})
+
+	t.Run("should reject disposable domains", func(t *testing.T) {
+		assert.ErrorIs(t, validator.Validate(ctx, "temp@MAILINATOR.com"), ErrDisposableEmail)
+	})
+
+	t.Run("should reject domains without mail servers", func(t *testing.T) {
+		assert.ErrorIs(t, validator.Validate(ctx, "john@unknown.com"), ErrNoMailServer)
+		assert.ErrorIs(t, validator.Validate(ctx, "john@nomail.com"), ErrNoMailServer)
+	})
+
+	t.Run("should report lookup failures separately", func(t *testing.T) {
+		failing := NewEmailValidator(&fakeResolver{err: errors.New("server misbehaving")}, nil)
+
+		err := failing.Validate(ctx, "john@example.com")
+
+		assert.ErrorIs(t, err, ErrEmailLookupFailed)
+		assert.NotErrorIs(t, err, ErrNoMailServer)
+	})
+
+	t.Run("should time out slow lookups", func(t *testing.T) {
+		slow := NewEmailValidator(slowResolver{}, nil)
+		slow.timeout = 50 * time.Millisecond
+
+		start := time.Now()
+		err := slow.Validate(ctx, "john@example.com")
+
+		assert.ErrorIs(t, err, ErrEmailLookupFailed)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestLoadDisposableDomains(t *testing.T) {
+	t.Run("should load domains ignoring comments and blank lines", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "blocklist.txt")
+		require.NoError(t, os.WriteFile(path, []byte("# disposable providers\nMailinator.com\n\n  guerrillamail.com  \n"), 0o644))
+
+		domains, err := LoadDisposableDomains(path)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[string]struct{}{"mailinator.com": {}, "guerrillamail.com": {}}, domains)
+	})
+
+	t.Run("should fail for missing files", func(t *testing.T) {
+		_, err := LoadDisposableDomains(filepath.Join(t.TempDir(), "missing.txt"))
+
+		assert.Error(t, err)
+	})
+}
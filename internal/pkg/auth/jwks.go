@@ -0,0 +1,244 @@
+package auth
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet is an RSA public key tokens are verified with, identified by the
+// kid header of the tokens signed with its private key
+type KeySet struct {
+	KID       string
+	PublicKey *rsa.PublicKey
+	// RetiresAt is when a rotated out key stops being accepted, zero for
+	// the key tokens are signed with
+	RetiresAt time.Time
+}
+
+// jwk is an RSA public key in the JSON Web Key format of RFC 7517
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// NewRSAJWTService creates a JWT service signing tokens with the RSA private
+// key file using algorithm (RS256, RS384 or RS512). Its public keys are
+// published with JWKS.
+func NewRSAJWTService(algorithm, privateKeyFile, publicKeyFile string, expiration int) (*JWTService, error) {
+	method, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodRSA)
+	if !ok {
+		return nil, fmt.Errorf("unsupported RSA signing algorithm %q", algorithm)
+	}
+
+	privateKey, keySet, err := loadRSAKeys(privateKeyFile, publicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	s := NewJWTService("", expiration)
+	s.rsaMethod = method
+	s.signingKey = privateKey
+	s.keys = []KeySet{keySet}
+	return s, nil
+}
+
+// UsesRSA reports whether tokens are signed with an RSA key, whose public
+// keys JWKS publishes
+func (s *JWTService) UsesRSA() bool {
+	return s.rsaMethod != nil
+}
+
+// RotateKeys signs new tokens with the RSA key pair in the given files. The
+// previous key keeps verifying tokens for window, so tokens issued before
+// the rotation stay valid meanwhile.
+func (s *JWTService) RotateKeys(privateKeyFile, publicKeyFile string, window time.Duration) error {
+	if !s.UsesRSA() {
+		return fmt.Errorf("key rotation requires an RSA signing algorithm")
+	}
+
+	privateKey, keySet, err := loadRSAKeys(privateKeyFile, publicKeyFile)
+	if err != nil {
+		return err
+	}
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	retiresAt := s.now().Add(window)
+	keys := []KeySet{keySet}
+	for _, key := range s.keys {
+		if key.KID == keySet.KID {
+			continue
+		}
+		if key.RetiresAt.IsZero() {
+			key.RetiresAt = retiresAt
+		}
+		keys = append(keys, key)
+	}
+
+	s.signingKey = privateKey
+	s.keys = keys
+	s.jwks = nil
+	return nil
+}
+
+// AcceptRetiringKey keeps verifying tokens signed with the private key of
+// the public key file, e.g. the one used before a restart with a new key
+// pair, for window
+func (s *JWTService) AcceptRetiringKey(publicKeyFile string, window time.Duration) error {
+	if !s.UsesRSA() {
+		return fmt.Errorf("key rotation requires an RSA signing algorithm")
+	}
+
+	publicKey, err := loadRSAPublicKey(publicKeyFile)
+	if err != nil {
+		return err
+	}
+	keySet := newKeySet(publicKey)
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	if slices.ContainsFunc(s.keys, func(key KeySet) bool { return key.KID == keySet.KID }) {
+		return nil
+	}
+	keySet.RetiresAt = s.now().Add(window)
+	s.keys = append(s.keys, keySet)
+	s.jwks = nil
+	return nil
+}
+
+// KeySets returns the keys tokens are accepted from, the signing key first
+func (s *JWTService) KeySets() []KeySet {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+
+	return s.activeKeys(s.now())
+}
+
+// JWKS returns the JSON Web Key Set of the keys tokens are accepted from,
+// cached until a key retires or is rotated
+func (s *JWTService) JWKS() ([]byte, error) {
+	now := s.now()
+
+	s.keysMu.RLock()
+	cached, until := s.jwks, s.jwksUntil
+	s.keysMu.RUnlock()
+	if cached != nil && (until.IsZero() || now.Before(until)) {
+		return cached, nil
+	}
+
+	s.keysMu.Lock()
+	defer s.keysMu.Unlock()
+
+	keys := s.activeKeys(now)
+	set := struct {
+		Keys []jwk `json:"keys"`
+	}{Keys: make([]jwk, 0, len(keys))}
+
+	var expires time.Time
+	for _, key := range keys {
+		set.Keys = append(set.Keys, jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: s.rsaMethod.Alg(),
+			Kid: key.KID,
+			N:   base64.RawURLEncoding.EncodeToString(key.PublicKey.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.PublicKey.E)).Bytes()),
+		})
+		if !key.RetiresAt.IsZero() && (expires.IsZero() || key.RetiresAt.Before(expires)) {
+			expires = key.RetiresAt
+		}
+	}
+
+	body, err := json.Marshal(set)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode JWKS: %w", err)
+	}
+
+	s.jwks, s.jwksUntil = body, expires
+	return body, nil
+}
+
+// verificationKey returns the public key of the active key set named by
+// kid. Tokens without a kid are verified with the signing key.
+func (s *JWTService) verificationKey(kid string) (*rsa.PublicKey, error) {
+	s.keysMu.RLock()
+	defer s.keysMu.RUnlock()
+
+	if kid == "" {
+		return &s.signingKey.PublicKey, nil
+	}
+	for _, key := range s.activeKeys(s.now()) {
+		if key.KID == kid {
+			return key.PublicKey, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown signing key %q", kid)
+}
+
+// activeKeys drops the retired keys; the caller holds keysMu
+func (s *JWTService) activeKeys(now time.Time) []KeySet {
+	return slices.DeleteFunc(slices.Clone(s.keys), func(key KeySet) bool {
+		return !key.RetiresAt.IsZero() && !now.Before(key.RetiresAt)
+	})
+}
+
+func loadRSAKeys(privateKeyFile, publicKeyFile string) (*rsa.PrivateKey, KeySet, error) {
+	data, err := os.ReadFile(privateKeyFile)
+	if err != nil {
+		return nil, KeySet{}, fmt.Errorf("failed to read JWT private key: %w", err)
+	}
+	privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+	if err != nil {
+		return nil, KeySet{}, fmt.Errorf("failed to parse JWT private key: %w", err)
+	}
+
+	publicKey, err := loadRSAPublicKey(publicKeyFile)
+	if err != nil {
+		return nil, KeySet{}, err
+	}
+	if !privateKey.PublicKey.Equal(publicKey) {
+		return nil, KeySet{}, fmt.Errorf("JWT public key does not match the private key")
+	}
+
+	return privateKey, newKeySet(publicKey), nil
+}
+
+func loadRSAPublicKey(publicKeyFile string) (*rsa.PublicKey, error) {
+	data, err := os.ReadFile(publicKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read JWT public key: %w", err)
+	}
+	publicKey, err := jwt.ParseRSAPublicKeyFromPEM(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse JWT public key: %w", err)
+	}
+	return publicKey, nil
+}
+
+// newKeySet identifies the key by its RFC 7638 thumbprint
+func newKeySet(publicKey *rsa.PublicKey) KeySet {
+	thumbprint := fmt.Sprintf(`{"e":"%s","kty":"RSA","n":"%s"}`,
+		base64.RawURLEncoding.EncodeToString(big.NewInt(int64(publicKey.E)).Bytes()),
+		base64.RawURLEncoding.EncodeToString(publicKey.N.Bytes()))
+	sum := sha256.Sum256([]byte(thumbprint))
+
+	return KeySet{
+		KID:       base64.RawURLEncoding.EncodeToString(sum[:]),
+		PublicKey: publicKey,
+	}
+}
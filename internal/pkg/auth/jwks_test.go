@@ -0,0 +1,173 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeRSAKeys writes a new RSA key pair as PEM files and returns their paths
+func writeRSAKeys(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	publicDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	privateFile := filepath.Join(dir, "jwt.key")
+	publicFile := filepath.Join(dir, "jwt.pub")
+	require.NoError(t, os.WriteFile(privateFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600))
+	require.NoError(t, os.WriteFile(publicFile, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600))
+	return privateFile, publicFile
+}
+
+type jwkSet struct {
+	Keys []map[string]string `json:"keys"`
+}
+
+func readJWKS(t *testing.T, service *JWTService) jwkSet {
+	t.Helper()
+
+	body, err := service.JWKS()
+	require.NoError(t, err)
+	var set jwkSet
+	require.NoError(t, json.Unmarshal(body, &set))
+	return set
+}
+
+func TestRSAJWTService(t *testing.T) {
+	privateFile, publicFile := writeRSAKeys(t)
+
+	t.Run("should sign tokens with the RSA key and name it in the kid header", func(t *testing.T) {
+		service, err := NewRSAJWTService("RS384", privateFile, publicFile, 3600)
+		require.NoError(t, err)
+
+		token, _, err := service.GenerateToken(uuid.New(), "user@example.com", "user")
+		require.NoError(t, err)
+
+		parsed, _, err := jwt.NewParser().ParseUnverified(token, &Claims{})
+		require.NoError(t, err)
+		assert.Equal(t, "RS384", parsed.Method.Alg())
+		assert.Equal(t, service.KeySets()[0].KID, parsed.Header["kid"])
+
+		claims, err := service.ValidateToken(token)
+		require.NoError(t, err)
+		assert.Equal(t, "user@example.com", claims.Email)
+	})
+
+	t.Run("should publish the public key as a JWK", func(t *testing.T) {
+		service, err := NewRSAJWTService("RS256", privateFile, publicFile, 3600)
+		require.NoError(t, err)
+
+		set := readJWKS(t, service)
+
+		require.Len(t, set.Keys, 1)
+		key := set.Keys[0]
+		assert.Equal(t, "RSA", key["kty"])
+		assert.Equal(t, "sig", key["use"])
+		assert.Equal(t, "RS256", key["alg"])
+		assert.Equal(t, "AQAB", key["e"])
+		assert.Equal(t, service.KeySets()[0].KID, key["kid"])
+		assert.NotEmpty(t, key["n"])
+		assert.True(t, service.UsesRSA())
+		assert.False(t, NewJWTService("secret", 3600).UsesRSA())
+	})
+
+	t.Run("should reject HMAC tokens and unknown keys", func(t *testing.T) {
+		service, err := NewRSAJWTService("RS256", privateFile, publicFile, 3600)
+		require.NoError(t, err)
+
+		hmacToken, _, err := NewJWTService("test-secret-key-that-is-long-enough", 3600).GenerateToken(uuid.New(), "user@example.com", "user")
+		require.NoError(t, err)
+		_, err = service.ValidateToken(hmacToken)
+		assert.ErrorContains(t, err, "unexpected signing method")
+
+		otherPrivate, otherPublic := writeRSAKeys(t)
+		other, err := NewRSAJWTService("RS256", otherPrivate, otherPublic, 3600)
+		require.NoError(t, err)
+		otherToken, _, err := other.GenerateToken(uuid.New(), "user@example.com", "user")
+		require.NoError(t, err)
+		_, err = service.ValidateToken(otherToken)
+		assert.ErrorContains(t, err, "unknown signing key")
+	})
+
+	t.Run("should reject mismatched keys and other algorithms", func(t *testing.T) {
+		_, otherPublic := writeRSAKeys(t)
+
+		_, err := NewRSAJWTService("RS256", privateFile, otherPublic, 3600)
+		assert.ErrorContains(t, err, "does not match")
+
+		_, err = NewRSAJWTService("HS256", privateFile, publicFile, 3600)
+		assert.ErrorContains(t, err, "unsupported RSA signing algorithm")
+	})
+}
+
+func TestRotateKeys(t *testing.T) {
+	oldPrivate, oldPublic := writeRSAKeys(t)
+	newPrivate, newPublic := writeRSAKeys(t)
+
+	service, err := NewRSAJWTService("RS256", oldPrivate, oldPublic, 3600)
+	require.NoError(t, err)
+	oldToken, _, err := service.GenerateToken(uuid.New(), "user@example.com", "user")
+	require.NoError(t, err)
+	require.Len(t, readJWKS(t, service).Keys, 1)
+
+	now := time.Now()
+	service.now = func() time.Time { return now }
+	require.NoError(t, service.RotateKeys(newPrivate, newPublic, time.Hour))
+
+	t.Run("should accept tokens of the previous key during the window", func(t *testing.T) {
+		newToken, _, err := service.GenerateToken(uuid.New(), "user@example.com", "user")
+		require.NoError(t, err)
+
+		_, err = service.ValidateToken(oldToken)
+		assert.NoError(t, err)
+		_, err = service.ValidateToken(newToken)
+		assert.NoError(t, err)
+
+		keys := service.KeySets()
+		require.Len(t, keys, 2)
+		assert.True(t, keys[0].RetiresAt.IsZero())
+		assert.Equal(t, now.Add(time.Hour), keys[1].RetiresAt)
+		assert.Len(t, readJWKS(t, service).Keys, 2)
+	})
+
+	t.Run("should drop the previous key after the window", func(t *testing.T) {
+		now = now.Add(time.Hour)
+
+		_, err := service.ValidateToken(oldToken)
+		assert.ErrorContains(t, err, "unknown signing key")
+		assert.Len(t, service.KeySets(), 1)
+		assert.Len(t, readJWKS(t, service).Keys, 1)
+	})
+
+	t.Run("should accept a retiring key loaded at startup", func(t *testing.T) {
+		restarted, err := NewRSAJWTService("RS256", newPrivate, newPublic, 3600)
+		require.NoError(t, err)
+		require.NoError(t, restarted.AcceptRetiringKey(oldPublic, time.Hour))
+
+		_, err = restarted.ValidateToken(oldToken)
+		assert.NoError(t, err)
+		assert.Len(t, readJWKS(t, restarted).Keys, 2)
+	})
+
+	t.Run("should require an RSA service", func(t *testing.T) {
+		hmac := NewJWTService("secret", 3600)
+
+		assert.Error(t, hmac.RotateKeys(newPrivate, newPublic, time.Hour))
+		assert.Error(t, hmac.AcceptRetiringKey(oldPublic, time.Hour))
+	})
+}
@@ -1,48 +1,105 @@
 package auth
 
 import (
+	"context"
+	"crypto/rsa"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
 )
 
 type JWTService struct {
-	secret     []byte
-	expiration time.Duration
+	secret            []byte
+	hmacMethod        *jwt.SigningMethodHMAC
+	expiration        time.Duration
+	refreshExpiration time.Duration
+	revocations       *redis.Client
+	now               func() time.Time
+
+	// RSA signing, see NewRSAJWTService. keys holds the signing key first,
+	// then the rotated out keys still accepted.
+	rsaMethod  *jwt.SigningMethodRSA
+	signingKey *rsa.PrivateKey
+	keys       []KeySet
+	jwks       []byte
+	jwksUntil  time.Time
+	keysMu     sync.RWMutex
 }
 
 type Claims struct {
 	UserID uuid.UUID `json:"user_id"`
 	Email  string    `json:"email"`
 	Role   string    `json:"role"`
+	// Priority ranks the caller's requests for load shedding: high, normal
+	// or low. Empty means normal.
+	Priority string `json:"priority,omitempty"`
+	// TokenType is "refresh" for refresh tokens and empty for access tokens
+	TokenType string `json:"token_type,omitempty"`
 	jwt.RegisteredClaims
 }
 
 func NewJWTService(secret string, expiration int) *JWTService {
 	return &JWTService{
-		secret:     []byte(secret),
-		expiration: time.Duration(expiration) * time.Second,
+		secret:            []byte(secret),
+		hmacMethod:        jwt.SigningMethodHS256,
+		expiration:        time.Duration(expiration) * time.Second,
+		refreshExpiration: defaultRefreshExpiration,
+		now:               time.Now,
 	}
 }
 
+// NewHMACJWTService creates a JWT service signing tokens with the secret
+// using algorithm (HS256, HS384 or HS512)
+func NewHMACJWTService(algorithm, secret string, expiration int) (*JWTService, error) {
+	method, ok := jwt.GetSigningMethod(algorithm).(*jwt.SigningMethodHMAC)
+	if !ok {
+		return nil, fmt.Errorf("unsupported HMAC signing algorithm %q", algorithm)
+	}
+
+	s := NewJWTService(secret, expiration)
+	s.hmacMethod = method
+	return s, nil
+}
+
+// SetRefreshExpiration sets how long refresh tokens stay valid
+func (s *JWTService) SetRefreshExpiration(expiration time.Duration) {
+	s.refreshExpiration = expiration
+}
+
+// SetRevocationStore enables token revocation: revoked token IDs are kept
+// in Redis until the tokens expire. Without a store, revoking and refresh
+// token rotation fail with ErrRevocationUnavailable.
+func (s *JWTService) SetRevocationStore(client *redis.Client) {
+	s.revocations = client
+}
+
 func (s *JWTService) GenerateToken(userID uuid.UUID, email, role string) (string, time.Time, error) {
+	return s.GenerateTokenWithPriority(userID, email, role, "")
+}
+
+// GenerateTokenWithPriority issues a token carrying a load shedding priority,
+// e.g. for service accounts whose calls must not be dropped under load
+func (s *JWTService) GenerateTokenWithPriority(userID uuid.UUID, email, role, priority string) (string, time.Time, error) {
 	expiresAt := time.Now().Add(s.expiration)
 
 	claims := Claims{
-		UserID: userID,
-		Email:  email,
-		Role:   role,
+		UserID:   userID,
+		Email:    email,
+		Role:     role,
+		Priority: priority,
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			NotBefore: jwt.NewNumericDate(time.Now()),
 		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	tokenString, err := token.SignedString(s.secret)
+	tokenString, err := s.sign(claims)
 	if err != nil {
 		return "", time.Time{}, fmt.Errorf("failed to sign token: %w", err)
 	}
@@ -50,9 +107,55 @@ func (s *JWTService) GenerateToken(userID uuid.UUID, email, role string) (string
 	return tokenString, expiresAt, nil
 }
 
+// ValidateToken accepts access tokens that are signed, unexpired and not
+// revoked
 func (s *JWTService) ValidateToken(tokenString string) (*Claims, error) {
+	claims, err := s.parse(tokenString)
+	if err != nil {
+		return nil, err
+	}
+
+	if claims.TokenType == refreshTokenType {
+		return nil, fmt.Errorf("refresh tokens cannot be used for access")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), revocationTimeout)
+	defer cancel()
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// sign signs claims with the RSA signing key, naming it in the kid header,
+// or with the HMAC secret
+func (s *JWTService) sign(claims Claims) (string, error) {
+	if s.rsaMethod == nil {
+		return jwt.NewWithClaims(s.hmacMethod, claims).SignedString(s.secret)
+	}
+
+	s.keysMu.RLock()
+	key, kid := s.signingKey, s.keys[0].KID
+	s.keysMu.RUnlock()
+
+	token := jwt.NewWithClaims(s.rsaMethod, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(key)
+}
+
+// parse verifies the signature and registered claims of a token
+func (s *JWTService) parse(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		if s.rsaMethod != nil {
+			if token.Method != s.rsaMethod {
+				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+			}
+			kid, _ := token.Header["kid"].(string)
+			return s.verificationKey(kid)
+		}
+
+		if token.Method != s.hmacMethod {
 			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
 		}
 		return s.secret, nil
@@ -85,5 +188,5 @@ func (s *JWTService) RefreshToken(tokenString string) (string, time.Time, error)
 		return "", time.Time{}, fmt.Errorf("token is not eligible for refresh")
 	}
 
-	return s.GenerateToken(claims.UserID, claims.Email, claims.Role)
+	return s.GenerateTokenWithPriority(claims.UserID, claims.Email, claims.Role, claims.Priority)
 }
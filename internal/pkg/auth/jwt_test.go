@@ -44,6 +44,45 @@ func TestNewJWTService(t *testing.T) {
 	})
 }
 
+func TestNewHMACJWTService(t *testing.T) {
+	userID := uuid.New()
+
+	t.Run("should sign with the selected algorithm", func(t *testing.T) {
+		for _, algorithm := range []string{"HS256", "HS384", "HS512"} {
+			service, err := NewHMACJWTService(algorithm, "test-secret-key", 3600)
+			require.NoError(t, err)
+
+			tokenString, _, err := service.GenerateToken(userID, "test@example.com", "user")
+			require.NoError(t, err)
+
+			token, _, err := jwt.NewParser().ParseUnverified(tokenString, &Claims{})
+			require.NoError(t, err)
+			assert.Equal(t, algorithm, token.Header["alg"])
+
+			claims, err := service.ValidateToken(tokenString)
+			require.NoError(t, err)
+			assert.Equal(t, userID, claims.UserID)
+		}
+	})
+
+	t.Run("should reject tokens signed with another HMAC algorithm", func(t *testing.T) {
+		hs256 := NewJWTService("test-secret-key", 3600)
+		hs512, err := NewHMACJWTService("HS512", "test-secret-key", 3600)
+		require.NoError(t, err)
+
+		tokenString, _, err := hs256.GenerateToken(userID, "test@example.com", "user")
+		require.NoError(t, err)
+
+		_, err = hs512.ValidateToken(tokenString)
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject algorithms other than HMAC", func(t *testing.T) {
+		_, err := NewHMACJWTService("RS256", "test-secret-key", 3600)
+		assert.Error(t, err)
+	})
+}
+
 func TestJWTService_GenerateToken(t *testing.T) {
 	service := NewJWTService("test-secret-key", 3600)
 	userID := uuid.New()
@@ -0,0 +1,82 @@
+package oauth
+
+import (
+	"context"
+	"strconv"
+
+	"golang.org/x/oauth2/endpoints"
+)
+
+const githubAPIURL = "https://api.github.com"
+
+// GitHubProvider logs users in with their GitHub account
+type GitHubProvider struct {
+	provider
+	apiURL string
+}
+
+// NewGitHubProvider creates a GitHub provider asking for the user's profile
+// and email addresses
+func NewGitHubProvider(cfg Config, opts ...Option) *GitHubProvider {
+	return &GitHubProvider{
+		provider: newProvider("github", cfg, endpoints.GitHub, []string{"read:user", "user:email"}, opts),
+		apiURL:   githubAPIURL,
+	}
+}
+
+// ExchangeCode reads the account of the user behind code and its primary
+// email. The profile email is public and unverified, so the verified one
+// is read from the emails of the account; without any ErrEmailNotVerified
+// is returned.
+func (g *GitHubProvider) ExchangeCode(ctx context.Context, code string) (*OAuthUser, error) {
+	client, err := g.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var account struct {
+		ID        int64  `json:"id"`
+		Login     string `json:"login"`
+		Name      string `json:"name"`
+		AvatarURL string `json:"avatar_url"`
+	}
+	if err := g.getJSON(ctx, client, g.apiURL+"/user", &account); err != nil {
+		return nil, err
+	}
+
+	var emails []struct {
+		Email    string `json:"email"`
+		Primary  bool   `json:"primary"`
+		Verified bool   `json:"verified"`
+	}
+	if err := g.getJSON(ctx, client, g.apiURL+"/user/emails", &emails); err != nil {
+		return nil, err
+	}
+
+	email := ""
+	for _, candidate := range emails {
+		if !candidate.Verified {
+			continue
+		}
+		if candidate.Primary || email == "" {
+			email = candidate.Email
+		}
+	}
+	if email == "" {
+		return nil, ErrEmailNotVerified
+	}
+
+	firstName, lastName := splitName(account.Name)
+	if firstName == "" {
+		firstName = account.Login
+	}
+
+	return &OAuthUser{
+		Provider:  g.name,
+		Subject:   strconv.FormatInt(account.ID, 10),
+		Email:     email,
+		FirstName: firstName,
+		LastName:  lastName,
+		AvatarURL: account.AvatarURL,
+	}, nil
+}
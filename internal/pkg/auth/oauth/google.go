@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"context"
+
+	"golang.org/x/oauth2/endpoints"
+)
+
+const googleUserInfoURL = "https://openidconnect.googleapis.com/v1/userinfo"
+
+// GoogleProvider logs users in with their Google account
+type GoogleProvider struct {
+	provider
+	userInfoURL string
+}
+
+// NewGoogleProvider creates a Google provider asking for the user's email
+// and profile
+func NewGoogleProvider(cfg Config, opts ...Option) *GoogleProvider {
+	return &GoogleProvider{
+		provider:    newProvider("google", cfg, endpoints.Google, []string{"openid", "email", "profile"}, opts),
+		userInfoURL: googleUserInfoURL,
+	}
+}
+
+// ExchangeCode reads the account of the user behind code from the OpenID
+// Connect userinfo endpoint. Accounts whose email Google has not verified
+// are rejected with ErrEmailNotVerified.
+func (g *GoogleProvider) ExchangeCode(ctx context.Context, code string) (*OAuthUser, error) {
+	client, err := g.exchange(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	var info struct {
+		Sub           string `json:"sub"`
+		Email         string `json:"email"`
+		EmailVerified bool   `json:"email_verified"`
+		GivenName     string `json:"given_name"`
+		FamilyName    string `json:"family_name"`
+		Picture       string `json:"picture"`
+	}
+	if err := g.getJSON(ctx, client, g.userInfoURL, &info); err != nil {
+		return nil, err
+	}
+	if info.Email == "" || !info.EmailVerified {
+		return nil, ErrEmailNotVerified
+	}
+
+	return &OAuthUser{
+		Provider:  g.name,
+		Subject:   info.Sub,
+		Email:     info.Email,
+		FirstName: info.GivenName,
+		LastName:  info.FamilyName,
+		AvatarURL: info.Picture,
+	}, nil
+}
@@ -0,0 +1,134 @@
+// Package oauth logs users in with their Google or GitHub account through
+// the OAuth2 authorization code flow
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"golang.org/x/oauth2"
+)
+
+// ErrEmailNotVerified is returned when the provider account has no verified
+// email to find or create the user with
+var ErrEmailNotVerified = errors.New("oauth account has no verified email")
+
+// OAuthProvider sends users to a provider to log in and exchanges the code
+// it redirects back with for the user's account details
+type OAuthProvider interface {
+	// GetAuthURL returns the provider's consent page URL. The provider
+	// redirects back with state, which must be checked on callback.
+	GetAuthURL(state string) string
+	// ExchangeCode exchanges the authorization code for a token and reads
+	// the user's account with it
+	ExchangeCode(ctx context.Context, code string) (*OAuthUser, error)
+}
+
+// OAuthUser is the account of a user at a provider
+type OAuthUser struct {
+	Provider string
+	// Subject is the provider's stable ID of the account
+	Subject   string
+	Email     string
+	FirstName string
+	LastName  string
+	AvatarURL string
+}
+
+// Config holds the OAuth application registered with a provider
+type Config struct {
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the callback route, e.g.
+	// https://example.com/api/v1/auth/google/callback
+	RedirectURL string
+}
+
+// Option configures a provider
+type Option func(*provider)
+
+// WithHTTPClient sends the token and account requests through client
+func WithHTTPClient(client *http.Client) Option {
+	return func(p *provider) {
+		p.client = client
+	}
+}
+
+// provider implements the parts of the code flow shared by every provider
+type provider struct {
+	name   string
+	oauth  *oauth2.Config
+	client *http.Client
+}
+
+func newProvider(name string, cfg Config, endpoint oauth2.Endpoint, scopes []string, opts []Option) provider {
+	p := provider{
+		name: name,
+		oauth: &oauth2.Config{
+			ClientID:     cfg.ClientID,
+			ClientSecret: cfg.ClientSecret,
+			RedirectURL:  cfg.RedirectURL,
+			Endpoint:     endpoint,
+			Scopes:       scopes,
+		},
+	}
+	for _, opt := range opts {
+		opt(&p)
+	}
+	return p
+}
+
+// GetAuthURL returns the provider's consent page URL
+func (p *provider) GetAuthURL(state string) string {
+	return p.oauth.AuthCodeURL(state)
+}
+
+// exchange returns a client authenticated with the token the code is
+// exchanged for
+func (p *provider) exchange(ctx context.Context, code string) (*http.Client, error) {
+	if p.client != nil {
+		ctx = context.WithValue(ctx, oauth2.HTTPClient, p.client)
+	}
+
+	token, err := p.oauth.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("failed to exchange %s authorization code: %w", p.name, err)
+	}
+	return p.oauth.Client(ctx, token), nil
+}
+
+// getJSON reads the JSON response of an authenticated GET into dest
+func (p *provider) getJSON(ctx context.Context, client *http.Client, url string, dest interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to read %s account: %w", p.name, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("failed to read %s account: status %d: %s", p.name, resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(dest); err != nil {
+		return fmt.Errorf("failed to decode %s account: %w", p.name, err)
+	}
+	return nil
+}
+
+// splitName splits a display name into a first name and the rest
+func splitName(name string) (string, string) {
+	first, last, _ := strings.Cut(strings.TrimSpace(name), " ")
+	return first, strings.TrimSpace(last)
+}
@@ -0,0 +1,170 @@
+package oauth
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+)
+
+// newProviderServer serves a token endpoint accepting the code "valid" and
+// the given account routes, which require the token it issues
+func newProviderServer(t *testing.T, routes map[string]interface{}) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if r.FormValue("code") != "valid" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant"})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]interface{}{"access_token": "token", "token_type": "Bearer", "expires_in": 3600})
+	})
+	for route, body := range routes {
+		body := body
+		mux.HandleFunc(route, func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer token" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			json.NewEncoder(w).Encode(body)
+		})
+	}
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func testEndpoint(server *httptest.Server) oauth2.Endpoint {
+	return oauth2.Endpoint{AuthURL: server.URL + "/authorize", TokenURL: server.URL + "/token"}
+}
+
+var testConfig = Config{ClientID: "client", ClientSecret: "secret", RedirectURL: "http://localhost/api/v1/auth/callback"}
+
+func TestGoogleProvider(t *testing.T) {
+	ctx := context.Background()
+
+	newGoogle := func(t *testing.T, userInfo map[string]interface{}) *GoogleProvider {
+		server := newProviderServer(t, map[string]interface{}{"/userinfo": userInfo})
+		google := NewGoogleProvider(testConfig, WithHTTPClient(server.Client()))
+		google.oauth.Endpoint = testEndpoint(server)
+		google.userInfoURL = server.URL + "/userinfo"
+		return google
+	}
+
+	t.Run("should build the consent URL with state", func(t *testing.T) {
+		authURL, err := url.Parse(NewGoogleProvider(testConfig).GetAuthURL("state-123"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "accounts.google.com", authURL.Host)
+		query := authURL.Query()
+		assert.Equal(t, "client", query.Get("client_id"))
+		assert.Equal(t, "state-123", query.Get("state"))
+		assert.Equal(t, testConfig.RedirectURL, query.Get("redirect_uri"))
+		assert.Equal(t, "openid email profile", query.Get("scope"))
+	})
+
+	t.Run("should read the account behind the code", func(t *testing.T) {
+		google := newGoogle(t, map[string]interface{}{
+			"sub":            "1234",
+			"email":          "jane@example.com",
+			"email_verified": true,
+			"given_name":     "Jane",
+			"family_name":    "Doe",
+		})
+
+		user, err := google.ExchangeCode(ctx, "valid")
+		require.NoError(t, err)
+
+		assert.Equal(t, &OAuthUser{Provider: "google", Subject: "1234", Email: "jane@example.com", FirstName: "Jane", LastName: "Doe"}, user)
+	})
+
+	t.Run("should reject unverified emails", func(t *testing.T) {
+		google := newGoogle(t, map[string]interface{}{"sub": "1234", "email": "jane@example.com", "email_verified": false})
+
+		_, err := google.ExchangeCode(ctx, "valid")
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+	})
+
+	t.Run("should fail on an invalid code", func(t *testing.T) {
+		google := newGoogle(t, map[string]interface{}{})
+
+		_, err := google.ExchangeCode(ctx, "invalid")
+		assert.ErrorContains(t, err, "failed to exchange google authorization code")
+	})
+}
+
+func TestGitHubProvider(t *testing.T) {
+	ctx := context.Background()
+
+	newGitHub := func(t *testing.T, emails []map[string]interface{}) *GitHubProvider {
+		server := newProviderServer(t, map[string]interface{}{
+			"/user":        map[string]interface{}{"id": 42, "login": "octocat", "name": "Mona Lisa Octocat"},
+			"/user/emails": emails,
+		})
+		github := NewGitHubProvider(testConfig, WithHTTPClient(server.Client()))
+		github.oauth.Endpoint = testEndpoint(server)
+		github.apiURL = server.URL
+		return github
+	}
+
+	t.Run("should read the account with its primary verified email", func(t *testing.T) {
+		github := newGitHub(t, []map[string]interface{}{
+			{"email": "old@example.com", "primary": false, "verified": true},
+			{"email": "mona@example.com", "primary": true, "verified": true},
+		})
+
+		user, err := github.ExchangeCode(ctx, "valid")
+		require.NoError(t, err)
+
+		assert.Equal(t, &OAuthUser{Provider: "github", Subject: "42", Email: "mona@example.com", FirstName: "Mona", LastName: "Lisa Octocat"}, user)
+	})
+
+	t.Run("should fall back to another verified email", func(t *testing.T) {
+		github := newGitHub(t, []map[string]interface{}{
+			{"email": "mona@example.com", "primary": true, "verified": false},
+			{"email": "work@example.com", "primary": false, "verified": true},
+		})
+
+		user, err := github.ExchangeCode(ctx, "valid")
+		require.NoError(t, err)
+		assert.Equal(t, "work@example.com", user.Email)
+	})
+
+	t.Run("should reject accounts without a verified email", func(t *testing.T) {
+		github := newGitHub(t, []map[string]interface{}{{"email": "mona@example.com", "primary": true, "verified": false}})
+
+		_, err := github.ExchangeCode(ctx, "valid")
+		assert.ErrorIs(t, err, ErrEmailNotVerified)
+	})
+}
+
+func TestSplitName(t *testing.T) {
+	tests := []struct {
+		name  string
+		first string
+		last  string
+	}{
+		{"Jane Doe", "Jane", "Doe"},
+		{"Mona Lisa Octocat", "Mona", "Lisa Octocat"},
+		{"octocat", "octocat", ""},
+		{"", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			first, last := splitName(tt.name)
+			assert.Equal(t, tt.first, first)
+			assert.Equal(t, tt.last, last)
+		})
+	}
+}
@@ -0,0 +1,209 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+const (
+	refreshTokenType = "refresh"
+
+	// revokedTokenPrefix prefixes the Redis keys of revoked token IDs
+	revokedTokenPrefix = "revoked_tokens:"
+
+	defaultRefreshExpiration = 7 * 24 * time.Hour
+
+	// revocationTimeout bounds the blacklist lookup of ValidateToken, which
+	// has no caller context
+	revocationTimeout = 2 * time.Second
+)
+
+var (
+	// ErrTokenRevoked is returned for tokens that were revoked, including
+	// refresh tokens that were already rotated
+	ErrTokenRevoked = errors.New("token has been revoked")
+
+	// ErrInvalidRefreshToken is returned when a refresh token is malformed,
+	// expired or is not a refresh token
+	ErrInvalidRefreshToken = errors.New("invalid refresh token")
+
+	// ErrRevocationUnavailable is returned when a token has to be revoked but
+	// no revocation store is configured. Refresh tokens cannot be rotated
+	// then, since the old token would stay usable.
+	ErrRevocationUnavailable = errors.New("token revocation store is not configured")
+)
+
+// RefreshSubject returns the current email and role of the user a refresh
+// token was issued to. It fails for users that may no longer log in, which
+// stops their refresh tokens from being rotated.
+type RefreshSubject func(ctx context.Context, userID uuid.UUID) (email, role string, err error)
+
+// IssueRefreshToken issues a refresh token for the user. Access tokens
+// rotated from it carry only the user ID; use GenerateRefreshToken to carry
+// the email and role over as well.
+func (s *JWTService) IssueRefreshToken(userID uuid.UUID) (string, error) {
+	return s.GenerateRefreshToken(userID, "", "")
+}
+
+// GenerateRefreshToken issues a refresh token whose rotations produce access
+// tokens for the given email and role
+func (s *JWTService) GenerateRefreshToken(userID uuid.UUID, email, role string) (string, error) {
+	return s.issueRefreshToken(Claims{UserID: userID, Email: email, Role: role})
+}
+
+// RotateRefreshToken exchanges a refresh token for a new access token and a
+// new refresh token. The old refresh token is revoked, so each one can be
+// used once: presenting it again fails with ErrTokenRevoked. The new tokens
+// carry the email and role returned by subject rather than the old claims,
+// so role changes take effect on the next rotation.
+func (s *JWTService) RotateRefreshToken(ctx context.Context, oldRefresh string, subject RefreshSubject) (accessToken, newRefresh string, err error) {
+	claims, err := s.validateRefreshToken(ctx, oldRefresh)
+	if err != nil {
+		return "", "", err
+	}
+
+	email, role, err := subject(ctx, claims.UserID)
+	if err != nil {
+		return "", "", err
+	}
+
+	// Claiming the revocation atomically stops two concurrent rotations of
+	// the same token from both succeeding
+	revoked, err := s.revoke(ctx, claims)
+	if err != nil {
+		return "", "", err
+	}
+	if !revoked {
+		return "", "", ErrTokenRevoked
+	}
+
+	accessToken, _, err = s.GenerateTokenWithPriority(claims.UserID, email, role, claims.Priority)
+	if err != nil {
+		return "", "", err
+	}
+
+	newRefresh, err = s.issueRefreshToken(Claims{
+		UserID:   claims.UserID,
+		Email:    email,
+		Role:     role,
+		Priority: claims.Priority,
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, newRefresh, nil
+}
+
+// RevokeRefreshToken revokes a refresh token until it expires. Tokens that
+// are already revoked or expired need no revoking.
+func (s *JWTService) RevokeRefreshToken(ctx context.Context, token string) error {
+	claims, err := s.validateRefreshToken(ctx, token)
+	if errors.Is(err, ErrTokenRevoked) || errors.Is(err, jwt.ErrTokenExpired) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = s.revoke(ctx, claims)
+	return err
+}
+
+// RevokeToken revokes an access token until it expires
+func (s *JWTService) RevokeToken(ctx context.Context, token string) error {
+	claims, err := s.parse(token)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.revoke(ctx, claims)
+	return err
+}
+
+func (s *JWTService) issueRefreshToken(claims Claims) (string, error) {
+	now := time.Now()
+	claims.TokenType = refreshTokenType
+	claims.RegisteredClaims = jwt.RegisteredClaims{
+		ID:        uuid.NewString(),
+		ExpiresAt: jwt.NewNumericDate(now.Add(s.refreshExpiration)),
+		IssuedAt:  jwt.NewNumericDate(now),
+		NotBefore: jwt.NewNumericDate(now),
+	}
+
+	token, err := s.sign(claims)
+	if err != nil {
+		return "", fmt.Errorf("failed to sign refresh token: %w", err)
+	}
+
+	return token, nil
+}
+
+func (s *JWTService) validateRefreshToken(ctx context.Context, token string) (*Claims, error) {
+	claims, err := s.parse(token)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidRefreshToken, err)
+	}
+
+	if claims.TokenType != refreshTokenType || claims.ID == "" {
+		return nil, ErrInvalidRefreshToken
+	}
+
+	if err := s.checkRevoked(ctx, claims); err != nil {
+		return nil, err
+	}
+
+	return claims, nil
+}
+
+// revoke blacklists the token ID for the rest of the token's validity. It
+// reports false if the token was already revoked, and fails closed with
+// ErrRevocationUnavailable when there is no store to revoke it in.
+func (s *JWTService) revoke(ctx context.Context, claims *Claims) (bool, error) {
+	if s.revocations == nil {
+		return false, ErrRevocationUnavailable
+	}
+	if claims.ID == "" {
+		return true, nil
+	}
+
+	// Tokens without an expiry are kept blacklisted as long as the longest
+	// lived token the service issues
+	ttl := s.refreshExpiration
+	if claims.ExpiresAt != nil {
+		ttl = time.Until(claims.ExpiresAt.Time)
+	}
+	if ttl <= 0 {
+		return true, nil
+	}
+
+	revoked, err := s.revocations.SetNX(ctx, revokedTokenPrefix+claims.ID, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to revoke token: %w", err)
+	}
+
+	return revoked, nil
+}
+
+// checkRevoked fails closed: a token is rejected when the blacklist cannot
+// be read
+func (s *JWTService) checkRevoked(ctx context.Context, claims *Claims) error {
+	if s.revocations == nil || claims.ID == "" {
+		return nil
+	}
+
+	exists, err := s.revocations.Exists(ctx, revokedTokenPrefix+claims.ID).Result()
+	if err != nil {
+		return fmt.Errorf("failed to check token revocation: %w", err)
+	}
+	if exists > 0 {
+		return ErrTokenRevoked
+	}
+
+	return nil
+}
@@ -0,0 +1,163 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+// currentSubject returns a RefreshSubject for a user with the given email
+// and role
+func currentSubject(email, role string) RefreshSubject {
+	return func(ctx context.Context, userID uuid.UUID) (string, string, error) {
+		return email, role, nil
+	}
+}
+
+func TestJWTService_RefreshTokens(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("should issue refresh tokens that cannot be used for access", func(t *testing.T) {
+		service := NewJWTService("test-secret-key", 3600)
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+
+		_, err = service.ValidateToken(refresh)
+		assert.Error(t, err)
+	})
+
+	t.Run("should not rotate refresh tokens without a revocation store", func(t *testing.T) {
+		service := NewJWTService("test-secret-key", 3600)
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+
+		_, _, err = service.RotateRefreshToken(ctx, refresh, currentSubject("test@example.com", "user"))
+		assert.ErrorIs(t, err, ErrRevocationUnavailable)
+		assert.ErrorIs(t, service.RevokeRefreshToken(ctx, refresh), ErrRevocationUnavailable)
+	})
+
+	t.Run("should reject access tokens as refresh tokens", func(t *testing.T) {
+		service := NewJWTService("test-secret-key", 3600)
+
+		access, _, err := service.GenerateToken(userID, "test@example.com", "user")
+		require.NoError(t, err)
+
+		_, _, err = service.RotateRefreshToken(ctx, access, currentSubject("test@example.com", "user"))
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+	})
+
+	t.Run("should reject expired refresh tokens", func(t *testing.T) {
+		subject := currentSubject("test@example.com", "user")
+		service := NewJWTService("test-secret-key", 3600)
+		service.SetRefreshExpiration(-time.Minute)
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+
+		_, _, err = service.RotateRefreshToken(ctx, refresh, subject)
+		assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+		assert.NoError(t, service.RevokeRefreshToken(ctx, refresh))
+	})
+}
+
+func TestJWTService_Revocation(t *testing.T) {
+	ctx := context.Background()
+	client := containertest.NewRedis(t)
+	userID := uuid.New()
+
+	newService := func(t *testing.T) *JWTService {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		service := NewJWTService("test-secret-key", 3600)
+		service.SetRevocationStore(client)
+		return service
+	}
+
+	t.Run("should rotate into an access token with the user's current role", func(t *testing.T) {
+		service := newService(t)
+
+		refresh, err := service.GenerateRefreshToken(userID, "test@example.com", "admin")
+		require.NoError(t, err)
+
+		access, newRefresh, err := service.RotateRefreshToken(ctx, refresh, currentSubject("test@example.com", "user"))
+		require.NoError(t, err)
+		assert.NotEqual(t, refresh, newRefresh)
+
+		claims, err := service.ValidateToken(access)
+		require.NoError(t, err)
+		assert.Equal(t, userID, claims.UserID)
+		assert.Equal(t, "test@example.com", claims.Email)
+		assert.Equal(t, "user", claims.Role)
+	})
+
+	t.Run("should not rotate refresh tokens of users that cannot log in", func(t *testing.T) {
+		service := newService(t)
+		disabled := errors.New("user account is disabled")
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+
+		_, _, err = service.RotateRefreshToken(ctx, refresh, func(ctx context.Context, id uuid.UUID) (string, string, error) {
+			return "", "", disabled
+		})
+		assert.ErrorIs(t, err, disabled)
+	})
+
+	t.Run("should allow each refresh token to be rotated once", func(t *testing.T) {
+		service := newService(t)
+		subject := currentSubject("test@example.com", "user")
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+
+		_, newRefresh, err := service.RotateRefreshToken(ctx, refresh, subject)
+		require.NoError(t, err)
+
+		_, _, err = service.RotateRefreshToken(ctx, refresh, subject)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		_, _, err = service.RotateRefreshToken(ctx, newRefresh, subject)
+		assert.NoError(t, err)
+	})
+
+	t.Run("should blacklist revoked tokens until they expire", func(t *testing.T) {
+		service := newService(t)
+		subject := currentSubject("test@example.com", "user")
+
+		refresh, err := service.IssueRefreshToken(userID)
+		require.NoError(t, err)
+		require.NoError(t, service.RevokeRefreshToken(ctx, refresh))
+
+		_, _, err = service.RotateRefreshToken(ctx, refresh, subject)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+
+		claims, err := service.parse(refresh)
+		require.NoError(t, err)
+		ttl, err := client.TTL(ctx, "revoked_tokens:"+claims.ID).Result()
+		require.NoError(t, err)
+		assert.InDelta(t, defaultRefreshExpiration.Seconds(), ttl.Seconds(), 5)
+	})
+
+	t.Run("should reject revoked access tokens", func(t *testing.T) {
+		service := newService(t)
+
+		access, _, err := service.GenerateToken(userID, "test@example.com", "user")
+		require.NoError(t, err)
+		_, err = service.ValidateToken(access)
+		require.NoError(t, err)
+
+		require.NoError(t, service.RevokeToken(ctx, access))
+
+		_, err = service.ValidateToken(access)
+		assert.ErrorIs(t, err, ErrTokenRevoked)
+	})
+}
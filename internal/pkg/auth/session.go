@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
+)
+
+const defaultSessionMaxAge = 24 * time.Hour
+
+// Session is a server-side session created by SessionService
+type Session = session.Session
+
+// SessionService manages server-side sessions in Redis, an alternative to
+// JWT access tokens. Sessions are stored at session:<sessionID> and expire
+// after SessionConfig.MaxAge unless refreshed.
+type SessionService struct {
+	store      session.Store
+	maxAge     time.Duration
+	cookieName string
+}
+
+// NewSessionService creates a session service storing sessions in Redis for
+// cfg.MaxAge, read from the cfg.CookieName cookie
+func NewSessionService(client *redis.Client, cfg config.SessionConfig) *SessionService {
+	maxAge := cfg.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultSessionMaxAge
+	}
+	cookieName := cfg.CookieName
+	if cookieName == "" {
+		cookieName = session.CookieName
+	}
+
+	return &SessionService{
+		store:      session.NewRedisStore(client),
+		maxAge:     maxAge,
+		cookieName: cookieName,
+	}
+}
+
+// Store is where the sessions are kept. Requests are authenticated against
+// it by the session middleware.
+func (s *SessionService) Store() session.Store {
+	return s.store
+}
+
+// CookieName is the cookie carrying the session ID
+func (s *SessionService) CookieName() string {
+	return s.cookieName
+}
+
+// MaxAge is how long a session lives after it is created or refreshed
+func (s *SessionService) MaxAge() time.Duration {
+	return s.maxAge
+}
+
+// Create starts a session for the user and returns its ID. The session
+// carries only the user ID; use CreateWithClaims to carry the email and role
+// as well.
+func (s *SessionService) Create(ctx context.Context, userID uuid.UUID) (string, error) {
+	return s.CreateWithClaims(ctx, userID, "", "")
+}
+
+// CreateWithClaims starts a session for the user with the email and role the
+// session middleware puts in the request context
+func (s *SessionService) CreateWithClaims(ctx context.Context, userID uuid.UUID, email, role string) (string, error) {
+	data := make(map[string]interface{})
+	if email != "" {
+		data["email"] = email
+	}
+	if role != "" {
+		data["role"] = role
+	}
+
+	sess, err := session.NewSession(userID.String(), data, s.maxAge)
+	if err != nil {
+		return "", err
+	}
+	if err := s.store.Create(ctx, sess); err != nil {
+		return "", fmt.Errorf("failed to create session: %w", err)
+	}
+	return sess.ID, nil
+}
+
+// Get returns the session, failing with session.ErrSessionNotFound when it
+// does not exist or was destroyed and session.ErrSessionExpired when it is
+// past its expiry
+func (s *SessionService) Get(ctx context.Context, sessionID string) (*Session, error) {
+	if sessionID == "" {
+		return nil, session.ErrSessionNotFound
+	}
+
+	sess, err := s.store.Get(ctx, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if sess.IsExpired() {
+		return nil, session.ErrSessionExpired
+	}
+	return sess, nil
+}
+
+// Refresh extends the session to MaxAge from now
+func (s *SessionService) Refresh(ctx context.Context, sessionID string) error {
+	sess, err := s.Get(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+
+	sess.ExpiresAt = time.Now().Add(s.maxAge)
+	return s.store.Update(ctx, sess)
+}
+
+// Destroy deletes the session, destroying a missing session is not an error
+func (s *SessionService) Destroy(ctx context.Context, sessionID string) error {
+	return s.store.Delete(ctx, sessionID)
+}
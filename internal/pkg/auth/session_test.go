@@ -0,0 +1,63 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+	"github.com/VeRJiL/go-template/internal/pkg/session"
+)
+
+func TestSessionService(t *testing.T) {
+	ctx := context.Background()
+	client := containertest.NewRedis(t)
+	service := NewSessionService(client, config.SessionConfig{MaxAge: time.Hour})
+	userID := uuid.New()
+
+	t.Run("should default the cookie name", func(t *testing.T) {
+		assert.Equal(t, session.CookieName, service.CookieName())
+	})
+
+	t.Run("should create a session stored with the max age", func(t *testing.T) {
+		sessionID, err := service.CreateWithClaims(ctx, userID, "test@example.com", "admin")
+		require.NoError(t, err)
+
+		sess, err := service.Get(ctx, sessionID)
+		require.NoError(t, err)
+		assert.Equal(t, userID.String(), sess.UserID)
+		assert.Equal(t, "admin", sess.Data["role"])
+
+		ttl, err := client.TTL(ctx, "session:"+sessionID).Result()
+		require.NoError(t, err)
+		assert.InDelta(t, time.Hour.Seconds(), ttl.Seconds(), 5)
+	})
+
+	t.Run("should refresh the expiry", func(t *testing.T) {
+		sessionID, err := service.Create(ctx, userID)
+		require.NoError(t, err)
+		require.NoError(t, client.Expire(ctx, "session:"+sessionID, time.Minute).Err())
+
+		require.NoError(t, service.Refresh(ctx, sessionID))
+
+		ttl, err := client.TTL(ctx, "session:"+sessionID).Result()
+		require.NoError(t, err)
+		assert.Greater(t, ttl, 59*time.Minute)
+	})
+
+	t.Run("should destroy the session", func(t *testing.T) {
+		sessionID, err := service.Create(ctx, userID)
+		require.NoError(t, err)
+
+		require.NoError(t, service.Destroy(ctx, sessionID))
+
+		_, err = service.Get(ctx, sessionID)
+		assert.ErrorIs(t, err, session.ErrSessionNotFound)
+		assert.ErrorIs(t, service.Refresh(ctx, sessionID), session.ErrSessionNotFound)
+	})
+}
@@ -0,0 +1,159 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// pendingTOTPExpiration bounds how long a generated secret waits for the
+	// first code before the user has to generate a new one
+	pendingTOTPExpiration = 10 * time.Minute
+
+	// pendingTOTPPrefix prefixes the Redis keys of the generated secrets
+	// awaiting their first code
+	pendingTOTPPrefix = "totp_pending:"
+)
+
+var (
+	// ErrInvalidTOTPCode is returned when a TOTP code does not match the
+	// user's secret
+	ErrInvalidTOTPCode = errors.New("invalid TOTP code")
+
+	// ErrTOTPNotPending is returned when enabling TOTP for a user without a
+	// freshly generated secret
+	ErrTOTPNotPending = errors.New("no pending TOTP secret, generate a new one")
+
+	// ErrTOTPNotEnabled is returned when verifying a code for a user that has
+	// not enabled TOTP
+	ErrTOTPNotEnabled = errors.New("TOTP is not enabled")
+
+	// ErrTOTPEnrollmentUnavailable is returned when generating or confirming
+	// a secret without a store for the pending secrets
+	ErrTOTPEnrollmentUnavailable = errors.New("TOTP enrollment store is not configured")
+)
+
+// TOTPStore persists the TOTP secrets of users
+type TOTPStore interface {
+	GetTOTP(ctx context.Context, userID uuid.UUID) (secret string, enabled bool, err error)
+	SetTOTP(ctx context.Context, userID uuid.UUID, secret string, enabled bool) error
+}
+
+// TOTPService enrolls users in TOTP two-factor authentication and verifies
+// their codes
+type TOTPService struct {
+	issuer  string
+	store   TOTPStore
+	pending *redis.Client
+}
+
+// NewTOTPService creates a TOTP service labelling secrets with the issuer,
+// usually the application name shown by authenticator apps
+func NewTOTPService(issuer string, store TOTPStore) *TOTPService {
+	return &TOTPService{
+		issuer: issuer,
+		store:  store,
+	}
+}
+
+// SetPendingStore enables enrollment: generated secrets are kept in Redis
+// until the user confirms them or they expire, so an enrollment can finish
+// on any instance. Without a store, GenerateSecret and EnableTOTP fail with
+// ErrTOTPEnrollmentUnavailable.
+func (s *TOTPService) SetPendingStore(client *redis.Client) {
+	s.pending = client
+}
+
+// GenerateSecret generates a secret for the user along with its otpauth://
+// URL to render as a QR code. The secret replaces any pending one and waits
+// pendingTOTPExpiration for the user to confirm it with EnableTOTP.
+func (s *TOTPService) GenerateSecret(ctx context.Context, userID uuid.UUID) (secret, qrCodeURL string, err error) {
+	if s.pending == nil {
+		return "", "", ErrTOTPEnrollmentUnavailable
+	}
+
+	key, err := totp.Generate(totp.GenerateOpts{
+		Issuer:      s.issuer,
+		AccountName: userID.String(),
+	})
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate TOTP secret: %w", err)
+	}
+
+	if err := s.pending.Set(ctx, pendingTOTPPrefix+userID.String(), key.Secret(), pendingTOTPExpiration).Err(); err != nil {
+		return "", "", fmt.Errorf("failed to store pending TOTP secret: %w", err)
+	}
+
+	return key.Secret(), key.URL(), nil
+}
+
+// EnableTOTP checks the first code against the secret generated for the
+// user and stores the secret, enabling TOTP on login
+func (s *TOTPService) EnableTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	if s.pending == nil {
+		return ErrTOTPEnrollmentUnavailable
+	}
+
+	key := pendingTOTPPrefix + userID.String()
+	secret, err := s.pending.Get(ctx, key).Result()
+	if errors.Is(err, redis.Nil) {
+		return ErrTOTPNotPending
+	}
+	if err != nil {
+		return fmt.Errorf("failed to load pending TOTP secret: %w", err)
+	}
+
+	if !totp.Validate(code, secret) {
+		return ErrInvalidTOTPCode
+	}
+
+	if err := s.store.SetTOTP(ctx, userID, secret, true); err != nil {
+		return fmt.Errorf("failed to enable TOTP: %w", err)
+	}
+
+	// The secret is enabled already, a leftover copy expires on its own
+	s.pending.Del(ctx, key)
+
+	return nil
+}
+
+// VerifyTOTP reports whether the code is valid for the user. It fails with
+// ErrTOTPNotEnabled for users without TOTP.
+func (s *TOTPService) VerifyTOTP(ctx context.Context, userID uuid.UUID, code string) (bool, error) {
+	secret, enabled, err := s.store.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to load TOTP secret: %w", err)
+	}
+	if !enabled || secret == "" {
+		return false, ErrTOTPNotEnabled
+	}
+
+	return totp.Validate(code, secret), nil
+}
+
+// DisableTOTP disables TOTP for the user and discards the secret, along
+// with any pending one
+func (s *TOTPService) DisableTOTP(ctx context.Context, userID string) error {
+	id, err := uuid.Parse(userID)
+	if err != nil {
+		return fmt.Errorf("invalid user ID %q: %w", userID, err)
+	}
+
+	if s.pending != nil {
+		if err := s.pending.Del(ctx, pendingTOTPPrefix+id.String()).Err(); err != nil {
+			return fmt.Errorf("failed to discard pending TOTP secret: %w", err)
+		}
+	}
+
+	if err := s.store.SetTOTP(ctx, id, "", false); err != nil {
+		return fmt.Errorf("failed to disable TOTP: %w", err)
+	}
+
+	return nil
+}
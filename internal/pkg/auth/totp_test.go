@@ -0,0 +1,177 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pquerna/otp/totp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+type memoryTOTPStore struct {
+	secrets map[uuid.UUID]string
+}
+
+func (m *memoryTOTPStore) GetTOTP(ctx context.Context, userID uuid.UUID) (string, bool, error) {
+	secret, ok := m.secrets[userID]
+	return secret, ok, nil
+}
+
+func (m *memoryTOTPStore) SetTOTP(ctx context.Context, userID uuid.UUID, secret string, enabled bool) error {
+	if !enabled {
+		delete(m.secrets, userID)
+		return nil
+	}
+	m.secrets[userID] = secret
+	return nil
+}
+
+func TestTOTPService(t *testing.T) {
+	ctx := context.Background()
+
+	newService := func() (*TOTPService, *memoryTOTPStore) {
+		store := &memoryTOTPStore{secrets: make(map[uuid.UUID]string)}
+		return NewTOTPService("go-template", store), store
+	}
+
+	code := func(t *testing.T, secret string) string {
+		c, err := totp.GenerateCode(secret, time.Now())
+		require.NoError(t, err)
+		return c
+	}
+
+	t.Run("should not enroll without a pending store", func(t *testing.T) {
+		service, _ := newService()
+		userID := uuid.New()
+
+		_, _, err := service.GenerateSecret(ctx, userID)
+		assert.ErrorIs(t, err, ErrTOTPEnrollmentUnavailable)
+		assert.ErrorIs(t, service.EnableTOTP(ctx, userID, "123456"), ErrTOTPEnrollmentUnavailable)
+	})
+
+	t.Run("should reject wrong codes", func(t *testing.T) {
+		service, store := newService()
+		userID := uuid.New()
+		key, err := totp.Generate(totp.GenerateOpts{Issuer: "go-template", AccountName: userID.String()})
+		require.NoError(t, err)
+		store.secrets[userID] = key.Secret()
+
+		valid, err := service.VerifyTOTP(ctx, userID, "not-a-code")
+		require.NoError(t, err)
+		assert.False(t, valid)
+	})
+
+	t.Run("should reject invalid user IDs on disable", func(t *testing.T) {
+		service, _ := newService()
+
+		assert.ErrorContains(t, service.DisableTOTP(ctx, "not-a-uuid"), "invalid user ID")
+	})
+
+	t.Run("with a pending store", func(t *testing.T) {
+		client := containertest.NewRedis(t)
+
+		newEnrollingService := func() (*TOTPService, *memoryTOTPStore) {
+			service, store := newService()
+			service.SetPendingStore(client)
+			return service, store
+		}
+
+		t.Run("should generate a secret with an otpauth URL", func(t *testing.T) {
+			service, _ := newEnrollingService()
+			userID := uuid.New()
+
+			secret, url, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+
+			assert.NotEmpty(t, secret)
+			assert.Contains(t, url, "otpauth://totp/")
+			assert.Contains(t, url, "issuer=go-template")
+			assert.Contains(t, url, "secret="+secret)
+		})
+
+		t.Run("should keep pending secrets in Redis until they expire", func(t *testing.T) {
+			service, _ := newEnrollingService()
+			userID := uuid.New()
+
+			secret, _, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+
+			key := pendingTOTPPrefix + userID.String()
+			pending, err := client.Get(ctx, key).Result()
+			require.NoError(t, err)
+			assert.Equal(t, secret, pending)
+
+			ttl, err := client.TTL(ctx, key).Result()
+			require.NoError(t, err)
+			assert.InDelta(t, pendingTOTPExpiration.Seconds(), ttl.Seconds(), 5)
+		})
+
+		t.Run("should enable TOTP with a valid first code on another instance", func(t *testing.T) {
+			service, _ := newEnrollingService()
+			other, store := newEnrollingService()
+			userID := uuid.New()
+
+			secret, _, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+
+			require.NoError(t, other.EnableTOTP(ctx, userID, code(t, secret)))
+			assert.Equal(t, secret, store.secrets[userID])
+
+			exists, err := client.Exists(ctx, pendingTOTPPrefix+userID.String()).Result()
+			require.NoError(t, err)
+			assert.Zero(t, exists)
+
+			valid, err := other.VerifyTOTP(ctx, userID, code(t, secret))
+			require.NoError(t, err)
+			assert.True(t, valid)
+		})
+
+		t.Run("should not enable TOTP with an invalid code", func(t *testing.T) {
+			service, store := newEnrollingService()
+			userID := uuid.New()
+
+			_, _, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+
+			assert.ErrorIs(t, service.EnableTOTP(ctx, userID, "000000x"), ErrInvalidTOTPCode)
+			assert.Empty(t, store.secrets)
+		})
+
+		t.Run("should require a pending secret to enable TOTP", func(t *testing.T) {
+			service, _ := newEnrollingService()
+			userID := uuid.New()
+
+			assert.ErrorIs(t, service.EnableTOTP(ctx, userID, "123456"), ErrTOTPNotPending)
+
+			secret, _, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+			require.NoError(t, client.Del(ctx, pendingTOTPPrefix+userID.String()).Err())
+
+			assert.ErrorIs(t, service.EnableTOTP(ctx, userID, code(t, secret)), ErrTOTPNotPending)
+		})
+
+		t.Run("should disable TOTP", func(t *testing.T) {
+			service, _ := newEnrollingService()
+			userID := uuid.New()
+
+			secret, _, err := service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+			require.NoError(t, service.EnableTOTP(ctx, userID, code(t, secret)))
+			_, _, err = service.GenerateSecret(ctx, userID)
+			require.NoError(t, err)
+
+			require.NoError(t, service.DisableTOTP(ctx, userID.String()))
+
+			_, err = service.VerifyTOTP(ctx, userID, code(t, secret))
+			assert.ErrorIs(t, err, ErrTOTPNotEnabled)
+			exists, err := client.Exists(ctx, pendingTOTPPrefix+userID.String()).Result()
+			require.NoError(t, err)
+			assert.Zero(t, exists)
+		})
+	})
+}
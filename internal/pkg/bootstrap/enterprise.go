@@ -4,18 +4,28 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"net/http"
+	"sync"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
 
+	"github.com/VeRJiL/go-template/internal/api/middleware"
 	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/cache"
 	"github.com/VeRJiL/go-template/internal/pkg/container"
 	"github.com/VeRJiL/go-template/internal/pkg/logger"
 	"github.com/VeRJiL/go-template/internal/pkg/modules"
 	"github.com/VeRJiL/go-template/internal/pkg/registry"
 )
 
+// defaultDrainTimeout is how long a module gets to drain when
+// BootstrapConfig.DrainTimeout is not set
+const defaultDrainTimeout = 10 * time.Second
+
 // EnterpriseBootstrap manages the complete enterprise application bootstrap
 type EnterpriseBootstrap struct {
 	container        *container.Container
@@ -24,7 +34,14 @@ type EnterpriseBootstrap struct {
 	logger           *logger.Logger
 	config           *config.Config
 	dependencies     *modules.Dependencies
+	cacheWarmer      *cache.CacheWarmer
+	stopWarming      context.CancelFunc
 	isInitialized    bool
+
+	// Development mode mounts module routes so they can be hot-reloaded
+	modulesDir       string
+	reloadable       map[string]*moduleRoutes
+	reloadMu         sync.Mutex
 }
 
 // NewEnterpriseBootstrap creates a new enterprise bootstrap instance
@@ -70,11 +87,24 @@ func (e *EnterpriseBootstrap) Initialize(ctx context.Context, db *sql.DB, redisC
 		return fmt.Errorf("failed to load modules: %w", err)
 	}
 
+	// Expose loaded modules through the container
+	for _, module := range e.moduleRegistry.GetModules() {
+		module := module
+		container.Provide(e.container, "module."+module.Name(), func() (modules.Module, error) {
+			return module, nil
+		})
+	}
+
 	// Initialize all modules
 	if err := e.moduleRegistry.Initialize(ctx, e.dependencies); err != nil {
 		return fmt.Errorf("failed to initialize modules: %w", err)
 	}
 
+	// Warm caches in the background so the HTTP server is not delayed
+	if e.config != nil && e.config.Performance.Warming.Enabled {
+		e.startCacheWarming()
+	}
+
 	e.isInitialized = true
 	e.logger.Info("Enterprise application initialized successfully",
 		"modules", e.moduleRegistry.GetModuleCount(),
@@ -83,8 +113,14 @@ func (e *EnterpriseBootstrap) Initialize(ctx context.Context, db *sql.DB, redisC
 	return nil
 }
 
-// RegisterModule registers a new module with the system
+// RegisterModule registers a new module with the system, failing with
+// ErrIncompatibleModule when the module does not run on this bootstrap
+// version
 func (e *EnterpriseBootstrap) RegisterModule(module modules.Module) error {
+	if err := checkCompatibility(e.Version(), module); err != nil {
+		return err
+	}
+
 	if err := e.moduleRegistry.Register(module); err != nil {
 		return fmt.Errorf("failed to register module %s: %w", module.Name(), err)
 	}
@@ -118,6 +154,14 @@ func (e *EnterpriseBootstrap) RegisterRoutes(router *gin.RouterGroup) error {
 
 	e.logger.Info("Registering module routes")
 
+	// Scoped services live for one request
+	router.Use(container.ScopeMiddleware())
+
+	// Container and cache internals are for admins only
+	admin := router.Group("/admin", middleware.AuthMiddleware(e.dependencies.JWTService), middleware.RequireRole("admin"))
+	admin.GET("/services", e.ServicesHandler)
+	admin.GET("/cache/warming-status", e.CacheWarmingStatusHandler)
+
 	// Register entity routes first
 	if err := e.entityRegistry.RegisterRoutes(router); err != nil {
 		return fmt.Errorf("failed to register entity routes: %w", err)
@@ -128,6 +172,13 @@ func (e *EnterpriseBootstrap) RegisterRoutes(router *gin.RouterGroup) error {
 	for _, module := range modules {
 		e.logger.Debug("Registering routes for module", "module", module.Name())
 
+		if e.isDevelopment() {
+			if err := e.mountReloadable(router, module); err != nil {
+				return err
+			}
+			continue
+		}
+
 		if err := module.RegisterRoutes(router, e.dependencies); err != nil {
 			return fmt.Errorf("failed to register routes for module %s: %w", module.Name(), err)
 		}
@@ -137,6 +188,42 @@ func (e *EnterpriseBootstrap) RegisterRoutes(router *gin.RouterGroup) error {
 	return nil
 }
 
+// ServicesHandler lists every service registered in the container and
+// whether it has been built yet
+func (e *EnterpriseBootstrap) ServicesHandler(c *gin.Context) {
+	info := e.container.GetServiceInfo()
+
+	services := make([]gin.H, 0, len(info))
+	for _, service := range info {
+		serviceType := ""
+		if service.Type != nil {
+			serviceType = service.Type.String()
+		}
+
+		services = append(services, gin.H{
+			"name":     service.Name,
+			"kind":     service.Kind,
+			"type":     serviceType,
+			"resolved": service.Resolved,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"services": services,
+		"count":    len(services),
+	})
+}
+
+// CacheWarmingStatusHandler reports the progress of startup cache warming
+func (e *EnterpriseBootstrap) CacheWarmingStatusHandler(c *gin.Context) {
+	if e.cacheWarmer == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Cache warming is disabled"})
+		return
+	}
+
+	e.cacheWarmer.StatusHandler(c)
+}
+
 // Migrate runs database migrations for all modules and entities
 func (e *EnterpriseBootstrap) Migrate(ctx context.Context) error {
 	if !e.isInitialized {
@@ -173,16 +260,71 @@ func (e *EnterpriseBootstrap) Shutdown(ctx context.Context) error {
 
 	e.logger.Info("Shutting down enterprise application")
 
+	if e.stopWarming != nil {
+		e.stopWarming()
+	}
+
+	drainTime := e.drainModules(ctx)
+
 	if err := e.moduleRegistry.Shutdown(ctx); err != nil {
 		e.logger.Error("Failed to shutdown modules", "error", err)
 		return err
 	}
 
 	e.isInitialized = false
-	e.logger.Info("Enterprise application shutdown completed")
+	e.logger.Info("Enterprise application shutdown completed", "drain_time", drainTime)
 	return nil
 }
 
+// drainModules drains every module in parallel and returns how long it
+// took. A module that fails or does not drain within the drain timeout is
+// logged and left behind, so it cannot hold up the others.
+func (e *EnterpriseBootstrap) drainModules(ctx context.Context) time.Duration {
+	start := time.Now()
+
+	var wg sync.WaitGroup
+	for _, module := range e.moduleRegistry.GetModules() {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			e.drainModule(ctx, module)
+		}()
+	}
+	wg.Wait()
+
+	return time.Since(start)
+}
+
+// drainModule waits for the module to drain for at most the drain timeout.
+// A Drain that ignores its context keeps running in the background.
+func (e *EnterpriseBootstrap) drainModule(ctx context.Context, module modules.Module) {
+	timeout := e.drainTimeout()
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	go func() {
+		done <- module.Drain(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			e.logger.Warn("Module failed to drain", "module", module.Name(), "error", err)
+		}
+	case <-ctx.Done():
+		e.logger.Warn("Module did not drain in time, shutting it down anyway",
+			"module", module.Name(), "timeout", timeout)
+	}
+}
+
+func (e *EnterpriseBootstrap) drainTimeout() time.Duration {
+	if e.config != nil && e.config.Bootstrap.DrainTimeout > 0 {
+		return e.config.Bootstrap.DrainTimeout
+	}
+	return defaultDrainTimeout
+}
+
 // GetModuleInfo returns information about all registered modules
 func (e *EnterpriseBootstrap) GetModuleInfo() []modules.ModuleInfo {
 	return e.moduleRegistry.GetModuleInfo()
@@ -207,19 +349,37 @@ func (e *EnterpriseBootstrap) GetModuleRegistry() modules.ModuleRegistry {
 
 func (e *EnterpriseBootstrap) registerCoreDependencies(db *sql.DB, redisClient *redis.Client, jwtService *auth.JWTService) error {
 	// Register database
-	e.container.Register("db", db)
+	container.Provide(e.container, "db", func() (*sql.DB, error) {
+		return db, nil
+	})
 
 	// Register Redis client
-	e.container.Register("redis", redisClient)
+	container.Provide(e.container, "redis", func() (*redis.Client, error) {
+		return redisClient, nil
+	})
 
 	// Register JWT service
-	e.container.Register("jwtService", jwtService)
+	container.Provide(e.container, "jwtService", func() (*auth.JWTService, error) {
+		return jwtService, nil
+	})
 
 	// Register logger
-	e.container.Register("logger", e.logger)
+	container.Provide(e.container, "logger", func() (*logger.Logger, error) {
+		return e.logger, nil
+	})
 
 	// Register config
-	e.container.Register("config", e.config)
+	container.Provide(e.container, "config", func() (*config.Config, error) {
+		return e.config, nil
+	})
+
+	// Register a database connection per request, released when the request
+	// ends, for work that must stay on one connection (e.g. session settings)
+	if db != nil {
+		e.container.RegisterScoped("dbConn", func() (interface{}, error) {
+			return db.Conn(context.Background())
+		})
+	}
 
 	// Register container itself (for self-reference in factories)
 	e.container.Register("container", e.container)
@@ -228,6 +388,26 @@ func (e *EnterpriseBootstrap) registerCoreDependencies(db *sql.DB, redisClient *
 	return nil
 }
 
+func (e *EnterpriseBootstrap) startCacheWarming() {
+	users, err := container.Resolve[repositories.UserRepository](e.container, "userRepository")
+	if err != nil {
+		e.logger.Warn("Cache warming skipped, user repository unavailable", "error", err)
+		return
+	}
+
+	userCache, err := container.Resolve[repositories.UserCacheRepository](e.container, "userCacheRepository")
+	if err != nil {
+		e.logger.Warn("Cache warming skipped, cache unavailable", "error", err)
+		return
+	}
+
+	e.cacheWarmer = cache.NewCacheWarmer(users, userCache, e.config.Performance.Warming, e.logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	e.stopWarming = cancel
+	go e.cacheWarmer.Warm(ctx)
+}
+
 // HealthCheck performs a health check on all enterprise components
 func (e *EnterpriseBootstrap) HealthCheck(ctx context.Context) map[string]interface{} {
 	health := map[string]interface{}{
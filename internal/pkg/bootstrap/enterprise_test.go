@@ -0,0 +1,226 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+	"github.com/VeRJiL/go-template/internal/pkg/container"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+	"github.com/VeRJiL/go-template/internal/pkg/registry"
+)
+
+func TestServicesHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should list services with their resolved status", func(t *testing.T) {
+		cont := container.NewContainer()
+		container.Provide(cont, "db", func() (string, error) { return "postgres", nil })
+		container.Provide(cont, "redis", func() (string, error) { return "redis", nil })
+		_, err := container.Resolve[string](cont, "db")
+		require.NoError(t, err)
+
+		e := &EnterpriseBootstrap{container: cont}
+		router := gin.New()
+		router.GET("/admin/services", e.ServicesHandler)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/services", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Services []struct {
+				Name     string `json:"name"`
+				Kind     string `json:"kind"`
+				Type     string `json:"type"`
+				Resolved bool   `json:"resolved"`
+			} `json:"services"`
+			Count int `json:"count"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, 2, body.Count)
+		assert.Equal(t, "db", body.Services[0].Name)
+		assert.Equal(t, "string", body.Services[0].Type)
+		assert.True(t, body.Services[0].Resolved)
+		assert.Equal(t, "redis", body.Services[1].Name)
+		assert.False(t, body.Services[1].Resolved)
+	})
+}
+
+// lifecycle records the drains and shutdowns of drainingModules in order
+type lifecycle struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (l *lifecycle) record(event string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.events = append(l.events, event)
+}
+
+func (l *lifecycle) Events() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]string(nil), l.events...)
+}
+
+// drainingModule drains with its drain function
+type drainingModule struct {
+	name      string
+	drain     func(ctx context.Context) error
+	lifecycle *lifecycle
+}
+
+func (m *drainingModule) Name() string                                     { return m.name }
+func (m *drainingModule) Version() string                                  { return "1.0.0" }
+func (m *drainingModule) MinBootstrapVersion() string                      { return "" }
+func (m *drainingModule) MaxBootstrapVersion() string                      { return "" }
+func (m *drainingModule) Dependencies() []string                           { return nil }
+func (m *drainingModule) RegisterServices(cont *container.Container) error { return nil }
+func (m *drainingModule) Migrate(db *sql.DB) error                         { return nil }
+func (m *drainingModule) Initialize(ctx context.Context) error             { return nil }
+func (m *drainingModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Dependencies) error {
+	return nil
+}
+
+func (m *drainingModule) Drain(ctx context.Context) error {
+	err := m.drain(ctx)
+	m.lifecycle.record("drained " + m.name)
+	return err
+}
+
+func (m *drainingModule) Shutdown(ctx context.Context) error {
+	m.lifecycle.record("shut down " + m.name)
+	return nil
+}
+
+func TestRegisterRoutesAdminAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	jwtService := auth.NewJWTService("admin-routes-test-secret", 1)
+	log := logger.New("error", "text")
+	cont := container.NewContainer()
+	e := &EnterpriseBootstrap{
+		container:      cont,
+		entityRegistry: registry.NewEntityRegistry(log, cont, nil),
+		moduleRegistry: registry.NewModuleRegistry(log, cont),
+		logger:         log,
+		config:         &config.Config{},
+		dependencies:   &modules.Dependencies{Container: cont, Logger: log, JWTService: jwtService},
+		isInitialized:  true,
+	}
+
+	router := gin.New()
+	require.NoError(t, e.RegisterRoutes(router.Group("/api/v1")))
+
+	request := func(role string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/services", nil)
+		if role != "" {
+			token, _, err := jwtService.GenerateToken(uuid.New(), role+"@example.com", role)
+			require.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should reject anonymous requests", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, request("").Code)
+	})
+
+	t.Run("should reject non-admin users", func(t *testing.T) {
+		assert.Equal(t, http.StatusForbidden, request("user").Code)
+	})
+
+	t.Run("should allow admins", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, request("admin").Code)
+	})
+}
+
+func newShutdownBootstrap(t *testing.T, drainTimeout time.Duration, mods ...modules.Module) *EnterpriseBootstrap {
+	t.Helper()
+
+	log := logger.New("error", "text")
+	cont := container.NewContainer()
+	moduleRegistry := registry.NewModuleRegistry(log, cont)
+	for _, module := range mods {
+		require.NoError(t, moduleRegistry.Register(module))
+	}
+	require.NoError(t, moduleRegistry.Initialize(context.Background(), &modules.Dependencies{Container: cont, Logger: log}))
+
+	return &EnterpriseBootstrap{
+		container:      cont,
+		moduleRegistry: moduleRegistry,
+		logger:         log,
+		config:         &config.Config{Bootstrap: config.BootstrapConfig{DrainTimeout: drainTimeout}},
+		isInitialized:  true,
+	}
+}
+
+func TestShutdownDrainsModules(t *testing.T) {
+	t.Run("should drain every module before shutting modules down", func(t *testing.T) {
+		events := &lifecycle{}
+		drained := func(ctx context.Context) error { return nil }
+		e := newShutdownBootstrap(t, time.Second,
+			&drainingModule{name: "orders", drain: drained, lifecycle: events},
+			&drainingModule{name: "billing", drain: drained, lifecycle: events},
+		)
+
+		require.NoError(t, e.Shutdown(context.Background()))
+
+		got := events.Events()
+		require.Len(t, got, 4)
+		assert.ElementsMatch(t, []string{"drained orders", "drained billing"}, got[:2])
+		assert.Equal(t, []string{"shut down billing", "shut down orders"}, got[2:])
+	})
+
+	t.Run("should not wait on a module past the drain timeout", func(t *testing.T) {
+		events := &lifecycle{}
+		stuck := make(chan struct{})
+		defer close(stuck)
+		e := newShutdownBootstrap(t, 20*time.Millisecond,
+			&drainingModule{name: "stuck", drain: func(ctx context.Context) error { <-stuck; return nil }, lifecycle: events},
+			&drainingModule{name: "slow", drain: func(ctx context.Context) error { <-ctx.Done(); return ctx.Err() }, lifecycle: events},
+			&drainingModule{name: "failing", drain: func(ctx context.Context) error { return errors.New("queue closed") }, lifecycle: events},
+		)
+
+		start := time.Now()
+		require.NoError(t, e.Shutdown(context.Background()))
+
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Subset(t, events.Events(), []string{"shut down stuck", "shut down slow", "shut down failing"})
+		assert.NotContains(t, events.Events(), "drained stuck")
+	})
+
+	t.Run("should drain modules in parallel", func(t *testing.T) {
+		events := &lifecycle{}
+		pause := func(ctx context.Context) error { time.Sleep(50 * time.Millisecond); return nil }
+		e := newShutdownBootstrap(t, time.Second,
+			&drainingModule{name: "a", drain: pause, lifecycle: events},
+			&drainingModule{name: "b", drain: pause, lifecycle: events},
+			&drainingModule{name: "c", drain: pause, lifecycle: events},
+		)
+
+		drainTime := e.drainModules(context.Background())
+
+		assert.GreaterOrEqual(t, drainTime, 50*time.Millisecond)
+		assert.Less(t, drainTime, 140*time.Millisecond)
+	})
+}
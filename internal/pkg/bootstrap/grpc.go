@@ -0,0 +1,113 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/reflection"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// NewGRPCServer creates a gRPC server with the message limits, keepalive
+// and TLS settings of cfg. Interceptors and other options can be added with
+// opts. Reflection is registered when cfg.Reflection is set.
+func NewGRPCServer(cfg *config.GRPCConfig, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	serverOpts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle:     cfg.MaxConnectionIdle,
+			MaxConnectionAge:      cfg.MaxConnectionAge,
+			MaxConnectionAgeGrace: cfg.MaxConnectionAgeGrace,
+			Time:                  cfg.KeepAliveTime,
+			Timeout:               cfg.KeepAliveTimeout,
+		}),
+	}
+	if cfg.MaxReceiveSize > 0 {
+		serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(cfg.MaxReceiveSize))
+	}
+	if cfg.MaxSendSize > 0 {
+		serverOpts = append(serverOpts, grpc.MaxSendMsgSize(cfg.MaxSendSize))
+	}
+	if cfg.TLS != nil && cfg.TLS.Enable {
+		creds, err := credentials.NewServerTLSFromFile(cfg.TLS.CertFile, cfg.TLS.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load gRPC TLS certificate: %w", err)
+		}
+		serverOpts = append(serverOpts, grpc.Creds(creds))
+	}
+
+	server := grpc.NewServer(append(serverOpts, opts...)...)
+	if cfg.Reflection {
+		reflection.Register(server)
+	}
+	return server, nil
+}
+
+// RegisterGRPCServices registers the gRPC services of every module that
+// serves any and returns how many modules did
+func (e *EnterpriseBootstrap) RegisterGRPCServices(server grpc.ServiceRegistrar) (int, error) {
+	if !e.isInitialized {
+		return 0, fmt.Errorf("enterprise bootstrap not initialized")
+	}
+
+	registered := 0
+	for _, module := range e.moduleRegistry.GetModules() {
+		grpcModule, ok := module.(modules.GRPCModule)
+		if !ok {
+			continue
+		}
+
+		if err := grpcModule.RegisterGRPC(server, e.dependencies); err != nil {
+			return registered, fmt.Errorf("failed to register gRPC services for module %s: %w", module.Name(), err)
+		}
+		registered++
+	}
+	return registered, nil
+}
+
+// ServeGRPC serves the modules' gRPC services on GRPC_PORT until ctx is
+// done, then stops gracefully. It returns right away when gRPC is disabled
+// or no module serves gRPC.
+func (e *EnterpriseBootstrap) ServeGRPC(ctx context.Context, opts ...grpc.ServerOption) error {
+	cfg := e.config.GRPC
+	if !cfg.Enabled {
+		return nil
+	}
+
+	server, err := NewGRPCServer(&cfg, opts...)
+	if err != nil {
+		return err
+	}
+
+	registered, err := e.RegisterGRPCServices(server)
+	if err != nil {
+		return err
+	}
+	if registered == 0 {
+		e.logger.Info("No module serves gRPC, not starting the gRPC server")
+		return nil
+	}
+
+	lis, err := net.Listen("tcp", ":"+cfg.Port)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC on port %s: %w", cfg.Port, err)
+	}
+
+	stopped := make(chan struct{})
+	defer close(stopped)
+	go func() {
+		select {
+		case <-ctx.Done():
+			server.GracefulStop()
+		case <-stopped:
+		}
+	}()
+
+	e.logger.Info("Starting gRPC server", "address", lis.Addr().String(), "modules", registered)
+	return server.Serve(lis)
+}
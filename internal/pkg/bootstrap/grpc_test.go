@@ -0,0 +1,88 @@
+package bootstrap
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// grpcModule serves the standard health service
+type grpcModule struct {
+	drainingModule
+}
+
+func (m *grpcModule) RegisterGRPC(server grpc.ServiceRegistrar, deps *modules.Dependencies) error {
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	return nil
+}
+
+// freePort returns a port that was free a moment ago
+func freePort(t *testing.T) string {
+	t.Helper()
+
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lis.Close()
+	return strconv.Itoa(lis.Addr().(*net.TCPAddr).Port)
+}
+
+func TestServeGRPC(t *testing.T) {
+	events := &lifecycle{}
+	drained := func(ctx context.Context) error { return nil }
+
+	t.Run("should serve the services of gRPC modules until stopped", func(t *testing.T) {
+		e := newShutdownBootstrap(t, time.Second,
+			&drainingModule{name: "billing", drain: drained, lifecycle: events},
+			&grpcModule{drainingModule{name: "orders", drain: drained, lifecycle: events}},
+		)
+		port := freePort(t)
+		e.config.GRPC = config.GRPCConfig{Enabled: true, Port: port}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		served := make(chan error, 1)
+		go func() { served <- e.ServeGRPC(ctx) }()
+
+		conn, err := grpc.NewClient("127.0.0.1:"+port, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		callCtx, callCancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer callCancel()
+		resp, err := healthpb.NewHealthClient(conn).Check(callCtx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+		cancel()
+		select {
+		case err := <-served:
+			assert.NoError(t, err)
+		case <-time.After(5 * time.Second):
+			t.Fatal("gRPC server did not stop")
+		}
+	})
+
+	t.Run("should not start without gRPC modules", func(t *testing.T) {
+		e := newShutdownBootstrap(t, time.Second, &drainingModule{name: "billing", drain: drained, lifecycle: events})
+		e.config.GRPC = config.GRPCConfig{Enabled: true, Port: freePort(t)}
+
+		assert.NoError(t, e.ServeGRPC(context.Background()))
+	})
+
+	t.Run("should not start when disabled", func(t *testing.T) {
+		e := newShutdownBootstrap(t, time.Second, &grpcModule{drainingModule{name: "orders", drain: drained, lifecycle: events}})
+
+		assert.NoError(t, e.ServeGRPC(context.Background()))
+	})
+}
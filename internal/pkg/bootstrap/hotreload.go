@@ -0,0 +1,207 @@
+package bootstrap
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gin-gonic/gin"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// defaultModulesDir is where generated modules live, relative to the
+// working directory
+const defaultModulesDir = "internal/modules"
+
+// reloadDebounce coalesces the burst of events an editor save produces
+const reloadDebounce = 250 * time.Millisecond
+
+// outerKeysKey carries the router's context keys, such as the authenticated
+// user, into a module's reloadable engine
+type outerKeysKey struct{}
+
+// moduleRoutes serves a module's routes from an engine that hot-reload can
+// swap out, since gin cannot remove routes from a running router
+type moduleRoutes struct {
+	basePath string
+	engine   atomic.Pointer[gin.Engine]
+}
+
+func (r *moduleRoutes) serve(c *gin.Context) {
+	ctx := context.WithValue(c.Request.Context(), outerKeysKey{}, c.Keys)
+	r.engine.Load().ServeHTTP(c.Writer, c.Request.WithContext(ctx))
+}
+
+// restoreOuterKeys copies the router's context keys into the module engine's
+// context before the module's handlers run
+func restoreOuterKeys(c *gin.Context) {
+	if keys, ok := c.Request.Context().Value(outerKeysKey{}).(map[string]any); ok {
+		for k, v := range keys {
+			c.Set(k, v)
+		}
+	}
+}
+
+// isDevelopment reports whether the server runs in development mode
+func (e *EnterpriseBootstrap) isDevelopment() bool {
+	return e.config != nil && e.config.Server.Mode == "development"
+}
+
+// mountReloadable registers the module's routes on router through a
+// moduleRoutes, so WatchModules can register them again later
+func (e *EnterpriseBootstrap) mountReloadable(router *gin.RouterGroup, module modules.Module) error {
+	routes := &moduleRoutes{basePath: router.BasePath()}
+	engine, err := e.moduleEngine(routes.basePath, module)
+	if err != nil {
+		return err
+	}
+	routes.engine.Store(engine)
+
+	for _, route := range engine.Routes() {
+		router.Handle(route.Method, strings.TrimPrefix(route.Path, routes.basePath), routes.serve)
+	}
+
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+	if e.reloadable == nil {
+		e.reloadable = make(map[string]*moduleRoutes)
+	}
+	e.reloadable[module.Name()] = routes
+	return nil
+}
+
+// moduleEngine registers the module's routes on a new route group of a
+// fresh engine
+func (e *EnterpriseBootstrap) moduleEngine(basePath string, module modules.Module) (*gin.Engine, error) {
+	engine := gin.New()
+	engine.Use(restoreOuterKeys)
+
+	if err := module.RegisterRoutes(engine.Group(basePath), e.dependencies); err != nil {
+		return nil, fmt.Errorf("failed to register routes for module %s: %w", module.Name(), err)
+	}
+	return engine, nil
+}
+
+// WatchModules reloads a module whenever a Go file of it in internal/modules
+// changes, until ctx is done. A reload shuts the running module down,
+// initializes it again and swaps in a fresh set of its routes, without
+// restarting the server. Files map to modules by name, so user_module.go
+// reloads the "user" module.
+//
+// Reloading resets a module's runtime state only; changed code still needs a
+// rebuild. It is only available in development mode.
+func (e *EnterpriseBootstrap) WatchModules(ctx context.Context) error {
+	if !e.isDevelopment() {
+		return fmt.Errorf("module hot-reload is only available in development mode")
+	}
+	if !e.isInitialized {
+		return fmt.Errorf("enterprise bootstrap not initialized")
+	}
+
+	dir := e.modulesDir
+	if dir == "" {
+		dir = defaultModulesDir
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("failed to create module watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := watcher.Add(dir); err != nil {
+		return fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+
+	e.logger.Warn("Module hot-reload is enabled; it is meant for development only and must not run in production",
+		"dir", dir)
+
+	pending := make(map[string]bool)
+	var debounce <-chan time.Time
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if !event.Has(fsnotify.Write|fsnotify.Create) || !isModuleSource(event.Name) {
+				continue
+			}
+
+			name := moduleNameForFile(event.Name)
+			if _, err := e.moduleRegistry.GetModule(name); err != nil {
+				e.logger.Debug("Changed file belongs to no registered module", "file", event.Name)
+				continue
+			}
+
+			pending[name] = true
+			debounce = time.After(reloadDebounce)
+
+		case <-debounce:
+			for name := range pending {
+				if err := e.ReloadModule(ctx, name); err != nil {
+					e.logger.Error("Failed to reload module", "module", name, "error", err)
+				}
+			}
+			pending = make(map[string]bool)
+			debounce = nil
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			e.logger.Error("Module watcher error", "error", err)
+		}
+	}
+}
+
+// ReloadModule drains and shuts the named module down, initializes it
+// again and re-registers its routes if they were mounted in development mode
+func (e *EnterpriseBootstrap) ReloadModule(ctx context.Context, name string) error {
+	module, err := e.moduleRegistry.GetModule(name)
+	if err != nil {
+		return err
+	}
+
+	e.reloadMu.Lock()
+	defer e.reloadMu.Unlock()
+
+	e.drainModule(ctx, module)
+	if err := module.Shutdown(ctx); err != nil {
+		return fmt.Errorf("failed to shut down module %s: %w", name, err)
+	}
+	if err := module.Initialize(ctx); err != nil {
+		return fmt.Errorf("failed to initialize module %s: %w", name, err)
+	}
+
+	if routes, ok := e.reloadable[name]; ok {
+		engine, err := e.moduleEngine(routes.basePath, module)
+		if err != nil {
+			return err
+		}
+		routes.engine.Store(engine)
+	}
+
+	e.logger.Info("Module reloaded", "module", name)
+	return nil
+}
+
+func isModuleSource(path string) bool {
+	return strings.HasSuffix(path, ".go") && !strings.HasSuffix(path, "_test.go")
+}
+
+// moduleNameForFile maps a module source file to its module's name,
+// following the generator's <name>_module.go layout
+func moduleNameForFile(path string) string {
+	name := strings.TrimSuffix(filepath.Base(path), ".go")
+	return strings.TrimSuffix(name, "_module")
+}
@@ -0,0 +1,180 @@
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/container"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+	"github.com/VeRJiL/go-template/internal/pkg/registry"
+)
+
+// widgetModule counts its lifecycle calls and reports them from its route
+type widgetModule struct {
+	mu          sync.Mutex
+	initialized int
+	drains      int
+	shutdowns   int
+}
+
+func (m *widgetModule) Name() string                                     { return "widget" }
+func (m *widgetModule) Version() string                                  { return "1.0.0" }
+func (m *widgetModule) MinBootstrapVersion() string                      { return "" }
+func (m *widgetModule) MaxBootstrapVersion() string                      { return "" }
+func (m *widgetModule) Dependencies() []string                           { return nil }
+func (m *widgetModule) RegisterServices(cont *container.Container) error { return nil }
+func (m *widgetModule) Migrate(db *sql.DB) error                         { return nil }
+
+func (m *widgetModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Dependencies) error {
+	generation := m.Initialized()
+	router.GET("/widgets", func(c *gin.Context) {
+		c.String(http.StatusOK, "%d %s", generation, c.GetString("user_id"))
+	})
+	return nil
+}
+
+func (m *widgetModule) Initialize(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.initialized++
+	return nil
+}
+
+func (m *widgetModule) Drain(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.drains++
+	return nil
+}
+
+func (m *widgetModule) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.shutdowns++
+	return nil
+}
+
+func (m *widgetModule) Initialized() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.initialized
+}
+
+func (m *widgetModule) Drains() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.drains
+}
+
+func (m *widgetModule) Shutdowns() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.shutdowns
+}
+
+func newDevelopmentBootstrap(t *testing.T, module modules.Module) *EnterpriseBootstrap {
+	log := logger.New("error", "text")
+	cont := container.NewContainer()
+	moduleRegistry := registry.NewModuleRegistry(log, cont)
+	require.NoError(t, moduleRegistry.Register(module))
+
+	deps := &modules.Dependencies{Container: cont, Logger: log}
+	require.NoError(t, moduleRegistry.Initialize(context.Background(), deps))
+
+	return &EnterpriseBootstrap{
+		container:      cont,
+		moduleRegistry: moduleRegistry,
+		logger:         log,
+		config:         &config.Config{Server: config.ServerConfig{Mode: "development"}},
+		dependencies:   deps,
+		isInitialized:  true,
+	}
+}
+
+func TestModuleHotReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	get := func(router *gin.Engine) string {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/widgets", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	t.Run("should serve fresh module routes after a reload", func(t *testing.T) {
+		module := &widgetModule{}
+		e := newDevelopmentBootstrap(t, module)
+
+		router := gin.New()
+		router.Use(func(c *gin.Context) { c.Set("user_id", "42") })
+		require.NoError(t, e.mountReloadable(router.Group("/api/v1"), module))
+		assert.Equal(t, "1 42", get(router))
+
+		require.NoError(t, e.ReloadModule(context.Background(), "widget"))
+
+		assert.Equal(t, 1, module.Drains())
+		assert.Equal(t, 1, module.Shutdowns())
+		assert.Equal(t, "2 42", get(router))
+	})
+
+	t.Run("should refuse to watch outside development mode", func(t *testing.T) {
+		e := newDevelopmentBootstrap(t, &widgetModule{})
+		e.config.Server.Mode = "production"
+
+		assert.ErrorContains(t, e.WatchModules(context.Background()), "only available in development mode")
+	})
+
+	t.Run("should reload the module whose file changed", func(t *testing.T) {
+		module := &widgetModule{}
+		e := newDevelopmentBootstrap(t, module)
+		e.modulesDir = t.TempDir()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan error)
+		go func() { done <- e.WatchModules(ctx) }()
+		defer func() {
+			cancel()
+			require.NoError(t, <-done)
+		}()
+
+		source := filepath.Join(e.modulesDir, "widget_module.go")
+		assert.Eventually(t, func() bool {
+			if err := os.WriteFile(source, []byte(fmt.Sprintf("package modules // %d\n", time.Now().UnixNano())), 0o644); err != nil {
+				return false
+			}
+			return module.Shutdowns() > 0
+		}, 5*time.Second, 2*reloadDebounce)
+		assert.GreaterOrEqual(t, module.Initialized(), 2)
+	})
+}
+
+func TestModuleNameForFile(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{"internal/modules/user_module.go", "user"},
+		{"/src/internal/modules/product_module.go", "product"},
+		{"internal/modules/billing.go", "billing"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			assert.Equal(t, tt.want, moduleNameForFile(tt.path))
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"golang.org/x/mod/semver"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// Version is the semantic version of the bootstrap, checked against the
+// range each module declares with MinBootstrapVersion and
+// MaxBootstrapVersion
+const Version = "1.0.0"
+
+// ErrIncompatibleModule is returned when registering a module that does not
+// run on this bootstrap version
+var ErrIncompatibleModule = errors.New("incompatible module")
+
+// ModuleInfo describes a registered module and whether it runs on the
+// bootstrap version
+type ModuleInfo struct {
+	Name                string `json:"name"`
+	Version             string `json:"version"`
+	MinBootstrapVersion string `json:"min_bootstrap_version,omitempty"`
+	MaxBootstrapVersion string `json:"max_bootstrap_version,omitempty"`
+	Compatible          bool   `json:"compatible"`
+	Reason              string `json:"reason,omitempty"`
+}
+
+// Version returns the bootstrap version modules are checked against
+func (e *EnterpriseBootstrap) Version() string {
+	return Version
+}
+
+// Modules returns the registered modules sorted by name, with whether each
+// one runs on the bootstrap version
+func (e *EnterpriseBootstrap) Modules() []ModuleInfo {
+	registered := e.moduleRegistry.GetModules()
+
+	infos := make([]ModuleInfo, 0, len(registered))
+	for _, module := range registered {
+		info := ModuleInfo{
+			Name:                module.Name(),
+			Version:             module.Version(),
+			MinBootstrapVersion: module.MinBootstrapVersion(),
+			MaxBootstrapVersion: module.MaxBootstrapVersion(),
+			Compatible:          true,
+		}
+		if err := checkCompatibility(e.Version(), module); err != nil {
+			info.Compatible = false
+			info.Reason = err.Error()
+		}
+		infos = append(infos, info)
+	}
+
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// checkCompatibility returns ErrIncompatibleModule when bootstrapVersion is
+// outside the module's declared range or a version cannot be parsed
+func checkCompatibility(bootstrapVersion string, module modules.Module) error {
+	current, ok := canonicalVersion(bootstrapVersion)
+	if !ok {
+		return fmt.Errorf("%w: bootstrap version %q is not a semantic version", ErrIncompatibleModule, bootstrapVersion)
+	}
+
+	if minVersion := module.MinBootstrapVersion(); minVersion != "" {
+		bound, ok := canonicalVersion(minVersion)
+		if !ok {
+			return fmt.Errorf("%w: module %s declares minimum bootstrap version %q, which is not a semantic version", ErrIncompatibleModule, module.Name(), minVersion)
+		}
+		if semver.Compare(current, bound) < 0 {
+			return fmt.Errorf("%w: module %s %s requires bootstrap %s or later, running %s", ErrIncompatibleModule, module.Name(), module.Version(), minVersion, bootstrapVersion)
+		}
+	}
+
+	if maxVersion := module.MaxBootstrapVersion(); maxVersion != "" {
+		bound, ok := canonicalVersion(maxVersion)
+		if !ok {
+			return fmt.Errorf("%w: module %s declares maximum bootstrap version %q, which is not a semantic version", ErrIncompatibleModule, module.Name(), maxVersion)
+		}
+		if semver.Compare(current, bound) > 0 {
+			return fmt.Errorf("%w: module %s %s requires bootstrap %s or earlier, running %s", ErrIncompatibleModule, module.Name(), module.Version(), maxVersion, bootstrapVersion)
+		}
+	}
+
+	return nil
+}
+
+// canonicalVersion adds the "v" prefix semver expects, accepting versions
+// written with or without it
+func canonicalVersion(version string) (string, bool) {
+	if !strings.HasPrefix(version, "v") {
+		version = "v" + version
+	}
+	return version, semver.IsValid(version)
+}
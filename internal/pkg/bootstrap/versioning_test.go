@@ -0,0 +1,92 @@
+package bootstrap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/container"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/registry"
+)
+
+// versionedModule is a drainingModule declaring a bootstrap version range
+type versionedModule struct {
+	drainingModule
+	minVersion string
+	maxVersion string
+}
+
+func (m *versionedModule) MinBootstrapVersion() string { return m.minVersion }
+func (m *versionedModule) MaxBootstrapVersion() string { return m.maxVersion }
+
+func newVersionedModule(name, minVersion, maxVersion string) *versionedModule {
+	return &versionedModule{drainingModule: drainingModule{name: name}, minVersion: minVersion, maxVersion: maxVersion}
+}
+
+func newVersioningBootstrap() *EnterpriseBootstrap {
+	log := logger.New("error", "text")
+	return &EnterpriseBootstrap{
+		moduleRegistry: registry.NewModuleRegistry(log, container.NewContainer()),
+		logger:         log,
+	}
+}
+
+func TestCheckCompatibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		minVersion string
+		maxVersion string
+		want       string
+	}{
+		{name: "unbounded"},
+		{name: "within range", minVersion: "1.0.0", maxVersion: "1.2.0"},
+		{name: "v prefix", minVersion: "v0.9.0", maxVersion: "v1.1.0"},
+		{name: "too old", minVersion: "1.1.0", want: "module billing 1.0.0 requires bootstrap 1.1.0 or later, running 1.0.1"},
+		{name: "too new", maxVersion: "1.0.0", want: "module billing 1.0.0 requires bootstrap 1.0.0 or earlier, running 1.0.1"},
+		{name: "prerelease minimum", minVersion: "1.0.1-rc.1"},
+		{name: "invalid", minVersion: "latest", want: `declares minimum bootstrap version "latest", which is not a semantic version`},
+	}
+
+	for _, tt := range tests {
+		t.Run("should check a module declaring "+tt.name, func(t *testing.T) {
+			err := checkCompatibility("1.0.1", newVersionedModule("billing", tt.minVersion, tt.maxVersion))
+
+			if tt.want == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrIncompatibleModule)
+			assert.ErrorContains(t, err, tt.want)
+		})
+	}
+}
+
+func TestRegisterModuleCompatibility(t *testing.T) {
+	t.Run("should reject modules outside their bootstrap range", func(t *testing.T) {
+		e := newVersioningBootstrap()
+
+		err := e.RegisterModule(newVersionedModule("billing", "2.0.0", ""))
+
+		assert.ErrorIs(t, err, ErrIncompatibleModule)
+		assert.Empty(t, e.Modules())
+	})
+
+	t.Run("should list modules by name with their compatibility", func(t *testing.T) {
+		e := newVersioningBootstrap()
+		require.NoError(t, e.RegisterModule(newVersionedModule("orders", "1.0.0", "")))
+		require.NoError(t, e.RegisterModule(newVersionedModule("billing", "", "1.9.0")))
+		// Modules loaded without RegisterModule are listed too
+		require.NoError(t, e.moduleRegistry.Register(newVersionedModule("legacy", "", "0.9.0")))
+
+		infos := e.Modules()
+
+		require.Len(t, infos, 3)
+		assert.Equal(t, ModuleInfo{Name: "billing", Version: "1.0.0", MaxBootstrapVersion: "1.9.0", Compatible: true}, infos[0])
+		assert.Equal(t, "legacy", infos[1].Name)
+		assert.False(t, infos[1].Compatible)
+		assert.Contains(t, infos[1].Reason, "requires bootstrap 0.9.0 or earlier")
+		assert.Equal(t, ModuleInfo{Name: "orders", Version: "1.0.0", MinBootstrapVersion: "1.0.0", Compatible: true}, infos[2])
+	})
+}
@@ -0,0 +1,150 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// EntityUsers is the warming entity name for user records
+const EntityUsers = "users"
+
+// UserCacheKey returns the cache key a user record is stored under
+func UserCacheKey(id uuid.UUID) string {
+	return fmt.Sprintf("user:%s", id)
+}
+
+// WarmingStatus reports the progress of the last warming run
+type WarmingStatus struct {
+	Running     bool       `json:"running"`
+	Total       int        `json:"total"`
+	Warmed      int        `json:"warmed"`
+	Failed      int        `json:"failed"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Error       string     `json:"error,omitempty"`
+}
+
+// CacheWarmer pre-populates caches with hot data so the first requests after
+// startup do not all miss
+type CacheWarmer struct {
+	users  repositories.UserRepository
+	cache  repositories.UserCacheRepository
+	config config.WarmingConfig
+	logger *logger.Logger
+
+	mu     sync.RWMutex
+	status WarmingStatus
+}
+
+// NewCacheWarmer creates a cache warmer for the configured entities
+func NewCacheWarmer(users repositories.UserRepository, cache repositories.UserCacheRepository, cfg config.WarmingConfig, logger *logger.Logger) *CacheWarmer {
+	return &CacheWarmer{
+		users:  users,
+		cache:  cache,
+		config: cfg,
+		logger: logger,
+	}
+}
+
+// Warm loads up to MaxRecords records of each configured entity and writes
+// them to the cache. Records that fail to cache are counted and skipped.
+func (w *CacheWarmer) Warm(ctx context.Context) error {
+	started := time.Now()
+	w.mu.Lock()
+	w.status = WarmingStatus{Running: true, StartedAt: &started}
+	w.mu.Unlock()
+
+	err := w.warm(ctx)
+
+	completed := time.Now()
+	w.mu.Lock()
+	w.status.Running = false
+	w.status.CompletedAt = &completed
+	if err != nil {
+		w.status.Error = err.Error()
+	}
+	status := w.status
+	w.mu.Unlock()
+
+	if err != nil {
+		w.logger.Error("Cache warming failed", "error", err)
+		return err
+	}
+
+	w.logger.Info("Cache warming completed",
+		"total", status.Total,
+		"warmed", status.Warmed,
+		"failed", status.Failed,
+		"duration", completed.Sub(started))
+	return nil
+}
+
+func (w *CacheWarmer) warm(ctx context.Context) error {
+	for _, entity := range w.config.Entities {
+		switch entity {
+		case EntityUsers:
+			if err := w.warmUsers(ctx); err != nil {
+				return err
+			}
+		default:
+			w.logger.Warn("Skipping unknown cache warming entity", "entity", entity)
+		}
+	}
+	return nil
+}
+
+func (w *CacheWarmer) warmUsers(ctx context.Context) error {
+	users, _, err := w.users.List(ctx, 0, w.config.MaxRecords)
+	if err != nil {
+		return fmt.Errorf("failed to list users: %w", err)
+	}
+
+	w.mu.Lock()
+	w.status.Total += len(users)
+	w.mu.Unlock()
+
+	for _, user := range users {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		err := w.cache.Set(ctx, UserCacheKey(user.ID), user)
+
+		w.mu.Lock()
+		if err != nil {
+			w.status.Failed++
+		} else {
+			w.status.Warmed++
+		}
+		w.mu.Unlock()
+
+		if err != nil {
+			w.logger.Warn("Failed to warm user cache", "user_id", user.ID, "error", err)
+		}
+	}
+
+	return nil
+}
+
+// Status returns a snapshot of the last warming run
+func (w *CacheWarmer) Status() WarmingStatus {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+
+	return w.status
+}
+
+// StatusHandler serves the warming status as JSON
+func (w *CacheWarmer) StatusHandler(c *gin.Context) {
+	c.JSON(http.StatusOK, w.Status())
+}
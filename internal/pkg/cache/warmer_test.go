@@ -0,0 +1,148 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/domain/repositories"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// MockUserRepository serves a fixed list of users
+type MockUserRepository struct {
+	repositories.UserRepository
+	users     []*entities.User
+	err       error
+	lastLimit int
+}
+
+func (m *MockUserRepository) List(ctx context.Context, offset, limit int) ([]*entities.User, int, error) {
+	m.lastLimit = limit
+	if m.err != nil {
+		return nil, 0, m.err
+	}
+	if limit < len(m.users) {
+		return m.users[:limit], len(m.users), nil
+	}
+	return m.users, len(m.users), nil
+}
+
+// MockUserCache records Set calls and fails for selected keys
+type MockUserCache struct {
+	repositories.UserCacheRepository
+	sets    map[string]*entities.User
+	failFor map[string]bool
+}
+
+func (m *MockUserCache) Set(ctx context.Context, key string, user *entities.User) error {
+	if m.failFor[key] {
+		return errors.New("cache unavailable")
+	}
+	m.sets[key] = user
+	return nil
+}
+
+func newUsers(n int) []*entities.User {
+	users := make([]*entities.User, n)
+	for i := range users {
+		users[i] = &entities.User{ID: uuid.New()}
+	}
+	return users
+}
+
+func newWarmer(users *MockUserRepository, cache *MockUserCache, maxRecords int) *CacheWarmer {
+	cfg := config.WarmingConfig{Enabled: true, Entities: []string{EntityUsers}, MaxRecords: maxRecords}
+	return NewCacheWarmer(users, cache, cfg, logger.New("error", "text"))
+}
+
+func TestCacheWarmer(t *testing.T) {
+	t.Run("should cache every listed user", func(t *testing.T) {
+		users := &MockUserRepository{users: newUsers(3)}
+		cache := &MockUserCache{sets: map[string]*entities.User{}}
+
+		err := newWarmer(users, cache, 1000).Warm(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, 1000, users.lastLimit)
+		assert.Len(t, cache.sets, 3)
+		for _, user := range users.users {
+			assert.Same(t, user, cache.sets[UserCacheKey(user.ID)])
+		}
+	})
+
+	t.Run("should respect max records", func(t *testing.T) {
+		users := &MockUserRepository{users: newUsers(5)}
+		cache := &MockUserCache{sets: map[string]*entities.User{}}
+
+		warmer := newWarmer(users, cache, 2)
+		require.NoError(t, warmer.Warm(context.Background()))
+
+		assert.Len(t, cache.sets, 2)
+		assert.Equal(t, 2, warmer.Status().Total)
+	})
+
+	t.Run("should count failed cache writes", func(t *testing.T) {
+		users := &MockUserRepository{users: newUsers(3)}
+		cache := &MockUserCache{
+			sets:    map[string]*entities.User{},
+			failFor: map[string]bool{UserCacheKey(users.users[1].ID): true},
+		}
+
+		warmer := newWarmer(users, cache, 1000)
+		require.NoError(t, warmer.Warm(context.Background()))
+
+		status := warmer.Status()
+		assert.False(t, status.Running)
+		assert.Equal(t, 3, status.Total)
+		assert.Equal(t, 2, status.Warmed)
+		assert.Equal(t, 1, status.Failed)
+		assert.NotNil(t, status.CompletedAt)
+	})
+
+	t.Run("should report repository errors", func(t *testing.T) {
+		users := &MockUserRepository{err: errors.New("connection refused")}
+		cache := &MockUserCache{sets: map[string]*entities.User{}}
+
+		warmer := newWarmer(users, cache, 1000)
+		err := warmer.Warm(context.Background())
+
+		assert.ErrorContains(t, err, "connection refused")
+		assert.Equal(t, "failed to list users: connection refused", warmer.Status().Error)
+		assert.Empty(t, cache.sets)
+	})
+}
+
+func TestCacheWarmerStatusHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should serve the warming status", func(t *testing.T) {
+		users := &MockUserRepository{users: newUsers(2)}
+		warmer := newWarmer(users, &MockUserCache{sets: map[string]*entities.User{}}, 1000)
+		require.NoError(t, warmer.Warm(context.Background()))
+
+		router := gin.New()
+		router.GET("/admin/cache/warming-status", warmer.StatusHandler)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/cache/warming-status", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var status WarmingStatus
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &status))
+		assert.Equal(t, 2, status.Total)
+		assert.Equal(t, 2, status.Warmed)
+		assert.Equal(t, 0, status.Failed)
+	})
+}
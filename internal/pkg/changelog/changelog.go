@@ -0,0 +1,213 @@
+package changelog
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gomarkdown/markdown"
+	"github.com/gomarkdown/markdown/ast"
+	"github.com/gomarkdown/markdown/parser"
+	"golang.org/x/mod/semver"
+)
+
+// Unreleased is the version name of the upcoming release section
+const Unreleased = "Unreleased"
+
+// Version is a single release section of the changelog
+type Version struct {
+	Version         string   `json:"version"`
+	Date            string   `json:"date,omitempty"`
+	BreakingChanges []string `json:"breaking_changes"`
+	Features        []string `json:"features"`
+	Changes         []string `json:"changes"`
+	Deprecations    []string `json:"deprecations"`
+	Removals        []string `json:"removals"`
+	Fixes           []string `json:"fixes"`
+	Security        []string `json:"security"`
+}
+
+// versionHeading matches "[1.2.0] - 2024-01-01", "1.2.0 - 2024-01-01" and "[Unreleased]"
+var versionHeading = regexp.MustCompile(`^\[?(Unreleased|v?\d+\.\d+\.\d+[^\]\s]*)\]?(?:\s*-\s*(\d{4}-\d{2}-\d{2}))?`)
+
+// breakingMarker matches items flagged as breaking inside any section
+var breakingMarker = regexp.MustCompile(`(?i)^breaking(?: change)?:\s*`)
+
+// ParseFile parses a Keep a Changelog formatted file
+func ParseFile(path string) ([]Version, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read changelog: %w", err)
+	}
+	return Parse(data), nil
+}
+
+// Parse extracts the release sections of a Keep a Changelog document.
+// Level two headings start a version, level three headings name the
+// section (Added, Changed, ...) and list items become entries. Items of a
+// "Breaking" section or prefixed with "BREAKING:" are breaking changes.
+func Parse(data []byte) []Version {
+	doc := markdown.Parse(data, parser.NewWithExtensions(parser.CommonExtensions))
+
+	var versions []Version
+	var current *Version
+	var section string
+
+	for _, node := range doc.GetChildren() {
+		switch n := node.(type) {
+		case *ast.Heading:
+			text := nodeText(n)
+			switch n.Level {
+			case 2:
+				current = nil
+				if m := versionHeading.FindStringSubmatch(text); m != nil {
+					versions = append(versions, newVersion(strings.TrimPrefix(m[1], "v"), m[2]))
+					current = &versions[len(versions)-1]
+				}
+				section = ""
+			case 3:
+				section = strings.ToLower(text)
+			}
+		case *ast.List:
+			if current == nil {
+				continue
+			}
+			for _, child := range n.GetChildren() {
+				if item, ok := child.(*ast.ListItem); ok {
+					current.add(section, itemText(item))
+				}
+			}
+		}
+	}
+
+	return versions
+}
+
+// newVersion creates a version with empty, non-nil entry lists so they
+// encode as [] rather than null
+func newVersion(version, date string) Version {
+	return Version{
+		Version:         version,
+		Date:            date,
+		BreakingChanges: []string{},
+		Features:        []string{},
+		Changes:         []string{},
+		Deprecations:    []string{},
+		Removals:        []string{},
+		Fixes:           []string{},
+		Security:        []string{},
+	}
+}
+
+func (v *Version) add(section, item string) {
+	if item == "" {
+		return
+	}
+
+	if breakingMarker.MatchString(item) {
+		v.BreakingChanges = append(v.BreakingChanges, breakingMarker.ReplaceAllString(item, ""))
+		return
+	}
+
+	switch {
+	case strings.HasPrefix(section, "breaking"):
+		v.BreakingChanges = append(v.BreakingChanges, item)
+	case section == "added":
+		v.Features = append(v.Features, item)
+	case section == "changed":
+		v.Changes = append(v.Changes, item)
+	case section == "deprecated":
+		v.Deprecations = append(v.Deprecations, item)
+	case section == "removed":
+		v.Removals = append(v.Removals, item)
+	case section == "fixed":
+		v.Fixes = append(v.Fixes, item)
+	case section == "security":
+		v.Security = append(v.Security, item)
+	}
+}
+
+// Filter returns the released versions in (from, to], newest first. Empty
+// bounds are open; the Unreleased section is only included when to is empty.
+func Filter(versions []Version, from, to string) ([]Version, error) {
+	fromSemver, err := canonical(from)
+	if err != nil {
+		return nil, err
+	}
+	toSemver, err := canonical(to)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := []Version{}
+	for _, v := range versions {
+		if v.Version == Unreleased {
+			if to == "" {
+				filtered = append(filtered, v)
+			}
+			continue
+		}
+
+		current := "v" + v.Version
+		if fromSemver != "" && semver.Compare(current, fromSemver) <= 0 {
+			continue
+		}
+		if toSemver != "" && semver.Compare(current, toSemver) > 0 {
+			continue
+		}
+		filtered = append(filtered, v)
+	}
+
+	sort.SliceStable(filtered, func(i, j int) bool {
+		a, b := filtered[i].Version, filtered[j].Version
+		if a == Unreleased || b == Unreleased {
+			return a == Unreleased && b != Unreleased
+		}
+		return semver.Compare("v"+a, "v"+b) > 0
+	})
+
+	return filtered, nil
+}
+
+func canonical(version string) (string, error) {
+	if version == "" {
+		return "", nil
+	}
+	v := "v" + strings.TrimPrefix(version, "v")
+	if !semver.IsValid(v) {
+		return "", fmt.Errorf("invalid version %q", version)
+	}
+	return v, nil
+}
+
+// itemText returns the text of a list item without nested lists
+func itemText(item *ast.ListItem) string {
+	for _, child := range item.GetChildren() {
+		if _, ok := child.(*ast.Paragraph); ok {
+			return nodeText(child)
+		}
+	}
+	return ""
+}
+
+// nodeText flattens the inline content of a node to plain text
+func nodeText(node ast.Node) string {
+	var b strings.Builder
+	ast.WalkFunc(node, func(n ast.Node, entering bool) ast.WalkStatus {
+		if !entering {
+			return ast.GoToNext
+		}
+		switch n := n.(type) {
+		case *ast.Text:
+			b.Write(n.Literal)
+		case *ast.Code:
+			b.Write(n.Literal)
+		case *ast.Softbreak, *ast.Hardbreak:
+			b.WriteString(" ")
+		}
+		return ast.GoToNext
+	})
+	return strings.Join(strings.Fields(b.String()), " ")
+}
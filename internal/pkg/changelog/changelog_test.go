@@ -0,0 +1,133 @@
+package changelog
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func loadFixture(t *testing.T) []Version {
+	versions, err := ParseFile("testdata/CHANGELOG.md")
+	require.NoError(t, err)
+	return versions
+}
+
+func TestParse(t *testing.T) {
+	versions := loadFixture(t)
+
+	t.Run("should parse every release section", func(t *testing.T) {
+		require.Len(t, versions, 4)
+		assert.Equal(t, Unreleased, versions[0].Version)
+		assert.Equal(t, "1.2.0", versions[1].Version)
+		assert.Equal(t, "2024-03-01", versions[1].Date)
+		assert.Equal(t, "1.0.0", versions[3].Version)
+	})
+
+	t.Run("should sort entries into sections", func(t *testing.T) {
+		v := versions[1]
+
+		assert.Equal(t, []string{"GET /api/v1/users returns a paginated envelope instead of an array"}, v.BreakingChanges)
+		assert.Equal(t, []string{"Search: GET /api/v1/users/search endpoint", "Session based authentication"}, v.Features)
+		assert.Equal(t, []string{"Login returned 500 for unknown emails"}, v.Fixes)
+		assert.Equal(t, []string{"Passwords are hashed with bcrypt"}, versions[3].Security)
+	})
+
+	t.Run("should treat BREAKING prefixed items as breaking changes", func(t *testing.T) {
+		v := versions[2]
+
+		assert.Equal(t, []string{"first_name and last_name are required on registration"}, v.BreakingChanges)
+		assert.Equal(t, []string{"Tokens expire after 24 hours"}, v.Changes)
+		assert.Equal(t, []string{"X-Auth-Token header, use Authorization: Bearer"}, v.Deprecations)
+	})
+
+	t.Run("should ignore non-release sections", func(t *testing.T) {
+		for _, v := range versions {
+			assert.NotContains(t, v.Features, "This list is not a release and must be ignored")
+		}
+	})
+}
+
+func TestFilter(t *testing.T) {
+	versions := loadFixture(t)
+
+	names := func(filtered []Version) []string {
+		var result []string
+		for _, v := range filtered {
+			result = append(result, v.Version)
+		}
+		return result
+	}
+
+	t.Run("should return versions after from up to and including to", func(t *testing.T) {
+		filtered, err := Filter(versions, "1.0.0", "1.2.0")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{"1.2.0", "1.1.0"}, names(filtered))
+	})
+
+	t.Run("should include unreleased changes when to is open", func(t *testing.T) {
+		filtered, err := Filter(versions, "1.1.0", "")
+
+		require.NoError(t, err)
+		assert.Equal(t, []string{Unreleased, "1.2.0"}, names(filtered))
+	})
+
+	t.Run("should reject invalid versions", func(t *testing.T) {
+		_, err := Filter(versions, "one", "")
+
+		assert.Error(t, err)
+	})
+}
+
+func TestNewHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.GET("/api/changelog", NewHandler(loadFixture(t)))
+
+	request := func(url, accept string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, url, nil)
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should return filtered versions as JSON", func(t *testing.T) {
+		w := request("/api/changelog?from=1.0.0&to=1.2.0", "application/json")
+
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var body struct {
+			Versions []map[string]interface{} `json:"versions"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Versions, 2)
+		assert.Equal(t, "1.2.0", body.Versions[0]["version"])
+		assert.Equal(t, "2024-03-01", body.Versions[0]["date"])
+		assert.Equal(t, []interface{}{"GET /api/v1/users returns a paginated envelope instead of an array"}, body.Versions[0]["breaking_changes"])
+		assert.Equal(t, []interface{}{}, body.Versions[1]["fixes"])
+	})
+
+	t.Run("should render HTML for browsers", func(t *testing.T) {
+		w := request("/api/changelog?to=1.0.0", "text/html,application/xhtml+xml")
+
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.Contains(t, w.Header().Get("Content-Type"), "text/html")
+		assert.Contains(t, w.Body.String(), "<h2>1.0.0 <small>2024-01-01</small></h2>")
+		assert.Contains(t, w.Body.String(), "<li>User registration and login</li>")
+	})
+
+	t.Run("should reject invalid version bounds", func(t *testing.T) {
+		w := request("/api/changelog?from=latest", "")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+	})
+}
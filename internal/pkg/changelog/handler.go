@@ -0,0 +1,89 @@
+package changelog
+
+import (
+	"html/template"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+var htmlTemplate = template.Must(template.New("changelog").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>API Changelog</title></head>
+<body>
+<h1>API Changelog</h1>
+{{- range .}}
+<section>
+<h2>{{.Version}}{{if .Date}} <small>{{.Date}}</small>{{end}}</h2>
+{{- range .Sections}}
+<h3>{{.Title}}</h3>
+<ul>
+{{- range .Items}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+</body>
+</html>
+`))
+
+// htmlSection is a titled, non-empty list of entries for the HTML view
+type htmlSection struct {
+	Title string
+	Items []string
+}
+
+// htmlVersion is a version with its non-empty sections in display order
+type htmlVersion struct {
+	Version  string
+	Date     string
+	Sections []htmlSection
+}
+
+// NewHandler serves the changelog filtered by the from and to query
+// parameters. Clients accepting text/html get a rendered page, everyone
+// else gets {"versions": [...]}.
+func NewHandler(versions []Version) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filtered, err := Filter(versions, c.Query("from"), c.Query("to"))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEHTML) {
+		case gin.MIMEHTML:
+			c.Header("Content-Type", "text/html; charset=utf-8")
+			c.Status(http.StatusOK)
+			if err := htmlTemplate.Execute(c.Writer, toHTMLVersions(filtered)); err != nil {
+				c.Error(err)
+			}
+		default:
+			c.JSON(http.StatusOK, gin.H{"versions": filtered})
+		}
+	}
+}
+
+func toHTMLVersions(versions []Version) []htmlVersion {
+	views := make([]htmlVersion, 0, len(versions))
+	for _, v := range versions {
+		view := htmlVersion{Version: v.Version, Date: v.Date}
+		for _, section := range []htmlSection{
+			{"Breaking changes", v.BreakingChanges},
+			{"Features", v.Features},
+			{"Changes", v.Changes},
+			{"Deprecations", v.Deprecations},
+			{"Removals", v.Removals},
+			{"Fixes", v.Fixes},
+			{"Security", v.Security},
+		} {
+			if len(section.Items) > 0 {
+				view.Sections = append(view.Sections, section)
+			}
+		}
+		views = append(views, view)
+	}
+	return views
+}
@@ -0,0 +1,272 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrOpen is returned for requests while the breaker is open
+	ErrOpen = errors.New("circuit breaker is open")
+
+	// ErrTooManyRequests is returned for requests over the concurrency limit,
+	// or while a half-open breaker is waiting on its trial request
+	ErrTooManyRequests = errors.New("circuit breaker has too many requests in flight")
+)
+
+const (
+	defaultFailureThreshold = 5
+	defaultSuccessThreshold = 2
+	defaultTimeout          = 30 * time.Second
+)
+
+// State is the state of a circuit breaker
+type State int
+
+const (
+	// StateClosed lets every request through
+	StateClosed State = iota
+	// StateOpen rejects every request until the timeout elapses
+	StateOpen
+	// StateHalfOpen lets one trial request through at a time
+	StateHalfOpen
+)
+
+// String returns the name of the state
+func (s State) String() string {
+	switch s {
+	case StateClosed:
+		return "closed"
+	case StateOpen:
+		return "open"
+	case StateHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// MarshalText encodes the state by name
+func (s State) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// CircuitBreakerConfig configures a circuit breaker
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the consecutive failures that open the breaker
+	FailureThreshold int
+	// SuccessThreshold is the consecutive successful trial requests that
+	// close a half-open breaker
+	SuccessThreshold int
+	// Timeout is how long the breaker stays open before it half-opens
+	Timeout time.Duration
+	// MaxConcurrentRequests caps the requests in flight, 0 for no limit
+	MaxConcurrentRequests int
+}
+
+// Status is a snapshot of a circuit breaker
+type Status struct {
+	Name                string     `json:"name"`
+	State               State      `json:"state"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	InFlight            int        `json:"in_flight"`
+	OpenedAt            *time.Time `json:"opened_at,omitempty"` // nil while closed
+}
+
+// CircuitBreaker stops calls to a failing dependency. It opens after
+// FailureThreshold consecutive failures and rejects calls for Timeout,
+// then half-opens and lets trial calls through one at a time. Any failing
+// trial opens it again, SuccessThreshold successful ones close it.
+type CircuitBreaker struct {
+	name   string
+	config CircuitBreakerConfig
+	now    func() time.Time
+
+	mu        sync.Mutex
+	state     State
+	failures  int
+	successes int
+	inFlight  int
+	trial     bool // a half-open trial request is in flight
+	openedAt  time.Time
+	// generation changes with the state, so results of requests let
+	// through in an earlier state are ignored
+	generation uint64
+}
+
+// New creates a closed circuit breaker. Thresholds and timeout that are
+// not set get defaults.
+func New(name string, config CircuitBreakerConfig) *CircuitBreaker {
+	if config.FailureThreshold <= 0 {
+		config.FailureThreshold = defaultFailureThreshold
+	}
+	if config.SuccessThreshold <= 0 {
+		config.SuccessThreshold = defaultSuccessThreshold
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultTimeout
+	}
+
+	return &CircuitBreaker{
+		name:   name,
+		config: config,
+		now:    time.Now,
+	}
+}
+
+// Name returns the name of the breaker
+func (b *CircuitBreaker) Name() string {
+	return b.name
+}
+
+// State returns the current state of the breaker
+func (b *CircuitBreaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	return b.state
+}
+
+// Status returns a snapshot of the breaker
+func (b *CircuitBreaker) Status() Status {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	status := Status{
+		Name:                b.name,
+		State:               b.state,
+		ConsecutiveFailures: b.failures,
+		InFlight:            b.inFlight,
+	}
+	if b.state != StateClosed {
+		openedAt := b.openedAt
+		status.OpenedAt = &openedAt
+	}
+	return status
+}
+
+// outcome is the result of a request let through the breaker
+type outcome int
+
+const (
+	outcomeSuccess outcome = iota
+	outcomeFailure
+	// outcomeIgnored releases the request without counting it, for
+	// requests that failed for reasons unrelated to the dependency
+	outcomeIgnored
+)
+
+// ticket is a request let through the breaker
+type ticket struct {
+	generation uint64
+	trial      bool
+}
+
+// Allow reports whether a request may be made. When it may, the returned
+// function must be called exactly once with the outcome of the request.
+func (b *CircuitBreaker) Allow() (func(success bool), error) {
+	t, err := b.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	var once sync.Once
+	return func(success bool) {
+		result := outcomeFailure
+		if success {
+			result = outcomeSuccess
+		}
+		once.Do(func() { b.release(t, result) })
+	}, nil
+}
+
+// Execute runs fn if the breaker allows it and records its error as the
+// outcome
+func (b *CircuitBreaker) Execute(fn func() error) error {
+	done, err := b.Allow()
+	if err != nil {
+		return err
+	}
+
+	err = fn()
+	done(err == nil)
+	return err
+}
+
+func (b *CircuitBreaker) acquire() (ticket, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.refresh()
+	switch {
+	case b.state == StateOpen:
+		return ticket{}, ErrOpen
+	case b.state == StateHalfOpen && b.trial:
+		return ticket{}, ErrTooManyRequests
+	case b.config.MaxConcurrentRequests > 0 && b.inFlight >= b.config.MaxConcurrentRequests:
+		return ticket{}, ErrTooManyRequests
+	}
+
+	b.inFlight++
+	t := ticket{generation: b.generation, trial: b.state == StateHalfOpen}
+	if t.trial {
+		b.trial = true
+	}
+	return t, nil
+}
+
+func (b *CircuitBreaker) release(t ticket, result outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.inFlight--
+	if t.generation != b.generation {
+		return
+	}
+	if t.trial {
+		b.trial = false
+	}
+
+	switch result {
+	case outcomeIgnored:
+		return
+	case outcomeFailure:
+		b.successes = 0
+		b.failures++
+		if b.state == StateHalfOpen || b.failures >= b.config.FailureThreshold {
+			b.setState(StateOpen)
+		}
+	case outcomeSuccess:
+		b.failures = 0
+		if b.state == StateHalfOpen {
+			b.successes++
+			if b.successes >= b.config.SuccessThreshold {
+				b.setState(StateClosed)
+			}
+		}
+	}
+}
+
+// refresh half-opens the breaker once it has been open for the timeout
+func (b *CircuitBreaker) refresh() {
+	if b.state == StateOpen && b.now().Sub(b.openedAt) >= b.config.Timeout {
+		b.setState(StateHalfOpen)
+	}
+}
+
+func (b *CircuitBreaker) setState(state State) {
+	b.state = state
+	b.successes = 0
+	b.trial = false
+	b.generation++
+
+	switch state {
+	case StateOpen:
+		b.openedAt = b.now()
+	case StateClosed:
+		b.failures = 0
+	}
+}
@@ -0,0 +1,135 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errDependency = errors.New("dependency failed")
+
+// newTestBreaker returns a breaker on a clock that only moves with advance
+func newTestBreaker(config CircuitBreakerConfig) (*CircuitBreaker, func(time.Duration)) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	breaker := New("test", config)
+	breaker.now = func() time.Time { return now }
+
+	return breaker, func(d time.Duration) { now = now.Add(d) }
+}
+
+func fail() error    { return errDependency }
+func succeed() error { return nil }
+
+func TestCircuitBreaker(t *testing.T) {
+	config := CircuitBreakerConfig{FailureThreshold: 3, SuccessThreshold: 2, Timeout: time.Minute}
+
+	t.Run("should open after consecutive failures", func(t *testing.T) {
+		breaker, _ := newTestBreaker(config)
+
+		breaker.Execute(fail)
+		breaker.Execute(fail)
+		breaker.Execute(succeed)
+		breaker.Execute(fail)
+		breaker.Execute(fail)
+		assert.Equal(t, StateClosed, breaker.State(), "a success resets the count")
+
+		breaker.Execute(fail)
+		assert.Equal(t, StateOpen, breaker.State())
+
+		called := false
+		err := breaker.Execute(func() error { called = true; return nil })
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.False(t, called)
+	})
+
+	t.Run("should let one trial through after the timeout", func(t *testing.T) {
+		breaker, advance := newTestBreaker(config)
+		for i := 0; i < 3; i++ {
+			breaker.Execute(fail)
+		}
+
+		advance(time.Minute)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+
+		done, err := breaker.Allow()
+		require.NoError(t, err)
+		_, err = breaker.Allow()
+		assert.ErrorIs(t, err, ErrTooManyRequests, "trial is in flight")
+
+		done(true)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+		require.NoError(t, breaker.Execute(succeed))
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+
+	t.Run("should reopen when a trial fails", func(t *testing.T) {
+		breaker, advance := newTestBreaker(config)
+		for i := 0; i < 3; i++ {
+			breaker.Execute(fail)
+		}
+		advance(time.Minute)
+
+		require.NoError(t, breaker.Execute(succeed))
+		breaker.Execute(fail)
+
+		assert.Equal(t, StateOpen, breaker.State())
+		advance(59 * time.Second)
+		assert.Equal(t, StateOpen, breaker.State(), "timeout restarts")
+	})
+
+	t.Run("should ignore results of requests from an earlier state", func(t *testing.T) {
+		breaker, advance := newTestBreaker(config)
+		slow, err := breaker.Allow()
+		require.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			breaker.Execute(fail)
+		}
+		advance(time.Minute)
+		trial, err := breaker.Allow()
+		require.NoError(t, err)
+
+		slow(false)
+		assert.Equal(t, StateHalfOpen, breaker.State())
+
+		trial(true)
+		assert.NoError(t, breaker.Execute(succeed))
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+
+	t.Run("should limit requests in flight", func(t *testing.T) {
+		breaker, _ := newTestBreaker(CircuitBreakerConfig{MaxConcurrentRequests: 2})
+
+		first, err := breaker.Allow()
+		require.NoError(t, err)
+		_, err = breaker.Allow()
+		require.NoError(t, err)
+		_, err = breaker.Allow()
+		assert.ErrorIs(t, err, ErrTooManyRequests)
+
+		first(true)
+		first(true)
+		_, err = breaker.Allow()
+		assert.NoError(t, err, "a done function only releases once")
+		assert.Equal(t, 2, breaker.Status().InFlight)
+	})
+
+	t.Run("should report when it opened", func(t *testing.T) {
+		breaker, advance := newTestBreaker(config)
+		assert.Nil(t, breaker.Status().OpenedAt)
+
+		for i := 0; i < 3; i++ {
+			breaker.Execute(fail)
+		}
+		opened := breaker.now()
+		advance(time.Minute)
+
+		status := breaker.Status()
+		assert.Equal(t, StateHalfOpen, status.State)
+		assert.Equal(t, 3, status.ConsecutiveFailures)
+		require.NotNil(t, status.OpenedAt)
+		assert.Equal(t, opened, *status.OpenedAt)
+	})
+}
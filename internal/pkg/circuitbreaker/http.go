@@ -0,0 +1,63 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+)
+
+// DefaultClientTimeout is the overall request timeout of clients built by
+// NewHTTPClient
+const DefaultClientTimeout = 30 * time.Second
+
+// NewHTTPClient returns a client whose requests go through the breaker
+// registered under name in DefaultRegistry, creating it from cfg if there
+// is none. Clients of the same external service should share a name so
+// they share a breaker.
+func NewHTTPClient(name string, cfg CircuitBreakerConfig) *http.Client {
+	return &http.Client{
+		Transport: NewTransport(DefaultRegistry.Get(name, cfg), nil),
+		Timeout:   DefaultClientTimeout,
+	}
+}
+
+// Transport is an http.RoundTripper that sends requests through a circuit
+// breaker. Transport errors, timeouts included, and 5xx responses count as
+// failures; requests cancelled by the caller count as neither.
+type Transport struct {
+	breaker *CircuitBreaker
+	next    http.RoundTripper
+}
+
+// NewTransport wraps next, or a clone of http.DefaultTransport when nil,
+// with the breaker
+func NewTransport(breaker *CircuitBreaker, next http.RoundTripper) *Transport {
+	if next == nil {
+		next = http.DefaultTransport.(*http.Transport).Clone()
+	}
+	return &Transport{breaker: breaker, next: next}
+}
+
+// RoundTrip sends the request unless the breaker rejects it
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ticket, err := t.breaker.acquire()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close()
+		}
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil && errors.Is(req.Context().Err(), context.Canceled):
+		t.breaker.release(ticket, outcomeIgnored)
+	case err != nil, resp.StatusCode >= http.StatusInternalServerError:
+		t.breaker.release(ticket, outcomeFailure)
+	default:
+		t.breaker.release(ticket, outcomeSuccess)
+	}
+
+	return resp, err
+}
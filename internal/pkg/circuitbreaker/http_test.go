@@ -0,0 +1,135 @@
+package circuitbreaker
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFlakyServer fails with 503 while failing is set and counts its requests
+func newFlakyServer(t *testing.T, failing *atomic.Bool, requests *atomic.Int32) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		if failing.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+func TestTransport(t *testing.T) {
+	config := CircuitBreakerConfig{FailureThreshold: 2, SuccessThreshold: 1, Timeout: time.Minute}
+
+	t.Run("should stop sending requests to a failing server", func(t *testing.T) {
+		var failing atomic.Bool
+		var requests atomic.Int32
+		failing.Store(true)
+		server := newFlakyServer(t, &failing, &requests)
+		client := &http.Client{Transport: NewTransport(New("flaky", config), nil)}
+
+		for i := 0; i < 2; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+		}
+
+		_, err := client.Get(server.URL)
+
+		assert.ErrorIs(t, err, ErrOpen)
+		assert.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("should not count client errors", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		t.Cleanup(server.Close)
+		breaker := New("not-found", config)
+		client := &http.Client{Transport: NewTransport(breaker, nil)}
+
+		for i := 0; i < 3; i++ {
+			resp, err := client.Get(server.URL)
+			require.NoError(t, err)
+			resp.Body.Close()
+		}
+
+		assert.Equal(t, StateClosed, breaker.State())
+	})
+
+	t.Run("should count timeouts but not cancellations", func(t *testing.T) {
+		var failing atomic.Bool
+		var requests atomic.Int32
+		server := newFlakyServer(t, &failing, &requests)
+		breaker := New("slow", config)
+		client := &http.Client{Transport: NewTransport(breaker, nil), Timeout: 20 * time.Millisecond}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/slow", nil)
+		require.NoError(t, err)
+		time.AfterFunc(5*time.Millisecond, cancel)
+		_, err = (&http.Client{Transport: NewTransport(breaker, nil)}).Do(req)
+		require.Error(t, err)
+		assert.Zero(t, breaker.Status().ConsecutiveFailures)
+
+		_, err = client.Get(server.URL + "/slow")
+		require.Error(t, err)
+		assert.Equal(t, 1, breaker.Status().ConsecutiveFailures)
+	})
+}
+
+func TestNewHTTPClient(t *testing.T) {
+	t.Run("should share the breaker of a name", func(t *testing.T) {
+		first := NewHTTPClient("test-shared", CircuitBreakerConfig{})
+		second := NewHTTPClient("test-shared", CircuitBreakerConfig{})
+
+		assert.Same(t, first.Transport.(*Transport).breaker, second.Transport.(*Transport).breaker)
+		assert.Equal(t, DefaultClientTimeout, first.Timeout)
+	})
+}
+
+func TestHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should list the breakers by name", func(t *testing.T) {
+		registry := NewRegistry()
+		registry.Get("stripe", CircuitBreakerConfig{})
+		google := registry.Get("google", CircuitBreakerConfig{FailureThreshold: 1})
+		google.Execute(fail)
+
+		router := gin.New()
+		router.GET("/admin/circuit-breakers", NewHandler(registry))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/circuit-breakers", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			CircuitBreakers []map[string]interface{} `json:"circuit_breakers"`
+			Count           int                      `json:"count"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Equal(t, 2, body.Count)
+		assert.Equal(t, "google", body.CircuitBreakers[0]["name"])
+		assert.Equal(t, "open", body.CircuitBreakers[0]["state"])
+		assert.NotEmpty(t, body.CircuitBreakers[0]["opened_at"])
+		assert.Equal(t, "stripe", body.CircuitBreakers[1]["name"])
+		assert.Equal(t, "closed", body.CircuitBreakers[1]["state"])
+		assert.NotContains(t, body.CircuitBreakers[1], "opened_at")
+	})
+}
@@ -0,0 +1,67 @@
+package circuitbreaker
+
+import (
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DefaultRegistry holds the breakers of clients built by NewHTTPClient
+var DefaultRegistry = NewRegistry()
+
+// Registry holds circuit breakers by name
+type Registry struct {
+	mu       sync.Mutex
+	breakers map[string]*CircuitBreaker
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{breakers: make(map[string]*CircuitBreaker)}
+}
+
+// Get returns the breaker registered under name, creating it from cfg if
+// there is none. The cfg of later calls for the same name is ignored.
+func (r *Registry) Get(name string, cfg CircuitBreakerConfig) *CircuitBreaker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	breaker, ok := r.breakers[name]
+	if !ok {
+		breaker = New(name, cfg)
+		r.breakers[name] = breaker
+	}
+	return breaker
+}
+
+// Statuses returns a snapshot of every breaker, sorted by name
+func (r *Registry) Statuses() []Status {
+	r.mu.Lock()
+	breakers := make([]*CircuitBreaker, 0, len(r.breakers))
+	for _, breaker := range r.breakers {
+		breakers = append(breakers, breaker)
+	}
+	r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(breakers))
+	for _, breaker := range breakers {
+		statuses = append(statuses, breaker.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Name < statuses[j].Name
+	})
+	return statuses
+}
+
+// NewHandler serves the state of the registry's breakers as JSON
+func NewHandler(registry *Registry) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		statuses := registry.Statuses()
+		c.JSON(http.StatusOK, gin.H{
+			"circuit_breakers": statuses,
+			"count":            len(statuses),
+		})
+	}
+}
@@ -3,7 +3,9 @@ package container
 import (
 	"fmt"
 	"reflect"
+	"sort"
 	"sync"
+	"sync/atomic"
 )
 
 // Container represents a dependency injection container
@@ -63,6 +65,15 @@ func (c *Container) Get(name string) (interface{}, error) {
 		return nil, fmt.Errorf("service '%s' not found", name)
 	}
 
+	// Handle typed providers
+	if provided, ok := service.(*providedService); ok {
+		instance, err := provided.getInstance()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build service '%s': %w", name, err)
+		}
+		return instance, nil
+	}
+
 	// Handle lazy singletons
 	if lazy, ok := service.(*lazySingleton); ok {
 		return lazy.getInstance(), nil
@@ -73,6 +84,11 @@ func (c *Container) Get(name string) (interface{}, error) {
 		return transient.factory(c), nil
 	}
 
+	// Scoped services only exist within a scope
+	if _, ok := service.(*scopedService); ok {
+		return nil, fmt.Errorf("service '%s' is scoped, resolve it with ResolveContext: %w", name, ErrNoScope)
+	}
+
 	return service, nil
 }
 
@@ -86,6 +102,14 @@ func (c *Container) GetByType(serviceType reflect.Type) (interface{}, error) {
 		return nil, fmt.Errorf("service of type '%s' not found", serviceType)
 	}
 
+	if provided, ok := service.(*providedService); ok {
+		instance, err := provided.getInstance()
+		if err != nil {
+			return nil, fmt.Errorf("failed to build service of type '%s': %w", serviceType, err)
+		}
+		return instance, nil
+	}
+
 	return service, nil
 }
 
@@ -203,11 +227,13 @@ type lazySingleton struct {
 	container *Container
 	instance  interface{}
 	once      sync.Once
+	resolved  atomic.Bool
 }
 
 func (l *lazySingleton) getInstance() interface{} {
 	l.once.Do(func() {
 		l.instance = l.factory(l.container)
+		l.resolved.Store(true)
 	})
 	return l.instance
 }
@@ -219,12 +245,14 @@ type transientService struct {
 
 // ServiceInfo holds information about a registered service
 type ServiceInfo struct {
-	Name string
-	Type reflect.Type
-	Kind string // singleton, transient, instance
+	Name     string
+	Type     reflect.Type
+	Kind     string // singleton, transient, scoped, instance
+	Resolved bool
 }
 
-// GetServiceInfo returns information about all registered services
+// GetServiceInfo returns information about all registered services, sorted
+// by name. Instances and transient services always count as resolved.
 func (c *Container) GetServiceInfo() []ServiceInfo {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -236,18 +264,31 @@ func (c *Container) GetServiceInfo() []ServiceInfo {
 			Type: reflect.TypeOf(service),
 		}
 
-		switch service.(type) {
+		switch s := service.(type) {
+		case *providedService:
+			serviceInfo.Kind = "singleton"
+			serviceInfo.Type = s.serviceType
+			serviceInfo.Resolved = s.isResolved()
 		case *lazySingleton:
 			serviceInfo.Kind = "singleton"
+			serviceInfo.Resolved = s.resolved.Load()
 		case *transientService:
 			serviceInfo.Kind = "transient"
+			serviceInfo.Resolved = true
+		case *scopedService:
+			serviceInfo.Kind = "scoped"
 		default:
 			serviceInfo.Kind = "instance"
+			serviceInfo.Resolved = true
 		}
 
 		info = append(info, serviceInfo)
 	}
 
+	sort.Slice(info, func(i, j int) bool {
+		return info[i].Name < info[j].Name
+	})
+
 	return info
 }
 
@@ -0,0 +1,137 @@
+package container
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type greeter interface {
+	Greet() string
+}
+
+type englishGreeter struct {
+	name string
+}
+
+func (g *englishGreeter) Greet() string {
+	return "hello " + g.name
+}
+
+func TestProvideResolve(t *testing.T) {
+	t.Run("should build the service lazily and only once", func(t *testing.T) {
+		c := NewContainer()
+		builds := 0
+		Provide(c, "greeter", func() (greeter, error) {
+			builds++
+			return &englishGreeter{name: "gopher"}, nil
+		})
+
+		assert.Equal(t, 0, builds)
+
+		first, err := Resolve[greeter](c, "greeter")
+		require.NoError(t, err)
+		second, err := Resolve[greeter](c, "greeter")
+		require.NoError(t, err)
+
+		assert.Equal(t, "hello gopher", first.Greet())
+		assert.Same(t, first, second)
+		assert.Equal(t, 1, builds)
+	})
+
+	t.Run("should resolve dependencies from other providers", func(t *testing.T) {
+		c := NewContainer()
+		Provide(c, "name", func() (string, error) { return "gopher", nil })
+		Provide(c, "greeter", func() (*englishGreeter, error) {
+			name, err := Resolve[string](c, "name")
+			if err != nil {
+				return nil, err
+			}
+			return &englishGreeter{name: name}, nil
+		})
+
+		g, err := Resolve[*englishGreeter](c, "greeter")
+
+		require.NoError(t, err)
+		assert.Equal(t, "hello gopher", g.Greet())
+	})
+
+	t.Run("should retry a factory that failed", func(t *testing.T) {
+		c := NewContainer()
+		calls := 0
+		Provide(c, "flaky", func() (int, error) {
+			calls++
+			if calls == 1 {
+				return 0, errors.New("not ready")
+			}
+			return 42, nil
+		})
+
+		_, err := Resolve[int](c, "flaky")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "not ready")
+
+		value, err := Resolve[int](c, "flaky")
+		require.NoError(t, err)
+		assert.Equal(t, 42, value)
+	})
+
+	t.Run("should resolve provided services by type", func(t *testing.T) {
+		c := NewContainer()
+		Provide(c, "greeter", func() (*englishGreeter, error) {
+			return &englishGreeter{name: "gopher"}, nil
+		})
+
+		service, err := c.GetByType(reflect.TypeOf(&englishGreeter{}))
+		require.NoError(t, err)
+		resolved, err := Resolve[*englishGreeter](c, "greeter")
+		require.NoError(t, err)
+
+		assert.Same(t, resolved, service)
+	})
+
+	t.Run("should reject a mismatched type", func(t *testing.T) {
+		c := NewContainer()
+		c.Register("answer", 42)
+
+		_, err := Resolve[string](c, "answer")
+
+		assert.EqualError(t, err, "service 'answer' is int, not string")
+	})
+
+	t.Run("should fail for unknown services", func(t *testing.T) {
+		_, err := Resolve[int](NewContainer(), "missing")
+
+		assert.EqualError(t, err, "service 'missing' not found")
+	})
+}
+
+func TestGetServiceInfo(t *testing.T) {
+	t.Run("should report resolved status sorted by name", func(t *testing.T) {
+		c := NewContainer()
+		c.Register("config", "value")
+		Provide(c, "greeter", func() (greeter, error) { return &englishGreeter{}, nil })
+		c.RegisterSingleton("answer", func(*Container) interface{} { return 42 })
+
+		info := c.GetServiceInfo()
+		require.Len(t, info, 3)
+		assert.Equal(t, "answer", info[0].Name)
+		assert.False(t, info[0].Resolved)
+		assert.Equal(t, "config", info[1].Name)
+		assert.True(t, info[1].Resolved)
+		assert.Equal(t, "greeter", info[2].Name)
+		assert.Equal(t, "container.greeter", info[2].Type.String())
+		assert.False(t, info[2].Resolved)
+
+		c.MustGet("answer")
+		_, err := Resolve[greeter](c, "greeter")
+		require.NoError(t, err)
+
+		info = c.GetServiceInfo()
+		assert.True(t, info[0].Resolved)
+		assert.True(t, info[2].Resolved)
+	})
+}
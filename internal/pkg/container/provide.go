@@ -0,0 +1,77 @@
+package container
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// Provide registers a typed service factory under name and under T for
+// GetByType. The factory runs on the first Resolve and its result is shared
+// by later calls; a factory that fails is retried on the next Resolve.
+func Provide[T any](c *Container, name string, factory func() (T, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	service := &providedService{
+		serviceType: reflect.TypeOf((*T)(nil)).Elem(),
+		factory: func() (interface{}, error) {
+			instance, err := factory()
+			return instance, err
+		},
+	}
+	c.services[name] = service
+	c.types[service.serviceType] = service
+}
+
+// Resolve retrieves the service registered under name as a T, building it
+// first if it was registered with Provide and has not been resolved yet
+func Resolve[T any](c *Container, name string) (T, error) {
+	var zero T
+
+	service, err := c.Get(name)
+	if err != nil {
+		return zero, err
+	}
+
+	typed, ok := service.(T)
+	if !ok {
+		return zero, fmt.Errorf("service '%s' is %T, not %s", name, service, reflect.TypeOf((*T)(nil)).Elem())
+	}
+
+	return typed, nil
+}
+
+// providedService is a lazily built service registered with Provide
+type providedService struct {
+	serviceType reflect.Type
+	factory     func() (interface{}, error)
+	instance    interface{}
+	resolved    bool
+	mu          sync.Mutex
+}
+
+func (p *providedService) getInstance() (interface{}, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.resolved {
+		return p.instance, nil
+	}
+
+	instance, err := p.factory()
+	if err != nil {
+		return nil, err
+	}
+
+	p.instance = instance
+	p.resolved = true
+	return instance, nil
+}
+
+func (p *providedService) isResolved() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.resolved
+}
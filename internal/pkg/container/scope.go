@@ -0,0 +1,197 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// scopeKey is the gin context key the request scope is stored under
+const scopeKey = "container.scope"
+
+// scopeContextKey is the context key the request scope is stored under
+type scopeContextKey struct{}
+
+// ErrNoScope is returned when a scoped service is resolved outside a scope
+var ErrNoScope = errors.New("no container scope in context")
+
+// Disposer is implemented by scoped services that release resources when
+// their scope ends. Services implementing io.Closer are closed instead.
+type Disposer interface {
+	Dispose() error
+}
+
+// RegisterScoped registers a service built once per scope, usually a
+// request, and disposed when the scope ends. Scoped services are resolved
+// with ResolveContext.
+func (c *Container) RegisterScoped(name string, factory func() (interface{}, error)) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.services[name] = &scopedService{factory: factory}
+}
+
+// ResolveContext retrieves a service by name, taking scoped services from
+// the scope in ctx (see WithScope and ScopeMiddleware)
+func (c *Container) ResolveContext(ctx context.Context, name string) (interface{}, error) {
+	c.mu.RLock()
+	service, exists := c.services[name]
+	c.mu.RUnlock()
+
+	scoped, ok := service.(*scopedService)
+	if !exists || !ok {
+		return c.Get(name)
+	}
+
+	scope, ok := ScopeFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("service '%s' is scoped: %w", name, ErrNoScope)
+	}
+
+	instance, err := scope.get(name, scoped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build service '%s': %w", name, err)
+	}
+	return instance, nil
+}
+
+// Scope holds the scoped services built during one request
+type Scope struct {
+	instances map[string]*scopedInstance
+	created   []interface{}
+	disposed  bool
+	mu        sync.Mutex
+}
+
+// NewScope creates an empty scope
+func NewScope() *Scope {
+	return &Scope{
+		instances: make(map[string]*scopedInstance),
+	}
+}
+
+// Dispose releases the scope's services, the most recently built first,
+// and returns the errors of those that failed
+func (s *Scope) Dispose() error {
+	s.mu.Lock()
+	created := s.created
+	s.created = nil
+	s.disposed = true
+	s.mu.Unlock()
+
+	var errs []error
+	for i := len(created) - 1; i >= 0; i-- {
+		if err := dispose(created[i]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// get returns the scope's instance of the service, building it on first use
+func (s *Scope) get(name string, service *scopedService) (interface{}, error) {
+	s.mu.Lock()
+	if s.disposed {
+		s.mu.Unlock()
+		return nil, errors.New("scope already disposed")
+	}
+	entry, exists := s.instances[name]
+	if !exists {
+		entry = &scopedInstance{}
+		s.instances[name] = entry
+	}
+	s.mu.Unlock()
+
+	// The entry is locked on its own so factories building other scoped
+	// services do not deadlock on the scope
+	entry.mu.Lock()
+	defer entry.mu.Unlock()
+
+	if entry.resolved {
+		return entry.instance, nil
+	}
+
+	instance, err := service.factory()
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	disposed := s.disposed
+	if !disposed {
+		s.created = append(s.created, instance)
+	}
+	s.mu.Unlock()
+	if disposed {
+		return nil, errors.Join(errors.New("scope already disposed"), dispose(instance))
+	}
+
+	entry.instance = instance
+	entry.resolved = true
+	return instance, nil
+}
+
+func dispose(instance interface{}) error {
+	switch instance := instance.(type) {
+	case Disposer:
+		return instance.Dispose()
+	case io.Closer:
+		return instance.Close()
+	}
+	return nil
+}
+
+// WithScope returns a copy of ctx carrying scope
+func WithScope(ctx context.Context, scope *Scope) context.Context {
+	return context.WithValue(ctx, scopeContextKey{}, scope)
+}
+
+// ScopeFromContext returns the scope carried by ctx, which can be a
+// *gin.Context served by ScopeMiddleware
+func ScopeFromContext(ctx context.Context) (*Scope, bool) {
+	if c, ok := ctx.(*gin.Context); ok {
+		if scope, ok := c.Get(scopeKey); ok {
+			return scope.(*Scope), true
+		}
+		if c.Request == nil {
+			return nil, false
+		}
+		ctx = c.Request.Context()
+	}
+
+	scope, ok := ctx.Value(scopeContextKey{}).(*Scope)
+	return scope, ok
+}
+
+// ScopeMiddleware opens a scope for every request, stored in the gin
+// context and the request context, and disposes it once the request has
+// been handled
+func ScopeMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		scope := NewScope()
+		c.Set(scopeKey, scope)
+		c.Request = c.Request.WithContext(WithScope(c.Request.Context(), scope))
+
+		defer func() {
+			if err := scope.Dispose(); err != nil {
+				_ = c.Error(fmt.Errorf("failed to dispose request scope: %w", err))
+			}
+		}()
+
+		c.Next()
+	}
+}
+
+type scopedService struct {
+	factory func() (interface{}, error)
+}
+
+type scopedInstance struct {
+	instance interface{}
+	resolved bool
+	mu       sync.Mutex
+}
@@ -0,0 +1,146 @@
+package container
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// closingService records when it is closed
+type closingService struct {
+	name   string
+	closed *[]string
+}
+
+func (s *closingService) Close() error {
+	*s.closed = append(*s.closed, s.name)
+	return nil
+}
+
+func TestResolveContext(t *testing.T) {
+	t.Run("should build scoped services once per scope", func(t *testing.T) {
+		c := NewContainer()
+		builds := 0
+		c.RegisterScoped("unitOfWork", func() (interface{}, error) {
+			builds++
+			return &englishGreeter{name: "gopher"}, nil
+		})
+
+		first := WithScope(context.Background(), NewScope())
+		a, err := c.ResolveContext(first, "unitOfWork")
+		require.NoError(t, err)
+		b, err := c.ResolveContext(first, "unitOfWork")
+		require.NoError(t, err)
+		assert.Same(t, a, b)
+
+		other, err := c.ResolveContext(WithScope(context.Background(), NewScope()), "unitOfWork")
+		require.NoError(t, err)
+		assert.NotSame(t, a, other)
+		assert.Equal(t, 2, builds)
+	})
+
+	t.Run("should resolve unscoped services like Get", func(t *testing.T) {
+		c := NewContainer()
+		c.Register("name", "gopher")
+
+		name, err := c.ResolveContext(context.Background(), "name")
+
+		require.NoError(t, err)
+		assert.Equal(t, "gopher", name)
+	})
+
+	t.Run("should fail for scoped services outside a scope", func(t *testing.T) {
+		c := NewContainer()
+		c.RegisterScoped("unitOfWork", func() (interface{}, error) { return "work", nil })
+
+		_, err := c.ResolveContext(context.Background(), "unitOfWork")
+		assert.ErrorIs(t, err, ErrNoScope)
+
+		_, err = c.Get("unitOfWork")
+		assert.ErrorIs(t, err, ErrNoScope)
+	})
+
+	t.Run("should retry a factory that failed", func(t *testing.T) {
+		c := NewContainer()
+		calls := 0
+		c.RegisterScoped("unitOfWork", func() (interface{}, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection refused")
+			}
+			return "work", nil
+		})
+		ctx := WithScope(context.Background(), NewScope())
+
+		_, err := c.ResolveContext(ctx, "unitOfWork")
+		require.Error(t, err)
+		work, err := c.ResolveContext(ctx, "unitOfWork")
+		require.NoError(t, err)
+		assert.Equal(t, "work", work)
+	})
+}
+
+func TestScopeDispose(t *testing.T) {
+	t.Run("should close services in reverse creation order", func(t *testing.T) {
+		c := NewContainer()
+		var closed []string
+		for _, name := range []string{"first", "second"} {
+			c.RegisterScoped(name, func() (interface{}, error) {
+				return &closingService{name: name, closed: &closed}, nil
+			})
+		}
+		scope := NewScope()
+		ctx := WithScope(context.Background(), scope)
+		_, err := c.ResolveContext(ctx, "first")
+		require.NoError(t, err)
+		_, err = c.ResolveContext(ctx, "second")
+		require.NoError(t, err)
+
+		require.NoError(t, scope.Dispose())
+
+		assert.Equal(t, []string{"second", "first"}, closed)
+		_, err = c.ResolveContext(ctx, "first")
+		assert.Error(t, err)
+	})
+}
+
+func TestScopeMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should give each request its own scope and dispose it", func(t *testing.T) {
+		c := NewContainer()
+		var closed []string
+		builds := 0
+		c.RegisterScoped("unitOfWork", func() (interface{}, error) {
+			builds++
+			return &closingService{name: "unitOfWork", closed: &closed}, nil
+		})
+
+		router := gin.New()
+		router.Use(ScopeMiddleware())
+		router.GET("/", func(ctx *gin.Context) {
+			fromGin, err := c.ResolveContext(ctx, "unitOfWork")
+			require.NoError(t, err)
+			fromRequest, err := c.ResolveContext(ctx.Request.Context(), "unitOfWork")
+			require.NoError(t, err)
+			assert.Same(t, fromGin, fromRequest)
+			assert.Len(t, closed, builds-1, "disposed before the request ended")
+			ctx.Status(http.StatusNoContent)
+		})
+
+		for i := 0; i < 2; i++ {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			require.Equal(t, http.StatusNoContent, w.Code)
+		}
+
+		assert.Equal(t, 2, builds)
+		assert.Equal(t, []string{"unitOfWork", "unitOfWork"}, closed)
+	})
+}
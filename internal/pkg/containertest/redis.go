@@ -0,0 +1,38 @@
+// Package containertest starts the services tests run against in containers
+package containertest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+)
+
+// NewRedis starts an empty Redis in a container and returns a client
+// connected to it. The client and container are removed when the test ends.
+// The test is skipped in short mode and when Docker is not available.
+func NewRedis(t *testing.T) *redis.Client {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("Skipping redis tests in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := tcredis.Run(ctx, "redis:7-alpine")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	uri, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	opts, err := redis.ParseURL(uri)
+	require.NoError(t, err)
+
+	client := redis.NewClient(opts)
+	t.Cleanup(func() { client.Close() })
+
+	return client
+}
@@ -0,0 +1,87 @@
+package eventbus
+
+import (
+	"sync"
+)
+
+// Domain event topics
+const (
+	UserCreated = "user.created"
+)
+
+// defaultBufferSize is the per-subscriber channel capacity
+const defaultBufferSize = 16
+
+// Event is a domain event delivered to subscribers
+type Event struct {
+	Topic   string
+	Payload interface{}
+}
+
+// Bus is an in-process publish/subscribe event bus. Publishing never blocks:
+// events for a subscriber whose buffer is full are dropped.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[string]map[uint64]chan Event
+	nextID      uint64
+}
+
+// New creates an event bus
+func New() *Bus {
+	return &Bus{
+		subscribers: make(map[string]map[uint64]chan Event),
+	}
+}
+
+// Publish delivers an event to every subscriber of the topic
+func (b *Bus) Publish(topic string, payload interface{}) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	event := Event{Topic: topic, Payload: payload}
+	for _, ch := range b.subscribers[topic] {
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe returns a channel receiving events for the topic and a function
+// that unsubscribes and closes the channel
+func (b *Bus) Subscribe(topic string) (<-chan Event, func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.nextID++
+	id := b.nextID
+	ch := make(chan Event, defaultBufferSize)
+
+	if b.subscribers[topic] == nil {
+		b.subscribers[topic] = make(map[uint64]chan Event)
+	}
+	b.subscribers[topic][id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+
+			delete(b.subscribers[topic], id)
+			if len(b.subscribers[topic]) == 0 {
+				delete(b.subscribers, topic)
+			}
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// SubscriberCount returns the number of subscribers for the topic
+func (b *Bus) SubscriberCount(topic string) int {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return len(b.subscribers[topic])
+}
@@ -0,0 +1,61 @@
+package eventbus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBus(t *testing.T) {
+	t.Run("should deliver events to every subscriber of the topic", func(t *testing.T) {
+		bus := New()
+		first, unsubscribeFirst := bus.Subscribe(UserCreated)
+		defer unsubscribeFirst()
+		second, unsubscribeSecond := bus.Subscribe(UserCreated)
+		defer unsubscribeSecond()
+
+		bus.Publish(UserCreated, "user-1")
+
+		for _, ch := range []<-chan Event{first, second} {
+			event := <-ch
+			assert.Equal(t, UserCreated, event.Topic)
+			assert.Equal(t, "user-1", event.Payload)
+		}
+	})
+
+	t.Run("should not deliver events of other topics", func(t *testing.T) {
+		bus := New()
+		ch, unsubscribe := bus.Subscribe(UserCreated)
+		defer unsubscribe()
+
+		bus.Publish("user.deleted", "user-1")
+
+		assert.Empty(t, ch)
+	})
+
+	t.Run("should close channel on unsubscribe", func(t *testing.T) {
+		bus := New()
+		ch, unsubscribe := bus.Subscribe(UserCreated)
+		require.Equal(t, 1, bus.SubscriberCount(UserCreated))
+
+		unsubscribe()
+		unsubscribe()
+
+		_, open := <-ch
+		assert.False(t, open)
+		assert.Equal(t, 0, bus.SubscriberCount(UserCreated))
+	})
+
+	t.Run("should drop events for slow subscribers instead of blocking", func(t *testing.T) {
+		bus := New()
+		ch, unsubscribe := bus.Subscribe(UserCreated)
+		defer unsubscribe()
+
+		for i := 0; i < defaultBufferSize*2; i++ {
+			bus.Publish(UserCreated, i)
+		}
+
+		assert.Len(t, ch, defaultBufferSize)
+	})
+}
@@ -0,0 +1,161 @@
+package features
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// flagFields maps the feature names IsEnabled accepts to the flags of c
+func flagFields(c *config.FeatureConfig) map[string]*bool {
+	return map[string]*bool{
+		"user_registration":  &c.UserRegistration,
+		"email_verification": &c.EmailVerification,
+		"two_factor_auth":    &c.TwoFactorAuth,
+		"social_login":       &c.SocialLogin,
+		"api_rate_limiting":  &c.APIRateLimiting,
+		"maintenance_mode":   &c.MaintenanceMode,
+		"payments":           &c.Payments,
+		"subscriptions":      &c.Subscriptions,
+		"invoicing":          &c.Invoicing,
+		"file_upload":        &c.FileUpload,
+		"image_processing":   &c.ImageProcessing,
+		"content_moderation": &c.ContentModeration,
+	}
+}
+
+// FeatureFlagManager serves feature flags that can be reloaded while the
+// application runs
+type FeatureFlagManager struct {
+	mu     sync.RWMutex
+	config config.FeatureConfig
+	flags  map[string]bool
+
+	redisClient *redis.Client
+	load        func() config.FeatureConfig
+
+	// history and logger receive the flags changed by reloads, when set
+	history *config.ConfigChangeHistory
+	logger  *logger.Logger
+}
+
+// NewFeatureFlagManager serves the flags of cfg until the first reload. The
+// Redis client is only used with the "redis" store and may be nil otherwise.
+func NewFeatureFlagManager(cfg config.FeatureConfig, redisClient *redis.Client) *FeatureFlagManager {
+	m := &FeatureFlagManager{
+		redisClient: redisClient,
+		load:        config.LoadFeatures,
+	}
+	m.swap(cfg)
+	return m
+}
+
+// IsEnabled reports whether the named feature, e.g. "maintenance_mode", is
+// enabled. Unknown features are disabled.
+func (m *FeatureFlagManager) IsEnabled(feature string) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.flags[feature]
+}
+
+// Config returns the current feature flags
+func (m *FeatureFlagManager) Config() config.FeatureConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.config
+}
+
+// TrackChanges logs each setting changed by a reload at INFO level and
+// records it in history. Either may be nil.
+func (m *FeatureFlagManager) TrackChanges(history *config.ConfigChangeHistory, log *logger.Logger) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.history = history
+	m.logger = log
+}
+
+// Reload reads the flags from the environment again, overridden by the
+// Redis hash with the "redis" store, and swaps them in at once. The flags
+// are left unchanged when reading Redis fails.
+func (m *FeatureFlagManager) Reload(ctx context.Context) error {
+	cfg := m.load()
+
+	if cfg.Store == "redis" {
+		if err := m.applyRedis(ctx, &cfg); err != nil {
+			return err
+		}
+	}
+
+	previous := m.swap(cfg)
+	m.trackChanges(config.DiffConfig("Features", previous, cfg))
+	return nil
+}
+
+// trackChanges logs and records the settings changed by a reload
+func (m *FeatureFlagManager) trackChanges(changes []config.ConfigChange) {
+	m.mu.RLock()
+	history, log := m.history, m.logger
+	m.mu.RUnlock()
+
+	if log != nil {
+		for _, change := range changes {
+			log.Info("Config changed", "field", change.Field, "old_value", change.OldValue, "new_value", change.NewValue)
+		}
+	}
+	if history != nil {
+		history.Record(changes...)
+	}
+}
+
+// applyRedis overrides the flags with the fields of the Redis hash
+func (m *FeatureFlagManager) applyRedis(ctx context.Context, cfg *config.FeatureConfig) error {
+	if m.redisClient == nil {
+		return fmt.Errorf("feature flag store is redis but no Redis client is configured")
+	}
+
+	values, err := m.redisClient.HGetAll(ctx, cfg.RedisKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags from %s: %w", cfg.RedisKey, err)
+	}
+
+	fields := flagFields(cfg)
+	for name, value := range values {
+		field, ok := fields[name]
+		if !ok {
+			continue
+		}
+
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("feature flag %s has invalid value %q", name, value)
+		}
+		*field = enabled
+	}
+
+	return nil
+}
+
+// swap serves the flags of cfg, returning those it replaced
+func (m *FeatureFlagManager) swap(cfg config.FeatureConfig) config.FeatureConfig {
+	flags := make(map[string]bool)
+	for name, field := range flagFields(&cfg) {
+		flags[name] = *field
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	previous := m.config
+	m.config = cfg
+	m.flags = flags
+	return previous
+}
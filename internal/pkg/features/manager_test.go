@@ -0,0 +1,101 @@
+package features
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+func TestFeatureFlagManager(t *testing.T) {
+	t.Run("should report the configured flags", func(t *testing.T) {
+		m := NewFeatureFlagManager(config.FeatureConfig{UserRegistration: true}, nil)
+
+		assert.True(t, m.IsEnabled("user_registration"))
+		assert.False(t, m.IsEnabled("maintenance_mode"))
+		assert.False(t, m.IsEnabled("no_such_feature"))
+	})
+
+	t.Run("should swap in reloaded flags", func(t *testing.T) {
+		m := NewFeatureFlagManager(config.FeatureConfig{Store: "env"}, nil)
+		m.load = func() config.FeatureConfig {
+			return config.FeatureConfig{Store: "env", MaintenanceMode: true}
+		}
+
+		require.NoError(t, m.Reload(context.Background()))
+
+		assert.True(t, m.IsEnabled("maintenance_mode"))
+		assert.True(t, m.Config().MaintenanceMode)
+	})
+
+	t.Run("should keep the flags when the redis store is unavailable", func(t *testing.T) {
+		m := NewFeatureFlagManager(config.FeatureConfig{Payments: true}, nil)
+		m.load = func() config.FeatureConfig {
+			return config.FeatureConfig{Store: "redis", RedisKey: "feature_flags"}
+		}
+
+		assert.Error(t, m.Reload(context.Background()))
+		assert.True(t, m.IsEnabled("payments"))
+	})
+
+	t.Run("should override flags from the redis hash", func(t *testing.T) {
+		client := containertest.NewRedis(t)
+		key := "test:feature_flags"
+		require.NoError(t, client.HSet(context.Background(), key, "maintenance_mode", "true", "file_upload", "false", "unknown", "true").Err())
+		t.Cleanup(func() { client.Del(context.Background(), key) })
+
+		m := NewFeatureFlagManager(config.FeatureConfig{}, client)
+		m.load = func() config.FeatureConfig {
+			return config.FeatureConfig{Store: "redis", RedisKey: key, FileUpload: true, Payments: true}
+		}
+
+		require.NoError(t, m.Reload(context.Background()))
+
+		assert.True(t, m.IsEnabled("maintenance_mode"))
+		assert.False(t, m.IsEnabled("file_upload"))
+		assert.True(t, m.IsEnabled("payments"))
+	})
+}
+
+func TestFeatureFlagManagerTrackChanges(t *testing.T) {
+	t.Run("should log and record the flags changed by a reload", func(t *testing.T) {
+		var logs bytes.Buffer
+		log := logger.New("info", "json")
+		log.SetOutput(&logs)
+		history := config.NewConfigChangeHistory(config.ConfigHistorySize)
+
+		m := NewFeatureFlagManager(config.FeatureConfig{Store: "env", Payments: true}, nil)
+		m.TrackChanges(history, log)
+		m.load = func() config.FeatureConfig {
+			return config.FeatureConfig{Store: "env", Payments: true, MaintenanceMode: true}
+		}
+
+		require.NoError(t, m.Reload(context.Background()))
+
+		changes := history.Changes()
+		require.Len(t, changes, 1)
+		assert.Equal(t, "Features.MaintenanceMode", changes[0].Field)
+		assert.Equal(t, "false", changes[0].OldValue)
+		assert.Equal(t, "true", changes[0].NewValue)
+		assert.Contains(t, logs.String(), `"field":"Features.MaintenanceMode"`)
+		assert.Contains(t, logs.String(), `"old_value":"false"`)
+		assert.Contains(t, logs.String(), `"new_value":"true"`)
+	})
+
+	t.Run("should record nothing when no flag changed", func(t *testing.T) {
+		history := config.NewConfigChangeHistory(config.ConfigHistorySize)
+		m := NewFeatureFlagManager(config.FeatureConfig{Store: "env"}, nil)
+		m.TrackChanges(history, nil)
+		m.load = func() config.FeatureConfig { return config.FeatureConfig{Store: "env"} }
+
+		require.NoError(t, m.Reload(context.Background()))
+
+		assert.Empty(t, history.Changes())
+	})
+}
@@ -0,0 +1,40 @@
+package generator
+
+import (
+	"path/filepath"
+	"slices"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// defaultAdminRole is admitted to admin routes when no role is granted
+// every permission
+const defaultAdminRole = "admin"
+
+// adminHandlerFile is the admin handler, written next to the handler
+func adminHandlerFile(layout GeneratorLayout, config modules.EntityConfig) string {
+	return filepath.Join(filepath.Dir(layout.HandlerPath(config)), entityFile(config, "_admin_handler"))
+}
+
+// adminRoles returns the roles granted every permission, in the order they
+// are first listed, or the admin role when there are none
+func adminRoles(permissions modules.PermissionConfig) []string {
+	lists := [][]string{permissions.Create, permissions.Read, permissions.Update, permissions.Delete, permissions.List}
+
+	var roles []string
+	for _, list := range lists {
+		for _, role := range list {
+			if slices.Contains(roles, role) {
+				continue
+			}
+			if !slices.ContainsFunc(lists, func(other []string) bool { return !slices.Contains(other, role) }) {
+				roles = append(roles, role)
+			}
+		}
+	}
+
+	if len(roles) == 0 {
+		return []string{defaultAdminRole}
+	}
+	return roles
+}
@@ -0,0 +1,87 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestGenerateAdmin(t *testing.T) {
+	basePath := t.TempDir()
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+	require.NoError(t, g.GenerateModule(modules.EntityConfig{
+		Name:      "Order",
+		TableName: "orders",
+		Admin:     true,
+		Permissions: modules.PermissionConfig{
+			Create: []string{"admin", "manager", "user"},
+			Read:   []string{"admin", "manager", "user"},
+			Update: []string{"admin", "manager"},
+			Delete: []string{"manager", "admin"},
+			List:   []string{"admin", "manager", "user"},
+		},
+	}))
+
+	t.Run("should restrict the admin handler to roles with every permission", func(t *testing.T) {
+		handler := readGenerated(t, basePath, "internal/api/handlers/order_admin_handler.go")
+
+		assert.Contains(t, handler, `var orderAdminRoles = []string{"admin", "manager"}`)
+		assert.Contains(t, handler, "claims, err := h.jwtService.ValidateToken(tokenString)")
+		assert.Contains(t, handler, "slices.Contains(orderAdminRoles, claims.Role)")
+		assert.Contains(t, handler, "*OrderHandler")
+	})
+
+	t.Run("should generate the bulk operations", func(t *testing.T) {
+		handler := readGenerated(t, basePath, "internal/api/handlers/order_admin_handler.go")
+
+		assert.Contains(t, handler, "func (h *OrderAdminHandler) BulkDelete(c *gin.Context)")
+		assert.Contains(t, handler, "func (h *OrderAdminHandler) BulkUpdate(c *gin.Context)")
+		assert.Contains(t, handler, "h.service.Update(c.Request.Context(), item.ID, item)")
+	})
+
+	t.Run("should register the admin routes behind AdminOnly", func(t *testing.T) {
+		module := readGenerated(t, basePath, "internal/modules/order_module.go")
+
+		assert.Contains(t, module, `"github.com/example/app/internal/pkg/auth"`)
+		assert.Contains(t, module, `handlers.NewOrderAdminHandler(service, logger, jwtService)`)
+		assert.Contains(t, module, `router.Group("/admin/orders", adminHandler.AdminOnly())`)
+		assert.Contains(t, module, `adminOrderGroup.DELETE("/bulk", adminHandler.BulkDelete)`)
+		assert.Contains(t, module, `adminOrderGroup.PUT("/bulk", adminHandler.BulkUpdate)`)
+	})
+
+	t.Run("should not generate admin routes unless enabled", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders"}))
+
+		assert.NoFileExists(t, filepath.Join(basePath, "internal/api/handlers/order_admin_handler.go"))
+		assert.NotContains(t, readGenerated(t, basePath, "internal/modules/order_module.go"), "/admin/")
+	})
+}
+
+func TestAdminRoles(t *testing.T) {
+	t.Run("should default to the admin role", func(t *testing.T) {
+		assert.Equal(t, []string{"admin"}, adminRoles(modules.PermissionConfig{}))
+		assert.Equal(t, []string{"admin"}, adminRoles(modules.PermissionConfig{
+			Create: []string{"editor"},
+			Delete: []string{"owner"},
+		}))
+	})
+
+	t.Run("should keep the roles listed for every operation", func(t *testing.T) {
+		roles := adminRoles(modules.PermissionConfig{
+			Create: []string{"owner", "admin"},
+			Read:   []string{"admin", "owner", "guest"},
+			Update: []string{"owner", "admin"},
+			Delete: []string{"admin", "owner"},
+			List:   []string{"guest", "admin", "owner"},
+		})
+
+		assert.Equal(t, []string{"owner", "admin"}, roles)
+	})
+}
@@ -0,0 +1,102 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SlowQueryRulesConfig configures the Prometheus rules generated for the
+// slow_queries_total counter of the monitoring package
+type SlowQueryRulesConfig struct {
+	Namespace string        // metric namespace, MONITORING_NAMESPACE
+	Window    time.Duration // window the slow query rate is computed over
+	Rate      float64       // slow queries per second that fire the alert
+	For       time.Duration // how long the rate must stay high before firing
+}
+
+type ruleFile struct {
+	Groups []ruleGroup `yaml:"groups"`
+}
+
+type ruleGroup struct {
+	Name  string `yaml:"name"`
+	Rules []rule `yaml:"rules"`
+}
+
+type rule struct {
+	Record      string            `yaml:"record,omitempty"`
+	Alert       string            `yaml:"alert,omitempty"`
+	Expr        string            `yaml:"expr"`
+	For         string            `yaml:"for,omitempty"`
+	Labels      map[string]string `yaml:"labels,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+// RenderSlowQueryRules renders a Prometheus rule file recording the rate of
+// slow queries per database and operation, and alerting when it stays above
+// config.Rate for config.For
+func RenderSlowQueryRules(config SlowQueryRulesConfig) (string, error) {
+	if config.Window <= 0 {
+		return "", fmt.Errorf("slow query rate window must be positive")
+	}
+	if config.Rate <= 0 {
+		return "", fmt.Errorf("slow query alert rate must be positive")
+	}
+
+	metric := "slow_queries_total"
+	if config.Namespace != "" {
+		metric = config.Namespace + "_" + metric
+	}
+	window := promDuration(config.Window)
+	recorded := fmt.Sprintf("database_operation:%s:rate%s", metric[:len(metric)-len("_total")], window)
+
+	rules := ruleFile{Groups: []ruleGroup{{
+		Name: "slow_queries",
+		Rules: []rule{
+			{
+				Record: recorded,
+				Expr:   fmt.Sprintf("sum by (database, operation) (rate(%s[%s]))", metric, window),
+			},
+			{
+				Alert:  "SlowDatabaseQueries",
+				Expr:   fmt.Sprintf("%s > %g", recorded, config.Rate),
+				For:    promDuration(config.For),
+				Labels: map[string]string{"severity": "warning"},
+				Annotations: map[string]string{
+					"summary":     "Slow {{ $labels.operation }} queries on {{ $labels.database }}",
+					"description": fmt.Sprintf("{{ $value | humanize }} queries per second exceeded the slow query threshold over the last %s.", window),
+				},
+			},
+		},
+	}}}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(rules); err != nil {
+		return "", fmt.Errorf("failed to render slow query rules: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return "", fmt.Errorf("failed to render slow query rules: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// promDuration formats d the way Prometheus writes durations, e.g. 5m
+func promDuration(d time.Duration) string {
+	switch {
+	case d <= 0:
+		return ""
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	case d%time.Second == 0:
+		return fmt.Sprintf("%ds", d/time.Second)
+	default:
+		return fmt.Sprintf("%dms", d/time.Millisecond)
+	}
+}
@@ -0,0 +1,60 @@
+package generator
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func TestRenderSlowQueryRules(t *testing.T) {
+	config := SlowQueryRulesConfig{
+		Namespace: "go_template",
+		Window:    5 * time.Minute,
+		Rate:      0.1,
+		For:       10 * time.Minute,
+	}
+
+	t.Run("should match golden file", func(t *testing.T) {
+		golden := "testdata/alert_rules/recording_rules.yml"
+		output, err := RenderSlowQueryRules(config)
+		require.NoError(t, err)
+
+		if *updateGolden {
+			require.NoError(t, os.WriteFile(golden, []byte(output), 0644))
+		}
+
+		expected, err := os.ReadFile(golden)
+		require.NoError(t, err)
+		assert.Equal(t, string(expected), output)
+	})
+
+	t.Run("should alert on the recorded rate", func(t *testing.T) {
+		output, err := RenderSlowQueryRules(config)
+		require.NoError(t, err)
+
+		var rules ruleFile
+		require.NoError(t, yaml.Unmarshal([]byte(output), &rules))
+		require.Len(t, rules.Groups, 1)
+		require.Len(t, rules.Groups[0].Rules, 2)
+
+		recorded := rules.Groups[0].Rules[0]
+		assert.Equal(t, "database_operation:go_template_slow_queries:rate5m", recorded.Record)
+		assert.Equal(t, "sum by (database, operation) (rate(go_template_slow_queries_total[5m]))", recorded.Expr)
+
+		alert := rules.Groups[0].Rules[1]
+		assert.Equal(t, "database_operation:go_template_slow_queries:rate5m > 0.1", alert.Expr)
+		assert.Equal(t, "10m", alert.For)
+	})
+
+	t.Run("should reject a missing rate or window", func(t *testing.T) {
+		_, err := RenderSlowQueryRules(SlowQueryRulesConfig{Window: time.Minute})
+		assert.Error(t, err)
+
+		_, err = RenderSlowQueryRules(SlowQueryRulesConfig{Rate: 1})
+		assert.Error(t, err)
+	})
+}
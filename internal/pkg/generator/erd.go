@@ -0,0 +1,430 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// ERD output formats
+const (
+	ERDFormatMermaid  = "mermaid"
+	ERDFormatPlantUML = "plantuml"
+	ERDFormatDBML     = "dbml"
+)
+
+// erdColumn is a normalized column used by all diagram formats
+type erdColumn struct {
+	Name     string
+	Kind     string
+	Values   []string
+	Primary  bool
+	Foreign  bool
+	Required bool
+	Unique   bool
+}
+
+// erdEntity is a normalized entity used by all diagram formats
+type erdEntity struct {
+	Name      string
+	Table     string
+	Columns   []erdColumn
+	Relations []modules.RelationDefinition
+}
+
+// LoadEntitySpecs reads every YAML entity spec matching the glob pattern
+func LoadEntitySpecs(pattern string) ([]modules.EntityConfig, error) {
+	files, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid spec pattern %s: %w", pattern, err)
+	}
+
+	sort.Strings(files)
+
+	var configs []modules.EntityConfig
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spec %s: %w", file, err)
+		}
+
+		var config modules.EntityConfig
+		if err := yaml.Unmarshal(data, &config); err != nil {
+			return nil, fmt.Errorf("failed to parse spec %s: %w", file, err)
+		}
+
+		if config.Name == "" {
+			return nil, fmt.Errorf("spec %s is missing the entity name", file)
+		}
+
+		configs = append(configs, config)
+	}
+
+	return configs, nil
+}
+
+// RenderERD renders an entity relationship diagram in the given format
+func RenderERD(format string, configs []modules.EntityConfig) (string, error) {
+	entities := buildERDEntities(configs)
+
+	switch format {
+	case ERDFormatMermaid:
+		return renderMermaid(entities), nil
+	case ERDFormatPlantUML:
+		return renderPlantUML(entities), nil
+	case ERDFormatDBML:
+		return renderDBML(entities), nil
+	default:
+		return "", fmt.Errorf("unsupported ERD format: %s", format)
+	}
+}
+
+func renderMermaid(entities []erdEntity) string {
+	var b strings.Builder
+
+	b.WriteString("erDiagram\n")
+
+	for _, entity := range entities {
+		fmt.Fprintf(&b, "    %s {\n", entity.Table)
+		for _, col := range entity.Columns {
+			fmt.Fprintf(&b, "        %s %s", col.Kind, col.Name)
+			switch {
+			case col.Primary:
+				b.WriteString(" PK")
+			case col.Foreign:
+				b.WriteString(" FK")
+			case col.Unique:
+				b.WriteString(" UK")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("    }\n")
+	}
+
+	for _, entity := range entities {
+		for _, rel := range entity.Relations {
+			fmt.Fprintf(&b, "    %s %s %s : \"%s\"\n",
+				entity.Table, cardinality(rel.Type), relatedTable(entities, rel.Entity), relationLabel(entities, rel))
+		}
+	}
+
+	return b.String()
+}
+
+func renderPlantUML(entities []erdEntity) string {
+	var b strings.Builder
+
+	b.WriteString("@startuml\n")
+	b.WriteString("hide circle\n")
+	b.WriteString("skinparam linetype ortho\n")
+
+	for _, entity := range entities {
+		fmt.Fprintf(&b, "\nentity \"%s\" as %s {\n", entity.Table, entity.Table)
+		for _, col := range entity.Columns {
+			if col.Primary {
+				fmt.Fprintf(&b, "  *%s : %s <<PK>>\n", col.Name, col.Kind)
+				b.WriteString("  --\n")
+			}
+		}
+		for _, col := range entity.Columns {
+			if col.Primary {
+				continue
+			}
+			prefix := "  "
+			if col.Required {
+				prefix = "  *"
+			}
+			fmt.Fprintf(&b, "%s%s : %s", prefix, col.Name, col.Kind)
+			if col.Foreign {
+				b.WriteString(" <<FK>>")
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	b.WriteString("\n")
+	for _, entity := range entities {
+		for _, rel := range entity.Relations {
+			fmt.Fprintf(&b, "%s %s %s : %s\n",
+				entity.Table, cardinality(rel.Type), relatedTable(entities, rel.Entity), relationLabel(entities, rel))
+		}
+	}
+
+	b.WriteString("@enduml\n")
+	return b.String()
+}
+
+func renderDBML(entities []erdEntity) string {
+	var b strings.Builder
+
+	for i, entity := range entities {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+
+		for _, col := range entity.Columns {
+			if col.Kind == "enum" && len(col.Values) > 0 {
+				fmt.Fprintf(&b, "Enum %s_%s {\n", entity.Table, col.Name)
+				for _, v := range col.Values {
+					fmt.Fprintf(&b, "  %s\n", v)
+				}
+				b.WriteString("}\n\n")
+			}
+		}
+
+		fmt.Fprintf(&b, "Table %s {\n", entity.Table)
+		for _, col := range entity.Columns {
+			colType := dbmlType(col.Kind)
+			if col.Kind == "enum" && len(col.Values) > 0 {
+				colType = entity.Table + "_" + col.Name
+			}
+
+			var settings []string
+			if col.Primary {
+				settings = append(settings, "pk")
+			}
+			if col.Required && !col.Primary {
+				settings = append(settings, "not null")
+			}
+			if col.Unique && !col.Primary {
+				settings = append(settings, "unique")
+			}
+
+			fmt.Fprintf(&b, "  %s %s", col.Name, colType)
+			if len(settings) > 0 {
+				fmt.Fprintf(&b, " [%s]", strings.Join(settings, ", "))
+			}
+			b.WriteString("\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	var refs []string
+	for _, entity := range entities {
+		for _, rel := range entity.Relations {
+			target := relatedTable(entities, rel.Entity)
+			switch rel.Type {
+			case modules.RelationOneToMany:
+				refs = append(refs, fmt.Sprintf("Ref: %s.%s > %s.id", target, foreignKey(rel, entity.Name), entity.Table))
+			case modules.RelationManyToOne:
+				refs = append(refs, fmt.Sprintf("Ref: %s.%s > %s.id", entity.Table, foreignKey(rel, rel.Entity), target))
+			case modules.RelationOneToOne:
+				refs = append(refs, fmt.Sprintf("Ref: %s.%s - %s.id", entity.Table, foreignKey(rel, rel.Entity), target))
+			case modules.RelationManyToMany:
+				refs = append(refs, fmt.Sprintf("Ref: %s.id <> %s.id", entity.Table, target))
+			}
+		}
+	}
+
+	if len(refs) > 0 {
+		b.WriteString("\n")
+		b.WriteString(strings.Join(refs, "\n"))
+		b.WriteString("\n")
+	}
+
+	return b.String()
+}
+
+func buildERDEntities(configs []modules.EntityConfig) []erdEntity {
+	entities := make([]erdEntity, 0, len(configs))
+
+	// One-to-many relations put the foreign key on the related entity
+	inbound := make(map[string][]string)
+	for _, config := range configs {
+		for _, rel := range config.Relations {
+			if rel.Type == modules.RelationOneToMany {
+				inbound[rel.Entity] = append(inbound[rel.Entity], foreignKey(rel, config.Name))
+			}
+		}
+	}
+
+	for _, config := range configs {
+		entity := erdEntity{
+			Name:      config.Name,
+			Table:     tableNameFor(config),
+			Relations: config.Relations,
+		}
+
+		required := make(map[string]bool)
+		for _, name := range config.Validation.Required {
+			required[name] = true
+		}
+
+		seen := map[string]bool{"id": true}
+		entity.Columns = append(entity.Columns, erdColumn{Name: "id", Kind: "uuid", Primary: true, Required: true})
+
+		for _, field := range config.Fields {
+			if field.Name == "id" {
+				entity.Columns[0].Kind = columnKind(field.Type)
+				continue
+			}
+			if seen[field.Name] {
+				continue
+			}
+			seen[field.Name] = true
+
+			col := erdColumn{
+				Name:     field.Name,
+				Kind:     columnKind(field.Type),
+				Values:   field.Values,
+				Required: required[field.Name] || hasConstraint(field, "required"),
+				Unique:   hasConstraint(field, "unique"),
+			}
+			entity.Columns = append(entity.Columns, col)
+		}
+
+		// Owning side of a relation carries the foreign key column
+		var foreignKeys []string
+		for _, rel := range config.Relations {
+			if rel.Type == modules.RelationManyToOne || rel.Type == modules.RelationOneToOne {
+				foreignKeys = append(foreignKeys, foreignKey(rel, rel.Entity))
+			}
+		}
+		foreignKeys = append(foreignKeys, inbound[config.Name]...)
+
+		for _, fk := range foreignKeys {
+			if seen[fk] {
+				markForeign(entity.Columns, fk)
+				continue
+			}
+			seen[fk] = true
+			entity.Columns = append(entity.Columns, erdColumn{Name: fk, Kind: "uuid", Foreign: true, Required: true})
+		}
+
+		if config.Timestamps {
+			entity.Columns = append(entity.Columns,
+				erdColumn{Name: "created_at", Kind: "datetime", Required: true},
+				erdColumn{Name: "updated_at", Kind: "datetime", Required: true},
+			)
+		}
+
+		if config.SoftDelete {
+			entity.Columns = append(entity.Columns, erdColumn{Name: "deleted_at", Kind: "datetime"})
+		}
+
+		entities = append(entities, entity)
+	}
+
+	return entities
+}
+
+func markForeign(columns []erdColumn, name string) {
+	for i := range columns {
+		if columns[i].Name == name {
+			columns[i].Foreign = true
+		}
+	}
+}
+
+func hasConstraint(field modules.FieldDefinition, constraint string) bool {
+	for _, c := range field.Constraints {
+		if c == constraint {
+			return true
+		}
+	}
+	return false
+}
+
+// columnKind maps spec and Go field types to a diagram attribute type
+func columnKind(fieldType string) string {
+	t := strings.ToLower(strings.TrimPrefix(fieldType, "*"))
+
+	switch t {
+	case "string", "text", "varchar":
+		return "string"
+	case "uuid", "uuid.uuid":
+		return "uuid"
+	case "decimal", "numeric", "float", "float32", "float64":
+		return "decimal"
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint32", "uint64", "integer":
+		return "int"
+	case "bool", "boolean":
+		return "boolean"
+	case "time", "time.time", "timestamp", "datetime", "date":
+		return "datetime"
+	case "json", "jsonb", "map[string]interface{}":
+		return "json"
+	case "enum":
+		return "enum"
+	default:
+		return strings.NewReplacer(".", "_", "[", "", "]", "").Replace(t)
+	}
+}
+
+func dbmlType(kind string) string {
+	switch kind {
+	case "string", "enum":
+		return "varchar"
+	case "int":
+		return "integer"
+	case "datetime":
+		return "timestamptz"
+	case "json":
+		return "jsonb"
+	default:
+		return kind
+	}
+}
+
+func cardinality(relationType string) string {
+	switch relationType {
+	case modules.RelationOneToMany:
+		return "||--o{"
+	case modules.RelationManyToOne:
+		return "}o--||"
+	case modules.RelationManyToMany:
+		return "}|--|{"
+	default:
+		return "||--||"
+	}
+}
+
+func relatedTable(entities []erdEntity, name string) string {
+	for _, entity := range entities {
+		if entity.Name == name {
+			return entity.Table
+		}
+	}
+	return toSnakeCase(name) + "s"
+}
+
+func relationLabel(entities []erdEntity, rel modules.RelationDefinition) string {
+	if rel.Name != "" {
+		return rel.Name
+	}
+	return relatedTable(entities, rel.Entity)
+}
+
+func foreignKey(rel modules.RelationDefinition, entityName string) string {
+	if rel.ForeignKey != "" {
+		return rel.ForeignKey
+	}
+	return toSnakeCase(entityName) + "_id"
+}
+
+// tableNameFor returns the configured table name or the pluralized snake_case entity name
+func tableNameFor(config modules.EntityConfig) string {
+	if config.TableName != "" {
+		return config.TableName
+	}
+	return toSnakeCase(config.Name) + "s"
+}
+
+// toSnakeCase converts CamelCase to snake_case
+func toSnakeCase(str string) string {
+	var result strings.Builder
+	for i, r := range str {
+		if i > 0 && r >= 'A' && r <= 'Z' {
+			result.WriteRune('_')
+		}
+		result.WriteRune(r)
+	}
+	return strings.ToLower(result.String())
+}
@@ -0,0 +1,92 @@
+package generator
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func TestLoadEntitySpecs(t *testing.T) {
+	t.Run("should load all specs with relations", func(t *testing.T) {
+		configs, err := LoadEntitySpecs("testdata/erd/specs/*.yaml")
+
+		require.NoError(t, err)
+		require.Len(t, configs, 3)
+		assert.Equal(t, "Author", configs[0].Name)
+		assert.Equal(t, modules.RelationOneToMany, configs[0].Relations[0].Type)
+		assert.Equal(t, "Post", configs[0].Relations[0].Entity)
+		assert.Equal(t, []string{"draft", "published"}, configs[1].Fields[2].Values)
+		assert.Equal(t, "tags", configs[2].TableName)
+	})
+
+	t.Run("should reject specs without a name", func(t *testing.T) {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.yaml"), []byte("table_name: things\n"), 0644))
+
+		_, err := LoadEntitySpecs(filepath.Join(dir, "*.yaml"))
+		assert.Error(t, err)
+	})
+}
+
+func TestRenderERD(t *testing.T) {
+	configs, err := LoadEntitySpecs("testdata/erd/specs/*.yaml")
+	require.NoError(t, err)
+
+	tests := []struct {
+		format string
+		golden string
+	}{
+		{ERDFormatMermaid, "testdata/erd/erd.mmd"},
+		{ERDFormatPlantUML, "testdata/erd/erd.puml"},
+		{ERDFormatDBML, "testdata/erd/erd.dbml"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should match golden file for "+tt.format, func(t *testing.T) {
+			output, err := RenderERD(tt.format, configs)
+			require.NoError(t, err)
+
+			if *updateGolden {
+				require.NoError(t, os.WriteFile(tt.golden, []byte(output), 0644))
+			}
+
+			expected, err := os.ReadFile(tt.golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(expected), output)
+		})
+	}
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		_, err := RenderERD("graphviz", configs)
+		assert.Error(t, err)
+	})
+}
+
+func TestColumnKind(t *testing.T) {
+	t.Run("should map field types to diagram types", func(t *testing.T) {
+		tests := map[string]string{
+			"string":     "string",
+			"uuid":       "uuid",
+			"uuid.UUID":  "uuid",
+			"decimal":    "decimal",
+			"float64":    "decimal",
+			"enum":       "enum",
+			"int":        "int",
+			"bool":       "boolean",
+			"*time.Time": "datetime",
+			"jsonb":      "json",
+		}
+
+		for input, expected := range tests {
+			assert.Equal(t, expected, columnKind(input), input)
+		}
+	})
+}
@@ -0,0 +1,559 @@
+package generator
+
+import (
+	"fmt"
+	"go/token"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// Field constraints accepted by ParseFields
+const (
+	ConstraintRequired = "required"
+	ConstraintUnique   = "unique"
+	ConstraintMin      = "min"
+	ConstraintMax      = "max"
+)
+
+// fieldKind groups Go field types that validate and scan alike
+type fieldKind int
+
+const (
+	kindString fieldKind = iota
+	kindNumber
+	kindBool
+	kindTime
+)
+
+// fieldTypes maps the Go types a field may have to their kind and column type
+var fieldTypes = map[string]struct {
+	kind    fieldKind
+	sqlType string
+}{
+	"string":    {kindString, "TEXT"},
+	"bool":      {kindBool, "BOOLEAN"},
+	"int":       {kindNumber, "BIGINT"},
+	"int32":     {kindNumber, "INTEGER"},
+	"int64":     {kindNumber, "BIGINT"},
+	"uint":      {kindNumber, "BIGINT"},
+	"uint32":    {kindNumber, "BIGINT"},
+	"uint64":    {kindNumber, "BIGINT"},
+	"float32":   {kindNumber, "REAL"},
+	"float64":   {kindNumber, "DOUBLE PRECISION"},
+	"time.Time": {kindTime, "TIMESTAMPTZ"},
+}
+
+// fieldNamePattern matches the snake_case column names fields may have
+var fieldNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+// reservedColumns are generated for every entity and cannot be declared
+var reservedColumns = map[string]bool{
+	"id": true, "created_at": true, "updated_at": true, "deleted_at": true, "tenant_id": true,
+}
+
+// defaultFields are generated for entity configs without fields
+var defaultFields = []modules.FieldDefinition{
+	{Name: "name", Type: "string", Constraints: []string{ConstraintRequired, ConstraintUnique, "min=2", "max=100"}},
+	{Name: "description", Type: "string"},
+}
+
+// ParseFields parses the -fields flag: comma separated name:type:constraints
+// tuples where constraints are optional and separated by "|", e.g.
+// "title:string:required|max=200,price:float64,published_at:*time.Time".
+// Types are Go types, optionally pointers for nullable columns.
+func ParseFields(spec string) ([]modules.FieldDefinition, error) {
+	var fields []modules.FieldDefinition
+	seen := make(map[string]bool)
+
+	for _, tuple := range strings.Split(spec, ",") {
+		tuple = strings.TrimSpace(tuple)
+		if tuple == "" {
+			continue
+		}
+
+		parts := strings.SplitN(tuple, ":", 3)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return nil, fmt.Errorf("invalid field %q, expected name:type[:constraints]", tuple)
+		}
+
+		field := modules.FieldDefinition{Name: toSnakeCase(parts[0]), Type: parts[1]}
+		if len(parts) == 3 && parts[2] != "" {
+			field.Constraints = strings.Split(parts[2], "|")
+		}
+
+		if seen[field.Name] {
+			return nil, fmt.Errorf("field %s is declared twice", field.Name)
+		}
+		seen[field.Name] = true
+
+		if _, err := newEntityField(field); err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields in %q", spec)
+	}
+	return fields, nil
+}
+
+// FieldValidation returns the validation config matching the field constraints
+func FieldValidation(fields []modules.FieldDefinition) modules.ValidationConfig {
+	validation := modules.ValidationConfig{Rules: make(map[string]string)}
+	for _, field := range fields {
+		if hasConstraint(field, ConstraintRequired) {
+			validation.Required = append(validation.Required, field.Name)
+		}
+		if rules := validateRules(field); rules != "" {
+			validation.Rules[field.Name] = rules
+		}
+	}
+	return validation
+}
+
+// entityField is a field as the templates render it
+type entityField struct {
+	Name     string // Go field name
+	Column   string
+	Type     string
+	SQLType  string
+	Tag      string
+	Pointer  bool
+	Required bool
+	Unique   bool
+	Min      string
+	Max      string
+	kind     fieldKind
+}
+
+// fieldCheck is one condition rejected by the generated Validate method
+type fieldCheck struct {
+	Cond    string
+	Message string
+}
+
+func newEntityField(field modules.FieldDefinition) (entityField, error) {
+	if !fieldNamePattern.MatchString(field.Name) {
+		return entityField{}, fmt.Errorf("invalid field name %q", field.Name)
+	}
+	if reservedColumns[field.Name] {
+		return entityField{}, fmt.Errorf("field %s is generated for every entity", field.Name)
+	}
+
+	baseType := strings.TrimPrefix(field.Type, "*")
+	info, ok := fieldTypes[baseType]
+	if !ok {
+		return entityField{}, fmt.Errorf("field %s has unsupported type %q", field.Name, field.Type)
+	}
+
+	f := entityField{
+		Name:    toCamelCase(field.Name),
+		Column:  field.Name,
+		Type:    field.Type,
+		SQLType: info.sqlType,
+		Pointer: baseType != field.Type,
+		kind:    info.kind,
+	}
+
+	for _, constraint := range field.Constraints {
+		name, value, _ := strings.Cut(strings.TrimSpace(constraint), "=")
+		switch name {
+		case ConstraintRequired:
+			f.Required = true
+		case ConstraintUnique:
+			f.Unique = true
+		case ConstraintMin, ConstraintMax:
+			if err := checkBound(f, name, value); err != nil {
+				return entityField{}, err
+			}
+			if name == ConstraintMin {
+				f.Min = value
+			} else {
+				f.Max = value
+			}
+		default:
+			return entityField{}, fmt.Errorf("field %s has unknown constraint %q", field.Name, constraint)
+		}
+	}
+
+	if f.kind == kindString && f.Max != "" {
+		f.SQLType = "VARCHAR(" + f.Max + ")"
+	}
+
+	json := f.Column
+	if f.Pointer {
+		json += ",omitempty"
+	}
+	f.Tag = fmt.Sprintf(`json:"%s" db:"%s"`, json, f.Column)
+	if rules := validateRules(field); rules != "" {
+		f.Tag += fmt.Sprintf(` validate:"%s"`, rules)
+	}
+
+	return f, nil
+}
+
+// checkBound validates a min or max constraint value for the field kind
+func checkBound(f entityField, name, value string) error {
+	var err error
+	switch f.kind {
+	case kindString:
+		_, err = strconv.ParseUint(value, 10, 32)
+	case kindNumber:
+		_, err = strconv.ParseFloat(value, 64)
+	default:
+		return fmt.Errorf("field %s of type %s does not support %s", f.Column, f.Type, name)
+	}
+	if err != nil {
+		return fmt.Errorf("field %s has invalid %s value %q", f.Column, name, value)
+	}
+	return nil
+}
+
+// validateRules renders the constraints as a validate tag, leaving out
+// unique which only the database enforces
+func validateRules(field modules.FieldDefinition) string {
+	var rules []string
+	for _, constraint := range field.Constraints {
+		if constraint != ConstraintUnique {
+			rules = append(rules, constraint)
+		}
+	}
+	return strings.Join(rules, ",")
+}
+
+// ColumnDefinition returns the column for the CREATE TABLE statement.
+// Non-pointer fields cannot scan NULL so their columns are NOT NULL.
+func (f entityField) ColumnDefinition() string {
+	def := f.Column + " " + f.SQLType
+	if !f.Pointer || f.Required {
+		def += " NOT NULL"
+	}
+	if f.Unique {
+		def += " UNIQUE"
+	}
+	return def
+}
+
+// Checks returns the conditions on e that make the entity invalid
+func (f entityField) Checks() []fieldCheck {
+	value := "e." + f.Name
+	guard := ""
+	if f.Pointer {
+		guard = value + " != nil && "
+		value = "*" + value
+	}
+
+	var checks []fieldCheck
+	if f.Required {
+		switch {
+		case f.Pointer:
+			checks = append(checks, fieldCheck{"e." + f.Name + " == nil", f.Column + " is required"})
+		case f.kind == kindString:
+			checks = append(checks, fieldCheck{value + ` == ""`, f.Column + " is required"})
+		case f.kind == kindNumber:
+			checks = append(checks, fieldCheck{value + " == 0", f.Column + " is required"})
+		case f.kind == kindTime:
+			checks = append(checks, fieldCheck{value + ".IsZero()", f.Column + " is required"})
+		}
+	}
+
+	if f.kind == kindString {
+		if f.Min != "" {
+			checks = append(checks, fieldCheck{guard + "len(" + value + ") < " + f.Min, f.Column + " must be at least " + f.Min + " characters long"})
+		}
+		if f.Max != "" {
+			checks = append(checks, fieldCheck{guard + "len(" + value + ") > " + f.Max, f.Column + " cannot exceed " + f.Max + " characters"})
+		}
+	}
+	if f.kind == kindNumber {
+		if f.Min != "" {
+			checks = append(checks, fieldCheck{guard + value + " < " + f.Min, f.Column + " must be at least " + f.Min})
+		}
+		if f.Max != "" {
+			checks = append(checks, fieldCheck{guard + value + " > " + f.Max, f.Column + " cannot exceed " + f.Max})
+		}
+	}
+	return checks
+}
+
+// Sample returns a Go expression for a value passing the field's checks
+func (f entityField) Sample() string {
+	var sample string
+	switch f.kind {
+	case kindString:
+		value := "test " + strings.ReplaceAll(f.Column, "_", " ")
+		if n, _ := strconv.Atoi(f.Min); len(value) < n {
+			value = strings.Repeat("a", n)
+		}
+		if n, err := strconv.Atoi(f.Max); err == nil && len(value) > n {
+			value = strings.Repeat("a", max(n, 1))
+		}
+		sample = strconv.Quote(value)
+	case kindNumber:
+		value := 1.0
+		if n, err := strconv.ParseFloat(f.Min, 64); err == nil && n > value {
+			value = n
+		}
+		if n, err := strconv.ParseFloat(f.Max, 64); err == nil && n < value {
+			value = n
+		}
+		sample = strconv.FormatFloat(value, 'f', -1, 64)
+	case kindBool:
+		sample = "true"
+	case kindTime:
+		sample = "time.Now()"
+	}
+
+	if f.Pointer {
+		baseType := strings.TrimPrefix(f.Type, "*")
+		return "func() " + f.Type + " { v := " + baseType + "(" + sample + "); return &v }()"
+	}
+	if f.kind == kindNumber {
+		return f.Type + "(" + sample + ")"
+	}
+	return sample
+}
+
+// TestValue returns a Go expression for a value the database accepts. Unique
+// fields derive it from n so repository tests can insert several rows.
+func (f entityField) TestValue() string {
+	if !f.Unique {
+		return f.Sample()
+	}
+
+	var value string
+	switch f.kind {
+	case kindString:
+		if n, err := strconv.Atoi(f.Max); err == nil {
+			value = fmt.Sprintf(`fmt.Sprintf("%%0%dd", n)`, min(n, 8))
+		} else {
+			value = fmt.Sprintf("fmt.Sprintf(%q, n)", "test "+strings.ReplaceAll(f.Column, "_", " ")+" %d")
+		}
+	case kindNumber:
+		value = "n"
+	case kindTime:
+		value = "time.Unix(int64(n), 0)"
+	default:
+		return f.Sample()
+	}
+
+	if f.Pointer {
+		baseType := strings.TrimPrefix(f.Type, "*")
+		return "func() " + f.Type + " { v := " + baseType + "(" + value + "); return &v }()"
+	}
+	if f.kind == kindNumber {
+		return f.Type + "(" + value + ")"
+	}
+	return value
+}
+
+// IsTime reports whether the field needs the time package
+func (f entityField) IsTime() bool {
+	return f.kind == kindTime
+}
+
+// lookupField is the string field the generated FindBy and SearchBy
+// methods query
+type lookupField struct {
+	entityField
+	Param string
+}
+
+// entityFields converts the config fields, falling back to the defaults
+func entityFields(config modules.EntityConfig) ([]entityField, error) {
+	definitions := config.Fields
+	if len(definitions) == 0 {
+		definitions = defaultFields
+	}
+
+	fields := make([]entityField, 0, len(definitions))
+	for _, definition := range definitions {
+		field, err := newEntityField(definition)
+		if err != nil {
+			return nil, err
+		}
+		fields = append(fields, field)
+	}
+	return fields, nil
+}
+
+// chooseLookup picks the field named name, else the first unique, required
+// or plain string field, and returns nil when there is no string field
+func chooseLookup(fields []entityField) *lookupField {
+	score := func(f entityField) int {
+		switch {
+		case f.Pointer || f.kind != kindString:
+			return 0
+		case f.Column == "name":
+			return 4
+		case f.Unique:
+			return 3
+		case f.Required:
+			return 2
+		default:
+			return 1
+		}
+	}
+
+	var best *entityField
+	for i := range fields {
+		if s := score(fields[i]); s > 0 && (best == nil || s > score(*best)) {
+			best = &fields[i]
+		}
+	}
+	if best == nil {
+		return nil
+	}
+
+	param := strings.ToLower(best.Name[:1]) + best.Name[1:]
+	if token.IsKeyword(param) {
+		param = "value"
+	}
+	return &lookupField{entityField: *best, Param: param}
+}
+
+// columnSet holds the column lists the generated repository queries use
+type columnSet struct {
+	Definitions   []string // field columns of the CREATE TABLE statement
+	Select        string
+	Scan          []string // Go field names in Select order
+	Insert        string
+	Placeholders  string
+	InsertValues  []string
+	Update        string
+	UpdateValues  []string
+	IDPlaceholder string
+	LiveIndex     string // column of the partial index over rows not soft deleted
+}
+
+func newColumnSet(config modules.EntityConfig, fields []entityField, versioned bool) columnSet {
+	var set columnSet
+	selectColumns := []string{"id"}
+	set.Scan = []string{"ID"}
+
+	var insertColumns, updateColumns []string
+	for i, field := range fields {
+		definition := field.ColumnDefinition()
+		if i < len(fields)-1 {
+			definition += ","
+		}
+		set.Definitions = append(set.Definitions, definition)
+
+		selectColumns = append(selectColumns, field.Column)
+		set.Scan = append(set.Scan, field.Name)
+		insertColumns = append(insertColumns, field.Column)
+		set.InsertValues = append(set.InsertValues, field.Name)
+		updateColumns = append(updateColumns, field.Column)
+		set.UpdateValues = append(set.UpdateValues, field.Name)
+	}
+
+	if config.Timestamps {
+		selectColumns = append(selectColumns, "created_at", "updated_at")
+		set.Scan = append(set.Scan, "CreatedAt", "UpdatedAt")
+		insertColumns = append(insertColumns, "created_at", "updated_at")
+		set.InsertValues = append(set.InsertValues, "CreatedAt", "UpdatedAt")
+		updateColumns = append(updateColumns, "updated_at")
+		set.UpdateValues = append(set.UpdateValues, "UpdatedAt")
+	}
+	if config.SoftDelete {
+		// deleted_at is a TIMESTAMPTZ, read as Unix seconds like the other timestamps
+		selectColumns = append(selectColumns, "CAST(EXTRACT(EPOCH FROM deleted_at) AS BIGINT) AS deleted_at")
+		set.Scan = append(set.Scan, "DeletedAt")
+
+		set.LiveIndex = "id"
+		for _, field := range fields {
+			if field.Column == "is_active" {
+				set.LiveIndex = "is_active"
+			}
+		}
+	}
+
+	placeholders := make([]string, len(insertColumns))
+	for i := range insertColumns {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	assignments := make([]string, len(updateColumns))
+	for i, column := range updateColumns {
+		assignments[i] = fmt.Sprintf("%s = $%d", column, i+1)
+	}
+	if versioned {
+		assignments = append(assignments, "version = version + 1")
+	}
+
+	set.Select = strings.Join(selectColumns, ", ")
+	set.Insert = strings.Join(insertColumns, ", ")
+	set.Placeholders = strings.Join(placeholders, ", ")
+	set.Update = strings.Join(assignments, ", ")
+	set.IDPlaceholder = fmt.Sprintf("$%d", len(updateColumns)+1)
+	return set
+}
+
+// entityChecks collects the Validate conditions of all fields
+func entityChecks(fields []entityField) []fieldCheck {
+	var checks []fieldCheck
+	for _, field := range fields {
+		checks = append(checks, field.Checks()...)
+	}
+	return checks
+}
+
+// validSamples returns the fields a valid entity literal has to set
+func validSamples(fields []entityField) []entityField {
+	var samples []entityField
+	for _, field := range fields {
+		if len(field.Checks()) > 0 {
+			samples = append(samples, field)
+		}
+	}
+	return samples
+}
+
+// anyUnique reports whether any of the fields has a unique column
+func anyUnique(fields []entityField) bool {
+	for _, field := range fields {
+		if field.Unique {
+			return true
+		}
+	}
+	return false
+}
+
+// anyUniqueString reports whether any TestValue needs the fmt package
+func anyUniqueString(fields []entityField) bool {
+	for _, field := range fields {
+		if field.Unique && field.kind == kindString {
+			return true
+		}
+	}
+	return false
+}
+
+// anyTime reports whether any of the fields needs the time package
+func anyTime(fields []entityField) bool {
+	for _, field := range fields {
+		if field.IsTime() {
+			return true
+		}
+	}
+	return false
+}
+
+// toCamelCase converts snake_case to an exported Go name
+func toCamelCase(str string) string {
+	var result strings.Builder
+	for _, part := range strings.Split(str, "_") {
+		if part == "" {
+			continue
+		}
+		if part == "id" {
+			result.WriteString("ID")
+			continue
+		}
+		result.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return result.String()
+}
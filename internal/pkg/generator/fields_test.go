@@ -0,0 +1,167 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestParseFields(t *testing.T) {
+	t.Run("should parse name, type and constraints", func(t *testing.T) {
+		fields, err := ParseFields("title:string:required|max=200, price:float64,publishedAt:*time.Time")
+		require.NoError(t, err)
+
+		assert.Equal(t, []modules.FieldDefinition{
+			{Name: "title", Type: "string", Constraints: []string{"required", "max=200"}},
+			{Name: "price", Type: "float64"},
+			{Name: "published_at", Type: "*time.Time"},
+		}, fields)
+	})
+
+	t.Run("should reject invalid fields", func(t *testing.T) {
+		for _, spec := range []string{
+			"",
+			"title",
+			":string",
+			"title:text",
+			"title:string:indexed",
+			"title:string:max=ten",
+			"active:bool:min=1",
+			"created_at:int64",
+			"9lives:int",
+			"title:string,title:string",
+		} {
+			_, err := ParseFields(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+
+	t.Run("should derive the validation config", func(t *testing.T) {
+		fields, err := ParseFields("title:string:required|unique|max=200,views:int")
+		require.NoError(t, err)
+
+		validation := FieldValidation(fields)
+		assert.Equal(t, []string{"title"}, validation.Required)
+		assert.Equal(t, map[string]string{"title": "required,max=200"}, validation.Rules)
+	})
+}
+
+func TestGenerateWithFields(t *testing.T) {
+	generate := func(t *testing.T, config modules.EntityConfig) string {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+
+		require.NoError(t, g.GenerateModule(config))
+		require.NoError(t, g.GenerateTests(config))
+		return basePath
+	}
+
+	fields, err := ParseFields("title:string:required,body:string,views:int,published_at:*time.Time")
+	require.NoError(t, err)
+	config := modules.EntityConfig{Name: "Article", TableName: "articles", Timestamps: true, Fields: fields}
+
+	t.Run("should generate the declared fields throughout the module", func(t *testing.T) {
+		basePath := generate(t, config)
+
+		entity := readGenerated(t, basePath, "internal/domain/entities/article.go")
+		assert.Contains(t, entity, "Title       string     `json:\"title\" db:\"title\" validate:\"required\"`")
+		assert.Contains(t, entity, "Views       int        `json:\"views\" db:\"views\"`")
+		assert.Contains(t, entity, "PublishedAt *time.Time `json:\"published_at,omitempty\" db:\"published_at\"`")
+		assert.Contains(t, entity, `if e.Title == "" {`)
+		assert.Contains(t, entity, `"time"`)
+		assert.NotContains(t, entity, "Description")
+
+		module := readGenerated(t, basePath, "internal/modules/article_module.go")
+		assert.Contains(t, module, "title TEXT NOT NULL,\n\t\tbody TEXT NOT NULL,\n\t\tviews BIGINT NOT NULL,\n\t\tpublished_at TIMESTAMPTZ\n\t)")
+		assert.Contains(t, module, `articleGroup.GET("/title/:title", handler.FindByTitle)`)
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/article_repository_impl.go")
+		assert.Contains(t, repository, "INSERT INTO articles (title, body, views, published_at, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6) RETURNING id")
+		assert.Contains(t, repository, "UPDATE articles SET title = $1, body = $2, views = $3, published_at = $4, updated_at = $5 WHERE id = $6")
+		assert.Contains(t, repository, "SELECT id, title, body, views, published_at, created_at, updated_at FROM articles WHERE title = $1")
+
+		test := readGenerated(t, basePath, "internal/domain/entities/article_test.go")
+		assert.Contains(t, test, `Title: "test title",`)
+
+		for _, file := range []string{
+			"internal/domain/entities/article.go",
+			"internal/domain/entities/article_test.go",
+			"internal/database/repositories/article_repository.go",
+			"internal/database/repositories/article_repository_impl.go",
+			"internal/domain/services/article_service.go",
+			"internal/domain/services/article_service_impl.go",
+			"internal/api/handlers/article_handler.go",
+			"internal/modules/article_module.go",
+		} {
+			_, err := parser.ParseFile(token.NewFileSet(), file, readGenerated(t, basePath, file), parser.AllErrors)
+			assert.NoError(t, err, file)
+		}
+	})
+
+	t.Run("should check min and max constraints", func(t *testing.T) {
+		fields, err := ParseFields("code:string:required|unique|min=3|max=12,stock:int:min=1")
+		require.NoError(t, err)
+
+		basePath := generate(t, modules.EntityConfig{Name: "Article", TableName: "articles", Fields: fields})
+
+		entity := readGenerated(t, basePath, "internal/domain/entities/article.go")
+		assert.Contains(t, entity, "if len(e.Code) < 3 {")
+		assert.Contains(t, entity, "if len(e.Code) > 12 {")
+		assert.Contains(t, entity, "if e.Stock < 1 {")
+
+		module := readGenerated(t, basePath, "internal/modules/article_module.go")
+		assert.Contains(t, module, "code VARCHAR(12) NOT NULL UNIQUE,")
+
+		service := readGenerated(t, basePath, "internal/domain/services/article_service_impl.go")
+		assert.Contains(t, service, "existing, err := s.repository.FindByCode(ctx, entity.Code)")
+	})
+
+	t.Run("should generate table-driven repository tests", func(t *testing.T) {
+		fields, err := ParseFields("code:string:required|unique|max=12,stock:int:unique,notes:string")
+		require.NoError(t, err)
+
+		basePath := generate(t, modules.EntityConfig{Name: "Article", TableName: "articles", Fields: fields})
+
+		file := "internal/database/repositories/article_repository_test.go"
+		test := readGenerated(t, basePath, file)
+		_, err = parser.ParseFile(token.NewFileSet(), file, test, parser.AllErrors)
+		require.NoError(t, err)
+
+		for _, method := range []string{"Create", "GetByID", "FindByCode", "Update", "Delete", "List", "FindByCodeLike"} {
+			assert.Contains(t, test, "func (suite *ArticleRepositoryTestSuite) TestArticleRepository_"+method+"() {")
+		}
+		assert.Equal(t, 7, strings.Count(test, `name:     "should handle context cancellation",`))
+		assert.Contains(t, test, `name: "should return error for duplicate unique fields",`)
+		assert.Contains(t, test, "code VARCHAR(12) NOT NULL UNIQUE,")
+		assert.Contains(t, test, `Code:  fmt.Sprintf("%08d", n),`)
+		assert.Contains(t, test, "Stock: int(n),")
+		assert.Contains(t, test, `Notes: "test notes",`)
+		assert.NotContains(t, test, "t.Skip(\"Repository tests not yet implemented\")")
+	})
+
+	t.Run("should leave out lookups without string fields", func(t *testing.T) {
+		fields, err := ParseFields("views:int:required")
+		require.NoError(t, err)
+
+		basePath := generate(t, modules.EntityConfig{Name: "Article", TableName: "articles", Fields: fields})
+
+		assert.NotContains(t, readGenerated(t, basePath, "internal/domain/services/article_service_impl.go"), "FindBy")
+		assert.NotContains(t, readGenerated(t, basePath, "internal/api/handlers/article_handler.go"), "SearchBy")
+		assert.NotContains(t, readGenerated(t, basePath, "internal/modules/article_module.go"), "/search")
+	})
+
+	t.Run("should generate name and description without fields", func(t *testing.T) {
+		basePath := generate(t, modules.EntityConfig{Name: "Article", TableName: "articles"})
+
+		module := readGenerated(t, basePath, "internal/modules/article_module.go")
+		assert.Contains(t, module, "name VARCHAR(100) NOT NULL UNIQUE,\n\t\tdescription TEXT NOT NULL\n")
+		assert.Contains(t, module, `articleGroup.GET("/name/:name", handler.FindByName)`)
+	})
+}
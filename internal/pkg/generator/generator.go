@@ -1,7 +1,9 @@
 package generator
 
 import (
+	"bytes"
 	"fmt"
+	"go/format"
 	"os"
 	"path/filepath"
 	"strings"
@@ -17,18 +19,54 @@ type Generator struct {
 	logger      *logger.Logger
 	basePath    string
 	packageName string
+	layout      GeneratorLayout
+	command     string // re-runs the generator from the Makefile
 	templates   map[string]*template.Template
+	// optimisticLocking adds a version column incremented by every UPDATE
+	optimisticLocking bool
+}
+
+// GeneratorOption configures optional Generator behavior
+type GeneratorOption func(*Generator)
+
+// WithLayout sets where generated files are written. The default is
+// StandardLayout.
+func WithLayout(layout GeneratorLayout) GeneratorOption {
+	return func(g *Generator) {
+		g.layout = layout
+	}
+}
+
+// WithCommand sets the command the generate-<entity> Makefile target runs.
+// The default runs cmd/generator with -all for the entity and its table.
+func WithCommand(command string) GeneratorOption {
+	return func(g *Generator) {
+		g.command = command
+	}
+}
+
+// WithOptimisticLocking adds a version INT NOT NULL DEFAULT 0 column to the
+// generated tables, incremented by every UPDATE of the repository
+func WithOptimisticLocking(enabled bool) GeneratorOption {
+	return func(g *Generator) {
+		g.optimisticLocking = enabled
+	}
 }
 
 // NewGenerator creates a new code generator
-func NewGenerator(logger *logger.Logger, basePath, packageName string) modules.Generator {
+func NewGenerator(logger *logger.Logger, basePath, packageName string, opts ...GeneratorOption) modules.Generator {
 	g := &Generator{
 		logger:      logger,
 		basePath:    basePath,
 		packageName: packageName,
+		layout:      StandardLayout{},
 		templates:   make(map[string]*template.Template),
 	}
 
+	for _, opt := range opts {
+		opt(g)
+	}
+
 	g.loadTemplates()
 	return g
 }
@@ -37,14 +75,8 @@ func NewGenerator(logger *logger.Logger, basePath, packageName string) modules.G
 func (g *Generator) GenerateEntity(config modules.EntityConfig) error {
 	g.logger.Info("Generating entity", "name", config.Name)
 
-	// Create entity directory
-	entityDir := filepath.Join(g.basePath, "internal", "domain", "entities")
-	if err := os.MkdirAll(entityDir, 0755); err != nil {
-		return fmt.Errorf("failed to create entity directory: %w", err)
-	}
-
 	// Generate entity file
-	entityFile := filepath.Join(entityDir, strings.ToLower(config.Name)+".go")
+	entityFile := g.layout.EntityPath(config)
 	if err := g.generateFromTemplate("entity", entityFile, config); err != nil {
 		return fmt.Errorf("failed to generate entity file: %w", err)
 	}
@@ -57,25 +89,33 @@ func (g *Generator) GenerateEntity(config modules.EntityConfig) error {
 func (g *Generator) GenerateRepository(config modules.EntityConfig) error {
 	g.logger.Info("Generating repository", "name", config.Name)
 
-	// Create repository directory
-	repoDir := filepath.Join(g.basePath, "internal", "database", "repositories")
-	if err := os.MkdirAll(repoDir, 0755); err != nil {
-		return fmt.Errorf("failed to create repository directory: %w", err)
-	}
-
 	// Generate repository interface
-	interfaceFile := filepath.Join(repoDir, strings.ToLower(config.Name)+"_repository.go")
+	interfaceFile := g.layout.RepositoryPath(config)
 	if err := g.generateFromTemplate("repository_interface", interfaceFile, config); err != nil {
 		return fmt.Errorf("failed to generate repository interface: %w", err)
 	}
 
 	// Generate repository implementation
-	implFile := filepath.Join(repoDir, strings.ToLower(config.Name)+"_repository_impl.go")
+	implFile := implPath(interfaceFile)
 	if err := g.generateFromTemplate("repository_impl", implFile, config); err != nil {
 		return fmt.Errorf("failed to generate repository implementation: %w", err)
 	}
 
-	g.logger.Info("Repository generated successfully", "interface", interfaceFile, "implementation", implFile)
+	// Generate the event store appending to the events table
+	if config.EventSourcing {
+		eventStoreFile := eventStorePath(interfaceFile)
+		if err := g.generateFromTemplate("event_store", eventStoreFile, config); err != nil {
+			return fmt.Errorf("failed to generate event store: %w", err)
+		}
+	}
+
+	// Generate the migrations creating and dropping its tables
+	upFile, downFile, err := g.generateMigrations(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate migrations: %w", err)
+	}
+
+	g.logger.Info("Repository generated successfully", "interface", interfaceFile, "implementation", implFile, "migration", upFile, "rollback", downFile)
 	return nil
 }
 
@@ -83,20 +123,14 @@ func (g *Generator) GenerateRepository(config modules.EntityConfig) error {
 func (g *Generator) GenerateService(config modules.EntityConfig) error {
 	g.logger.Info("Generating service", "name", config.Name)
 
-	// Create service directory
-	serviceDir := filepath.Join(g.basePath, "internal", "domain", "services")
-	if err := os.MkdirAll(serviceDir, 0755); err != nil {
-		return fmt.Errorf("failed to create service directory: %w", err)
-	}
-
 	// Generate service interface
-	interfaceFile := filepath.Join(serviceDir, strings.ToLower(config.Name)+"_service.go")
+	interfaceFile := g.layout.ServicePath(config)
 	if err := g.generateFromTemplate("service_interface", interfaceFile, config); err != nil {
 		return fmt.Errorf("failed to generate service interface: %w", err)
 	}
 
 	// Generate service implementation
-	implFile := filepath.Join(serviceDir, strings.ToLower(config.Name)+"_service_impl.go")
+	implFile := implPath(interfaceFile)
 	if err := g.generateFromTemplate("service_impl", implFile, config); err != nil {
 		return fmt.Errorf("failed to generate service implementation: %w", err)
 	}
@@ -109,19 +143,42 @@ func (g *Generator) GenerateService(config modules.EntityConfig) error {
 func (g *Generator) GenerateHandler(config modules.EntityConfig) error {
 	g.logger.Info("Generating handler", "name", config.Name)
 
-	// Create handler directory
-	handlerDir := filepath.Join(g.basePath, "internal", "api", "handlers")
-	if err := os.MkdirAll(handlerDir, 0755); err != nil {
-		return fmt.Errorf("failed to create handler directory: %w", err)
-	}
-
 	// Generate handler file
-	handlerFile := filepath.Join(handlerDir, strings.ToLower(config.Name)+"_handler.go")
+	handlerFile := g.layout.HandlerPath(config)
 	if err := g.generateFromTemplate("handler", handlerFile, config); err != nil {
 		return fmt.Errorf("failed to generate handler file: %w", err)
 	}
 
-	g.logger.Info("Handler generated successfully", "file", handlerFile)
+	// Describe the handler's endpoints for the Swagger docs
+	specFile, err := g.generateOpenAPI(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate OpenAPI spec: %w", err)
+	}
+
+	g.logger.Info("Handler generated successfully", "file", handlerFile, "openapi", specFile)
+
+	// Serve the entity over gRPC as well
+	if config.GRPC {
+		protoPath, serverPath, err := g.generateGRPC(config)
+		if err != nil {
+			return fmt.Errorf("failed to generate gRPC server: %w", err)
+		}
+		g.logger.Info("gRPC server generated successfully", "proto", protoPath, "file", serverPath)
+	}
+	return nil
+}
+
+// GenerateAdmin generates the admin handler serving the entity's admin
+// panel routes, next to the handler it builds on
+func (g *Generator) GenerateAdmin(config modules.EntityConfig) error {
+	g.logger.Info("Generating admin handler", "name", config.Name)
+
+	adminFile := adminHandlerFile(g.layout, config)
+	if err := g.generateFromTemplate("admin_handler", adminFile, config); err != nil {
+		return fmt.Errorf("failed to generate admin handler: %w", err)
+	}
+
+	g.logger.Info("Admin handler generated successfully", "file", adminFile)
 	return nil
 }
 
@@ -146,13 +203,14 @@ func (g *Generator) GenerateModule(config modules.EntityConfig) error {
 		return err
 	}
 
-	// Generate module file
-	moduleDir := filepath.Join(g.basePath, "internal", "modules")
-	if err := os.MkdirAll(moduleDir, 0755); err != nil {
-		return fmt.Errorf("failed to create module directory: %w", err)
+	if config.Admin {
+		if err := g.GenerateAdmin(config); err != nil {
+			return err
+		}
 	}
 
-	moduleFile := filepath.Join(moduleDir, strings.ToLower(config.Name)+"_module.go")
+	// Generate module file
+	moduleFile := g.layout.ModulePath(config)
 	if err := g.generateFromTemplate("module", moduleFile, config); err != nil {
 		return fmt.Errorf("failed to generate module file: %w", err)
 	}
@@ -165,79 +223,152 @@ func (g *Generator) GenerateModule(config modules.EntityConfig) error {
 func (g *Generator) GenerateTests(config modules.EntityConfig) error {
 	g.logger.Info("Generating tests", "name", config.Name)
 
-	// Generate entity tests
-	entityTestDir := filepath.Join(g.basePath, "internal", "domain", "entities")
-	entityTestFile := filepath.Join(entityTestDir, strings.ToLower(config.Name)+"_test.go")
-	if err := g.generateFromTemplate("entity_test", entityTestFile, config); err != nil {
-		return fmt.Errorf("failed to generate entity tests: %w", err)
+	tests := []struct {
+		component string
+		template  string
+	}{
+		{ComponentEntity, "entity_test"},
+		{ComponentRepository, "repository_test"},
+		{ComponentService, "service_test"},
+		{ComponentHandler, "handler_test"},
 	}
 
-	// Generate repository tests
-	repoTestDir := filepath.Join(g.basePath, "internal", "database", "repositories")
-	repoTestFile := filepath.Join(repoTestDir, strings.ToLower(config.Name)+"_repository_test.go")
-	if err := g.generateFromTemplate("repository_test", repoTestFile, config); err != nil {
-		return fmt.Errorf("failed to generate repository tests: %w", err)
+	for _, test := range tests {
+		testFile := g.layout.TestPath(config, test.component)
+		if err := g.generateFromTemplate(test.template, testFile, config); err != nil {
+			return fmt.Errorf("failed to generate %s tests: %w", test.component, err)
+		}
 	}
 
-	// Generate service tests
-	serviceTestDir := filepath.Join(g.basePath, "internal", "domain", "services")
-	serviceTestFile := filepath.Join(serviceTestDir, strings.ToLower(config.Name)+"_service_test.go")
-	if err := g.generateFromTemplate("service_test", serviceTestFile, config); err != nil {
-		return fmt.Errorf("failed to generate service tests: %w", err)
-	}
+	g.logger.Info("Tests generated successfully", "name", config.Name)
+	return nil
+}
 
-	// Generate handler tests
-	handlerTestDir := filepath.Join(g.basePath, "internal", "api", "handlers")
-	handlerTestFile := filepath.Join(handlerTestDir, strings.ToLower(config.Name)+"_handler_test.go")
-	if err := g.generateFromTemplate("handler_test", handlerTestFile, config); err != nil {
-		return fmt.Errorf("failed to generate handler tests: %w", err)
+// GenerateIntegrationTests generates a repository test suite running the
+// CRUD operations against a PostgreSQL container started with dockertest.
+// It is built with the integration tag, so go test ./... skips it.
+func (g *Generator) GenerateIntegrationTests(config modules.EntityConfig) error {
+	g.logger.Info("Generating integration tests", "name", config.Name)
+
+	testFile := integrationTestFile(g.layout, config)
+	if err := g.generateFromTemplate("integration_test", testFile, config); err != nil {
+		return fmt.Errorf("failed to generate integration tests: %w", err)
 	}
 
-	g.logger.Info("Tests generated successfully", "name", config.Name)
+	g.logger.Info("Integration tests generated successfully", "file", testFile)
 	return nil
 }
 
 // Helper methods
 
-func (g *Generator) generateFromTemplate(templateName, outputFile string, config modules.EntityConfig) error {
-	tmpl, exists := g.templates[templateName]
-	if !exists {
-		return fmt.Errorf("template %s not found", templateName)
+// generateFromTemplate writes a Go file at a path relative to the base path
+func (g *Generator) generateFromTemplate(templateName, relPath string, config modules.EntityConfig) error {
+	rendered, err := g.renderTemplate(templateName, relPath, config)
+	if err != nil {
+		return err
 	}
 
-	// Create output file
-	file, err := os.Create(outputFile)
+	// Field declarations are aligned by gofmt
+	source, err := format.Source(rendered)
 	if err != nil {
-		return fmt.Errorf("failed to create file %s: %w", outputFile, err)
+		return fmt.Errorf("failed to format %s: %w", relPath, err)
+	}
+
+	return g.writeFile(relPath, source)
+}
+
+// renderTemplate executes a template for the file at relPath
+func (g *Generator) renderTemplate(templateName, relPath string, config modules.EntityConfig) ([]byte, error) {
+	tmpl, exists := g.templates[templateName]
+	if !exists {
+		return nil, fmt.Errorf("template %s not found", templateName)
 	}
-	defer file.Close()
 
 	// Prepare template data
-	data := g.prepareTemplateData(config)
+	data, err := g.prepareTemplateData(config, filepath.Dir(relPath))
+	if err != nil {
+		return nil, err
+	}
 
 	// Execute template
-	if err := tmpl.Execute(file, data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// writeFile writes data at a path relative to the base path
+func (g *Generator) writeFile(relPath string, data []byte) error {
+	outputFile := filepath.Join(g.basePath, relPath)
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return fmt.Errorf("failed to create directory for %s: %w", outputFile, err)
+	}
+
+	if err := os.WriteFile(outputFile, data, 0644); err != nil {
+		return fmt.Errorf("failed to create file %s: %w", outputFile, err)
 	}
 
 	return nil
 }
 
-func (g *Generator) prepareTemplateData(config modules.EntityConfig) map[string]interface{} {
+// prepareTemplateData builds the data for a file generated into dir
+func (g *Generator) prepareTemplateData(config modules.EntityConfig, dir string) (map[string]interface{}, error) {
+	imports, qualifiers := packageRefs(g.layout, config, g.packageName, dir)
+
+	fields, err := entityFields(config)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fields for %s: %w", config.Name, err)
+	}
+
+	relations, err := resolveRelations(g.layout, g.basePath, config)
+	if err != nil {
+		return nil, err
+	}
+
+	var proto *protoSpec
+	if config.GRPC {
+		if proto, err = buildProtoSpec(g.layout, config, g.packageName, fields); err != nil {
+			return nil, fmt.Errorf("invalid gRPC fields for %s: %w", config.Name, err)
+		}
+	}
+
 	return map[string]interface{}{
 		"PackageName":   g.packageName,
+		"Package":       filepath.Base(dir),
+		"Imports":       imports,
+		"Refs":          qualifiers,
 		"EntityName":    config.Name,
 		"EntityLower":   strings.ToLower(config.Name),
 		"TableName":     config.TableName,
 		"SoftDelete":    config.SoftDelete,
 		"Timestamps":    config.Timestamps,
+		"Versioned":     g.optimisticLocking,
+		"MultiTenant":   config.MultiTenant,
+		"EventSourcing": config.EventSourcing,
+		"EventsTable":   toSnakeCase(config.Name) + "_events",
 		"Cache":         config.Cache,
 		"Validation":    config.Validation,
 		"Permissions":   config.Permissions,
 		"Routes":        config.Routes,
+		"GRPC":          config.GRPC,
+		"Admin":         config.Admin,
+		"AdminRoles":    adminRoles(config.Permissions),
+		"Proto":         proto,
+		"Fields":        fields,
+		"Lookup":        chooseLookup(fields),
+		"Columns":       newColumnSet(config, fields, g.optimisticLocking),
+		"Checks":        entityChecks(fields),
+		"Samples":       validSamples(fields),
+		"ManyToMany":    relations,
+		"FieldsUseTime": anyTime(fields),
+		"TestsUseTime":  anyTime(validSamples(fields)),
+		"HasUnique":     anyUnique(fields),
+		"TestsUseFmt":   anyUniqueString(fields),
 		"GeneratedAt":   time.Now().Format(time.RFC3339),
 		"Generator":     "go-template enterprise generator",
-	}
+	}, nil
 }
 
 func (g *Generator) loadTemplates() {
@@ -247,9 +378,15 @@ func (g *Generator) loadTemplates() {
 	g.templates["service_interface"] = template.Must(template.New("service_interface").Parse(serviceInterfaceTemplate))
 	g.templates["service_impl"] = template.Must(template.New("service_impl").Parse(serviceImplTemplate))
 	g.templates["handler"] = template.Must(template.New("handler").Parse(handlerTemplate))
+	g.templates["admin_handler"] = template.Must(template.New("admin_handler").Parse(adminHandlerTemplate))
+	g.templates["proto"] = template.Must(template.New("proto").Parse(protoTemplate))
+	g.templates["grpc_server"] = template.Must(template.New("grpc_server").Parse(grpcServerTemplate))
 	g.templates["module"] = template.Must(template.New("module").Parse(moduleTemplate))
+	g.templates["event_store"] = template.Must(template.New("event_store").Parse(eventStoreTemplate))
+	g.templates["migration_up"] = template.Must(template.New("migration_up").Parse(migrationUpTemplate))
 	g.templates["entity_test"] = template.Must(template.New("entity_test").Parse(entityTestTemplate))
 	g.templates["repository_test"] = template.Must(template.New("repository_test").Parse(repositoryTestTemplate))
 	g.templates["service_test"] = template.Must(template.New("service_test").Parse(serviceTestTemplate))
 	g.templates["handler_test"] = template.Must(template.New("handler_test").Parse(handlerTestTemplate))
+	g.templates["integration_test"] = template.Must(template.New("integration_test").Parse(integrationTestTemplate))
 }
\ No newline at end of file
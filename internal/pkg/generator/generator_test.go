@@ -0,0 +1,240 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestGenerateModuleMultiTenant(t *testing.T) {
+	generate := func(t *testing.T, config modules.EntityConfig) string {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+
+		require.NoError(t, g.GenerateModule(config))
+
+		data, err := os.ReadFile(filepath.Join(basePath, "internal", "modules", "order_module.go"))
+		require.NoError(t, err)
+		return string(data)
+	}
+
+	t.Run("should emit RLS setup for multi-tenant entities", func(t *testing.T) {
+		source := generate(t, modules.EntityConfig{Name: "Order", TableName: "orders", MultiTenant: true})
+
+		assert.Contains(t, source, `"github.com/example/app/internal/database/postgres"`)
+		assert.Contains(t, source, "tenant_id UUID NOT NULL")
+		assert.Contains(t, source, `postgres.NewRLSManager(db).EnableRLS(context.Background(), "orders")`)
+	})
+
+	t.Run("should scope repository queries to the tenant", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		config := modules.EntityConfig{Name: "Order", TableName: "orders", MultiTenant: true}
+		require.NoError(t, g.GenerateModule(config))
+		require.NoError(t, g.GenerateTests(config))
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+		assert.Contains(t, repository, "db *postgres.TenantDB")
+		assert.Contains(t, repository, "db: postgres.NewRLSManager(db).TenantDB(),")
+		assert.Contains(t, repository, "scanAll(rows *postgres.Rows)")
+
+		repositoryTest := readGenerated(t, basePath, "internal/database/repositories/order_repository_test.go")
+		assert.Contains(t, repositoryTest, "suite.ctx = postgres.WithTenant(suite.ctx, uuid.NewString())")
+		assert.NotContains(t, repositoryTest, "context.WithCancel(context.Background())")
+
+		// Rows inserted in the tenant transaction default to its tenant
+		ups, err := filepath.Glob(filepath.Join(basePath, "migrations", "postgres", "*_create_orders.up.sql"))
+		require.NoError(t, err)
+		require.Len(t, ups, 1)
+		up, err := os.ReadFile(ups[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(up), "tenant_id UUID NOT NULL DEFAULT NULLIF(current_setting('app.tenant_id', true), '')::uuid")
+	})
+
+	t.Run("should not emit RLS setup for single-tenant entities", func(t *testing.T) {
+		source := generate(t, modules.EntityConfig{Name: "Order", TableName: "orders"})
+
+		assert.NotContains(t, source, "internal/database/postgres")
+		assert.NotContains(t, source, "tenant_id")
+		assert.NotContains(t, source, "EnableRLS")
+	})
+}
+
+func TestGenerateModuleSoftDelete(t *testing.T) {
+	fields, err := ParseFields("name:string:required,is_active:bool")
+	require.NoError(t, err)
+
+	basePath := t.TempDir()
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+	require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders", SoftDelete: true, Fields: fields}))
+
+	t.Run("should scope reads to rows that are not deleted", func(t *testing.T) {
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+
+		selectColumns := "id, name, is_active, CAST(EXTRACT(EPOCH FROM deleted_at) AS BIGINT) AS deleted_at"
+		assert.Contains(t, repository, "SELECT "+selectColumns+" FROM orders WHERE id = $1 AND deleted_at IS NULL")
+		assert.Contains(t, repository, "SELECT "+selectColumns+" FROM orders WHERE deleted_at IS NULL ORDER BY id DESC")
+		assert.Contains(t, repository, "SELECT "+selectColumns+" FROM orders WHERE name ILIKE $1 AND deleted_at IS NULL")
+		assert.Contains(t, repository, "UPDATE orders SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL")
+	})
+
+	t.Run("should emit the deleted scopes, restore and permanent delete", func(t *testing.T) {
+		contract := readGenerated(t, basePath, "internal/database/repositories/order_repository.go")
+		for _, method := range []string{"ListWithDeleted(", "GetDeletedByID(", "Restore(", "PermanentDelete("} {
+			assert.Contains(t, contract, method)
+		}
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+		assert.Contains(t, repository, "FROM orders ORDER BY id DESC LIMIT $1 OFFSET $2")
+		assert.Contains(t, repository, "FROM orders WHERE id = $1 AND deleted_at IS NOT NULL")
+		assert.Contains(t, repository, "UPDATE orders SET deleted_at = NULL WHERE id = $1 AND deleted_at IS NOT NULL")
+		assert.Contains(t, repository, "DELETE FROM orders WHERE id = $1")
+	})
+
+	t.Run("should migrate a timestamp column with a partial index", func(t *testing.T) {
+		module := readGenerated(t, basePath, "internal/modules/order_module.go")
+
+		assert.Contains(t, module, "deleted_at TIMESTAMPTZ,")
+		assert.Contains(t, module, "CREATE INDEX IF NOT EXISTS idx_orders_is_active_live ON orders (is_active) WHERE deleted_at IS NULL")
+	})
+
+	t.Run("should leave hard deleting entities unscoped", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders", Fields: fields}))
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+		assert.NotContains(t, repository, "deleted_at")
+		assert.NotContains(t, repository, "Restore")
+	})
+}
+
+func TestGenerateModuleOptimisticLocking(t *testing.T) {
+	fields, err := ParseFields("name:string:required")
+	require.NoError(t, err)
+
+	generate := func(t *testing.T, enabled bool) string {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app", WithOptimisticLocking(enabled))
+		require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders", Fields: fields}))
+		return basePath
+	}
+
+	t.Run("should add a version column incremented by updates", func(t *testing.T) {
+		basePath := generate(t, true)
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+		assert.Contains(t, repository, "UPDATE orders SET name = $1, version = version + 1 WHERE id = $2")
+
+		module := readGenerated(t, basePath, "internal/modules/order_module.go")
+		assert.Contains(t, module, "version INT NOT NULL DEFAULT 0,")
+
+		ups, err := filepath.Glob(filepath.Join(basePath, "migrations", "postgres", "*_create_orders.up.sql"))
+		require.NoError(t, err)
+		require.Len(t, ups, 1)
+		up, err := os.ReadFile(ups[0])
+		require.NoError(t, err)
+		assert.Contains(t, string(up), "version INT NOT NULL DEFAULT 0,")
+	})
+
+	t.Run("should leave the table unversioned by default", func(t *testing.T) {
+		basePath := generate(t, false)
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/order_repository_impl.go")
+		assert.Contains(t, repository, "UPDATE orders SET name = $1 WHERE id = $2")
+		assert.NotContains(t, readGenerated(t, basePath, "internal/modules/order_module.go"), "version INT")
+	})
+}
+
+func TestGenerateModuleEventSourcing(t *testing.T) {
+	basePath := t.TempDir()
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+	require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders", Timestamps: true, EventSourcing: true}))
+
+	t.Run("should generate the event store", func(t *testing.T) {
+		store := readGenerated(t, basePath, "internal/database/repositories/order_repository_events.go")
+
+		assert.Contains(t, store, "Append(ctx context.Context, aggregateID uuid.UUID, eventType string, payload interface{}) error")
+		assert.Contains(t, store, "GetEvents(ctx context.Context, aggregateID uuid.UUID) ([]*entities.OrderEvent, error)")
+		assert.Contains(t, store, "GetEventsSince(ctx context.Context, aggregateID uuid.UUID, version int) ([]*entities.OrderEvent, error)")
+		assert.Contains(t, store, "COALESCE(MAX(version), 0) + 1 FROM order_events WHERE aggregate_id = $1")
+	})
+
+	t.Run("should record changes and replay them in the service", func(t *testing.T) {
+		service := readGenerated(t, basePath, "internal/domain/services/order_service_impl.go")
+
+		assert.Contains(t, service, "events repositories.OrderEventStore")
+		assert.Contains(t, service, "s.events.Append(ctx, entities.OrderAggregateID(entity.ID), entities.OrderCreated, entity)")
+		assert.Contains(t, service, "s.events.Append(ctx, entities.OrderAggregateID(id), entities.OrderDeleted")
+		assert.Contains(t, service, "func (s *orderService) Replay(ctx context.Context, aggregateID uuid.UUID) (*entities.Order, error)")
+
+		module := readGenerated(t, basePath, "internal/modules/order_module.go")
+		assert.Contains(t, module, "services.NewOrderService(repo, events, logger)")
+		assert.Contains(t, module, "CREATE TABLE IF NOT EXISTS order_events")
+	})
+
+	t.Run("should create and drop the events table in the migrations", func(t *testing.T) {
+		ups, err := filepath.Glob(filepath.Join(basePath, migrationsDir, "*_create_orders.up.sql"))
+		require.NoError(t, err)
+		require.Len(t, ups, 1)
+		up, err := os.ReadFile(ups[0])
+		require.NoError(t, err)
+		down, err := os.ReadFile(strings.TrimSuffix(ups[0], ".up.sql") + ".down.sql")
+		require.NoError(t, err)
+
+		for _, column := range []string{"aggregate_id UUID NOT NULL", "event_type VARCHAR(100) NOT NULL", "payload JSONB NOT NULL", "version INT NOT NULL", "occurred_at TIMESTAMPTZ NOT NULL", "UNIQUE (aggregate_id, version)"} {
+			assert.Contains(t, string(up), column)
+		}
+		assert.True(t, strings.HasPrefix(string(down), "DROP TABLE IF EXISTS order_events;"))
+	})
+
+	t.Run("should leave entities without event sourcing unchanged", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Order", TableName: "orders"}))
+
+		assert.NoFileExists(t, filepath.Join(basePath, "internal/database/repositories/order_repository_events.go"))
+		service := readGenerated(t, basePath, "internal/domain/services/order_service_impl.go")
+		assert.NotContains(t, service, "events")
+		assert.NotContains(t, service, "Replay")
+	})
+}
+
+func TestGenerateIntegrationTests(t *testing.T) {
+	fields, err := ParseFields("name:string:required|unique,price:float64")
+	require.NoError(t, err)
+	config := modules.EntityConfig{Name: "Order", TableName: "orders", Timestamps: true, Fields: fields}
+
+	t.Run("should generate a dockertest suite behind the integration build tag", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateIntegrationTests(config))
+
+		source := readGenerated(t, basePath, "internal/database/repositories/order_integration_test.go")
+		assert.True(t, strings.HasPrefix(source, "//go:build integration\n"))
+		assert.Contains(t, source, "package repositories")
+		assert.Contains(t, source, `"github.com/ory/dockertest/v3"`)
+		assert.Contains(t, source, "CREATE TABLE orders (")
+		assert.Contains(t, source, "name TEXT NOT NULL UNIQUE")
+		assert.Contains(t, source, "suite.pool.Purge(suite.resource)")
+		for _, call := range []string{"Create(", "GetByID(", "List(", "Update(", "Delete("} {
+			assert.Contains(t, source, "suite.repository."+call)
+		}
+	})
+
+	t.Run("should not generate them with the unit tests", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateTests(config))
+
+		_, err := os.Stat(filepath.Join(basePath, "internal", "database", "repositories", "order_integration_test.go"))
+		assert.True(t, os.IsNotExist(err))
+	})
+}
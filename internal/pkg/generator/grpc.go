@@ -0,0 +1,209 @@
+package generator
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// protoScalars maps field Go types to their proto type and the Go type
+// protoc-gen-go generates for it
+var protoScalars = map[string]struct {
+	protoType string
+	goType    string
+}{
+	"string":  {"string", "string"},
+	"bool":    {"bool", "bool"},
+	"int":     {"int64", "int64"},
+	"int32":   {"int32", "int32"},
+	"int64":   {"int64", "int64"},
+	"uint":    {"uint64", "uint64"},
+	"uint32":  {"uint32", "uint32"},
+	"uint64":  {"uint64", "uint64"},
+	"float32": {"float", "float32"},
+	"float64": {"double", "float64"},
+}
+
+// protoSpec is what the proto and gRPC server templates render an entity with
+type protoSpec struct {
+	Package   string // proto package, e.g. order_item.v1
+	GoPackage string // go_package option
+	Import    string // import path of the package protoc generates
+	Name      string // name of that package, e.g. orderitempb
+	File      string // path of the proto file from the handler directory
+	Message   string // field holding the entity input in requests
+	MessageGo string // Go name protoc-gen-go gives that field
+	// EntityFields are the fields of the entity message, ID and timestamps
+	// included; Fields those of the input message
+	EntityFields []protoField
+	Fields       []protoField
+	UsesTime     bool
+}
+
+// protoField is a message field with the statements converting it between
+// the entity (e) and the message (msg when returned, in when received)
+type protoField struct {
+	Column    string
+	Type      string
+	Number    int
+	ToProto   string
+	FromProto string
+}
+
+// protoFile is the proto definition of the entity's gRPC service, written
+// into the directory protoc generates its Go package into
+func protoFile(layout GeneratorLayout, config modules.EntityConfig) string {
+	return filepath.Join(filepath.Dir(layout.HandlerPath(config)), protoGoPackage(config), strings.ToLower(config.Name)+".proto")
+}
+
+// grpcServerFile is the gRPC server, written next to the handler
+func grpcServerFile(layout GeneratorLayout, config modules.EntityConfig) string {
+	return filepath.Join(filepath.Dir(layout.HandlerPath(config)), entityFile(config, "_grpc"))
+}
+
+func protoGoPackage(config modules.EntityConfig) string {
+	return strings.ToLower(config.Name) + "pb"
+}
+
+// generateGRPC writes the proto definition of the entity's service and the
+// server implementing it over the entity's service
+func (g *Generator) generateGRPC(config modules.EntityConfig) (string, string, error) {
+	protoPath := protoFile(g.layout, config)
+	proto, err := g.renderTemplate("proto", protoPath, config)
+	if err != nil {
+		return "", "", err
+	}
+	if err := g.writeFile(protoPath, proto); err != nil {
+		return "", "", err
+	}
+
+	serverPath := grpcServerFile(g.layout, config)
+	if err := g.generateFromTemplate("grpc_server", serverPath, config); err != nil {
+		return "", "", err
+	}
+
+	return protoPath, serverPath, nil
+}
+
+// buildProtoSpec mirrors the entity's fields in proto messages
+func buildProtoSpec(layout GeneratorLayout, config modules.EntityConfig, packageName string, fields []entityField) (*protoSpec, error) {
+	handlerDir := filepath.Dir(layout.HandlerPath(config))
+	goPackage := protoGoPackage(config)
+	message := toSnakeCase(config.Name)
+
+	spec := &protoSpec{
+		Package:   message + ".v1",
+		Import:    path.Join(packageName, filepath.ToSlash(handlerDir), goPackage),
+		Name:      goPackage,
+		File:      path.Join(goPackage, strings.ToLower(config.Name)+".proto"),
+		Message:   message,
+		MessageGo: protoGoName(message),
+	}
+	spec.GoPackage = spec.Import + ";" + goPackage
+
+	spec.EntityFields = append(spec.EntityFields, protoField{
+		Column:  "id",
+		Type:    "uint64",
+		ToProto: "msg.Id = uint64(e.ID)",
+	})
+	if config.Timestamps {
+		spec.EntityFields = append(spec.EntityFields,
+			protoField{Column: "created_at", Type: "int64", ToProto: "msg.CreatedAt = e.CreatedAt"},
+			protoField{Column: "updated_at", Type: "int64", ToProto: "msg.UpdatedAt = e.UpdatedAt"},
+		)
+	}
+	if config.SoftDelete {
+		spec.EntityFields = append(spec.EntityFields, protoField{
+			Column:  "deleted_at",
+			Type:    "optional int64",
+			ToProto: "msg.DeletedAt = e.DeletedAt",
+		})
+	}
+
+	for _, field := range fields {
+		converted, err := newProtoField(field)
+		if err != nil {
+			return nil, err
+		}
+		spec.UsesTime = spec.UsesTime || field.kind == kindTime
+		spec.Fields = append(spec.Fields, converted)
+		spec.EntityFields = append(spec.EntityFields, converted)
+	}
+
+	for i := range spec.EntityFields {
+		spec.EntityFields[i].Number = i + 1
+	}
+	for i := range spec.Fields {
+		spec.Fields[i].Number = i + 1
+	}
+	return spec, nil
+}
+
+// newProtoField maps a field to a scalar, or to google.protobuf.Timestamp
+// for times. Nullable scalars become optional fields.
+func newProtoField(field entityField) (protoField, error) {
+	name := protoGoName(field.Column)
+	baseType := strings.TrimPrefix(field.Type, "*")
+	converted := protoField{Column: field.Column}
+
+	if field.kind == kindTime {
+		converted.Type = "google.protobuf.Timestamp"
+		if field.Pointer {
+			converted.ToProto = fmt.Sprintf("if e.%[1]s != nil {\nmsg.%[2]s = timestamppb.New(*e.%[1]s)\n}", field.Name, name)
+			converted.FromProto = fmt.Sprintf("if in.%[2]s != nil {\nv := in.%[2]s.AsTime()\ne.%[1]s = &v\n}", field.Name, name)
+		} else {
+			converted.ToProto = fmt.Sprintf("msg.%s = timestamppb.New(e.%s)", name, field.Name)
+			converted.FromProto = fmt.Sprintf("e.%s = in.Get%s().AsTime()", field.Name, name)
+		}
+		return converted, nil
+	}
+
+	scalar, ok := protoScalars[baseType]
+	if !ok {
+		return protoField{}, fmt.Errorf("field %s: type %s has no proto equivalent", field.Column, field.Type)
+	}
+	converted.Type = scalar.protoType
+
+	switch {
+	case field.Pointer && scalar.goType == baseType:
+		converted.Type = "optional " + scalar.protoType
+		converted.ToProto = fmt.Sprintf("msg.%s = e.%s", name, field.Name)
+		converted.FromProto = fmt.Sprintf("e.%s = in.%s", field.Name, name)
+	case field.Pointer:
+		converted.Type = "optional " + scalar.protoType
+		converted.ToProto = fmt.Sprintf("if e.%[1]s != nil {\nv := %[3]s(*e.%[1]s)\nmsg.%[2]s = &v\n}", field.Name, name, scalar.goType)
+		converted.FromProto = fmt.Sprintf("if in.%[2]s != nil {\nv := %[3]s(*in.%[2]s)\ne.%[1]s = &v\n}", field.Name, name, baseType)
+	case scalar.goType == baseType:
+		converted.ToProto = fmt.Sprintf("msg.%s = e.%s", name, field.Name)
+		converted.FromProto = fmt.Sprintf("e.%s = in.%s", field.Name, name)
+	default:
+		converted.ToProto = fmt.Sprintf("msg.%s = %s(e.%s)", name, scalar.goType, field.Name)
+		converted.FromProto = fmt.Sprintf("e.%s = %s(in.%s)", field.Name, baseType, name)
+	}
+	return converted, nil
+}
+
+// protoGoName returns the Go name protoc-gen-go gives a snake_case field:
+// published_at becomes PublishedAt, user_id UserId and v2beta V2Beta
+func protoGoName(column string) string {
+	var b strings.Builder
+	for i := 0; i < len(column); i++ {
+		c := column[i]
+		switch {
+		case c == '_' && i+1 < len(column) && isLower(column[i+1]):
+			// Dropped, the next letter is capitalized
+		case isLower(c) && (i == 0 || !isLower(column[i-1])):
+			b.WriteByte(c - 'a' + 'A')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func isLower(c byte) bool {
+	return 'a' <= c && c <= 'z'
+}
@@ -0,0 +1,91 @@
+package generator
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestGenerateGRPC(t *testing.T) {
+	fields, err := ParseFields("title:string:required,views:int,rank:*int,published_at:*time.Time")
+	require.NoError(t, err)
+
+	basePath := t.TempDir()
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+	require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Article", TableName: "articles", Timestamps: true, GRPC: true, Fields: fields}))
+
+	t.Run("should mirror the entity in the proto messages", func(t *testing.T) {
+		proto := readGenerated(t, basePath, "internal/api/handlers/articlepb/article.proto")
+
+		assert.Contains(t, proto, "package article.v1;")
+		assert.Contains(t, proto, `import "google/protobuf/timestamp.proto";`)
+		assert.Contains(t, proto, `option go_package = "github.com/example/app/internal/api/handlers/articlepb;articlepb";`)
+		for _, rpc := range []string{
+			"rpc CreateArticle(CreateArticleRequest) returns (Article);",
+			"rpc GetArticle(GetArticleRequest) returns (Article);",
+			"rpc UpdateArticle(UpdateArticleRequest) returns (Article);",
+			"rpc DeleteArticle(DeleteArticleRequest) returns (DeleteArticleResponse);",
+			"rpc ListArticle(ListArticleRequest) returns (ListArticleResponse);",
+		} {
+			assert.Contains(t, proto, rpc)
+		}
+		assert.Contains(t, proto, "message Article {\n  uint64 id = 1;\n  int64 created_at = 2;\n  int64 updated_at = 3;\n  string title = 4;\n  int64 views = 5;\n  optional int64 rank = 6;\n  google.protobuf.Timestamp published_at = 7;\n}")
+		assert.Contains(t, proto, "message ArticleInput {\n  string title = 1;\n  int64 views = 2;\n  optional int64 rank = 3;\n  google.protobuf.Timestamp published_at = 4;\n}")
+		assert.Contains(t, proto, "repeated Article articles = 1;")
+	})
+
+	t.Run("should implement the service over the entity service", func(t *testing.T) {
+		server := readGenerated(t, basePath, "internal/api/handlers/article_grpc.go")
+
+		assert.Contains(t, server, `"github.com/example/app/internal/api/handlers/articlepb"`)
+		assert.Contains(t, server, "//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative articlepb/article.proto")
+		assert.Contains(t, server, "articlepb.UnimplementedArticleServiceServer")
+		assert.Contains(t, server, "s.service.Update(ctx, uint(req.GetId()), articleFromProto(req.GetArticle()))")
+		assert.Contains(t, server, "msg.Views = int64(e.Views)")
+		assert.Contains(t, server, "msg.PublishedAt = timestamppb.New(*e.PublishedAt)")
+		assert.Contains(t, server, "e.Rank = &v")
+	})
+
+	t.Run("should register the server from the module", func(t *testing.T) {
+		module := readGenerated(t, basePath, "internal/modules/article_module.go")
+
+		assert.Contains(t, module, "var _ modules.GRPCModule = (*ArticleModule)(nil)")
+		assert.Contains(t, module, "func (m *ArticleModule) RegisterGRPC(server grpc.ServiceRegistrar, deps *modules.Dependencies) error")
+		assert.Contains(t, module, "handlers.RegisterArticleGRPCServer(server, service, logger)")
+	})
+
+	t.Run("should not generate gRPC code unless enabled", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(modules.EntityConfig{Name: "Article", TableName: "articles", Fields: fields}))
+
+		assert.NoDirExists(t, filepath.Join(basePath, "internal/api/handlers/articlepb"))
+		assert.NoFileExists(t, filepath.Join(basePath, "internal/api/handlers/article_grpc.go"))
+		assert.NotContains(t, readGenerated(t, basePath, "internal/modules/article_module.go"), "grpc")
+	})
+
+}
+
+func TestProtoGoName(t *testing.T) {
+	tests := []struct {
+		column string
+		want   string
+	}{
+		{"title", "Title"},
+		{"published_at", "PublishedAt"},
+		{"user_id", "UserId"},
+		{"v2beta", "V2Beta"},
+		{"field__name", "Field_Name"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.column, func(t *testing.T) {
+			assert.Equal(t, tt.want, protoGoName(tt.column))
+		})
+	}
+}
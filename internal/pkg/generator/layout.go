@@ -0,0 +1,304 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// Layout names accepted by the generator CLI
+const (
+	LayoutStandard = "standard"
+	LayoutFlat     = "flat"
+	LayoutCustom   = "custom"
+)
+
+// Components passed to GeneratorLayout.TestPath
+const (
+	ComponentEntity     = "entity"
+	ComponentRepository = "repository"
+	ComponentService    = "service"
+	ComponentHandler    = "handler"
+)
+
+// GeneratorLayout decides where generated files are written. Paths are
+// relative to the generator's base path. Repository and service
+// implementations are written next to the returned path with an _impl
+// suffix. Generated files declare the package named after their directory
+// and import the other components from theirs.
+type GeneratorLayout interface {
+	EntityPath(config modules.EntityConfig) string
+	RepositoryPath(config modules.EntityConfig) string
+	ServicePath(config modules.EntityConfig) string
+	HandlerPath(config modules.EntityConfig) string
+	ModulePath(config modules.EntityConfig) string
+	TestPath(config modules.EntityConfig, component string) string
+}
+
+// StandardLayout spreads the components over the application's layers:
+// entities under internal/domain, repositories under internal/database,
+// handlers under internal/api and modules under internal/modules
+type StandardLayout struct{}
+
+func (StandardLayout) EntityPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", "domain", "entities", entityFile(config, ""))
+}
+
+func (StandardLayout) RepositoryPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", "database", "repositories", entityFile(config, "_repository"))
+}
+
+func (StandardLayout) ServicePath(config modules.EntityConfig) string {
+	return filepath.Join("internal", "domain", "services", entityFile(config, "_service"))
+}
+
+func (StandardLayout) HandlerPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", "api", "handlers", entityFile(config, "_handler"))
+}
+
+func (StandardLayout) ModulePath(config modules.EntityConfig) string {
+	return filepath.Join("internal", "modules", entityFile(config, "_module"))
+}
+
+func (l StandardLayout) TestPath(config modules.EntityConfig, component string) string {
+	return testFileFor(l, config, component)
+}
+
+// FlatLayout writes every component into the single internal package,
+// named after the entity: internal/product.go, internal/product_service.go
+type FlatLayout struct{}
+
+func (FlatLayout) EntityPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", entityFile(config, ""))
+}
+
+func (FlatLayout) RepositoryPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", entityFile(config, "_repository"))
+}
+
+func (FlatLayout) ServicePath(config modules.EntityConfig) string {
+	return filepath.Join("internal", entityFile(config, "_service"))
+}
+
+func (FlatLayout) HandlerPath(config modules.EntityConfig) string {
+	return filepath.Join("internal", entityFile(config, "_handler"))
+}
+
+func (FlatLayout) ModulePath(config modules.EntityConfig) string {
+	return filepath.Join("internal", entityFile(config, "_module"))
+}
+
+func (l FlatLayout) TestPath(config modules.EntityConfig, component string) string {
+	return testFileFor(l, config, component)
+}
+
+// CustomLayout builds paths from text/template patterns read from YAML:
+//
+//	entity: internal/{{.EntityLower}}/model.go
+//	repository: internal/{{.EntityLower}}/store/store.go
+//	service: internal/{{.EntityLower}}/service.go
+//	handler: internal/{{.EntityLower}}/http/handler.go
+//	module: internal/{{.EntityLower}}/module.go
+//	test: test/{{.EntityLower}}/{{.Component}}_test.go
+//
+// The patterns see EntityName, EntityLower, TableName and, for test,
+// Component. Without a test pattern tests go next to the file they cover.
+type CustomLayout struct {
+	entity     *template.Template
+	repository *template.Template
+	service    *template.Template
+	handler    *template.Template
+	module     *template.Template
+	test       *template.Template
+}
+
+// customLayoutFile is the YAML form of a CustomLayout
+type customLayoutFile struct {
+	Entity     string `yaml:"entity"`
+	Repository string `yaml:"repository"`
+	Service    string `yaml:"service"`
+	Handler    string `yaml:"handler"`
+	Module     string `yaml:"module"`
+	Test       string `yaml:"test"`
+}
+
+// layoutData is what CustomLayout patterns are executed with
+type layoutData struct {
+	EntityName  string
+	EntityLower string
+	TableName   string
+	Component   string
+}
+
+// LoadCustomLayout reads a CustomLayout from a YAML file
+func LoadCustomLayout(file string) (*CustomLayout, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layout %s: %w", file, err)
+	}
+
+	layout, err := ParseCustomLayout(data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid layout %s: %w", file, err)
+	}
+	return layout, nil
+}
+
+// ParseCustomLayout builds a CustomLayout from YAML. Every component but
+// test needs a pattern, and patterns are checked against a sample entity so
+// mistakes surface here instead of half way through a generation.
+func ParseCustomLayout(data []byte) (*CustomLayout, error) {
+	var file customLayoutFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse layout: %w", err)
+	}
+
+	layout := &CustomLayout{}
+	patterns := []struct {
+		name     string
+		pattern  string
+		target   **template.Template
+		optional bool
+	}{
+		{"entity", file.Entity, &layout.entity, false},
+		{"repository", file.Repository, &layout.repository, false},
+		{"service", file.Service, &layout.service, false},
+		{"handler", file.Handler, &layout.handler, false},
+		{"module", file.Module, &layout.module, false},
+		{"test", file.Test, &layout.test, true},
+	}
+
+	sample := layoutData{EntityName: "Sample", EntityLower: "sample", TableName: "samples", Component: ComponentEntity}
+	for _, p := range patterns {
+		if p.pattern == "" {
+			if p.optional {
+				continue
+			}
+			return nil, fmt.Errorf("missing %s path", p.name)
+		}
+
+		tmpl, err := template.New(p.name).Option("missingkey=error").Parse(p.pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s path: %w", p.name, err)
+		}
+		if err := tmpl.Execute(&bytes.Buffer{}, sample); err != nil {
+			return nil, fmt.Errorf("invalid %s path: %w", p.name, err)
+		}
+		*p.target = tmpl
+	}
+
+	return layout, nil
+}
+
+func (l *CustomLayout) EntityPath(config modules.EntityConfig) string {
+	return renderPath(l.entity, config, "")
+}
+
+func (l *CustomLayout) RepositoryPath(config modules.EntityConfig) string {
+	return renderPath(l.repository, config, "")
+}
+
+func (l *CustomLayout) ServicePath(config modules.EntityConfig) string {
+	return renderPath(l.service, config, "")
+}
+
+func (l *CustomLayout) HandlerPath(config modules.EntityConfig) string {
+	return renderPath(l.handler, config, "")
+}
+
+func (l *CustomLayout) ModulePath(config modules.EntityConfig) string {
+	return renderPath(l.module, config, "")
+}
+
+func (l *CustomLayout) TestPath(config modules.EntityConfig, component string) string {
+	if l.test == nil {
+		return testFileFor(l, config, component)
+	}
+	return renderPath(l.test, config, component)
+}
+
+// renderPath executes a pattern that ParseCustomLayout already checked, so
+// it cannot fail on a well-formed entity
+func renderPath(tmpl *template.Template, config modules.EntityConfig, component string) string {
+	var buf bytes.Buffer
+	tmpl.Execute(&buf, layoutData{
+		EntityName:  config.Name,
+		EntityLower: strings.ToLower(config.Name),
+		TableName:   config.TableName,
+		Component:   component,
+	})
+	return filepath.FromSlash(buf.String())
+}
+
+// entityFile names a file after the entity, e.g. product_service.go
+func entityFile(config modules.EntityConfig, suffix string) string {
+	return strings.ToLower(config.Name) + suffix + ".go"
+}
+
+// testFileFor puts the test next to the file it covers
+func testFileFor(layout GeneratorLayout, config modules.EntityConfig, component string) string {
+	var file string
+	switch component {
+	case ComponentRepository:
+		file = layout.RepositoryPath(config)
+	case ComponentService:
+		file = layout.ServicePath(config)
+	case ComponentHandler:
+		file = layout.HandlerPath(config)
+	default:
+		file = layout.EntityPath(config)
+	}
+	return strings.TrimSuffix(file, ".go") + "_test.go"
+}
+
+// integrationTestFile puts the integration test next to the repository it
+// covers, named after the entity
+func integrationTestFile(layout GeneratorLayout, config modules.EntityConfig) string {
+	return filepath.Join(filepath.Dir(layout.RepositoryPath(config)), entityFile(config, "_integration_test"))
+}
+
+// implPath is where the implementation of a repository or service interface
+// is written
+func implPath(file string) string {
+	return strings.TrimSuffix(file, ".go") + "_impl.go"
+}
+
+// eventStorePath returns where the event store of a repository is written
+func eventStorePath(file string) string {
+	return strings.TrimSuffix(file, ".go") + "_events.go"
+}
+
+// componentRefs holds a value per component referenced from a template
+type componentRefs struct {
+	Entity     string
+	Repository string
+	Service    string
+	Handler    string
+}
+
+// packageRefs works out how a file in dir refers to the other components:
+// the import path and the qualifier ("services.") of each, both empty when
+// the component lives in the same package
+func packageRefs(layout GeneratorLayout, config modules.EntityConfig, packageName, dir string) (imports, qualifiers componentRefs) {
+	ref := func(file string) (string, string) {
+		componentDir := filepath.Dir(file)
+		if componentDir == dir {
+			return "", ""
+		}
+		return path.Join(packageName, filepath.ToSlash(componentDir)), filepath.Base(componentDir) + "."
+	}
+
+	imports.Entity, qualifiers.Entity = ref(layout.EntityPath(config))
+	imports.Repository, qualifiers.Repository = ref(layout.RepositoryPath(config))
+	imports.Service, qualifiers.Service = ref(layout.ServicePath(config))
+	imports.Handler, qualifiers.Handler = ref(layout.HandlerPath(config))
+	return imports, qualifiers
+}
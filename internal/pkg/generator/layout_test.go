@@ -0,0 +1,188 @@
+package generator
+
+import (
+	"go/parser"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// generateWithLayout generates the module and tests for a Product entity and
+// returns the created files relative to the base path. Migrations are left
+// out, they go to migrations/postgres whatever the layout.
+func generateWithLayout(t *testing.T, layout GeneratorLayout) (string, []string) {
+	t.Helper()
+
+	basePath := t.TempDir()
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app", WithLayout(layout))
+
+	config := modules.EntityConfig{Name: "Product", TableName: "products", Timestamps: true}
+	require.NoError(t, g.GenerateModule(config))
+	require.NoError(t, g.GenerateTests(config))
+
+	var files []string
+	err := filepath.WalkDir(basePath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(basePath, path)
+		if d.IsDir() {
+			if rel == migrationsDir {
+				return filepath.SkipDir
+			}
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return err
+	})
+	require.NoError(t, err)
+
+	sort.Strings(files)
+	return basePath, files
+}
+
+func readGenerated(t *testing.T, basePath, file string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(filepath.Join(basePath, filepath.FromSlash(file)))
+	require.NoError(t, err)
+	return string(data)
+}
+
+func TestGeneratorLayout(t *testing.T) {
+	t.Run("should generate the standard layout", func(t *testing.T) {
+		basePath, files := generateWithLayout(t, StandardLayout{})
+
+		assert.Equal(t, []string{
+			"internal/api/handlers/product_handler.go",
+			"internal/api/handlers/product_handler_test.go",
+			"internal/api/handlers/product_openapi.yaml",
+			"internal/database/repositories/product_repository.go",
+			"internal/database/repositories/product_repository_impl.go",
+			"internal/database/repositories/product_repository_test.go",
+			"internal/domain/entities/product.go",
+			"internal/domain/entities/product_test.go",
+			"internal/domain/services/product_service.go",
+			"internal/domain/services/product_service_impl.go",
+			"internal/domain/services/product_service_test.go",
+			"internal/modules/product_module.go",
+		}, files)
+
+		source := readGenerated(t, basePath, "internal/domain/services/product_service_impl.go")
+		assert.Contains(t, source, "package services")
+		assert.Contains(t, source, `"github.com/example/app/internal/database/repositories"`)
+		assert.Contains(t, source, "repository repositories.ProductRepository")
+		assert.Contains(t, source, "*entities.Product")
+	})
+
+	t.Run("should generate the flat layout as a single package", func(t *testing.T) {
+		basePath, files := generateWithLayout(t, FlatLayout{})
+
+		assert.Equal(t, []string{
+			"internal/product.go",
+			"internal/product_handler.go",
+			"internal/product_handler_test.go",
+			"internal/product_module.go",
+			"internal/product_openapi.yaml",
+			"internal/product_repository.go",
+			"internal/product_repository_impl.go",
+			"internal/product_repository_test.go",
+			"internal/product_service.go",
+			"internal/product_service_impl.go",
+			"internal/product_service_test.go",
+			"internal/product_test.go",
+		}, files)
+
+		for _, file := range files {
+			if filepath.Ext(file) != ".go" {
+				continue
+			}
+
+			source := readGenerated(t, basePath, file)
+			parsed, err := parser.ParseFile(token.NewFileSet(), file, source, parser.ImportsOnly)
+			require.NoError(t, err, file)
+
+			assert.Equal(t, "internal", parsed.Name.Name, file)
+			assert.NotContains(t, source, "entities.Product", file)
+			assert.NotContains(t, source, "internal/domain", file)
+		}
+	})
+
+	t.Run("should generate a custom layout from YAML", func(t *testing.T) {
+		layout, err := ParseCustomLayout([]byte(`
+entity: internal/{{.EntityLower}}/model.go
+repository: internal/{{.EntityLower}}/store/store.go
+service: internal/{{.EntityLower}}/service.go
+handler: internal/{{.EntityLower}}/http/{{.TableName}}.go
+module: internal/{{.EntityLower}}/module.go
+`))
+		require.NoError(t, err)
+
+		basePath, files := generateWithLayout(t, layout)
+
+		assert.Equal(t, []string{
+			"internal/product/http/product_openapi.yaml",
+			"internal/product/http/products.go",
+			"internal/product/http/products_test.go",
+			"internal/product/model.go",
+			"internal/product/model_test.go",
+			"internal/product/module.go",
+			"internal/product/service.go",
+			"internal/product/service_impl.go",
+			"internal/product/service_test.go",
+			"internal/product/store/store.go",
+			"internal/product/store/store_impl.go",
+			"internal/product/store/store_test.go",
+		}, files)
+
+		source := readGenerated(t, basePath, "internal/product/service_impl.go")
+		assert.Contains(t, source, "package product")
+		assert.Contains(t, source, `"github.com/example/app/internal/product/store"`)
+		assert.Contains(t, source, "repository store.ProductRepository")
+		assert.Contains(t, source, "*Product")
+		assert.NotContains(t, source, "entities.")
+	})
+
+	t.Run("should place custom tests by the test pattern", func(t *testing.T) {
+		layout, err := ParseCustomLayout([]byte(`
+entity: internal/{{.EntityLower}}/model.go
+repository: internal/{{.EntityLower}}/store.go
+service: internal/{{.EntityLower}}/service.go
+handler: internal/{{.EntityLower}}/handler.go
+module: internal/{{.EntityLower}}/module.go
+test: test/{{.EntityLower}}/{{.Component}}_test.go
+`))
+		require.NoError(t, err)
+
+		config := modules.EntityConfig{Name: "Product"}
+		assert.Equal(t, filepath.FromSlash("test/product/service_test.go"), layout.TestPath(config, ComponentService))
+	})
+
+	t.Run("should reject incomplete or invalid custom layouts", func(t *testing.T) {
+		_, err := ParseCustomLayout([]byte(`entity: internal/{{.EntityLower}}.go`))
+		assert.ErrorContains(t, err, "missing repository path")
+
+		_, err = ParseCustomLayout([]byte(`
+entity: internal/{{.Entity}}.go
+repository: internal/repository.go
+service: internal/service.go
+handler: internal/handler.go
+module: internal/module.go
+`))
+		assert.ErrorContains(t, err, "invalid entity path")
+	})
+
+	t.Run("should report a missing layout file", func(t *testing.T) {
+		_, err := LoadCustomLayout(filepath.Join(t.TempDir(), "missing.yaml"))
+		assert.ErrorContains(t, err, "failed to read layout")
+	})
+}
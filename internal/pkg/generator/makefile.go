@@ -0,0 +1,140 @@
+package generator
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// makefileName is the Makefile the entity targets are written to, relative
+// to the base path
+const makefileName = "Makefile"
+
+// GenerateMakefile writes make targets for the entity between "# BEGIN
+// <entity>" and "# END <entity>" in the base path's Makefile: generate-,
+// migrate-, rollback-, test- and lint-<entity>. The block is replaced in
+// place when the entity already has one and appended otherwise, creating
+// the Makefile if needed.
+func (g *Generator) GenerateMakefile(config modules.EntityConfig) error {
+	g.logger.Info("Generating Makefile targets", "name", config.Name)
+
+	block, err := g.makefileBlock(config)
+	if err != nil {
+		return fmt.Errorf("failed to generate Makefile targets: %w", err)
+	}
+
+	existing, err := os.ReadFile(filepath.Join(g.basePath, makefileName))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to read %s: %w", makefileName, err)
+	}
+
+	if err := g.writeFile(makefileName, []byte(mergeMakefileBlock(string(existing), config.Name, block))); err != nil {
+		return err
+	}
+
+	g.logger.Info("Makefile targets generated successfully", "file", makefileName)
+	return nil
+}
+
+// makefileBlock renders the entity's targets, sentinel comments included
+func (g *Generator) makefileBlock(config modules.EntityConfig) (string, error) {
+	version, err := g.migrationVersion(config.TableName)
+	if err != nil {
+		return "", err
+	}
+	migration := filepath.ToSlash(filepath.Join(migrationsDir, fmt.Sprintf("%s_create_%s", version, config.TableName)))
+
+	var testDirs []string
+	for _, component := range []string{ComponentEntity, ComponentRepository, ComponentService, ComponentHandler} {
+		testDirs = appendDir(testDirs, g.layout.TestPath(config, component))
+	}
+
+	var packageDirs []string
+	for _, path := range []string{
+		g.layout.EntityPath(config),
+		g.layout.RepositoryPath(config),
+		g.layout.ServicePath(config),
+		g.layout.HandlerPath(config),
+		g.layout.ModulePath(config),
+	} {
+		packageDirs = appendDir(packageDirs, path)
+	}
+
+	command := g.command
+	if command == "" {
+		command = fmt.Sprintf("go run ./cmd/generator -entity=%s -table=%s -all", config.Name, config.TableName)
+	}
+
+	name := strings.ToLower(config.Name)
+	var b strings.Builder
+	fmt.Fprintf(&b, "# BEGIN %s\n", config.Name)
+	fmt.Fprintf(&b, ".PHONY: generate-%[1]s migrate-%[1]s rollback-%[1]s test-%[1]s lint-%[1]s\n\n", name)
+	fmt.Fprintf(&b, "generate-%s: ## Re-run the generator for %s\n\t%s\n\n", name, config.Name, command)
+	fmt.Fprintf(&b, "migrate-%s: ## Run the %s up migration\n\tpsql \"$(DATABASE_URL)\" -f %s.up.sql\n\n", name, config.Name, migration)
+	fmt.Fprintf(&b, "rollback-%s: ## Run the %s down migration\n\tpsql \"$(DATABASE_URL)\" -f %s.down.sql\n\n", name, config.Name, migration)
+	fmt.Fprintf(&b, "test-%s: ## Run the %s tests\n\tgo test %s -run '^Test%s'\n\n", name, config.Name, strings.Join(testDirs, " "), config.Name)
+	fmt.Fprintf(&b, "lint-%s: ## Lint the %s packages\n\tgolangci-lint run %s\n", name, config.Name, strings.Join(packageDirs, " "))
+	fmt.Fprintf(&b, "# END %s\n", config.Name)
+	return b.String(), nil
+}
+
+// appendDir adds the directory of the file at path, as a ./ package path,
+// unless dirs already holds it
+func appendDir(dirs []string, path string) []string {
+	dir := "./" + filepath.ToSlash(filepath.Dir(path))
+	if slices.Contains(dirs, dir) {
+		return dirs
+	}
+	return append(dirs, dir)
+}
+
+// mergeMakefileBlock replaces the entity's block in makefile with block,
+// or appends block when there is none
+func mergeMakefileBlock(makefile, entity, block string) string {
+	begin, end := "# BEGIN "+entity+"\n", "# END "+entity+"\n"
+
+	if start := strings.Index(makefile, begin); start >= 0 {
+		if stop := strings.Index(makefile[start:], end); stop >= 0 {
+			return makefile[:start] + block + makefile[start+stop+len(end):]
+		}
+	}
+
+	switch {
+	case makefile == "":
+		return block
+	case strings.HasSuffix(makefile, "\n\n"):
+		return makefile + block
+	case strings.HasSuffix(makefile, "\n"):
+		return makefile + "\n" + block
+	default:
+		return makefile + "\n\n" + block
+	}
+}
+
+// ShellCommand joins args into a command line for a Makefile recipe,
+// quoting the arguments the shell would split or expand and escaping $ for
+// make
+func ShellCommand(args ...string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		if arg == "" || strings.ContainsFunc(arg, needsQuoting) {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted[i] = strings.ReplaceAll(arg, "$", "$$")
+	}
+	return strings.Join(quoted, " ")
+}
+
+func needsQuoting(r rune) bool {
+	switch {
+	case 'a' <= r && r <= 'z', 'A' <= r && r <= 'Z', '0' <= r && r <= '9':
+		return false
+	}
+	return !strings.ContainsRune("-_./=:,+@%", r)
+}
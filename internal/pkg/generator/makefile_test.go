@@ -0,0 +1,79 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestGenerateMakefile(t *testing.T) {
+	order := modules.EntityConfig{Name: "Order", TableName: "orders"}
+
+	t.Run("should write the entity targets into a new Makefile", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateRepository(order))
+		require.NoError(t, g.GenerateMakefile(order))
+
+		ups, err := filepath.Glob(filepath.Join(basePath, "migrations", "postgres", "*_create_orders.up.sql"))
+		require.NoError(t, err)
+		require.Len(t, ups, 1)
+		migration := "migrations/postgres/" + strings.TrimSuffix(filepath.Base(ups[0]), ".up.sql")
+
+		makefile := readGenerated(t, basePath, "Makefile")
+
+		assert.True(t, strings.HasPrefix(makefile, "# BEGIN Order\n"))
+		assert.True(t, strings.HasSuffix(makefile, "# END Order\n"))
+		assert.Contains(t, makefile, "generate-order: ## Re-run the generator for Order\n\tgo run ./cmd/generator -entity=Order -table=orders -all\n")
+		assert.Contains(t, makefile, "migrate-order: ## Run the Order up migration\n\tpsql \"$(DATABASE_URL)\" -f "+migration+".up.sql\n")
+		assert.Contains(t, makefile, "rollback-order: ## Run the Order down migration\n\tpsql \"$(DATABASE_URL)\" -f "+migration+".down.sql\n")
+		assert.Contains(t, makefile, "\tgo test ./internal/domain/entities ./internal/database/repositories ./internal/domain/services ./internal/api/handlers -run '^TestOrder'\n")
+		assert.Contains(t, makefile, "\tgolangci-lint run ./internal/domain/entities ./internal/database/repositories ./internal/domain/services ./internal/api/handlers ./internal/modules\n")
+	})
+
+	t.Run("should replace the entity block and keep the other targets", func(t *testing.T) {
+		basePath := t.TempDir()
+		existing := "build: ## Build\n\tgo build ./...\n"
+		require.NoError(t, os.WriteFile(filepath.Join(basePath, "Makefile"), []byte(existing), 0644))
+
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app", WithCommand("go run ./cmd/generator -entity=Order -gen-entity"))
+		require.NoError(t, g.GenerateMakefile(order))
+		require.NoError(t, g.GenerateMakefile(modules.EntityConfig{Name: "Tag", TableName: "tags"}))
+		require.NoError(t, g.GenerateMakefile(order))
+
+		makefile := readGenerated(t, basePath, "Makefile")
+
+		assert.True(t, strings.HasPrefix(makefile, existing+"\n# BEGIN Order\n"))
+		assert.Equal(t, 1, strings.Count(makefile, "# BEGIN Order\n"))
+		assert.Equal(t, 1, strings.Count(makefile, "generate-order:"))
+		assert.Equal(t, 1, strings.Count(makefile, "# BEGIN Tag\n"))
+		assert.Contains(t, makefile, "\tgo run ./cmd/generator -entity=Order -gen-entity\n")
+		assert.Less(t, strings.Index(makefile, "# END Order"), strings.Index(makefile, "# BEGIN Tag"))
+	})
+}
+
+func TestShellCommand(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want string
+	}{
+		{"plain flags", []string{"-entity=Product", "-all"}, "-entity=Product -all"},
+		{"shell characters", []string{"-fields=title:string:required|max=200,published_at:*time.Time"}, "'-fields=title:string:required|max=200,published_at:*time.Time'"},
+		{"quotes and dollars", []string{"it's", "$HOME"}, `'it'\''s' '$$HOME'`},
+		{"empty argument", []string{"-table", ""}, "-table ''"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ShellCommand(tt.args...))
+		})
+	}
+}
@@ -0,0 +1,180 @@
+package generator
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// migrationVersionFormat numbers generated migrations by creation time, so
+// they sort after the sequential ones in migrations/postgres
+const migrationVersionFormat = "20060102150405"
+
+// migrationsDir is where migrations are written, relative to the base path
+var migrationsDir = filepath.Join("migrations", "postgres")
+
+// Statements of an up migration that the down migration has to undo
+var (
+	createTablePattern    = regexp.MustCompile(`(?i)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w.]+)`)
+	createIndexPattern    = regexp.MustCompile(`(?i)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?([\w.]+)`)
+	createSequencePattern = regexp.MustCompile(`(?i)^CREATE\s+SEQUENCE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w.]+)`)
+	createFunctionPattern = regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?FUNCTION\s+([\w.]+)\s*\(([^)]*)\)`)
+	createTriggerPattern  = regexp.MustCompile(`(?i)^CREATE\s+(?:OR\s+REPLACE\s+)?TRIGGER\s+(\w+)[\s\S]*?\sON\s+([\w.]+)`)
+)
+
+// generateMigrations writes the up migration creating the entity's tables
+// and the down migration dropping them, and returns their paths. A
+// migration already generated for the table is overwritten in place.
+func (g *Generator) generateMigrations(config modules.EntityConfig) (string, string, error) {
+	version, err := g.migrationVersion(config.TableName)
+	if err != nil {
+		return "", "", err
+	}
+
+	base := filepath.Join(migrationsDir, fmt.Sprintf("%s_create_%s", version, config.TableName))
+	upFile, downFile := base+".up.sql", base+".down.sql"
+
+	up, err := g.renderTemplate("migration_up", upFile, config)
+	if err != nil {
+		return "", "", err
+	}
+	if err := g.writeFile(upFile, up); err != nil {
+		return "", "", err
+	}
+
+	if err := g.writeFile(downFile, []byte(downMigration(string(up)))); err != nil {
+		return "", "", err
+	}
+
+	return upFile, downFile, nil
+}
+
+// migrationVersion returns the version of the table's generated migration,
+// or a new one when there is none
+func (g *Generator) migrationVersion(table string) (string, error) {
+	pattern := filepath.Join(g.basePath, migrationsDir, "*_create_"+table+".up.sql")
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return "", fmt.Errorf("failed to look up migrations for %s: %w", table, err)
+	}
+
+	for _, match := range matches {
+		version := strings.TrimSuffix(filepath.Base(match), "_create_"+table+".up.sql")
+		if _, err := time.Parse(migrationVersionFormat, version); err == nil {
+			return version, nil
+		}
+	}
+
+	return time.Now().UTC().Format(migrationVersionFormat), nil
+}
+
+// downMigration returns the statements undoing an up migration: every
+// table, index, sequence, function and trigger it creates is dropped, in
+// reverse order of creation. Other statements are left alone.
+func downMigration(up string) string {
+	var drops []string
+	for _, statement := range splitStatements(up) {
+		if drop := dropStatement(statement); drop != "" {
+			drops = append(drops, drop)
+		}
+	}
+
+	var b strings.Builder
+	for i := len(drops) - 1; i >= 0; i-- {
+		b.WriteString(drops[i])
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+func dropStatement(statement string) string {
+	if m := createTriggerPattern.FindStringSubmatch(statement); m != nil {
+		return fmt.Sprintf("DROP TRIGGER IF EXISTS %s ON %s", m[1], m[2])
+	}
+	if m := createFunctionPattern.FindStringSubmatch(statement); m != nil {
+		return fmt.Sprintf("DROP FUNCTION IF EXISTS %s(%s)", m[1], strings.TrimSpace(m[2]))
+	}
+	if m := createIndexPattern.FindStringSubmatch(statement); m != nil {
+		return "DROP INDEX IF EXISTS " + m[1]
+	}
+	if m := createSequencePattern.FindStringSubmatch(statement); m != nil {
+		return "DROP SEQUENCE IF EXISTS " + m[1]
+	}
+	if m := createTablePattern.FindStringSubmatch(statement); m != nil {
+		return "DROP TABLE IF EXISTS " + m[1]
+	}
+	return ""
+}
+
+// splitStatements splits SQL on semicolons outside of comments, quoted
+// strings and dollar quoted function bodies, dropping the comments
+func splitStatements(sql string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+	flush := func() {
+		if statement := strings.TrimSpace(current.String()); statement != "" {
+			statements = append(statements, statement)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		switch {
+		case strings.HasPrefix(sql[i:], "--"):
+			end := strings.IndexByte(sql[i:], '\n')
+			if end < 0 {
+				i = len(sql)
+			} else {
+				i += end
+				current.WriteByte('\n')
+			}
+		case sql[i] == '\'':
+			end := len(sql)
+			if n := strings.IndexByte(sql[i+1:], '\''); n >= 0 {
+				end = i + n + 2
+			}
+			current.WriteString(sql[i:end])
+			i = end - 1
+		case sql[i] == '$':
+			tag := dollarQuoteTag(sql[i:])
+			if tag == "" {
+				current.WriteByte(sql[i])
+				continue
+			}
+			end := len(sql)
+			if n := strings.Index(sql[i+len(tag):], tag); n >= 0 {
+				end = i + len(tag) + n + len(tag)
+			}
+			current.WriteString(sql[i:end])
+			i = end - 1
+		case sql[i] == ';':
+			flush()
+		default:
+			current.WriteByte(sql[i])
+		}
+	}
+	flush()
+
+	return statements
+}
+
+// dollarQuoteTag returns the $tag$ opening s, or an empty string when s
+// does not open a dollar quote
+func dollarQuoteTag(s string) string {
+	end := strings.IndexByte(s[1:], '$')
+	if end < 0 {
+		return ""
+	}
+	for _, r := range s[1 : end+1] {
+		if r != '_' && !('a' <= r && r <= 'z') && !('A' <= r && r <= 'Z') && !('0' <= r && r <= '9') {
+			return ""
+		}
+	}
+	return s[:end+2]
+}
@@ -0,0 +1,101 @@
+package generator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func generateMigrations(t *testing.T, basePath string, config modules.EntityConfig) (up, down string) {
+	t.Helper()
+
+	g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+	require.NoError(t, g.GenerateRepository(config))
+
+	ups, err := filepath.Glob(filepath.Join(basePath, "migrations", "postgres", "*_create_"+config.TableName+".up.sql"))
+	require.NoError(t, err)
+	require.Len(t, ups, 1)
+
+	upData, err := os.ReadFile(ups[0])
+	require.NoError(t, err)
+	downData, err := os.ReadFile(ups[0][:len(ups[0])-len(".up.sql")] + ".down.sql")
+	require.NoError(t, err)
+
+	return string(upData), string(downData)
+}
+
+func TestGenerateMigrations(t *testing.T) {
+	t.Run("should drop what the up migration creates in reverse order", func(t *testing.T) {
+		up, down := generateMigrations(t, t.TempDir(), modules.EntityConfig{
+			Name: "Order", TableName: "orders", SoftDelete: true, Timestamps: true,
+		})
+
+		assert.Contains(t, up, "CREATE TABLE IF NOT EXISTS orders (")
+		assert.Contains(t, up, "deleted_at TIMESTAMPTZ,")
+		assert.Contains(t, up, "CREATE TRIGGER set_orders_updated_at")
+		assert.Equal(t, "DROP TRIGGER IF EXISTS set_orders_updated_at ON orders;\n"+
+			"DROP FUNCTION IF EXISTS set_orders_updated_at();\n"+
+			"DROP INDEX IF EXISTS idx_orders_id_live;\n"+
+			"DROP TABLE IF EXISTS orders;\n", down)
+	})
+
+	t.Run("should only drop the table without soft delete or timestamps", func(t *testing.T) {
+		up, down := generateMigrations(t, t.TempDir(), modules.EntityConfig{Name: "Order", TableName: "orders"})
+
+		assert.NotContains(t, up, "TRIGGER")
+		assert.Equal(t, "DROP TABLE IF EXISTS orders;\n", down)
+	})
+
+	t.Run("should overwrite the migration when regenerating", func(t *testing.T) {
+		basePath := t.TempDir()
+		config := modules.EntityConfig{Name: "Order", TableName: "orders"}
+		generateMigrations(t, basePath, config)
+
+		config.Timestamps = true
+		_, down := generateMigrations(t, basePath, config)
+
+		assert.Contains(t, down, "DROP TRIGGER IF EXISTS set_orders_updated_at ON orders;")
+	})
+}
+
+func TestDownMigration(t *testing.T) {
+	t.Run("should skip semicolons in comments, strings and function bodies", func(t *testing.T) {
+		up := `-- Orders; with a note
+CREATE SEQUENCE IF NOT EXISTS order_numbers START 1000;
+CREATE TABLE orders (
+    id SERIAL PRIMARY KEY,
+    status VARCHAR(20) NOT NULL DEFAULT 'new;pending'
+);
+CREATE UNIQUE INDEX idx_orders_status ON orders(status);
+INSERT INTO orders (status) VALUES ('seed');
+
+CREATE OR REPLACE FUNCTION touch_orders(row_id INTEGER)
+RETURNS TRIGGER AS $body$
+BEGIN
+    NEW.updated_at = NOW();
+    RETURN NEW;
+END;
+$body$ LANGUAGE plpgsql;
+
+CREATE TRIGGER touch_orders
+    BEFORE UPDATE ON public.orders
+    FOR EACH ROW EXECUTE FUNCTION touch_orders();
+`
+
+		assert.Equal(t, "DROP TRIGGER IF EXISTS touch_orders ON public.orders;\n"+
+			"DROP FUNCTION IF EXISTS touch_orders(row_id INTEGER);\n"+
+			"DROP INDEX IF EXISTS idx_orders_status;\n"+
+			"DROP TABLE IF EXISTS orders;\n"+
+			"DROP SEQUENCE IF EXISTS order_numbers;\n", downMigration(up))
+	})
+
+	t.Run("should return nothing for an up migration without creates", func(t *testing.T) {
+		assert.Empty(t, downMigration("ALTER TABLE orders ADD COLUMN note TEXT;"))
+	})
+}
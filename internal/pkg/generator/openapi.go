@@ -0,0 +1,418 @@
+package generator
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// openAPIDoc is an OpenAPI 3.0 fragment holding one entity's endpoints,
+// merged into the top-level document by swagger combine
+type openAPIDoc struct {
+	OpenAPI    string                 `yaml:"openapi"`
+	Info       openAPIInfo            `yaml:"info"`
+	Tags       []openAPITag           `yaml:"tags"`
+	Paths      map[string]openAPIPath `yaml:"paths"`
+	Components openAPIComponents      `yaml:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `yaml:"title"`
+	Version string `yaml:"version"`
+}
+
+type openAPITag struct {
+	Name        string `yaml:"name"`
+	Description string `yaml:"description,omitempty"`
+}
+
+type openAPIPath struct {
+	Get    *openAPIOperation `yaml:"get,omitempty"`
+	Post   *openAPIOperation `yaml:"post,omitempty"`
+	Put    *openAPIOperation `yaml:"put,omitempty"`
+	Delete *openAPIOperation `yaml:"delete,omitempty"`
+}
+
+type openAPIOperation struct {
+	Tags        []string                   `yaml:"tags"`
+	Summary     string                     `yaml:"summary"`
+	OperationID string                     `yaml:"operationId"`
+	Parameters  []openAPIParameter         `yaml:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `yaml:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `yaml:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string         `yaml:"name"`
+	In          string         `yaml:"in"`
+	Description string         `yaml:"description,omitempty"`
+	Required    bool           `yaml:"required"`
+	Schema      *openAPISchema `yaml:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                    `yaml:"required"`
+	Content  map[string]openAPIMedia `yaml:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                  `yaml:"description"`
+	Content     map[string]openAPIMedia `yaml:"content,omitempty"`
+}
+
+type openAPIMedia struct {
+	Schema *openAPISchema `yaml:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]*openAPISchema `yaml:"schemas"`
+}
+
+type openAPISchema struct {
+	Ref        string                    `yaml:"$ref,omitempty"`
+	Type       string                    `yaml:"type,omitempty"`
+	Format     string                    `yaml:"format,omitempty"`
+	Nullable   bool                      `yaml:"nullable,omitempty"`
+	ReadOnly   bool                      `yaml:"readOnly,omitempty"`
+	Default    interface{}               `yaml:"default,omitempty"`
+	MinLength  *uint64                   `yaml:"minLength,omitempty"`
+	MaxLength  *uint64                   `yaml:"maxLength,omitempty"`
+	Minimum    *float64                  `yaml:"minimum,omitempty"`
+	Maximum    *float64                  `yaml:"maximum,omitempty"`
+	Items      *openAPISchema            `yaml:"items,omitempty"`
+	Required   []string                  `yaml:"required,omitempty"`
+	Properties map[string]*openAPISchema `yaml:"properties,omitempty"`
+}
+
+// openAPIFile is the fragment written next to the entity's handler
+func openAPIFile(layout GeneratorLayout, config modules.EntityConfig) string {
+	return filepath.Join(filepath.Dir(layout.HandlerPath(config)), strings.ToLower(config.Name)+"_openapi.yaml")
+}
+
+// generateOpenAPI writes the OpenAPI fragment describing the handler's
+// endpoints
+func (g *Generator) generateOpenAPI(config modules.EntityConfig) (string, error) {
+	fields, err := entityFields(config)
+	if err != nil {
+		return "", fmt.Errorf("invalid fields for %s: %w", config.Name, err)
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(buildOpenAPI(config, fields)); err != nil {
+		return "", fmt.Errorf("failed to marshal OpenAPI spec: %w", err)
+	}
+
+	relPath := openAPIFile(g.layout, config)
+	outputFile := filepath.Join(g.basePath, relPath)
+	if err := os.MkdirAll(filepath.Dir(outputFile), 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory for %s: %w", outputFile, err)
+	}
+
+	if err := os.WriteFile(outputFile, buf.Bytes(), 0644); err != nil {
+		return "", fmt.Errorf("failed to create file %s: %w", outputFile, err)
+	}
+
+	return relPath, nil
+}
+
+// buildOpenAPI describes the routes the generated module registers
+func buildOpenAPI(config modules.EntityConfig, fields []entityField) openAPIDoc {
+	name := config.Name
+	lower := strings.ToLower(name)
+	tag := lower + "s"
+	collection := "/" + lower + "s"
+
+	ref := func(schema string) *openAPISchema {
+		return &openAPISchema{Ref: "#/components/schemas/" + schema}
+	}
+	jsonBody := func(schema string) map[string]openAPIMedia {
+		return map[string]openAPIMedia{"application/json": {Schema: ref(schema)}}
+	}
+	failure := func(description string) openAPIResponse {
+		return openAPIResponse{Description: description, Content: jsonBody("ErrorResponse")}
+	}
+	input := &openAPIRequestBody{Required: true, Content: jsonBody(name + "Input")}
+	idParam := openAPIParameter{
+		Name:        "id",
+		In:          "path",
+		Description: name + " ID",
+		Required:    true,
+		Schema:      &openAPISchema{Type: "integer", Format: "int64"},
+	}
+
+	paths := map[string]openAPIPath{
+		collection: {
+			Get: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "List " + lower + "s",
+				OperationID: "list" + name + "s",
+				Parameters: []openAPIParameter{
+					{Name: "offset", In: "query", Description: "Offset for pagination", Schema: &openAPISchema{Type: "integer", Default: 0, Minimum: float64Ptr(0)}},
+					{Name: "limit", In: "query", Description: "Limit for pagination", Schema: &openAPISchema{Type: "integer", Default: 10, Minimum: float64Ptr(1), Maximum: float64Ptr(100)}},
+				},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + "s retrieved successfully", Content: jsonBody(name + "ListResponse")},
+					"400": failure("Bad request"),
+					"500": failure("Internal server error"),
+				},
+			},
+			Post: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Create a new " + lower,
+				OperationID: "create" + name,
+				RequestBody: input,
+				Responses: map[string]openAPIResponse{
+					"201": {Description: name + " created successfully", Content: jsonBody(name + "Response")},
+					"400": failure("Bad request"),
+					"500": failure("Internal server error"),
+				},
+			},
+		},
+		collection + "/{id}": {
+			Get: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Get " + lower + " by ID",
+				OperationID: "get" + name + "ByID",
+				Parameters:  []openAPIParameter{idParam},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + " found successfully", Content: jsonBody(name + "Response")},
+					"400": failure("Bad request"),
+					"404": failure(name + " not found"),
+				},
+			},
+			Put: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Update " + lower,
+				OperationID: "update" + name,
+				Parameters:  []openAPIParameter{idParam},
+				RequestBody: input,
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + " updated successfully", Content: jsonBody(name + "Response")},
+					"400": failure("Bad request"),
+					"500": failure("Internal server error"),
+				},
+			},
+			Delete: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Delete " + lower,
+				OperationID: "delete" + name,
+				Parameters:  []openAPIParameter{idParam},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + " deleted successfully", Content: jsonBody("MessageResponse")},
+					"400": failure("Bad request"),
+					"404": failure(name + " not found"),
+				},
+			},
+		},
+	}
+
+	if lookup := chooseLookup(fields); lookup != nil {
+		paths[collection+"/"+lookup.Column+"/{"+lookup.Column+"}"] = openAPIPath{
+			Get: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Find " + lower + " by " + lookup.Column,
+				OperationID: "find" + name + "By" + lookup.Name,
+				Parameters: []openAPIParameter{
+					{Name: lookup.Column, In: "path", Description: name + " " + lookup.Column, Required: true, Schema: &openAPISchema{Type: "string"}},
+				},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + " found successfully", Content: jsonBody(name + "Response")},
+					"400": failure("Bad request"),
+					"404": failure(name + " not found"),
+				},
+			},
+		}
+		paths[collection+"/search"] = openAPIPath{
+			Get: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Search " + lower + "s by " + lookup.Column,
+				OperationID: "search" + name + "sBy" + lookup.Name,
+				Parameters: []openAPIParameter{
+					{Name: "q", In: "query", Description: "Search pattern", Required: true, Schema: &openAPISchema{Type: "string"}},
+				},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + "s found successfully", Content: jsonBody(name + "SearchResponse")},
+					"400": failure("Bad request"),
+					"500": failure("Internal server error"),
+				},
+			},
+		}
+	}
+
+	doc := openAPIDoc{
+		OpenAPI: "3.0.3",
+		Info:    openAPIInfo{Title: name + " API", Version: "1.0"},
+		Tags:    []openAPITag{{Name: tag, Description: name + " management"}},
+		Paths:   paths,
+		Components: openAPIComponents{Schemas: map[string]*openAPISchema{
+			name:           entitySchema(config, fields),
+			name + "Input": inputSchema(config, fields),
+			name + "Response": {Type: "object", Properties: map[string]*openAPISchema{
+				"message": {Type: "string"},
+				"data":    ref(name),
+			}},
+			name + "ListResponse": {Type: "object", Properties: map[string]*openAPISchema{
+				"message": {Type: "string"},
+				"data":    {Type: "array", Items: ref(name)},
+				"total":   {Type: "integer", Format: "int64"},
+				"offset":  {Type: "integer"},
+				"limit":   {Type: "integer"},
+			}},
+			name + "SearchResponse": {Type: "object", Properties: map[string]*openAPISchema{
+				"message": {Type: "string"},
+				"data":    {Type: "array", Items: ref(name)},
+				"count":   {Type: "integer"},
+			}},
+			"MessageResponse": {Type: "object", Properties: map[string]*openAPISchema{
+				"message": {Type: "string"},
+			}},
+			"ErrorResponse": {Type: "object", Properties: map[string]*openAPISchema{
+				"error":   {Type: "string"},
+				"message": {Type: "string"},
+			}},
+		}},
+	}
+
+	for _, relation := range manyToManyRelations(config) {
+		input := name + relation.Plural + "Input"
+		response := name + relation.Plural + "Response"
+
+		doc.Paths[collection+"/{id}/"+relation.PluralLower] = openAPIPath{
+			Put: &openAPIOperation{
+				Tags:        []string{tag},
+				Summary:     "Set " + lower + " " + relation.Lower + "s",
+				OperationID: "set" + name + relation.Plural,
+				Parameters:  []openAPIParameter{idParam},
+				RequestBody: &openAPIRequestBody{Required: true, Content: jsonBody(input)},
+				Responses: map[string]openAPIResponse{
+					"200": {Description: name + " " + relation.Lower + "s updated successfully", Content: jsonBody(response)},
+					"400": failure("Bad request"),
+					"500": failure("Internal server error"),
+				},
+			},
+		}
+
+		schemas := doc.Components.Schemas
+		schemas[relation.JoinEntity] = &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{
+			relation.OwnerColumn:   {Type: "integer", Format: "int64"},
+			relation.RelatedColumn: {Type: "integer", Format: "int64"},
+			"created_at":           {Type: "integer", Format: "int64", ReadOnly: true},
+		}}
+		schemas[input] = &openAPISchema{
+			Type:     "object",
+			Required: []string{relation.IDsKey},
+			Properties: map[string]*openAPISchema{
+				relation.IDsKey: {Type: "array", Items: &openAPISchema{Type: "integer", Format: "int64"}},
+			},
+		}
+		schemas[response] = &openAPISchema{Type: "object", Properties: map[string]*openAPISchema{
+			"message": {Type: "string"},
+			"data":    {Type: "array", Items: ref(relation.JoinEntity)},
+		}}
+	}
+
+	return doc
+}
+
+// entitySchema describes the entity as the handlers return it
+func entitySchema(config modules.EntityConfig, fields []entityField) *openAPISchema {
+	schema := inputSchema(config, fields)
+	schema.Properties["id"] = &openAPISchema{Type: "integer", Format: "int64", ReadOnly: true}
+	if config.Timestamps {
+		schema.Properties["created_at"] = &openAPISchema{Type: "integer", Format: "int64", ReadOnly: true}
+		schema.Properties["updated_at"] = &openAPISchema{Type: "integer", Format: "int64", ReadOnly: true}
+	}
+	if config.SoftDelete {
+		schema.Properties["deleted_at"] = &openAPISchema{Type: "integer", Format: "int64", Nullable: true, ReadOnly: true}
+	}
+	schema.Required = append([]string{"id"}, schema.Required...)
+	return schema
+}
+
+// inputSchema describes the fields clients send to create and update the
+// entity. Fields are required when the validation config requires them.
+func inputSchema(config modules.EntityConfig, fields []entityField) *openAPISchema {
+	required := make(map[string]bool)
+	for _, name := range config.Validation.Required {
+		required[name] = true
+	}
+	for name, rules := range config.Validation.Rules {
+		for _, rule := range strings.Split(rules, ",") {
+			if strings.TrimSpace(rule) == ConstraintRequired {
+				required[name] = true
+			}
+		}
+	}
+
+	schema := &openAPISchema{Type: "object", Properties: make(map[string]*openAPISchema)}
+	for _, field := range fields {
+		schema.Properties[field.Column] = fieldSchema(field)
+		if required[field.Column] || field.Required {
+			schema.Required = append(schema.Required, field.Column)
+		}
+	}
+	return schema
+}
+
+// fieldSchema maps a field's Go type and bounds to a schema
+func fieldSchema(field entityField) *openAPISchema {
+	schema := &openAPISchema{Nullable: field.Pointer}
+
+	switch baseType := strings.TrimPrefix(field.Type, "*"); baseType {
+	case "string":
+		schema.Type = "string"
+	case "bool":
+		schema.Type = "boolean"
+	case "int32":
+		schema.Type, schema.Format = "integer", "int32"
+	case "int", "int64", "uint", "uint32", "uint64":
+		schema.Type, schema.Format = "integer", "int64"
+	case "float32":
+		schema.Type, schema.Format = "number", "float"
+	case "float64":
+		schema.Type, schema.Format = "number", "double"
+	case "time.Time":
+		schema.Type, schema.Format = "string", "date-time"
+	}
+
+	switch field.kind {
+	case kindString:
+		schema.MinLength = uint64Ptr(field.Min)
+		schema.MaxLength = uint64Ptr(field.Max)
+	case kindNumber:
+		schema.Minimum = parseFloat64Ptr(field.Min)
+		schema.Maximum = parseFloat64Ptr(field.Max)
+	}
+	return schema
+}
+
+func float64Ptr(value float64) *float64 {
+	return &value
+}
+
+// uint64Ptr parses a bound, returning nil when it is unset
+func uint64Ptr(value string) *uint64 {
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
+
+// parseFloat64Ptr parses a bound, returning nil when it is unset
+func parseFloat64Ptr(value string) *float64 {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil
+	}
+	return &n
+}
@@ -0,0 +1,96 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestGenerateOpenAPI(t *testing.T) {
+	// generateSpec generates the handler and parses the OpenAPI fragment
+	generateSpec := func(t *testing.T, config modules.EntityConfig) map[string]interface{} {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateHandler(config))
+
+		var spec map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(readGenerated(t, basePath, "internal/api/handlers/article_openapi.yaml")), &spec))
+		return spec
+	}
+
+	schema := func(spec map[string]interface{}, name string) map[string]interface{} {
+		schemas := spec["components"].(map[string]interface{})["schemas"].(map[string]interface{})
+		return schemas[name].(map[string]interface{})
+	}
+
+	fields, err := ParseFields("title:string:required|min=3|max=200,body:string,views:int:min=0,published_at:*time.Time")
+	require.NoError(t, err)
+	config := modules.EntityConfig{Name: "Article", TableName: "articles", Timestamps: true, Fields: fields}
+
+	t.Run("should describe every CRUD endpoint", func(t *testing.T) {
+		spec := generateSpec(t, config)
+
+		assert.Equal(t, "3.0.3", spec["openapi"])
+
+		paths := spec["paths"].(map[string]interface{})
+		assert.ElementsMatch(t, []string{"/articles", "/articles/{id}", "/articles/title/{title}", "/articles/search"}, keys(paths))
+		assert.ElementsMatch(t, []string{"get", "post"}, keys(paths["/articles"].(map[string]interface{})))
+		assert.ElementsMatch(t, []string{"get", "put", "delete"}, keys(paths["/articles/{id}"].(map[string]interface{})))
+
+		create := paths["/articles"].(map[string]interface{})["post"].(map[string]interface{})
+		body := create["requestBody"].(map[string]interface{})["content"].(map[string]interface{})["application/json"].(map[string]interface{})
+		assert.Equal(t, "#/components/schemas/ArticleInput", body["schema"].(map[string]interface{})["$ref"])
+	})
+
+	t.Run("should derive schemas from the entity fields", func(t *testing.T) {
+		spec := generateSpec(t, config)
+
+		input := schema(spec, "ArticleInput")
+		properties := input["properties"].(map[string]interface{})
+		assert.ElementsMatch(t, []string{"title", "body", "views", "published_at"}, keys(properties))
+		assert.Equal(t, map[string]interface{}{"type": "string", "minLength": 3, "maxLength": 200}, properties["title"])
+		assert.Equal(t, map[string]interface{}{"type": "integer", "format": "int64", "minimum": 0}, properties["views"])
+		assert.Equal(t, map[string]interface{}{"type": "string", "format": "date-time", "nullable": true}, properties["published_at"])
+		assert.Equal(t, []interface{}{"title"}, input["required"])
+
+		entity := schema(spec, "Article")
+		assert.Contains(t, entity["properties"], "id")
+		assert.Contains(t, entity["properties"], "created_at")
+		assert.NotContains(t, entity["properties"], "deleted_at")
+	})
+
+	t.Run("should mark fields required by the validation config", func(t *testing.T) {
+		config := config
+		config.Validation = modules.ValidationConfig{
+			Required: []string{"body"},
+			Rules:    map[string]string{"views": "required,min=0"},
+		}
+
+		spec := generateSpec(t, config)
+
+		assert.Equal(t, []interface{}{"title", "body", "views"}, schema(spec, "ArticleInput")["required"])
+	})
+
+	t.Run("should leave out lookups without string fields", func(t *testing.T) {
+		fields, err := ParseFields("views:int")
+		require.NoError(t, err)
+
+		spec := generateSpec(t, modules.EntityConfig{Name: "Article", TableName: "articles", Fields: fields})
+
+		assert.ElementsMatch(t, []string{"/articles", "/articles/{id}"}, keys(spec["paths"].(map[string]interface{})))
+	})
+}
+
+// keys returns the keys of a parsed YAML mapping
+func keys(m map[string]interface{}) []string {
+	var result []string
+	for key := range m {
+		result = append(result, key)
+	}
+	return result
+}
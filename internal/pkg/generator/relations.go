@@ -0,0 +1,152 @@
+package generator
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+// relationTypes maps the relation names accepted by ParseRelations to the
+// relation types of entity specs
+var relationTypes = map[string]string{
+	"ManyToMany":               modules.RelationManyToMany,
+	modules.RelationManyToMany: modules.RelationManyToMany,
+}
+
+// entityNamePattern matches the CamelCase names related entities may have
+var entityNamePattern = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+
+// tableNamePattern finds the table name in a generated entity's GetTableName
+var tableNamePattern = regexp.MustCompile(`GetTableName\(\) string \{\s*return "([^"]+)"`)
+
+// manyToMany is a many-to-many relation as the templates render it, e.g.
+// Article to Tag through the article_tags pivot table
+type manyToMany struct {
+	Entity        string // related entity, Tag
+	Lower         string // tag
+	Plural        string // Tags
+	PluralLower   string // tags, the route segment
+	JoinEntity    string // ArticleTag
+	PivotTable    string // article_tags
+	OwnerColumn   string // article_id
+	OwnerField    string // ArticleID
+	RelatedColumn string // tag_id
+	RelatedField  string // TagID
+	RelatedTable  string // tags, read from the generated Tag entity
+	IDsKey        string // tag_ids, the JSON key of the handler's request
+}
+
+// ParseRelations parses the -relation flag: comma separated type:Entity
+// pairs such as "ManyToMany:Tag". Only many-to-many relations are generated.
+func ParseRelations(spec string) ([]modules.RelationDefinition, error) {
+	var relations []modules.RelationDefinition
+	seen := make(map[string]bool)
+
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 || parts[1] == "" {
+			return nil, fmt.Errorf("invalid relation %q, expected ManyToMany:Entity", pair)
+		}
+
+		relationType, ok := relationTypes[parts[0]]
+		if !ok {
+			return nil, fmt.Errorf("unsupported relation type %q in %q, only ManyToMany relations can be generated", parts[0], pair)
+		}
+
+		entity := parts[1]
+		if !entityNamePattern.MatchString(entity) {
+			return nil, fmt.Errorf("invalid relation entity %q, expected a CamelCase entity name", entity)
+		}
+
+		if seen[entity] {
+			return nil, fmt.Errorf("relation to %s is declared twice", entity)
+		}
+		seen[entity] = true
+
+		relations = append(relations, modules.RelationDefinition{Type: relationType, Entity: entity})
+	}
+
+	if len(relations) == 0 {
+		return nil, fmt.Errorf("no relations in %q", spec)
+	}
+	return relations, nil
+}
+
+// CheckRelations refuses relations to entities that have not been generated
+// into basePath yet: the pivot table references the related entity's table
+func CheckRelations(layout GeneratorLayout, basePath string, config modules.EntityConfig) error {
+	_, err := resolveRelations(layout, basePath, config)
+	return err
+}
+
+// manyToManyRelations derives the names of the entity's many-to-many
+// relations. RelatedTable is left for resolveRelations to fill in.
+func manyToManyRelations(config modules.EntityConfig) []manyToMany {
+	owner := toSnakeCase(config.Name)
+
+	var relations []manyToMany
+	for _, rel := range config.Relations {
+		if rel.Type != modules.RelationManyToMany {
+			continue
+		}
+
+		related := toSnakeCase(rel.Entity)
+		relation := manyToMany{
+			Entity:        rel.Entity,
+			Lower:         strings.ToLower(rel.Entity),
+			Plural:        rel.Entity + "s",
+			PluralLower:   strings.ToLower(rel.Entity) + "s",
+			JoinEntity:    config.Name + rel.Entity,
+			PivotTable:    rel.PivotTable,
+			OwnerColumn:   owner + "_id",
+			RelatedColumn: foreignKey(rel, rel.Entity),
+			IDsKey:        related + "_ids",
+		}
+		if relation.PivotTable == "" {
+			relation.PivotTable = owner + "_" + related + "s"
+		}
+		relation.OwnerField = toCamelCase(relation.OwnerColumn)
+		relation.RelatedField = toCamelCase(relation.RelatedColumn)
+
+		relations = append(relations, relation)
+	}
+	return relations
+}
+
+// resolveRelations returns the entity's many-to-many relations with the
+// tables of the related entities, which must already be generated
+func resolveRelations(layout GeneratorLayout, basePath string, config modules.EntityConfig) ([]manyToMany, error) {
+	relations := manyToManyRelations(config)
+
+	for i, relation := range relations {
+		if relation.Entity == config.Name {
+			return nil, fmt.Errorf("%s cannot have a many-to-many relation to itself", config.Name)
+		}
+
+		entityFile := filepath.Join(basePath, layout.EntityPath(modules.EntityConfig{Name: relation.Entity}))
+		source, err := os.ReadFile(entityFile)
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("related entity %s has not been generated, generate it first (expected %s)", relation.Entity, entityFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read related entity %s: %w", relation.Entity, err)
+		}
+
+		match := tableNamePattern.FindSubmatch(source)
+		if match == nil {
+			return nil, fmt.Errorf("related entity %s in %s has no GetTableName method", relation.Entity, entityFile)
+		}
+		relations[i].RelatedTable = string(match[1])
+	}
+
+	return relations, nil
+}
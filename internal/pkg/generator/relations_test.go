@@ -0,0 +1,108 @@
+package generator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+func TestParseRelations(t *testing.T) {
+	t.Run("should parse many-to-many relations", func(t *testing.T) {
+		relations, err := ParseRelations("ManyToMany:Tag, many_to_many:Category")
+
+		require.NoError(t, err)
+		assert.Equal(t, []modules.RelationDefinition{
+			{Type: modules.RelationManyToMany, Entity: "Tag"},
+			{Type: modules.RelationManyToMany, Entity: "Category"},
+		}, relations)
+	})
+
+	t.Run("should reject invalid relations", func(t *testing.T) {
+		for _, spec := range []string{
+			"",
+			"Tag",
+			"ManyToMany:",
+			"OneToMany:Comment",
+			"ManyToMany:tag",
+			"ManyToMany:Tag,ManyToMany:Tag",
+		} {
+			_, err := ParseRelations(spec)
+			assert.Error(t, err, spec)
+		}
+	})
+}
+
+func TestGenerateManyToMany(t *testing.T) {
+	relations, err := ParseRelations("ManyToMany:Tag")
+	require.NoError(t, err)
+
+	article := modules.EntityConfig{Name: "Article", TableName: "articles", Timestamps: true, Relations: relations}
+	tag := modules.EntityConfig{Name: "Tag", TableName: "tags"}
+
+	t.Run("should refuse to run before the related entity is generated", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+
+		assert.ErrorContains(t, CheckRelations(StandardLayout{}, basePath, article), "Tag has not been generated")
+		assert.ErrorContains(t, g.GenerateModule(article), "Tag has not been generated")
+	})
+
+	t.Run("should refuse relations of an entity to itself", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateEntity(tag))
+
+		self := tag
+		self.Relations = []modules.RelationDefinition{{Type: modules.RelationManyToMany, Entity: "Tag"}}
+
+		assert.ErrorContains(t, CheckRelations(StandardLayout{}, basePath, self), "to itself")
+	})
+
+	t.Run("should generate the pivot table and relation methods", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(tag))
+		require.NoError(t, CheckRelations(StandardLayout{}, basePath, article))
+		require.NoError(t, g.GenerateModule(article))
+
+		entity := readGenerated(t, basePath, "internal/domain/entities/article.go")
+		assert.Contains(t, entity, "type ArticleTag struct")
+		assert.Contains(t, entity, `return "article_tags"`)
+
+		repository := readGenerated(t, basePath, "internal/database/repositories/article_repository.go")
+		assert.Contains(t, repository, "AddTag(ctx context.Context, articleID, tagID uint) error")
+		assert.Contains(t, repository, "RemoveTag(ctx context.Context, articleID, tagID uint) error")
+		assert.Contains(t, repository, "GetTags(ctx context.Context, articleID uint) ([]*entities.ArticleTag, error)")
+
+		service := readGenerated(t, basePath, "internal/domain/services/article_service.go")
+		assert.Contains(t, service, "AttachTags(ctx context.Context, articleID uint, tagIDs []uint) error")
+		assert.Contains(t, service, "DetachTags(ctx context.Context, articleID uint, tagIDs []uint) error")
+
+		module := readGenerated(t, basePath, "internal/modules/article_module.go")
+		assert.Contains(t, module, `articleGroup.PUT("/:id/tags", handler.SetTags)`)
+		assert.Contains(t, module, "CREATE TABLE IF NOT EXISTS article_tags")
+		assert.Contains(t, module, "tag_id INTEGER NOT NULL REFERENCES tags(id) ON DELETE CASCADE")
+		assert.Contains(t, module, "PRIMARY KEY (article_id, tag_id)")
+
+		var spec map[string]interface{}
+		require.NoError(t, yaml.Unmarshal([]byte(readGenerated(t, basePath, "internal/api/handlers/article_openapi.yaml")), &spec))
+		paths := spec["paths"].(map[string]interface{})
+		assert.Contains(t, paths, "/articles/{id}/tags")
+		assert.Contains(t, spec["components"].(map[string]interface{})["schemas"], "ArticleTag")
+	})
+
+	t.Run("should leave entities without relations unchanged", func(t *testing.T) {
+		basePath := t.TempDir()
+		g := NewGenerator(logger.New("error", "json"), basePath, "github.com/example/app")
+		require.NoError(t, g.GenerateModule(tag))
+
+		module := readGenerated(t, basePath, "internal/modules/tag_module.go")
+		assert.NotContains(t, module, "Relation routes")
+		assert.NotContains(t, module, "pivot")
+	})
+}
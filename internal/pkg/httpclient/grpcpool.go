@@ -0,0 +1,86 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// GRPCClientPool shares one client connection per target between callers
+type GRPCClientPool struct {
+	dialOptions []grpc.DialOption
+	conns       map[string]*grpc.ClientConn
+	mu          sync.Mutex
+}
+
+// NewGRPCClientPool creates a pool whose connections use mTLS when
+// cfg.MTLS is enabled, server-only TLS when cfg.TLS is enabled and
+// plaintext otherwise
+func NewGRPCClientPool(cfg *config.GRPCConfig) (*GRPCClientPool, error) {
+	var transport grpc.DialOption
+
+	switch {
+	case cfg.MTLS != nil && cfg.MTLS.Enable:
+		option, err := MTLSMiddleware(cfg.MTLS.CertFile, cfg.MTLS.KeyFile, cfg.MTLS.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure gRPC mTLS: %w", err)
+		}
+		transport = option
+	case cfg.TLS != nil && cfg.TLS.Enable:
+		transport = grpc.WithTransportCredentials(credentials.NewTLS(&tls.Config{MinVersion: tls.VersionTLS12}))
+	default:
+		transport = grpc.WithTransportCredentials(insecure.NewCredentials())
+	}
+
+	return &GRPCClientPool{
+		dialOptions: []grpc.DialOption{
+			transport,
+			grpc.WithDefaultCallOptions(
+				grpc.MaxCallRecvMsgSize(cfg.MaxReceiveSize),
+				grpc.MaxCallSendMsgSize(cfg.MaxSendSize),
+			),
+		},
+		conns: make(map[string]*grpc.ClientConn),
+	}, nil
+}
+
+// Get returns the connection for target, creating it on first use
+func (p *GRPCClientPool) Get(target string) (*grpc.ClientConn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if conn, ok := p.conns[target]; ok {
+		return conn, nil
+	}
+
+	conn, err := grpc.NewClient(target, p.dialOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gRPC client for %s: %w", target, err)
+	}
+
+	p.conns[target] = conn
+	return conn, nil
+}
+
+// Close closes every pooled connection
+func (p *GRPCClientPool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var errs []error
+	for target, conn := range p.conns {
+		if err := conn.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close gRPC client for %s: %w", target, err))
+		}
+		delete(p.conns, target)
+	}
+
+	return errors.Join(errs...)
+}
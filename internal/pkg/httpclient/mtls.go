@@ -0,0 +1,70 @@
+package httpclient
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// DefaultTimeout is the overall request timeout of clients built here
+const DefaultTimeout = 30 * time.Second
+
+// LoadMTLSConfig builds a client TLS configuration that presents the
+// certificate in certFile/keyFile and only trusts servers signed by a CA
+// in caFile
+func LoadMTLSConfig(certFile, keyFile, caFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load client certificate: %w", err)
+	}
+
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA file: %w", err)
+	}
+
+	caPool := x509.NewCertPool()
+	if !caPool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in CA file %s", caFile)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		RootCAs:      caPool,
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// NewMTLSClient creates an HTTP client that authenticates itself to other
+// services with a client certificate
+func NewMTLSClient(certFile, keyFile, caFile string) (*http.Client, error) {
+	tlsConfig, err := LoadMTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   DefaultTimeout,
+	}, nil
+}
+
+// MTLSMiddleware returns the gRPC dial option that authenticates client
+// connections with the same client certificate and CA as NewMTLSClient
+func MTLSMiddleware(certFile, keyFile, caFile string) (grpc.DialOption, error) {
+	tlsConfig, err := LoadMTLSConfig(certFile, keyFile, caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	return grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)), nil
+}
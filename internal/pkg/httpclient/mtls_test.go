@@ -0,0 +1,220 @@
+package httpclient
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// testPKI is a throwaway CA with a server and a client certificate
+type testPKI struct {
+	caPool     *x509.CertPool
+	serverCert tls.Certificate
+	caFile     string
+	certFile   string
+	keyFile    string
+}
+
+func newTestPKI(t *testing.T) *testPKI {
+	t.Helper()
+	dir := t.TempDir()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-ca"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	require.NoError(t, err)
+	caCert, err := x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+
+	issue := func(serial int64, cn string, usage x509.ExtKeyUsage) ([]byte, *ecdsa.PrivateKey) {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(serial),
+			Subject:      pkix.Name{CommonName: cn},
+			NotBefore:    time.Now().Add(-time.Hour),
+			NotAfter:     time.Now().Add(time.Hour),
+			KeyUsage:     x509.KeyUsageDigitalSignature,
+			ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+			DNSNames:     []string{"localhost"},
+			IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, caCert, &key.PublicKey, caKey)
+		require.NoError(t, err)
+		return der, key
+	}
+
+	encodeKey := func(key *ecdsa.PrivateKey) []byte {
+		der, err := x509.MarshalECPrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})
+	}
+	encodeCert := func(der []byte) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	}
+
+	serverDER, serverKey := issue(2, "test-server", x509.ExtKeyUsageServerAuth)
+	serverCert, err := tls.X509KeyPair(encodeCert(serverDER), encodeKey(serverKey))
+	require.NoError(t, err)
+
+	clientDER, clientKey := issue(3, "test-client", x509.ExtKeyUsageClientAuth)
+
+	pki := &testPKI{
+		caPool:     x509.NewCertPool(),
+		serverCert: serverCert,
+		caFile:     filepath.Join(dir, "ca.crt"),
+		certFile:   filepath.Join(dir, "client.crt"),
+		keyFile:    filepath.Join(dir, "client.key"),
+	}
+	pki.caPool.AddCert(caCert)
+
+	require.NoError(t, os.WriteFile(pki.caFile, encodeCert(caDER), 0o600))
+	require.NoError(t, os.WriteFile(pki.certFile, encodeCert(clientDER), 0o600))
+	require.NoError(t, os.WriteFile(pki.keyFile, encodeKey(clientKey), 0o600))
+
+	return pki
+}
+
+// serverTLS requires clients to present a certificate signed by the test CA
+func (p *testPKI) serverTLS() *tls.Config {
+	return &tls.Config{
+		Certificates: []tls.Certificate{p.serverCert},
+		ClientCAs:    p.caPool,
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+	}
+}
+
+func TestNewMTLSClient(t *testing.T) {
+	pki := newTestPKI(t)
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = pki.serverTLS()
+	server.StartTLS()
+	defer server.Close()
+
+	t.Run("should present the client certificate", func(t *testing.T) {
+		client, err := NewMTLSClient(pki.certFile, pki.keyFile, pki.caFile)
+		require.NoError(t, err)
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+		assert.Equal(t, "test-client", string(body[:n]))
+	})
+
+	t.Run("should be rejected without a client certificate", func(t *testing.T) {
+		client := &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{RootCAs: pki.caPool}}}
+
+		_, err := client.Get(server.URL)
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should fail for missing or invalid files", func(t *testing.T) {
+		_, err := NewMTLSClient(pki.certFile, pki.keyFile, filepath.Join(t.TempDir(), "missing.crt"))
+		assert.ErrorContains(t, err, "failed to read CA file")
+
+		_, err = NewMTLSClient(pki.caFile, pki.keyFile, pki.caFile)
+		assert.ErrorContains(t, err, "failed to load client certificate")
+
+		_, err = NewMTLSClient(pki.certFile, pki.keyFile, pki.keyFile)
+		assert.ErrorContains(t, err, "no certificates found")
+	})
+}
+
+func TestGRPCClientPool(t *testing.T) {
+	pki := newTestPKI(t)
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	server := grpc.NewServer(grpc.Creds(credentials.NewTLS(pki.serverTLS())))
+	healthpb.RegisterHealthServer(server, health.NewServer())
+	go server.Serve(listener)
+	defer server.Stop()
+
+	cfg := &config.GRPCConfig{
+		MaxReceiveSize: 4 * 1024 * 1024,
+		MaxSendSize:    4 * 1024 * 1024,
+		MTLS: &config.GRPCMTLSConfig{
+			Enable:   true,
+			CertFile: pki.certFile,
+			KeyFile:  pki.keyFile,
+			CAFile:   pki.caFile,
+		},
+	}
+
+	t.Run("should call the server over mTLS", func(t *testing.T) {
+		pool, err := NewGRPCClientPool(cfg)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		conn, err := pool.Get(listener.Addr().String())
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.Status)
+	})
+
+	t.Run("should reuse connections per target", func(t *testing.T) {
+		pool, err := NewGRPCClientPool(cfg)
+		require.NoError(t, err)
+		defer pool.Close()
+
+		first, err := pool.Get(listener.Addr().String())
+		require.NoError(t, err)
+		second, err := pool.Get(listener.Addr().String())
+		require.NoError(t, err)
+
+		assert.Same(t, first, second)
+	})
+
+	t.Run("should fail when the client certificate cannot be loaded", func(t *testing.T) {
+		_, err := NewGRPCClientPool(&config.GRPCConfig{
+			MTLS: &config.GRPCMTLSConfig{Enable: true, CertFile: "missing.crt", KeyFile: "missing.key", CAFile: pki.caFile},
+		})
+
+		assert.ErrorContains(t, err, "failed to configure gRPC mTLS")
+	})
+}
@@ -0,0 +1,88 @@
+package listener
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"syscall"
+)
+
+// Listener names tagged on requests by WithName
+const (
+	Public   = "public"
+	Priority = "priority"
+)
+
+// DefaultSocketPriority is the highest SO_PRIORITY an unprivileged process
+// may set; values above 6 need CAP_NET_ADMIN
+const DefaultSocketPriority = 6
+
+type nameKey struct{}
+
+// Listen opens a TCP listener whose connections carry the given SO_PRIORITY,
+// so the kernel queues their packets ahead of ordinary traffic. The option is
+// set on the listening socket too, so values the process may not use fail
+// here instead of on every connection. On platforms without SO_PRIORITY the
+// option is ignored.
+func Listen(ctx context.Context, addr string, socketPriority int) (net.Listener, error) {
+	config := net.ListenConfig{
+		Control: func(network, address string, conn syscall.RawConn) error {
+			return controlPriority(conn, socketPriority)
+		},
+	}
+
+	ln, err := config.Listen(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", addr, err)
+	}
+	return &priorityListener{Listener: ln, priority: socketPriority}, nil
+}
+
+// priorityListener sets the socket priority on accepted connections, which
+// do not reliably inherit it from the listening socket
+type priorityListener struct {
+	net.Listener
+	priority int
+}
+
+func (l *priorityListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	// Best effort: the listening socket accepted the same value, and failing
+	// Accept would stop the server
+	if sc, ok := conn.(syscall.Conn); ok {
+		if raw, err := sc.SyscallConn(); err == nil {
+			controlPriority(raw, l.priority)
+		}
+	}
+	return conn, nil
+}
+
+func controlPriority(conn syscall.RawConn, priority int) error {
+	var sockErr error
+	if err := conn.Control(func(fd uintptr) {
+		sockErr = setSocketPriority(fd, priority)
+	}); err != nil {
+		return err
+	}
+	return sockErr
+}
+
+// WithName tags every request served by next with the listener name, so
+// routes can be limited to one listener
+func WithName(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), nameKey{}, name)))
+	})
+}
+
+// NameFromContext returns the listener a request arrived on, or "" when the
+// handler was not wrapped with WithName
+func NameFromContext(ctx context.Context) string {
+	name, _ := ctx.Value(nameKey{}).(string)
+	return name
+}
@@ -0,0 +1,106 @@
+package listener
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"runtime"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// startServer serves handler on a priority listener at a random local port
+func startServer(t *testing.T, name string, bucket *TokenBucket, handler http.Handler) string {
+	t.Helper()
+
+	ln, err := Listen(context.Background(), "127.0.0.1:0", DefaultSocketPriority)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: WithName(name, bucket.Limit(handler))}
+	go server.Serve(ln)
+	t.Cleanup(func() { server.Close() })
+
+	return "http://" + ln.Addr().String()
+}
+
+func TestPriorityListener(t *testing.T) {
+	t.Run("should serve the priority port while the public port is saturated", func(t *testing.T) {
+		release := make(chan struct{})
+		defer close(release)
+
+		mux := http.NewServeMux()
+		mux.HandleFunc("/slow", func(w http.ResponseWriter, r *http.Request) {
+			<-release
+		})
+		mux.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(NameFromContext(r.Context())))
+		})
+
+		public := startServer(t, Public, NewTokenBucket(0.01, 1), mux)
+		priority := startServer(t, Priority, NewTokenBucket(1000, 1000), mux)
+
+		// The slow request takes the public port's only token and hangs
+		go http.Get(public + "/slow")
+		require.Eventually(t, func() bool {
+			resp, err := http.Get(public + "/health")
+			if err != nil {
+				return false
+			}
+			resp.Body.Close()
+			return resp.StatusCode == http.StatusTooManyRequests
+		}, time.Second, 10*time.Millisecond)
+
+		client := &http.Client{Timeout: time.Second}
+		resp, err := client.Get(priority + "/health")
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("should tag requests with the listener name", func(t *testing.T) {
+		names := make(chan string, 1)
+		url := startServer(t, Priority, nil, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			names <- NameFromContext(r.Context())
+		}))
+
+		resp, err := http.Get(url)
+		require.NoError(t, err)
+		resp.Body.Close()
+
+		assert.Equal(t, Priority, <-names)
+	})
+
+	t.Run("should set SO_PRIORITY on accepted connections", func(t *testing.T) {
+		if runtime.GOOS != "linux" {
+			t.Skip("SO_PRIORITY is Linux only")
+		}
+
+		ln, err := Listen(context.Background(), "127.0.0.1:0", 5)
+		require.NoError(t, err)
+		defer ln.Close()
+
+		client, err := net.Dial("tcp", ln.Addr().String())
+		require.NoError(t, err)
+		defer client.Close()
+
+		conn, err := ln.Accept()
+		require.NoError(t, err)
+		defer conn.Close()
+
+		raw, err := conn.(syscall.Conn).SyscallConn()
+		require.NoError(t, err)
+
+		var priority int
+		var sockErr error
+		require.NoError(t, raw.Control(func(fd uintptr) {
+			priority, sockErr = syscall.GetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PRIORITY)
+		}))
+		require.NoError(t, sockErr)
+		assert.Equal(t, 5, priority)
+	})
+}
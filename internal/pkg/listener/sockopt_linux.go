@@ -0,0 +1,7 @@
+package listener
+
+import "syscall"
+
+func setSocketPriority(fd uintptr, priority int) error {
+	return syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, syscall.SO_PRIORITY, priority)
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package listener
+
+// SO_PRIORITY is Linux only; elsewhere the listener is opened unchanged
+func setSocketPriority(fd uintptr, priority int) error {
+	return nil
+}
@@ -0,0 +1,74 @@
+package listener
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenBucket(t *testing.T) {
+	newBucket := func(rate float64, burst int) (*TokenBucket, *time.Time) {
+		now := time.Now()
+		bucket := NewTokenBucket(rate, burst)
+		bucket.last = now
+		bucket.now = func() time.Time { return now }
+		return bucket, &now
+	}
+
+	t.Run("should allow a burst and then reject", func(t *testing.T) {
+		bucket, _ := newBucket(1, 3)
+
+		for i := 0; i < 3; i++ {
+			assert.True(t, bucket.Allow())
+		}
+		assert.False(t, bucket.Allow())
+	})
+
+	t.Run("should refill at the configured rate", func(t *testing.T) {
+		bucket, now := newBucket(10, 1)
+		assert.True(t, bucket.Allow())
+		assert.False(t, bucket.Allow())
+
+		*now = now.Add(100 * time.Millisecond)
+
+		assert.True(t, bucket.Allow())
+		assert.False(t, bucket.Allow())
+	})
+
+	t.Run("should not refill beyond the burst", func(t *testing.T) {
+		bucket, now := newBucket(10, 2)
+		*now = now.Add(time.Hour)
+
+		assert.True(t, bucket.Allow())
+		assert.True(t, bucket.Allow())
+		assert.False(t, bucket.Allow())
+	})
+
+	t.Run("should answer 429 once the bucket is empty", func(t *testing.T) {
+		bucket, _ := newBucket(1, 1)
+		handler := bucket.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		first := httptest.NewRecorder()
+		handler.ServeHTTP(first, httptest.NewRequest(http.MethodGet, "/", nil))
+		second := httptest.NewRecorder()
+		handler.ServeHTTP(second, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, first.Code)
+		assert.Equal(t, http.StatusTooManyRequests, second.Code)
+		assert.Equal(t, "1", second.Header().Get("Retry-After"))
+	})
+
+	t.Run("should not limit with a nil bucket", func(t *testing.T) {
+		var bucket *TokenBucket
+		handler := bucket.Limit(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+		for i := 0; i < 10; i++ {
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+	})
+}
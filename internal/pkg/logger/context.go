@@ -0,0 +1,54 @@
+package logger
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+)
+
+type requestIDKey struct{}
+
+// ContextWithRequestID returns a copy of ctx carrying the request ID that
+// WithContext adds to log entries
+func ContextWithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// RequestIDFromContext returns the request ID stored in ctx, or "" if there
+// is none
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// Entry is a log entry bound to a context. It logs key/value pairs the same
+// way Logger does.
+type Entry struct {
+	*logrus.Entry
+}
+
+// WithContext returns an entry for ctx, tagged with its request ID so every
+// line logged while handling a request can be correlated
+func (l *Logger) WithContext(ctx context.Context) *Entry {
+	entry := l.Logger.WithContext(ctx)
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		entry = entry.WithField("request_id", requestID)
+	}
+	return &Entry{Entry: entry}
+}
+
+func (e *Entry) Error(msg string, keysAndValues ...interface{}) {
+	e.WithFields(parseFields(keysAndValues...)).Error(msg)
+}
+
+func (e *Entry) Warn(msg string, keysAndValues ...interface{}) {
+	e.WithFields(parseFields(keysAndValues...)).Warn(msg)
+}
+
+func (e *Entry) Info(msg string, keysAndValues ...interface{}) {
+	e.WithFields(parseFields(keysAndValues...)).Info(msg)
+}
+
+func (e *Entry) Debug(msg string, keysAndValues ...interface{}) {
+	e.WithFields(parseFields(keysAndValues...)).Debug(msg)
+}
@@ -0,0 +1,44 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithContext(t *testing.T) {
+	logLine := func(t *testing.T, ctx context.Context) map[string]interface{} {
+		log := New("info", "json")
+		var buf bytes.Buffer
+		log.SetOutput(&buf)
+
+		log.WithContext(ctx).Info("handled", "status", 200)
+
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &line))
+		return line
+	}
+
+	t.Run("should tag entries with the request ID", func(t *testing.T) {
+		ctx := ContextWithRequestID(context.Background(), "req-123")
+
+		assert.Equal(t, "req-123", RequestIDFromContext(ctx))
+		assert.Equal(t, "req-123", logLine(t, ctx)["request_id"])
+	})
+
+	t.Run("should log key/value pairs as fields", func(t *testing.T) {
+		line := logLine(t, context.Background())
+
+		assert.Equal(t, "handled", line["msg"])
+		assert.EqualValues(t, 200, line["status"])
+	})
+
+	t.Run("should leave entries untagged without a request ID", func(t *testing.T) {
+		assert.Empty(t, RequestIDFromContext(context.Background()))
+		assert.NotContains(t, logLine(t, context.Background()), "request_id")
+	})
+}
@@ -33,6 +33,8 @@ func New(level, format string) *Logger {
 	// Set formatter
 	if format == "json" {
 		logger.SetFormatter(&logrus.JSONFormatter{})
+	} else if format == FormatLoki {
+		logger.SetFormatter(&LokiFormatter{})
 	} else {
 		logger.SetFormatter(&logrus.TextFormatter{
 			FullTimestamp: true,
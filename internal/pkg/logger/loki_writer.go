@@ -0,0 +1,240 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// FormatLoki selects the LokiFormatter
+const FormatLoki = "loki"
+
+const (
+	lokiPushPath         = "/loki/api/v1/push"
+	defaultLokiBatchSize = 100
+	defaultLokiBatchWait = time.Second
+)
+
+// LokiFormatter writes one JSON object per line, starting with the time
+// (RFC3339Nano), level (lowercase) and msg keys and followed by the entry's
+// fields in key order, the shape Loki's json parser expects. Fields named
+// like a fixed key are prefixed with "fields.".
+type LokiFormatter struct{}
+
+// Format renders an entry as a single NDJSON line
+func (f *LokiFormatter) Format(entry *logrus.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+
+	fixed := [][2]string{
+		{"time", entry.Time.Format(time.RFC3339Nano)},
+		{"level", strings.ToLower(entry.Level.String())},
+		{"msg", entry.Message},
+	}
+	for i, field := range fixed {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		if err := writeJSONField(&buf, field[0], field[1]); err != nil {
+			return nil, err
+		}
+	}
+
+	keys := make([]string, 0, len(entry.Data))
+	for key := range entry.Data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := entry.Data[key]
+		if err, ok := value.(error); ok {
+			value = err.Error()
+		}
+
+		name := key
+		switch key {
+		case "time", "level", "msg":
+			name = "fields." + key
+		}
+
+		buf.WriteByte(',')
+		if err := writeJSONField(&buf, name, value); err != nil {
+			return nil, err
+		}
+	}
+
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+func writeJSONField(buf *bytes.Buffer, key string, value interface{}) error {
+	encodedKey, err := json.Marshal(key)
+	if err != nil {
+		return err
+	}
+	encodedValue, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log field %s: %w", key, err)
+	}
+
+	buf.Write(encodedKey)
+	buf.WriteByte(':')
+	buf.Write(encodedValue)
+	return nil
+}
+
+// LokiConfig holds where and how log lines are pushed to Loki
+type LokiConfig struct {
+	URL       string
+	Labels    map[string]string
+	BatchSize int
+	BatchWait time.Duration
+}
+
+// lokiEntry is one line waiting to be pushed
+type lokiEntry struct {
+	timestamp time.Time
+	line      string
+}
+
+// LokiPushClient is an io.Writer that batches log lines and pushes them to
+// the Loki HTTP API. A batch is sent once it holds BatchSize lines or has
+// waited BatchWait, whichever comes first. Pushes that fail are reported on
+// stderr and dropped, so Loki being down never blocks logging.
+type LokiPushClient struct {
+	config     LokiConfig
+	httpClient *http.Client
+
+	mu      sync.Mutex
+	pending []lokiEntry
+
+	flush chan struct{}
+	done  chan struct{}
+	wg    sync.WaitGroup
+}
+
+// NewLokiPushClient starts a client pushing to config.URL
+func NewLokiPushClient(config LokiConfig) *LokiPushClient {
+	if config.BatchSize <= 0 {
+		config.BatchSize = defaultLokiBatchSize
+	}
+	if config.BatchWait <= 0 {
+		config.BatchWait = defaultLokiBatchWait
+	}
+
+	c := &LokiPushClient{
+		config:     config,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		flush:      make(chan struct{}, 1),
+		done:       make(chan struct{}),
+	}
+
+	c.wg.Add(1)
+	go c.run()
+	return c
+}
+
+// Write queues one log line. logrus calls it once per entry.
+func (c *LokiPushClient) Write(p []byte) (int, error) {
+	entry := lokiEntry{timestamp: time.Now(), line: strings.TrimSuffix(string(p), "\n")}
+
+	c.mu.Lock()
+	c.pending = append(c.pending, entry)
+	full := len(c.pending) >= c.config.BatchSize
+	c.mu.Unlock()
+
+	if full {
+		select {
+		case c.flush <- struct{}{}:
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+// Close pushes the remaining lines and stops the client
+func (c *LokiPushClient) Close() error {
+	close(c.done)
+	c.wg.Wait()
+	return nil
+}
+
+func (c *LokiPushClient) run() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(c.config.BatchWait)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.done:
+			c.push()
+			return
+		case <-ticker.C:
+			c.push()
+		case <-c.flush:
+			c.push()
+		}
+	}
+}
+
+// push sends everything queued so far as one stream
+func (c *LokiPushClient) push() {
+	c.mu.Lock()
+	batch := c.pending
+	c.pending = nil
+	c.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	values := make([][2]string, len(batch))
+	for i, entry := range batch {
+		values[i] = [2]string{strconv.FormatInt(entry.timestamp.UnixNano(), 10), entry.line}
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"streams": []map[string]interface{}{
+			{"stream": c.config.Labels, "values": values},
+		},
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to encode loki push: %v\n", err)
+		return
+	}
+
+	if err := c.post(body); err != nil {
+		fmt.Fprintf(os.Stderr, "failed to push %d log lines to loki: %v\n", len(batch), err)
+	}
+}
+
+func (c *LokiPushClient) post(body []byte) error {
+	url := strings.TrimSuffix(c.config.URL, "/") + lokiPushPath
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("loki returned status %d", resp.StatusCode)
+	}
+	return nil
+}
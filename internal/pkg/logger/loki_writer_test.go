@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lokiPush is the body of a push API request
+type lokiPush struct {
+	Streams []struct {
+		Stream map[string]string `json:"stream"`
+		Values [][2]string       `json:"values"`
+	} `json:"streams"`
+}
+
+// mockLoki records the pushes it receives
+type mockLoki struct {
+	*httptest.Server
+	mu     sync.Mutex
+	pushes []lokiPush
+}
+
+func newMockLoki(t *testing.T) *mockLoki {
+	t.Helper()
+
+	loki := &mockLoki{}
+	loki.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/loki/api/v1/push" || r.Header.Get("Content-Type") != "application/json" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		var push lokiPush
+		if err := json.NewDecoder(r.Body).Decode(&push); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		loki.mu.Lock()
+		loki.pushes = append(loki.pushes, push)
+		loki.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	t.Cleanup(loki.Close)
+	return loki
+}
+
+func (l *mockLoki) Pushes() []lokiPush {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]lokiPush(nil), l.pushes...)
+}
+
+func TestLokiFormatter(t *testing.T) {
+	entry := &logrus.Entry{
+		Time:    time.Date(2024, 5, 1, 12, 30, 0, 123456789, time.UTC),
+		Level:   logrus.WarnLevel,
+		Message: "payment retried",
+		Data: logrus.Fields{
+			"order_id": 42,
+			"error":    errors.New("card declined"),
+			"level":    "custom",
+		},
+	}
+
+	t.Run("should write the fixed keys first followed by the fields", func(t *testing.T) {
+		line, err := (&LokiFormatter{}).Format(entry)
+		require.NoError(t, err)
+
+		assert.Equal(t, `{"time":"2024-05-01T12:30:00.123456789Z","level":"warning","msg":"payment retried",`+
+			`"error":"card declined","fields.level":"custom","order_id":42}`+"\n", string(line))
+	})
+
+	t.Run("should produce one JSON object per line", func(t *testing.T) {
+		line, err := (&LokiFormatter{}).Format(&logrus.Entry{Time: time.Now(), Level: logrus.InfoLevel, Message: "multi\nline"})
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, bytes.Count(line, []byte("\n")))
+		var decoded map[string]interface{}
+		require.NoError(t, json.Unmarshal(line, &decoded))
+		assert.Equal(t, "info", decoded["level"])
+		assert.Equal(t, "multi\nline", decoded["msg"])
+	})
+
+	t.Run("should be selected by the loki format", func(t *testing.T) {
+		_, ok := New("info", FormatLoki).Formatter.(*LokiFormatter)
+		assert.True(t, ok)
+	})
+}
+
+func TestLokiPushClient(t *testing.T) {
+	labels := map[string]string{"app": "go-template", "env": "test"}
+
+	t.Run("should push batches of the configured size", func(t *testing.T) {
+		loki := newMockLoki(t)
+		client := NewLokiPushClient(LokiConfig{URL: loki.URL, Labels: labels, BatchSize: 2, BatchWait: time.Hour})
+		defer client.Close()
+
+		client.Write([]byte(`{"msg":"first"}` + "\n"))
+		client.Write([]byte(`{"msg":"second"}` + "\n"))
+
+		require.Eventually(t, func() bool { return len(loki.Pushes()) == 1 }, time.Second, 10*time.Millisecond)
+
+		push := loki.Pushes()[0]
+		require.Len(t, push.Streams, 1)
+		assert.Equal(t, labels, push.Streams[0].Stream)
+		require.Len(t, push.Streams[0].Values, 2)
+		assert.Equal(t, `{"msg":"first"}`, push.Streams[0].Values[0][1])
+		assert.Equal(t, `{"msg":"second"}`, push.Streams[0].Values[1][1])
+
+		nanos, err := strconv.ParseInt(push.Streams[0].Values[0][0], 10, 64)
+		require.NoError(t, err)
+		assert.WithinDuration(t, time.Now(), time.Unix(0, nanos), time.Minute)
+	})
+
+	t.Run("should push partial batches after the batch wait", func(t *testing.T) {
+		loki := newMockLoki(t)
+		client := NewLokiPushClient(LokiConfig{URL: loki.URL, Labels: labels, BatchSize: 100, BatchWait: 20 * time.Millisecond})
+		defer client.Close()
+
+		client.Write([]byte(`{"msg":"lonely"}` + "\n"))
+
+		require.Eventually(t, func() bool { return len(loki.Pushes()) == 1 }, time.Second, 10*time.Millisecond)
+	})
+
+	t.Run("should push what is left on close", func(t *testing.T) {
+		loki := newMockLoki(t)
+		client := NewLokiPushClient(LokiConfig{URL: loki.URL, Labels: labels, BatchSize: 100, BatchWait: time.Hour})
+
+		client.Write([]byte(`{"msg":"last words"}` + "\n"))
+		require.NoError(t, client.Close())
+
+		require.Len(t, loki.Pushes(), 1)
+		assert.Equal(t, `{"msg":"last words"}`, loki.Pushes()[0].Streams[0].Values[0][1])
+	})
+
+	t.Run("should push structured logs from the logger", func(t *testing.T) {
+		loki := newMockLoki(t)
+		client := NewLokiPushClient(LokiConfig{URL: loki.URL, Labels: labels})
+		log := New("info", FormatLoki)
+		log.SetOutput(client)
+
+		log.Info("user registered", "user_id", "42")
+		log.Debug("filtered out")
+		require.NoError(t, client.Close())
+
+		pushes := loki.Pushes()
+		require.Len(t, pushes, 1)
+		require.Len(t, pushes[0].Streams[0].Values, 1)
+
+		var line map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(pushes[0].Streams[0].Values[0][1]), &line))
+		assert.Equal(t, "info", line["level"])
+		assert.Equal(t, "user registered", line["msg"])
+		assert.Equal(t, "42", line["user_id"])
+
+		_, err := time.Parse(time.RFC3339Nano, line["time"].(string))
+		assert.NoError(t, err)
+	})
+}
@@ -0,0 +1,49 @@
+package logger
+
+import (
+	"io"
+	"os"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// Log outputs selected by LOG_OUTPUT
+const (
+	OutputStdout = "stdout"
+	OutputStderr = "stderr"
+	OutputFile   = "file"
+	OutputBoth   = "both"
+)
+
+// NewRotatingWriter writes to cfg.FilePath, rotating the file once it
+// reaches MaxSizeMB. Rotated files are kept for MaxAgeDays up to
+// MaxBackups of them, gzipped when Compress is set; zero keeps them all.
+func NewRotatingWriter(cfg *config.LoggingConfig) io.WriteCloser {
+	return &lumberjack.Logger{
+		Filename:   cfg.FilePath,
+		MaxSize:    cfg.MaxSizeMB,
+		MaxBackups: cfg.MaxBackups,
+		MaxAge:     cfg.MaxAgeDays,
+		Compress:   cfg.Compress,
+	}
+}
+
+// NewOutput returns the writer LOG_OUTPUT selects. The closer is the
+// rotating file for the file and both outputs, to be closed on shutdown,
+// and nil otherwise.
+func NewOutput(cfg *config.LoggingConfig) (io.Writer, io.Closer) {
+	switch cfg.Output {
+	case OutputFile:
+		file := NewRotatingWriter(cfg)
+		return file, file
+	case OutputBoth:
+		file := NewRotatingWriter(cfg)
+		return io.MultiWriter(os.Stdout, file), file
+	case OutputStderr:
+		return os.Stderr, nil
+	default:
+		return os.Stdout, nil
+	}
+}
@@ -0,0 +1,111 @@
+package logger
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// fillLog logs a little over a megabyte, enough to rotate a 1 MB file
+func fillLog(t *testing.T, cfg *config.LoggingConfig) {
+	t.Helper()
+
+	output, closer := NewOutput(cfg)
+	require.NotNil(t, closer)
+
+	log := New("info", "json")
+	log.SetOutput(output)
+
+	padding := strings.Repeat("x", 1024)
+	for i := 0; i < 1100; i++ {
+		log.Info("filling the log", "line", i, "padding", padding)
+	}
+	require.NoError(t, closer.Close())
+}
+
+// backups lists the rotated files next to the active log file
+func backups(t *testing.T, dir string) []string {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	var names []string
+	for _, entry := range entries {
+		if entry.Name() != "app.log" {
+			names = append(names, entry.Name())
+		}
+	}
+	return names
+}
+
+func TestNewRotatingWriter(t *testing.T) {
+	newConfig := func(t *testing.T, compress bool) (*config.LoggingConfig, string) {
+		dir := t.TempDir()
+		return &config.LoggingConfig{
+			Output:     OutputFile,
+			FilePath:   filepath.Join(dir, "app.log"),
+			MaxSizeMB:  1,
+			MaxBackups: 3,
+			MaxAgeDays: 28,
+			Compress:   compress,
+		}, dir
+	}
+
+	t.Run("should rotate the file once it reaches the maximum size", func(t *testing.T) {
+		cfg, dir := newConfig(t, false)
+
+		fillLog(t, cfg)
+
+		names := backups(t, dir)
+		require.Len(t, names, 1)
+		assert.True(t, strings.HasPrefix(names[0], "app-"))
+		assert.True(t, strings.HasSuffix(names[0], ".log"))
+
+		info, err := os.Stat(cfg.FilePath)
+		require.NoError(t, err)
+		assert.Less(t, info.Size(), int64(1024*1024))
+	})
+
+	t.Run("should compress rotated files", func(t *testing.T) {
+		cfg, dir := newConfig(t, true)
+
+		fillLog(t, cfg)
+
+		// Compression runs in the background after rotation
+		assert.Eventually(t, func() bool {
+			names := backups(t, dir)
+			return len(names) == 1 && strings.HasSuffix(names[0], ".log.gz")
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+}
+
+func TestNewOutput(t *testing.T) {
+	t.Run("should write to stdout by default", func(t *testing.T) {
+		output, closer := NewOutput(&config.LoggingConfig{Output: OutputStdout})
+
+		assert.Equal(t, os.Stdout, output)
+		assert.Nil(t, closer)
+	})
+
+	t.Run("should write to stdout and the file for both", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "app.log")
+		output, closer := NewOutput(&config.LoggingConfig{Output: OutputBoth, FilePath: path, MaxSizeMB: 1})
+		require.NotNil(t, closer)
+
+		_, err := output.Write([]byte("to both\n"))
+		require.NoError(t, err)
+		require.NoError(t, closer.Close())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		assert.Equal(t, "to both\n", string(data))
+	})
+}
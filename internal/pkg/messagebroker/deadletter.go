@@ -0,0 +1,154 @@
+package messagebroker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Headers set on dead-lettered messages
+const (
+	// DeadLetterErrorHeader holds the MessageBrokerError of the last attempt
+	DeadLetterErrorHeader = "x-dead-letter-error"
+	// DeadLetterTopicHeader holds the topic the message failed on, without
+	// the namespace prefix, so it can be published there again
+	DeadLetterTopicHeader = "x-original-topic"
+)
+
+// RetryHistoryKey is the Metadata key holding a message's failed attempts.
+// They are stored as a JSON string, the one metadata type every driver
+// carries across the wire.
+const RetryHistoryKey = "retry_history"
+
+// DeadLetterConfig routes messages that failed all their retries to a
+// dead-letter topic instead of discarding them
+type DeadLetterConfig struct {
+	Enabled bool   `json:"enabled" mapstructure:"enabled"`
+	Topic   string `json:"topic" mapstructure:"topic"`
+	// Driver publishes the dead letters; empty uses the driver the message
+	// failed on
+	Driver string `json:"driver,omitempty" mapstructure:"driver"`
+}
+
+// RetryAttempt is one failed attempt at handling a message
+type RetryAttempt struct {
+	Attempt  int       `json:"attempt"`
+	Error    string    `json:"error"`
+	FailedAt time.Time `json:"failed_at"`
+}
+
+// DeadLetterHandler receives a message that failed its last retry along
+// with the error of that attempt. A returned error means the message could
+// not be dead-lettered.
+type DeadLetterHandler func(ctx context.Context, message *Message, cause error) error
+
+// DeadLetterRouter is implemented by drivers that hand messages which ran
+// out of retries to a dead-letter handler rather than dropping them
+type DeadLetterRouter interface {
+	SetDeadLetterHandler(handler DeadLetterHandler)
+}
+
+// RecordFailure adds the failed attempt at the message's current retry
+// count to its retry history
+func (m *Message) RecordFailure(err error) {
+	history := append(m.RetryHistory(), RetryAttempt{
+		Attempt:  m.RetryCount + 1,
+		Error:    err.Error(),
+		FailedAt: time.Now(),
+	})
+
+	data, marshalErr := json.Marshal(history)
+	if marshalErr != nil {
+		return
+	}
+	if m.Metadata == nil {
+		m.Metadata = make(map[string]interface{})
+	}
+	m.Metadata[RetryHistoryKey] = string(data)
+}
+
+// RetryHistory returns the failed attempts recorded on the message, oldest
+// first
+func (m *Message) RetryHistory() []RetryAttempt {
+	data, _ := m.Metadata[RetryHistoryKey].(string)
+	if data == "" {
+		return nil
+	}
+
+	var history []RetryAttempt
+	if err := json.Unmarshal([]byte(data), &history); err != nil {
+		return nil
+	}
+	return history
+}
+
+// routeDeadLetters hands the driver's exhausted messages to the dead-letter
+// topic when dead-lettering is enabled
+func (m *Manager) routeDeadLetters(driverName string, driver MessageBroker) {
+	router, ok := driver.(DeadLetterRouter)
+	if !ok || m.config.DeadLetter == nil || !m.config.DeadLetter.Enabled {
+		return
+	}
+
+	router.SetDeadLetterHandler(func(ctx context.Context, message *Message, cause error) error {
+		return m.publishDeadLetter(ctx, driverName, message, cause)
+	})
+}
+
+// publishDeadLetter publishes a copy of the message to the dead-letter
+// topic, recording why and where it failed in its headers
+func (m *Manager) publishDeadLetter(ctx context.Context, driverName string, message *Message, cause error) error {
+	targetName := m.deadLetterDriver(driverName)
+	topic := m.topicWithNamespace(m.config.DeadLetter.Topic)
+	if message.Topic == topic {
+		return fmt.Errorf("message %s failed on the dead-letter topic itself: %w", message.ID, cause)
+	}
+
+	dead := *message
+	dead.Topic = topic
+	dead.RetryCount = 0
+	dead.Headers = make(map[string]string, len(message.Headers)+2)
+	for k, v := range message.Headers {
+		dead.Headers[k] = v
+	}
+	dead.Metadata = make(map[string]interface{}, len(message.Metadata))
+	for k, v := range message.Metadata {
+		dead.Metadata[k] = v
+	}
+
+	brokerErr := &MessageBrokerError{
+		Driver:  driverName,
+		Op:      "consume",
+		Message: fmt.Sprintf("message %s exceeded %d retries", message.ID, message.MaxRetries),
+		Err:     cause,
+	}
+	dead.Headers[DeadLetterErrorHeader] = brokerErr.Error()
+	dead.Headers[DeadLetterTopicHeader] = m.topicWithoutNamespace(message.Topic)
+
+	if err := CheckMessageSize(&dead, m.maxMessageBytes(targetName)); err != nil {
+		return err
+	}
+	return m.sinkDriver(targetName).Publish(ctx, topic, &dead)
+}
+
+// deadLetterDriver names the driver dead letters from driverName go to
+func (m *Manager) deadLetterDriver(driverName string) string {
+	if m.config.DeadLetter.Driver != "" {
+		return m.config.DeadLetter.Driver
+	}
+	return driverName
+}
+
+// ProcessDeadLetters subscribes the handler to the dead-letter topic to
+// replay or inspect failed messages. The DeadLetterTopicHeader of each
+// message names the topic to publish it to again. Without a driver
+// override the default driver's dead letters are read.
+func (m *Manager) ProcessDeadLetters(ctx context.Context, handler MessageHandler) error {
+	if m.config.DeadLetter == nil || !m.config.DeadLetter.Enabled {
+		return fmt.Errorf("dead-letter queue is not enabled")
+	}
+
+	driver := m.sinkDriver(m.deadLetterDriver(m.defaultDriver))
+	return driver.Subscribe(ctx, m.topicWithNamespace(m.config.DeadLetter.Topic), handler)
+}
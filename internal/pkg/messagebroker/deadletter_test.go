@@ -0,0 +1,129 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// routingBroker is a stubBroker that accepts a dead-letter handler
+type routingBroker struct {
+	stubBroker
+	deadLetter DeadLetterHandler
+}
+
+func (r *routingBroker) SetDeadLetterHandler(handler DeadLetterHandler) {
+	r.deadLetter = handler
+}
+
+func TestMessageRetryHistory(t *testing.T) {
+	t.Run("should record failed attempts in order", func(t *testing.T) {
+		message, err := NewMessage("orders", "payload")
+		require.NoError(t, err)
+
+		message.RecordFailure(errors.New("timeout"))
+		message.RetryCount++
+		message.RecordFailure(errors.New("connection refused"))
+
+		history := message.RetryHistory()
+		require.Len(t, history, 2)
+		assert.Equal(t, 1, history[0].Attempt)
+		assert.Equal(t, "timeout", history[0].Error)
+		assert.Equal(t, 2, history[1].Attempt)
+		assert.Equal(t, "connection refused", history[1].Error)
+		assert.IsType(t, "", message.Metadata[RetryHistoryKey])
+	})
+
+	t.Run("should be empty without failures", func(t *testing.T) {
+		assert.Empty(t, (&Message{}).RetryHistory())
+	})
+}
+
+func TestManagerDeadLetters(t *testing.T) {
+	ctx := context.Background()
+	cause := errors.New("handler failed")
+
+	newManager := func(deadLetter *DeadLetterConfig) *Manager {
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis", TopicPrefix: "app.", DeadLetter: deadLetter},
+			map[string]MessageBroker{})
+		t.Cleanup(func() { manager.Close() })
+		return manager
+	}
+	failedMessage := func() *Message {
+		message, err := NewMessage("app.orders", "payload")
+		require.NoError(t, err)
+		message.WithHeaders(map[string]string{"tenant": "acme"})
+		message.RetryCount = message.MaxRetries
+		message.RecordFailure(cause)
+		return message
+	}
+
+	t.Run("should publish exhausted messages to the dead-letter topic", func(t *testing.T) {
+		manager := newManager(&DeadLetterConfig{Enabled: true, Topic: "dead-letters"})
+		broker := &routingBroker{}
+		manager.AddDriver("redis", broker)
+		require.NotNil(t, broker.deadLetter)
+
+		message := failedMessage()
+		require.NoError(t, broker.deadLetter(ctx, message, cause))
+
+		require.Equal(t, []string{"app.dead-letters"}, broker.Topics())
+		dead := broker.Published()[0]
+		assert.Equal(t, "orders", dead.Headers[DeadLetterTopicHeader])
+		assert.Contains(t, dead.Headers[DeadLetterErrorHeader], "messagebroker: redis driver failed on consume")
+		assert.Contains(t, dead.Headers[DeadLetterErrorHeader], "handler failed")
+		assert.Equal(t, "acme", dead.Headers["tenant"])
+		assert.Len(t, dead.RetryHistory(), 1)
+		assert.Equal(t, message.Payload, dead.Payload)
+
+		assert.NotContains(t, message.Headers, DeadLetterTopicHeader)
+		assert.Equal(t, "app.orders", message.Topic)
+	})
+
+	t.Run("should publish through the driver override", func(t *testing.T) {
+		manager := newManager(&DeadLetterConfig{Enabled: true, Topic: "dead-letters", Driver: "kafka"})
+		source := &routingBroker{}
+		target := &stubBroker{}
+		manager.AddDriver("kafka", target)
+		manager.AddDriver("redis", source)
+
+		require.NoError(t, source.deadLetter(ctx, failedMessage(), cause))
+
+		assert.Empty(t, source.Published())
+		assert.Equal(t, []string{"app.dead-letters"}, target.Topics())
+	})
+
+	t.Run("should not dead-letter messages failing on the dead-letter topic", func(t *testing.T) {
+		manager := newManager(&DeadLetterConfig{Enabled: true, Topic: "dead-letters"})
+		broker := &routingBroker{}
+		manager.AddDriver("redis", broker)
+
+		message := failedMessage()
+		message.Topic = "app.dead-letters"
+
+		assert.ErrorIs(t, broker.deadLetter(ctx, message, cause), cause)
+		assert.Empty(t, broker.Published())
+	})
+
+	t.Run("should leave drivers alone while disabled", func(t *testing.T) {
+		manager := newManager(nil)
+		broker := &routingBroker{}
+		manager.AddDriver("redis", broker)
+
+		assert.Nil(t, broker.deadLetter)
+		assert.Error(t, manager.ProcessDeadLetters(ctx, func(ctx context.Context, msg *Message) error { return nil }))
+	})
+
+	t.Run("should subscribe the replay handler to the dead-letter topic", func(t *testing.T) {
+		manager := newManager(&DeadLetterConfig{Enabled: true, Topic: "dead-letters"})
+		broker := &routingBroker{}
+		manager.AddDriver("redis", broker)
+
+		require.NoError(t, manager.ProcessDeadLetters(ctx, func(ctx context.Context, msg *Message) error { return nil }))
+
+		assert.Equal(t, []string{"app.dead-letters"}, broker.Topics())
+	})
+}
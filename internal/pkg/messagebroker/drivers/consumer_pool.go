@@ -0,0 +1,310 @@
+package drivers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+const (
+	defaultScaleInterval = 5 * time.Second
+	defaultScaleUpLag    = 100
+
+	// poolQueuePerWorker bounds the messages buffered per worker before the
+	// dispatcher stops reading from the broker
+	poolQueuePerWorker = 256
+)
+
+// ConsumerConcurrency tracks the number of handler goroutines per topic.
+var ConsumerConcurrency = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "broker_consumer_concurrency",
+		Help: "Current number of concurrent consumer goroutines per topic",
+	},
+	[]string{"topic"},
+)
+
+// consumerPool runs a resizable set of workers over a work-stealing queue.
+// Each worker owns a deque that the dispatcher fills round robin; a worker
+// takes from the front of its own deque and, when that is empty, steals from
+// the back of another worker's, so a slow message does not hold up the
+// messages queued behind it.
+type consumerPool struct {
+	topic   string
+	handle  func(ctx context.Context, message *messagebroker.Message)
+	ctx     context.Context
+	slots   chan struct{}
+	wake    chan struct{}
+	pending atomic.Int64
+	wg      sync.WaitGroup
+
+	mu      sync.Mutex
+	workers []*poolWorker
+	next    int
+}
+
+// poolWorker is one consumer goroutine and its local deque
+type poolWorker struct {
+	mu    sync.Mutex
+	queue []*messagebroker.Message
+	stop  chan struct{}
+}
+
+func newConsumerPool(ctx context.Context, topic string, maxConcurrency int, handle func(context.Context, *messagebroker.Message)) *consumerPool {
+	return &consumerPool{
+		topic:  topic,
+		handle: handle,
+		ctx:    ctx,
+		slots:  make(chan struct{}, maxConcurrency*poolQueuePerWorker),
+		wake:   make(chan struct{}, maxConcurrency),
+	}
+}
+
+// Submit queues a message, blocking while the pool is full
+func (p *consumerPool) Submit(message *messagebroker.Message) bool {
+	select {
+	case p.slots <- struct{}{}:
+	case <-p.ctx.Done():
+		return false
+	}
+
+	// Pushing under the pool lock keeps messages off workers being stopped
+	p.mu.Lock()
+	worker := p.workers[p.next%len(p.workers)]
+	p.next++
+	worker.push(message)
+	p.pending.Add(1)
+	p.mu.Unlock()
+
+	p.signal()
+	return true
+}
+
+// Pending returns the number of queued messages not yet picked up
+func (p *consumerPool) Pending() int64 {
+	return p.pending.Load()
+}
+
+// Concurrency returns the current number of workers
+func (p *consumerPool) Concurrency() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.workers)
+}
+
+// SetConcurrency adds or stops workers until n are running. Stopped workers
+// finish the messages already in their deque before exiting.
+func (p *consumerPool) SetConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for len(p.workers) < n {
+		worker := &poolWorker{stop: make(chan struct{})}
+		p.workers = append(p.workers, worker)
+		p.wg.Add(1)
+		go p.run(worker)
+	}
+
+	for len(p.workers) > n {
+		last := len(p.workers) - 1
+		close(p.workers[last].stop)
+		p.workers = p.workers[:last]
+	}
+
+	ConsumerConcurrency.WithLabelValues(p.topic).Set(float64(len(p.workers)))
+}
+
+// Wait blocks until every worker has exited after the context is cancelled
+func (p *consumerPool) Wait() {
+	p.wg.Wait()
+	ConsumerConcurrency.DeleteLabelValues(p.topic)
+}
+
+func (p *consumerPool) run(worker *poolWorker) {
+	defer p.wg.Done()
+
+	for {
+		message := worker.popFront()
+		if message == nil {
+			select {
+			case <-worker.stop:
+				// This worker may have taken the wake-up meant for another
+				if p.pending.Load() > 0 {
+					p.signal()
+				}
+				return
+			default:
+			}
+			message = p.steal(worker)
+		}
+
+		if message == nil {
+			select {
+			case <-p.ctx.Done():
+				return
+			case <-worker.stop:
+			case <-p.wake:
+			}
+			continue
+		}
+
+		p.pending.Add(-1)
+		<-p.slots
+		// Pass the wake-up on so idle workers help with the remaining backlog
+		if p.pending.Load() > 0 {
+			p.signal()
+		}
+
+		p.handle(p.ctx, message)
+	}
+}
+
+// steal takes the newest message from the back of another worker's deque
+func (p *consumerPool) steal(thief *poolWorker) *messagebroker.Message {
+	p.mu.Lock()
+	victims := make([]*poolWorker, len(p.workers))
+	copy(victims, p.workers)
+	p.mu.Unlock()
+
+	for _, victim := range victims {
+		if victim == thief {
+			continue
+		}
+		if message := victim.popBack(); message != nil {
+			return message
+		}
+	}
+	return nil
+}
+
+func (p *consumerPool) signal() {
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (w *poolWorker) push(message *messagebroker.Message) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.queue = append(w.queue, message)
+}
+
+func (w *poolWorker) popFront() *messagebroker.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) == 0 {
+		return nil
+	}
+	message := w.queue[0]
+	w.queue[0] = nil
+	w.queue = w.queue[1:]
+	return message
+}
+
+func (w *poolWorker) popBack() *messagebroker.Message {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.queue) == 0 {
+		return nil
+	}
+	last := len(w.queue) - 1
+	message := w.queue[last]
+	w.queue[last] = nil
+	w.queue = w.queue[:last]
+	return message
+}
+
+// Scalable is a consumer whose number of workers can be changed at runtime
+type Scalable interface {
+	Concurrency() int
+	SetConcurrency(n int)
+}
+
+// LagFunc reports how many messages are waiting to be processed
+type LagFunc func(ctx context.Context) (int64, error)
+
+// AutoScaler resizes a consumer based on its processing lag. When the lag per
+// worker exceeds ScaleUpLag the worker count doubles, up to MaxConcurrency;
+// when it falls below a tenth of that, one worker is removed at a time, down
+// to MinConcurrency.
+type AutoScaler struct {
+	config messagebroker.ConsumerScalingConfig
+	target Scalable
+	lag    LagFunc
+}
+
+// NewAutoScaler creates an auto scaler for target
+func NewAutoScaler(config messagebroker.ConsumerScalingConfig, target Scalable, lag LagFunc) *AutoScaler {
+	if config.MinConcurrency < 1 {
+		config.MinConcurrency = 1
+	}
+	if config.MaxConcurrency < config.MinConcurrency {
+		config.MaxConcurrency = config.MinConcurrency
+	}
+	if config.ScaleInterval <= 0 {
+		config.ScaleInterval = defaultScaleInterval
+	}
+	if config.ScaleUpLag <= 0 {
+		config.ScaleUpLag = defaultScaleUpLag
+	}
+
+	return &AutoScaler{
+		config: config,
+		target: target,
+		lag:    lag,
+	}
+}
+
+// Start evaluates the lag every interval until the context is cancelled
+func (a *AutoScaler) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.ScaleInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			a.Evaluate(ctx)
+		}
+	}
+}
+
+// Evaluate checks the lag once, resizes the target if needed and returns the
+// resulting concurrency
+func (a *AutoScaler) Evaluate(ctx context.Context) (int, error) {
+	current := a.target.Concurrency()
+
+	lag, err := a.lag(ctx)
+	if err != nil {
+		return current, err
+	}
+
+	desired := current
+	perWorker := lag / int64(current)
+	switch {
+	case perWorker > int64(a.config.ScaleUpLag):
+		desired = min(current*2, a.config.MaxConcurrency)
+	case perWorker < int64(a.config.ScaleUpLag)/10:
+		desired = max(current-1, a.config.MinConcurrency)
+	}
+
+	if desired != current {
+		a.target.SetConcurrency(desired)
+	}
+	return desired, nil
+}
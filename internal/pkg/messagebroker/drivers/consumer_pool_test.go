@@ -0,0 +1,172 @@
+package drivers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+func TestConsumerPool(t *testing.T) {
+	t.Run("should handle messages concurrently", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		var active, peak atomic.Int32
+		var handled sync.WaitGroup
+		pool := newConsumerPool(ctx, "orders", 4, func(ctx context.Context, message *messagebroker.Message) {
+			defer handled.Done()
+			current := active.Add(1)
+			for {
+				old := peak.Load()
+				if current <= old || peak.CompareAndSwap(old, current) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			active.Add(-1)
+		})
+		pool.SetConcurrency(4)
+
+		handled.Add(20)
+		for i := 0; i < 20; i++ {
+			require.True(t, pool.Submit(&messagebroker.Message{ID: "msg"}))
+		}
+		handled.Wait()
+
+		assert.Equal(t, int32(4), peak.Load())
+		assert.Equal(t, int64(0), pool.Pending())
+	})
+
+	t.Run("should let idle workers steal queued messages", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		release := make(chan struct{})
+		var handled atomic.Int32
+		pool := newConsumerPool(ctx, "orders", 2, func(ctx context.Context, message *messagebroker.Message) {
+			if message.ID == "slow" {
+				<-release
+			}
+			handled.Add(1)
+		})
+		pool.SetConcurrency(2)
+
+		// Round robin puts every other message behind the slow one
+		require.True(t, pool.Submit(&messagebroker.Message{ID: "slow"}))
+		for i := 0; i < 9; i++ {
+			require.True(t, pool.Submit(&messagebroker.Message{ID: "fast"}))
+		}
+
+		assert.Eventually(t, func() bool { return handled.Load() == 9 }, time.Second, 5*time.Millisecond)
+		close(release)
+		assert.Eventually(t, func() bool { return handled.Load() == 10 }, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("should track concurrency in the gauge", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		pool := newConsumerPool(ctx, "gauge-topic", 8, func(ctx context.Context, message *messagebroker.Message) {})
+
+		pool.SetConcurrency(3)
+		assert.Equal(t, 3.0, testutil.ToFloat64(ConsumerConcurrency.WithLabelValues("gauge-topic")))
+
+		pool.SetConcurrency(1)
+		assert.Equal(t, 1, pool.Concurrency())
+		assert.Equal(t, 1.0, testutil.ToFloat64(ConsumerConcurrency.WithLabelValues("gauge-topic")))
+
+		cancel()
+		pool.Wait()
+	})
+}
+
+func TestAutoScaler(t *testing.T) {
+	config := messagebroker.ConsumerScalingConfig{
+		MinConcurrency: 1,
+		MaxConcurrency: 8,
+		ScaleInterval:  10 * time.Millisecond,
+		ScaleUpLag:     10,
+	}
+
+	t.Run("should scale up under load", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		release := make(chan struct{})
+		pool := newConsumerPool(ctx, "busy-topic", config.MaxConcurrency, func(ctx context.Context, message *messagebroker.Message) {
+			select {
+			case <-release:
+			case <-ctx.Done():
+			}
+		})
+		pool.SetConcurrency(1)
+
+		go func() {
+			for i := 0; i < 500; i++ {
+				if !pool.Submit(&messagebroker.Message{ID: "msg"}) {
+					return
+				}
+			}
+		}()
+
+		scaler := NewAutoScaler(config, pool, func(ctx context.Context) (int64, error) {
+			return pool.Pending(), nil
+		})
+		go scaler.Start(ctx)
+
+		assert.Eventually(t, func() bool { return pool.Concurrency() == config.MaxConcurrency }, 2*time.Second, 5*time.Millisecond)
+		assert.Equal(t, float64(config.MaxConcurrency), testutil.ToFloat64(ConsumerConcurrency.WithLabelValues("busy-topic")))
+		close(release)
+	})
+
+	t.Run("should scale down one worker at a time when idle", func(t *testing.T) {
+		target := &fakeScalable{concurrency: 4}
+		scaler := NewAutoScaler(config, target, func(ctx context.Context) (int64, error) { return 0, nil })
+
+		concurrency, err := scaler.Evaluate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 3, concurrency)
+
+		target.concurrency = 1
+		concurrency, err = scaler.Evaluate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 1, concurrency)
+	})
+
+	t.Run("should double up to the maximum", func(t *testing.T) {
+		target := &fakeScalable{concurrency: 3}
+		scaler := NewAutoScaler(config, target, func(ctx context.Context) (int64, error) { return 1000, nil })
+
+		concurrency, err := scaler.Evaluate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 6, concurrency)
+
+		concurrency, err = scaler.Evaluate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 8, concurrency)
+	})
+
+	t.Run("should keep concurrency when lag is moderate", func(t *testing.T) {
+		target := &fakeScalable{concurrency: 2}
+		scaler := NewAutoScaler(config, target, func(ctx context.Context) (int64, error) { return 10, nil })
+
+		concurrency, err := scaler.Evaluate(context.Background())
+		require.NoError(t, err)
+		assert.Equal(t, 2, concurrency)
+	})
+}
+
+// fakeScalable records the concurrency the auto scaler asks for
+type fakeScalable struct {
+	concurrency int
+}
+
+func (f *fakeScalable) Concurrency() int { return f.concurrency }
+
+func (f *fakeScalable) SetConcurrency(n int) { f.concurrency = n }
@@ -0,0 +1,55 @@
+package drivers
+
+import (
+	"context"
+	"log"
+	"sync"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+// deadLetters holds the handler a driver gives messages to once they run
+// out of retries. Drivers embed it to implement messagebroker.DeadLetterRouter.
+type deadLetters struct {
+	mu      sync.RWMutex
+	handler messagebroker.DeadLetterHandler
+}
+
+// SetDeadLetterHandler routes messages that failed all their retries to
+// handler instead of dropping them
+func (d *deadLetters) SetDeadLetterHandler(handler messagebroker.DeadLetterHandler) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.handler = handler
+}
+
+func (d *deadLetters) deadLetterHandler() messagebroker.DeadLetterHandler {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.handler
+}
+
+// retryOrDeadLetter records the failed attempt and publishes the message to
+// topic again until it has used up MaxRetries, then dead-letters it, or
+// drops it when no dead-letter handler is set
+func (d *deadLetters) retryOrDeadLetter(ctx context.Context, topic string, message *messagebroker.Message, cause error,
+	publish func(ctx context.Context, topic string, message *messagebroker.Message) error) {
+	message.RecordFailure(cause)
+
+	if message.RetryCount < message.MaxRetries {
+		message.RetryCount++
+		if err := publish(ctx, topic, message); err != nil {
+			log.Printf("Failed to retry message %s: %v", message.ID, err)
+		}
+		return
+	}
+
+	handler := d.deadLetterHandler()
+	if handler == nil {
+		log.Printf("Message %s exceeded max retries: %v", message.ID, cause)
+		return
+	}
+	if err := handler(ctx, message, cause); err != nil {
+		log.Printf("Failed to dead-letter message %s: %v", message.ID, err)
+	}
+}
@@ -0,0 +1,28 @@
+package drivers
+
+import (
+	"sync"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+// idempotency holds the store a driver deduplicates PublishIdempotent with.
+// Drivers embed it to implement messagebroker.IdempotencyStoreSetter.
+type idempotency struct {
+	mu    sync.RWMutex
+	store *messagebroker.IdempotencyStore
+}
+
+// SetIdempotencyStore deduplicates PublishIdempotent with store
+func (i *idempotency) SetIdempotencyStore(store *messagebroker.IdempotencyStore) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.store = store
+}
+
+// idempotencyStore returns the store, nil until one is set
+func (i *idempotency) idempotencyStore() *messagebroker.IdempotencyStore {
+	i.mu.RLock()
+	defer i.mu.RUnlock()
+	return i.store
+}
@@ -12,62 +12,32 @@ import (
 
 	"github.com/IBM/sarama"
 
-	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
 )
 
-// Message represents a message in the broker
-type Message struct {
-	ID        string                 `json:"id"`
-	Topic     string                 `json:"topic"`
-	Key       string                 `json:"key,omitempty"`
-	Value     []byte                 `json:"value"`
-	Headers   map[string]string      `json:"headers,omitempty"`
-	Metadata  map[string]interface{} `json:"metadata,omitempty"`
-	Timestamp time.Time              `json:"timestamp"`
-	Partition int32                  `json:"partition,omitempty"`
-	Offset    int64                  `json:"offset,omitempty"`
-}
-
-// BrokerStats contains statistics about the message broker
-type BrokerStats struct {
-	MessagesProduced int64         `json:"messages_produced"`
-	MessagesConsumed int64         `json:"messages_consumed"`
-	MessagesFailed   int64         `json:"messages_failed"`
-	ActiveConsumers  int           `json:"active_consumers"`
-	ActiveTopics     int           `json:"active_topics"`
-	ConnectionStatus string        `json:"connection_status"`
-	LastActivity     time.Time     `json:"last_activity"`
-	Uptime           time.Duration `json:"uptime"`
-	BytesProduced    int64         `json:"bytes_produced"`
-	BytesConsumed    int64         `json:"bytes_consumed"`
-}
-
-// TopicConfig defines configuration for creating topics
-type TopicConfig struct {
-	Partitions        int               `json:"partitions"`
-	ReplicationFactor int               `json:"replication_factor"`
-	Config            map[string]string `json:"config,omitempty"`
-}
-
-// MessageHandler is the function signature for message handlers
-type MessageHandler func(ctx context.Context, message Message) error
-
 // KafkaDriver implements MessageBroker interface for Apache Kafka
 type KafkaDriver struct {
-	config        *config.KafkaConfig
+	config        *messagebroker.KafkaConfig
 	client        sarama.Client
 	producer      sarama.SyncProducer
+	asyncProducer sarama.AsyncProducer // batches, results routed by batchDelivery
 	consumerGroup sarama.ConsumerGroup
 	consumers     map[string]*kafkaConsumer
 	mu            sync.RWMutex
 	closed        bool
-	stats         *BrokerStats
+	stats         *messagebroker.BrokerStats
 	startTime     time.Time
 	topics        map[string]bool
+	consumerLag   map[string]int64 // last measured lag by group:topic
+	lagMu         sync.Mutex
+	schemas       *schemaRegistry // nil unless SchemaRegistryURL is set
+	deadLetters
+	idempotency
 }
 
 // kafkaConsumer wraps Sarama consumer with our handler
 type kafkaConsumer struct {
+	driver  *KafkaDriver
 	handler messagebroker.MessageHandler
 	ready   chan bool
 }
@@ -90,10 +60,11 @@ func NewKafkaDriver(config *messagebroker.KafkaConfig) (*KafkaDriver, error) {
 	}
 
 	driver := &KafkaDriver{
-		config:    config,
-		startTime: time.Now(),
-		consumers: make(map[string]*kafkaConsumer),
-		topics:    make(map[string]bool),
+		config:      config,
+		startTime:   time.Now(),
+		consumers:   make(map[string]*kafkaConsumer),
+		topics:      make(map[string]bool),
+		consumerLag: make(map[string]int64),
 		stats: &messagebroker.BrokerStats{
 			DriverInfo: map[string]string{
 				"driver":   "kafka",
@@ -104,6 +75,11 @@ func NewKafkaDriver(config *messagebroker.KafkaConfig) (*KafkaDriver, error) {
 		},
 	}
 
+	if config.SchemaRegistryURL != "" {
+		driver.schemas = newSchemaRegistry(config.SchemaRegistryURL)
+		driver.stats.DriverInfo["schema_registry"] = config.SchemaRegistryURL
+	}
+
 	if err := driver.connect(); err != nil {
 		return nil, fmt.Errorf("failed to connect to Kafka: %w", err)
 	}
@@ -111,6 +87,115 @@ func NewKafkaDriver(config *messagebroker.KafkaConfig) (*KafkaDriver, error) {
 	return driver, nil
 }
 
+// RegisterSchema registers an Avro schema for the values of a topic with
+// the schema registry and returns its ID. Messages published to the topic
+// afterwards are serialized with it.
+func (k *KafkaDriver) RegisterSchema(topic, avroSchema string) (int, error) {
+	if k.schemas == nil {
+		return 0, fmt.Errorf("Kafka schema registry is not configured")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), schemaRegistryTimeout)
+	defer cancel()
+
+	return k.schemas.register(ctx, topic, avroSchema)
+}
+
+// producerMessage converts a message to a Kafka record, serializing the
+// payload with the topic's schema when there is one
+func (k *KafkaDriver) producerMessage(ctx context.Context, topic string, message *messagebroker.Message) (*sarama.ProducerMessage, error) {
+	// Serialize as Avro when the topic has a schema in the registry
+	payload := message.Payload
+	if k.schemas != nil {
+		encoded, err := k.schemas.encode(ctx, topic, payload)
+		if err != nil {
+			return nil, err
+		}
+		payload = encoded
+	}
+
+	// Create Kafka headers
+	headers := make([]sarama.RecordHeader, 0)
+	for key, value := range message.Headers {
+		headers = append(headers, sarama.RecordHeader{
+			Key:   []byte(key),
+			Value: []byte(value),
+		})
+	}
+
+	// Add metadata to headers
+	for key, value := range message.Metadata {
+		if strVal, ok := value.(string); ok {
+			headers = append(headers, sarama.RecordHeader{
+				Key:   []byte(fmt.Sprintf("meta_%s", key)),
+				Value: []byte(strVal),
+			})
+		}
+	}
+
+	// Add message info to headers
+	headers = append(headers,
+		sarama.RecordHeader{Key: []byte("message_id"), Value: []byte(message.ID)},
+		sarama.RecordHeader{Key: []byte("retry_count"), Value: []byte(fmt.Sprintf("%d", message.RetryCount))},
+		sarama.RecordHeader{Key: []byte("max_retries"), Value: []byte(fmt.Sprintf("%d", message.MaxRetries))},
+		sarama.RecordHeader{Key: []byte("timestamp"), Value: []byte(fmt.Sprintf("%d", message.Timestamp.Unix()))},
+	)
+
+	// Records sharing a key land on the same partition, which keeps them in
+	// order; without a partition key messages spread out by ID
+	key := message.ID
+	if message.PartitionKey != "" {
+		key = message.PartitionKey
+	}
+
+	return &sarama.ProducerMessage{
+		Topic:     topic,
+		Key:       sarama.StringEncoder(key),
+		Value:     sarama.ByteEncoder(payload),
+		Headers:   headers,
+		Timestamp: message.Timestamp,
+	}, nil
+}
+
+// batchDelivery is set as the Metadata of batched records, so their result
+// reaches the BatchPublish call that sent them
+type batchDelivery struct {
+	index   int
+	results chan<- batchResult
+}
+
+// batchResult is the outcome of the record at index in its batch
+type batchResult struct {
+	index int
+	err   error
+}
+
+// dispatchBatchResults routes the async producer's successes and errors to
+// the batches they belong to until the producer is closed
+func dispatchBatchResults(producer sarama.AsyncProducer) {
+	successes, failures := producer.Successes(), producer.Errors()
+	for successes != nil || failures != nil {
+		select {
+		case msg, ok := <-successes:
+			if !ok {
+				successes = nil
+				continue
+			}
+			if delivery, ok := msg.Metadata.(batchDelivery); ok {
+				delivery.results <- batchResult{index: delivery.index}
+			}
+		case produceErr, ok := <-failures:
+			if !ok {
+				failures = nil
+				continue
+			}
+			if delivery, ok := produceErr.Msg.Metadata.(batchDelivery); ok {
+				delivery.results <- batchResult{index: delivery.index, err: produceErr.Err}
+			}
+		}
+	}
+}
+
 // connect establishes connection to Kafka
 func (k *KafkaDriver) connect() error {
 	saramaConfig := sarama.NewConfig()
@@ -126,7 +211,8 @@ func (k *KafkaDriver) connect() error {
 	saramaConfig.ClientID = k.config.ClientID
 
 	// Producer configuration
-	saramaConfig.Producer.Return.Successes = k.config.ReturnSuccesses
+	// The sync producer requires successes, and batches are acknowledged with them
+	saramaConfig.Producer.Return.Successes = true
 	saramaConfig.Producer.RequiredAcks = sarama.RequiredAcks(k.config.RequiredAcks)
 	saramaConfig.Producer.Retry.Max = 3
 	saramaConfig.Producer.Flush.Frequency = k.config.FlushFrequency
@@ -216,9 +302,18 @@ func (k *KafkaDriver) connect() error {
 		return fmt.Errorf("failed to create Kafka producer: %w", err)
 	}
 
+	// Create the producer batches are sent with
+	asyncProducer, err := sarama.NewAsyncProducerFromClient(client)
+	if err != nil {
+		producer.Close()
+		client.Close()
+		return fmt.Errorf("failed to create Kafka async producer: %w", err)
+	}
+
 	// Create consumer group
 	consumerGroup, err := sarama.NewConsumerGroupFromClient(k.config.GroupID, client)
 	if err != nil {
+		asyncProducer.Close()
 		producer.Close()
 		client.Close()
 		return fmt.Errorf("failed to create Kafka consumer group: %w", err)
@@ -226,8 +321,11 @@ func (k *KafkaDriver) connect() error {
 
 	k.client = client
 	k.producer = producer
+	k.asyncProducer = asyncProducer
 	k.consumerGroup = consumerGroup
 
+	go dispatchBatchResults(asyncProducer)
+
 	k.stats.ActiveConnections = 1
 	return nil
 }
@@ -241,41 +339,20 @@ func (k *KafkaDriver) Publish(ctx context.Context, topic string, message *messag
 		return fmt.Errorf("Kafka driver is closed")
 	}
 
-	// Create Kafka headers
-	headers := make([]sarama.RecordHeader, 0)
-	for key, value := range message.Headers {
-		headers = append(headers, sarama.RecordHeader{
-			Key:   []byte(key),
-			Value: []byte(value),
-		})
+	if err := messagebroker.CheckMessageSize(message, k.config.MaxMessageBytes); err != nil {
+		return err
 	}
 
-	// Add metadata to headers
-	for key, value := range message.Metadata {
-		if strVal, ok := value.(string); ok {
-			headers = append(headers, sarama.RecordHeader{
-				Key:   []byte(fmt.Sprintf("meta_%s", key)),
-				Value: []byte(strVal),
-			})
+	kafkaMessage, err := k.producerMessage(ctx, topic, message)
+	if err != nil {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "kafka",
+			Op:      "publish",
+			Message: fmt.Sprintf("failed to serialize message for topic %s", topic),
+			Err:     err,
 		}
 	}
 
-	// Add message info to headers
-	headers = append(headers,
-		sarama.RecordHeader{Key: []byte("message_id"), Value: []byte(message.ID)},
-		sarama.RecordHeader{Key: []byte("retry_count"), Value: []byte(fmt.Sprintf("%d", message.RetryCount))},
-		sarama.RecordHeader{Key: []byte("max_retries"), Value: []byte(fmt.Sprintf("%d", message.MaxRetries))},
-		sarama.RecordHeader{Key: []byte("timestamp"), Value: []byte(fmt.Sprintf("%d", message.Timestamp.Unix()))},
-	)
-
-	kafkaMessage := &sarama.ProducerMessage{
-		Topic:     topic,
-		Key:       sarama.StringEncoder(message.ID),
-		Value:     sarama.ByteEncoder(message.Payload),
-		Headers:   headers,
-		Timestamp: message.Timestamp,
-	}
-
 	partition, offset, err := k.producer.SendMessage(kafkaMessage)
 	if err != nil {
 		return &messagebroker.MessageBrokerError{
@@ -295,6 +372,85 @@ func (k *KafkaDriver) Publish(ctx context.Context, topic string, message *messag
 	return nil
 }
 
+// BatchPublish hands the messages to the async producer, which batches them
+// into produce requests, and waits until each one is acknowledged or failed
+func (k *KafkaDriver) BatchPublish(ctx context.Context, topic string, messages []*messagebroker.Message) []error {
+	errs := make([]error, len(messages))
+	results := make(chan batchResult, len(messages))
+	batchError := func(err error) error {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "kafka",
+			Op:      "batch_publish",
+			Message: fmt.Sprintf("failed to publish message to topic %s", topic),
+			Err:     err,
+		}
+	}
+
+	// The read lock keeps Close from closing the producer's input meanwhile
+	k.mu.RLock()
+	if k.closed {
+		k.mu.RUnlock()
+		return messagebroker.BatchErrors(len(messages), fmt.Errorf("Kafka driver is closed"))
+	}
+
+	pending := make(map[int]bool, len(messages))
+	for i, message := range messages {
+		if err := messagebroker.CheckMessageSize(message, k.config.MaxMessageBytes); err != nil {
+			errs[i] = err
+			continue
+		}
+
+		kafkaMessage, err := k.producerMessage(ctx, topic, message)
+		if err != nil {
+			errs[i] = batchError(err)
+			continue
+		}
+		kafkaMessage.Metadata = batchDelivery{index: i, results: results}
+
+		select {
+		case k.asyncProducer.Input() <- kafkaMessage:
+			pending[i] = true
+		case <-ctx.Done():
+			errs[i] = batchError(ctx.Err())
+		}
+	}
+	k.mu.RUnlock()
+
+	var published int64
+	for len(pending) > 0 {
+		select {
+		case result := <-results:
+			delete(pending, result.index)
+			if result.err != nil {
+				errs[result.index] = batchError(result.err)
+				continue
+			}
+			published++
+		case <-ctx.Done():
+			// The results still in flight land in the buffered channel unread
+			for i := range pending {
+				errs[i] = batchError(ctx.Err())
+			}
+			pending = nil
+		}
+	}
+
+	k.mu.Lock()
+	k.stats.MessagesPublished += published
+	k.mu.Unlock()
+
+	return errs
+}
+
+// PublishIdempotent publishes the message unless one was already published
+// under idempotencyKey within ttl, deduplicating with the store set with
+// SetIdempotencyStore
+func (k *KafkaDriver) PublishIdempotent(ctx context.Context, topic string, message *messagebroker.Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return k.idempotencyStore().PublishOnce(ctx, idempotencyKey, ttl, func() error {
+		return k.Publish(ctx, topic, message)
+	})
+}
+
 // PublishJSON publishes JSON data to a topic
 func (k *KafkaDriver) PublishJSON(ctx context.Context, topic string, data interface{}) error {
 	message, err := messagebroker.NewMessage(topic, data)
@@ -304,6 +460,18 @@ func (k *KafkaDriver) PublishJSON(ctx context.Context, topic string, data interf
 	return k.Publish(ctx, topic, message)
 }
 
+// PublishOrdered publishes payload with partitionKey as its record key, so
+// every message published with the same key goes to the same partition and
+// is consumed in publish order
+func (k *KafkaDriver) PublishOrdered(ctx context.Context, topic, partitionKey string, payload interface{}) error {
+	message, err := messagebroker.NewMessage(topic, payload)
+	if err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+	message.PartitionKey = partitionKey
+	return k.Publish(ctx, topic, message)
+}
+
 // PublishWithDelay publishes a message with a delay (simulated with metadata)
 func (k *KafkaDriver) PublishWithDelay(ctx context.Context, topic string, message *messagebroker.Message, delay time.Duration) error {
 	// Kafka doesn't have native delayed message support
@@ -340,6 +508,7 @@ func (k *KafkaDriver) SubscribeWithGroup(ctx context.Context, topic string, grou
 	}
 
 	consumer := &kafkaConsumer{
+		driver:  k,
 		handler: handler,
 		ready:   make(chan bool),
 	}
@@ -443,6 +612,9 @@ func (c *kafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 					}
 				}
 			}
+			if key := string(message.Key); key != msg.ID {
+				msg.PartitionKey = key
+			}
 
 			// Check if message is delayed
 			if delayedUntil, exists := msg.Metadata["delayed_until"]; exists {
@@ -459,9 +631,25 @@ func (c *kafkaConsumer) ConsumeClaim(session sarama.ConsumerGroupSession, claim
 
 			// Handle the message
 			ctx := context.Background()
+
+			// Deserialize Avro payloads so handlers always get JSON
+			if c.driver.schemas != nil {
+				payload, schemaID, err := c.driver.schemas.decode(ctx, msg.Payload)
+				if err != nil {
+					log.Printf("Error deserializing message: %v", err)
+					c.driver.retryOrDeadLetter(ctx, msg.Topic, msg, err, c.driver.Publish)
+					session.MarkMessage(message, "")
+					continue
+				}
+				msg.Payload = payload
+				if schemaID > 0 {
+					msg.Metadata["schema_id"] = schemaID
+				}
+			}
+
 			if err := c.handler(ctx, msg); err != nil {
 				log.Printf("Error handling message: %v", err)
-				// Handle retry logic here if needed
+				c.driver.retryOrDeadLetter(ctx, msg.Topic, msg, err, c.driver.Publish)
 			}
 
 			// Mark message as processed
@@ -626,8 +814,13 @@ func (k *KafkaDriver) GetTopicInfo(ctx context.Context, topic string) (*messageb
 		return nil, fmt.Errorf("failed to describe topic %s: %w", topic, err)
 	}
 
-	topicMetadata, exists := metadata[topic]
-	if !exists {
+	var topicMetadata *sarama.TopicMetadata
+	for _, candidate := range metadata {
+		if candidate.Name == topic && candidate.Err == sarama.ErrNoError {
+			topicMetadata = candidate
+		}
+	}
+	if topicMetadata == nil {
 		return nil, messagebroker.ErrTopicNotFound
 	}
 
@@ -641,6 +834,97 @@ func (k *KafkaDriver) GetTopicInfo(ctx context.Context, topic string) (*messageb
 	}, nil
 }
 
+// GetConsumerGroupLag returns, per partition of the topic, how many messages
+// the consumer group has yet to commit. Partitions the group never committed
+// count every message still retained. The total is reported as ConsumerLag
+// by GetStats.
+func (k *KafkaDriver) GetConsumerGroupLag(ctx context.Context, topic string, group string) (map[int32]int64, error) {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+
+	if k.closed {
+		return nil, fmt.Errorf("Kafka driver is closed")
+	}
+
+	partitions, err := k.client.Partitions(topic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list partitions of topic %s: %w", topic, err)
+	}
+
+	clusterAdmin, err := sarama.NewClusterAdminFromClient(k.client)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cluster admin: %w", err)
+	}
+	// The admin is not closed: closing it closes k.client, which it shares
+	committed, err := clusterAdmin.ListConsumerGroupOffsets(group, map[string][]int32{topic: partitions})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch offsets of consumer group %s: %w", group, err)
+	}
+
+	oldest := make(map[int32]int64, len(partitions))
+	newest := make(map[int32]int64, len(partitions))
+	for _, partition := range partitions {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if newest[partition], err = k.client.GetOffset(topic, partition, sarama.OffsetNewest); err != nil {
+			return nil, fmt.Errorf("failed to get latest offset of %s/%d: %w", topic, partition, err)
+		}
+		if oldest[partition], err = k.client.GetOffset(topic, partition, sarama.OffsetOldest); err != nil {
+			return nil, fmt.Errorf("failed to get oldest offset of %s/%d: %w", topic, partition, err)
+		}
+	}
+
+	lag, err := consumerGroupLag(topic, committed, oldest, newest)
+	if err != nil {
+		return nil, err
+	}
+
+	var total int64
+	for _, partitionLag := range lag {
+		total += partitionLag
+	}
+	k.recordConsumerLag(fmt.Sprintf("%s:%s", group, topic), total)
+
+	return lag, nil
+}
+
+// consumerGroupLag compares the committed offsets of each partition with its
+// latest offset. Uncommitted partitions lag from their oldest offset.
+func consumerGroupLag(topic string, committed *sarama.OffsetFetchResponse, oldest, newest map[int32]int64) (map[int32]int64, error) {
+	if committed.Err != sarama.ErrNoError {
+		return nil, fmt.Errorf("failed to fetch committed offsets: %w", committed.Err)
+	}
+
+	lag := make(map[int32]int64, len(newest))
+	for partition, latest := range newest {
+		offset := oldest[partition]
+		if block := committed.GetBlock(topic, partition); block != nil {
+			if block.Err != sarama.ErrNoError {
+				return nil, fmt.Errorf("failed to fetch committed offset of %s/%d: %w", topic, partition, block.Err)
+			}
+			if block.Offset >= 0 {
+				offset = block.Offset
+			}
+		}
+
+		lag[partition] = max(latest-offset, 0)
+	}
+
+	return lag, nil
+}
+
+// recordConsumerLag keeps the lag measured for a group and topic for
+// GetStats. It runs under the read lock of GetConsumerGroupLag, so the map
+// is guarded by its own lock.
+func (k *KafkaDriver) recordConsumerLag(key string, lag int64) {
+	k.lagMu.Lock()
+	defer k.lagMu.Unlock()
+
+	k.consumerLag[key] = lag
+}
+
 // Ping checks if the connection is alive
 func (k *KafkaDriver) Ping(ctx context.Context) error {
 	k.mu.RLock()
@@ -680,10 +964,13 @@ func (k *KafkaDriver) Close() error {
 		k.consumerGroup.Close()
 	}
 
-	// Close producer
+	// Close producers, the async one after flushing pending batches
 	if k.producer != nil {
 		k.producer.Close()
 	}
+	if k.asyncProducer != nil {
+		k.asyncProducer.Close()
+	}
 
 	// Close client
 	if k.client != nil {
@@ -705,6 +992,13 @@ func (k *KafkaDriver) GetStats() (*messagebroker.BrokerStats, error) {
 
 	// Create a copy to avoid race conditions
 	statsCopy := *k.stats
+
+	k.lagMu.Lock()
+	statsCopy.ConsumerLag = 0
+	for _, lag := range k.consumerLag {
+		statsCopy.ConsumerLag += lag
+	}
+	k.lagMu.Unlock()
 	statsCopy.DriverInfo = make(map[string]string)
 	for key, value := range k.stats.DriverInfo {
 		statsCopy.DriverInfo[key] = value
@@ -0,0 +1,282 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/linkedin/goavro/v2"
+)
+
+const (
+	// avroMagicByte starts every payload in the Confluent wire format,
+	// followed by the 4-byte big-endian schema ID and the Avro binary data
+	avroMagicByte    = 0
+	avroHeaderLength = 5
+
+	schemaRegistryContentType = "application/vnd.schemaregistry.v1+json"
+	schemaRegistryTimeout     = 10 * time.Second
+
+	// missingSchemaTTL is how long a topic without a registered schema is
+	// published as is before the registry is asked again
+	missingSchemaTTL = time.Minute
+)
+
+// errSchemaNotFound is returned by the registry for unknown subjects
+var errSchemaNotFound = errors.New("schema not found")
+
+// avroSchema is a schema of the registry with its compiled codec
+type avroSchema struct {
+	id    int
+	codec *goavro.Codec
+}
+
+// schemaRegistry is a client of the Confluent Schema Registry REST API. It
+// caches schemas by ID, which never change, and the schema of each topic's
+// value subject, which is updated by register.
+type schemaRegistry struct {
+	url    string
+	client *http.Client
+
+	mu        sync.RWMutex
+	byID      map[int]*avroSchema
+	bySubject map[string]*avroSchema
+	missing   map[string]time.Time // subjects without a schema, by when they were looked up
+}
+
+func newSchemaRegistry(registryURL string) *schemaRegistry {
+	return &schemaRegistry{
+		url:       strings.TrimRight(registryURL, "/"),
+		client:    &http.Client{Timeout: schemaRegistryTimeout},
+		byID:      make(map[int]*avroSchema),
+		bySubject: make(map[string]*avroSchema),
+		missing:   make(map[string]time.Time),
+	}
+}
+
+// valueSubject is the subject of a topic's values under the registry's
+// default TopicNameStrategy
+func valueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// register registers schema as the latest version of the topic's value
+// subject and returns its ID. Registering a schema the subject already has
+// returns the existing ID.
+func (r *schemaRegistry) register(ctx context.Context, topic, schema string) (int, error) {
+	codec, err := newAvroCodec(schema)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Avro schema for topic %s: %w", topic, err)
+	}
+
+	body, err := json.Marshal(map[string]string{"schema": schema})
+	if err != nil {
+		return 0, err
+	}
+
+	var response struct {
+		ID int `json:"id"`
+	}
+	subject := valueSubject(topic)
+	path := "/subjects/" + url.PathEscape(subject) + "/versions"
+	if err := r.do(ctx, http.MethodPost, path, body, &response); err != nil {
+		return 0, fmt.Errorf("failed to register schema for topic %s: %w", topic, err)
+	}
+
+	registered := &avroSchema{id: response.ID, codec: codec}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.byID[registered.id] = registered
+	r.bySubject[subject] = registered
+	delete(r.missing, subject)
+
+	return registered.id, nil
+}
+
+// latest returns the latest schema of the topic's value subject, or nil
+// when the topic has none
+func (r *schemaRegistry) latest(ctx context.Context, topic string) (*avroSchema, error) {
+	subject := valueSubject(topic)
+
+	r.mu.RLock()
+	schema, cached := r.bySubject[subject]
+	lookedUp, missing := r.missing[subject]
+	r.mu.RUnlock()
+
+	if cached {
+		return schema, nil
+	}
+	if missing && time.Since(lookedUp) < missingSchemaTTL {
+		return nil, nil
+	}
+
+	var response struct {
+		ID     int    `json:"id"`
+		Schema string `json:"schema"`
+	}
+	path := "/subjects/" + url.PathEscape(subject) + "/versions/latest"
+	err := r.do(ctx, http.MethodGet, path, nil, &response)
+	if errors.Is(err, errSchemaNotFound) {
+		r.mu.Lock()
+		r.missing[subject] = time.Now()
+		r.mu.Unlock()
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch schema for topic %s: %w", topic, err)
+	}
+
+	schema, err = r.cache(response.ID, response.Schema)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.bySubject[subject] = schema
+	delete(r.missing, subject)
+	r.mu.Unlock()
+
+	return schema, nil
+}
+
+// byIDOrFetch returns the schema with the given ID
+func (r *schemaRegistry) byIDOrFetch(ctx context.Context, id int) (*avroSchema, error) {
+	r.mu.RLock()
+	schema, ok := r.byID[id]
+	r.mu.RUnlock()
+	if ok {
+		return schema, nil
+	}
+
+	var response struct {
+		Schema string `json:"schema"`
+	}
+	if err := r.do(ctx, http.MethodGet, fmt.Sprintf("/schemas/ids/%d", id), nil, &response); err != nil {
+		return nil, fmt.Errorf("failed to fetch schema %d: %w", id, err)
+	}
+
+	return r.cache(id, response.Schema)
+}
+
+func (r *schemaRegistry) cache(id int, schema string) (*avroSchema, error) {
+	codec, err := newAvroCodec(schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Avro schema %d: %w", id, err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.byID[id]; ok {
+		return existing, nil
+	}
+	r.byID[id] = &avroSchema{id: id, codec: codec}
+	return r.byID[id], nil
+}
+
+// do sends a request to the registry and decodes its JSON response into out
+func (r *schemaRegistry) do(ctx context.Context, method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, r.url+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", schemaRegistryContentType)
+	if body != nil {
+		req.Header.Set("Content-Type", schemaRegistryContentType)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return errSchemaNotFound
+	}
+	if resp.StatusCode >= http.StatusBadRequest {
+		var registryErr struct {
+			Message string `json:"message"`
+		}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if json.Unmarshal(data, &registryErr) == nil && registryErr.Message != "" {
+			return fmt.Errorf("schema registry returned %d: %s", resp.StatusCode, registryErr.Message)
+		}
+		return fmt.Errorf("schema registry returned %d", resp.StatusCode)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// encode serializes a JSON payload as Avro in the Confluent wire format
+// with the topic's latest schema. Payloads of topics without a schema, and
+// payloads already in the wire format, such as retries of messages that
+// could not be decoded, are returned unchanged.
+func (r *schemaRegistry) encode(ctx context.Context, topic string, payload []byte) ([]byte, error) {
+	if isAvroWireFormat(payload) {
+		return payload, nil
+	}
+
+	schema, err := r.latest(ctx, topic)
+	if err != nil || schema == nil {
+		return payload, err
+	}
+
+	native, _, err := schema.codec.NativeFromTextual(payload)
+	if err != nil {
+		return nil, fmt.Errorf("payload does not match schema %d of topic %s: %w", schema.id, topic, err)
+	}
+
+	buf := make([]byte, avroHeaderLength, avroHeaderLength+len(payload))
+	buf[0] = avroMagicByte
+	binary.BigEndian.PutUint32(buf[1:avroHeaderLength], uint32(schema.id))
+
+	return schema.codec.BinaryFromNative(buf, native)
+}
+
+// decode deserializes an Avro payload in the Confluent wire format back to
+// JSON. Payloads that do not start with the magic byte are returned
+// unchanged, along with a schema ID of 0.
+func (r *schemaRegistry) decode(ctx context.Context, payload []byte) ([]byte, int, error) {
+	if !isAvroWireFormat(payload) {
+		return payload, 0, nil
+	}
+
+	id := int(binary.BigEndian.Uint32(payload[1:avroHeaderLength]))
+	schema, err := r.byIDOrFetch(ctx, id)
+	if err != nil {
+		return nil, id, err
+	}
+
+	native, _, err := schema.codec.NativeFromBinary(payload[avroHeaderLength:])
+	if err != nil {
+		return nil, id, fmt.Errorf("failed to decode payload with schema %d: %w", id, err)
+	}
+
+	decoded, err := schema.codec.TextualFromNative(nil, native)
+	if err != nil {
+		return nil, id, fmt.Errorf("failed to decode payload with schema %d: %w", id, err)
+	}
+	return decoded, id, nil
+}
+
+// isAvroWireFormat reports whether a payload starts with the Confluent wire
+// format header. JSON never starts with a zero byte.
+func isAvroWireFormat(payload []byte) bool {
+	return len(payload) >= avroHeaderLength && payload[0] == avroMagicByte
+}
+
+// newAvroCodec compiles a schema to convert between Avro and plain JSON,
+// with unions as bare values rather than Avro's {"type": value} encoding
+func newAvroCodec(schema string) (*goavro.Codec, error) {
+	return goavro.NewCodecForStandardJSONFull(schema)
+}
@@ -0,0 +1,228 @@
+package drivers
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const orderSchema = `{
+	"type": "record",
+	"name": "Order",
+	"fields": [
+		{"name": "id", "type": "string"},
+		{"name": "total", "type": "double"},
+		{"name": "note", "type": ["null", "string"], "default": null}
+	]
+}`
+
+// fakeSchemaRegistry serves the part of the Schema Registry API the driver
+// uses and counts the requests it gets
+type fakeSchemaRegistry struct {
+	mu       sync.Mutex
+	schemas  []string       // schema i has ID i+1
+	subjects map[string]int // latest schema ID by subject
+	requests int
+}
+
+func newFakeSchemaRegistry(t *testing.T) (*fakeSchemaRegistry, *schemaRegistry) {
+	t.Helper()
+
+	fake := &fakeSchemaRegistry{subjects: make(map[string]int)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /subjects/{subject}/versions", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, schemaRegistryContentType, r.Header.Get("Content-Type"))
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, `{"error_code":42201,"message":"Invalid schema"}`, http.StatusUnprocessableEntity)
+			return
+		}
+
+		id := fake.add(r.PathValue("subject"), body.Schema)
+		json.NewEncoder(w).Encode(map[string]int{"id": id})
+	})
+	mux.HandleFunc("GET /subjects/{subject}/versions/latest", func(w http.ResponseWriter, r *http.Request) {
+		id, schema, ok := fake.latest(r.PathValue("subject"))
+		if !ok {
+			http.Error(w, `{"error_code":40401,"message":"Subject not found."}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"id": id, "schema": schema})
+	})
+	mux.HandleFunc("GET /schemas/ids/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		schema, ok := fake.schema(id)
+		if !ok {
+			http.Error(w, `{"error_code":40403,"message":"Schema not found"}`, http.StatusNotFound)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"schema": schema})
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return fake, newSchemaRegistry(server.URL + "/")
+}
+
+func (f *fakeSchemaRegistry) add(subject, schema string) int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	f.schemas = append(f.schemas, schema)
+	f.subjects[subject] = len(f.schemas)
+	return len(f.schemas)
+}
+
+func (f *fakeSchemaRegistry) latest(subject string) (int, string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	id, ok := f.subjects[subject]
+	if !ok {
+		return 0, "", false
+	}
+	return id, f.schemas[id-1], true
+}
+
+func (f *fakeSchemaRegistry) schema(id int) (string, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.requests++
+	if id < 1 || id > len(f.schemas) {
+		return "", false
+	}
+	return f.schemas[id-1], true
+}
+
+func (f *fakeSchemaRegistry) requestCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.requests
+}
+
+func TestSchemaRegistry(t *testing.T) {
+	ctx := context.Background()
+	payload := []byte(`{"id":"order-1","total":12.5,"note":"gift"}`)
+
+	t.Run("should prefix Avro payloads with the magic byte and schema ID", func(t *testing.T) {
+		fake, registry := newFakeSchemaRegistry(t)
+		fake.add("other-value", `"string"`)
+
+		id, err := registry.register(ctx, "orders", orderSchema)
+		require.NoError(t, err)
+		assert.Equal(t, 2, id)
+
+		encoded, err := registry.encode(ctx, "orders", payload)
+		require.NoError(t, err)
+
+		assert.Equal(t, byte(avroMagicByte), encoded[0])
+		assert.Equal(t, uint32(2), binary.BigEndian.Uint32(encoded[1:5]))
+		assert.Less(t, len(encoded), len(payload))
+	})
+
+	t.Run("should decode with the schema named in the payload", func(t *testing.T) {
+		fake, producer := newFakeSchemaRegistry(t)
+		fake.add("orders-value", orderSchema)
+
+		encoded, err := producer.encode(ctx, "orders", payload)
+		require.NoError(t, err)
+
+		// A consumer has no schemas cached and fetches it by ID
+		consumer := newSchemaRegistry(producer.url)
+		decoded, id, err := consumer.decode(ctx, encoded)
+		require.NoError(t, err)
+
+		assert.Equal(t, 1, id)
+		assert.JSONEq(t, string(payload), string(decoded))
+	})
+
+	t.Run("should cache schemas", func(t *testing.T) {
+		fake, registry := newFakeSchemaRegistry(t)
+		fake.add("orders-value", orderSchema)
+
+		for i := 0; i < 3; i++ {
+			encoded, err := registry.encode(ctx, "orders", payload)
+			require.NoError(t, err)
+			_, _, err = registry.decode(ctx, encoded)
+			require.NoError(t, err)
+		}
+
+		assert.Equal(t, 2, fake.requestCount(), "one add and one lookup")
+	})
+
+	t.Run("should publish topics without a schema unchanged", func(t *testing.T) {
+		fake, registry := newFakeSchemaRegistry(t)
+
+		for i := 0; i < 2; i++ {
+			encoded, err := registry.encode(ctx, "events", payload)
+			require.NoError(t, err)
+			assert.Equal(t, payload, encoded)
+		}
+		assert.Equal(t, 1, fake.requestCount(), "missing schema is remembered")
+
+		decoded, id, err := registry.decode(ctx, payload)
+		require.NoError(t, err)
+		assert.Zero(t, id)
+		assert.Equal(t, payload, decoded)
+	})
+
+	t.Run("should use a schema registered after a miss", func(t *testing.T) {
+		_, registry := newFakeSchemaRegistry(t)
+		_, err := registry.encode(ctx, "orders", payload)
+		require.NoError(t, err)
+
+		_, err = registry.register(ctx, "orders", orderSchema)
+		require.NoError(t, err)
+		encoded, err := registry.encode(ctx, "orders", payload)
+		require.NoError(t, err)
+
+		assert.Equal(t, byte(avroMagicByte), encoded[0])
+	})
+
+	t.Run("should reject payloads that do not match the schema", func(t *testing.T) {
+		_, registry := newFakeSchemaRegistry(t)
+		_, err := registry.register(ctx, "orders", orderSchema)
+		require.NoError(t, err)
+
+		_, err = registry.encode(ctx, "orders", []byte(`{"id":"order-1"}`))
+
+		assert.ErrorContains(t, err, "payload does not match schema 1 of topic orders")
+	})
+
+	t.Run("should reject invalid schemas without registering them", func(t *testing.T) {
+		fake, registry := newFakeSchemaRegistry(t)
+
+		_, err := registry.register(ctx, "orders", `{"type": "record"}`)
+
+		assert.ErrorContains(t, err, "invalid Avro schema for topic orders")
+		assert.Zero(t, fake.requestCount())
+	})
+
+	t.Run("should fail on unknown schema IDs", func(t *testing.T) {
+		_, registry := newFakeSchemaRegistry(t)
+
+		_, id, err := registry.decode(ctx, []byte{avroMagicByte, 0, 0, 0, 7, 2})
+
+		assert.Equal(t, 7, id)
+		assert.ErrorContains(t, err, "failed to fetch schema 7")
+	})
+}
+
+func TestKafkaDriverRegisterSchema(t *testing.T) {
+	t.Run("should fail without a schema registry", func(t *testing.T) {
+		_, err := (&KafkaDriver{}).RegisterSchema("orders", orderSchema)
+
+		assert.ErrorContains(t, err, "schema registry is not configured")
+	})
+}
@@ -0,0 +1,70 @@
+package drivers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/IBM/sarama"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+func TestConsumerGroupLag(t *testing.T) {
+	oldest := map[int32]int64{0: 0, 1: 10, 2: 5}
+	newest := map[int32]int64{0: 100, 1: 50, 2: 5}
+
+	t.Run("should compare committed offsets with the latest offsets", func(t *testing.T) {
+		committed := &sarama.OffsetFetchResponse{}
+		committed.AddBlock("orders", 0, &sarama.OffsetFetchResponseBlock{Offset: 60})
+		committed.AddBlock("orders", 1, &sarama.OffsetFetchResponseBlock{Offset: 50})
+		committed.AddBlock("orders", 2, &sarama.OffsetFetchResponseBlock{Offset: 5})
+
+		lag, err := consumerGroupLag("orders", committed, oldest, newest)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[int32]int64{0: 40, 1: 0, 2: 0}, lag)
+	})
+
+	t.Run("should count retained messages of uncommitted partitions", func(t *testing.T) {
+		committed := &sarama.OffsetFetchResponse{}
+		committed.AddBlock("orders", 0, &sarama.OffsetFetchResponseBlock{Offset: -1})
+
+		lag, err := consumerGroupLag("orders", committed, oldest, newest)
+
+		require.NoError(t, err)
+		assert.Equal(t, map[int32]int64{0: 100, 1: 40, 2: 0}, lag)
+	})
+
+	t.Run("should fail on partition errors", func(t *testing.T) {
+		committed := &sarama.OffsetFetchResponse{}
+		committed.AddBlock("orders", 1, &sarama.OffsetFetchResponseBlock{Offset: -1, Err: sarama.ErrNotCoordinatorForConsumer})
+
+		_, err := consumerGroupLag("orders", committed, oldest, newest)
+
+		assert.ErrorIs(t, err, sarama.ErrNotCoordinatorForConsumer)
+	})
+}
+
+func TestKafkaProducerMessageKey(t *testing.T) {
+	driver := &KafkaDriver{}
+
+	t.Run("should key records by partition key", func(t *testing.T) {
+		message := &messagebroker.Message{ID: "1", PartitionKey: "customer-1"}
+
+		record, err := driver.producerMessage(context.Background(), "orders", message)
+
+		require.NoError(t, err)
+		assert.Equal(t, sarama.StringEncoder("customer-1"), record.Key)
+	})
+
+	t.Run("should key records by ID without a partition key", func(t *testing.T) {
+		message := &messagebroker.Message{ID: "1"}
+
+		record, err := driver.producerMessage(context.Background(), "orders", message)
+
+		require.NoError(t, err)
+		assert.Equal(t, sarama.StringEncoder("1"), record.Key)
+	})
+}
@@ -0,0 +1,103 @@
+package drivers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+// ordering holds the store a driver keeps the messages of a partition key in
+// order with. Drivers embed it to implement messagebroker.OrderingStoreSetter.
+type ordering struct {
+	mu    sync.RWMutex
+	store *messagebroker.OrderingStore
+	keys  keyedMutex
+}
+
+// SetOrderingStore queues messages with a partition key in store, handling
+// them in publish order
+func (o *ordering) SetOrderingStore(store *messagebroker.OrderingStore) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.store = store
+}
+
+// orderingStore returns the store, nil until one is set
+func (o *ordering) orderingStore() *messagebroker.OrderingStore {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.store
+}
+
+// appendOrdered queues a message with a partition key before it is
+// published, so consumers find it behind the messages published before it
+func (o *ordering) appendOrdered(ctx context.Context, topic string, message *messagebroker.Message) error {
+	store := o.orderingStore()
+	if store == nil || message.PartitionKey == "" {
+		return nil
+	}
+	_, err := store.Append(ctx, topic, message)
+	return err
+}
+
+// handleOrdered handles a delivered message. For a message with a partition
+// key, the oldest message queued under the key is handled in its place, one
+// at a time per key, and handling is skipped when another consumer already
+// took the queue's messages.
+func (o *ordering) handleOrdered(ctx context.Context, topic string, message *messagebroker.Message, handle func(*messagebroker.Message) error) error {
+	store := o.orderingStore()
+	if store == nil || message.PartitionKey == "" {
+		return handle(message)
+	}
+
+	unlock := o.keys.lock(topic + ":" + message.PartitionKey)
+	defer unlock()
+
+	next, err := store.Next(ctx, topic, message.PartitionKey)
+	if err != nil {
+		return err
+	}
+	if next == nil {
+		return nil
+	}
+	return handle(next)
+}
+
+// keyedMutex serializes work per key, forgetting keys nobody holds
+type keyedMutex struct {
+	mu    sync.Mutex
+	locks map[string]*keyedLock
+}
+
+type keyedLock struct {
+	mu      sync.Mutex
+	holders int
+}
+
+// lock locks key and returns the function unlocking it
+func (k *keyedMutex) lock(key string) func() {
+	k.mu.Lock()
+	if k.locks == nil {
+		k.locks = make(map[string]*keyedLock)
+	}
+	l, exists := k.locks[key]
+	if !exists {
+		l = &keyedLock{}
+		k.locks[key] = l
+	}
+	l.holders++
+	k.mu.Unlock()
+
+	l.mu.Lock()
+	return func() {
+		l.mu.Unlock()
+
+		k.mu.Lock()
+		l.holders--
+		if l.holders == 0 {
+			delete(k.locks, key)
+		}
+		k.mu.Unlock()
+	}
+}
@@ -0,0 +1,77 @@
+package drivers
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+func TestOrderingHandleOrdered(t *testing.T) {
+	t.Run("should handle messages directly without a store", func(t *testing.T) {
+		var o ordering
+		message := &messagebroker.Message{ID: "1", PartitionKey: "customer-1"}
+
+		var handled *messagebroker.Message
+		err := o.handleOrdered(context.Background(), "orders", message, func(m *messagebroker.Message) error {
+			handled = m
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Same(t, message, handled)
+		assert.NoError(t, o.appendOrdered(context.Background(), "orders", message))
+	})
+}
+
+func TestKeyedMutex(t *testing.T) {
+	t.Run("should serialize work on the same key", func(t *testing.T) {
+		var keys keyedMutex
+		var running, maxRunning int32
+		var wg sync.WaitGroup
+
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				unlock := keys.lock("orders:customer-1")
+				defer unlock()
+
+				n := atomic.AddInt32(&running, 1)
+				if n > atomic.LoadInt32(&maxRunning) {
+					atomic.StoreInt32(&maxRunning, n)
+				}
+				time.Sleep(time.Millisecond)
+				atomic.AddInt32(&running, -1)
+			}()
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), maxRunning)
+		assert.Empty(t, keys.locks)
+	})
+
+	t.Run("should not block other keys", func(t *testing.T) {
+		var keys keyedMutex
+		unlock := keys.lock("orders:customer-1")
+		defer unlock()
+
+		done := make(chan struct{})
+		go func() {
+			keys.lock("orders:customer-2")()
+			close(done)
+		}()
+
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("lock on another key blocked")
+		}
+	})
+}
@@ -25,6 +25,9 @@ type RabbitMQDriver struct {
 	startTime time.Time
 	exchanges map[string]bool
 	queues    map[string]bool
+	deadLetters
+	idempotency
+	ordering
 }
 
 // NewRabbitMQDriver creates a new RabbitMQ driver instance
@@ -108,6 +111,38 @@ func (r *RabbitMQDriver) connect() error {
 	return nil
 }
 
+// amqpPublishing converts a message to a persistent AMQP publishing,
+// carrying its headers, metadata and retry information as AMQP headers
+func amqpPublishing(message *messagebroker.Message) amqp.Publishing {
+	headers := make(amqp.Table)
+	for k, v := range message.Headers {
+		headers[k] = v
+	}
+
+	// Add metadata to headers
+	for k, v := range message.Metadata {
+		headers[fmt.Sprintf("meta_%s", k)] = v
+	}
+
+	// Add message info to headers
+	headers["message_id"] = message.ID
+	headers["retry_count"] = message.RetryCount
+	headers["max_retries"] = message.MaxRetries
+	headers["timestamp"] = message.Timestamp.Unix()
+	if message.PartitionKey != "" {
+		headers["partition_key"] = message.PartitionKey
+	}
+
+	return amqp.Publishing{
+		DeliveryMode: amqp.Persistent, // Make message persistent
+		ContentType:  "application/json",
+		Body:         message.Payload,
+		MessageId:    message.ID,
+		Timestamp:    message.Timestamp,
+		Headers:      headers,
+	}
+}
+
 // declareExchange declares an exchange if it doesn't exist
 func (r *RabbitMQDriver) declareExchange(name, exchangeType string) error {
 	r.mu.Lock()
@@ -204,35 +239,23 @@ func (r *RabbitMQDriver) Publish(ctx context.Context, topic string, message *mes
 		return fmt.Errorf("RabbitMQ driver is closed")
 	}
 
+	if err := messagebroker.CheckMessageSize(message, r.config.MaxMessageBytes); err != nil {
+		return err
+	}
+
 	// Ensure exchange exists
 	if err := r.declareExchange(r.config.Exchange, r.config.ExchangeType); err != nil {
 		return fmt.Errorf("failed to declare exchange: %w", err)
 	}
 
-	// Convert message to AMQP format
-	headers := make(amqp.Table)
-	for k, v := range message.Headers {
-		headers[k] = v
-	}
-
-	// Add metadata to headers
-	for k, v := range message.Metadata {
-		headers[fmt.Sprintf("meta_%s", k)] = v
-	}
-
-	// Add message info to headers
-	headers["message_id"] = message.ID
-	headers["retry_count"] = message.RetryCount
-	headers["max_retries"] = message.MaxRetries
-	headers["timestamp"] = message.Timestamp.Unix()
-
-	publishing := amqp.Publishing{
-		DeliveryMode: amqp.Persistent, // Make message persistent
-		ContentType:  "application/json",
-		Body:         message.Payload,
-		MessageId:    message.ID,
-		Timestamp:    message.Timestamp,
-		Headers:      headers,
+	// Queue messages with a partition key so consumers take them in order
+	if err := r.appendOrdered(ctx, topic, message); err != nil {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "rabbitmq",
+			Op:      "publish",
+			Message: fmt.Sprintf("failed to order message for topic %s", topic),
+			Err:     err,
+		}
 	}
 
 	err := r.channel.Publish(
@@ -240,7 +263,7 @@ func (r *RabbitMQDriver) Publish(ctx context.Context, topic string, message *mes
 		topic,             // routing key
 		false,             // mandatory
 		false,             // immediate
-		publishing,
+		amqpPublishing(message),
 	)
 
 	if err != nil {
@@ -259,6 +282,112 @@ func (r *RabbitMQDriver) Publish(ctx context.Context, topic string, message *mes
 	return nil
 }
 
+// BatchPublish publishes the messages on a dedicated channel in confirm
+// mode and waits for the broker to confirm the whole batch, instead of
+// waiting on each message. A message is failed when the broker nacks it.
+func (r *RabbitMQDriver) BatchPublish(ctx context.Context, topic string, messages []*messagebroker.Message) []error {
+	r.mu.RLock()
+	closed, conn := r.closed, r.conn
+	r.mu.RUnlock()
+
+	if closed {
+		return messagebroker.BatchErrors(len(messages), fmt.Errorf("RabbitMQ driver is closed"))
+	}
+
+	if err := r.declareExchange(r.config.Exchange, r.config.ExchangeType); err != nil {
+		return messagebroker.BatchErrors(len(messages), fmt.Errorf("failed to declare exchange: %w", err))
+	}
+
+	batchError := func(err error) error {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "rabbitmq",
+			Op:      "batch_publish",
+			Message: fmt.Sprintf("failed to publish message to topic %s", topic),
+			Err:     err,
+		}
+	}
+
+	// Confirm mode stays on for the life of a channel, so the batch gets its own
+	channel, err := conn.Channel()
+	if err != nil {
+		return messagebroker.BatchErrors(len(messages), batchError(err))
+	}
+	defer channel.Close()
+
+	if err := channel.Confirm(false); err != nil {
+		return messagebroker.BatchErrors(len(messages), batchError(err))
+	}
+	confirms := channel.NotifyPublish(make(chan amqp.Confirmation, len(messages)))
+
+	errs := make([]error, len(messages))
+	// Delivery tags are numbered from 1 in publishing order on the channel
+	pending := make(map[uint64]int, len(messages))
+	var tag uint64
+	for i, message := range messages {
+		if err := messagebroker.CheckMessageSize(message, r.config.MaxMessageBytes); err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := r.appendOrdered(ctx, topic, message); err != nil {
+			errs[i] = batchError(err)
+			continue
+		}
+
+		err := channel.Publish(r.config.Exchange, topic, false, false, amqpPublishing(message))
+		if err != nil {
+			errs[i] = batchError(err)
+			continue
+		}
+		tag++
+		pending[tag] = i
+	}
+
+	var published int64
+	for len(pending) > 0 {
+		select {
+		case confirm, ok := <-confirms:
+			if !ok {
+				// The channel closed before confirming the rest of the batch
+				for _, i := range pending {
+					errs[i] = batchError(amqp.ErrClosed)
+				}
+				pending = nil
+				continue
+			}
+			i, found := pending[confirm.DeliveryTag]
+			if !found {
+				continue
+			}
+			delete(pending, confirm.DeliveryTag)
+			if !confirm.Ack {
+				errs[i] = batchError(fmt.Errorf("message %s was nacked by the broker", messages[i].ID))
+				continue
+			}
+			published++
+		case <-ctx.Done():
+			for _, i := range pending {
+				errs[i] = batchError(ctx.Err())
+			}
+			pending = nil
+		}
+	}
+
+	r.mu.Lock()
+	r.stats.MessagesPublished += published
+	r.mu.Unlock()
+
+	return errs
+}
+
+// PublishIdempotent publishes the message unless one was already published
+// under idempotencyKey within ttl, deduplicating with the store set with
+// SetIdempotencyStore
+func (r *RabbitMQDriver) PublishIdempotent(ctx context.Context, topic string, message *messagebroker.Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return r.idempotencyStore().PublishOnce(ctx, idempotencyKey, ttl, func() error {
+		return r.Publish(ctx, topic, message)
+	})
+}
+
 // PublishJSON publishes JSON data to a topic
 func (r *RabbitMQDriver) PublishJSON(ctx context.Context, topic string, data interface{}) error {
 	message, err := messagebroker.NewMessage(topic, data)
@@ -442,6 +571,8 @@ func (r *RabbitMQDriver) SubscribeWithGroup(ctx context.Context, topic string, g
 					if strVal, ok := v.(string); ok {
 						if strings.HasPrefix(k, "meta_") {
 							message.Metadata[strings.TrimPrefix(k, "meta_")] = strVal
+						} else if k == "partition_key" {
+							message.PartitionKey = strVal
 						} else {
 							message.Headers[k] = strVal
 						}
@@ -449,26 +580,22 @@ func (r *RabbitMQDriver) SubscribeWithGroup(ctx context.Context, topic string, g
 				}
 
 				// Extract retry information
-				if retryCount, exists := msg.Headers["retry_count"]; exists {
-					if count, ok := retryCount.(int); ok {
-						message.RetryCount = count
-					}
+				if count, ok := headerInt(msg.Headers["retry_count"]); ok {
+					message.RetryCount = count
 				}
-				if maxRetries, exists := msg.Headers["max_retries"]; exists {
-					if max, ok := maxRetries.(int); ok {
-						message.MaxRetries = max
-					}
+				if max, ok := headerInt(msg.Headers["max_retries"]); ok {
+					message.MaxRetries = max
 				}
 
-				// Handle message
-				if err := handler(ctx, message); err != nil {
-					// Handle retry logic
-					if message.RetryCount < message.MaxRetries {
-						message.RetryCount++
-						if retryErr := r.Publish(ctx, topic, message); retryErr != nil {
-							log.Printf("Failed to retry message %s: %v", message.ID, retryErr)
-						}
+				// Handle message, or the oldest one queued under its partition key
+				err := r.handleOrdered(ctx, topic, message, func(message *messagebroker.Message) error {
+					err := handler(ctx, message)
+					if err != nil {
+						r.retryOrDeadLetter(ctx, topic, message, err, r.Publish)
 					}
+					return err
+				})
+				if err != nil {
 					msg.Nack(false, false) // Don't requeue, we handle retry ourselves
 				} else {
 					msg.Ack(false)
@@ -664,3 +791,22 @@ func (r *RabbitMQDriver) GetStats() (*messagebroker.BrokerStats, error) {
 
 	return &statsCopy, nil
 }
+
+// headerInt reads an integer header, which AMQP decodes to a sized integer
+// type depending on how it was encoded
+func headerInt(value interface{}) (int, bool) {
+	switch v := value.(type) {
+	case int:
+		return v, true
+	case int8:
+		return int(v), true
+	case int16:
+		return int(v), true
+	case int32:
+		return int(v), true
+	case int64:
+		return int(v), true
+	default:
+		return 0, false
+	}
+}
@@ -21,19 +21,26 @@ type RedisPubSubDriver struct {
 	client      *redis.Client
 	pubsub      map[string]*redis.PubSub
 	subscribers map[string]*redisSubscriber
+	patterns    map[string]*redisSubscriber
 	mu          sync.RWMutex
 	closed      bool
 	stats       *messagebroker.BrokerStats
 	startTime   time.Time
 	topics      map[string]bool
+	streams     map[string]*streamSubscriber
+	deadLetters
+	idempotency
+	ordering
 }
 
-// redisSubscriber wraps Redis PubSub with our handler
+// redisSubscriber wraps Redis PubSub with our handler. The topic of a
+// pattern subscription is its pattern.
 type redisSubscriber struct {
 	pubsub  *redis.PubSub
 	handler messagebroker.MessageHandler
 	topic   string
 	group   string
+	pattern bool
 	cancel  context.CancelFunc
 }
 
@@ -47,8 +54,10 @@ func NewRedisPubSubDriver(config *messagebroker.RedisPubSubConfig) (*RedisPubSub
 		config:      config,
 		pubsub:      make(map[string]*redis.PubSub),
 		subscribers: make(map[string]*redisSubscriber),
+		patterns:    make(map[string]*redisSubscriber),
 		startTime:   time.Now(),
 		topics:      make(map[string]bool),
+		streams:     make(map[string]*streamSubscriber),
 		stats: &messagebroker.BrokerStats{
 			DriverInfo: map[string]string{
 				"driver": "redis_pubsub",
@@ -62,22 +71,26 @@ func NewRedisPubSubDriver(config *messagebroker.RedisPubSubConfig) (*RedisPubSub
 		return nil, fmt.Errorf("failed to connect to Redis: %w", err)
 	}
 
+	// Idempotency keys live next to the messages unless a store is set
+	driver.SetIdempotencyStore(messagebroker.NewIdempotencyStore(driver.client))
+	driver.SetOrderingStore(messagebroker.NewOrderingStore(driver.client))
+
 	return driver, nil
 }
 
 // connect establishes connection to Redis
 func (r *RedisPubSubDriver) connect() error {
 	options := &redis.Options{
-		Addr:         fmt.Sprintf("%s:%d", r.config.Host, r.config.Port),
-		Password:     r.config.Password,
-		DB:           r.config.DB,
-		PoolSize:     r.config.PoolSize,
-		MinIdleConns: r.config.MinIdleConns,
-		MaxRetries:   r.config.MaxRetries,
-		DialTimeout:  r.config.ConnectTimeout,
-		ReadTimeout:  r.config.ReadTimeout,
-		WriteTimeout: r.config.WriteTimeout,
-		IdleTimeout:  r.config.IdleTimeout,
+		Addr:            fmt.Sprintf("%s:%d", r.config.Host, r.config.Port),
+		Password:        r.config.Password,
+		DB:              r.config.DB,
+		PoolSize:        r.config.PoolSize,
+		MinIdleConns:    r.config.MinIdleConns,
+		MaxRetries:      r.config.MaxRetries,
+		DialTimeout:     r.config.ConnectTimeout,
+		ReadTimeout:     r.config.ReadTimeout,
+		WriteTimeout:    r.config.WriteTimeout,
+		ConnMaxIdleTime: r.config.IdleTimeout,
 	}
 
 	// TLS configuration
@@ -116,28 +129,30 @@ func (r *RedisPubSubDriver) connect() error {
 // Publish publishes a message to a topic
 func (r *RedisPubSubDriver) Publish(ctx context.Context, topic string, message *messagebroker.Message) error {
 	r.mu.RLock()
-	defer r.mu.RUnlock()
+	closed := r.closed
+	r.mu.RUnlock()
 
-	if r.closed {
+	if closed {
 		return fmt.Errorf("Redis Pub/Sub driver is closed")
 	}
 
-	// Create Redis message with metadata
-	redisMessage := map[string]interface{}{
-		"id":          message.ID,
-		"topic":       message.Topic,
-		"payload":     string(message.Payload),
-		"headers":     message.Headers,
-		"timestamp":   message.Timestamp.Unix(),
-		"retry_count": message.RetryCount,
-		"max_retries": message.MaxRetries,
-		"metadata":    message.Metadata,
+	if err := messagebroker.CheckMessageSize(message, r.config.MaxMessageBytes); err != nil {
+		return err
 	}
 
-	// Serialize message
-	data, err := json.Marshal(redisMessage)
+	data, err := encodeRedisMessage(message)
 	if err != nil {
-		return fmt.Errorf("failed to marshal message: %w", err)
+		return err
+	}
+
+	// Queue messages with a partition key so consumers take them in order
+	if err := r.appendOrdered(ctx, topic, message); err != nil {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "redis_pubsub",
+			Op:      "publish",
+			Message: fmt.Sprintf("failed to order message for topic %s", topic),
+			Err:     err,
+		}
 	}
 
 	// Publish to Redis
@@ -159,6 +174,81 @@ func (r *RedisPubSubDriver) Publish(ctx context.Context, topic string, message *
 	return nil
 }
 
+// BatchPublish publishes the messages through a single pipeline, sending
+// every PUBLISH in one round trip
+func (r *RedisPubSubDriver) BatchPublish(ctx context.Context, topic string, messages []*messagebroker.Message) []error {
+	r.mu.RLock()
+	closed := r.closed
+	r.mu.RUnlock()
+
+	if closed {
+		return messagebroker.BatchErrors(len(messages), fmt.Errorf("Redis Pub/Sub driver is closed"))
+	}
+
+	errs := make([]error, len(messages))
+	cmds := make([]*redis.IntCmd, len(messages))
+	pipe := r.client.Pipeline()
+	for i, message := range messages {
+		if err := messagebroker.CheckMessageSize(message, r.config.MaxMessageBytes); err != nil {
+			errs[i] = err
+			continue
+		}
+		data, err := encodeRedisMessage(message)
+		if err != nil {
+			errs[i] = err
+			continue
+		}
+		if err := r.appendOrdered(ctx, topic, message); err != nil {
+			errs[i] = &messagebroker.MessageBrokerError{
+				Driver:  "redis_pubsub",
+				Op:      "batch_publish",
+				Message: fmt.Sprintf("failed to order message for topic %s", topic),
+				Err:     err,
+			}
+			continue
+		}
+		cmds[i] = pipe.Publish(ctx, topic, data)
+	}
+
+	// Exec returns the first failed command's error; each command keeps its own
+	if pipe.Len() > 0 {
+		_, _ = pipe.Exec(ctx)
+	}
+
+	var published int64
+	for i, cmd := range cmds {
+		if cmd == nil {
+			continue
+		}
+		if err := cmd.Err(); err != nil {
+			errs[i] = &messagebroker.MessageBrokerError{
+				Driver:  "redis_pubsub",
+				Op:      "batch_publish",
+				Message: fmt.Sprintf("failed to publish message to topic %s", topic),
+				Err:     err,
+			}
+			continue
+		}
+		published++
+	}
+
+	r.mu.Lock()
+	r.stats.MessagesPublished += published
+	r.topics[topic] = true
+	r.mu.Unlock()
+
+	return errs
+}
+
+// PublishIdempotent publishes the message unless one was already published
+// under idempotencyKey within ttl. The keys are kept in the driver's own
+// Redis unless another store is set with SetIdempotencyStore.
+func (r *RedisPubSubDriver) PublishIdempotent(ctx context.Context, topic string, message *messagebroker.Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return r.idempotencyStore().PublishOnce(ctx, idempotencyKey, ttl, func() error {
+		return r.Publish(ctx, topic, message)
+	})
+}
+
 // PublishJSON publishes JSON data to a topic
 func (r *RedisPubSubDriver) PublishJSON(ctx context.Context, topic string, data interface{}) error {
 	message, err := messagebroker.NewMessage(topic, data)
@@ -201,7 +291,7 @@ func (r *RedisPubSubDriver) PublishWithDelay(ctx context.Context, topic string,
 	}
 
 	// Add to sorted set with execution time as score
-	err = r.client.ZAdd(ctx, delayedKey, &redis.Z{
+	err = r.client.ZAdd(ctx, delayedKey, redis.Z{
 		Score:  float64(executeAt.Unix()),
 		Member: data,
 	}).Err()
@@ -317,20 +407,173 @@ func (r *RedisPubSubDriver) SubscribeWithGroup(ctx context.Context, topic string
 	return nil
 }
 
+// SubscribePattern subscribes to every topic matching a glob-style pattern
+// with PSUBSCRIBE, e.g. orders.* for orders.created and orders.paid. The
+// messages carry the topic they were published to, not the pattern.
+func (r *RedisPubSubDriver) SubscribePattern(ctx context.Context, pattern string, handler messagebroker.MessageHandler) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("Redis Pub/Sub driver is closed")
+	}
+
+	if _, exists := r.patterns[pattern]; exists {
+		return fmt.Errorf("subscription already exists for pattern %s", pattern)
+	}
+
+	// Wait for Redis to confirm, so messages published once this returns
+	// are delivered
+	pubsub := r.client.PSubscribe(ctx, pattern)
+	if _, err := pubsub.Receive(ctx); err != nil {
+		pubsub.Close()
+		return &messagebroker.MessageBrokerError{
+			Driver:  "redis_pubsub",
+			Op:      "subscribe_pattern",
+			Message: fmt.Sprintf("failed to subscribe to pattern %s", pattern),
+			Err:     err,
+		}
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	subscriber := &redisSubscriber{
+		pubsub:  pubsub,
+		handler: handler,
+		topic:   pattern,
+		pattern: true,
+		cancel:  cancel,
+	}
+
+	r.patterns[pattern] = subscriber
+
+	go r.processMessages(subCtx, subscriber)
+
+	return nil
+}
+
+// UnsubscribePattern ends a subscription made with SubscribePattern
+func (r *RedisPubSubDriver) UnsubscribePattern(ctx context.Context, pattern string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	subscriber, exists := r.patterns[pattern]
+	if !exists {
+		return fmt.Errorf("no subscription for pattern %s", pattern)
+	}
+
+	if err := subscriber.pubsub.PUnsubscribe(ctx, pattern); err != nil {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "redis_pubsub",
+			Op:      "unsubscribe_pattern",
+			Message: fmt.Sprintf("failed to unsubscribe from pattern %s", pattern),
+			Err:     err,
+		}
+	}
+
+	subscriber.cancel()
+	delete(r.patterns, pattern)
+
+	return nil
+}
+
+// SubscribeWithConcurrency subscribes to a topic with concurrency handler
+// goroutines sharing one subscription, for topics too busy for a single
+// handler. When consumer scaling is configured the goroutine count then
+// follows the topic's lag, between MinConcurrency and MaxConcurrency.
+func (r *RedisPubSubDriver) SubscribeWithConcurrency(ctx context.Context, topic string, concurrency int, handler messagebroker.MessageHandler) error {
+	if concurrency < 1 {
+		return fmt.Errorf("concurrency must be at least 1, got %d", concurrency)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("Redis Pub/Sub driver is closed")
+	}
+
+	if _, exists := r.subscribers[topic]; exists {
+		return fmt.Errorf("subscription already exists for topic %s", topic)
+	}
+
+	pubsub := r.client.Subscribe(ctx, topic)
+
+	subCtx, cancel := context.WithCancel(ctx)
+	subscriber := &redisSubscriber{
+		pubsub:  pubsub,
+		handler: handler,
+		topic:   topic,
+		cancel:  cancel,
+	}
+
+	r.subscribers[topic] = subscriber
+	r.pubsub[topic] = pubsub
+
+	maxConcurrency := concurrency
+	if r.config.Consumer != nil && r.config.Consumer.MaxConcurrency > maxConcurrency {
+		maxConcurrency = r.config.Consumer.MaxConcurrency
+	}
+
+	pool := newConsumerPool(subCtx, topic, maxConcurrency, func(ctx context.Context, message *messagebroker.Message) {
+		r.handleMessage(ctx, subscriber, message)
+	})
+	pool.SetConcurrency(concurrency)
+
+	if r.config.Consumer != nil {
+		scaler := NewAutoScaler(*r.config.Consumer, pool, r.consumerLag(topic, pool))
+		go scaler.Start(subCtx)
+	}
+
+	go r.dispatchMessages(subCtx, subscriber, pool)
+
+	return nil
+}
+
+// consumerLag counts the messages waiting in the pool plus, for queue-based
+// topics, the length of the topic's Redis list
+func (r *RedisPubSubDriver) consumerLag(topic string, pool *consumerPool) LagFunc {
+	queueKey := fmt.Sprintf("queue:%s", topic)
+
+	return func(ctx context.Context) (int64, error) {
+		queued, err := r.client.LLen(ctx, queueKey).Result()
+		if err != nil {
+			return pool.Pending(), err
+		}
+		return pool.Pending() + queued, nil
+	}
+}
+
 // processMessages processes incoming messages for a subscriber
 func (r *RedisPubSubDriver) processMessages(ctx context.Context, subscriber *redisSubscriber) {
 	ch := subscriber.pubsub.Channel()
-	defer func() {
-		subscriber.pubsub.Close()
+	defer r.removeSubscriber(subscriber)
 
-		r.mu.Lock()
-		subscriptionKey := subscriber.topic
-		if subscriber.group != "" {
-			subscriptionKey = fmt.Sprintf("%s:group:%s", subscriber.topic, subscriber.group)
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case redisMsg := <-ch:
+			if redisMsg == nil {
+				continue
+			}
+
+			message, err := decodeRedisMessage(redisMsg.Channel, redisMsg.Payload)
+			if err != nil {
+				log.Printf("Failed to unmarshal message: %v", err)
+				continue
+			}
+
+			r.handleMessage(ctx, subscriber, message)
 		}
-		delete(r.subscribers, subscriptionKey)
-		delete(r.pubsub, subscriptionKey)
-		r.mu.Unlock()
+	}
+}
+
+// dispatchMessages feeds a subscription into a consumer pool
+func (r *RedisPubSubDriver) dispatchMessages(ctx context.Context, subscriber *redisSubscriber, pool *consumerPool) {
+	ch := subscriber.pubsub.Channel()
+	defer func() {
+		pool.Wait()
+		r.removeSubscriber(subscriber)
 	}()
 
 	for {
@@ -342,77 +585,145 @@ func (r *RedisPubSubDriver) processMessages(ctx context.Context, subscriber *red
 				continue
 			}
 
-			// Parse Redis message
-			var msgData map[string]interface{}
-			if err := json.Unmarshal([]byte(redisMsg.Payload), &msgData); err != nil {
+			message, err := decodeRedisMessage(redisMsg.Channel, redisMsg.Payload)
+			if err != nil {
 				log.Printf("Failed to unmarshal message: %v", err)
 				continue
 			}
 
-			// Convert to our message format
-			message := &messagebroker.Message{
-				Topic:     subscriber.topic,
-				Timestamp: time.Now(),
-				Headers:   make(map[string]string),
-				Metadata:  make(map[string]interface{}),
+			if !pool.Submit(message) {
+				return
 			}
+		}
+	}
+}
 
-			// Extract message fields
-			if id, ok := msgData["id"].(string); ok {
-				message.ID = id
-			}
-			if payload, ok := msgData["payload"].(string); ok {
-				message.Payload = []byte(payload)
-			}
-			if timestamp, ok := msgData["timestamp"].(float64); ok {
-				message.Timestamp = time.Unix(int64(timestamp), 0)
-			}
-			if retryCount, ok := msgData["retry_count"].(float64); ok {
-				message.RetryCount = int(retryCount)
-			}
-			if maxRetries, ok := msgData["max_retries"].(float64); ok {
-				message.MaxRetries = int(maxRetries)
-			}
+// removeSubscriber closes a finished subscription and forgets it, unless
+// it was replaced by a new subscription already
+func (r *RedisPubSubDriver) removeSubscriber(subscriber *redisSubscriber) {
+	subscriber.pubsub.Close()
 
-			// Extract headers
-			if headers, ok := msgData["headers"].(map[string]interface{}); ok {
-				for k, v := range headers {
-					if strVal, ok := v.(string); ok {
-						message.Headers[k] = strVal
-					}
-				}
-			}
+	r.mu.Lock()
+	defer r.mu.Unlock()
 
-			// Extract metadata
-			if metadata, ok := msgData["metadata"].(map[string]interface{}); ok {
-				message.Metadata = metadata
-			}
+	if subscriber.pattern {
+		if r.patterns[subscriber.topic] == subscriber {
+			delete(r.patterns, subscriber.topic)
+		}
+		return
+	}
 
-			// Check if this is a delayed message that's being executed
-			if _, isDelayed := msgData["execute_at"]; isDelayed {
-				// This is a delayed message being executed, clean up the delay metadata
-				delete(message.Metadata, "execute_at")
-				delete(message.Metadata, "delay")
-			}
+	subscriptionKey := subscriber.topic
+	if subscriber.group != "" {
+		subscriptionKey = fmt.Sprintf("%s:group:%s", subscriber.topic, subscriber.group)
+	}
+	if r.subscribers[subscriptionKey] == subscriber {
+		delete(r.subscribers, subscriptionKey)
+		delete(r.pubsub, subscriptionKey)
+	}
+}
 
-			// Handle the message
-			if err := subscriber.handler(ctx, message); err != nil {
-				// Handle retry logic
-				if message.RetryCount < message.MaxRetries {
-					message.RetryCount++
-					if retryErr := r.Publish(ctx, subscriber.topic, message); retryErr != nil {
-						log.Printf("Failed to retry message %s: %v", message.ID, retryErr)
-					}
-				} else {
-					log.Printf("Message %s exceeded max retries", message.ID)
-				}
-			} else {
-				r.mu.Lock()
-				r.stats.MessagesConsumed++
-				r.mu.Unlock()
+// handleMessage runs the subscriber's handler and republishes failed
+// messages to their topic until they run out of retries, then dead-letters
+// them. Messages with a partition key are taken from their ordered queue.
+func (r *RedisPubSubDriver) handleMessage(ctx context.Context, subscriber *redisSubscriber, message *messagebroker.Message) {
+	topic := message.Topic
+	err := r.handleOrdered(ctx, topic, message, func(message *messagebroker.Message) error {
+		if err := subscriber.handler(ctx, message); err != nil {
+			r.retryOrDeadLetter(ctx, topic, message, err, r.Publish)
+			return nil
+		}
+
+		r.mu.Lock()
+		r.stats.MessagesConsumed++
+		r.mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		log.Printf("Failed to take ordered message %s: %v", message.ID, err)
+	}
+}
+
+// encodeRedisMessage serializes a message with its metadata for publishing
+func encodeRedisMessage(message *messagebroker.Message) ([]byte, error) {
+	redisMessage := map[string]interface{}{
+		"id":          message.ID,
+		"topic":       message.Topic,
+		"payload":     string(message.Payload),
+		"headers":     message.Headers,
+		"timestamp":   message.Timestamp.Unix(),
+		"retry_count": message.RetryCount,
+		"max_retries": message.MaxRetries,
+		"metadata":    message.Metadata,
+	}
+	if message.PartitionKey != "" {
+		redisMessage["partition_key"] = message.PartitionKey
+	}
+
+	data, err := json.Marshal(redisMessage)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal message: %w", err)
+	}
+	return data, nil
+}
+
+// decodeRedisMessage converts a published payload back into a message
+func decodeRedisMessage(topic string, payload string) (*messagebroker.Message, error) {
+	var msgData map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &msgData); err != nil {
+		return nil, err
+	}
+
+	// Convert to our message format
+	message := &messagebroker.Message{
+		Topic:     topic,
+		Timestamp: time.Now(),
+		Headers:   make(map[string]string),
+		Metadata:  make(map[string]interface{}),
+	}
+
+	// Extract message fields
+	if id, ok := msgData["id"].(string); ok {
+		message.ID = id
+	}
+	if payload, ok := msgData["payload"].(string); ok {
+		message.Payload = []byte(payload)
+	}
+	if timestamp, ok := msgData["timestamp"].(float64); ok {
+		message.Timestamp = time.Unix(int64(timestamp), 0)
+	}
+	if retryCount, ok := msgData["retry_count"].(float64); ok {
+		message.RetryCount = int(retryCount)
+	}
+	if maxRetries, ok := msgData["max_retries"].(float64); ok {
+		message.MaxRetries = int(maxRetries)
+	}
+	if partitionKey, ok := msgData["partition_key"].(string); ok {
+		message.PartitionKey = partitionKey
+	}
+
+	// Extract headers
+	if headers, ok := msgData["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			if strVal, ok := v.(string); ok {
+				message.Headers[k] = strVal
 			}
 		}
 	}
+
+	// Extract metadata
+	if metadata, ok := msgData["metadata"].(map[string]interface{}); ok {
+		message.Metadata = metadata
+	}
+
+	// Check if this is a delayed message that's being executed
+	if _, isDelayed := msgData["execute_at"]; isDelayed {
+		// This is a delayed message being executed, clean up the delay metadata
+		delete(message.Metadata, "execute_at")
+		delete(message.Metadata, "delay")
+	}
+
+	return message, nil
 }
 
 // EnqueueJob enqueues a job using Redis lists
@@ -442,7 +753,7 @@ func (r *RedisPubSubDriver) EnqueueJob(ctx context.Context, queue string, job *m
 	if job.Priority > 0 {
 		// Use sorted set for priority queue
 		priorityKey := fmt.Sprintf("priority:%s", queue)
-		err = r.client.ZAdd(ctx, priorityKey, &redis.Z{
+		err = r.client.ZAdd(ctx, priorityKey, redis.Z{
 			Score:  float64(-job.Priority), // Negative for high priority first
 			Member: jobData,
 		}).Err()
@@ -477,7 +788,7 @@ func (r *RedisPubSubDriver) enqueueDelayedJob(ctx context.Context, queue string,
 		return fmt.Errorf("failed to marshal delayed job: %w", err)
 	}
 
-	err = r.client.ZAdd(ctx, delayedKey, &redis.Z{
+	err = r.client.ZAdd(ctx, delayedKey, redis.Z{
 		Score:  float64(executeAt.Unix()),
 		Member: jobData,
 	}).Err()
@@ -609,6 +920,12 @@ func (r *RedisPubSubDriver) DeleteTopic(ctx context.Context, topic string) error
 			delete(r.subscribers, key)
 		}
 	}
+	for key, subscriber := range r.streams {
+		if subscriber.topic == topic {
+			subscriber.cancel()
+			delete(r.streams, key)
+		}
+	}
 
 	delete(r.topics, topic)
 	r.stats.TopicCount--
@@ -673,6 +990,13 @@ func (r *RedisPubSubDriver) Close() error {
 	for _, subscriber := range r.subscribers {
 		subscriber.cancel()
 	}
+	for _, subscriber := range r.patterns {
+		subscriber.cancel()
+		subscriber.pubsub.Close()
+	}
+	for _, subscriber := range r.streams {
+		subscriber.cancel()
+	}
 
 	// Close all PubSub instances
 	for _, pubsub := range r.pubsub {
@@ -696,7 +1020,7 @@ func (r *RedisPubSubDriver) GetStats() (*messagebroker.BrokerStats, error) {
 	// Update uptime
 	r.stats.Uptime = time.Since(r.startTime)
 	r.stats.TopicCount = len(r.topics)
-	r.stats.QueueCount = len(r.subscribers)
+	r.stats.QueueCount = len(r.subscribers) + len(r.patterns)
 
 	// Create a copy to avoid race conditions
 	statsCopy := *r.stats
@@ -705,5 +1029,11 @@ func (r *RedisPubSubDriver) GetStats() (*messagebroker.BrokerStats, error) {
 		statsCopy.DriverInfo[k] = v
 	}
 
+	if !r.closed && len(r.streams) > 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		statsCopy.PendingMessages, statsCopy.DeadLetterCount = r.streamStats(ctx)
+	}
+
 	return &statsCopy, nil
 }
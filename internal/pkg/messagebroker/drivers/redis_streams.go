@@ -0,0 +1,350 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+const (
+	// streamMessageField is the stream entry field holding the message
+	streamMessageField = "message"
+
+	// streamReadCount is the number of entries read from a stream at once
+	streamReadCount = 10
+
+	// streamBlock is how long a read waits for new entries
+	streamBlock = time.Second
+
+	// streamClaimIdle is how long an entry stays unacknowledged before
+	// another consumer of the group takes it over
+	streamClaimIdle = time.Minute
+)
+
+// defaultStreamRetry is used when the config has no retry policy
+var defaultStreamRetry = messagebroker.RetryConfig{
+	MaxRetries:      3,
+	InitialInterval: 100 * time.Millisecond,
+	MaxInterval:     10 * time.Second,
+	Multiplier:      2,
+}
+
+// streamSubscriber is a consumer group member reading a topic's stream
+type streamSubscriber struct {
+	handler  messagebroker.MessageHandler
+	topic    string
+	group    string
+	consumer string
+	cancel   context.CancelFunc
+}
+
+// streamKey is the Redis Stream holding a topic's messages
+func streamKey(topic string) string {
+	return fmt.Sprintf("stream:%s", topic)
+}
+
+// deadLetterKey is the Redis Stream holding a topic's failed messages
+func deadLetterKey(topic string) string {
+	return fmt.Sprintf("stream:%s:dead", topic)
+}
+
+// AtLeastOncePublish appends a message to the topic's Redis Stream instead
+// of publishing it. The message is kept until a consumer group acknowledges
+// it, so it is not lost when no subscriber is listening.
+func (r *RedisPubSubDriver) AtLeastOncePublish(ctx context.Context, topic string, message *messagebroker.Message) error {
+	r.mu.RLock()
+	closed := r.closed
+	r.mu.RUnlock()
+
+	if closed {
+		return fmt.Errorf("Redis Pub/Sub driver is closed")
+	}
+
+	if err := messagebroker.CheckMessageSize(message, r.config.MaxMessageBytes); err != nil {
+		return err
+	}
+
+	data, err := encodeRedisMessage(message)
+	if err != nil {
+		return err
+	}
+
+	err = r.client.XAdd(ctx, &redis.XAddArgs{
+		Stream: streamKey(topic),
+		Values: map[string]interface{}{streamMessageField: data},
+	}).Err()
+	if err != nil {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "redis_pubsub",
+			Op:      "publish",
+			Message: fmt.Sprintf("failed to add message to stream %s", streamKey(topic)),
+			Err:     err,
+		}
+	}
+
+	r.mu.Lock()
+	r.stats.MessagesPublished++
+	r.topics[topic] = true
+	r.mu.Unlock()
+
+	return nil
+}
+
+// AtLeastOnceSubscribe consumes the topic's stream as a member of a
+// consumer group, creating the group on first use. A message is
+// acknowledged once the handler succeeds. A failing handler is retried up
+// to Retry.MaxRetries times with exponential backoff, after which the
+// message moves to the topic's dead-letter stream. Messages a crashed
+// consumer left unacknowledged are taken over by the group after a minute.
+func (r *RedisPubSubDriver) AtLeastOnceSubscribe(ctx context.Context, topic string, group string, handler messagebroker.MessageHandler) error {
+	if group == "" {
+		return fmt.Errorf("consumer group is required for at-least-once delivery")
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return fmt.Errorf("Redis Pub/Sub driver is closed")
+	}
+
+	subscriptionKey := fmt.Sprintf("%s:group:%s", streamKey(topic), group)
+	if _, exists := r.streams[subscriptionKey]; exists {
+		return fmt.Errorf("stream subscription already exists for topic %s and group %s", topic, group)
+	}
+
+	err := r.client.XGroupCreateMkStream(ctx, streamKey(topic), group, "0").Err()
+	if err != nil && !strings.HasPrefix(err.Error(), "BUSYGROUP") {
+		return &messagebroker.MessageBrokerError{
+			Driver:  "redis_pubsub",
+			Op:      "subscribe",
+			Message: fmt.Sprintf("failed to create consumer group %s for stream %s", group, streamKey(topic)),
+			Err:     err,
+		}
+	}
+
+	subCtx, cancel := context.WithCancel(ctx)
+	subscriber := &streamSubscriber{
+		handler:  handler,
+		topic:    topic,
+		group:    group,
+		consumer: streamConsumerName(),
+		cancel:   cancel,
+	}
+
+	r.streams[subscriptionKey] = subscriber
+	r.topics[topic] = true
+
+	go r.consumeStream(subCtx, subscriptionKey, subscriber)
+
+	return nil
+}
+
+// streamConsumerName names this process within its consumer groups
+func streamConsumerName() string {
+	hostname, err := os.Hostname()
+	if err != nil || hostname == "" {
+		hostname = "consumer"
+	}
+	return fmt.Sprintf("%s-%d-%d", hostname, os.Getpid(), rand.Int63())
+}
+
+// consumeStream takes over abandoned entries and reads new ones until the
+// subscription is cancelled
+func (r *RedisPubSubDriver) consumeStream(ctx context.Context, subscriptionKey string, subscriber *streamSubscriber) {
+	defer func() {
+		r.mu.Lock()
+		if r.streams[subscriptionKey] == subscriber {
+			delete(r.streams, subscriptionKey)
+		}
+		r.mu.Unlock()
+	}()
+
+	key := streamKey(subscriber.topic)
+
+	for ctx.Err() == nil {
+		claimed, _, err := r.client.XAutoClaim(ctx, &redis.XAutoClaimArgs{
+			Stream:   key,
+			Group:    subscriber.group,
+			Consumer: subscriber.consumer,
+			MinIdle:  streamClaimIdle,
+			Start:    "0-0",
+			Count:    streamReadCount,
+		}).Result()
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Failed to claim pending messages from stream %s: %v", key, err)
+		}
+		for _, entry := range claimed {
+			r.processStreamEntry(ctx, subscriber, entry)
+		}
+
+		streams, err := r.client.XReadGroup(ctx, &redis.XReadGroupArgs{
+			Group:    subscriber.group,
+			Consumer: subscriber.consumer,
+			Streams:  []string{key, ">"},
+			Count:    streamReadCount,
+			Block:    streamBlock,
+		}).Result()
+		if err != nil {
+			if errors.Is(err, redis.Nil) || ctx.Err() != nil {
+				continue
+			}
+			log.Printf("Failed to read from stream %s: %v", key, err)
+			sleepContext(ctx, streamBlock)
+			continue
+		}
+
+		for _, stream := range streams {
+			for _, entry := range stream.Messages {
+				r.processStreamEntry(ctx, subscriber, entry)
+			}
+		}
+	}
+}
+
+// processStreamEntry runs the handler with retries and acknowledges the
+// entry once it succeeds or has been dead-lettered. An entry interrupted by
+// cancellation stays pending for the group to deliver again.
+func (r *RedisPubSubDriver) processStreamEntry(ctx context.Context, subscriber *streamSubscriber, entry redis.XMessage) {
+	data, _ := entry.Values[streamMessageField].(string)
+	message, err := decodeRedisMessage(subscriber.topic, data)
+	if err != nil {
+		r.deadLetter(ctx, subscriber, entry, nil, fmt.Errorf("failed to unmarshal message: %w", err))
+		return
+	}
+
+	retry := r.retryConfig()
+	for attempt := 0; ; attempt++ {
+		err = subscriber.handler(ctx, message)
+		if err == nil {
+			break
+		}
+		message.RecordFailure(err)
+		if attempt >= retry.MaxRetries {
+			break
+		}
+
+		message.RetryCount = attempt + 1
+		if !sleepContext(ctx, retryDelay(retry, attempt)) {
+			return
+		}
+	}
+
+	if err != nil {
+		r.deadLetter(ctx, subscriber, entry, message, err)
+		return
+	}
+
+	if err := r.client.XAck(ctx, streamKey(subscriber.topic), subscriber.group, entry.ID).Err(); err != nil {
+		log.Printf("Failed to acknowledge message %s: %v", entry.ID, err)
+		return
+	}
+
+	r.mu.Lock()
+	r.stats.MessagesConsumed++
+	r.mu.Unlock()
+}
+
+// deadLetter hands an entry that exhausted its retries to the dead-letter
+// handler, or moves it to the topic's dead-letter stream when none is set
+// or the entry could not be decoded. The entry stays pending if that fails.
+func (r *RedisPubSubDriver) deadLetter(ctx context.Context, subscriber *streamSubscriber, entry redis.XMessage, message *messagebroker.Message, cause error) {
+	var err error
+	if handler := r.deadLetterHandler(); handler != nil && message != nil {
+		err = handler(ctx, message, cause)
+	} else {
+		err = r.client.XAdd(ctx, &redis.XAddArgs{
+			Stream: deadLetterKey(subscriber.topic),
+			Values: map[string]interface{}{
+				streamMessageField: entry.Values[streamMessageField],
+				"stream_id":        entry.ID,
+				"group":            subscriber.group,
+				"error":            cause.Error(),
+			},
+		}).Err()
+	}
+	if err != nil {
+		log.Printf("Failed to dead-letter message %s: %v", entry.ID, err)
+		return
+	}
+
+	log.Printf("Message %s exceeded max retries: %v", entry.ID, cause)
+
+	if err := r.client.XAck(ctx, streamKey(subscriber.topic), subscriber.group, entry.ID).Err(); err != nil {
+		log.Printf("Failed to acknowledge message %s: %v", entry.ID, err)
+	}
+}
+
+// retryConfig returns the configured retry policy or the default one
+func (r *RedisPubSubDriver) retryConfig() messagebroker.RetryConfig {
+	if r.config.Retry != nil {
+		return *r.config.Retry
+	}
+	return defaultStreamRetry
+}
+
+// retryDelay is the wait before retry attempt+1: InitialInterval grown by
+// Multiplier per attempt, jittered by RandomFactor and capped at MaxInterval
+func retryDelay(config messagebroker.RetryConfig, attempt int) time.Duration {
+	multiplier := config.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	delay := float64(config.InitialInterval) * math.Pow(multiplier, float64(attempt))
+	if config.RandomFactor > 0 {
+		delay *= 1 + config.RandomFactor*(2*rand.Float64()-1)
+	}
+	if config.MaxInterval > 0 && delay > float64(config.MaxInterval) {
+		delay = float64(config.MaxInterval)
+	}
+
+	return time.Duration(delay)
+}
+
+// sleepContext waits for d, returning false if ctx is cancelled first
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// streamStats counts the entries awaiting acknowledgement in the subscribed
+// consumer groups and the entries in their topics' dead-letter streams. The
+// caller holds r.mu.
+func (r *RedisPubSubDriver) streamStats(ctx context.Context) (pending int64, deadLetters int64) {
+	topics := make(map[string]bool)
+
+	for _, subscriber := range r.streams {
+		summary, err := r.client.XPending(ctx, streamKey(subscriber.topic), subscriber.group).Result()
+		if err == nil {
+			pending += summary.Count
+		}
+		topics[subscriber.topic] = true
+	}
+
+	for topic := range topics {
+		count, err := r.client.XLen(ctx, deadLetterKey(topic)).Result()
+		if err == nil {
+			deadLetters += count
+		}
+	}
+
+	return pending, deadLetters
+}
@@ -0,0 +1,142 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+// newStreamDriver connects to the Redis at REDIS_HOST:REDIS_PORT, skipping
+// the test when none is running
+func newStreamDriver(t *testing.T, retry *messagebroker.RetryConfig) *RedisPubSubDriver {
+	t.Helper()
+
+	if testing.Short() {
+		t.Skip("Skipping Redis stream tests in short mode")
+	}
+
+	host := os.Getenv("REDIS_HOST")
+	if host == "" {
+		host = "localhost"
+	}
+	port, err := strconv.Atoi(os.Getenv("REDIS_PORT"))
+	if err != nil {
+		port = 6379
+	}
+
+	driver, err := NewRedisPubSubDriver(&messagebroker.RedisPubSubConfig{Host: host, Port: port, Retry: retry})
+	if err != nil {
+		t.Skipf("redis not available: %v", err)
+	}
+	t.Cleanup(func() { driver.Close() })
+
+	return driver
+}
+
+func TestRetryDelay(t *testing.T) {
+	config := messagebroker.RetryConfig{
+		InitialInterval: 100 * time.Millisecond,
+		MaxInterval:     time.Second,
+		Multiplier:      2,
+	}
+
+	t.Run("should grow by the multiplier up to the maximum interval", func(t *testing.T) {
+		assert.Equal(t, 100*time.Millisecond, retryDelay(config, 0))
+		assert.Equal(t, 200*time.Millisecond, retryDelay(config, 1))
+		assert.Equal(t, 800*time.Millisecond, retryDelay(config, 3))
+		assert.Equal(t, time.Second, retryDelay(config, 4))
+	})
+
+	t.Run("should jitter by the random factor", func(t *testing.T) {
+		config := config
+		config.RandomFactor = 0.5
+
+		for i := 0; i < 20; i++ {
+			delay := retryDelay(config, 1)
+			assert.GreaterOrEqual(t, delay, 100*time.Millisecond)
+			assert.LessOrEqual(t, delay, 300*time.Millisecond)
+		}
+	})
+}
+
+func TestAtLeastOnce(t *testing.T) {
+	retry := &messagebroker.RetryConfig{MaxRetries: 2, InitialInterval: time.Millisecond, Multiplier: 2}
+
+	newTopic := func(t *testing.T, driver *RedisPubSubDriver) string {
+		topic := fmt.Sprintf("test-%d", time.Now().UnixNano())
+		t.Cleanup(func() {
+			driver.client.Del(context.Background(), streamKey(topic), deadLetterKey(topic))
+		})
+		return topic
+	}
+
+	t.Run("should deliver messages published before subscribing", func(t *testing.T) {
+		driver := newStreamDriver(t, retry)
+		topic := newTopic(t, driver)
+		ctx := context.Background()
+
+		message, err := messagebroker.NewMessage(topic, map[string]string{"order": "42"})
+		require.NoError(t, err)
+		require.NoError(t, driver.AtLeastOncePublish(ctx, topic, message))
+
+		received := make(chan *messagebroker.Message, 1)
+		require.NoError(t, driver.AtLeastOnceSubscribe(ctx, topic, "workers", func(ctx context.Context, message *messagebroker.Message) error {
+			received <- message
+			return nil
+		}))
+
+		select {
+		case got := <-received:
+			assert.Equal(t, message.ID, got.ID)
+			assert.Equal(t, message.Payload, got.Payload)
+		case <-time.After(5 * time.Second):
+			t.Fatal("message was not delivered")
+		}
+
+		assert.Eventually(t, func() bool {
+			stats, err := driver.GetStats()
+			return err == nil && stats.MessagesConsumed == 1 && stats.PendingMessages == 0
+		}, 5*time.Second, 20*time.Millisecond)
+	})
+
+	t.Run("should retry and then dead-letter failing messages", func(t *testing.T) {
+		driver := newStreamDriver(t, retry)
+		topic := newTopic(t, driver)
+		ctx := context.Background()
+
+		var attempts atomic.Int32
+		require.NoError(t, driver.AtLeastOnceSubscribe(ctx, topic, "workers", func(ctx context.Context, message *messagebroker.Message) error {
+			attempts.Add(1)
+			return errors.New("handler failed")
+		}))
+
+		message, err := messagebroker.NewMessage(topic, "payload")
+		require.NoError(t, err)
+		require.NoError(t, driver.AtLeastOncePublish(ctx, topic, message))
+
+		assert.Eventually(t, func() bool {
+			stats, err := driver.GetStats()
+			return err == nil && stats.DeadLetterCount == 1 && stats.PendingMessages == 0
+		}, 5*time.Second, 20*time.Millisecond)
+		assert.Equal(t, int32(3), attempts.Load())
+	})
+
+	t.Run("should require a consumer group", func(t *testing.T) {
+		driver := newStreamDriver(t, retry)
+
+		err := driver.AtLeastOnceSubscribe(context.Background(), "orders", "", func(ctx context.Context, message *messagebroker.Message) error {
+			return nil
+		})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,76 @@
+package drivers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+func TestSubscribePattern(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should deliver messages of every matching topic with their topic", func(t *testing.T) {
+		driver := newStreamDriver(t, nil)
+		received := make(chan *messagebroker.Message, 3)
+
+		require.NoError(t, driver.SubscribePattern(ctx, "orders.*", func(ctx context.Context, message *messagebroker.Message) error {
+			received <- message
+			return nil
+		}))
+
+		for _, topic := range []string{"orders.created", "users.created", "orders.paid"} {
+			message, err := messagebroker.NewMessage(topic, map[string]string{"topic": topic})
+			require.NoError(t, err)
+			require.NoError(t, driver.Publish(ctx, topic, message))
+		}
+
+		var topics []string
+		for i := 0; i < 2; i++ {
+			select {
+			case message := <-received:
+				topics = append(topics, message.Topic)
+			case <-time.After(2 * time.Second):
+				t.Fatalf("received %d of 2 messages", i)
+			}
+		}
+		assert.ElementsMatch(t, []string{"orders.created", "orders.paid"}, topics)
+		assert.Empty(t, received)
+	})
+
+	t.Run("should reject a second subscription to the same pattern", func(t *testing.T) {
+		driver := newStreamDriver(t, nil)
+		handler := func(ctx context.Context, message *messagebroker.Message) error { return nil }
+
+		require.NoError(t, driver.SubscribePattern(ctx, "audit.*", handler))
+
+		assert.Error(t, driver.SubscribePattern(ctx, "audit.*", handler))
+	})
+
+	t.Run("should stop delivering after unsubscribing", func(t *testing.T) {
+		driver := newStreamDriver(t, nil)
+		received := make(chan *messagebroker.Message, 1)
+
+		require.NoError(t, driver.SubscribePattern(ctx, "invoices.*", func(ctx context.Context, message *messagebroker.Message) error {
+			received <- message
+			return nil
+		}))
+
+		require.NoError(t, driver.UnsubscribePattern(ctx, "invoices.*"))
+
+		message, err := messagebroker.NewMessage("invoices.sent", "payload")
+		require.NoError(t, err)
+		require.NoError(t, driver.Publish(ctx, "invoices.sent", message))
+
+		select {
+		case <-received:
+			t.Fatal("received a message after unsubscribing")
+		case <-time.After(200 * time.Millisecond):
+		}
+		assert.Error(t, driver.UnsubscribePattern(ctx, "invoices.*"))
+	})
+}
@@ -0,0 +1,37 @@
+package drivers
+
+import (
+	"fmt"
+
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+)
+
+// init registers the built-in drivers with the message broker manager
+func init() {
+	messagebroker.RegisterDriver("rabbitmq", func(config *messagebroker.MessageBrokerConfig) (messagebroker.MessageBroker, error) {
+		if config.RabbitMQ == nil {
+			return nil, fmt.Errorf("RabbitMQ configuration is required")
+		}
+		return NewRabbitMQDriver(config.RabbitMQ)
+	})
+
+	messagebroker.RegisterDriver("kafka", func(config *messagebroker.MessageBrokerConfig) (messagebroker.MessageBroker, error) {
+		if config.Kafka == nil {
+			return nil, fmt.Errorf("Kafka configuration is required")
+		}
+		return NewKafkaDriver(config.Kafka)
+	})
+
+	messagebroker.RegisterDriver("redis", func(config *messagebroker.MessageBrokerConfig) (messagebroker.MessageBroker, error) {
+		if config.Redis == nil {
+			return nil, fmt.Errorf("Redis configuration is required")
+		}
+		// At-least-once subscriptions retry with the broker-wide policy
+		// unless the Redis config sets its own
+		redisConfig := *config.Redis
+		if redisConfig.Retry == nil {
+			redisConfig.Retry = config.RetryConfig
+		}
+		return NewRedisPubSubDriver(&redisConfig)
+	})
+}
@@ -0,0 +1,80 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// IdempotencyKeyPrefix prefixes the Redis keys claimed for PublishIdempotent
+const IdempotencyKeyPrefix = "idempotent:"
+
+// ErrNoIdempotencyStore is returned by PublishIdempotent on a broker without
+// an idempotency store
+var ErrNoIdempotencyStore = errors.New("no idempotency store configured")
+
+// IdempotencyStore remembers the idempotency keys of published messages in
+// Redis, so a message published again under the same key, e.g. when a
+// publish is retried after a network error, is only delivered once
+type IdempotencyStore struct {
+	client *redis.Client
+}
+
+// NewIdempotencyStore creates an idempotency store keeping its keys in client
+func NewIdempotencyStore(client *redis.Client) *IdempotencyStore {
+	return &IdempotencyStore{client: client}
+}
+
+// IdempotencyStoreSetter is implemented by brokers that deduplicate
+// PublishIdempotent with a store set after they are created
+type IdempotencyStoreSetter interface {
+	SetIdempotencyStore(store *IdempotencyStore)
+}
+
+// Claim records key for ttl with SET idempotent:<key> 1 NX EX <ttl>. It
+// returns false when the key was already claimed.
+func (s *IdempotencyStore) Claim(ctx context.Context, key string, ttl time.Duration) (bool, error) {
+	if key == "" {
+		return false, fmt.Errorf("idempotency key cannot be empty")
+	}
+	if ttl <= 0 {
+		return false, fmt.Errorf("idempotency key TTL must be positive")
+	}
+
+	claimed, err := s.client.SetNX(ctx, IdempotencyKeyPrefix+key, 1, ttl).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to claim idempotency key %s: %w", key, err)
+	}
+	return claimed, nil
+}
+
+// Release forgets key, so the message can be published under it again
+func (s *IdempotencyStore) Release(ctx context.Context, key string) error {
+	if err := s.client.Del(ctx, IdempotencyKeyPrefix+key).Err(); err != nil {
+		return fmt.Errorf("failed to release idempotency key %s: %w", key, err)
+	}
+	return nil
+}
+
+// PublishOnce claims key and calls publish, returning false without
+// publishing when the key was already claimed. The key is released when
+// publish fails so that retrying is not mistaken for a duplicate.
+func (s *IdempotencyStore) PublishOnce(ctx context.Context, key string, ttl time.Duration, publish func() error) (bool, error) {
+	if s == nil {
+		return false, ErrNoIdempotencyStore
+	}
+
+	claimed, err := s.Claim(ctx, key, ttl)
+	if err != nil || !claimed {
+		return false, err
+	}
+
+	if err := publish(); err != nil {
+		// The claim must go even when the caller's context is done
+		return false, errors.Join(err, s.Release(context.WithoutCancel(ctx), key))
+	}
+	return true, nil
+}
@@ -0,0 +1,106 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func TestIdempotencyStore(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should claim a key only once until it expires", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		store := NewIdempotencyStore(client)
+
+		claimed, err := store.Claim(ctx, "order-1", time.Minute)
+		require.NoError(t, err)
+		assert.True(t, claimed)
+
+		claimed, err = store.Claim(ctx, "order-1", time.Minute)
+		require.NoError(t, err)
+		assert.False(t, claimed)
+
+		ttl, err := client.TTL(ctx, IdempotencyKeyPrefix+"order-1").Result()
+		require.NoError(t, err)
+		assert.Greater(t, ttl, time.Duration(0))
+	})
+
+	t.Run("should publish once per key", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		store := NewIdempotencyStore(client)
+		publishes := 0
+		publish := func() error {
+			publishes++
+			return nil
+		}
+
+		published, err := store.PublishOnce(ctx, "order-1", time.Minute, publish)
+		require.NoError(t, err)
+		assert.True(t, published)
+
+		published, err = store.PublishOnce(ctx, "order-1", time.Minute, publish)
+		require.NoError(t, err)
+		assert.False(t, published)
+		assert.Equal(t, 1, publishes)
+	})
+
+	t.Run("should release the key when publishing fails", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		store := NewIdempotencyStore(client)
+		errDown := errors.New("broker down")
+
+		published, err := store.PublishOnce(ctx, "order-1", time.Minute, func() error { return errDown })
+		assert.ErrorIs(t, err, errDown)
+		assert.False(t, published)
+
+		published, err = store.PublishOnce(ctx, "order-1", time.Minute, func() error { return nil })
+		require.NoError(t, err)
+		assert.True(t, published)
+	})
+
+	t.Run("should reject empty keys and TTLs", func(t *testing.T) {
+		store := NewIdempotencyStore(client)
+
+		_, err := store.Claim(ctx, "", time.Minute)
+		assert.Error(t, err)
+		_, err = store.Claim(ctx, "order-1", 0)
+		assert.Error(t, err)
+	})
+}
+
+func TestPublishOnceWithoutStore(t *testing.T) {
+	var store *IdempotencyStore
+
+	published, err := store.PublishOnce(context.Background(), "order-1", time.Minute, func() error { return nil })
+
+	assert.ErrorIs(t, err, ErrNoIdempotencyStore)
+	assert.False(t, published)
+}
+
+func TestMultiSinkBrokerPublishIdempotent(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+	require.NoError(t, client.FlushDB(ctx).Err())
+
+	first, second := &stubBroker{}, &stubBroker{}
+	broker := newMultiSinkBroker(t, QuorumAll, []MessageBroker{first, second}, "redis", "kafka")
+	broker.SetIdempotencyStore(NewIdempotencyStore(client))
+	message := &Message{ID: "1", Payload: []byte(`{}`)}
+
+	for _, want := range []bool{true, false} {
+		published, err := broker.PublishIdempotent(ctx, "orders", message, "order-1", time.Minute)
+		require.NoError(t, err)
+		assert.Equal(t, want, published)
+	}
+
+	assert.Len(t, first.published, 1)
+	assert.Len(t, second.published, 1)
+}
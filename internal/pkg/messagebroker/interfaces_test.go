@@ -8,105 +8,101 @@ import (
 )
 
 func TestInterfacesMessage(t *testing.T) {
-	t.Run("should create message with correct structure from interfaces.go", func(t *testing.T) {
+	t.Run("should create message with correct structure", func(t *testing.T) {
 		now := time.Now()
 		headers := map[string]string{"Content-Type": "application/json"}
 		metadata := map[string]interface{}{"priority": "high"}
 
-		// Using the Message struct defined in interfaces.go
 		message := Message{
-			ID:        "test-123",
-			Topic:     "user-events",
-			Key:       "user-123",
-			Value:     []byte(`{"action": "login"}`),
-			Headers:   headers,
-			Metadata:  metadata,
-			Timestamp: now,
-			Partition: 1,
-			Offset:    100,
+			ID:           "test-123",
+			Topic:        "user-events",
+			PartitionKey: "user-123",
+			Payload:      []byte(`{"action": "login"}`),
+			Headers:      headers,
+			Metadata:     metadata,
+			Timestamp:    now,
+			RetryCount:   1,
+			MaxRetries:   3,
 		}
 
 		assert.Equal(t, "test-123", message.ID)
 		assert.Equal(t, "user-events", message.Topic)
-		assert.Equal(t, "user-123", message.Key)
-		assert.Equal(t, []byte(`{"action": "login"}`), message.Value)
+		assert.Equal(t, "user-123", message.PartitionKey)
+		assert.Equal(t, []byte(`{"action": "login"}`), message.Payload)
 		assert.Equal(t, headers, message.Headers)
 		assert.Equal(t, metadata, message.Metadata)
 		assert.Equal(t, now, message.Timestamp)
-		assert.Equal(t, int32(1), message.Partition)
-		assert.Equal(t, int64(100), message.Offset)
+		assert.Equal(t, 1, message.RetryCount)
+		assert.Equal(t, 3, message.MaxRetries)
 	})
 }
 
 func TestInterfacesBrokerStats(t *testing.T) {
 	t.Run("should create broker stats with correct structure", func(t *testing.T) {
-		lastActivity := time.Now()
 		uptime := 2 * time.Hour
+		driverInfo := map[string]string{"driver": "kafka"}
 
 		stats := BrokerStats{
-			MessagesProduced: 1000,
-			MessagesConsumed: 950,
-			MessagesFailed:   5,
-			ActiveConsumers:  3,
-			ActiveTopics:     10,
-			ConnectionStatus: "connected",
-			LastActivity:     lastActivity,
-			Uptime:          uptime,
-			BytesProduced:   1024000,
-			BytesConsumed:   1020000,
+			MessagesPublished: 1000,
+			MessagesConsumed:  950,
+			JobsEnqueued:      40,
+			JobsProcessed:     38,
+			ActiveConnections: 3,
+			TopicCount:        10,
+			QueueCount:        2,
+			Uptime:            uptime,
+			PendingMessages:   45,
+			DeadLetterCount:   5,
+			ConsumerLag:       12,
+			DriverInfo:        driverInfo,
 		}
 
-		assert.Equal(t, int64(1000), stats.MessagesProduced)
+		assert.Equal(t, int64(1000), stats.MessagesPublished)
 		assert.Equal(t, int64(950), stats.MessagesConsumed)
-		assert.Equal(t, int64(5), stats.MessagesFailed)
-		assert.Equal(t, 3, stats.ActiveConsumers)
-		assert.Equal(t, 10, stats.ActiveTopics)
-		assert.Equal(t, "connected", stats.ConnectionStatus)
-		assert.Equal(t, lastActivity, stats.LastActivity)
+		assert.Equal(t, int64(40), stats.JobsEnqueued)
+		assert.Equal(t, int64(38), stats.JobsProcessed)
+		assert.Equal(t, 3, stats.ActiveConnections)
+		assert.Equal(t, 10, stats.TopicCount)
+		assert.Equal(t, 2, stats.QueueCount)
 		assert.Equal(t, uptime, stats.Uptime)
-		assert.Equal(t, int64(1024000), stats.BytesProduced)
-		assert.Equal(t, int64(1020000), stats.BytesConsumed)
+		assert.Equal(t, int64(45), stats.PendingMessages)
+		assert.Equal(t, int64(5), stats.DeadLetterCount)
+		assert.Equal(t, int64(12), stats.ConsumerLag)
+		assert.Equal(t, driverInfo, stats.DriverInfo)
 	})
 }
 
 func TestInterfacesTopicConfig(t *testing.T) {
 	t.Run("should create topic config with correct structure", func(t *testing.T) {
-		config := map[string]string{
-			"cleanup.policy":     "delete",
-			"retention.ms":       "86400000",
-			"compression.type":   "gzip",
-		}
-
 		topicConfig := TopicConfig{
 			Partitions:        6,
 			ReplicationFactor: 3,
-			Config:            config,
+			RetentionTime:     24 * time.Hour,
+			CleanupPolicy:     "delete",
 		}
 
 		assert.Equal(t, 6, topicConfig.Partitions)
 		assert.Equal(t, 3, topicConfig.ReplicationFactor)
-		assert.Equal(t, config, topicConfig.Config)
-		assert.Equal(t, "delete", topicConfig.Config["cleanup.policy"])
-		assert.Equal(t, "86400000", topicConfig.Config["retention.ms"])
-		assert.Equal(t, "gzip", topicConfig.Config["compression.type"])
+		assert.Equal(t, 24*time.Hour, topicConfig.RetentionTime)
+		assert.Equal(t, "delete", topicConfig.CleanupPolicy)
 	})
 
 	t.Run("should handle empty config", func(t *testing.T) {
 		topicConfig := TopicConfig{
 			Partitions:        1,
 			ReplicationFactor: 1,
-			Config:            nil,
 		}
 
 		assert.Equal(t, 1, topicConfig.Partitions)
 		assert.Equal(t, 1, topicConfig.ReplicationFactor)
-		assert.Nil(t, topicConfig.Config)
+		assert.Zero(t, topicConfig.RetentionTime)
+		assert.Empty(t, topicConfig.CleanupPolicy)
 	})
 }
 
 func TestInterfacesRetryPolicy(t *testing.T) {
 	t.Run("should create retry policy with correct structure", func(t *testing.T) {
-		policy := RetryPolicy{
+		policy := RetryConfig{
 			MaxRetries:      5,
 			InitialInterval: 500 * time.Millisecond,
 			MaxInterval:     30 * time.Second,
@@ -122,13 +118,7 @@ func TestInterfacesRetryPolicy(t *testing.T) {
 	})
 
 	t.Run("should handle zero values", func(t *testing.T) {
-		policy := RetryPolicy{
-			MaxRetries:      0,
-			InitialInterval: 0,
-			MaxInterval:     0,
-			Multiplier:      0,
-			RandomFactor:    0,
-		}
+		policy := RetryConfig{}
 
 		assert.Equal(t, 0, policy.MaxRetries)
 		assert.Equal(t, time.Duration(0), policy.InitialInterval)
@@ -150,10 +140,10 @@ func TestInterfacesConnectionStatus(t *testing.T) {
 
 		for _, status := range statuses {
 			stats := BrokerStats{
-				ConnectionStatus: status,
+				DriverInfo: map[string]string{"status": status},
 			}
 
-			assert.Equal(t, status, stats.ConnectionStatus)
+			assert.Equal(t, status, stats.DriverInfo["status"])
 		}
 	})
 }
@@ -161,12 +151,12 @@ func TestInterfacesConnectionStatus(t *testing.T) {
 func TestInterfacesMessageMetadata(t *testing.T) {
 	t.Run("should handle various metadata types", func(t *testing.T) {
 		metadata := map[string]interface{}{
-			"string_value":  "test",
-			"int_value":     123,
-			"float_value":   45.67,
-			"bool_value":    true,
-			"array_value":   []string{"a", "b", "c"},
-			"object_value":  map[string]string{"key": "value"},
+			"string_value": "test",
+			"int_value":    123,
+			"float_value":  45.67,
+			"bool_value":   true,
+			"array_value":  []string{"a", "b", "c"},
+			"object_value": map[string]string{"key": "value"},
 		}
 
 		message := Message{
@@ -187,35 +177,29 @@ func TestInterfacesMessageMetadata(t *testing.T) {
 func TestInterfacesKafkaSpecificFields(t *testing.T) {
 	t.Run("should handle Kafka-specific message fields", func(t *testing.T) {
 		message := Message{
-			ID:        "kafka-msg-1",
-			Topic:     "user-events",
-			Key:       "user-123",
-			Value:     []byte(`{"user_id": 123, "action": "login"}`),
-			Partition: 2,
-			Offset:    1500,
+			ID:           "kafka-msg-1",
+			Topic:        "user-events",
+			PartitionKey: "user-123",
+			Payload:      []byte(`{"user_id": 123, "action": "login"}`),
 		}
 
 		assert.Equal(t, "kafka-msg-1", message.ID)
 		assert.Equal(t, "user-events", message.Topic)
-		assert.Equal(t, "user-123", message.Key)
-		assert.NotEmpty(t, message.Value)
-		assert.Equal(t, int32(2), message.Partition)
-		assert.Equal(t, int64(1500), message.Offset)
+		assert.Equal(t, "user-123", message.PartitionKey)
+		assert.NotEmpty(t, message.Payload)
 	})
 
 	t.Run("should handle messages without Kafka fields", func(t *testing.T) {
 		message := Message{
-			ID:    "simple-msg",
-			Topic: "notifications",
-			Value: []byte("Hello World"),
+			ID:      "simple-msg",
+			Topic:   "notifications",
+			Payload: []byte("Hello World"),
 		}
 
 		assert.Equal(t, "simple-msg", message.ID)
 		assert.Equal(t, "notifications", message.Topic)
-		assert.Equal(t, []byte("Hello World"), message.Value)
-		assert.Empty(t, message.Key)
-		assert.Equal(t, int32(0), message.Partition)
-		assert.Equal(t, int64(0), message.Offset)
+		assert.Equal(t, []byte("Hello World"), message.Payload)
+		assert.Empty(t, message.PartitionKey)
 	})
 }
 
@@ -247,17 +231,17 @@ func TestInterfacesMessageHeaders(t *testing.T) {
 func TestInterfacesBrokerStatsCalculations(t *testing.T) {
 	t.Run("should handle statistical calculations", func(t *testing.T) {
 		stats := BrokerStats{
-			MessagesProduced: 1000,
-			MessagesConsumed: 950,
-			MessagesFailed:   50,
-			BytesProduced:   1048576, // 1MB
-			BytesConsumed:   1000000, // ~1MB
+			MessagesPublished: 1000,
+			MessagesConsumed:  950,
+			DeadLetterCount:   50,
+			JobsEnqueued:      400,
+			JobsProcessed:     380,
 		}
 
 		// Calculate success rate
-		totalMessages := stats.MessagesProduced
+		totalMessages := stats.MessagesPublished
 		successfulMessages := stats.MessagesConsumed
-		failureRate := float64(stats.MessagesFailed) / float64(totalMessages) * 100
+		failureRate := float64(stats.DeadLetterCount) / float64(totalMessages) * 100
 		successRate := float64(successfulMessages) / float64(totalMessages) * 100
 
 		assert.Equal(t, int64(1000), totalMessages)
@@ -265,11 +249,9 @@ func TestInterfacesBrokerStatsCalculations(t *testing.T) {
 		assert.Equal(t, 5.0, failureRate)  // 5% failure rate
 		assert.Equal(t, 95.0, successRate) // 95% success rate
 
-		// Calculate average message size
-		avgProducedSize := float64(stats.BytesProduced) / float64(stats.MessagesProduced)
-		avgConsumedSize := float64(stats.BytesConsumed) / float64(stats.MessagesConsumed)
+		// Calculate job completion rate
+		completionRate := float64(stats.JobsProcessed) / float64(stats.JobsEnqueued) * 100
 
-		assert.InDelta(t, 1048.576, avgProducedSize, 0.001) // ~1KB per message
-		assert.InDelta(t, 1052.631, avgConsumedSize, 0.001) // ~1KB per message
+		assert.InDelta(t, 95.0, completionRate, 0.001)
 	})
-}
\ No newline at end of file
+}
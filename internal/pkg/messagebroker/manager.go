@@ -3,10 +3,9 @@ package messagebroker
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
-
-	"github.com/VeRJiL/go-template/internal/pkg/messagebroker/drivers"
 )
 
 // Manager manages message brokers with Laravel-style facade pattern
@@ -16,6 +15,8 @@ type Manager struct {
 	config         *MessageBrokerConfig
 	mu             sync.RWMutex
 	healthCheckers map[string]*healthChecker
+	idempotency    *IdempotencyStore // set on every driver, nil to keep their own
+	ordering       *OrderingStore    // set on every driver, nil to keep their own
 }
 
 // healthChecker monitors driver health
@@ -32,6 +33,11 @@ func NewManager(config *MessageBrokerConfig) (*Manager, error) {
 	if config == nil {
 		return nil, fmt.Errorf("message broker config cannot be nil")
 	}
+	if config.Sink != nil {
+		if _, err := ParseQuorum(string(config.Sink.Quorum)); err != nil {
+			return nil, fmt.Errorf("invalid sink config: %w", err)
+		}
+	}
 
 	manager := &Manager{
 		drivers:        make(map[string]MessageBroker),
@@ -48,43 +54,42 @@ func NewManager(config *MessageBrokerConfig) (*Manager, error) {
 	return manager, nil
 }
 
+// DriverFactory creates a driver from the broker configuration
+type DriverFactory func(config *MessageBrokerConfig) (MessageBroker, error)
+
+var (
+	driverFactoriesMu sync.RWMutex
+	driverFactories   = make(map[string]DriverFactory)
+)
+
+// RegisterDriver makes a driver available to the manager under name. The
+// built-in drivers register themselves when the drivers package is
+// imported, which keeps this package free of an import of its drivers.
+func RegisterDriver(name string, factory DriverFactory) {
+	driverFactoriesMu.Lock()
+	defer driverFactoriesMu.Unlock()
+	driverFactories[name] = factory
+}
+
 // initializeDriver initializes a specific driver
 func (m *Manager) initializeDriver(driverName string) error {
-	switch driverName {
-	case "rabbitmq":
-		if m.config.RabbitMQ == nil {
-			return fmt.Errorf("RabbitMQ configuration is required")
-		}
-		driver, err := drivers.NewRabbitMQDriver(m.config.RabbitMQ)
-		if err != nil {
-			return err
-		}
-		m.drivers[driverName] = driver
-		
-	case "kafka":
-		if m.config.Kafka == nil {
-			return fmt.Errorf("Kafka configuration is required")
-		}
-		driver, err := drivers.NewKafkaDriver(m.config.Kafka)
-		if err != nil {
-			return err
-		}
-		m.drivers[driverName] = driver
-		
-	case "redis":
-		if m.config.Redis == nil {
-			return fmt.Errorf("Redis configuration is required")
-		}
-		driver, err := drivers.NewRedisPubSubDriver(m.config.Redis)
-		if err != nil {
-			return err
-		}
-		m.drivers[driverName] = driver
-		
-	default:
+	driverFactoriesMu.RLock()
+	factory, ok := driverFactories[driverName]
+	driverFactoriesMu.RUnlock()
+	if !ok {
 		return fmt.Errorf("unsupported message broker driver: %s", driverName)
 	}
 
+	driver, err := factory(m.config)
+	if err != nil {
+		return err
+	}
+	m.drivers[driverName] = driver
+
+	m.routeDeadLetters(driverName, m.drivers[driverName])
+	m.useIdempotencyStore(m.drivers[driverName])
+	m.useOrderingStore(m.drivers[driverName])
+
 	// Start health checking for this driver
 	m.startHealthCheck(driverName)
 	
@@ -144,6 +149,25 @@ func (m *Manager) Driver(name string) MessageBroker {
 	return nil
 }
 
+// AddDriver registers an already constructed driver under name, e.g. to
+// write to Redis and Kafka side by side while migrating between them. A
+// driver registered under the same name is replaced but not closed.
+func (m *Manager) AddDriver(name string, driver MessageBroker) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if checker, exists := m.healthCheckers[name]; exists {
+		close(checker.stop)
+		delete(m.healthCheckers, name)
+	}
+
+	m.drivers[name] = driver
+	m.routeDeadLetters(name, driver)
+	m.useIdempotencyStore(driver)
+	m.useOrderingStore(driver)
+	m.startHealthCheck(name)
+}
+
 // GetDefaultDriver returns the name of the default driver
 func (m *Manager) GetDefaultDriver() string {
 	return m.defaultDriver
@@ -239,7 +263,23 @@ func (m *Manager) Publish(ctx context.Context, topic string, message *Message) e
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.Publish(ctx, topic, message)
+	if err := CheckMessageSize(message, m.maxMessageBytes(m.defaultDriver)); err != nil {
+		return err
+	}
+	return driver.Publish(ctx, m.topicWithNamespace(topic), message)
+}
+
+// RawPublish publishes to rawTopic exactly as given, bypassing the topic
+// namespace prefix
+func (m *Manager) RawPublish(ctx context.Context, rawTopic string, message *Message) error {
+	driver := m.Driver(m.defaultDriver)
+	if driver == nil {
+		return fmt.Errorf("default driver %s not available", m.defaultDriver)
+	}
+	if err := CheckMessageSize(message, m.maxMessageBytes(m.defaultDriver)); err != nil {
+		return err
+	}
+	return driver.Publish(ctx, rawTopic, message)
 }
 
 // PublishJSON publishes JSON data using the default driver
@@ -248,7 +288,7 @@ func (m *Manager) PublishJSON(ctx context.Context, topic string, data interface{
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.PublishJSON(ctx, topic, data)
+	return driver.PublishJSON(ctx, m.topicWithNamespace(topic), data)
 }
 
 // PublishWithDelay publishes a delayed message using the default driver
@@ -257,7 +297,155 @@ func (m *Manager) PublishWithDelay(ctx context.Context, topic string, message *M
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.PublishWithDelay(ctx, topic, message, delay)
+	if err := CheckMessageSize(message, m.maxMessageBytes(m.defaultDriver)); err != nil {
+		return err
+	}
+	return driver.PublishWithDelay(ctx, m.topicWithNamespace(topic), message, delay)
+}
+
+// PublishIdempotent publishes a message using the default driver unless one
+// was already published under idempotencyKey within ttl
+func (m *Manager) PublishIdempotent(ctx context.Context, topic string, message *Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	driver := m.Driver(m.defaultDriver)
+	if driver == nil {
+		return false, fmt.Errorf("default driver %s not available", m.defaultDriver)
+	}
+	if err := CheckMessageSize(message, m.maxMessageBytes(m.defaultDriver)); err != nil {
+		return false, err
+	}
+	return driver.PublishIdempotent(ctx, m.topicWithNamespace(topic), message, idempotencyKey, ttl)
+}
+
+// SetIdempotencyStore deduplicates PublishIdempotent on every driver with
+// store, which Kafka and RabbitMQ need since they have no Redis of their own
+func (m *Manager) SetIdempotencyStore(store *IdempotencyStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.idempotency = store
+	for _, driver := range m.drivers {
+		m.useIdempotencyStore(driver)
+	}
+}
+
+// useIdempotencyStore sets the manager's idempotency store on the driver
+func (m *Manager) useIdempotencyStore(driver MessageBroker) {
+	setter, ok := driver.(IdempotencyStoreSetter)
+	if !ok || m.idempotency == nil {
+		return
+	}
+	setter.SetIdempotencyStore(m.idempotency)
+}
+
+// SetOrderingStore keeps the messages of each partition key in order on
+// every driver with store, which RabbitMQ needs since it has no Redis of its
+// own
+func (m *Manager) SetOrderingStore(store *OrderingStore) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ordering = store
+	for _, driver := range m.drivers {
+		m.useOrderingStore(driver)
+	}
+}
+
+// useOrderingStore sets the manager's ordering store on the driver
+func (m *Manager) useOrderingStore(driver MessageBroker) {
+	setter, ok := driver.(OrderingStoreSetter)
+	if !ok || m.ordering == nil {
+		return
+	}
+	setter.SetOrderingStore(m.ordering)
+}
+
+// BatchPublish publishes messages in one batch using the default driver.
+// Oversized messages fail without being sent; the others are still published.
+func (m *Manager) BatchPublish(ctx context.Context, topic string, messages []*Message) []error {
+	return m.batchPublish(ctx, topic, messages, make([]error, len(messages)))
+}
+
+// BatchPublishJSON wraps each item of data in a message and publishes them in
+// one batch using the default driver. The errors are indexed like data.
+func (m *Manager) BatchPublishJSON(ctx context.Context, topic string, data []interface{}) []error {
+	errs := make([]error, len(data))
+	messages := make([]*Message, len(data))
+	for i, item := range data {
+		message, err := NewMessage(m.topicWithNamespace(topic), item)
+		if err != nil {
+			errs[i] = fmt.Errorf("failed to create message: %w", err)
+			continue
+		}
+		messages[i] = message
+	}
+	return m.batchPublish(ctx, topic, messages, errs)
+}
+
+// batchPublish sends the messages whose error is still nil and fills in
+// their results
+func (m *Manager) batchPublish(ctx context.Context, topic string, messages []*Message, errs []error) []error {
+	driver := m.Driver(m.defaultDriver)
+	if driver == nil {
+		return BatchErrors(len(messages), fmt.Errorf("default driver %s not available", m.defaultDriver))
+	}
+
+	maxBytes := m.maxMessageBytes(m.defaultDriver)
+	var batch []*Message
+	var positions []int
+	for i, message := range messages {
+		if errs[i] != nil {
+			continue
+		}
+		if err := CheckMessageSize(message, maxBytes); err != nil {
+			errs[i] = err
+			continue
+		}
+		batch = append(batch, message)
+		positions = append(positions, i)
+	}
+	if len(batch) == 0 {
+		return errs
+	}
+
+	results := driver.BatchPublish(ctx, m.topicWithNamespace(topic), batch)
+	for j, i := range positions {
+		if j < len(results) {
+			errs[i] = results[j]
+		}
+	}
+	return errs
+}
+
+// topicWithNamespace returns the physical topic name for a logical one.
+// The prefix (app name and mode by default) keeps environments sharing a
+// cluster from consuming each other's messages.
+func (m *Manager) topicWithNamespace(topic string) string {
+	return m.config.TopicPrefix + topic
+}
+
+// topicWithoutNamespace returns the logical name of a physical topic
+func (m *Manager) topicWithoutNamespace(topic string) string {
+	return strings.TrimPrefix(topic, m.config.TopicPrefix)
+}
+
+// maxMessageBytes returns the configured payload limit for a driver.
+// Checking it here means every driver gets the limit, not only those that enforce it themselves.
+func (m *Manager) maxMessageBytes(driverName string) int {
+	switch driverName {
+	case "rabbitmq":
+		if m.config.RabbitMQ != nil {
+			return m.config.RabbitMQ.MaxMessageBytes
+		}
+	case "kafka":
+		if m.config.Kafka != nil {
+			return m.config.Kafka.MaxMessageBytes
+		}
+	case "redis":
+		if m.config.Redis != nil {
+			return m.config.Redis.MaxMessageBytes
+		}
+	}
+	return DefaultMaxMessageBytes
 }
 
 // Subscribe subscribes to a topic using the default driver
@@ -266,7 +454,7 @@ func (m *Manager) Subscribe(ctx context.Context, topic string, handler MessageHa
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.Subscribe(ctx, topic, handler)
+	return driver.Subscribe(ctx, m.topicWithNamespace(topic), handler)
 }
 
 // SubscribeWithGroup subscribes to a topic with a group using the default driver
@@ -275,7 +463,7 @@ func (m *Manager) SubscribeWithGroup(ctx context.Context, topic string, group st
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.SubscribeWithGroup(ctx, topic, group, handler)
+	return driver.SubscribeWithGroup(ctx, m.topicWithNamespace(topic), group, handler)
 }
 
 // EnqueueJob enqueues a job using the default driver
@@ -302,7 +490,7 @@ func (m *Manager) CreateTopic(ctx context.Context, topic string, config *TopicCo
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.CreateTopic(ctx, topic, config)
+	return driver.CreateTopic(ctx, m.topicWithNamespace(topic), config)
 }
 
 // DeleteTopic deletes a topic using the default driver
@@ -311,16 +499,24 @@ func (m *Manager) DeleteTopic(ctx context.Context, topic string) error {
 	if driver == nil {
 		return fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.DeleteTopic(ctx, topic)
+	return driver.DeleteTopic(ctx, m.topicWithNamespace(topic))
 }
 
-// GetTopicInfo returns topic information using the default driver
+// GetTopicInfo returns topic information using the default driver. The
+// topic may be given with or without the namespace prefix; the returned
+// name never includes it.
 func (m *Manager) GetTopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
 	driver := m.Driver(m.defaultDriver)
 	if driver == nil {
 		return nil, fmt.Errorf("default driver %s not available", m.defaultDriver)
 	}
-	return driver.GetTopicInfo(ctx, topic)
+
+	info, err := driver.GetTopicInfo(ctx, m.topicWithNamespace(m.topicWithoutNamespace(topic)))
+	if err != nil {
+		return nil, err
+	}
+	info.Name = m.topicWithoutNamespace(info.Name)
+	return info, nil
 }
 
 // Ping checks if the default driver connection is alive
@@ -474,7 +670,7 @@ func (ds *DriverSwitcher) PublishJSON(ctx context.Context, topic string, data in
 	if driver == nil {
 		return fmt.Errorf("driver not available")
 	}
-	return driver.PublishJSON(ctx, topic, data)
+	return driver.PublishJSON(ctx, ds.manager.topicWithNamespace(topic), data)
 }
 
 // EnqueueJob enqueues a job using the specified driver
@@ -499,10 +695,110 @@ func (m *Manager) Mirror(drivers []string, topic string, payload interface{}) er
 			return fmt.Errorf("driver %s not available", driverName)
 		}
 		
-		if err := driver.PublishJSON(ctx, topic, payload); err != nil {
+		if err := driver.PublishJSON(ctx, m.topicWithNamespace(topic), payload); err != nil {
 			return fmt.Errorf("failed to mirror to driver %s: %w", driverName, err)
 		}
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// PublishToAll publishes the message to every registered driver at once and
+// returns the errors of the drivers that failed, keyed by driver name. An
+// empty map means every driver accepted the message.
+func (m *Manager) PublishToAll(ctx context.Context, topic string, message *Message) map[string]error {
+	m.mu.RLock()
+	targets := make(map[string]MessageBroker, len(m.drivers))
+	for name, driver := range m.drivers {
+		targets[name] = driver
+	}
+	m.mu.RUnlock()
+
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs = make(map[string]error)
+	)
+	for name, driver := range targets {
+		wg.Add(1)
+		go func(name string, driver MessageBroker) {
+			defer wg.Done()
+
+			err := CheckMessageSize(message, m.maxMessageBytes(name))
+			if err == nil {
+				err = driver.Publish(ctx, m.topicWithNamespace(topic), message)
+			}
+			if err != nil {
+				mu.Lock()
+				errs[name] = err
+				mu.Unlock()
+			}
+		}(name, driver)
+	}
+	wg.Wait()
+
+	return errs
+}
+
+// MultiSink returns a broker that writes to all of the named drivers at once
+// and succeeds when the quorum ("all", "majority" or "any") accepts the
+// write. Drivers that cannot be initialized count as failed sinks. Topics
+// are namespaced and payloads limited like the rest of the manager's
+// operations, with the smallest limit of the drivers.
+func (m *Manager) MultiSink(drivers []string, quorum string) (MessageBroker, error) {
+	sinks := make([]MessageBroker, len(drivers))
+	maxBytes := 0
+	for i, name := range drivers {
+		sinks[i] = m.sinkDriver(name)
+
+		limit := m.maxMessageBytes(name)
+		if limit <= 0 {
+			limit = DefaultMaxMessageBytes
+		}
+		if maxBytes == 0 || limit < maxBytes {
+			maxBytes = limit
+		}
+	}
+
+	broker, err := NewMultiSinkBroker(Quorum(quorum), sinks, drivers...)
+	if err != nil {
+		return nil, err
+	}
+	broker.topicPrefix = m.config.TopicPrefix
+	broker.maxMessageBytes = maxBytes
+	broker.SetIdempotencyStore(m.idempotencyStore())
+	return broker, nil
+}
+
+func (m *Manager) idempotencyStore() *IdempotencyStore {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.idempotency
+}
+
+// Sink returns the multi-sink broker configured for critical messages, or
+// the default driver when no sinks are configured
+func (m *Manager) Sink() (MessageBroker, error) {
+	if m.config.Sink == nil || len(m.config.Sink.Drivers) == 0 {
+		return m.Driver(m.defaultDriver), nil
+	}
+	return m.MultiSink(m.config.Sink.Drivers, string(m.config.Sink.Quorum))
+}
+
+// sinkDriver returns the named driver, initializing it on first use
+func (m *Manager) sinkDriver(name string) MessageBroker {
+	if driver := m.Driver(name); driver != nil {
+		return driver
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if driver, exists := m.drivers[name]; exists {
+		return driver
+	}
+	if err := m.initializeDriver(name); err != nil {
+		return unavailableBroker{err: fmt.Errorf("driver %s not available: %w", name, err)}
+	}
+	return m.drivers[name]
+}
@@ -0,0 +1,335 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubBroker records published messages and the topics it was called with
+// without a real backend
+type stubBroker struct {
+	mu        sync.Mutex
+	published []*Message
+	topics    []string
+}
+
+func (s *stubBroker) record(topic string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topics = append(s.topics, topic)
+}
+
+func (s *stubBroker) Topics() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.topics
+}
+
+func (s *stubBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	s.record(topic)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.published = append(s.published, message)
+	return nil
+}
+
+func (s *stubBroker) PublishJSON(ctx context.Context, topic string, data interface{}) error {
+	message, err := NewMessage(topic, data)
+	if err != nil {
+		return err
+	}
+	return s.Publish(ctx, topic, message)
+}
+
+func (s *stubBroker) BatchPublish(ctx context.Context, topic string, messages []*Message) []error {
+	errs := make([]error, len(messages))
+	for i, message := range messages {
+		errs[i] = s.Publish(ctx, topic, message)
+	}
+	return errs
+}
+
+func (s *stubBroker) PublishIdempotent(ctx context.Context, topic string, message *Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return true, s.Publish(ctx, topic, message)
+}
+
+func (s *stubBroker) PublishWithDelay(ctx context.Context, topic string, message *Message, delay time.Duration) error {
+	return s.Publish(ctx, topic, message)
+}
+
+func (s *stubBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	s.record(topic)
+	return nil
+}
+
+func (s *stubBroker) SubscribeWithGroup(ctx context.Context, topic string, group string, handler MessageHandler) error {
+	s.record(topic)
+	return nil
+}
+
+func (s *stubBroker) EnqueueJob(ctx context.Context, queue string, job *Job) error { return nil }
+
+func (s *stubBroker) ProcessJobs(ctx context.Context, queue string, handler JobHandler) error {
+	return nil
+}
+
+func (s *stubBroker) CreateTopic(ctx context.Context, topic string, config *TopicConfig) error {
+	s.record(topic)
+	return nil
+}
+
+func (s *stubBroker) DeleteTopic(ctx context.Context, topic string) error {
+	s.record(topic)
+	return nil
+}
+
+func (s *stubBroker) GetTopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	s.record(topic)
+	return &TopicInfo{Name: topic}, nil
+}
+
+func (s *stubBroker) Ping(ctx context.Context) error { return nil }
+func (s *stubBroker) Close() error                   { return nil }
+
+func (s *stubBroker) GetStats() (*BrokerStats, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return &BrokerStats{MessagesPublished: int64(len(s.published))}, nil
+}
+
+func (s *stubBroker) Published() []*Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.published
+}
+
+// newTestManager builds a manager around stub drivers without connecting anywhere
+func newTestManager(config *MessageBrokerConfig, drivers map[string]MessageBroker) *Manager {
+	return &Manager{
+		drivers:        drivers,
+		defaultDriver:  config.Driver,
+		config:         config,
+		healthCheckers: make(map[string]*healthChecker),
+	}
+}
+
+func TestManagerPublishMessageSize(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should reject oversized message before reaching the driver", func(t *testing.T) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Redis:  &RedisPubSubConfig{MaxMessageBytes: 1024 * 1024},
+		}, map[string]MessageBroker{"redis": broker})
+
+		err := manager.Publish(ctx, "events", &Message{Payload: make([]byte, 5*1024*1024)})
+
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+		assert.Empty(t, broker.Published())
+	})
+
+	t.Run("should publish message within the limit", func(t *testing.T) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Redis:  &RedisPubSubConfig{MaxMessageBytes: 1024 * 1024},
+		}, map[string]MessageBroker{"redis": broker})
+
+		err := manager.Publish(ctx, "events", &Message{Payload: make([]byte, 1024)})
+
+		require.NoError(t, err)
+		assert.Len(t, broker.Published(), 1)
+	})
+
+	t.Run("should apply the limit to delayed messages", func(t *testing.T) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "kafka",
+			Kafka:  &KafkaConfig{MaxMessageBytes: 512},
+		}, map[string]MessageBroker{"kafka": broker})
+
+		err := manager.PublishWithDelay(ctx, "events", &Message{Payload: make([]byte, 1024)}, time.Second)
+
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	})
+}
+
+func TestManagerTopicNamespace(t *testing.T) {
+	ctx := context.Background()
+
+	newNamespacedManager := func(prefix string) (*Manager, *stubBroker) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver:      "redis",
+			Redis:       &RedisPubSubConfig{},
+			TopicPrefix: prefix,
+		}, map[string]MessageBroker{"redis": broker})
+		return manager, broker
+	}
+
+	t.Run("should resolve the same logical topic to different physical topics per environment", func(t *testing.T) {
+		dev, devBroker := newNamespacedManager("go-template.development.")
+		staging, stagingBroker := newNamespacedManager("go-template.staging.")
+
+		require.NoError(t, dev.Publish(ctx, "user.created", &Message{Payload: []byte("{}")}))
+		require.NoError(t, staging.Publish(ctx, "user.created", &Message{Payload: []byte("{}")}))
+
+		assert.Equal(t, []string{"go-template.development.user.created"}, devBroker.Topics())
+		assert.Equal(t, []string{"go-template.staging.user.created"}, stagingBroker.Topics())
+	})
+
+	t.Run("should prefix subscribe and topic management calls", func(t *testing.T) {
+		manager, broker := newNamespacedManager("app.dev.")
+
+		require.NoError(t, manager.Subscribe(ctx, "orders", nil))
+		require.NoError(t, manager.SubscribeWithGroup(ctx, "orders", "billing", nil))
+		require.NoError(t, manager.CreateTopic(ctx, "orders", &TopicConfig{}))
+		require.NoError(t, manager.DeleteTopic(ctx, "orders"))
+		require.NoError(t, manager.PublishJSON(ctx, "orders", map[string]string{"id": "1"}))
+
+		for _, topic := range broker.Topics() {
+			assert.Equal(t, "app.dev.orders", topic)
+		}
+		assert.Len(t, broker.Topics(), 5)
+	})
+
+	t.Run("should accept and strip the prefix in topic info", func(t *testing.T) {
+		manager, broker := newNamespacedManager("app.dev.")
+
+		logical, err := manager.GetTopicInfo(ctx, "orders")
+		require.NoError(t, err)
+		physical, err := manager.GetTopicInfo(ctx, "app.dev.orders")
+		require.NoError(t, err)
+
+		assert.Equal(t, "orders", logical.Name)
+		assert.Equal(t, "orders", physical.Name)
+		assert.Equal(t, []string{"app.dev.orders", "app.dev.orders"}, broker.Topics())
+	})
+
+	t.Run("should bypass the prefix for raw publishes", func(t *testing.T) {
+		manager, broker := newNamespacedManager("app.dev.")
+
+		require.NoError(t, manager.RawPublish(ctx, "shared.audit", &Message{Payload: []byte("{}")}))
+
+		assert.Equal(t, []string{"shared.audit"}, broker.Topics())
+	})
+
+	t.Run("should leave topics untouched without a prefix", func(t *testing.T) {
+		manager, broker := newNamespacedManager("")
+
+		require.NoError(t, manager.Publish(ctx, "orders", &Message{Payload: []byte("{}")}))
+
+		assert.Equal(t, []string{"orders"}, broker.Topics())
+	})
+}
+
+func TestManagerBatchPublishJSON(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should publish every item to the namespaced topic", func(t *testing.T) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver:      "redis",
+			Redis:       &RedisPubSubConfig{},
+			TopicPrefix: "app.",
+		}, map[string]MessageBroker{"redis": broker})
+
+		errs := manager.BatchPublishJSON(ctx, "orders", []interface{}{
+			map[string]string{"id": "1"},
+			map[string]string{"id": "2"},
+		})
+
+		assert.Equal(t, []error{nil, nil}, errs)
+		require.Len(t, broker.Published(), 2)
+		assert.JSONEq(t, `{"id":"2"}`, string(broker.Published()[1].Payload))
+		assert.Equal(t, []string{"app.orders", "app.orders"}, broker.Topics())
+	})
+
+	t.Run("should report failed items at their position and send the others", func(t *testing.T) {
+		broker := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Redis:  &RedisPubSubConfig{MaxMessageBytes: 16},
+		}, map[string]MessageBroker{"redis": broker})
+
+		errs := manager.BatchPublishJSON(ctx, "orders", []interface{}{
+			"a",
+			make(chan int),
+			string(make([]byte, 32)),
+			"b",
+		})
+
+		require.Len(t, errs, 4)
+		assert.NoError(t, errs[0])
+		assert.Error(t, errs[1])
+		assert.ErrorIs(t, errs[2], ErrMessageTooLarge)
+		assert.NoError(t, errs[3])
+		require.Len(t, broker.Published(), 2)
+		assert.Equal(t, "b", string(broker.Published()[1].Payload))
+	})
+
+	t.Run("should fail every item without the default driver", func(t *testing.T) {
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis"}, map[string]MessageBroker{})
+
+		errs := manager.BatchPublishJSON(ctx, "orders", []interface{}{"a", "b"})
+
+		require.Len(t, errs, 2)
+		assert.Error(t, errs[0])
+		assert.Error(t, errs[1])
+	})
+}
+
+func TestManagerPublishToAll(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should publish to every driver including added ones", func(t *testing.T) {
+		redis, kafka := &stubBroker{}, &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis", TopicPrefix: "app."},
+			map[string]MessageBroker{"redis": redis})
+		manager.AddDriver("kafka", kafka)
+		defer manager.Close()
+
+		errs := manager.PublishToAll(ctx, "orders", &Message{Payload: []byte("{}")})
+
+		assert.Empty(t, errs)
+		assert.Equal(t, []string{"app.orders"}, redis.Topics())
+		assert.Equal(t, []string{"app.orders"}, kafka.Topics())
+		assert.ElementsMatch(t, []string{"redis", "kafka"}, manager.GetAvailableDrivers())
+	})
+
+	t.Run("should report the drivers that failed", func(t *testing.T) {
+		healthy := &stubBroker{}
+		errDown := errors.New("broker down")
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis"}, map[string]MessageBroker{
+			"redis": healthy,
+			"kafka": &failingBroker{err: errDown},
+		})
+
+		errs := manager.PublishToAll(ctx, "orders", &Message{Payload: []byte("{}")})
+
+		assert.Equal(t, map[string]error{"kafka": errDown}, errs)
+		assert.Len(t, healthy.Published(), 1)
+	})
+
+	t.Run("should check the message size per driver", func(t *testing.T) {
+		redis, kafka := &stubBroker{}, &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Redis:  &RedisPubSubConfig{MaxMessageBytes: 4096},
+			Kafka:  &KafkaConfig{MaxMessageBytes: 512},
+		}, map[string]MessageBroker{"redis": redis, "kafka": kafka})
+
+		errs := manager.PublishToAll(ctx, "orders", &Message{Payload: make([]byte, 1024)})
+
+		require.Len(t, errs, 1)
+		assert.ErrorIs(t, errs["kafka"], ErrMessageTooLarge)
+		assert.Len(t, redis.Published(), 1)
+	})
+}
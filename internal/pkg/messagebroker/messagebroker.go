@@ -15,6 +15,13 @@ type MessageBroker interface {
 	Publish(ctx context.Context, topic string, message *Message) error
 	PublishJSON(ctx context.Context, topic string, data interface{}) error
 	PublishWithDelay(ctx context.Context, topic string, message *Message, delay time.Duration) error
+	// BatchPublish publishes messages in one round trip where the driver
+	// allows it. The errors are indexed like messages, nil for those published.
+	BatchPublish(ctx context.Context, topic string, messages []*Message) []error
+	// PublishIdempotent publishes the message unless one was already
+	// published under idempotencyKey within ttl, in which case it returns
+	// false and no error
+	PublishIdempotent(ctx context.Context, topic string, message *Message, idempotencyKey string, ttl time.Duration) (bool, error)
 	
 	// Subscribing and consuming
 	Subscribe(ctx context.Context, topic string, handler MessageHandler) error
@@ -45,6 +52,10 @@ type Message struct {
 	RetryCount  int                   `json:"retry_count"`
 	MaxRetries  int                   `json:"max_retries"`
 	Metadata    map[string]interface{} `json:"metadata,omitempty"`
+	// PartitionKey keeps messages sharing it in publish order. Kafka routes
+	// them to one partition; Redis and RabbitMQ queue them in a sorted set
+	// of the OrderingStore.
+	PartitionKey string `json:"partition_key,omitempty"`
 }
 
 // Job represents a job/task to be processed
@@ -96,6 +107,9 @@ type BrokerStats struct {
 	TopicCount        int               `json:"topic_count"`
 	QueueCount        int               `json:"queue_count"`
 	Uptime            time.Duration     `json:"uptime"`
+	PendingMessages   int64             `json:"pending_messages"`
+	DeadLetterCount   int64             `json:"dead_letter_count"`
+	ConsumerLag       int64             `json:"consumer_lag"` // total of the last measured consumer group lags
 	DriverInfo        map[string]string `json:"driver_info"`
 }
 
@@ -106,6 +120,9 @@ type MessageBrokerConfig struct {
 	Kafka       *KafkaConfig        `json:"kafka,omitempty" mapstructure:"kafka"`
 	Redis       *RedisPubSubConfig  `json:"redis,omitempty" mapstructure:"redis"`
 	RetryConfig *RetryConfig        `json:"retry,omitempty" mapstructure:"retry"`
+	TopicPrefix string              `json:"topic_prefix" mapstructure:"topic_prefix"`
+	Sink        *SinkConfig         `json:"sink,omitempty" mapstructure:"sink"`
+	DeadLetter  *DeadLetterConfig   `json:"dead_letter,omitempty" mapstructure:"dead_letter"`
 }
 
 // RabbitMQConfig holds RabbitMQ-specific configuration
@@ -123,6 +140,7 @@ type RabbitMQConfig struct {
 	PrefetchCount      int           `json:"prefetch_count" mapstructure:"prefetch_count"`
 	Durable            bool          `json:"durable" mapstructure:"durable"`
 	AutoDelete         bool          `json:"auto_delete" mapstructure:"auto_delete"`
+	MaxMessageBytes    int           `json:"max_message_bytes" mapstructure:"max_message_bytes"`
 }
 
 // KafkaConfig holds Kafka-specific configuration
@@ -142,24 +160,38 @@ type KafkaConfig struct {
 	EnableAutoCommit      bool          `json:"enable_auto_commit" mapstructure:"enable_auto_commit"`
 	AutoCommitInterval    time.Duration `json:"auto_commit_interval" mapstructure:"auto_commit_interval"`
 	InitialOffset         string        `json:"initial_offset" mapstructure:"initial_offset"` // oldest, newest
+	MaxMessageBytes       int           `json:"max_message_bytes" mapstructure:"max_message_bytes"`
 	SASL                  *SASLConfig   `json:"sasl,omitempty" mapstructure:"sasl"`
 	TLS                   *TLSConfig    `json:"tls,omitempty" mapstructure:"tls"`
+	SchemaRegistryURL     string        `json:"schema_registry_url" mapstructure:"schema_registry_url"` // serialize payloads as Avro when set
 }
 
 // RedisPubSubConfig holds Redis Pub/Sub configuration
 type RedisPubSubConfig struct {
-	Host               string        `json:"host" mapstructure:"host"`
-	Port               int           `json:"port" mapstructure:"port"`
-	Password           string        `json:"password" mapstructure:"password"`
-	DB                 int           `json:"db" mapstructure:"db"`
-	PoolSize           int           `json:"pool_size" mapstructure:"pool_size"`
-	MinIdleConns       int           `json:"min_idle_conns" mapstructure:"min_idle_conns"`
-	MaxRetries         int           `json:"max_retries" mapstructure:"max_retries"`
-	ConnectTimeout     time.Duration `json:"connect_timeout" mapstructure:"connect_timeout"`
-	ReadTimeout        time.Duration `json:"read_timeout" mapstructure:"read_timeout"`
-	WriteTimeout       time.Duration `json:"write_timeout" mapstructure:"write_timeout"`
-	IdleTimeout        time.Duration `json:"idle_timeout" mapstructure:"idle_timeout"`
-	TLS                *TLSConfig    `json:"tls,omitempty" mapstructure:"tls"`
+	Host            string                 `json:"host" mapstructure:"host"`
+	Port            int                    `json:"port" mapstructure:"port"`
+	Password        string                 `json:"password" mapstructure:"password"`
+	DB              int                    `json:"db" mapstructure:"db"`
+	PoolSize        int                    `json:"pool_size" mapstructure:"pool_size"`
+	MinIdleConns    int                    `json:"min_idle_conns" mapstructure:"min_idle_conns"`
+	MaxRetries      int                    `json:"max_retries" mapstructure:"max_retries"`
+	ConnectTimeout  time.Duration          `json:"connect_timeout" mapstructure:"connect_timeout"`
+	ReadTimeout     time.Duration          `json:"read_timeout" mapstructure:"read_timeout"`
+	WriteTimeout    time.Duration          `json:"write_timeout" mapstructure:"write_timeout"`
+	IdleTimeout     time.Duration          `json:"idle_timeout" mapstructure:"idle_timeout"`
+	MaxMessageBytes int                    `json:"max_message_bytes" mapstructure:"max_message_bytes"`
+	TLS             *TLSConfig             `json:"tls,omitempty" mapstructure:"tls"`
+	Consumer        *ConsumerScalingConfig `json:"consumer,omitempty" mapstructure:"consumer"`
+	Retry           *RetryConfig           `json:"retry,omitempty" mapstructure:"retry"`
+}
+
+// ConsumerScalingConfig bounds the number of concurrent handlers a
+// subscription scales between, based on its processing lag
+type ConsumerScalingConfig struct {
+	MinConcurrency int           `json:"min_concurrency" mapstructure:"min_concurrency"`
+	MaxConcurrency int           `json:"max_concurrency" mapstructure:"max_concurrency"`
+	ScaleInterval  time.Duration `json:"scale_interval" mapstructure:"scale_interval"`
+	ScaleUpLag     int           `json:"scale_up_lag" mapstructure:"scale_up_lag"`
 }
 
 // RetryConfig holds retry configuration for failed messages/jobs
@@ -188,6 +220,32 @@ type TLSConfig struct {
 	InsecureSkipVerify bool   `json:"insecure_skip_verify" mapstructure:"insecure_skip_verify"`
 }
 
+// DefaultMaxMessageBytes is the payload limit applied when a driver config leaves MaxMessageBytes unset
+const DefaultMaxMessageBytes = 1024 * 1024
+
+// CheckMessageSize returns ErrMessageTooLarge when the payload exceeds maxBytes.
+// A non-positive maxBytes applies DefaultMaxMessageBytes.
+func CheckMessageSize(message *Message, maxBytes int) error {
+	if maxBytes <= 0 {
+		maxBytes = DefaultMaxMessageBytes
+	}
+
+	if message != nil && len(message.Payload) > maxBytes {
+		return fmt.Errorf("%w: payload is %d bytes, limit is %d", ErrMessageTooLarge, len(message.Payload), maxBytes)
+	}
+
+	return nil
+}
+
+// BatchErrors returns the result of a batch of n messages that all failed with err
+func BatchErrors(n int, err error) []error {
+	errs := make([]error, n)
+	for i := range errs {
+		errs[i] = err
+	}
+	return errs
+}
+
 // Helper functions for creating messages and jobs
 func NewMessage(topic string, payload interface{}) (*Message, error) {
 	var data []byte
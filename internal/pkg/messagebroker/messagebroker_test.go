@@ -869,4 +869,25 @@ func TestCommonErrors(t *testing.T) {
 		assert.Contains(t, ErrMessageTooLarge.Error(), "large")
 		assert.Contains(t, ErrMaxRetriesExceeded.Error(), "retries")
 	})
-}
\ No newline at end of file
+}
+
+func TestCheckMessageSize(t *testing.T) {
+	t.Run("should reject payload over the limit", func(t *testing.T) {
+		message := &Message{Payload: make([]byte, 5*1024*1024)}
+
+		err := CheckMessageSize(message, 1024*1024)
+
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+	})
+
+	t.Run("should accept payload under the limit", func(t *testing.T) {
+		message := &Message{Payload: make([]byte, 1024)}
+
+		assert.NoError(t, CheckMessageSize(message, 1024*1024))
+	})
+
+	t.Run("should apply default limit when unset", func(t *testing.T) {
+		assert.ErrorIs(t, CheckMessageSize(&Message{Payload: make([]byte, DefaultMaxMessageBytes+1)}, 0), ErrMessageTooLarge)
+		assert.NoError(t, CheckMessageSize(&Message{Payload: make([]byte, DefaultMaxMessageBytes)}, 0))
+	})
+}
@@ -0,0 +1,430 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Quorum is the number of sinks a multi-sink write must reach
+type Quorum string
+
+const (
+	// QuorumAll requires every sink to accept the write
+	QuorumAll Quorum = "all"
+	// QuorumMajority requires more than half of the sinks
+	QuorumMajority Quorum = "majority"
+	// QuorumAny requires at least one sink
+	QuorumAny Quorum = "any"
+)
+
+// SinkConfig selects the brokers that critical messages are written to and
+// how many of them must accept each write
+type SinkConfig struct {
+	Drivers []string `json:"drivers" mapstructure:"drivers"`
+	Quorum  Quorum   `json:"quorum" mapstructure:"quorum"`
+}
+
+// ParseQuorum validates a quorum name
+func ParseQuorum(quorum string) (Quorum, error) {
+	switch q := Quorum(strings.ToLower(quorum)); q {
+	case QuorumAll, QuorumMajority, QuorumAny:
+		return q, nil
+	case "":
+		return QuorumAll, nil
+	default:
+		return "", fmt.Errorf("unsupported quorum %q, expected all, majority or any", quorum)
+	}
+}
+
+// required returns how many of n sinks must succeed
+func (q Quorum) required(n int) int {
+	switch q {
+	case QuorumAny:
+		return 1
+	case QuorumMajority:
+		return n/2 + 1
+	default:
+		return n
+	}
+}
+
+// MultiSinkError reports a multi-sink write that did not reach its quorum
+type MultiSinkError struct {
+	Op        string
+	Quorum    Quorum
+	Succeeded int
+	Required  int
+	Failures  map[string]error
+}
+
+func (e *MultiSinkError) Error() string {
+	names := make([]string, 0, len(e.Failures))
+	for name := range e.Failures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	failures := make([]string, len(names))
+	for i, name := range names {
+		failures[i] = fmt.Sprintf("%s: %v", name, e.Failures[name])
+	}
+
+	return fmt.Sprintf("%s reached %d of %d required sinks (quorum %s): %s",
+		e.Op, e.Succeeded, e.Required, e.Quorum, strings.Join(failures, "; "))
+}
+
+// Unwrap exposes the individual sink errors to errors.Is and errors.As
+func (e *MultiSinkError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, err := range e.Failures {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// MultiSinkBroker writes every message to several brokers at once, so a
+// critical event survives the loss of one of them. Writes go to all sinks
+// concurrently and succeed once the quorum has accepted them; sinks that
+// failed while the quorum was still met are logged.
+//
+// Reads (Subscribe, ProcessJobs, GetTopicInfo) use the first sink only, since
+// consuming from every sink would deliver each message several times.
+type MultiSinkBroker struct {
+	sinks       []MessageBroker
+	names       []string
+	quorum      Quorum
+	topicPrefix string
+	idempotency *IdempotencyStore
+
+	// maxMessageBytes is the smallest payload limit of the sinks, so a
+	// message too large for one of them is not written to the others
+	maxMessageBytes int
+}
+
+// NewMultiSinkBroker creates a broker writing to sinks with the given quorum.
+// names label the sinks in errors; missing names default to sink-<index>.
+func NewMultiSinkBroker(quorum Quorum, sinks []MessageBroker, names ...string) (*MultiSinkBroker, error) {
+	q, err := ParseQuorum(string(quorum))
+	if err != nil {
+		return nil, err
+	}
+
+	labels := make([]string, len(sinks))
+	for i := range sinks {
+		if i < len(names) && names[i] != "" {
+			labels[i] = names[i]
+		} else {
+			labels[i] = fmt.Sprintf("sink-%d", i)
+		}
+	}
+
+	return &MultiSinkBroker{
+		sinks:  sinks,
+		names:  labels,
+		quorum: q,
+	}, nil
+}
+
+// Publish writes the message to every sink
+func (b *MultiSinkBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	if err := CheckMessageSize(message, b.maxMessageBytes); err != nil {
+		return err
+	}
+	return b.write(ctx, "publish", func(ctx context.Context, sink MessageBroker) error {
+		return sink.Publish(ctx, b.topic(topic), message)
+	})
+}
+
+// BatchPublish writes the batch to every sink concurrently. Each message is
+// checked against the quorum on its own, so the result for a message is nil
+// when enough sinks accepted it. Messages over the size limit are not sent.
+func (b *MultiSinkBroker) BatchPublish(ctx context.Context, topic string, messages []*Message) []error {
+	errs := make([]error, len(messages))
+
+	var batch []*Message
+	var positions []int
+	for i, message := range messages {
+		if err := CheckMessageSize(message, b.maxMessageBytes); err != nil {
+			errs[i] = err
+			continue
+		}
+		batch = append(batch, message)
+		positions = append(positions, i)
+	}
+	if len(batch) == 0 {
+		return errs
+	}
+
+	results := make([][]error, len(b.sinks))
+
+	var wg sync.WaitGroup
+	for i, sink := range b.sinks {
+		wg.Add(1)
+		go func(i int, sink MessageBroker) {
+			defer wg.Done()
+			results[i] = sink.BatchPublish(ctx, b.topic(topic), batch)
+		}(i, sink)
+	}
+	wg.Wait()
+
+	for j, m := range positions {
+		sinkErrs := make([]error, len(b.sinks))
+		for i, result := range results {
+			if j < len(result) {
+				sinkErrs[i] = result[j]
+			} else {
+				sinkErrs[i] = errors.New("no result for message")
+			}
+		}
+		errs[m] = b.checkQuorum("batch_publish", sinkErrs)
+	}
+	return errs
+}
+
+// PublishIdempotent writes the message to every sink unless one was already
+// published under idempotencyKey within ttl. The key is claimed once for all
+// sinks, which may share a Redis, and released when the quorum is not met.
+func (b *MultiSinkBroker) PublishIdempotent(ctx context.Context, topic string, message *Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return b.idempotency.PublishOnce(ctx, idempotencyKey, ttl, func() error {
+		return b.Publish(ctx, topic, message)
+	})
+}
+
+// SetIdempotencyStore deduplicates PublishIdempotent with store
+func (b *MultiSinkBroker) SetIdempotencyStore(store *IdempotencyStore) {
+	b.idempotency = store
+}
+
+// PublishJSON wraps data in a single message so every sink receives the same
+// message ID, letting consumers deduplicate across sinks
+func (b *MultiSinkBroker) PublishJSON(ctx context.Context, topic string, data interface{}) error {
+	message, err := NewMessage(b.topic(topic), data)
+	if err != nil {
+		return fmt.Errorf("failed to create message: %w", err)
+	}
+	return b.Publish(ctx, topic, message)
+}
+
+// PublishWithDelay writes the delayed message to every sink
+func (b *MultiSinkBroker) PublishWithDelay(ctx context.Context, topic string, message *Message, delay time.Duration) error {
+	if err := CheckMessageSize(message, b.maxMessageBytes); err != nil {
+		return err
+	}
+	return b.write(ctx, "publish_with_delay", func(ctx context.Context, sink MessageBroker) error {
+		return sink.PublishWithDelay(ctx, b.topic(topic), message, delay)
+	})
+}
+
+// Subscribe subscribes on the first sink
+func (b *MultiSinkBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	return b.primary().Subscribe(ctx, b.topic(topic), handler)
+}
+
+// SubscribeWithGroup subscribes with a group on the first sink
+func (b *MultiSinkBroker) SubscribeWithGroup(ctx context.Context, topic string, group string, handler MessageHandler) error {
+	return b.primary().SubscribeWithGroup(ctx, b.topic(topic), group, handler)
+}
+
+// EnqueueJob enqueues the job on every sink
+func (b *MultiSinkBroker) EnqueueJob(ctx context.Context, queue string, job *Job) error {
+	return b.write(ctx, "enqueue_job", func(ctx context.Context, sink MessageBroker) error {
+		return sink.EnqueueJob(ctx, queue, job)
+	})
+}
+
+// ProcessJobs processes jobs from the first sink
+func (b *MultiSinkBroker) ProcessJobs(ctx context.Context, queue string, handler JobHandler) error {
+	return b.primary().ProcessJobs(ctx, queue, handler)
+}
+
+// CreateTopic creates the topic on every sink
+func (b *MultiSinkBroker) CreateTopic(ctx context.Context, topic string, config *TopicConfig) error {
+	return b.each(func(sink MessageBroker) error {
+		return sink.CreateTopic(ctx, b.topic(topic), config)
+	})
+}
+
+// DeleteTopic deletes the topic from every sink
+func (b *MultiSinkBroker) DeleteTopic(ctx context.Context, topic string) error {
+	return b.each(func(sink MessageBroker) error {
+		return sink.DeleteTopic(ctx, b.topic(topic))
+	})
+}
+
+// GetTopicInfo returns the topic information of the first sink
+func (b *MultiSinkBroker) GetTopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	return b.primary().GetTopicInfo(ctx, b.topic(topic))
+}
+
+// Ping succeeds when enough sinks are reachable to meet the quorum
+func (b *MultiSinkBroker) Ping(ctx context.Context) error {
+	return b.write(ctx, "ping", func(ctx context.Context, sink MessageBroker) error {
+		return sink.Ping(ctx)
+	})
+}
+
+// Close closes every sink. Sinks obtained from Manager.MultiSink belong to
+// the manager and are closed by Manager.Close instead.
+func (b *MultiSinkBroker) Close() error {
+	return b.each(func(sink MessageBroker) error {
+		return sink.Close()
+	})
+}
+
+// GetStats sums the statistics of all sinks
+func (b *MultiSinkBroker) GetStats() (*BrokerStats, error) {
+	total := &BrokerStats{
+		DriverInfo: map[string]string{
+			"driver": "multisink",
+			"sinks":  strings.Join(b.names, ","),
+			"quorum": string(b.quorum),
+		},
+	}
+
+	for _, sink := range b.sinks {
+		stats, err := sink.GetStats()
+		if err != nil {
+			continue
+		}
+		total.MessagesPublished += stats.MessagesPublished
+		total.MessagesConsumed += stats.MessagesConsumed
+		total.JobsEnqueued += stats.JobsEnqueued
+		total.JobsProcessed += stats.JobsProcessed
+		total.ActiveConnections += stats.ActiveConnections
+		if stats.Uptime > total.Uptime {
+			total.Uptime = stats.Uptime
+		}
+	}
+
+	return total, nil
+}
+
+// write runs op on all sinks concurrently and checks the quorum
+func (b *MultiSinkBroker) write(ctx context.Context, op string, fn func(ctx context.Context, sink MessageBroker) error) error {
+	errs := make([]error, len(b.sinks))
+
+	var wg sync.WaitGroup
+	for i, sink := range b.sinks {
+		wg.Add(1)
+		go func(i int, sink MessageBroker) {
+			defer wg.Done()
+			errs[i] = fn(ctx, sink)
+		}(i, sink)
+	}
+	wg.Wait()
+
+	return b.checkQuorum(op, errs)
+}
+
+// checkQuorum returns a MultiSinkError unless enough of the sink errors are
+// nil, logging the failed sinks when the quorum is met anyway
+func (b *MultiSinkBroker) checkQuorum(op string, errs []error) error {
+	failures := make(map[string]error)
+	for i, err := range errs {
+		if err != nil {
+			failures[b.names[i]] = err
+		}
+	}
+
+	succeeded := len(b.sinks) - len(failures)
+	required := b.quorum.required(len(b.sinks))
+	if succeeded >= required && len(b.sinks) > 0 {
+		for name, err := range failures {
+			log.Printf("Multi-sink %s failed on %s (quorum %s still met): %v", op, name, b.quorum, err)
+		}
+		return nil
+	}
+
+	return &MultiSinkError{
+		Op:        op,
+		Quorum:    b.quorum,
+		Succeeded: succeeded,
+		Required:  required,
+		Failures:  failures,
+	}
+}
+
+// each runs fn on every sink in order and joins the errors
+func (b *MultiSinkBroker) each(fn func(sink MessageBroker) error) error {
+	var errs []error
+	for i, sink := range b.sinks {
+		if err := fn(sink); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", b.names[i], err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (b *MultiSinkBroker) primary() MessageBroker {
+	if len(b.sinks) == 0 {
+		return unavailableBroker{err: errors.New("multi-sink broker has no sinks")}
+	}
+	return b.sinks[0]
+}
+
+func (b *MultiSinkBroker) topic(topic string) string {
+	return b.topicPrefix + topic
+}
+
+// unavailableBroker stands in for a sink that could not be initialized, so
+// it counts as a failed write instead of disappearing from the quorum
+type unavailableBroker struct {
+	err error
+}
+
+func (u unavailableBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	return u.err
+}
+
+func (u unavailableBroker) PublishJSON(ctx context.Context, topic string, data interface{}) error {
+	return u.err
+}
+
+func (u unavailableBroker) BatchPublish(ctx context.Context, topic string, messages []*Message) []error {
+	return BatchErrors(len(messages), u.err)
+}
+
+func (u unavailableBroker) PublishIdempotent(ctx context.Context, topic string, message *Message, idempotencyKey string, ttl time.Duration) (bool, error) {
+	return false, u.err
+}
+
+func (u unavailableBroker) PublishWithDelay(ctx context.Context, topic string, message *Message, delay time.Duration) error {
+	return u.err
+}
+
+func (u unavailableBroker) Subscribe(ctx context.Context, topic string, handler MessageHandler) error {
+	return u.err
+}
+
+func (u unavailableBroker) SubscribeWithGroup(ctx context.Context, topic string, group string, handler MessageHandler) error {
+	return u.err
+}
+
+func (u unavailableBroker) EnqueueJob(ctx context.Context, queue string, job *Job) error {
+	return u.err
+}
+
+func (u unavailableBroker) ProcessJobs(ctx context.Context, queue string, handler JobHandler) error {
+	return u.err
+}
+
+func (u unavailableBroker) CreateTopic(ctx context.Context, topic string, config *TopicConfig) error {
+	return u.err
+}
+
+func (u unavailableBroker) DeleteTopic(ctx context.Context, topic string) error { return u.err }
+
+func (u unavailableBroker) GetTopicInfo(ctx context.Context, topic string) (*TopicInfo, error) {
+	return nil, u.err
+}
+
+func (u unavailableBroker) Ping(ctx context.Context) error { return u.err }
+func (u unavailableBroker) Close() error                   { return nil }
+
+func (u unavailableBroker) GetStats() (*BrokerStats, error) { return nil, u.err }
@@ -0,0 +1,282 @@
+package messagebroker
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// failingBroker is a stub whose writes always fail
+type failingBroker struct {
+	stubBroker
+	err error
+}
+
+func (f *failingBroker) Publish(ctx context.Context, topic string, message *Message) error {
+	return f.err
+}
+
+func (f *failingBroker) BatchPublish(ctx context.Context, topic string, messages []*Message) []error {
+	return BatchErrors(len(messages), f.err)
+}
+
+func (f *failingBroker) EnqueueJob(ctx context.Context, queue string, job *Job) error {
+	return f.err
+}
+
+func (f *failingBroker) Ping(ctx context.Context) error { return f.err }
+
+// newMultiSinkBroker creates a multi-sink broker with a valid quorum
+func newMultiSinkBroker(t *testing.T, quorum Quorum, sinks []MessageBroker, names ...string) *MultiSinkBroker {
+	t.Helper()
+
+	broker, err := NewMultiSinkBroker(quorum, sinks, names...)
+	require.NoError(t, err)
+	return broker
+}
+
+func TestNewMultiSinkBroker(t *testing.T) {
+	t.Run("should reject an unsupported quorum", func(t *testing.T) {
+		_, err := NewMultiSinkBroker("most", []MessageBroker{&stubBroker{}})
+
+		assert.EqualError(t, err, `unsupported quorum "most", expected all, majority or any`)
+	})
+
+	t.Run("should default an empty quorum to all", func(t *testing.T) {
+		broker := newMultiSinkBroker(t, "", []MessageBroker{&stubBroker{}})
+
+		assert.Equal(t, QuorumAll, broker.quorum)
+	})
+}
+
+func TestMultiSinkBrokerQuorum(t *testing.T) {
+	ctx := context.Background()
+	errDown := errors.New("broker down")
+
+	newOneOfTwoFailing := func(quorum Quorum) (*MultiSinkBroker, *stubBroker) {
+		healthy := &stubBroker{}
+		broker := newMultiSinkBroker(t, quorum, []MessageBroker{healthy, &failingBroker{err: errDown}}, "redis", "kafka")
+		return broker, healthy
+	}
+
+	t.Run("should fail under quorum all when one of two sinks fails", func(t *testing.T) {
+		broker, healthy := newOneOfTwoFailing(QuorumAll)
+
+		err := broker.Publish(ctx, "orders", &Message{Payload: []byte("{}")})
+
+		var sinkErr *MultiSinkError
+		require.ErrorAs(t, err, &sinkErr)
+		assert.Equal(t, 1, sinkErr.Succeeded)
+		assert.Equal(t, 2, sinkErr.Required)
+		assert.Contains(t, sinkErr.Failures, "kafka")
+		assert.ErrorIs(t, err, errDown)
+		assert.Len(t, healthy.Published(), 1)
+	})
+
+	t.Run("should fail under quorum majority when one of two sinks fails", func(t *testing.T) {
+		broker, _ := newOneOfTwoFailing(QuorumMajority)
+
+		err := broker.Publish(ctx, "orders", &Message{Payload: []byte("{}")})
+
+		var sinkErr *MultiSinkError
+		require.ErrorAs(t, err, &sinkErr)
+		assert.Equal(t, 2, sinkErr.Required)
+	})
+
+	t.Run("should succeed under quorum any when one of two sinks fails", func(t *testing.T) {
+		broker, healthy := newOneOfTwoFailing(QuorumAny)
+
+		err := broker.Publish(ctx, "orders", &Message{Payload: []byte("{}")})
+
+		require.NoError(t, err)
+		assert.Len(t, healthy.Published(), 1)
+	})
+
+	t.Run("should succeed under quorum majority when two of three sinks succeed", func(t *testing.T) {
+		broker := newMultiSinkBroker(t, QuorumMajority, []MessageBroker{
+			&stubBroker{}, &stubBroker{}, &failingBroker{err: errDown},
+		})
+
+		assert.NoError(t, broker.Publish(ctx, "orders", &Message{Payload: []byte("{}")}))
+	})
+
+	t.Run("should apply the quorum to jobs and pings", func(t *testing.T) {
+		broker, _ := newOneOfTwoFailing(QuorumAll)
+
+		assert.Error(t, broker.EnqueueJob(ctx, "emails", &Job{}))
+		assert.Error(t, broker.Ping(ctx))
+	})
+
+	t.Run("should fail without any sinks", func(t *testing.T) {
+		broker := newMultiSinkBroker(t, QuorumAny, nil)
+
+		assert.Error(t, broker.Publish(ctx, "orders", &Message{}))
+	})
+}
+
+func TestMultiSinkBrokerBatchPublish(t *testing.T) {
+	ctx := context.Background()
+	messages := []*Message{{Payload: []byte("{}")}, {Payload: []byte("{}")}}
+
+	t.Run("should apply the quorum to each message", func(t *testing.T) {
+		healthy := &stubBroker{}
+		broker := newMultiSinkBroker(t, QuorumAny, []MessageBroker{healthy, &failingBroker{err: errors.New("broker down")}})
+
+		assert.Equal(t, []error{nil, nil}, broker.BatchPublish(ctx, "orders", messages))
+		assert.Len(t, healthy.Published(), 2)
+	})
+
+	t.Run("should fail each message that misses the quorum", func(t *testing.T) {
+		errDown := errors.New("broker down")
+		broker := newMultiSinkBroker(t, QuorumAll, []MessageBroker{&stubBroker{}, &failingBroker{err: errDown}})
+
+		errs := broker.BatchPublish(ctx, "orders", messages)
+
+		require.Len(t, errs, 2)
+		for _, err := range errs {
+			var sinkErr *MultiSinkError
+			require.ErrorAs(t, err, &sinkErr)
+			assert.Equal(t, "batch_publish", sinkErr.Op)
+			assert.ErrorIs(t, err, errDown)
+		}
+	})
+}
+
+func TestMultiSinkBrokerMessageSize(t *testing.T) {
+	ctx := context.Background()
+	large := &Message{Payload: make([]byte, 11)}
+
+	t.Run("should reject an oversized message before writing to any sink", func(t *testing.T) {
+		first, second := &stubBroker{}, &stubBroker{}
+		broker := newMultiSinkBroker(t, QuorumAny, []MessageBroker{first, second})
+		broker.maxMessageBytes = 10
+
+		assert.ErrorIs(t, broker.Publish(ctx, "orders", large), ErrMessageTooLarge)
+		assert.ErrorIs(t, broker.PublishWithDelay(ctx, "orders", large, time.Second), ErrMessageTooLarge)
+		assert.Empty(t, first.Published())
+		assert.Empty(t, second.Published())
+	})
+
+	t.Run("should send only the batch messages within the limit", func(t *testing.T) {
+		sink := &stubBroker{}
+		broker := newMultiSinkBroker(t, QuorumAll, []MessageBroker{sink})
+		broker.maxMessageBytes = 10
+
+		errs := broker.BatchPublish(ctx, "orders", []*Message{large, {Payload: []byte("{}")}})
+
+		require.Len(t, errs, 2)
+		assert.ErrorIs(t, errs[0], ErrMessageTooLarge)
+		assert.NoError(t, errs[1])
+		assert.Len(t, sink.Published(), 1)
+	})
+}
+
+func TestMultiSinkBrokerPublishJSON(t *testing.T) {
+	t.Run("should send the same message to every sink", func(t *testing.T) {
+		first, second := &stubBroker{}, &stubBroker{}
+		broker := newMultiSinkBroker(t, QuorumAll, []MessageBroker{first, second})
+
+		require.NoError(t, broker.PublishJSON(context.Background(), "orders", map[string]string{"id": "1"}))
+
+		require.Len(t, first.Published(), 1)
+		require.Len(t, second.Published(), 1)
+		assert.Equal(t, first.Published()[0].ID, second.Published()[0].ID)
+	})
+}
+
+func TestParseQuorum(t *testing.T) {
+	t.Run("should default to all", func(t *testing.T) {
+		quorum, err := ParseQuorum("")
+		require.NoError(t, err)
+		assert.Equal(t, QuorumAll, quorum)
+	})
+
+	t.Run("should reject unknown quorums", func(t *testing.T) {
+		_, err := ParseQuorum("most")
+		assert.Error(t, err)
+	})
+}
+
+func TestManagerMultiSink(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should write prefixed topics to every configured driver", func(t *testing.T) {
+		redis, kafka := &stubBroker{}, &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver:      "redis",
+			TopicPrefix: "app.dev.",
+		}, map[string]MessageBroker{"redis": redis, "kafka": kafka})
+
+		sink, err := manager.MultiSink([]string{"redis", "kafka"}, "all")
+		require.NoError(t, err)
+
+		require.NoError(t, sink.Publish(ctx, "payments", &Message{Payload: []byte("{}")}))
+		assert.Equal(t, []string{"app.dev.payments"}, redis.Topics())
+		assert.Equal(t, []string{"app.dev.payments"}, kafka.Topics())
+	})
+
+	t.Run("should count a driver that cannot be initialized as a failed sink", func(t *testing.T) {
+		redis := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis"}, map[string]MessageBroker{"redis": redis})
+
+		all, err := manager.MultiSink([]string{"redis", "kafka"}, "all")
+		require.NoError(t, err)
+		err = all.Publish(ctx, "payments", &Message{})
+
+		var sinkErr *MultiSinkError
+		require.ErrorAs(t, err, &sinkErr)
+		assert.Contains(t, sinkErr.Failures, "kafka")
+
+		anyOf, err := manager.MultiSink([]string{"redis", "kafka"}, "any")
+		require.NoError(t, err)
+		assert.NoError(t, anyOf.Publish(ctx, "payments", &Message{}))
+	})
+
+	t.Run("should limit payloads to the smallest driver limit", func(t *testing.T) {
+		redis, kafka := &stubBroker{}, &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Redis:  &RedisPubSubConfig{MaxMessageBytes: 1024 * 1024},
+			Kafka:  &KafkaConfig{MaxMessageBytes: 10},
+		}, map[string]MessageBroker{"redis": redis, "kafka": kafka})
+
+		sink, err := manager.MultiSink([]string{"redis", "kafka"}, "any")
+		require.NoError(t, err)
+
+		err = sink.Publish(ctx, "payments", &Message{Payload: make([]byte, 11)})
+
+		assert.ErrorIs(t, err, ErrMessageTooLarge)
+		assert.Empty(t, redis.Published())
+	})
+
+	t.Run("should reject an unsupported quorum", func(t *testing.T) {
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis"}, map[string]MessageBroker{"redis": &stubBroker{}})
+
+		_, err := manager.MultiSink([]string{"redis"}, "most")
+
+		assert.Error(t, err)
+	})
+
+	t.Run("should reject an unsupported sink quorum in the config", func(t *testing.T) {
+		_, err := NewManager(&MessageBrokerConfig{
+			Driver: "redis",
+			Sink:   &SinkConfig{Drivers: []string{"redis", "kafka"}, Quorum: "most"},
+		})
+
+		assert.ErrorContains(t, err, "unsupported quorum")
+	})
+
+	t.Run("should fall back to the default driver without sinks", func(t *testing.T) {
+		redis := &stubBroker{}
+		manager := newTestManager(&MessageBrokerConfig{Driver: "redis"}, map[string]MessageBroker{"redis": redis})
+
+		sink, err := manager.Sink()
+
+		require.NoError(t, err)
+		assert.Same(t, redis, sink)
+	})
+}
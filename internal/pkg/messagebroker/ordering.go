@@ -0,0 +1,96 @@
+package messagebroker
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// OrderingKeyPrefix prefixes the Redis sorted sets queuing the messages of
+// each partition key
+const OrderingKeyPrefix = "ordered:"
+
+// orderingTTL is how long a partition's queue and sequence are kept after
+// its last message was appended
+const orderingTTL = 24 * time.Hour
+
+// appendOrdered numbers the message with the partition's next sequence and
+// queues it under that score, returning the sequence
+var appendOrdered = redis.NewScript(`
+local seq = redis.call("INCR", KEYS[2])
+redis.call("ZADD", KEYS[1], seq, ARGV[1])
+redis.call("EXPIRE", KEYS[1], ARGV[2])
+redis.call("EXPIRE", KEYS[2], ARGV[2])
+return seq
+`)
+
+// OrderingStore queues the messages of each partition key in a Redis sorted
+// set at ordered:<topic>:<key>, scored by a per-key sequence, for brokers
+// that cannot route them to a single ordered partition like Kafka does.
+//
+// Publishing appends the message to its queue; consumers receiving a
+// message with a partition key handle the oldest message of the queue in
+// its place, so the messages of a key are handled once each, in publish
+// order, whatever order they are delivered in.
+type OrderingStore struct {
+	client *redis.Client
+}
+
+// NewOrderingStore creates an ordering store keeping its queues in client
+func NewOrderingStore(client *redis.Client) *OrderingStore {
+	return &OrderingStore{client: client}
+}
+
+// OrderingStoreSetter is implemented by brokers that keep the messages of a
+// partition key in order with a store set after they are created
+type OrderingStoreSetter interface {
+	SetOrderingStore(store *OrderingStore)
+}
+
+// Append queues the message behind those already published with its
+// partition key and returns its sequence number
+func (s *OrderingStore) Append(ctx context.Context, topic string, message *Message) (int64, error) {
+	if message.PartitionKey == "" {
+		return 0, fmt.Errorf("message %s has no partition key", message.ID)
+	}
+
+	data, err := json.Marshal(message)
+	if err != nil {
+		return 0, fmt.Errorf("failed to marshal message: %w", err)
+	}
+
+	key := orderingKey(topic, message.PartitionKey)
+	keys := []string{key, key + ":seq"}
+	seq, err := appendOrdered.Run(ctx, s.client, keys, data, int(orderingTTL.Seconds())).Int64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to queue message %s under partition key %s: %w", message.ID, message.PartitionKey, err)
+	}
+	return seq, nil
+}
+
+// Next removes and returns the oldest message queued under partitionKey, nil
+// when the queue is empty
+func (s *OrderingStore) Next(ctx context.Context, topic, partitionKey string) (*Message, error) {
+	popped, err := s.client.ZPopMin(ctx, orderingKey(topic, partitionKey), 1).Result()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return nil, fmt.Errorf("failed to dequeue message under partition key %s: %w", partitionKey, err)
+	}
+	if len(popped) == 0 {
+		return nil, nil
+	}
+
+	data, _ := popped[0].Member.(string)
+	var message Message
+	if err := json.Unmarshal([]byte(data), &message); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal message under partition key %s: %w", partitionKey, err)
+	}
+	return &message, nil
+}
+
+func orderingKey(topic, partitionKey string) string {
+	return OrderingKeyPrefix + topic + ":" + partitionKey
+}
@@ -0,0 +1,60 @@
+package messagebroker
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func TestOrderingStore(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should hand out the messages of a key in publish order", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		store := NewOrderingStore(client)
+
+		for _, id := range []string{"1", "2", "3"} {
+			_, err := store.Append(ctx, "orders", &Message{ID: id, Topic: "orders", Payload: []byte(id), PartitionKey: "customer-1"})
+			require.NoError(t, err)
+		}
+		_, err := store.Append(ctx, "orders", &Message{ID: "other", Topic: "orders", PartitionKey: "customer-2"})
+		require.NoError(t, err)
+
+		for _, id := range []string{"1", "2", "3"} {
+			message, err := store.Next(ctx, "orders", "customer-1")
+			require.NoError(t, err)
+			require.NotNil(t, message)
+			assert.Equal(t, id, message.ID)
+			assert.Equal(t, []byte(id), message.Payload)
+			assert.Equal(t, "customer-1", message.PartitionKey)
+		}
+
+		message, err := store.Next(ctx, "orders", "customer-1")
+		require.NoError(t, err)
+		assert.Nil(t, message)
+	})
+
+	t.Run("should number the messages of each key", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		store := NewOrderingStore(client)
+
+		first, err := store.Append(ctx, "orders", &Message{ID: "1", PartitionKey: "customer-1"})
+		require.NoError(t, err)
+		second, err := store.Append(ctx, "orders", &Message{ID: "2", PartitionKey: "customer-1"})
+		require.NoError(t, err)
+
+		assert.Equal(t, int64(1), first)
+		assert.Equal(t, int64(2), second)
+	})
+
+	t.Run("should require a partition key", func(t *testing.T) {
+		_, err := NewOrderingStore(client).Append(ctx, "orders", &Message{ID: "1"})
+
+		assert.ErrorContains(t, err, "has no partition key")
+	})
+}
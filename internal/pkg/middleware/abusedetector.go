@@ -0,0 +1,262 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	abuseBlockedKey = "abuse:blocked"
+	abuseReasonsKey = "abuse:blocked:reasons"
+	abuseKeyPrefix  = "abuse:"
+
+	// DefaultAbuseBlockDuration is how long an IP stays blocked when no
+	// duration is configured
+	DefaultAbuseBlockDuration = 15 * time.Minute
+)
+
+// AbuseDetectorConfig holds the thresholds that get a client IP blocked.
+// Zero values use the defaults noted on each field.
+type AbuseDetectorConfig struct {
+	// ErrorRateThreshold is the share of error responses (default 0.5)
+	ErrorRateThreshold float64 `json:"error_rate_threshold" mapstructure:"error_rate_threshold"`
+	// ErrorRateWindow is the number of recent requests it is measured over
+	// (default 100)
+	ErrorRateWindow int `json:"error_rate_window" mapstructure:"error_rate_window"`
+	// SamePathPerMinute is the number of requests to one path allowed per
+	// minute (default 1000)
+	SamePathPerMinute int `json:"same_path_per_minute" mapstructure:"same_path_per_minute"`
+	// DistinctPathsPerSecond is the number of different paths allowed per
+	// second (default 20)
+	DistinctPathsPerSecond int `json:"distinct_paths_per_second" mapstructure:"distinct_paths_per_second"`
+	// BlockDuration is how long an abusive IP is blocked (default 15m)
+	BlockDuration time.Duration `json:"block_duration" mapstructure:"block_duration"`
+}
+
+// BlockedIP is an entry of the abuse blocklist
+type BlockedIP struct {
+	IP           string    `json:"ip"`
+	Reason       string    `json:"reason"`
+	BlockedUntil time.Time `json:"blocked_until"`
+}
+
+type abuseDetector struct {
+	redis  *redis.Client
+	config AbuseDetectorConfig
+}
+
+// NewAbuseDetector blocks client IPs whose traffic looks like abuse rather
+// than heavy use: mostly failing requests, hammering one path, or sweeping
+// many paths at once. Blocked IPs receive 403 until BlockDuration passes.
+// Register it before the rate limiter so blocked clients are turned away
+// without consuming rate limit budget.
+//
+// Counters live in Redis so all instances share them. Redis errors fail
+// open.
+func NewAbuseDetector(client *redis.Client, cfg AbuseDetectorConfig) gin.HandlerFunc {
+	if cfg.ErrorRateThreshold <= 0 {
+		cfg.ErrorRateThreshold = 0.5
+	}
+	if cfg.ErrorRateWindow <= 0 {
+		cfg.ErrorRateWindow = 100
+	}
+	if cfg.SamePathPerMinute <= 0 {
+		cfg.SamePathPerMinute = 1000
+	}
+	if cfg.DistinctPathsPerSecond <= 0 {
+		cfg.DistinctPathsPerSecond = 20
+	}
+	if cfg.BlockDuration <= 0 {
+		cfg.BlockDuration = DefaultAbuseBlockDuration
+	}
+
+	d := &abuseDetector{redis: client, config: cfg}
+	return d.handle
+}
+
+func (d *abuseDetector) handle(c *gin.Context) {
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+
+	blocked, err := d.isBlocked(ctx, ip)
+	if err == nil && blocked {
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access temporarily blocked"})
+		return
+	}
+
+	if reason, err := d.checkRequestPattern(ctx, ip, c.Request.URL.Path); err == nil && reason != "" {
+		d.block(ctx, ip, reason)
+		c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "access temporarily blocked"})
+		return
+	}
+
+	c.Next()
+
+	if reason, err := d.checkErrorRate(ctx, ip, c.Writer.Status()); err == nil && reason != "" {
+		d.block(ctx, ip, reason)
+	}
+}
+
+func (d *abuseDetector) isBlocked(ctx context.Context, ip string) (bool, error) {
+	until, err := d.redis.ZScore(ctx, abuseBlockedKey, ip).Result()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return int64(until) > time.Now().Unix(), nil
+}
+
+// checkRequestPattern counts the request towards the same-path and
+// distinct-path windows and returns a reason when either is exceeded
+func (d *abuseDetector) checkRequestPattern(ctx context.Context, ip, path string) (string, error) {
+	now := time.Now()
+	pathKey := fmt.Sprintf("%spath:%s:%d:%s", abuseKeyPrefix, ip, now.Unix()/60, path)
+	distinctKey := fmt.Sprintf("%spaths:%s:%d", abuseKeyPrefix, ip, now.Unix())
+
+	pipe := d.redis.TxPipeline()
+	pathCount := pipe.Incr(ctx, pathKey)
+	pipe.Expire(ctx, pathKey, 2*time.Minute)
+	pipe.SAdd(ctx, distinctKey, path)
+	distinctCount := pipe.SCard(ctx, distinctKey)
+	pipe.Expire(ctx, distinctKey, 2*time.Second)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+
+	if pathCount.Val() > int64(d.config.SamePathPerMinute) {
+		return fmt.Sprintf("more than %d requests to %s in a minute", d.config.SamePathPerMinute, path), nil
+	}
+	if distinctCount.Val() > int64(d.config.DistinctPathsPerSecond) {
+		return fmt.Sprintf("more than %d distinct paths in a second", d.config.DistinctPathsPerSecond), nil
+	}
+	return "", nil
+}
+
+// checkErrorRate records the response status and returns a reason once the
+// window is full and its error rate is over the threshold
+func (d *abuseDetector) checkErrorRate(ctx context.Context, ip string, status int) (string, error) {
+	key := fmt.Sprintf("%sstatus:%s", abuseKeyPrefix, ip)
+	outcome := "0"
+	if status >= http.StatusBadRequest {
+		outcome = "1"
+	}
+
+	window := int64(d.config.ErrorRateWindow)
+	pipe := d.redis.TxPipeline()
+	pipe.LPush(ctx, key, outcome)
+	pipe.LTrim(ctx, key, 0, window-1)
+	pipe.Expire(ctx, key, time.Hour)
+	recent := pipe.LRange(ctx, key, 0, window-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return "", err
+	}
+
+	outcomes := recent.Val()
+	if int64(len(outcomes)) < window {
+		return "", nil
+	}
+
+	failures := 0
+	for _, o := range outcomes {
+		if o == "1" {
+			failures++
+		}
+	}
+
+	rate := float64(failures) / float64(len(outcomes))
+	if rate > d.config.ErrorRateThreshold {
+		return fmt.Sprintf("error rate %.0f%% over the last %d requests", rate*100, window), nil
+	}
+	return "", nil
+}
+
+func (d *abuseDetector) block(ctx context.Context, ip, reason string) {
+	until := time.Now().Add(d.config.BlockDuration).Unix()
+
+	pipe := d.redis.TxPipeline()
+	pipe.ZAdd(ctx, abuseBlockedKey, redis.Z{Score: float64(until), Member: ip})
+	pipe.HSet(ctx, abuseReasonsKey, ip, reason)
+	// A fresh window once the block expires, so old errors do not re-block
+	pipe.Del(ctx, fmt.Sprintf("%sstatus:%s", abuseKeyPrefix, ip))
+	pipe.Exec(ctx)
+}
+
+// ListBlockedIPs returns the IPs currently blocked by the abuse detector,
+// soonest to be unblocked first. Expired entries are removed.
+func ListBlockedIPs(ctx context.Context, client *redis.Client) ([]BlockedIP, error) {
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	expired, err := client.ZRangeByScore(ctx, abuseBlockedKey, &redis.ZRangeBy{Min: "-inf", Max: now}).Result()
+	if err != nil {
+		return nil, err
+	}
+	if len(expired) > 0 {
+		pipe := client.TxPipeline()
+		pipe.ZRem(ctx, abuseBlockedKey, toInterfaces(expired)...)
+		pipe.HDel(ctx, abuseReasonsKey, expired...)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	entries, err := client.ZRangeByScoreWithScores(ctx, abuseBlockedKey, &redis.ZRangeBy{Min: "(" + now, Max: "+inf"}).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	blocked := make([]BlockedIP, 0, len(entries))
+	if len(entries) == 0 {
+		return blocked, nil
+	}
+
+	ips := make([]string, len(entries))
+	for i, entry := range entries {
+		ips[i] = entry.Member.(string)
+	}
+	reasons, err := client.HMGet(ctx, abuseReasonsKey, ips...).Result()
+	if err != nil {
+		return nil, err
+	}
+
+	for i, entry := range entries {
+		reason, _ := reasons[i].(string)
+		blocked = append(blocked, BlockedIP{
+			IP:           ips[i],
+			Reason:       reason,
+			BlockedUntil: time.Unix(int64(entry.Score), 0).UTC(),
+		})
+	}
+	return blocked, nil
+}
+
+// NewBlockedIPsHandler serves the abuse blocklist as JSON
+func NewBlockedIPsHandler(client *redis.Client) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		blocked, err := ListBlockedIPs(c.Request.Context(), client)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list blocked IPs"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"blocked_ips": blocked,
+			"count":       len(blocked),
+		})
+	}
+}
+
+func toInterfaces(values []string) []interface{} {
+	result := make([]interface{}, len(values))
+	for i, v := range values {
+		result[i] = v
+	}
+	return result
+}
@@ -0,0 +1,121 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func newAbuseRouter(t *testing.T, cfg AbuseDetectorConfig) *gin.Engine {
+	t.Helper()
+
+	client := containertest.NewRedis(t)
+	require.NoError(t, client.FlushDB(context.Background()).Err())
+
+	router := gin.New()
+	router.Use(NewAbuseDetector(client, cfg))
+	router.GET("/ok/*path", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fail", func(c *gin.Context) {
+		c.Status(http.StatusNotFound)
+	})
+	router.GET("/admin/abuse/blocked-ips", NewBlockedIPsHandler(client))
+
+	return router
+}
+
+func abuseRequest(router *gin.Engine, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewAbuseDetector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should block IPs with a high error rate", func(t *testing.T) {
+		router := newAbuseRouter(t, AbuseDetectorConfig{})
+
+		for i := 0; i < 100; i++ {
+			path := "/ok/a"
+			if i%10 < 6 {
+				path = "/fail"
+			}
+			abuseRequest(router, path, "10.0.0.1")
+		}
+
+		assert.Equal(t, http.StatusForbidden, abuseRequest(router, "/ok/a", "10.0.0.1").Code)
+		assert.Equal(t, http.StatusOK, abuseRequest(router, "/ok/a", "10.0.0.2").Code)
+	})
+
+	t.Run("should allow IPs with a tolerable error rate", func(t *testing.T) {
+		router := newAbuseRouter(t, AbuseDetectorConfig{})
+
+		for i := 0; i < 100; i++ {
+			path := "/ok/a"
+			if i%10 < 4 {
+				path = "/fail"
+			}
+			abuseRequest(router, path, "10.0.0.1")
+		}
+
+		assert.Equal(t, http.StatusOK, abuseRequest(router, "/ok/a", "10.0.0.1").Code)
+	})
+
+	t.Run("should block IPs hammering one path", func(t *testing.T) {
+		router := newAbuseRouter(t, AbuseDetectorConfig{})
+
+		var last int
+		for i := 0; i < 1001; i++ {
+			last = abuseRequest(router, "/ok/same", "10.0.0.3").Code
+		}
+
+		assert.Equal(t, http.StatusForbidden, last)
+		assert.Equal(t, http.StatusForbidden, abuseRequest(router, "/ok/other", "10.0.0.3").Code)
+	})
+
+	t.Run("should block IPs sweeping many paths", func(t *testing.T) {
+		router := newAbuseRouter(t, AbuseDetectorConfig{})
+
+		// Twice the limit, so one second holds more than 20 even if the
+		// requests straddle a second boundary
+		for i := 0; i < 41; i++ {
+			abuseRequest(router, fmt.Sprintf("/ok/scan-%d", i), "10.0.0.4")
+		}
+
+		assert.Equal(t, http.StatusForbidden, abuseRequest(router, "/ok/a", "10.0.0.4").Code)
+	})
+
+	t.Run("should list blocked IPs", func(t *testing.T) {
+		router := newAbuseRouter(t, AbuseDetectorConfig{DistinctPathsPerSecond: 2})
+
+		for i := 0; i < 5; i++ {
+			abuseRequest(router, fmt.Sprintf("/ok/scan-%d", i), "10.0.0.5")
+		}
+
+		w := abuseRequest(router, "/admin/abuse/blocked-ips", "10.0.0.6")
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var response struct {
+			BlockedIPs []BlockedIP `json:"blocked_ips"`
+			Count      int         `json:"count"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		require.Equal(t, 1, response.Count)
+		assert.Equal(t, "10.0.0.5", response.BlockedIPs[0].IP)
+		assert.Contains(t, response.BlockedIPs[0].Reason, "distinct paths")
+		assert.False(t, response.BlockedIPs[0].BlockedUntil.IsZero())
+	})
+}
@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func newCanaryTestRouter(userID string, handler gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.GET("/checkout", func(c *gin.Context) {
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		c.Next()
+	}, handler)
+	return router
+}
+
+func serveCanary(router *gin.Engine) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/checkout", nil))
+	return w
+}
+
+func versionHandler(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.String(http.StatusOK, version)
+	}
+}
+
+func TestNewCanaryRouter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should always route the same user to the same version", func(t *testing.T) {
+		canary := NewCanaryRouter(versionHandler("v1"), versionHandler("v2"), 50, true)
+
+		for i := 0; i < 20; i++ {
+			router := newCanaryTestRouter(fmt.Sprintf("user-%d", i), canary)
+			first := serveCanary(router).Body.String()
+
+			for j := 0; j < 10; j++ {
+				assert.Equal(t, first, serveCanary(router).Body.String())
+			}
+		}
+	})
+
+	t.Run("should record served version in header", func(t *testing.T) {
+		router := newCanaryTestRouter("", NewCanaryRouter(versionHandler("v1"), versionHandler("v2"), 100, false))
+
+		w := serveCanary(router)
+
+		assert.Equal(t, "v2", w.Header().Get(CanaryVersionHeader))
+		assert.Equal(t, "v2", w.Body.String())
+	})
+
+	t.Run("should keep all traffic on v1 at zero percent", func(t *testing.T) {
+		router := newCanaryTestRouter("", NewCanaryRouter(versionHandler("v1"), versionHandler("v2"), 0, false))
+
+		for i := 0; i < 100; i++ {
+			assert.Equal(t, "v1", serveCanary(router).Header().Get(CanaryVersionHeader))
+		}
+	})
+
+	t.Run("should split random traffic by percentage", func(t *testing.T) {
+		router := newCanaryTestRouter("", NewCanaryRouter(versionHandler("v1"), versionHandler("v2"), 30, false))
+
+		v2 := 0
+		for i := 0; i < 2000; i++ {
+			if serveCanary(router).Body.String() == "v2" {
+				v2++
+			}
+		}
+
+		assert.InDelta(t, 600, v2, 150)
+	})
+
+	t.Run("should split sticky users by percentage", func(t *testing.T) {
+		canary := NewCanaryRouter(versionHandler("v1"), versionHandler("v2"), 20, true)
+
+		v2 := 0
+		for i := 0; i < 2000; i++ {
+			if serveCanary(newCanaryTestRouter(fmt.Sprintf("user-%d", i), canary)).Body.String() == "v2" {
+				v2++
+			}
+		}
+
+		assert.InDelta(t, 400, v2, 150)
+	})
+}
+
+func TestCanaryRouterRedisReload(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should pick up percentage changes from redis", func(t *testing.T) {
+		require.NoError(t, client.Set(ctx, canaryKey("checkout"), "100", 0).Err())
+		defer client.Del(ctx, canaryKey("checkout"))
+
+		router := newCanaryTestRouter("", NewCanaryRouter(
+			versionHandler("v1"), versionHandler("v2"), 0, false,
+			WithCanaryRedis(client, "checkout", 10*time.Millisecond),
+		))
+
+		assert.Eventually(t, func() bool {
+			return serveCanary(router).Body.String() == "v2"
+		}, 2*time.Second, 20*time.Millisecond)
+	})
+}
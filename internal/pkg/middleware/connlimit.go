@@ -0,0 +1,72 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxConnRetryExponent caps the Retry-After hint at 2^10 seconds
+const maxConnRetryExponent = 10
+
+type connectionLimiter struct {
+	maxPerIP int32
+	active   sync.Map // client IP -> *atomic.Int32
+}
+
+// NewConnectionLimiter answers 429 once a client IP has more than maxPerIP
+// requests in flight, which rate limiting alone does not bound for slow or
+// long-lived requests. The IP is gin's ClientIP, so X-Forwarded-For is
+// honoured from trusted proxies as in the abuse detector. Retry-After
+// doubles with every request over the limit: 2^(in flight - maxPerIP)
+// seconds.
+func NewConnectionLimiter(maxPerIP int) gin.HandlerFunc {
+	l := &connectionLimiter{maxPerIP: int32(maxPerIP)}
+	return l.handle
+}
+
+func (l *connectionLimiter) handle(c *gin.Context) {
+	ip := c.ClientIP()
+	counter, count := l.acquire(ip)
+	defer l.release(ip, counter)
+
+	if count > l.maxPerIP {
+		c.Header("Retry-After", strconv.Itoa(connRetryAfter(count-l.maxPerIP)))
+		c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{"error": "too many concurrent requests"})
+		return
+	}
+
+	c.Next()
+}
+
+// acquire counts a request in flight for ip and returns the counter with
+// the new count
+func (l *connectionLimiter) acquire(ip string) (*atomic.Int32, int32) {
+	for {
+		value, _ := l.active.LoadOrStore(ip, new(atomic.Int32))
+		counter := value.(*atomic.Int32)
+		count := counter.Add(1)
+
+		// release may have dropped the counter between the load and the
+		// increment; count against the one now in the map instead
+		if current, ok := l.active.Load(ip); ok && current == value {
+			return counter, count
+		}
+		counter.Add(-1)
+	}
+}
+
+// release forgets IPs without requests in flight so the map does not grow
+// with every client ever seen
+func (l *connectionLimiter) release(ip string, counter *atomic.Int32) {
+	if counter.Add(-1) == 0 {
+		l.active.CompareAndDelete(ip, counter)
+	}
+}
+
+func connRetryAfter(over int32) int {
+	return 1 << min(over, maxConnRetryExponent)
+}
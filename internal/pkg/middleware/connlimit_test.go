@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newConnLimitRouter serves /slow until release is closed and reports each
+// request that reached the handler on entered
+func newConnLimitRouter(maxPerIP int) (*gin.Engine, chan struct{}, chan struct{}) {
+	release := make(chan struct{})
+	entered := make(chan struct{}, 100)
+
+	router := gin.New()
+	router.Use(NewConnectionLimiter(maxPerIP))
+	router.GET("/slow", func(c *gin.Context) {
+		entered <- struct{}{}
+		<-release
+		c.Status(http.StatusOK)
+	})
+	router.GET("/fast", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router, release, entered
+}
+
+func requestFrom(router *gin.Engine, path, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestNewConnectionLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should allow 5 of 10 concurrent connections from one IP", func(t *testing.T) {
+		router, release, entered := newConnLimitRouter(5)
+
+		var wg sync.WaitGroup
+		responses := make(chan *httptest.ResponseRecorder, 10)
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				responses <- requestFrom(router, "/slow", "203.0.113.7")
+			}()
+		}
+
+		// Five requests hold their slot while the other five are turned away
+		for i := 0; i < 5; i++ {
+			select {
+			case <-entered:
+			case <-time.After(time.Second):
+				t.Fatal("allowed requests did not reach the handler")
+			}
+		}
+		var rejected []*httptest.ResponseRecorder
+		for i := 0; i < 5; i++ {
+			select {
+			case w := <-responses:
+				rejected = append(rejected, w)
+			case <-time.After(time.Second):
+				t.Fatal("excess requests were not rejected")
+			}
+		}
+
+		close(release)
+		wg.Wait()
+		close(responses)
+
+		for _, w := range rejected {
+			assert.Equal(t, http.StatusTooManyRequests, w.Code)
+
+			retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+			require.NoError(t, err)
+			assert.Contains(t, []int{2, 4, 8, 16, 32}, retryAfter)
+		}
+		for w := range responses {
+			assert.Equal(t, http.StatusOK, w.Code)
+		}
+	})
+
+	t.Run("should limit each IP separately", func(t *testing.T) {
+		router, release, entered := newConnLimitRouter(1)
+		defer close(release)
+
+		go requestFrom(router, "/slow", "203.0.113.7")
+		<-entered
+
+		assert.Equal(t, http.StatusTooManyRequests, requestFrom(router, "/fast", "203.0.113.7").Code)
+		assert.Equal(t, http.StatusOK, requestFrom(router, "/fast", "198.51.100.1").Code)
+	})
+
+	t.Run("should free the slot when the request ends", func(t *testing.T) {
+		router, release, _ := newConnLimitRouter(1)
+		close(release)
+
+		for i := 0; i < 3; i++ {
+			assert.Equal(t, http.StatusOK, requestFrom(router, "/slow", "203.0.113.7").Code)
+		}
+	})
+
+	t.Run("should use the forwarded client IP from trusted proxies", func(t *testing.T) {
+		router, release, entered := newConnLimitRouter(1)
+		defer close(release)
+
+		go requestFrom(router, "/slow", "203.0.113.7")
+		<-entered
+
+		req := httptest.NewRequest(http.MethodGet, "/fast", nil)
+		req.RemoteAddr = "10.0.0.1:1234"
+		req.Header.Set("X-Forwarded-For", "203.0.113.7")
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusTooManyRequests, w.Code)
+	})
+}
+
+func TestConnRetryAfter(t *testing.T) {
+	t.Run("should double with every request over the limit", func(t *testing.T) {
+		assert.Equal(t, 2, connRetryAfter(1))
+		assert.Equal(t, 4, connRetryAfter(2))
+		assert.Equal(t, 32, connRetryAfter(5))
+		assert.Equal(t, 1024, connRetryAfter(40))
+	})
+}
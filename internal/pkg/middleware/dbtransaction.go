@@ -0,0 +1,74 @@
+package middleware
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TxContextKey is the gin context key holding the request transaction
+const TxContextKey = "db_tx"
+
+// TxBeginner starts transactions. *sql.DB implements it, as does
+// postgres.ManagedDB.
+type TxBeginner interface {
+	BeginTx(ctx context.Context, opts *sql.TxOptions) (*sql.Tx, error)
+}
+
+// NewDBTransaction wraps each request in a database transaction. The
+// transaction is committed when the handler responds with a status below 500
+// and rolled back otherwise, or when the handler panics.
+//
+// The response is buffered until the commit succeeds, so a client is never
+// told a write succeeded when the commit failed.
+func NewDBTransaction(db TxBeginner, isolation sql.IsolationLevel) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tx, err := db.BeginTx(c.Request.Context(), &sql.TxOptions{Isolation: isolation})
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, gin.H{"error": "Failed to begin transaction"})
+			return
+		}
+		c.Set(TxContextKey, tx)
+
+		recorder := newResponseRecorder(c.Writer)
+		c.Writer = recorder
+
+		defer func() {
+			if r := recover(); r != nil {
+				tx.Rollback()
+				c.Writer = recorder.ResponseWriter
+				panic(r)
+			}
+		}()
+
+		c.Next()
+
+		c.Writer = recorder.ResponseWriter
+
+		if recorder.Status() >= http.StatusInternalServerError {
+			tx.Rollback()
+			c.Writer.Write(recorder.body.Bytes())
+			return
+		}
+
+		if err := tx.Commit(); err != nil {
+			c.Error(err)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to commit transaction"})
+			return
+		}
+
+		c.Writer.Write(recorder.body.Bytes())
+	}
+}
+
+// GetTx returns the transaction started by NewDBTransaction for this request
+func GetTx(c *gin.Context) (*sql.Tx, bool) {
+	value, exists := c.Get(TxContextKey)
+	if !exists {
+		return nil, false
+	}
+	tx, ok := value.(*sql.Tx)
+	return tx, ok
+}
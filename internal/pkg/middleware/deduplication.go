@@ -0,0 +1,168 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// DefaultDedupTTL is the deduplication window used when none is configured
+	DefaultDedupTTL = 5 * time.Second
+
+	// DedupReplayedHeader is set on responses served from the dedup cache
+	DedupReplayedHeader = "X-Dedup-Replayed"
+
+	dedupKeyPrefix    = "dedup:"
+	dedupPending      = "pending"
+	dedupPollInterval = 50 * time.Millisecond
+)
+
+// dedupResponse is the cached copy of the first response for a fingerprint
+type dedupResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type"`
+	Body        []byte `json:"body"`
+}
+
+// NewContentDedup collapses identical POST and PUT requests received within
+// the TTL into a single execution. The first request runs the handler and its
+// response is cached under the fingerprint returned by hashFn; duplicates wait
+// for it and receive the same response. Other methods pass through untouched.
+//
+// A zero ttl uses DefaultDedupTTL and a nil hashFn uses DefaultDedupHash.
+// Server errors are not cached so the client can retry.
+func NewContentDedup(client *redis.Client, ttl time.Duration, hashFn func(*gin.Context) string) gin.HandlerFunc {
+	if ttl <= 0 {
+		ttl = DefaultDedupTTL
+	}
+	if hashFn == nil {
+		hashFn = DefaultDedupHash
+	}
+
+	return func(c *gin.Context) {
+		if c.Request.Method != http.MethodPost && c.Request.Method != http.MethodPut {
+			c.Next()
+			return
+		}
+
+		key := dedupKeyPrefix + hashFn(c)
+		ctx := c.Request.Context()
+
+		claimed, err := client.SetNX(ctx, key, dedupPending, ttl).Result()
+		if err != nil {
+			// Fail open: a Redis outage should not take writes down with it
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			cached, err := waitForDedupResponse(ctx, client, key, ttl)
+			if err != nil {
+				c.AbortWithStatusJSON(http.StatusConflict, gin.H{"error": "duplicate request is still being processed"})
+				return
+			}
+			if cached != nil {
+				c.Header(DedupReplayedHeader, "true")
+				c.Data(cached.Status, cached.ContentType, cached.Body)
+				c.Abort()
+				return
+			}
+			// The first request failed and released the key; handle this one normally
+		}
+
+		recorder := newResponseRecorder(c.Writer)
+		c.Writer = recorder
+
+		c.Next()
+
+		c.Writer = recorder.ResponseWriter
+		body := recorder.body.Bytes()
+
+		// Use a fresh context so a cancelled request still updates the cache
+		storeCtx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			client.Del(storeCtx, key)
+		} else if data, err := json.Marshal(dedupResponse{
+			Status:      status,
+			ContentType: recorder.Header().Get("Content-Type"),
+			Body:        body,
+		}); err == nil {
+			client.Set(storeCtx, key, data, ttl)
+		}
+
+		c.Writer.Write(body)
+	}
+}
+
+// DefaultDedupHash fingerprints a request as the SHA-256 of its method, path,
+// body and authenticated user ID. The body is restored for the handler.
+func DefaultDedupHash(c *gin.Context) string {
+	var body []byte
+	if c.Request.Body != nil {
+		body, _ = io.ReadAll(c.Request.Body)
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	var userID string
+	if v, exists := c.Get("user_id"); exists {
+		userID = fmt.Sprint(v)
+	}
+
+	h := sha256.New()
+	h.Write([]byte(c.Request.Method))
+	h.Write([]byte{0})
+	h.Write([]byte(c.Request.URL.Path))
+	h.Write([]byte{0})
+	h.Write(body)
+	h.Write([]byte{0})
+	h.Write([]byte(userID))
+
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// waitForDedupResponse polls until the first request stores its response.
+// It returns nil without error when the key disappears, meaning the first
+// request failed and the caller should process the request itself.
+func waitForDedupResponse(ctx context.Context, client *redis.Client, key string, ttl time.Duration) (*dedupResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, ttl)
+	defer cancel()
+
+	ticker := time.NewTicker(dedupPollInterval)
+	defer ticker.Stop()
+
+	for {
+		value, err := client.Get(ctx, key).Result()
+		switch {
+		case errors.Is(err, redis.Nil):
+			return nil, nil
+		case err != nil:
+			return nil, err
+		case value != dedupPending:
+			var cached dedupResponse
+			if err := json.Unmarshal([]byte(value), &cached); err != nil {
+				return nil, err
+			}
+			return &cached, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func TestNewContentDedup(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := containertest.NewRedis(t)
+
+	t.Run("should run handler once for concurrent identical requests", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		var inserts int32
+		router := gin.New()
+		router.Use(NewContentDedup(client, 0, nil))
+		router.POST("/orders", func(c *gin.Context) {
+			atomic.AddInt32(&inserts, 1)
+			time.Sleep(100 * time.Millisecond)
+			c.JSON(http.StatusCreated, gin.H{"id": "order-1"})
+		})
+
+		var wg sync.WaitGroup
+		responses := make([]*httptest.ResponseRecorder, 5)
+		for i := range responses {
+			wg.Add(1)
+			go func(i int) {
+				defer wg.Done()
+				req := httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{"sku":"abc"}`))
+				responses[i] = httptest.NewRecorder()
+				router.ServeHTTP(responses[i], req)
+			}(i)
+		}
+		wg.Wait()
+
+		assert.Equal(t, int32(1), atomic.LoadInt32(&inserts))
+		for _, w := range responses {
+			assert.Equal(t, http.StatusCreated, w.Code)
+			assert.JSONEq(t, `{"id":"order-1"}`, w.Body.String())
+		}
+	})
+
+	t.Run("should process requests with different bodies separately", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		var inserts int32
+		router := gin.New()
+		router.Use(NewContentDedup(client, time.Second, nil))
+		router.POST("/orders", func(c *gin.Context) {
+			atomic.AddInt32(&inserts, 1)
+			c.Status(http.StatusCreated)
+		})
+
+		for _, body := range []string{`{"sku":"abc"}`, `{"sku":"def"}`} {
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(body)))
+			assert.Empty(t, w.Header().Get(DedupReplayedHeader))
+		}
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&inserts))
+	})
+
+	t.Run("should not cache server errors", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		var calls int32
+		router := gin.New()
+		router.Use(NewContentDedup(client, time.Second, nil))
+		router.PUT("/orders/1", func(c *gin.Context) {
+			if atomic.AddInt32(&calls, 1) == 1 {
+				c.Status(http.StatusInternalServerError)
+				return
+			}
+			c.Status(http.StatusOK)
+		})
+
+		for range 2 {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodPut, "/orders/1", bytes.NewBufferString(`{}`)))
+		}
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+
+	t.Run("should ignore GET requests", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(context.Background()).Err())
+
+		var calls int32
+		router := gin.New()
+		router.Use(NewContentDedup(client, time.Second, nil))
+		router.GET("/orders", func(c *gin.Context) {
+			atomic.AddInt32(&calls, 1)
+			c.Status(http.StatusOK)
+		})
+
+		for range 2 {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/orders", nil))
+		}
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+	})
+}
+
+func TestDefaultDedupHash(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	hash := func(method, body, userID string) string {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(method, "/orders", bytes.NewBufferString(body))
+		if userID != "" {
+			c.Set("user_id", userID)
+		}
+		return DefaultDedupHash(c)
+	}
+
+	t.Run("should be stable for identical requests", func(t *testing.T) {
+		assert.Equal(t, hash(http.MethodPost, `{"a":1}`, "u1"), hash(http.MethodPost, `{"a":1}`, "u1"))
+	})
+
+	t.Run("should differ by body, method and user", func(t *testing.T) {
+		base := hash(http.MethodPost, `{"a":1}`, "u1")
+		assert.NotEqual(t, base, hash(http.MethodPost, `{"a":2}`, "u1"))
+		assert.NotEqual(t, base, hash(http.MethodPut, `{"a":1}`, "u1"))
+		assert.NotEqual(t, base, hash(http.MethodPost, `{"a":1}`, "u2"))
+	})
+
+	t.Run("should restore request body", func(t *testing.T) {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/orders", bytes.NewBufferString(`{"a":1}`))
+
+		DefaultDedupHash(c)
+
+		var body map[string]int
+		require.NoError(t, c.ShouldBindJSON(&body))
+		assert.Equal(t, 1, body["a"])
+	})
+}
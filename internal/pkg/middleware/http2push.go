@@ -0,0 +1,27 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// NewHTTP2Push pushes the assets listed for the matched route pattern (e.g.
+// "/" or "/api/v1/users/:id") before the handler runs, so HTTP/2 clients get
+// them without waiting to parse the response. Connections that cannot push,
+// HTTP/1 or clients that disabled it, are served as usual.
+func NewHTTP2Push(paths map[string][]string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		assets := paths[c.FullPath()]
+		if len(assets) > 0 && c.Request.Method == http.MethodGet {
+			if pusher := c.Writer.Pusher(); pusher != nil {
+				for _, asset := range assets {
+					// A refused push only costs the client a normal request
+					_ = pusher.Push(asset, nil)
+				}
+			}
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+// pushRecorder records the assets pushed through it
+type pushRecorder struct {
+	*httptest.ResponseRecorder
+	pushed []string
+}
+
+func (r *pushRecorder) Push(target string, opts *http.PushOptions) error {
+	r.pushed = append(r.pushed, target)
+	return nil
+}
+
+func TestNewHTTP2Push(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := gin.New()
+	router.Use(NewHTTP2Push(map[string][]string{
+		"/":          {"/static/app.css", "/static/app.js"},
+		"/users/:id": {"/static/users.css"},
+	}))
+	handler := func(c *gin.Context) { c.Status(http.StatusOK) }
+	router.GET("/", handler)
+	router.POST("/", handler)
+	router.GET("/users/:id", handler)
+	router.GET("/health", handler)
+
+	t.Run("should push the assets of the matched route pattern", func(t *testing.T) {
+		w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, []string{"/static/app.css", "/static/app.js"}, w.pushed)
+
+		w = &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/42", nil))
+
+		assert.Equal(t, []string{"/static/users.css"}, w.pushed)
+	})
+
+	t.Run("should not push for other routes or methods", func(t *testing.T) {
+		w := &pushRecorder{ResponseRecorder: httptest.NewRecorder()}
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/health", nil))
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/", nil))
+
+		assert.Empty(t, w.pushed)
+	})
+
+	t.Run("should serve connections that cannot push", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+	})
+}
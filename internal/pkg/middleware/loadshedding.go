@@ -0,0 +1,278 @@
+package middleware
+
+import (
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/shirou/gopsutil/v3/cpu"
+	"github.com/shirou/gopsutil/v3/mem"
+
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+)
+
+// Request priorities used by the load shedder
+const (
+	PriorityHigh   = "high"
+	PriorityNormal = "normal"
+	PriorityLow    = "low"
+
+	// PriorityHeader marks the priority of service-to-service calls. It is
+	// only honoured from the networks given to WithTrustedPriorityNetworks.
+	PriorityHeader = "X-Priority"
+
+	loadSampleInterval = time.Second
+	shedRetryAfter     = "5"
+)
+
+// LoadSheddingRate is the fraction of requests currently shed per priority
+var LoadSheddingRate = prometheus.NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name: "load_shedding_rate",
+		Help: "Fraction of requests currently shed by the load shedder",
+	},
+	[]string{"priority"},
+)
+
+// LoadSampler returns the current system CPU and memory usage in percent
+type LoadSampler func() (cpu, memory float64, err error)
+
+// LoadSheddingOption configures a load shedder
+type LoadSheddingOption func(*loadShedder)
+
+// WithLoadSampler replaces the gopsutil system sampler
+func WithLoadSampler(sampler LoadSampler) LoadSheddingOption {
+	return func(s *loadShedder) {
+		s.sampler = sampler
+	}
+}
+
+// WithPriorityTokens reads the priority claim from bearer tokens. The shedder
+// runs before authentication, so it validates the token itself; invalid
+// tokens are left for the auth middleware to reject.
+func WithPriorityTokens(jwtService *auth.JWTService) LoadSheddingOption {
+	return func(s *loadShedder) {
+		s.tokens = jwtService
+	}
+}
+
+// WithTrustedPriorityNetworks honours the X-Priority header of requests
+// coming directly from one of the networks, such as other services inside
+// the cluster. Elsewhere it is ignored, so clients cannot raise their own
+// priority. Invalid CIDRs are ignored; the config validates them on load.
+func WithTrustedPriorityNetworks(cidrs ...string) LoadSheddingOption {
+	return func(s *loadShedder) {
+		for _, cidr := range cidrs {
+			if _, network, err := net.ParseCIDR(strings.TrimSpace(cidr)); err == nil {
+				s.trustedNetworks = append(s.trustedNetworks, network)
+			}
+		}
+	}
+}
+
+// shedRates are the fractions of low and normal priority requests to drop.
+// High priority requests are never shed.
+type shedRates struct {
+	low    float64
+	normal float64
+}
+
+type loadShedder struct {
+	cpuThreshold    float64
+	memoryThreshold float64
+	sampler         LoadSampler
+	tokens          *auth.JWTService
+	trustedNetworks []*net.IPNet
+
+	rates      atomic.Pointer[shedRates]
+	mu         sync.Mutex
+	lastSample time.Time
+	sampling   atomic.Bool
+}
+
+// NewLoadShedder drops part of the non-priority traffic once system CPU or
+// memory usage passes the given percentages. Usage is sampled at most once a
+// second in the background, so requests never wait on it.
+//
+// The shed rate grows with the overload, measured as the share of the
+// headroom above the threshold that is in use. With a 50% CPU threshold,
+// 50% CPU sheds 10% of low priority requests and 80% CPU sheds 50% of low
+// and 10% of normal ones; at 100% all low and half of normal requests are
+// shed. Shed requests get a 503 with Retry-After.
+func NewLoadShedder(cpu float64, memory float64, opts ...LoadSheddingOption) gin.HandlerFunc {
+	s := &loadShedder{
+		cpuThreshold:    cpu,
+		memoryThreshold: memory,
+		sampler:         systemLoad,
+	}
+	s.rates.Store(&shedRates{})
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s.handle
+}
+
+func (s *loadShedder) handle(c *gin.Context) {
+	s.maybeSample()
+
+	rates := s.rates.Load()
+	var rate float64
+	switch s.priority(c) {
+	case PriorityLow:
+		rate = rates.low
+	case PriorityNormal:
+		rate = rates.normal
+	}
+
+	if rate > 0 && rand.Float64() < rate {
+		c.Header("Retry-After", shedRetryAfter)
+		c.AbortWithStatusJSON(http.StatusServiceUnavailable, gin.H{"error": "server is overloaded, retry later"})
+		return
+	}
+
+	c.Next()
+}
+
+// priority prefers the verified token claim over the header, which is only
+// read from trusted networks
+func (s *loadShedder) priority(c *gin.Context) string {
+	if s.tokens != nil {
+		if token, found := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer "); found {
+			if claims, err := s.tokens.ValidateToken(token); err == nil && claims.Priority != "" {
+				return normalizePriority(claims.Priority)
+			}
+		}
+	}
+
+	if !s.trusted(c.RemoteIP()) {
+		return PriorityNormal
+	}
+	return normalizePriority(c.GetHeader(PriorityHeader))
+}
+
+// trusted reports whether the peer address is in a trusted network
+func (s *loadShedder) trusted(remoteIP string) bool {
+	ip := net.ParseIP(remoteIP)
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range s.trustedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func normalizePriority(priority string) string {
+	switch p := strings.ToLower(strings.TrimSpace(priority)); p {
+	case PriorityHigh, PriorityLow:
+		return p
+	default:
+		return PriorityNormal
+	}
+}
+
+// maybeSample refreshes the shed rates in the background once the sample
+// interval has passed
+func (s *loadShedder) maybeSample() {
+	s.mu.Lock()
+	due := time.Since(s.lastSample) >= loadSampleInterval
+	if due {
+		s.lastSample = time.Now()
+	}
+	s.mu.Unlock()
+
+	if !due || !s.sampling.CompareAndSwap(false, true) {
+		return
+	}
+
+	go func() {
+		defer s.sampling.Store(false)
+		s.sample()
+	}()
+}
+
+func (s *loadShedder) sample() {
+	cpuUsage, memoryUsage, err := s.sampler()
+	if err != nil {
+		return
+	}
+
+	overload := max(
+		headroomUsed(cpuUsage, s.cpuThreshold),
+		headroomUsed(memoryUsage, s.memoryThreshold),
+	)
+	rates := shedRatesFor(overload)
+	s.rates.Store(&rates)
+
+	LoadSheddingRate.WithLabelValues(PriorityLow).Set(rates.low)
+	LoadSheddingRate.WithLabelValues(PriorityNormal).Set(rates.normal)
+	LoadSheddingRate.WithLabelValues(PriorityHigh).Set(0)
+}
+
+// headroomUsed returns how far usage is past threshold as a fraction of the
+// remaining headroom, or -1 below the threshold
+func headroomUsed(usage, threshold float64) float64 {
+	if usage < threshold {
+		return -1
+	}
+	if threshold >= 100 {
+		return 1
+	}
+	return min(1, (usage-threshold)/(100-threshold))
+}
+
+// shedRatesFor interpolates the shed rates for an overload in [0, 1]
+func shedRatesFor(overload float64) shedRates {
+	if overload < 0 {
+		return shedRates{}
+	}
+
+	return shedRates{
+		low:    interpolate(overload, [2]float64{0, 0.1}, [2]float64{0.6, 0.5}, [2]float64{1, 1}),
+		normal: interpolate(overload, [2]float64{0.4, 0}, [2]float64{0.6, 0.1}, [2]float64{1, 0.5}),
+	}
+}
+
+// interpolate is a piecewise linear function through points sorted by x,
+// flat outside them
+func interpolate(x float64, points ...[2]float64) float64 {
+	if x <= points[0][0] {
+		return points[0][1]
+	}
+	for i := 1; i < len(points); i++ {
+		if x <= points[i][0] {
+			from, to := points[i-1], points[i]
+			return from[1] + (x-from[0])/(to[0]-from[0])*(to[1]-from[1])
+		}
+	}
+	return points[len(points)-1][1]
+}
+
+// systemLoad samples CPU usage since the previous call and current memory use
+func systemLoad() (float64, float64, error) {
+	cpuUsage, err := cpu.Percent(0, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	memory, err := mem.VirtualMemory()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var usage float64
+	if len(cpuUsage) > 0 {
+		usage = cpuUsage[0]
+	}
+	return usage, memory.UsedPercent, nil
+}
@@ -0,0 +1,187 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/auth"
+)
+
+// newSampledLoadShedder builds a shedder that has already sampled the given
+// usage, so requests see its rates without waiting on the background sample.
+// The priority header is trusted from httptest's default peer address.
+func newSampledLoadShedder(cpu, memory float64, opts ...LoadSheddingOption) *gin.Engine {
+	s := &loadShedder{
+		cpuThreshold:    50,
+		memoryThreshold: 80,
+		sampler:         func() (float64, float64, error) { return cpu, memory, nil },
+		lastSample:      time.Now(),
+	}
+	opts = append([]LoadSheddingOption{WithTrustedPriorityNetworks("192.0.2.0/24")}, opts...)
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.sample()
+
+	router := gin.New()
+	router.Use(s.handle)
+	router.GET("/orders", func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+// shedCount sends n requests and counts the 503 responses
+func shedCount(router *gin.Engine, n int, setup func(*http.Request)) int {
+	shed := 0
+	for i := 0; i < n; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if setup != nil {
+			setup(req)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		if w.Code == http.StatusServiceUnavailable {
+			shed++
+		}
+	}
+	return shed
+}
+
+func withPriority(priority string) func(*http.Request) {
+	return func(req *http.Request) {
+		req.Header.Set(PriorityHeader, priority)
+	}
+}
+
+func TestNewLoadShedder(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should not shed below the thresholds", func(t *testing.T) {
+		router := newSampledLoadShedder(40, 50)
+
+		assert.Zero(t, shedCount(router, 500, withPriority(PriorityLow)))
+	})
+
+	t.Run("should shed 10 percent of low priority requests at the CPU threshold", func(t *testing.T) {
+		router := newSampledLoadShedder(50, 50)
+
+		assert.InDelta(t, 200, shedCount(router, 2000, withPriority(PriorityLow)), 60)
+		assert.Zero(t, shedCount(router, 500, withPriority(PriorityNormal)))
+	})
+
+	t.Run("should shed low and normal priority requests at 80 percent CPU", func(t *testing.T) {
+		router := newSampledLoadShedder(80, 50)
+
+		assert.InDelta(t, 1000, shedCount(router, 2000, withPriority(PriorityLow)), 120)
+		assert.InDelta(t, 200, shedCount(router, 2000, withPriority(PriorityNormal)), 60)
+		assert.Zero(t, shedCount(router, 500, withPriority(PriorityHigh)))
+	})
+
+	t.Run("should shed on memory pressure", func(t *testing.T) {
+		router := newSampledLoadShedder(10, 100)
+
+		assert.Equal(t, 500, shedCount(router, 500, withPriority(PriorityLow)))
+	})
+
+	t.Run("should treat requests without priority as normal", func(t *testing.T) {
+		router := newSampledLoadShedder(100, 50)
+
+		assert.InDelta(t, 1000, shedCount(router, 2000, nil), 120)
+	})
+
+	t.Run("should return 503 with Retry-After", func(t *testing.T) {
+		router := newSampledLoadShedder(100, 100)
+
+		w := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		req.Header.Set(PriorityHeader, PriorityLow)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusServiceUnavailable, w.Code)
+		assert.Equal(t, "5", w.Header().Get("Retry-After"))
+	})
+
+	t.Run("should take the priority from the JWT claim over the header", func(t *testing.T) {
+		jwtService := auth.NewJWTService("secret", 3600)
+		token := signPriorityToken(t, jwtService, PriorityHigh)
+		router := newSampledLoadShedder(100, 100, WithPriorityTokens(jwtService))
+
+		shed := shedCount(router, 200, func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+token)
+			req.Header.Set(PriorityHeader, PriorityLow)
+		})
+
+		assert.Zero(t, shed)
+	})
+
+	t.Run("should ignore the priority header from untrusted networks", func(t *testing.T) {
+		router := newSampledLoadShedder(100, 100)
+
+		shed := shedCount(router, 200, func(req *http.Request) {
+			req.RemoteAddr = "203.0.113.7:4321"
+			req.Header.Set(PriorityHeader, PriorityHigh)
+		})
+
+		assert.InDelta(t, 100, shed, 30)
+	})
+
+	t.Run("should export the current shed rate", func(t *testing.T) {
+		newSampledLoadShedder(80, 50)
+
+		assert.InDelta(t, 0.5, testutil.ToFloat64(LoadSheddingRate.WithLabelValues(PriorityLow)), 0.001)
+		assert.InDelta(t, 0.1, testutil.ToFloat64(LoadSheddingRate.WithLabelValues(PriorityNormal)), 0.001)
+	})
+
+	t.Run("should sample system load in the background", func(t *testing.T) {
+		sampled := make(chan struct{}, 1)
+		router := gin.New()
+		router.Use(NewLoadShedder(50, 80, WithLoadSampler(func() (float64, float64, error) {
+			select {
+			case sampled <- struct{}{}:
+			default:
+			}
+			return 100, 100, nil
+		})))
+		router.GET("/orders", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		shedCount(router, 1, withPriority(PriorityLow))
+
+		select {
+		case <-sampled:
+		case <-time.After(time.Second):
+			t.Fatal("load was not sampled")
+		}
+		assert.Eventually(t, func() bool {
+			return shedCount(router, 1, withPriority(PriorityLow)) == 1
+		}, time.Second, 10*time.Millisecond)
+	})
+}
+
+func TestShedRatesFor(t *testing.T) {
+	t.Run("should grow with the overload", func(t *testing.T) {
+		assert.Equal(t, shedRates{}, shedRatesFor(-1))
+		assert.InDelta(t, 0.1, shedRatesFor(0).low, 0.001)
+		assert.InDelta(t, 0.5, shedRatesFor(0.6).low, 0.001)
+		assert.InDelta(t, 0.1, shedRatesFor(0.6).normal, 0.001)
+		assert.Equal(t, shedRates{low: 1, normal: 0.5}, shedRatesFor(1))
+	})
+}
+
+func signPriorityToken(t *testing.T, jwtService *auth.JWTService, priority string) string {
+	t.Helper()
+
+	token, _, err := jwtService.GenerateTokenWithPriority(uuid.New(), "service@example.com", "service", priority)
+	require.NoError(t, err)
+	return token
+}
@@ -0,0 +1,172 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/oschwald/geoip2-golang"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const (
+	loginIPsKeyPrefix = "user_login_ips:"
+
+	// loginIPsRetained caps how many distinct IPs are kept per user, the
+	// least used are dropped first
+	loginIPsRetained = 100
+	// loginIPsTTL expires the history of users who stop logging in
+	loginIPsTTL = 90 * 24 * time.Hour
+
+	// DefaultKnownLoginIPs is how many of a user's most used IPs count as
+	// familiar when no number is configured
+	DefaultKnownLoginIPs = 10
+)
+
+// AnomalousLogins counts successful logins from an IP outside the user's
+// most used ones.
+var AnomalousLogins = prometheus.NewCounter(
+	prometheus.CounterOpts{
+		Name: "security_anomalous_logins_total",
+		Help: "Total number of logins from an IP the user does not usually log in from",
+	},
+)
+
+// LoginAnomalyConfig configures the login anomaly detector
+type LoginAnomalyConfig struct {
+	// KnownIPs is how many of the user's most used IPs are familiar
+	// (default 10)
+	KnownIPs int
+	// GeoIP2DatabasePath is a MaxMind GeoIP2 or GeoLite2 country or city
+	// database used to add the country to warnings. Empty skips the lookup.
+	GeoIP2DatabasePath string
+	// BlockSuspiciousLogins rejects anomalous logins with 403 instead of
+	// only reporting them
+	BlockSuspiciousLogins bool
+	Logger                *logger.Logger
+}
+
+type loginAnomalyDetector struct {
+	redis  *redis.Client
+	geoip  *geoip2.Reader
+	config LoginAnomalyConfig
+}
+
+// LoginAnomalyDetector watches the login route for successful logins from
+// an IP outside the user's KnownIPs most used ones. Those are logged as a
+// warning and counted in AnomalousLogins, and rejected with 403 when
+// BlockSuspiciousLogins is set. A user's first login has nothing to compare
+// against and is never anomalous.
+//
+// The login handler must set "user_id" on the context once the credentials
+// are verified. Login IPs are counted per user in the Redis sorted set
+// user_login_ips:<userID>; rejected logins are not counted. Redis errors
+// fail open.
+func LoginAnomalyDetector(client *redis.Client, cfg LoginAnomalyConfig) (gin.HandlerFunc, error) {
+	if cfg.KnownIPs <= 0 {
+		cfg.KnownIPs = DefaultKnownLoginIPs
+	}
+	if cfg.Logger == nil {
+		cfg.Logger = logger.New("info", "json")
+	}
+
+	d := &loginAnomalyDetector{redis: client, config: cfg}
+	if cfg.GeoIP2DatabasePath != "" {
+		reader, err := geoip2.Open(cfg.GeoIP2DatabasePath)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open GeoIP2 database: %w", err)
+		}
+		d.geoip = reader
+	}
+
+	return d.handle, nil
+}
+
+func (d *loginAnomalyDetector) handle(c *gin.Context) {
+	if !d.config.BlockSuspiciousLogins {
+		c.Next()
+		d.inspect(c, c.Writer.Status())
+		return
+	}
+
+	// Hold the response back so an anomalous login can still be rejected
+	writer := &budgetWriter{ResponseWriter: c.Writer, buffering: true}
+	c.Writer = writer
+	c.Next()
+	c.Writer = writer.ResponseWriter
+
+	if !d.inspect(c, writer.Status()) || !writer.discard() {
+		writer.commit()
+		return
+	}
+
+	// Drop the session cookie and any headers of the discarded response
+	c.Writer.Header().Del("Set-Cookie")
+	c.Writer.Header().Del("Content-Length")
+	c.AbortWithStatusJSON(http.StatusForbidden, gin.H{"error": "login from an unrecognized location blocked"})
+}
+
+// inspect checks a login that ended with status against the user's IP
+// history and reports whether it should be blocked. Logins that are let through are
+// recorded.
+func (d *loginAnomalyDetector) inspect(c *gin.Context, status int) bool {
+	userID, ok := c.Get("user_id")
+	if !ok || status != http.StatusOK {
+		return false
+	}
+
+	ctx := c.Request.Context()
+	ip := c.ClientIP()
+	key := loginIPsKeyPrefix + fmt.Sprint(userID)
+
+	known, err := d.redis.ZRevRange(ctx, key, 0, int64(d.config.KnownIPs)-1).Result()
+	if err != nil {
+		return false
+	}
+
+	if len(known) > 0 && !slices.Contains(known, ip) {
+		AnomalousLogins.Inc()
+		d.config.Logger.Warn("Login from an unusual IP",
+			"user_id", fmt.Sprint(userID),
+			"ip", ip,
+			"country", d.country(ip),
+			"blocked", d.config.BlockSuspiciousLogins,
+		)
+		if d.config.BlockSuspiciousLogins {
+			return true
+		}
+	}
+
+	pipe := d.redis.TxPipeline()
+	pipe.ZIncrBy(ctx, key, 1, ip)
+	pipe.ZRemRangeByRank(ctx, key, 0, -loginIPsRetained-1)
+	pipe.Expire(ctx, key, loginIPsTTL)
+	pipe.Exec(ctx)
+
+	return false
+}
+
+// country returns the ISO code of the IP's country, or an empty string
+// without a GeoIP2 database or a match
+func (d *loginAnomalyDetector) country(ip string) string {
+	if d.geoip == nil {
+		return ""
+	}
+
+	parsed := net.ParseIP(ip)
+	if parsed == nil {
+		return ""
+	}
+
+	record, err := d.geoip.Country(parsed)
+	if err != nil {
+		return ""
+	}
+	return record.Country.IsoCode
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+func newLoginAnomalyRouter(t *testing.T, client *redis.Client, cfg LoginAnomalyConfig) *gin.Engine {
+	t.Helper()
+
+	cfg.Logger = logger.New("error", "text")
+	detector, err := LoginAnomalyDetector(client, cfg)
+	require.NoError(t, err)
+
+	router := gin.New()
+	router.POST("/login", detector, func(c *gin.Context) {
+		if c.Query("password") != "secret" {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+			return
+		}
+		c.Set("user_id", c.Query("user"))
+		c.SetCookie("session_id", "abc", 3600, "/", "", false, true)
+		c.JSON(http.StatusOK, gin.H{"message": "Login successful"})
+	})
+
+	return router
+}
+
+func loginRequest(router *gin.Engine, user, password, ip string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/login?user="+user+"&password="+password, nil)
+	req.RemoteAddr = ip + ":1234"
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func TestLoginAnomalyDetector(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should record login IPs per user", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		router := newLoginAnomalyRouter(t, client, LoginAnomalyConfig{})
+
+		for i := 0; i < 2; i++ {
+			require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.1").Code)
+		}
+
+		score, err := client.ZScore(ctx, "user_login_ips:alice", "10.0.0.1").Result()
+		require.NoError(t, err)
+		assert.Equal(t, float64(2), score)
+	})
+
+	t.Run("should count a login from an unfamiliar IP without blocking it", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		router := newLoginAnomalyRouter(t, client, LoginAnomalyConfig{})
+		before := testutil.ToFloat64(AnomalousLogins)
+
+		require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.1").Code)
+		assert.Equal(t, before, testutil.ToFloat64(AnomalousLogins), "first login has no history")
+
+		require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.2").Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(AnomalousLogins))
+
+		require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.2").Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(AnomalousLogins), "recorded IP is familiar")
+	})
+
+	t.Run("should only treat the most used IPs as familiar", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		router := newLoginAnomalyRouter(t, client, LoginAnomalyConfig{KnownIPs: 1})
+		require.NoError(t, client.ZAdd(ctx, "user_login_ips:alice",
+			redis.Z{Score: 5, Member: "10.0.0.1"},
+			redis.Z{Score: 1, Member: "10.0.0.2"},
+		).Err())
+		before := testutil.ToFloat64(AnomalousLogins)
+
+		require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.2").Code)
+		assert.Equal(t, before+1, testutil.ToFloat64(AnomalousLogins))
+	})
+
+	t.Run("should block unfamiliar logins when configured", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		router := newLoginAnomalyRouter(t, client, LoginAnomalyConfig{BlockSuspiciousLogins: true})
+
+		w := loginRequest(router, "alice", "secret", "10.0.0.1")
+		require.Equal(t, http.StatusOK, w.Code)
+		assert.NotEmpty(t, w.Header().Get("Set-Cookie"))
+		assert.Contains(t, w.Body.String(), "Login successful")
+
+		w = loginRequest(router, "alice", "secret", "10.0.0.2")
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Empty(t, w.Header().Get("Set-Cookie"))
+		assert.NotContains(t, w.Body.String(), "Login successful")
+
+		exists, err := client.ZScore(ctx, "user_login_ips:alice", "10.0.0.2").Result()
+		assert.ErrorIs(t, err, redis.Nil, "blocked login must not become familiar, got %v", exists)
+	})
+
+	t.Run("should ignore failed logins", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		router := newLoginAnomalyRouter(t, client, LoginAnomalyConfig{BlockSuspiciousLogins: true})
+
+		require.Equal(t, http.StatusOK, loginRequest(router, "alice", "secret", "10.0.0.1").Code)
+		w := loginRequest(router, "alice", "wrong", "10.0.0.2")
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		assert.Contains(t, w.Body.String(), "Invalid credentials")
+	})
+}
+
+func TestLoginAnomalyDetectorGeoIP(t *testing.T) {
+	t.Run("should fail on a missing GeoIP2 database", func(t *testing.T) {
+		_, err := LoginAnomalyDetector(nil, LoginAnomalyConfig{
+			GeoIP2DatabasePath: filepath.Join(t.TempDir(), "GeoLite2-Country.mmdb"),
+		})
+
+		assert.ErrorContains(t, err, "failed to open GeoIP2 database")
+	})
+
+	t.Run("should report no country without a database", func(t *testing.T) {
+		d := &loginAnomalyDetector{}
+
+		assert.Empty(t, d.country("8.8.8.8"))
+	})
+}
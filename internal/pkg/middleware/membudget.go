@@ -0,0 +1,257 @@
+package middleware
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"runtime"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+)
+
+// MemBudgetOverride is the gin context key holding a route's memory budget
+// in bytes as a uint64, replacing the global one. Zero disables the check
+// for the route. Set it with OverrideMemoryBudget.
+const MemBudgetOverride = "mem_budget_override"
+
+// defaultBudgetSampleRate checks one request in this many, since reading
+// the memory statistics stops the world
+const defaultBudgetSampleRate = 100
+
+// ErrMemoryBudgetExceeded is recorded on requests over their memory budget
+// whose response had already been flushed and so could not be replaced
+var ErrMemoryBudgetExceeded = errors.New("request exceeded its memory budget")
+
+// MemoryBudgetOption configures a memory budget
+type MemoryBudgetOption func(*memoryBudget)
+
+// WithBudgetSampleRate checks one request in n. One checks every request.
+func WithBudgetSampleRate(n int) MemoryBudgetOption {
+	return func(b *memoryBudget) {
+		b.sampleRate = max(n, 1)
+	}
+}
+
+// WithBudgetHub overrides the Sentry hub alerts are captured on
+func WithBudgetHub(hub *sentry.Hub) MemoryBudgetOption {
+	return func(b *memoryBudget) {
+		b.hub = hub
+	}
+}
+
+type memoryBudget struct {
+	maxBytes   uint64
+	sampleRate int
+	hub        *sentry.Hub
+}
+
+// NewMemoryBudget fails sampled requests whose handlers grow the heap by
+// more than maxBytes with 507 Insufficient Storage, and reports them to
+// Sentry. The growth is the HeapAlloc delta across the rest of the chain.
+// It is process wide, so concurrent requests count against the sampled one
+// and budgets need headroom over what a handler really allocates.
+//
+// Sampled responses are buffered so they can still be replaced. Once a
+// handler flushes or hijacks the connection the response goes out as is,
+// and an exceeded budget is only reported and recorded as
+// ErrMemoryBudgetExceeded.
+func NewMemoryBudget(maxBytes uint64, opts ...MemoryBudgetOption) gin.HandlerFunc {
+	b := &memoryBudget{
+		maxBytes:   maxBytes,
+		sampleRate: defaultBudgetSampleRate,
+		hub:        sentry.CurrentHub(),
+	}
+
+	for _, opt := range opts {
+		opt(b)
+	}
+
+	return b.handle
+}
+
+// OverrideMemoryBudget sets the memory budget for the routes it is added to
+func OverrideMemoryBudget(maxBytes uint64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(MemBudgetOverride, maxBytes)
+		c.Next()
+	}
+}
+
+func (b *memoryBudget) handle(c *gin.Context) {
+	if b.sampleRate > 1 && rand.IntN(b.sampleRate) != 0 {
+		c.Next()
+		return
+	}
+
+	writer := &budgetWriter{ResponseWriter: c.Writer, buffering: true}
+	c.Writer = writer
+
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	c.Next()
+	runtime.ReadMemStats(&after)
+
+	c.Writer = writer.ResponseWriter
+
+	// A collection during the request can shrink the heap
+	var allocated uint64
+	if after.HeapAlloc > before.HeapAlloc {
+		allocated = after.HeapAlloc - before.HeapAlloc
+	}
+
+	budget := b.budgetFor(c)
+	if budget == 0 || allocated <= budget {
+		writer.commit()
+		return
+	}
+
+	b.alert(c, allocated, budget)
+
+	if !writer.discard() {
+		_ = c.Error(ErrMemoryBudgetExceeded)
+		return
+	}
+	c.Writer.Header().Del("Content-Length")
+	c.AbortWithStatusJSON(http.StatusInsufficientStorage, gin.H{"error": ErrMemoryBudgetExceeded.Error()})
+}
+
+func (b *memoryBudget) budgetFor(c *gin.Context) uint64 {
+	if override, ok := c.Get(MemBudgetOverride); ok {
+		if budget, ok := override.(uint64); ok {
+			return budget
+		}
+	}
+	return b.maxBytes
+}
+
+func (b *memoryBudget) alert(c *gin.Context, allocated, budget uint64) {
+	if b.hub == nil {
+		return
+	}
+
+	endpoint := c.Request.Method + " " + c.FullPath()
+	b.hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetLevel(sentry.LevelWarning)
+		if userID, ok := c.Get("user_id"); ok {
+			email, _ := c.Get("user_email")
+			scope.SetUser(sentry.User{ID: fmt.Sprint(userID), Email: fmt.Sprint(email)})
+		}
+		scope.SetContext("memory_budget", sentry.Context{
+			"endpoint":        endpoint,
+			"allocated_bytes": allocated,
+			"budget_bytes":    budget,
+		})
+		b.hub.CaptureMessage(fmt.Sprintf("%s allocated %d bytes, over its memory budget of %d", endpoint, allocated, budget))
+	})
+}
+
+// budgetWriter holds the response back until the middleware has decided
+// whether to send it
+type budgetWriter struct {
+	gin.ResponseWriter
+	buffering bool
+	status    int
+	written   bool
+	body      bytes.Buffer
+}
+
+func (w *budgetWriter) WriteHeader(code int) {
+	if !w.buffering {
+		w.ResponseWriter.WriteHeader(code)
+		return
+	}
+	if code > 0 && !w.written {
+		w.status = code
+	}
+}
+
+func (w *budgetWriter) WriteHeaderNow() {
+	if !w.buffering {
+		w.ResponseWriter.WriteHeaderNow()
+		return
+	}
+	w.written = true
+}
+
+func (w *budgetWriter) Write(data []byte) (int, error) {
+	if !w.buffering {
+		return w.ResponseWriter.Write(data)
+	}
+	w.written = true
+	return w.body.Write(data)
+}
+
+func (w *budgetWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *budgetWriter) Status() int {
+	if !w.buffering {
+		return w.ResponseWriter.Status()
+	}
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+func (w *budgetWriter) Size() int {
+	if !w.buffering {
+		return w.ResponseWriter.Size()
+	}
+	if !w.written {
+		return -1
+	}
+	return w.body.Len()
+}
+
+func (w *budgetWriter) Written() bool {
+	if !w.buffering {
+		return w.ResponseWriter.Written()
+	}
+	return w.written
+}
+
+func (w *budgetWriter) Flush() {
+	w.commit()
+	w.ResponseWriter.Flush()
+}
+
+func (w *budgetWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	w.commit()
+	return w.ResponseWriter.Hijack()
+}
+
+// commit sends what was buffered and passes later writes straight through
+func (w *budgetWriter) commit() {
+	if !w.buffering {
+		return
+	}
+	w.buffering = false
+
+	if w.status != 0 {
+		w.ResponseWriter.WriteHeader(w.status)
+	}
+	if w.written {
+		w.ResponseWriter.WriteHeaderNow()
+		if w.body.Len() > 0 {
+			_, _ = w.ResponseWriter.Write(w.body.Bytes())
+		}
+	}
+}
+
+// discard drops the buffered response, reporting false if it was already
+// sent
+func (w *budgetWriter) discard() bool {
+	if !w.buffering {
+		return false
+	}
+	w.buffering = false
+	w.body.Reset()
+	return true
+}
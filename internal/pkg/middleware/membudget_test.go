@@ -0,0 +1,143 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// sentryRecorder records events instead of sending them to Sentry
+type sentryRecorder struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *sentryRecorder) Flush(timeout time.Duration) bool       { return true }
+func (t *sentryRecorder) Configure(options sentry.ClientOptions) {}
+func (t *sentryRecorder) Close()                                 {}
+
+func (t *sentryRecorder) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *sentryRecorder) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]*sentry.Event(nil), t.events...)
+}
+
+// allocatedSize is what the allocating handlers retain, far above the
+// budget so other allocations cannot hide it
+const allocatedSize = 64 << 20
+
+func newMemoryBudgetRouter(t *testing.T, maxBytes uint64, opts ...MemoryBudgetOption) (*gin.Engine, *sentryRecorder) {
+	t.Helper()
+
+	transport := &sentryRecorder{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	require.NoError(t, err)
+
+	opts = append([]MemoryBudgetOption{WithBudgetSampleRate(1), WithBudgetHub(sentry.NewHub(client, sentry.NewScope()))}, opts...)
+
+	// The handlers keep what they allocate reachable until the test ends,
+	// and earlier garbage is collected up front, so a collection during the
+	// request cannot shrink the heap
+	var retained [][]byte
+	t.Cleanup(func() { retained = nil })
+	runtime.GC()
+
+	router := gin.New()
+	router.Use(NewMemoryBudget(maxBytes, opts...))
+
+	allocate := func(c *gin.Context) {
+		c.Set("user_id", "user-1")
+		data := make([]byte, allocatedSize)
+		retained = append(retained, data)
+		c.String(http.StatusOK, "allocated %d", len(data))
+	}
+	router.GET("/allocate", allocate)
+	router.GET("/override", OverrideMemoryBudget(2*allocatedSize), allocate)
+	router.GET("/stream", func(c *gin.Context) {
+		c.String(http.StatusOK, "first chunk")
+		c.Writer.Flush()
+		retained = append(retained, make([]byte, allocatedSize))
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"status": "ok"})
+	})
+
+	return router, transport
+}
+
+func TestNewMemoryBudget(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should reject a handler allocating over the budget with 507", func(t *testing.T) {
+		router, transport := newMemoryBudgetRouter(t, 1<<20)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/allocate", nil))
+
+		assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+		assert.JSONEq(t, `{"error":"request exceeded its memory budget"}`, w.Body.String())
+
+		events := transport.Events()
+		require.Len(t, events, 1)
+		assert.Equal(t, "user-1", events[0].User.ID)
+		assert.Equal(t, "GET /allocate", events[0].Contexts["memory_budget"]["endpoint"])
+		assert.Greater(t, events[0].Contexts["memory_budget"]["allocated_bytes"], uint64(1<<20))
+	})
+
+	t.Run("should pass responses within the budget through", func(t *testing.T) {
+		router, transport := newMemoryBudgetRouter(t, 1<<30)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/small", nil))
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+		assert.Empty(t, transport.Events())
+	})
+
+	t.Run("should apply the route override", func(t *testing.T) {
+		router, transport := newMemoryBudgetRouter(t, 1<<20)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/override", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "allocated 67108864", w.Body.String())
+		assert.Empty(t, transport.Events())
+	})
+
+	t.Run("should only report responses that were already flushed", func(t *testing.T) {
+		router, transport := newMemoryBudgetRouter(t, 1<<20)
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/stream", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "first chunk", w.Body.String())
+		assert.Len(t, transport.Events(), 1)
+	})
+
+	t.Run("should skip requests that are not sampled", func(t *testing.T) {
+		router, transport := newMemoryBudgetRouter(t, 1<<20, WithBudgetSampleRate(1_000_000_000))
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/allocate", nil))
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, transport.Events())
+	})
+}
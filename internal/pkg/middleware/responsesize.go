@@ -0,0 +1,218 @@
+package middleware
+
+import (
+	"errors"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// TruncationMode selects what happens to responses over the size limit
+type TruncationMode string
+
+const (
+	// TruncationModeTruncate cuts the body at the limit and appends
+	// TruncatedMarker
+	TruncationModeTruncate TruncationMode = "truncate"
+
+	// TruncationModeReject fails the response with 507 Insufficient Storage
+	TruncationModeReject TruncationMode = "reject"
+)
+
+// TruncatedMarker is appended to bodies cut short in truncate mode
+const TruncatedMarker = `{"truncated": true}`
+
+// ErrResponseTooLarge is returned from Write once a response in reject mode
+// exceeds its limit
+var ErrResponseTooLarge = errors.New("response exceeds the maximum size")
+
+// ResponseSizeOption configures a response size limiter
+type ResponseSizeOption func(*responseSizeLimiter)
+
+// WithTruncationMode sets the behaviour for oversized responses. The default
+// is TruncationModeTruncate.
+func WithTruncationMode(mode TruncationMode) ResponseSizeOption {
+	return func(l *responseSizeLimiter) {
+		l.mode = mode
+	}
+}
+
+// WithContentTypeLimit overrides the limit for one media type, such as
+// "text/csv", or for a whole type with a wildcard, such as "image/*". A
+// limit of zero or less leaves that content type unlimited.
+func WithContentTypeLimit(contentType string, maxBytes int64) ResponseSizeOption {
+	return func(l *responseSizeLimiter) {
+		l.limits[strings.ToLower(contentType)] = maxBytes
+	}
+}
+
+type responseSizeLimiter struct {
+	maxBytes int64
+	mode     TruncationMode
+	limits   map[string]int64
+}
+
+// NewResponseSizeLimiter caps response bodies at maxBytes so a runaway
+// endpoint cannot send clients more than they can hold. The limit is chosen
+// from the response Content-Type when the headers are written.
+//
+// In truncate mode the body is cut at the limit and TruncatedMarker is
+// appended. In reject mode a response whose Content-Length already exceeds
+// the limit is replaced by a 507 before any body is sent; otherwise the 507
+// is sent when the limit is crossed, or, if part of the body has already
+// been flushed, the rest is dropped and the handler's writes fail with
+// ErrResponseTooLarge.
+func NewResponseSizeLimiter(maxBytes int64, opts ...ResponseSizeOption) gin.HandlerFunc {
+	l := &responseSizeLimiter{
+		maxBytes: maxBytes,
+		mode:     TruncationModeTruncate,
+		limits:   make(map[string]int64),
+	}
+
+	for _, opt := range opts {
+		opt(l)
+	}
+
+	return func(c *gin.Context) {
+		writer := &sizeLimitedWriter{
+			ResponseWriter: c.Writer,
+			limiter:        l,
+			limit:          -1,
+		}
+		c.Writer = writer
+
+		c.Next()
+
+		if writer.exceeded && l.mode == TruncationModeReject {
+			_ = c.Error(ErrResponseTooLarge)
+		}
+	}
+}
+
+// limitFor returns the limit for a Content-Type header, or a negative value
+// for no limit
+func (l *responseSizeLimiter) limitFor(contentType string) int64 {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err == nil {
+		if limit, ok := l.limits[mediaType]; ok {
+			return normalizeLimit(limit)
+		}
+		if slash := strings.Index(mediaType, "/"); slash > 0 {
+			if limit, ok := l.limits[mediaType[:slash]+"/*"]; ok {
+				return normalizeLimit(limit)
+			}
+		}
+	}
+	return normalizeLimit(l.maxBytes)
+}
+
+func normalizeLimit(limit int64) int64 {
+	if limit <= 0 {
+		return -1
+	}
+	return limit
+}
+
+// sizeLimitedWriter counts body bytes and enforces the limit
+type sizeLimitedWriter struct {
+	gin.ResponseWriter
+	limiter  *responseSizeLimiter
+	limit    int64
+	resolved bool
+	written  int64
+	exceeded bool
+}
+
+// resolve picks the limit once the handler has set its headers and applies
+// the Content-Length pre-check. It runs on the first write rather than in
+// WriteHeader, since gin sets the status before the Content-Type.
+func (w *sizeLimitedWriter) resolve() {
+	if w.resolved {
+		return
+	}
+	w.resolved = true
+
+	header := w.ResponseWriter.Header()
+	w.limit = w.limiter.limitFor(header.Get("Content-Type"))
+	if w.limit < 0 {
+		return
+	}
+
+	declared, err := strconv.ParseInt(header.Get("Content-Length"), 10, 64)
+	if err != nil || declared <= w.limit {
+		return
+	}
+
+	if w.limiter.mode == TruncationModeReject {
+		w.reject()
+		return
+	}
+	// The truncated body will not match the declared length
+	header.Del("Content-Length")
+}
+
+func (w *sizeLimitedWriter) WriteHeaderNow() {
+	w.resolve()
+	if w.exceeded {
+		return
+	}
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+func (w *sizeLimitedWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+func (w *sizeLimitedWriter) Write(data []byte) (int, error) {
+	w.resolve()
+	if w.exceeded {
+		if w.limiter.mode == TruncationModeReject {
+			return 0, ErrResponseTooLarge
+		}
+		// Report the dropped bytes as written so handlers keep going
+		return len(data), nil
+	}
+
+	if w.limit < 0 || w.written+int64(len(data)) <= w.limit {
+		n, err := w.ResponseWriter.Write(data)
+		w.written += int64(n)
+		return n, err
+	}
+
+	w.exceeded = true
+
+	if w.limiter.mode == TruncationModeReject {
+		if !w.ResponseWriter.Written() {
+			w.reject()
+		}
+		return 0, ErrResponseTooLarge
+	}
+
+	remaining := w.limit - w.written
+	n, err := w.ResponseWriter.Write(data[:remaining])
+	w.written += int64(n)
+	if err != nil {
+		return n, err
+	}
+	if _, err := w.ResponseWriter.WriteString("\n" + TruncatedMarker); err != nil {
+		return n, err
+	}
+	return len(data), nil
+}
+
+// reject replaces the response with a 507 while nothing has been sent
+func (w *sizeLimitedWriter) reject() {
+	w.exceeded = true
+
+	header := w.ResponseWriter.Header()
+	header.Del("Content-Length")
+	header.Del("Content-Encoding")
+	header.Del("Content-Disposition")
+	header.Set("Content-Type", "application/json; charset=utf-8")
+
+	w.ResponseWriter.WriteHeader(http.StatusInsufficientStorage)
+	w.ResponseWriter.WriteString(`{"error":"response exceeds the maximum size"}`)
+}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testResponseLimit = 1024 * 1024
+	largeResponseSize = 10 * 1024 * 1024
+)
+
+func newResponseSizeRouter(limiter gin.HandlerFunc) *gin.Engine {
+	router := gin.New()
+	router.Use(limiter)
+
+	large := bytes.Repeat([]byte("a"), largeResponseSize)
+
+	router.GET("/large", func(c *gin.Context) {
+		c.Data(http.StatusOK, "application/json", large)
+	})
+	router.GET("/stream", func(c *gin.Context) {
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		chunk := large[:64*1024]
+		for written := 0; written < largeResponseSize; written += len(chunk) {
+			if _, err := c.Writer.Write(chunk); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	})
+	router.GET("/declared", func(c *gin.Context) {
+		c.Header("Content-Length", strconv.Itoa(largeResponseSize))
+		c.Data(http.StatusOK, "application/octet-stream", large)
+	})
+	router.GET("/small", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+
+	return router
+}
+
+func serveResponseSize(router *gin.Engine, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+	return w
+}
+
+func TestNewResponseSizeLimiter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should truncate large responses and append the marker", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit))
+
+		w := serveResponseSize(router, "/large")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, testResponseLimit+len("\n"+TruncatedMarker), w.Body.Len())
+		assert.True(t, strings.HasSuffix(w.Body.String(), "\n"+TruncatedMarker))
+	})
+
+	t.Run("should truncate streamed responses", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit))
+
+		w := serveResponseSize(router, "/stream")
+
+		assert.Equal(t, testResponseLimit+len("\n"+TruncatedMarker), w.Body.Len())
+	})
+
+	t.Run("should drop a declared Content-Length when truncating", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit))
+
+		w := serveResponseSize(router, "/declared")
+
+		assert.Empty(t, w.Header().Get("Content-Length"))
+		assert.True(t, strings.HasSuffix(w.Body.String(), TruncatedMarker))
+	})
+
+	t.Run("should reject large responses with 507", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit, WithTruncationMode(TruncationModeReject)))
+
+		w := serveResponseSize(router, "/large")
+
+		assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+		assert.Contains(t, w.Body.String(), "response exceeds the maximum size")
+		assert.Less(t, w.Body.Len(), 100)
+	})
+
+	t.Run("should reject declared large responses before the body is sent", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit, WithTruncationMode(TruncationModeReject)))
+
+		w := serveResponseSize(router, "/declared")
+
+		assert.Equal(t, http.StatusInsufficientStorage, w.Code)
+		assert.Empty(t, w.Header().Get("Content-Length"))
+		assert.Less(t, w.Body.Len(), 100)
+	})
+
+	t.Run("should stop streamed responses mid-stream in reject mode", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit, WithTruncationMode(TruncationModeReject)))
+
+		w := serveResponseSize(router, "/stream")
+
+		// The status was flushed with the first chunk, so only the body is cut
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.LessOrEqual(t, w.Body.Len(), testResponseLimit)
+	})
+
+	t.Run("should apply per content type limits", func(t *testing.T) {
+		router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit,
+			WithContentTypeLimit("application/json", 0),
+			WithContentTypeLimit("text/*", 1024),
+		))
+
+		large := serveResponseSize(router, "/large")
+		assert.Equal(t, largeResponseSize, large.Body.Len())
+
+		stream := serveResponseSize(router, "/stream")
+		assert.Equal(t, 1024+len("\n"+TruncatedMarker), stream.Body.Len())
+	})
+
+	t.Run("should pass small responses through", func(t *testing.T) {
+		for _, mode := range []TruncationMode{TruncationModeTruncate, TruncationModeReject} {
+			router := newResponseSizeRouter(NewResponseSizeLimiter(testResponseLimit, WithTruncationMode(mode)))
+
+			w := serveResponseSize(router, "/small")
+
+			require.Equal(t, http.StatusOK, w.Code)
+			assert.JSONEq(t, `{"status":"ok"}`, w.Body.String())
+		}
+	})
+}
@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// APIVersionHeader carries the schema version the client was built against
+const APIVersionHeader = "X-API-Version"
+
+// MigrationFn transforms a JSON body from one schema version to the next
+type MigrationFn func(old map[string]interface{}) map[string]interface{}
+
+// ResponseRoute is the migrations key holding the response downgrades of a
+// route pattern
+func ResponseRoute(route string) string {
+	return route + " response"
+}
+
+// NewRequestMigration upgrades request bodies from old API versions to the
+// current schema and downgrades JSON responses back to the client's version.
+//
+// Migrations are keyed by route pattern ("POST /api/v1/users").
+// migrations[route][i] migrates a request body from version i+1 to i+2, so the
+// current version of a route is len(migrations[route])+1.
+// migrations[ResponseRoute(route)][i] reverses migrations[route][i] for the
+// response and may be nil when responses did not change. Requests without
+// X-API-Version are treated as current.
+func NewRequestMigration(migrations map[string][]MigrationFn) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		route := c.Request.Method + " " + c.FullPath()
+		chain := migrations[route]
+
+		version, ok := parseAPIVersion(c.GetHeader(APIVersionHeader))
+		if !ok || len(chain) == 0 || version > len(chain) {
+			c.Next()
+			return
+		}
+
+		if c.Request.Body != nil && c.Request.ContentLength != 0 {
+			body, err := io.ReadAll(c.Request.Body)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read request body"})
+				c.Abort()
+				return
+			}
+
+			var payload map[string]interface{}
+			if err := json.Unmarshal(body, &payload); err == nil {
+				for _, migrate := range chain[version-1:] {
+					payload = migrate(payload)
+				}
+				if upgraded, err := json.Marshal(payload); err == nil {
+					body = upgraded
+				}
+			}
+
+			c.Request.Body = io.NopCloser(bytes.NewReader(body))
+			c.Request.ContentLength = int64(len(body))
+			c.Request.Header.Set("Content-Length", strconv.Itoa(len(body)))
+		}
+
+		steps := migrations[ResponseRoute(route)]
+		if len(steps) < version {
+			c.Next()
+			return
+		}
+
+		recorder := newResponseRecorder(c.Writer)
+		c.Writer = recorder
+
+		c.Next()
+
+		body := recorder.body.Bytes()
+
+		var payload map[string]interface{}
+		if err := json.Unmarshal(body, &payload); err == nil {
+			for i := len(steps) - 1; i >= version-1; i-- {
+				if steps[i] != nil {
+					payload = steps[i](payload)
+				}
+			}
+			if downgraded, err := json.Marshal(payload); err == nil {
+				body = downgraded
+			}
+		}
+
+		recorder.Header().Del("Content-Length")
+		recorder.ResponseWriter.Write(body)
+	}
+}
+
+func parseAPIVersion(header string) (int, bool) {
+	header = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(header)), "v")
+	if header == "" {
+		return 0, false
+	}
+
+	version, err := strconv.Atoi(header)
+	if err != nil || version < 1 {
+		return 0, false
+	}
+
+	return version, true
+}
+
+// responseRecorder buffers the response body so middleware can inspect or
+// rewrite it before it reaches the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body *bytes.Buffer
+}
+
+func newResponseRecorder(w gin.ResponseWriter) *responseRecorder {
+	return &responseRecorder{ResponseWriter: w, body: &bytes.Buffer{}}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	return r.body.Write(b)
+}
+
+func (r *responseRecorder) WriteString(s string) (int, error) {
+	return r.body.WriteString(s)
+}
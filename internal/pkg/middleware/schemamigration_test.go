@@ -0,0 +1,115 @@
+package middleware
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRequestMigration(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// v1 sent "name", v2 split it into first_name/last_name
+	migrations := map[string][]MigrationFn{
+		"POST /users": {
+			func(old map[string]interface{}) map[string]interface{} {
+				name, _ := old["name"].(string)
+				first, last := name, ""
+				for i := range name {
+					if name[i] == ' ' {
+						first, last = name[:i], name[i+1:]
+						break
+					}
+				}
+				delete(old, "name")
+				old["first_name"] = first
+				old["last_name"] = last
+				return old
+			},
+		},
+		ResponseRoute("POST /users"): {
+			func(current map[string]interface{}) map[string]interface{} {
+				current["name"] = current["first_name"].(string) + " " + current["last_name"].(string)
+				delete(current, "first_name")
+				delete(current, "last_name")
+				return current
+			},
+		},
+	}
+
+	var received map[string]interface{}
+
+	router := gin.New()
+	router.Use(NewRequestMigration(migrations))
+	router.POST("/users", func(c *gin.Context) {
+		require.NoError(t, c.ShouldBindJSON(&received))
+		c.JSON(http.StatusCreated, received)
+	})
+
+	t.Run("should upgrade v1 request and downgrade response", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"name":"John Doe"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(APIVersionHeader, "v1")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "John", received["first_name"])
+		assert.Equal(t, "Doe", received["last_name"])
+		assert.NotContains(t, received, "name")
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, map[string]interface{}{"name": "John Doe"}, response)
+	})
+
+	t.Run("should pass current version through unchanged", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"first_name":"Jane","last_name":"Roe"}`))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(APIVersionHeader, "2")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		var response map[string]interface{}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+		assert.Equal(t, "Jane", response["first_name"])
+		assert.NotContains(t, response, "name")
+	})
+
+	t.Run("should treat requests without version header as current", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodPost, "/users", bytes.NewBufferString(`{"first_name":"Jane","last_name":"Roe"}`))
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusCreated, w.Code)
+		assert.Equal(t, "Jane", received["first_name"])
+	})
+}
+
+func TestParseAPIVersion(t *testing.T) {
+	t.Run("should parse numeric and prefixed versions", func(t *testing.T) {
+		tests := map[string]int{"1": 1, "v2": 2, "V3": 3}
+		for input, expected := range tests {
+			version, ok := parseAPIVersion(input)
+			assert.True(t, ok)
+			assert.Equal(t, expected, version)
+		}
+	})
+
+	t.Run("should reject invalid versions", func(t *testing.T) {
+		for _, input := range []string{"", "latest", "v0", "-1"} {
+			_, ok := parseAPIVersion(input)
+			assert.False(t, ok, input)
+		}
+	})
+}
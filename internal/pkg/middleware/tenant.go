@@ -0,0 +1,40 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/database/postgres"
+)
+
+// TenantHeader names the tenant a request acts for
+const TenantHeader = "X-Tenant-ID"
+
+// NewTenant puts the tenant of the X-Tenant-ID header in the request
+// context, where the repositories of multi-tenant modules scope their
+// queries to it through row level security. Requests without the header
+// pass through with no tenant, so tenant scoped queries fail with
+// postgres.ErrNoTenant; a header that is not a UUID is rejected.
+//
+// The header is trusted as sent, so it has to be set by a gateway that
+// checks the caller belongs to the tenant.
+func NewTenant() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tenantID := c.GetHeader(TenantHeader)
+		if tenantID == "" {
+			c.Next()
+			return
+		}
+
+		if _, err := uuid.Parse(tenantID); err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, gin.H{"error": "Invalid tenant ID"})
+			return
+		}
+
+		c.Request = c.Request.WithContext(postgres.WithTenant(c.Request.Context(), tenantID))
+		c.Set("tenant_id", tenantID)
+		c.Next()
+	}
+}
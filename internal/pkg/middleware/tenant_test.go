@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/VeRJiL/go-template/internal/database/postgres"
+)
+
+func TestNewTenant(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(seen *string) *gin.Engine {
+		router := gin.New()
+		router.Use(NewTenant())
+		router.GET("/orders", func(c *gin.Context) {
+			*seen, _ = postgres.TenantFromContext(c.Request.Context())
+			c.Status(http.StatusOK)
+		})
+		return router
+	}
+	get := func(router *gin.Engine, tenantID string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/orders", nil)
+		if tenantID != "" {
+			req.Header.Set(TenantHeader, tenantID)
+		}
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		return w
+	}
+
+	t.Run("should put the tenant in the request context", func(t *testing.T) {
+		var seen string
+		tenantID := uuid.NewString()
+
+		w := get(newRouter(&seen), tenantID)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, tenantID, seen)
+	})
+
+	t.Run("should pass requests without a tenant through", func(t *testing.T) {
+		var seen string
+
+		w := get(newRouter(&seen), "")
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Empty(t, seen)
+	})
+
+	t.Run("should reject tenant IDs that are not UUIDs", func(t *testing.T) {
+		var seen string
+
+		w := get(newRouter(&seen), "acme'; --")
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		assert.Empty(t, seen)
+	})
+}
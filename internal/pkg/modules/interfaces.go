@@ -6,6 +6,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 
 	"github.com/VeRJiL/go-template/internal/config"
 	"github.com/VeRJiL/go-template/internal/pkg/auth"
@@ -81,14 +82,28 @@ type Handler interface {
 type Module interface {
 	Name() string
 	Version() string
+	// MinBootstrapVersion and MaxBootstrapVersion bound the semantic
+	// versions of the bootstrap the module runs on, both inclusive. An
+	// empty version leaves that side unbounded.
+	MinBootstrapVersion() string
+	MaxBootstrapVersion() string
 	Dependencies() []string
 	RegisterServices(container *container.Container) error
 	RegisterRoutes(router *gin.RouterGroup, deps *Dependencies) error
 	Migrate(db *sql.DB) error
 	Initialize(ctx context.Context) error
+	// Drain stops accepting new work and waits for in-flight work to
+	// finish, returning early when ctx is done. It runs before Shutdown.
+	Drain(ctx context.Context) error
 	Shutdown(ctx context.Context) error
 }
 
+// GRPCModule is a module serving gRPC services next to its routes. They are
+// registered on the gRPC server started when GRPC_ENABLED is set.
+type GRPCModule interface {
+	RegisterGRPC(server grpc.ServiceRegistrar, deps *Dependencies) error
+}
+
 // ModuleInfo contains module metadata
 type ModuleInfo struct {
 	Name         string   `json:"name"`
@@ -155,36 +170,67 @@ type PaginationResponse struct {
 
 // EntityConfig represents entity configuration
 type EntityConfig struct {
-	Name        string            `json:"name"`
-	TableName   string            `json:"table_name"`
-	SoftDelete  bool              `json:"soft_delete"`
-	Timestamps  bool              `json:"timestamps"`
-	Cache       CacheConfig       `json:"cache"`
-	Validation  ValidationConfig  `json:"validation"`
-	Permissions PermissionConfig  `json:"permissions"`
-	Routes      []Route           `json:"routes"`
+	Name          string               `json:"name" yaml:"name"`
+	TableName     string               `json:"table_name" yaml:"table_name"`
+	SoftDelete    bool                 `json:"soft_delete" yaml:"soft_delete"`
+	Timestamps    bool                 `json:"timestamps" yaml:"timestamps"`
+	MultiTenant   bool                 `json:"multi_tenant" yaml:"multi_tenant"`
+	EventSourcing bool                 `json:"event_sourcing" yaml:"event_sourcing"`
+	GRPC          bool                 `json:"grpc" yaml:"grpc"`
+	Admin         bool                 `json:"admin" yaml:"admin"`
+	Fields        []FieldDefinition    `json:"fields" yaml:"fields"`
+	Relations     []RelationDefinition `json:"relations" yaml:"relations"`
+	Cache         CacheConfig          `json:"cache" yaml:"cache"`
+	Validation    ValidationConfig     `json:"validation" yaml:"validation"`
+	Permissions   PermissionConfig     `json:"permissions" yaml:"permissions"`
+	Routes        []Route              `json:"routes" yaml:"routes"`
+}
+
+// FieldDefinition represents a custom entity field
+type FieldDefinition struct {
+	Name        string   `json:"name" yaml:"name"`
+	Type        string   `json:"type" yaml:"type"`
+	Constraints []string `json:"constraints,omitempty" yaml:"constraints"`
+	Values      []string `json:"values,omitempty" yaml:"values"`
+}
+
+// Relation types supported in entity specs
+const (
+	RelationOneToOne   = "one_to_one"
+	RelationOneToMany  = "one_to_many"
+	RelationManyToOne  = "many_to_one"
+	RelationManyToMany = "many_to_many"
+)
+
+// RelationDefinition represents a relation to another entity
+type RelationDefinition struct {
+	Type       string `json:"type" yaml:"type"`
+	Entity     string `json:"entity" yaml:"entity"`
+	Name       string `json:"name,omitempty" yaml:"name"`
+	ForeignKey string `json:"foreign_key,omitempty" yaml:"foreign_key"`
+	PivotTable string `json:"pivot_table,omitempty" yaml:"pivot_table"`
 }
 
 // CacheConfig represents cache configuration
 type CacheConfig struct {
-	Enabled bool   `json:"enabled"`
-	TTL     string `json:"ttl"`
-	Prefix  string `json:"prefix"`
+	Enabled bool   `json:"enabled" yaml:"enabled"`
+	TTL     string `json:"ttl" yaml:"ttl"`
+	Prefix  string `json:"prefix" yaml:"prefix"`
 }
 
 // ValidationConfig represents validation configuration
 type ValidationConfig struct {
-	Required []string          `json:"required"`
-	Rules    map[string]string `json:"rules"`
+	Required []string          `json:"required" yaml:"required"`
+	Rules    map[string]string `json:"rules" yaml:"rules"`
 }
 
 // PermissionConfig represents permission configuration
 type PermissionConfig struct {
-	Create []string `json:"create"`
-	Read   []string `json:"read"`
-	Update []string `json:"update"`
-	Delete []string `json:"delete"`
-	List   []string `json:"list"`
+	Create []string `json:"create" yaml:"create"`
+	Read   []string `json:"read" yaml:"read"`
+	Update []string `json:"update" yaml:"update"`
+	Delete []string `json:"delete" yaml:"delete"`
+	List   []string `json:"list" yaml:"list"`
 }
 
 // Middleware represents middleware interface
@@ -212,8 +258,11 @@ type Generator interface {
 	GenerateRepository(config EntityConfig) error
 	GenerateService(config EntityConfig) error
 	GenerateHandler(config EntityConfig) error
+	GenerateAdmin(config EntityConfig) error
 	GenerateModule(config EntityConfig) error
 	GenerateTests(config EntityConfig) error
+	GenerateIntegrationTests(config EntityConfig) error
+	GenerateMakefile(config EntityConfig) error
 }
 
 // EventPublisher represents event publishing interface
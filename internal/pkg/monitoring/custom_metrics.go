@@ -0,0 +1,182 @@
+package monitoring
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	// ErrMetricExists is returned when registering a metric under a name
+	// already taken
+	ErrMetricExists = errors.New("metric already registered")
+	// ErrMetricNotRegistered is returned when recording a metric that was
+	// never registered
+	ErrMetricNotRegistered = errors.New("metric not registered")
+)
+
+// RegisterCounter registers a business counter, recorded with Increment.
+// The name is prefixed with the monitor's namespace when exported.
+func (m *PrometheusMonitor) RegisterCounter(name, help string, labelNames []string) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	return m.registerCustom(name, prometheus.NewCounterVec(
+		prometheus.CounterOpts{Namespace: m.config.Namespace, Name: name, Help: help},
+		labelNames,
+	))
+}
+
+// RegisterGauge registers a business gauge, recorded with Set or Increment
+func (m *PrometheusMonitor) RegisterGauge(name, help string, labelNames []string) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	return m.registerCustom(name, prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{Namespace: m.config.Namespace, Name: name, Help: help},
+		labelNames,
+	))
+}
+
+// RegisterHistogram registers a business histogram, recorded with Observe.
+// Nil buckets use the Prometheus default buckets.
+func (m *PrometheusMonitor) RegisterHistogram(name, help string, labelNames []string, buckets []float64) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	if buckets == nil {
+		buckets = prometheus.DefBuckets
+	}
+	return m.registerCustom(name, prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{Namespace: m.config.Namespace, Name: name, Help: help, Buckets: buckets},
+		labelNames,
+	))
+}
+
+// Increment adds one to a registered counter or gauge, labels given in the
+// order of the registered label names
+func (m *PrometheusMonitor) Increment(name string, labels ...string) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	collector, err := m.customMetric(name)
+	if err != nil {
+		return err
+	}
+
+	switch vec := collector.(type) {
+	case *prometheus.CounterVec:
+		counter, err := vec.GetMetricWithLabelValues(labels...)
+		if err != nil {
+			return fmt.Errorf("failed to increment metric %s: %w", name, err)
+		}
+		counter.Inc()
+	case *prometheus.GaugeVec:
+		gauge, err := vec.GetMetricWithLabelValues(labels...)
+		if err != nil {
+			return fmt.Errorf("failed to increment metric %s: %w", name, err)
+		}
+		gauge.Inc()
+	default:
+		return fmt.Errorf("metric %s is not a counter or gauge", name)
+	}
+	return nil
+}
+
+// Set sets a registered gauge to value
+func (m *PrometheusMonitor) Set(name string, value float64, labels ...string) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	collector, err := m.customMetric(name)
+	if err != nil {
+		return err
+	}
+
+	vec, ok := collector.(*prometheus.GaugeVec)
+	if !ok {
+		return fmt.Errorf("metric %s is not a gauge", name)
+	}
+	gauge, err := vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		return fmt.Errorf("failed to set metric %s: %w", name, err)
+	}
+	gauge.Set(value)
+	return nil
+}
+
+// Observe adds value to a registered histogram
+func (m *PrometheusMonitor) Observe(name string, value float64, labels ...string) error {
+	if !m.config.Enabled {
+		return nil
+	}
+
+	collector, err := m.customMetric(name)
+	if err != nil {
+		return err
+	}
+
+	vec, ok := collector.(*prometheus.HistogramVec)
+	if !ok {
+		return fmt.Errorf("metric %s is not a histogram", name)
+	}
+	histogram, err := vec.GetMetricWithLabelValues(labels...)
+	if err != nil {
+		return fmt.Errorf("failed to observe metric %s: %w", name, err)
+	}
+	histogram.Observe(value)
+	return nil
+}
+
+// Register adds collectors defined elsewhere, such as the package level
+// metrics of middleware, to the registry. It does nothing while the monitor
+// is disabled.
+func (m *PrometheusMonitor) Register(collectors ...prometheus.Collector) error {
+	if m.registry == nil {
+		return nil
+	}
+
+	for _, collector := range collectors {
+		if err := m.registry.Register(collector); err != nil {
+			return fmt.Errorf("failed to register collector: %w", err)
+		}
+	}
+	return nil
+}
+
+// registerCustom adds collector to the registry under name
+func (m *PrometheusMonitor) registerCustom(name string, collector prometheus.Collector) error {
+	m.customMu.Lock()
+	defer m.customMu.Unlock()
+
+	if _, exists := m.custom[name]; exists {
+		return fmt.Errorf("%w: %s", ErrMetricExists, name)
+	}
+	if err := m.registry.Register(collector); err != nil {
+		return fmt.Errorf("failed to register metric %s: %w", name, err)
+	}
+
+	if m.custom == nil {
+		m.custom = make(map[string]prometheus.Collector)
+	}
+	m.custom[name] = collector
+	return nil
+}
+
+// customMetric returns the metric registered under name
+func (m *PrometheusMonitor) customMetric(name string) (prometheus.Collector, error) {
+	m.customMu.RLock()
+	defer m.customMu.RUnlock()
+
+	collector, exists := m.custom[name]
+	if !exists {
+		return nil, fmt.Errorf("%w: %s", ErrMetricNotRegistered, name)
+	}
+	return collector, nil
+}
@@ -0,0 +1,68 @@
+package monitoring
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCustomMetrics(t *testing.T) {
+	monitor, err := NewPrometheusMonitor(&Config{Enabled: true, Namespace: "test"})
+	require.NoError(t, err)
+
+	require.NoError(t, monitor.RegisterCounter("orders_total", "Orders placed", []string{"channel"}))
+	require.NoError(t, monitor.RegisterGauge("cart_items", "Items in open carts", nil))
+	require.NoError(t, monitor.RegisterHistogram("order_value_dollars", "Order value", []string{"channel"}, []float64{10, 100}))
+
+	t.Run("should record registered metrics", func(t *testing.T) {
+		require.NoError(t, monitor.Increment("orders_total", "web"))
+		require.NoError(t, monitor.Increment("orders_total", "web"))
+		require.NoError(t, monitor.Set("cart_items", 7))
+		require.NoError(t, monitor.Increment("cart_items"))
+		require.NoError(t, monitor.Observe("order_value_dollars", 42, "web"))
+
+		expected := `
+# HELP test_orders_total Orders placed
+# TYPE test_orders_total counter
+test_orders_total{channel="web"} 2
+# HELP test_cart_items Items in open carts
+# TYPE test_cart_items gauge
+test_cart_items 8
+# HELP test_order_value_dollars Order value
+# TYPE test_order_value_dollars histogram
+test_order_value_dollars_bucket{channel="web",le="10"} 0
+test_order_value_dollars_bucket{channel="web",le="100"} 1
+test_order_value_dollars_bucket{channel="web",le="+Inf"} 1
+test_order_value_dollars_sum{channel="web"} 42
+test_order_value_dollars_count{channel="web"} 1
+`
+		assert.NoError(t, testutil.GatherAndCompare(monitor.GetGatherer(), strings.NewReader(expected),
+			"test_orders_total", "test_cart_items", "test_order_value_dollars"))
+	})
+
+	t.Run("should reject names already registered", func(t *testing.T) {
+		assert.ErrorIs(t, monitor.RegisterGauge("orders_total", "Duplicate", nil), ErrMetricExists)
+		assert.Error(t, monitor.RegisterCounter("http_requests_total", "Clashes with a built-in metric", nil))
+	})
+
+	t.Run("should reject unknown metrics, wrong types and labels", func(t *testing.T) {
+		assert.ErrorIs(t, monitor.Increment("refunds_total"), ErrMetricNotRegistered)
+		assert.ErrorContains(t, monitor.Set("orders_total", 1, "web"), "metric orders_total is not a gauge")
+		assert.ErrorContains(t, monitor.Observe("cart_items", 1), "metric cart_items is not a histogram")
+		assert.ErrorContains(t, monitor.Increment("order_value_dollars", "web"), "metric order_value_dollars is not a counter or gauge")
+		assert.Error(t, monitor.Increment("orders_total"))
+	})
+
+	t.Run("should do nothing when disabled", func(t *testing.T) {
+		disabled, err := NewPrometheusMonitor(&Config{Enabled: false})
+		require.NoError(t, err)
+
+		assert.NoError(t, disabled.RegisterCounter("orders_total", "Orders placed", nil))
+		assert.NoError(t, disabled.Increment("orders_total"))
+		assert.NoError(t, disabled.Set("cart_items", 1))
+		assert.NoError(t, disabled.Observe("order_value_dollars", 1))
+	})
+}
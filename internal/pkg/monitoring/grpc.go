@@ -0,0 +1,80 @@
+package monitoring
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// gRPC call types used as the grpc_type label
+const (
+	grpcUnary        = "unary"
+	grpcClientStream = "client_stream"
+	grpcServerStream = "server_stream"
+	grpcBidiStream   = "bidi_stream"
+)
+
+// UnaryServerInterceptor returns a gRPC interceptor recording the count and
+// duration of unary calls
+func (m *PrometheusMonitor) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	if !m.config.Enabled {
+		return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			return handler(ctx, req)
+		}
+	}
+
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		m.recordGRPCCall(info.FullMethod, grpcUnary, err, time.Since(start))
+		return resp, err
+	}
+}
+
+// StreamServerInterceptor returns a gRPC interceptor recording the count and
+// duration of streaming calls, measured until the handler returns
+func (m *PrometheusMonitor) StreamServerInterceptor() grpc.StreamServerInterceptor {
+	if !m.config.Enabled {
+		return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			return handler(srv, ss)
+		}
+	}
+
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		m.recordGRPCCall(info.FullMethod, streamType(info), err, time.Since(start))
+		return err
+	}
+}
+
+func (m *PrometheusMonitor) recordGRPCCall(fullMethod, callType string, err error, duration time.Duration) {
+	service, method := splitMethodName(fullMethod)
+	code := status.Code(err).String()
+
+	m.metrics.GRPCHandled.WithLabelValues(service, method, callType, code).Inc()
+	m.metrics.GRPCDuration.WithLabelValues(service, method, callType).Observe(duration.Seconds())
+}
+
+// splitMethodName splits "/package.Service/Method" into service and method
+func splitMethodName(fullMethod string) (string, string) {
+	fullMethod = strings.TrimPrefix(fullMethod, "/")
+	if i := strings.LastIndex(fullMethod, "/"); i >= 0 {
+		return fullMethod[:i], fullMethod[i+1:]
+	}
+	return "unknown", "unknown"
+}
+
+func streamType(info *grpc.StreamServerInfo) string {
+	switch {
+	case info.IsClientStream && info.IsServerStream:
+		return grpcBidiStream
+	case info.IsClientStream:
+		return grpcClientStream
+	default:
+		return grpcServerStream
+	}
+}
@@ -0,0 +1,73 @@
+package monitoring
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGRPCInterceptors(t *testing.T) {
+	monitor, err := NewPrometheusMonitor(&Config{Enabled: true, Namespace: "test"})
+	require.NoError(t, err)
+
+	t.Run("should record unary calls by service, method and code", func(t *testing.T) {
+		interceptor := monitor.UnaryServerInterceptor()
+		info := &grpc.UnaryServerInfo{FullMethod: "/users.v1.UserService/GetUser"}
+
+		resp, err := interceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return "response", nil
+		})
+		require.NoError(t, err)
+		assert.Equal(t, "response", resp)
+
+		_, err = interceptor(context.Background(), "request", info, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, status.Error(codes.NotFound, "user not found")
+		})
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		handled := monitor.metrics.GRPCHandled
+		assert.Equal(t, float64(1), testutil.ToFloat64(handled.WithLabelValues("users.v1.UserService", "GetUser", "unary", "OK")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(handled.WithLabelValues("users.v1.UserService", "GetUser", "unary", "NotFound")))
+		assert.Equal(t, 1, testutil.CollectAndCount(monitor.metrics.GRPCDuration, "test_grpc_server_handling_seconds"))
+	})
+
+	t.Run("should record streaming calls by stream type", func(t *testing.T) {
+		interceptor := monitor.StreamServerInterceptor()
+		handler := func(srv interface{}, stream grpc.ServerStream) error { return nil }
+
+		for _, info := range []*grpc.StreamServerInfo{
+			{FullMethod: "/chat.Chat/Send", IsClientStream: true},
+			{FullMethod: "/chat.Chat/Watch", IsServerStream: true},
+			{FullMethod: "/chat.Chat/Talk", IsClientStream: true, IsServerStream: true},
+		} {
+			require.NoError(t, interceptor(nil, nil, info, handler))
+		}
+
+		handled := monitor.metrics.GRPCHandled
+		assert.Equal(t, float64(1), testutil.ToFloat64(handled.WithLabelValues("chat.Chat", "Send", "client_stream", "OK")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(handled.WithLabelValues("chat.Chat", "Watch", "server_stream", "OK")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(handled.WithLabelValues("chat.Chat", "Talk", "bidi_stream", "OK")))
+	})
+
+	t.Run("should pass calls through when disabled", func(t *testing.T) {
+		disabledMonitor, err := NewPrometheusMonitor(&Config{Enabled: false})
+		require.NoError(t, err)
+
+		resp, err := disabledMonitor.UnaryServerInterceptor()(context.Background(), "request", &grpc.UnaryServerInfo{FullMethod: "/svc/M"},
+			func(ctx context.Context, req interface{}) (interface{}, error) {
+				return "response", nil
+			})
+		require.NoError(t, err)
+		assert.Equal(t, "response", resp)
+
+		err = disabledMonitor.StreamServerInterceptor()(nil, nil, &grpc.StreamServerInfo{FullMethod: "/svc/M"},
+			func(srv interface{}, stream grpc.ServerStream) error { return nil })
+		assert.NoError(t, err)
+	})
+}
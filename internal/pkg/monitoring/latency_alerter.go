@@ -0,0 +1,331 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const (
+	latencyMetricName    = "http_request_duration_seconds"
+	latencyAlertLockKey  = "monitoring:latency_alert:lock"
+	defaultAlertInterval = 60 * time.Second
+	defaultAlertCooldown = 10 * time.Minute
+)
+
+// LatencyAlerterConfig holds latency alerting configuration
+type LatencyAlerterConfig struct {
+	Threshold  time.Duration `json:"threshold" mapstructure:"threshold"`
+	Interval   time.Duration `json:"interval" mapstructure:"interval"`
+	Cooldown   time.Duration `json:"cooldown" mapstructure:"cooldown"`
+	WebhookURL string        `json:"webhook_url" mapstructure:"webhook_url"`
+}
+
+// LatencyAlert describes a fired latency alert
+type LatencyAlert struct {
+	P99Ms       float64  `json:"p99_ms"`
+	ThresholdMs float64  `json:"threshold_ms"`
+	Endpoints   []string `json:"endpoints"`
+	FiredAt     string   `json:"fired_at"`
+}
+
+// LatencyAlerter periodically computes the p99 HTTP latency from the
+// Prometheus histogram and alerts when it exceeds the threshold
+type LatencyAlerter struct {
+	config     LatencyAlerterConfig
+	gatherer   prometheus.Gatherer
+	hub        *sentry.Hub
+	redis      *redis.Client
+	httpClient *http.Client
+	logger     *logger.Logger
+
+	mu        sync.Mutex
+	previous  map[string]seriesSample
+	lastFired time.Time
+}
+
+// seriesSample is a snapshot of one histogram series used to compute deltas
+type seriesSample struct {
+	count   uint64
+	buckets []*dto.Bucket
+}
+
+// NewLatencyAlerter creates a latency alerter. The Redis client is used to
+// suppress duplicate alerts across instances and may be nil.
+func NewLatencyAlerter(config LatencyAlerterConfig, gatherer prometheus.Gatherer, redisClient *redis.Client, log *logger.Logger) *LatencyAlerter {
+	if config.Interval <= 0 {
+		config.Interval = defaultAlertInterval
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = defaultAlertCooldown
+	}
+
+	return &LatencyAlerter{
+		config:     config,
+		gatherer:   gatherer,
+		hub:        sentry.CurrentHub(),
+		redis:      redisClient,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+		logger:     log,
+		previous:   make(map[string]seriesSample),
+	}
+}
+
+// SetHub overrides the Sentry hub alerts are captured on
+func (a *LatencyAlerter) SetHub(hub *sentry.Hub) {
+	a.hub = hub
+}
+
+// Start samples latency every interval until the context is cancelled
+func (a *LatencyAlerter) Start(ctx context.Context) {
+	ticker := time.NewTicker(a.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.Check(ctx); err != nil {
+				a.logger.Error("Latency check failed", "error", err)
+			}
+		}
+	}
+}
+
+// Check samples the histogram once and fires an alert if the p99 latency
+// since the previous sample exceeds the threshold. It returns the alert
+// that was fired, or nil.
+func (a *LatencyAlerter) Check(ctx context.Context) (*LatencyAlert, error) {
+	families, err := a.gatherer.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	a.mu.Lock()
+	overall, perEndpoint := a.collectWindow(families)
+	a.mu.Unlock()
+
+	p99 := histogramQuantile(0.99, overall)
+	if math.IsNaN(p99) || p99 <= a.config.Threshold.Seconds() {
+		return nil, nil
+	}
+
+	var endpoints []string
+	for endpoint, buckets := range perEndpoint {
+		if q := histogramQuantile(0.99, buckets); !math.IsNaN(q) && q > a.config.Threshold.Seconds() {
+			endpoints = append(endpoints, endpoint)
+		}
+	}
+	sort.Strings(endpoints)
+
+	fire, err := a.acquireCooldown(ctx)
+	if err != nil || !fire {
+		return nil, err
+	}
+
+	alert := &LatencyAlert{
+		P99Ms:       p99 * 1000,
+		ThresholdMs: float64(a.config.Threshold.Milliseconds()),
+		Endpoints:   endpoints,
+		FiredAt:     time.Now().UTC().Format(time.RFC3339),
+	}
+
+	a.notify(ctx, alert)
+	return alert, nil
+}
+
+// collectWindow aggregates bucket increments since the previous sample,
+// overall and per endpoint
+func (a *LatencyAlerter) collectWindow(families []*dto.MetricFamily) (map[float64]uint64, map[string]map[float64]uint64) {
+	overall := make(map[float64]uint64)
+	perEndpoint := make(map[string]map[float64]uint64)
+
+	for _, family := range families {
+		if !strings.HasSuffix(family.GetName(), latencyMetricName) || family.GetType() != dto.MetricType_HISTOGRAM {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			key, endpoint := seriesKey(metric)
+			histogram := metric.GetHistogram()
+			prev, seen := a.previous[key]
+
+			// Counters reset when the process restarts; start over
+			if seen && histogram.GetSampleCount() < prev.count {
+				seen = false
+			}
+
+			for i, bucket := range histogram.GetBucket() {
+				count := bucket.GetCumulativeCount()
+				if seen && i < len(prev.buckets) {
+					count -= prev.buckets[i].GetCumulativeCount()
+				}
+
+				bound := bucket.GetUpperBound()
+				overall[bound] += count
+				if perEndpoint[endpoint] == nil {
+					perEndpoint[endpoint] = make(map[float64]uint64)
+				}
+				perEndpoint[endpoint][bound] += count
+			}
+
+			// The +Inf bucket is implicit in the sample count
+			total := histogram.GetSampleCount()
+			if seen {
+				total -= prev.count
+			}
+			overall[math.Inf(1)] += total
+			if perEndpoint[endpoint] == nil {
+				perEndpoint[endpoint] = make(map[float64]uint64)
+			}
+			perEndpoint[endpoint][math.Inf(1)] += total
+
+			a.previous[key] = seriesSample{count: histogram.GetSampleCount(), buckets: histogram.GetBucket()}
+		}
+	}
+
+	return overall, perEndpoint
+}
+
+func (a *LatencyAlerter) acquireCooldown(ctx context.Context) (bool, error) {
+	if a.redis != nil {
+		ok, err := a.redis.SetNX(ctx, latencyAlertLockKey, time.Now().Unix(), a.config.Cooldown).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to acquire alert lock: %w", err)
+		}
+		return ok, nil
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if !a.lastFired.IsZero() && time.Since(a.lastFired) < a.config.Cooldown {
+		return false, nil
+	}
+	a.lastFired = time.Now()
+	return true, nil
+}
+
+func (a *LatencyAlerter) notify(ctx context.Context, alert *LatencyAlert) {
+	message := fmt.Sprintf("p99 latency %.0fms exceeds threshold %.0fms", alert.P99Ms, alert.ThresholdMs)
+
+	a.logger.Warn("Latency alert fired", "p99_ms", alert.P99Ms, "threshold_ms", alert.ThresholdMs, "endpoints", alert.Endpoints)
+
+	if a.hub != nil {
+		a.hub.WithScope(func(scope *sentry.Scope) {
+			scope.SetLevel(sentry.LevelWarning)
+			scope.SetContext("latency", sentry.Context{
+				"p99_ms":       alert.P99Ms,
+				"threshold_ms": alert.ThresholdMs,
+				"endpoints":    alert.Endpoints,
+			})
+			a.hub.CaptureMessage(message)
+		})
+	}
+
+	if a.config.WebhookURL != "" {
+		if err := a.postWebhook(ctx, message, alert); err != nil {
+			a.logger.Error("Failed to send latency alert webhook", "error", err)
+		}
+	}
+}
+
+func (a *LatencyAlerter) postWebhook(ctx context.Context, message string, alert *LatencyAlert) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"message": message,
+		"alert":   alert,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.config.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := a.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func seriesKey(metric *dto.Metric) (string, string) {
+	var parts []string
+	var method, endpoint string
+
+	for _, label := range metric.GetLabel() {
+		parts = append(parts, label.GetName()+"="+label.GetValue())
+		switch label.GetName() {
+		case "method":
+			method = label.GetValue()
+		case "endpoint":
+			endpoint = label.GetValue()
+		}
+	}
+
+	return strings.Join(parts, ","), strings.TrimSpace(method + " " + endpoint)
+}
+
+// histogramQuantile estimates a quantile from cumulative bucket counts using
+// linear interpolation, the same way PromQL's histogram_quantile does
+func histogramQuantile(q float64, buckets map[float64]uint64) float64 {
+	bounds := make([]float64, 0, len(buckets))
+	for bound := range buckets {
+		bounds = append(bounds, bound)
+	}
+	sort.Float64s(bounds)
+
+	if len(bounds) == 0 {
+		return math.NaN()
+	}
+
+	total := buckets[bounds[len(bounds)-1]]
+	if total == 0 {
+		return math.NaN()
+	}
+
+	rank := q * float64(total)
+	var prevBound float64
+	var prevCount uint64
+
+	for _, bound := range bounds {
+		count := buckets[bound]
+		if float64(count) >= rank {
+			if math.IsInf(bound, 1) {
+				// Above the highest finite bucket; report its bound
+				return prevBound
+			}
+			if count == prevCount {
+				return bound
+			}
+			return prevBound + (bound-prevBound)*(rank-float64(prevCount))/float64(count-prevCount)
+		}
+		prevBound = bound
+		prevCount = count
+	}
+
+	return prevBound
+}
@@ -0,0 +1,167 @@
+package monitoring
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// mockTransport records events instead of sending them to Sentry
+type mockTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *mockTransport) Flush(timeout time.Duration) bool       { return true }
+func (t *mockTransport) Configure(options sentry.ClientOptions) {}
+func (t *mockTransport) Close()                                 {}
+
+func (t *mockTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func (t *mockTransport) Events() []*sentry.Event {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.events
+}
+
+func newTestAlerter(t *testing.T, config LatencyAlerterConfig) (*LatencyAlerter, *prometheus.HistogramVec, *mockTransport) {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	histogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "test",
+			Name:      "http_request_duration_seconds",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"method", "endpoint", "status_code"},
+	)
+	registry.MustRegister(histogram)
+
+	transport := &mockTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	require.NoError(t, err)
+
+	alerter := NewLatencyAlerter(config, registry, nil, logger.New("error", "text"))
+	alerter.SetHub(sentry.NewHub(client, sentry.NewScope()))
+
+	return alerter, histogram, transport
+}
+
+func TestLatencyAlerter(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should not alert when p99 is under threshold", func(t *testing.T) {
+		alerter, histogram, transport := newTestAlerter(t, LatencyAlerterConfig{Threshold: 500 * time.Millisecond})
+
+		for i := 0; i < 100; i++ {
+			histogram.WithLabelValues("GET", "/users", "200").Observe(0.02)
+		}
+
+		alert, err := alerter.Check(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, alert)
+		assert.Empty(t, transport.Events())
+	})
+
+	t.Run("should alert with affected endpoints when p99 exceeds threshold", func(t *testing.T) {
+		alerter, histogram, transport := newTestAlerter(t, LatencyAlerterConfig{Threshold: 500 * time.Millisecond})
+
+		for i := 0; i < 90; i++ {
+			histogram.WithLabelValues("GET", "/users", "200").Observe(0.02)
+		}
+		for i := 0; i < 10; i++ {
+			histogram.WithLabelValues("POST", "/reports", "200").Observe(3)
+		}
+
+		alert, err := alerter.Check(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, alert)
+		assert.Greater(t, alert.P99Ms, 500.0)
+		assert.Equal(t, 500.0, alert.ThresholdMs)
+		assert.Equal(t, []string{"POST /reports"}, alert.Endpoints)
+
+		events := transport.Events()
+		require.Len(t, events, 1)
+		assert.Contains(t, events[0].Message, "exceeds threshold")
+		assert.Equal(t, 500.0, events[0].Contexts["latency"]["threshold_ms"])
+	})
+
+	t.Run("should suppress alerts during cooldown", func(t *testing.T) {
+		alerter, histogram, transport := newTestAlerter(t, LatencyAlerterConfig{Threshold: 100 * time.Millisecond})
+
+		histogram.WithLabelValues("GET", "/slow", "200").Observe(2)
+		alert, err := alerter.Check(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, alert)
+
+		histogram.WithLabelValues("GET", "/slow", "200").Observe(2)
+		alert, err = alerter.Check(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, alert)
+		assert.Len(t, transport.Events(), 1)
+	})
+
+	t.Run("should only consider requests since the previous sample", func(t *testing.T) {
+		alerter, histogram, _ := newTestAlerter(t, LatencyAlerterConfig{Threshold: 500 * time.Millisecond, Cooldown: time.Nanosecond})
+
+		histogram.WithLabelValues("GET", "/users", "200").Observe(5)
+		alert, err := alerter.Check(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, alert)
+
+		for i := 0; i < 100; i++ {
+			histogram.WithLabelValues("GET", "/users", "200").Observe(0.01)
+		}
+		alert, err = alerter.Check(ctx)
+		require.NoError(t, err)
+		assert.Nil(t, alert)
+	})
+
+	t.Run("should post alert to webhook", func(t *testing.T) {
+		var payload map[string]interface{}
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			json.NewDecoder(r.Body).Decode(&payload)
+			w.WriteHeader(http.StatusOK)
+		}))
+		defer server.Close()
+
+		alerter, histogram, _ := newTestAlerter(t, LatencyAlerterConfig{Threshold: 100 * time.Millisecond, WebhookURL: server.URL})
+
+		histogram.WithLabelValues("GET", "/slow", "200").Observe(2)
+		alert, err := alerter.Check(ctx)
+		require.NoError(t, err)
+		require.NotNil(t, alert)
+
+		assert.Contains(t, payload["message"], "exceeds threshold")
+		assert.NotNil(t, payload["alert"])
+	})
+}
+
+func TestHistogramQuantile(t *testing.T) {
+	t.Run("should interpolate within bucket", func(t *testing.T) {
+		buckets := map[float64]uint64{0.1: 50, 0.5: 100}
+		assert.InDelta(t, 0.1, histogramQuantile(0.5, buckets), 0.0001)
+		assert.InDelta(t, 0.492, histogramQuantile(0.99, buckets), 0.0001)
+	})
+
+	t.Run("should return NaN for empty histogram", func(t *testing.T) {
+		assert.True(t, math.IsNaN(histogramQuantile(0.99, map[float64]uint64{})))
+	})
+}
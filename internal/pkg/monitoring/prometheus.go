@@ -2,13 +2,18 @@ package monitoring
 
 import (
 	"context"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
 )
 
 // Config holds Prometheus monitoring configuration
@@ -17,6 +22,52 @@ type Config struct {
 	Namespace   string `json:"namespace" mapstructure:"namespace"`
 	MetricsPath string `json:"metrics_path" mapstructure:"metrics_path"`
 	ListenAddr  string `json:"listen_addr" mapstructure:"listen_addr"`
+
+	// HistogramBuckets overrides histogram bucket boundaries, keyed by metric
+	// name without the namespace (e.g. "http_request_duration_seconds").
+	// Histograms without an entry keep their default buckets.
+	HistogramBuckets map[string][]float64 `json:"histogram_buckets" mapstructure:"histogram_buckets"`
+
+	// SlowQueryThreshold is the duration past which a database operation
+	// counts as slow. Zero disables slow query tracking.
+	SlowQueryThreshold time.Duration `json:"slow_query_threshold" mapstructure:"slow_query_threshold"`
+}
+
+// histogramNames lists the histograms whose buckets can be configured
+var histogramNames = []string{
+	"http_request_duration_seconds",
+	"http_request_size_bytes",
+	"http_response_size_bytes",
+	"database_query_duration_seconds",
+	"message_broker_operation_duration_seconds",
+	"cache_operation_duration_seconds",
+	"grpc_server_handling_seconds",
+}
+
+// buckets returns the configured buckets of the named histogram, or defaults
+func (c *Config) buckets(name string, defaults []float64) []float64 {
+	if buckets, ok := c.HistogramBuckets[name]; ok {
+		return buckets
+	}
+	return defaults
+}
+
+// validateBuckets rejects bucket overrides prometheus would panic on
+func (c *Config) validateBuckets() error {
+	for name, buckets := range c.HistogramBuckets {
+		if !slices.Contains(histogramNames, name) {
+			return fmt.Errorf("unknown histogram %q in histogram buckets", name)
+		}
+		if len(buckets) == 0 {
+			return fmt.Errorf("histogram %s needs at least one bucket", name)
+		}
+		for i := 1; i < len(buckets); i++ {
+			if buckets[i] <= buckets[i-1] {
+				return fmt.Errorf("histogram %s buckets must be in increasing order", name)
+			}
+		}
+	}
+	return nil
 }
 
 // Metrics holds all Prometheus metrics
@@ -31,6 +82,7 @@ type Metrics struct {
 	DBConnections   *prometheus.GaugeVec
 	DBQueries       *prometheus.CounterVec
 	DBQueryDuration *prometheus.HistogramVec
+	SlowQueries     *prometheus.CounterVec
 
 	// Message broker metrics
 	MBMessages    *prometheus.CounterVec
@@ -42,6 +94,10 @@ type Metrics struct {
 	CacheDuration   *prometheus.HistogramVec
 	CacheHitRate    *prometheus.GaugeVec
 
+	// gRPC server metrics
+	GRPCHandled  *prometheus.CounterVec
+	GRPCDuration *prometheus.HistogramVec
+
 	// Application metrics
 	AppInfo         *prometheus.GaugeVec
 	UserSessions    *prometheus.GaugeVec
@@ -62,6 +118,12 @@ type PrometheusMonitor struct {
 	config   *Config
 	metrics  *Metrics
 	registry *prometheus.Registry
+	started  time.Time
+	logger   *logger.Logger
+
+	// Business metrics registered at runtime, keyed by name
+	custom   map[string]prometheus.Collector
+	customMu sync.RWMutex
 }
 
 // NewPrometheusMonitor creates a new Prometheus monitor
@@ -70,6 +132,10 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 		return &PrometheusMonitor{config: config}, nil
 	}
 
+	if err := config.validateBuckets(); err != nil {
+		return nil, err
+	}
+
 	registry := prometheus.NewRegistry()
 
 	metrics := &Metrics{
@@ -87,7 +153,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "http_request_duration_seconds",
 				Help:      "HTTP request duration in seconds",
-				Buckets:   prometheus.DefBuckets,
+				Buckets:   config.buckets("http_request_duration_seconds", prometheus.DefBuckets),
 			},
 			[]string{"method", "endpoint", "status_code"},
 		),
@@ -96,7 +162,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "http_request_size_bytes",
 				Help:      "HTTP request size in bytes",
-				Buckets:   prometheus.ExponentialBuckets(100, 10, 8),
+				Buckets:   config.buckets("http_request_size_bytes", prometheus.ExponentialBuckets(100, 10, 8)),
 			},
 			[]string{"method", "endpoint"},
 		),
@@ -105,7 +171,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "http_response_size_bytes",
 				Help:      "HTTP response size in bytes",
-				Buckets:   prometheus.ExponentialBuckets(100, 10, 8),
+				Buckets:   config.buckets("http_response_size_bytes", prometheus.ExponentialBuckets(100, 10, 8)),
 			},
 			[]string{"method", "endpoint", "status_code"},
 		),
@@ -132,7 +198,15 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "database_query_duration_seconds",
 				Help:      "Database query duration in seconds",
-				Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+				Buckets:   config.buckets("database_query_duration_seconds", []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10}),
+			},
+			[]string{"database", "operation"},
+		),
+		SlowQueries: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "slow_queries_total",
+				Help:      "Total number of database queries slower than the slow query threshold",
 			},
 			[]string{"database", "operation"},
 		),
@@ -151,7 +225,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "message_broker_operation_duration_seconds",
 				Help:      "Message broker operation duration in seconds",
-				Buckets:   []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5},
+				Buckets:   config.buckets("message_broker_operation_duration_seconds", []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5}),
 			},
 			[]string{"driver", "operation", "topic"},
 		),
@@ -178,7 +252,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 				Namespace: config.Namespace,
 				Name:      "cache_operation_duration_seconds",
 				Help:      "Cache operation duration in seconds",
-				Buckets:   []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1},
+				Buckets:   config.buckets("cache_operation_duration_seconds", []float64{0.0001, 0.0005, 0.001, 0.005, 0.01, 0.05, 0.1}),
 			},
 			[]string{"operation"},
 		),
@@ -191,6 +265,25 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 			[]string{"cache_type"},
 		),
 
+		// gRPC server metrics
+		GRPCHandled: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Namespace: config.Namespace,
+				Name:      "grpc_server_handled_total",
+				Help:      "Total number of RPCs completed on the server",
+			},
+			[]string{"grpc_service", "grpc_method", "grpc_type", "grpc_code"},
+		),
+		GRPCDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Namespace: config.Namespace,
+				Name:      "grpc_server_handling_seconds",
+				Help:      "RPC handling duration in seconds",
+				Buckets:   config.buckets("grpc_server_handling_seconds", prometheus.DefBuckets),
+			},
+			[]string{"grpc_service", "grpc_method", "grpc_type"},
+		),
+
 		// Application metrics
 		AppInfo: prometheus.NewGaugeVec(
 			prometheus.GaugeOpts{
@@ -261,12 +354,15 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 		metrics.DBConnections,
 		metrics.DBQueries,
 		metrics.DBQueryDuration,
+		metrics.SlowQueries,
 		metrics.MBMessages,
 		metrics.MBDuration,
 		metrics.MBConnections,
 		metrics.CacheOperations,
 		metrics.CacheDuration,
 		metrics.CacheHitRate,
+		metrics.GRPCHandled,
+		metrics.GRPCDuration,
 		metrics.AppInfo,
 		metrics.UserSessions,
 		metrics.ActiveUsers,
@@ -281,6 +377,7 @@ func NewPrometheusMonitor(config *Config) (*PrometheusMonitor, error) {
 		config:   config,
 		metrics:  metrics,
 		registry: registry,
+		started:  time.Now(),
 	}
 
 	return monitor, nil
@@ -291,6 +388,14 @@ func (m *PrometheusMonitor) GetMetrics() *Metrics {
 	return m.metrics
 }
 
+// GetGatherer returns the registry metrics are gathered from
+func (m *PrometheusMonitor) GetGatherer() prometheus.Gatherer {
+	if m.registry == nil {
+		return prometheus.NewRegistry()
+	}
+	return m.registry
+}
+
 // GetHandler returns the Prometheus metrics HTTP handler
 func (m *PrometheusMonitor) GetHandler() http.Handler {
 	if !m.config.Enabled {
@@ -310,6 +415,13 @@ func (m *PrometheusMonitor) GinMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
+		// Collect the request's slow queries recorded with RecordDBQuery
+		var slowQueries *slowQueryTracker
+		if m.config.SlowQueryThreshold > 0 {
+			slowQueries = &slowQueryTracker{}
+			c.Request = c.Request.WithContext(context.WithValue(c.Request.Context(), slowQueryContextKey{}, slowQueries))
+		}
+
 		// Get request size
 		requestSize := float64(0)
 		if c.Request.ContentLength > 0 {
@@ -340,6 +452,10 @@ func (m *PrometheusMonitor) GinMiddleware() gin.HandlerFunc {
 		if responseSize > 0 {
 			m.metrics.HTTPResponseSize.WithLabelValues(method, endpoint, statusCode).Observe(responseSize)
 		}
+
+		if slowQueries != nil {
+			m.logSlowQueries(method, endpoint, slowQueries)
+		}
 	}
 }
 
@@ -351,6 +467,10 @@ func (m *PrometheusMonitor) RecordDBOperation(database, operation, status string
 
 	m.metrics.DBQueries.WithLabelValues(database, operation, status).Inc()
 	m.metrics.DBQueryDuration.WithLabelValues(database, operation).Observe(duration.Seconds())
+
+	if m.isSlowQuery(duration) {
+		m.metrics.SlowQueries.WithLabelValues(database, operation).Inc()
+	}
 }
 
 // RecordDBConnections records database connection metrics
@@ -89,6 +89,8 @@ func TestNewPrometheusMonitor(t *testing.T) {
 		assert.NotNil(t, monitor.metrics.CacheOperations)
 		assert.NotNil(t, monitor.metrics.CacheDuration)
 		assert.NotNil(t, monitor.metrics.CacheHitRate)
+		assert.NotNil(t, monitor.metrics.GRPCHandled)
+		assert.NotNil(t, monitor.metrics.GRPCDuration)
 		assert.NotNil(t, monitor.metrics.AppInfo)
 		assert.NotNil(t, monitor.metrics.UserSessions)
 		assert.NotNil(t, monitor.metrics.ActiveUsers)
@@ -125,6 +127,72 @@ func TestNewPrometheusMonitor(t *testing.T) {
 		}
 		assert.True(t, found, "Should find metrics with namespace prefix")
 	})
+
+	// bucketBounds observes one value and returns the upper bounds of the
+	// gathered histogram
+	bucketBounds := func(t *testing.T, monitor *PrometheusMonitor, name string, histogram *prometheus.HistogramVec, labels ...string) []float64 {
+		histogram.WithLabelValues(labels...).Observe(0.01)
+
+		families, err := monitor.registry.Gather()
+		require.NoError(t, err)
+
+		for _, family := range families {
+			if family.GetName() == name {
+				var bounds []float64
+				for _, bucket := range family.GetMetric()[0].GetHistogram().GetBucket() {
+					bounds = append(bounds, bucket.GetUpperBound())
+				}
+				return bounds
+			}
+		}
+		t.Fatalf("histogram %s not gathered", name)
+		return nil
+	}
+
+	t.Run("should use configured histogram buckets", func(t *testing.T) {
+		config := &Config{
+			Enabled:   true,
+			Namespace: "test",
+			HistogramBuckets: map[string][]float64{
+				"http_request_duration_seconds": {0.05, 0.25, 1},
+			},
+		}
+
+		monitor, err := NewPrometheusMonitor(config)
+
+		require.NoError(t, err)
+		assert.Equal(t, []float64{0.05, 0.25, 1},
+			bucketBounds(t, monitor, "test_http_request_duration_seconds", monitor.metrics.HTTPDuration, "GET", "/", "200"))
+	})
+
+	t.Run("should keep default buckets for histograms without an entry", func(t *testing.T) {
+		config := &Config{
+			Enabled:   true,
+			Namespace: "test",
+			HistogramBuckets: map[string][]float64{
+				"cache_operation_duration_seconds": {0.001, 0.01},
+			},
+		}
+
+		monitor, err := NewPrometheusMonitor(config)
+
+		require.NoError(t, err)
+		assert.Equal(t, prometheus.DefBuckets,
+			bucketBounds(t, monitor, "test_http_request_duration_seconds", monitor.metrics.HTTPDuration, "GET", "/", "200"))
+		assert.Equal(t, []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1, 5, 10},
+			bucketBounds(t, monitor, "test_database_query_duration_seconds", monitor.metrics.DBQueryDuration, "select", "users"))
+	})
+
+	t.Run("should reject invalid histogram buckets", func(t *testing.T) {
+		for name, buckets := range map[string]map[string][]float64{
+			"unknown histogram": {"request_latency": {1}},
+			"empty buckets":     {"http_request_size_bytes": {}},
+			"unordered buckets": {"http_request_duration_seconds": {1, 0.5}},
+		} {
+			_, err := NewPrometheusMonitor(&Config{Enabled: true, Namespace: "test", HistogramBuckets: buckets})
+			assert.Error(t, err, name)
+		}
+	})
 }
 
 func TestPrometheusMonitorGetters(t *testing.T) {
@@ -0,0 +1,327 @@
+package monitoring
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+const defaultSLOInterval = time.Minute
+
+// defaultSLOWindows are the windows error budgets are reported over when
+// none are configured
+var defaultSLOWindows = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour}
+
+// SLOConfig holds the service level objectives of HTTP requests
+type SLOConfig struct {
+	// AvailabilityTarget is the fraction of requests that must not fail
+	// with a 5xx status, e.g. 0.999
+	AvailabilityTarget float64 `json:"availability_target" mapstructure:"availability_target"`
+	// LatencyTarget is the duration requests must complete within. Choose a
+	// bucket bound of the duration histogram for exact results.
+	LatencyTarget time.Duration `json:"latency_target" mapstructure:"latency_target"`
+	// LatencyPercentile is the fraction of requests that must complete
+	// within LatencyTarget, e.g. 0.99
+	LatencyPercentile float64         `json:"latency_percentile" mapstructure:"latency_percentile"`
+	Windows           []time.Duration `json:"windows" mapstructure:"windows"`
+	Interval          time.Duration   `json:"interval" mapstructure:"interval"`
+}
+
+// SLOObjective reports one objective over one window. The budget is the
+// fraction of requests allowed to miss the objective; a burn rate above 1
+// spends it faster than the objective allows and leaves a negative budget.
+type SLOObjective struct {
+	SLI                    float64 `json:"sli"`
+	Target                 float64 `json:"target"`
+	BudgetRemainingPercent float64 `json:"budget_remaining_percent"`
+	BurnRate               float64 `json:"burn_rate"`
+}
+
+// SLOWindow reports the objectives over one window. ObservedSeconds is
+// shorter than the window until the tracker has recorded that much history.
+type SLOWindow struct {
+	Window          string       `json:"window"`
+	ObservedSeconds float64      `json:"observed_seconds"`
+	Requests        uint64       `json:"requests"`
+	Availability    SLOObjective `json:"availability"`
+	Latency         SLOObjective `json:"latency"`
+}
+
+// SLOReport reports the error budgets of every configured window
+type SLOReport struct {
+	LatencyTargetMs float64     `json:"latency_target_ms"`
+	Windows         []SLOWindow `json:"windows"`
+}
+
+// SLOTracker records the totals of the HTTP duration histogram every
+// interval, like a Prometheus recording rule, and computes error budgets by
+// comparing the current totals with those recorded a window ago
+type SLOTracker struct {
+	config   SLOConfig
+	gatherer prometheus.Gatherer
+	logger   *logger.Logger
+	now      func() time.Time
+
+	mu      sync.Mutex
+	samples []sloSample
+	burning map[string]bool
+}
+
+// sloSample holds cumulative request counts at one point in time
+type sloSample struct {
+	at       time.Time
+	requests uint64
+	errors   uint64
+	fast     float64
+}
+
+// NewSLOTracker creates a tracker reading the HTTP duration histogram from
+// the gatherer
+func NewSLOTracker(config SLOConfig, gatherer prometheus.Gatherer, log *logger.Logger) *SLOTracker {
+	if config.Interval <= 0 {
+		config.Interval = defaultSLOInterval
+	}
+	if len(config.Windows) == 0 {
+		config.Windows = defaultSLOWindows
+	}
+
+	return &SLOTracker{
+		config:   config,
+		gatherer: gatherer,
+		logger:   log,
+		now:      time.Now,
+		burning:  make(map[string]bool),
+	}
+}
+
+// Start records a sample every interval until the context is cancelled
+func (t *SLOTracker) Start(ctx context.Context) {
+	if err := t.Evaluate(); err != nil {
+		t.logger.Error("SLO evaluation failed", "error", err)
+	}
+
+	ticker := time.NewTicker(t.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := t.Evaluate(); err != nil {
+				t.logger.Error("SLO evaluation failed", "error", err)
+			}
+		}
+	}
+}
+
+// Evaluate records a sample and logs a warning for every objective whose
+// burn rate rose above 1, and once more when it recovers
+func (t *SLOTracker) Evaluate() error {
+	current, err := t.sample()
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.record(current)
+	report := t.report(current)
+	for _, window := range report.Windows {
+		t.alert("availability", window, window.Availability)
+		t.alert("latency", window, window.Latency)
+	}
+
+	return nil
+}
+
+// Report computes the error budgets from the current totals without
+// recording them
+func (t *SLOTracker) Report() (*SLOReport, error) {
+	current, err := t.sample()
+	if err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return t.report(current), nil
+}
+
+// Handler serves Report as JSON
+func (t *SLOTracker) Handler(c *gin.Context) {
+	report, err := t.Report()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// sample totals the requests, server errors and requests within the latency
+// target across every series of the histogram
+func (t *SLOTracker) sample() (sloSample, error) {
+	families, err := t.gatherer.Gather()
+	if err != nil {
+		return sloSample{}, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	current := sloSample{at: t.now()}
+	for _, family := range families {
+		if !strings.HasSuffix(family.GetName(), latencyMetricName) || family.GetType() != dto.MetricType_HISTOGRAM {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			histogram := metric.GetHistogram()
+			current.requests += histogram.GetSampleCount()
+			current.fast += countWithin(t.config.LatencyTarget.Seconds(), histogram)
+			if isServerError(metric) {
+				current.errors += histogram.GetSampleCount()
+			}
+		}
+	}
+
+	return current, nil
+}
+
+// record appends a sample and drops those no window reaches back to
+func (t *SLOTracker) record(current sloSample) {
+	t.samples = append(t.samples, current)
+
+	longest := t.config.Windows[0]
+	for _, window := range t.config.Windows {
+		longest = max(longest, window)
+	}
+
+	// Keep the newest sample at or before the cutoff as the longest window's baseline
+	cutoff := current.at.Add(-longest)
+	keep := sort.Search(len(t.samples), func(i int) bool {
+		return t.samples[i].at.After(cutoff)
+	})
+	if keep > 1 {
+		t.samples = append(t.samples[:0], t.samples[keep-1:]...)
+	}
+}
+
+func (t *SLOTracker) report(current sloSample) *SLOReport {
+	report := &SLOReport{
+		LatencyTargetMs: float64(t.config.LatencyTarget.Milliseconds()),
+		Windows:         make([]SLOWindow, 0, len(t.config.Windows)),
+	}
+
+	for _, window := range t.config.Windows {
+		base := t.baseline(current, window)
+		requests := current.requests - base.requests
+
+		report.Windows = append(report.Windows, SLOWindow{
+			Window:          formatWindow(window),
+			ObservedSeconds: current.at.Sub(base.at).Seconds(),
+			Requests:        requests,
+			Availability:    objective(t.config.AvailabilityTarget, requests, float64(requests-(current.errors-base.errors))),
+			Latency:         objective(t.config.LatencyPercentile, requests, current.fast-base.fast),
+		})
+	}
+
+	return report
+}
+
+// baseline returns the newest recorded sample at least a window older than
+// current, or the oldest one while there is less history than the window
+func (t *SLOTracker) baseline(current sloSample, window time.Duration) sloSample {
+	if len(t.samples) == 0 {
+		return current
+	}
+
+	cutoff := current.at.Add(-window)
+	i := sort.Search(len(t.samples), func(i int) bool {
+		return t.samples[i].at.After(cutoff)
+	})
+	return t.samples[max(i-1, 0)]
+}
+
+func (t *SLOTracker) alert(name string, window SLOWindow, status SLOObjective) {
+	key := name + "/" + window.Window
+	burning := status.BurnRate > 1
+
+	switch {
+	case burning && !t.burning[key]:
+		t.logger.Warn("SLO error budget burning too fast",
+			"objective", name,
+			"window", window.Window,
+			"burn_rate", status.BurnRate,
+			"budget_remaining_percent", status.BudgetRemainingPercent,
+		)
+	case !burning && t.burning[key]:
+		t.logger.Info("SLO burn rate recovered", "objective", name, "window", window.Window, "burn_rate", status.BurnRate)
+	}
+	t.burning[key] = burning
+}
+
+// objective computes the SLI and error budget of good out of requests
+func objective(target float64, requests uint64, good float64) SLOObjective {
+	status := SLOObjective{SLI: 1, Target: target, BudgetRemainingPercent: 100}
+	if requests == 0 {
+		return status
+	}
+
+	status.SLI = good / float64(requests)
+	if budget := 1 - target; budget > 0 {
+		status.BurnRate = (1 - status.SLI) / budget
+		status.BudgetRemainingPercent = (1 - status.BurnRate) * 100
+	}
+	return status
+}
+
+// countWithin estimates how many observations of the histogram took at most
+// seconds, interpolating linearly within the bucket it falls in. Requests
+// slower than the highest bucket bound are never counted.
+func countWithin(seconds float64, histogram *dto.Histogram) float64 {
+	var prevBound, prevCount float64
+	for _, bucket := range histogram.GetBucket() {
+		bound, count := bucket.GetUpperBound(), float64(bucket.GetCumulativeCount())
+		if seconds <= bound {
+			return prevCount + (count-prevCount)*(seconds-prevBound)/(bound-prevBound)
+		}
+		prevBound, prevCount = bound, count
+	}
+	return prevCount
+}
+
+func isServerError(metric *dto.Metric) bool {
+	for _, label := range metric.GetLabel() {
+		if label.GetName() == "status_code" {
+			code, err := strconv.Atoi(label.GetValue())
+			return err == nil && code >= 500
+		}
+	}
+	return false
+}
+
+// formatWindow renders a window the way it is usually written, e.g. 1h or 7d
+func formatWindow(d time.Duration) string {
+	day := 24 * time.Hour
+	switch {
+	case d > day && d%day == 0:
+		return strconv.Itoa(int(d/day)) + "d"
+	case d%time.Hour == 0:
+		return strconv.Itoa(int(d/time.Hour)) + "h"
+	case d%time.Minute == 0:
+		return strconv.Itoa(int(d/time.Minute)) + "m"
+	}
+	return d.String()
+}
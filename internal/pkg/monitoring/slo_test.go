@@ -0,0 +1,189 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// newTestSLOTracker returns a tracker on a clock that only moves with advance
+func newTestSLOTracker(t *testing.T, config SLOConfig) (*SLOTracker, *prometheus.HistogramVec, func(time.Duration)) {
+	t.Helper()
+
+	registry := prometheus.NewRegistry()
+	histogram := prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "test",
+			Name:      "http_request_duration_seconds",
+			Buckets:   []float64{0.1, 0.2, 0.5, 1},
+		},
+		[]string{"method", "endpoint", "status_code"},
+	)
+	registry.MustRegister(histogram)
+
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tracker := NewSLOTracker(config, registry, logger.New("error", "text"))
+	tracker.now = func() time.Time { return now }
+
+	return tracker, histogram, func(d time.Duration) { now = now.Add(d) }
+}
+
+func TestSLOTracker(t *testing.T) {
+	config := SLOConfig{
+		AvailabilityTarget: 0.99,
+		LatencyTarget:      200 * time.Millisecond,
+		LatencyPercentile:  0.9,
+		Windows:            []time.Duration{time.Hour, 24 * time.Hour},
+	}
+	observe := func(histogram *prometheus.HistogramVec, status string, seconds float64, times int) {
+		for i := 0; i < times; i++ {
+			histogram.WithLabelValues("GET", "/users", status).Observe(seconds)
+		}
+	}
+
+	t.Run("should compute budgets over each window", func(t *testing.T) {
+		tracker, histogram, advance := newTestSLOTracker(t, config)
+		require.NoError(t, tracker.Evaluate())
+
+		// An hour with no errors and every request fast
+		observe(histogram, "200", 0.05, 1000)
+		advance(time.Hour)
+		require.NoError(t, tracker.Evaluate())
+
+		// The last hour fails 2% of requests and 20% are slow
+		observe(histogram, "200", 0.05, 780)
+		observe(histogram, "200", 0.3, 200)
+		observe(histogram, "503", 0.05, 20)
+		advance(time.Hour)
+
+		report, err := tracker.Report()
+		require.NoError(t, err)
+		assert.Equal(t, 200.0, report.LatencyTargetMs)
+		require.Len(t, report.Windows, 2)
+
+		hour := report.Windows[0]
+		assert.Equal(t, "1h", hour.Window)
+		assert.Equal(t, 3600.0, hour.ObservedSeconds)
+		assert.Equal(t, uint64(1000), hour.Requests)
+		assert.InDelta(t, 0.98, hour.Availability.SLI, 1e-9)
+		assert.InDelta(t, 2, hour.Availability.BurnRate, 1e-9)
+		assert.InDelta(t, -100, hour.Availability.BudgetRemainingPercent, 1e-9)
+		assert.InDelta(t, 0.8, hour.Latency.SLI, 1e-9)
+		assert.InDelta(t, 2, hour.Latency.BurnRate, 1e-9)
+
+		day := report.Windows[1]
+		assert.Equal(t, "24h", day.Window)
+		assert.Equal(t, 7200.0, day.ObservedSeconds, "covers the history recorded so far")
+		assert.Equal(t, uint64(2000), day.Requests)
+		assert.InDelta(t, 0.99, day.Availability.SLI, 1e-9)
+		assert.InDelta(t, 1, day.Availability.BurnRate, 1e-9)
+		assert.InDelta(t, 0, day.Availability.BudgetRemainingPercent, 1e-9)
+		assert.InDelta(t, 0.9, day.Latency.SLI, 1e-9)
+	})
+
+	t.Run("should interpolate requests within the latency target", func(t *testing.T) {
+		tracker, histogram, _ := newTestSLOTracker(t, SLOConfig{LatencyTarget: 150 * time.Millisecond, LatencyPercentile: 0.5})
+		require.NoError(t, tracker.Evaluate())
+		observe(histogram, "200", 0.05, 50)
+		observe(histogram, "200", 0.15, 50)
+		observe(histogram, "200", 2, 100)
+
+		report, err := tracker.Report()
+		require.NoError(t, err)
+
+		// Half of the (0.1, 0.2] bucket counts, as does nothing above 1s
+		assert.InDelta(t, 0.375, report.Windows[0].Latency.SLI, 1e-9)
+	})
+
+	t.Run("should report a full budget without requests", func(t *testing.T) {
+		tracker, _, _ := newTestSLOTracker(t, config)
+
+		report, err := tracker.Report()
+		require.NoError(t, err)
+
+		for _, window := range report.Windows {
+			assert.Zero(t, window.Requests)
+			assert.Equal(t, 100.0, window.Availability.BudgetRemainingPercent)
+			assert.Zero(t, window.Latency.BurnRate)
+		}
+	})
+
+	t.Run("should drop samples older than the longest window", func(t *testing.T) {
+		tracker, _, advance := newTestSLOTracker(t, SLOConfig{Windows: []time.Duration{time.Hour}})
+		for i := 0; i < 180; i++ {
+			require.NoError(t, tracker.Evaluate())
+			advance(time.Minute)
+		}
+
+		assert.Len(t, tracker.samples, 61)
+	})
+
+	t.Run("should track which objectives are burning", func(t *testing.T) {
+		tracker, histogram, advance := newTestSLOTracker(t, config)
+		require.NoError(t, tracker.Evaluate())
+
+		observe(histogram, "500", 0.05, 10)
+		advance(time.Minute)
+		require.NoError(t, tracker.Evaluate())
+		assert.True(t, tracker.burning["availability/1h"])
+		assert.False(t, tracker.burning["latency/1h"])
+
+		observe(histogram, "200", 0.05, 10000)
+		advance(time.Minute)
+		require.NoError(t, tracker.Evaluate())
+		assert.False(t, tracker.burning["availability/1h"])
+	})
+}
+
+func TestSLOHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should serve the report of the default windows", func(t *testing.T) {
+		tracker, _, _ := newTestSLOTracker(t, SLOConfig{AvailabilityTarget: 0.999, LatencyPercentile: 0.99})
+
+		router := gin.New()
+		router.GET("/admin/slo", tracker.Handler)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/slo", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Windows []map[string]interface{} `json:"windows"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Windows, 3)
+		assert.Equal(t, "1h", body.Windows[0]["window"])
+		assert.Equal(t, "24h", body.Windows[1]["window"])
+		assert.Equal(t, "7d", body.Windows[2]["window"])
+		assert.Contains(t, body.Windows[0]["availability"], "budget_remaining_percent")
+		assert.Contains(t, body.Windows[0]["latency"], "burn_rate")
+	})
+}
+
+func TestFormatWindow(t *testing.T) {
+	tests := []struct {
+		window time.Duration
+		want   string
+	}{
+		{time.Hour, "1h"},
+		{24 * time.Hour, "24h"},
+		{7 * 24 * time.Hour, "7d"},
+		{30 * time.Minute, "30m"},
+		{90 * time.Second, "1m30s"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			assert.Equal(t, tt.want, formatWindow(tt.window))
+		})
+	}
+}
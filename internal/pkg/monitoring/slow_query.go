@@ -0,0 +1,112 @@
+package monitoring
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+// slowQueryContextKey is the request context key of the slow query tracker
+// GinMiddleware attaches
+type slowQueryContextKey struct{}
+
+var (
+	stringLiteral   = regexp.MustCompile(`'(?:[^']|'')*'`)
+	numericLiteral  = regexp.MustCompile(`\b\d+(?:\.\d+)?\b`)
+	queryParameter  = regexp.MustCompile(`\$\d+`)
+	placeholderList = regexp.MustCompile(`\(\s*\?(?:\s*,\s*\?)*\s*\)`)
+	whitespace      = regexp.MustCompile(`\s+`)
+)
+
+// slowQuery is a database operation that exceeded the slow query threshold
+type slowQuery struct {
+	database    string
+	operation   string
+	fingerprint string
+	duration    time.Duration
+}
+
+// slowQueryTracker collects the slow queries of one request
+type slowQueryTracker struct {
+	mu      sync.Mutex
+	queries []slowQuery
+}
+
+func (t *slowQueryTracker) add(query slowQuery) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.queries = append(t.queries, query)
+}
+
+func (t *slowQueryTracker) list() []slowQuery {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]slowQuery(nil), t.queries...)
+}
+
+// SetLogger sets the logger slow queries are reported to
+func (m *PrometheusMonitor) SetLogger(log *logger.Logger) {
+	m.logger = log
+}
+
+// RecordDBQuery records a database operation like RecordDBOperation. When
+// it exceeds the slow query threshold and ctx is a request context served
+// by GinMiddleware, it is also logged once the request completes.
+func (m *PrometheusMonitor) RecordDBQuery(ctx context.Context, database, operation, status, query string, duration time.Duration) {
+	if !m.config.Enabled {
+		return
+	}
+
+	m.RecordDBOperation(database, operation, status, duration)
+
+	if !m.isSlowQuery(duration) {
+		return
+	}
+	if tracker, ok := ctx.Value(slowQueryContextKey{}).(*slowQueryTracker); ok {
+		tracker.add(slowQuery{
+			database:    database,
+			operation:   operation,
+			fingerprint: QueryFingerprint(query),
+			duration:    duration,
+		})
+	}
+}
+
+func (m *PrometheusMonitor) isSlowQuery(duration time.Duration) bool {
+	return m.config.SlowQueryThreshold > 0 && duration > m.config.SlowQueryThreshold
+}
+
+// logSlowQueries warns about each slow query of a completed request
+func (m *PrometheusMonitor) logSlowQueries(method, endpoint string, tracker *slowQueryTracker) {
+	if m.logger == nil {
+		return
+	}
+
+	for _, query := range tracker.list() {
+		m.logger.Warn("slow_query_detected",
+			"database", query.database,
+			"operation", query.operation,
+			"fingerprint", query.fingerprint,
+			"duration_ms", query.duration.Milliseconds(),
+			"threshold_ms", m.config.SlowQueryThreshold.Milliseconds(),
+			"method", method,
+			"endpoint", endpoint)
+	}
+}
+
+// QueryFingerprint normalizes a SQL query so that executions differing only
+// in their literals and parameters share a fingerprint: literals and
+// parameters become ?, lists of them a single (?), and whitespace is
+// collapsed
+func QueryFingerprint(query string) string {
+	fingerprint := stringLiteral.ReplaceAllString(query, "?")
+	fingerprint = queryParameter.ReplaceAllString(fingerprint, "?")
+	fingerprint = numericLiteral.ReplaceAllString(fingerprint, "?")
+	fingerprint = placeholderList.ReplaceAllString(fingerprint, "(?)")
+	fingerprint = whitespace.ReplaceAllString(fingerprint, " ")
+	return strings.TrimSpace(fingerprint)
+}
@@ -0,0 +1,119 @@
+package monitoring
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+)
+
+func TestQueryFingerprint(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{
+			name:  "should replace literals",
+			query: "SELECT * FROM users WHERE email = 'a@b.c' AND age > 21",
+			want:  "SELECT * FROM users WHERE email = ? AND age > ?",
+		},
+		{
+			name:  "should replace parameters and collapse lists",
+			query: "SELECT id FROM users WHERE id IN ($1, $2, $3) LIMIT $4",
+			want:  "SELECT id FROM users WHERE id IN (?) LIMIT ?",
+		},
+		{
+			name:  "should keep digits in identifiers and collapse whitespace",
+			query: "SELECT t1.id\n\tFROM  users t1",
+			want:  "SELECT t1.id FROM users t1",
+		},
+		{
+			name:  "should handle escaped quotes",
+			query: "UPDATE users SET name = 'O''Brien' WHERE id = 7",
+			want:  "UPDATE users SET name = ? WHERE id = ?",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, QueryFingerprint(tt.query))
+		})
+	}
+}
+
+func TestSlowQueries(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newMonitor := func(t *testing.T) (*PrometheusMonitor, *bytes.Buffer) {
+		monitor, err := NewPrometheusMonitor(&Config{
+			Enabled:            true,
+			Namespace:          "slow_query_test",
+			SlowQueryThreshold: 100 * time.Millisecond,
+		})
+		require.NoError(t, err)
+
+		var buf bytes.Buffer
+		log := logger.New("info", "json")
+		log.SetOutput(&buf)
+		monitor.SetLogger(log)
+		return monitor, &buf
+	}
+
+	t.Run("should count operations over the threshold", func(t *testing.T) {
+		monitor, _ := newMonitor(t)
+
+		monitor.RecordDBOperation("postgres", "SELECT", "success", 50*time.Millisecond)
+		monitor.RecordDBOperation("postgres", "SELECT", "success", 150*time.Millisecond)
+		monitor.RecordDBQuery(context.Background(), "postgres", "UPDATE", "success", "UPDATE users SET name = $1", 200*time.Millisecond)
+
+		assert.Equal(t, float64(1), testutil.ToFloat64(monitor.metrics.SlowQueries.WithLabelValues("postgres", "SELECT")))
+		assert.Equal(t, float64(1), testutil.ToFloat64(monitor.metrics.SlowQueries.WithLabelValues("postgres", "UPDATE")))
+		assert.Equal(t, float64(2), testutil.ToFloat64(monitor.metrics.DBQueries.WithLabelValues("postgres", "SELECT", "success")))
+	})
+
+	t.Run("should log the slow queries of a request once it completes", func(t *testing.T) {
+		monitor, buf := newMonitor(t)
+
+		router := gin.New()
+		router.Use(monitor.GinMiddleware())
+		router.GET("/users/:id", func(c *gin.Context) {
+			ctx := c.Request.Context()
+			monitor.RecordDBQuery(ctx, "postgres", "SELECT", "success", "SELECT * FROM users WHERE id = $1", 10*time.Millisecond)
+			monitor.RecordDBQuery(ctx, "postgres", "SELECT", "success", "SELECT * FROM orders WHERE user_id = 42", 250*time.Millisecond)
+			assert.Empty(t, buf.String())
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var entry map[string]interface{}
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &entry))
+		assert.Equal(t, "warning", entry["level"])
+		assert.Equal(t, "slow_query_detected", entry["msg"])
+		assert.Equal(t, "SELECT * FROM orders WHERE user_id = ?", entry["fingerprint"])
+		assert.Equal(t, float64(250), entry["duration_ms"])
+		assert.Equal(t, "/users/:id", entry["endpoint"])
+	})
+
+	t.Run("should not track queries when the threshold is not set", func(t *testing.T) {
+		monitor, err := NewPrometheusMonitor(&Config{Enabled: true, Namespace: "no_slow_query_test"})
+		require.NoError(t, err)
+
+		monitor.RecordDBOperation("postgres", "SELECT", "success", time.Hour)
+
+		assert.Zero(t, testutil.CollectAndCount(monitor.metrics.SlowQueries))
+	})
+}
@@ -0,0 +1,130 @@
+package monitoring
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// slowestRoutesLimit is how many routes the summary ranks by p99
+const slowestRoutesLimit = 5
+
+// RouteLatency holds the latency percentiles of one route, in milliseconds
+type RouteLatency struct {
+	Route    string  `json:"route"`
+	Requests uint64  `json:"requests"`
+	P50Ms    float64 `json:"p50_ms"`
+	P95Ms    float64 `json:"p95_ms"`
+	P99Ms    float64 `json:"p99_ms"`
+}
+
+// LatencySummary reports HTTP latency percentiles over every request since
+// the monitor started, overall and per route
+type LatencySummary struct {
+	Requests          uint64         `json:"requests"`
+	RequestsPerSecond float64        `json:"requests_per_second"`
+	P50Ms             float64        `json:"p50_ms"`
+	P95Ms             float64        `json:"p95_ms"`
+	P99Ms             float64        `json:"p99_ms"`
+	SlowestRoutes     []RouteLatency `json:"slowest_routes"`
+	Routes            []RouteLatency `json:"routes"`
+}
+
+// LatencySummary computes latency percentiles from the buckets of the HTTP
+// duration histogram recorded by GinMiddleware. The request rate is averaged
+// since the monitor started.
+func (m *PrometheusMonitor) LatencySummary() (*LatencySummary, error) {
+	families, err := prometheus.Gatherers{m.GetGatherer()}.Gather()
+	if err != nil {
+		return nil, fmt.Errorf("failed to gather metrics: %w", err)
+	}
+
+	name := prometheus.BuildFQName(m.config.Namespace, "", latencyMetricName)
+	overall := make(map[float64]uint64)
+	perRoute := make(map[string]map[float64]uint64)
+
+	for _, family := range families {
+		if family.GetName() != name || family.GetType() != dto.MetricType_HISTOGRAM {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			_, route := seriesKey(metric)
+			if perRoute[route] == nil {
+				perRoute[route] = make(map[float64]uint64)
+			}
+			addBuckets(overall, metric.GetHistogram())
+			addBuckets(perRoute[route], metric.GetHistogram())
+		}
+	}
+
+	summary := &LatencySummary{Routes: make([]RouteLatency, 0, len(perRoute))}
+	for route, buckets := range perRoute {
+		if latency := routeLatency(route, buckets); latency.Requests > 0 {
+			summary.Routes = append(summary.Routes, latency)
+		}
+	}
+	sort.Slice(summary.Routes, func(i, j int) bool {
+		return summary.Routes[i].Route < summary.Routes[j].Route
+	})
+
+	total := routeLatency("", overall)
+	summary.Requests = total.Requests
+	summary.P50Ms, summary.P95Ms, summary.P99Ms = total.P50Ms, total.P95Ms, total.P99Ms
+	if uptime := time.Since(m.started).Seconds(); uptime > 0 {
+		summary.RequestsPerSecond = float64(summary.Requests) / uptime
+	}
+
+	summary.SlowestRoutes = append([]RouteLatency(nil), summary.Routes...)
+	sort.SliceStable(summary.SlowestRoutes, func(i, j int) bool {
+		return summary.SlowestRoutes[i].P99Ms > summary.SlowestRoutes[j].P99Ms
+	})
+	if len(summary.SlowestRoutes) > slowestRoutesLimit {
+		summary.SlowestRoutes = summary.SlowestRoutes[:slowestRoutesLimit]
+	}
+
+	return summary, nil
+}
+
+// SummaryHandler serves LatencySummary as JSON
+func (m *PrometheusMonitor) SummaryHandler(c *gin.Context) {
+	if !m.config.Enabled {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Metrics are disabled"})
+		return
+	}
+
+	summary, err := m.LatencySummary()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, summary)
+}
+
+// addBuckets adds the histogram's cumulative bucket counts to buckets, with
+// the sample count as the implicit +Inf bucket
+func addBuckets(buckets map[float64]uint64, histogram *dto.Histogram) {
+	for _, bucket := range histogram.GetBucket() {
+		buckets[bucket.GetUpperBound()] += bucket.GetCumulativeCount()
+	}
+	buckets[math.Inf(1)] += histogram.GetSampleCount()
+}
+
+func routeLatency(route string, buckets map[float64]uint64) RouteLatency {
+	latency := RouteLatency{Route: route, Requests: buckets[math.Inf(1)]}
+	if latency.Requests == 0 {
+		return latency
+	}
+
+	latency.P50Ms = histogramQuantile(0.50, buckets) * 1000
+	latency.P95Ms = histogramQuantile(0.95, buckets) * 1000
+	latency.P99Ms = histogramQuantile(0.99, buckets) * 1000
+	return latency
+}
@@ -0,0 +1,140 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatencySummary(t *testing.T) {
+	newMonitor := func(t *testing.T) *PrometheusMonitor {
+		monitor, err := NewPrometheusMonitor(&Config{
+			Enabled:   true,
+			Namespace: "test",
+			HistogramBuckets: map[string][]float64{
+				"http_request_duration_seconds": {0.1, 0.2, 0.5, 1},
+			},
+		})
+		require.NoError(t, err)
+		return monitor
+	}
+	observe := func(monitor *PrometheusMonitor, endpoint string, seconds float64, times int) {
+		for i := 0; i < times; i++ {
+			monitor.metrics.HTTPDuration.WithLabelValues("GET", endpoint, "200").Observe(seconds)
+		}
+	}
+
+	t.Run("should interpolate percentiles from the histogram buckets", func(t *testing.T) {
+		monitor := newMonitor(t)
+		observe(monitor, "/fast", 0.05, 100)
+		observe(monitor, "/slow", 0.3, 10)
+
+		summary, err := monitor.LatencySummary()
+		require.NoError(t, err)
+
+		assert.Equal(t, uint64(110), summary.Requests)
+		assert.Greater(t, summary.RequestsPerSecond, 0.0)
+		assert.InDelta(t, 55, summary.P50Ms, 0.001)
+
+		require.Len(t, summary.Routes, 2)
+		fast, slow := summary.Routes[0], summary.Routes[1]
+		assert.Equal(t, "GET /fast", fast.Route)
+		assert.Equal(t, uint64(100), fast.Requests)
+		assert.InDelta(t, 50, fast.P50Ms, 0.001)
+		assert.InDelta(t, 99, fast.P99Ms, 0.001)
+		assert.Equal(t, "GET /slow", slow.Route)
+		assert.InDelta(t, 350, slow.P50Ms, 0.001)
+		assert.InDelta(t, 485, slow.P95Ms, 0.001)
+		assert.InDelta(t, 497, slow.P99Ms, 0.001)
+
+		assert.Equal(t, "GET /slow", summary.SlowestRoutes[0].Route)
+	})
+
+	t.Run("should merge status codes of a route", func(t *testing.T) {
+		monitor := newMonitor(t)
+		monitor.metrics.HTTPDuration.WithLabelValues("GET", "/users", "200").Observe(0.05)
+		monitor.metrics.HTTPDuration.WithLabelValues("GET", "/users", "500").Observe(0.05)
+
+		summary, err := monitor.LatencySummary()
+		require.NoError(t, err)
+
+		require.Len(t, summary.Routes, 1)
+		assert.Equal(t, uint64(2), summary.Routes[0].Requests)
+	})
+
+	t.Run("should rank the five slowest routes by p99", func(t *testing.T) {
+		monitor := newMonitor(t)
+		for i := 1; i <= 7; i++ {
+			observe(monitor, fmt.Sprintf("/route%d", i), 0.1*float64(i), 1)
+		}
+
+		summary, err := monitor.LatencySummary()
+		require.NoError(t, err)
+
+		require.Len(t, summary.Routes, 7)
+		require.Len(t, summary.SlowestRoutes, 5)
+		for i := 1; i < len(summary.SlowestRoutes); i++ {
+			assert.GreaterOrEqual(t, summary.SlowestRoutes[i-1].P99Ms, summary.SlowestRoutes[i].P99Ms)
+		}
+		assert.NotContains(t, summary.SlowestRoutes, summary.Routes[0])
+	})
+
+	t.Run("should report zeros without requests", func(t *testing.T) {
+		summary, err := newMonitor(t).LatencySummary()
+		require.NoError(t, err)
+
+		assert.Zero(t, summary.Requests)
+		assert.Zero(t, summary.P99Ms)
+		assert.Empty(t, summary.SlowestRoutes)
+	})
+}
+
+func TestSummaryHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should summarize requests recorded by the middleware", func(t *testing.T) {
+		monitor, err := NewPrometheusMonitor(&Config{Enabled: true, Namespace: "test"})
+		require.NoError(t, err)
+
+		router := gin.New()
+		router.Use(monitor.GinMiddleware())
+		router.GET("/items/:id", func(c *gin.Context) {
+			time.Sleep(time.Millisecond)
+			c.Status(http.StatusOK)
+		})
+		router.GET("/admin/metrics/summary", monitor.SummaryHandler)
+
+		for i := 0; i < 3; i++ {
+			router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, fmt.Sprintf("/items/%d", i), nil))
+		}
+
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/metrics/summary", nil))
+		require.Equal(t, http.StatusOK, w.Code)
+
+		var summary LatencySummary
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &summary))
+		assert.Equal(t, uint64(3), summary.Requests)
+		require.Len(t, summary.Routes, 1)
+		assert.Equal(t, "GET /items/:id", summary.Routes[0].Route)
+		assert.Greater(t, summary.Routes[0].P50Ms, 0.0)
+	})
+
+	t.Run("should return not found when disabled", func(t *testing.T) {
+		monitor, err := NewPrometheusMonitor(&Config{Enabled: false})
+		require.NoError(t, err)
+
+		w := httptest.NewRecorder()
+		c, _ := gin.CreateTestContext(w)
+		monitor.SummaryHandler(c)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/VeRJiL/go-template/internal/pkg/container"
@@ -45,17 +46,12 @@ func (r *ModuleRegistry) Register(module modules.Module) error {
 		return fmt.Errorf("module %s is already registered", name)
 	}
 
-	// Validate dependencies
-	if err := r.validateDependencies(module); err != nil {
-		return fmt.Errorf("dependency validation failed for module %s: %w", name, err)
-	}
-
+	// Dependencies are resolved during Initialize so modules can be
+	// registered in any order
 	r.modules[name] = module
+	r.moduleOrder = append(r.moduleOrder, name)
 	r.logger.Info("Module registered", "module", name, "version", module.Version())
 
-	// Recalculate module order after registration
-	r.calculateModuleOrder()
-
 	return nil
 }
 
@@ -72,7 +68,8 @@ func (r *ModuleRegistry) GetModule(name string) (modules.Module, error) {
 	return module, nil
 }
 
-// GetModules returns all registered modules in dependency order
+// GetModules returns all registered modules, in dependency order once the
+// registry has been initialized
 func (r *ModuleRegistry) GetModules() []modules.Module {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -147,6 +144,12 @@ func (r *ModuleRegistry) Initialize(ctx context.Context, deps *modules.Dependenc
 
 	r.logger.Info("Initializing modules", "count", len(r.modules))
 
+	order, err := r.calculateModuleOrder()
+	if err != nil {
+		return fmt.Errorf("failed to resolve module dependencies: %w", err)
+	}
+	r.moduleOrder = order
+
 	// Initialize modules in dependency order
 	for _, name := range r.moduleOrder {
 		module, exists := r.modules[name]
@@ -204,65 +207,50 @@ func (r *ModuleRegistry) Shutdown(ctx context.Context) error {
 
 // Helper methods
 
-func (r *ModuleRegistry) validateDependencies(module modules.Module) error {
-	dependencies := module.Dependencies()
-	for _, depName := range dependencies {
-		if _, exists := r.modules[depName]; !exists {
-			return fmt.Errorf("dependency %s not found", depName)
-		}
-	}
-	return nil
-}
-
-func (r *ModuleRegistry) calculateModuleOrder() {
-	// Topological sort to determine module initialization order
+// calculateModuleOrder topologically sorts the modules so every module comes
+// after the modules it depends on. Independent modules keep their
+// registration order.
+func (r *ModuleRegistry) calculateModuleOrder() ([]string, error) {
 	visited := make(map[string]bool)
 	tempMark := make(map[string]bool)
-	var order []string
+	order := make([]string, 0, len(r.moduleOrder))
+	var path []string
 
 	var visit func(string) error
 	visit = func(name string) error {
 		if tempMark[name] {
-			return fmt.Errorf("circular dependency detected involving module %s", name)
+			return fmt.Errorf("circular dependency detected: %s", strings.Join(append(path, name), " -> "))
 		}
 		if visited[name] {
 			return nil
 		}
 
 		tempMark[name] = true
+		path = append(path, name)
 
-		module, exists := r.modules[name]
-		if exists {
-			for _, dep := range module.Dependencies() {
-				if err := visit(dep); err != nil {
-					return err
-				}
+		for _, dep := range r.modules[name].Dependencies() {
+			if _, exists := r.modules[dep]; !exists {
+				return fmt.Errorf("module %s depends on unregistered module %s", name, dep)
+			}
+			if err := visit(dep); err != nil {
+				return err
 			}
 		}
 
+		path = path[:len(path)-1]
 		tempMark[name] = false
 		visited[name] = true
 		order = append(order, name)
 		return nil
 	}
 
-	// Visit all modules
-	for name := range r.modules {
-		if !visited[name] {
-			if err := visit(name); err != nil {
-				r.logger.Error("Failed to calculate module order", "error", err)
-				// Fallback to alphabetical order
-				order = make([]string, 0, len(r.modules))
-				for name := range r.modules {
-					order = append(order, name)
-				}
-				sort.Strings(order)
-				break
-			}
+	for _, name := range r.moduleOrder {
+		if err := visit(name); err != nil {
+			return nil, err
 		}
 	}
 
-	r.moduleOrder = order
+	return order, nil
 }
 
 func (r *ModuleRegistry) discoverModulesFromContainer() error {
@@ -390,4 +378,4 @@ func (r *ModuleRegistry) IsInitialized() bool {
 	defer r.mu.RUnlock()
 
 	return r.initialized
-}
\ No newline at end of file
+}
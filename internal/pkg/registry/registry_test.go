@@ -0,0 +1,93 @@
+package registry
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/container"
+	"github.com/VeRJiL/go-template/internal/pkg/logger"
+	"github.com/VeRJiL/go-template/internal/pkg/modules"
+)
+
+type fakeModule struct {
+	name         string
+	dependencies []string
+	initialized  *[]string
+}
+
+func (m *fakeModule) Name() string                                  { return m.name }
+func (m *fakeModule) Version() string                               { return "1.0.0" }
+func (m *fakeModule) MinBootstrapVersion() string                   { return "" }
+func (m *fakeModule) MaxBootstrapVersion() string                   { return "" }
+func (m *fakeModule) Dependencies() []string                        { return m.dependencies }
+func (m *fakeModule) RegisterServices(c *container.Container) error { return nil }
+func (m *fakeModule) Migrate(db *sql.DB) error                      { return nil }
+func (m *fakeModule) Drain(ctx context.Context) error               { return nil }
+func (m *fakeModule) Shutdown(ctx context.Context) error            { return nil }
+func (m *fakeModule) RegisterRoutes(router *gin.RouterGroup, deps *modules.Dependencies) error {
+	return nil
+}
+
+func (m *fakeModule) Initialize(ctx context.Context) error {
+	*m.initialized = append(*m.initialized, m.name)
+	return nil
+}
+
+func newTestRegistry() (modules.ModuleRegistry, *modules.Dependencies) {
+	cont := container.NewContainer()
+	return NewModuleRegistry(logger.New("error", "text"), cont), &modules.Dependencies{Container: cont}
+}
+
+func TestModuleRegistryInitialize(t *testing.T) {
+	t.Run("should initialize dependencies before their dependents", func(t *testing.T) {
+		registry, deps := newTestRegistry()
+		var initialized []string
+
+		require.NoError(t, registry.Register(&fakeModule{name: "payment", dependencies: []string{"user", "product"}, initialized: &initialized}))
+		require.NoError(t, registry.Register(&fakeModule{name: "product", dependencies: []string{"user"}, initialized: &initialized}))
+		require.NoError(t, registry.Register(&fakeModule{name: "user", initialized: &initialized}))
+		require.NoError(t, registry.Register(&fakeModule{name: "audit", initialized: &initialized}))
+
+		require.NoError(t, registry.Initialize(context.Background(), deps))
+
+		assert.Equal(t, []string{"user", "product", "payment", "audit"}, initialized)
+
+		names := make([]string, 0, len(initialized))
+		for _, module := range registry.GetModules() {
+			names = append(names, module.Name())
+		}
+		assert.Equal(t, initialized, names)
+	})
+
+	t.Run("should reject circular dependencies", func(t *testing.T) {
+		registry, deps := newTestRegistry()
+		var initialized []string
+
+		require.NoError(t, registry.Register(&fakeModule{name: "user", dependencies: []string{"payment"}, initialized: &initialized}))
+		require.NoError(t, registry.Register(&fakeModule{name: "payment", dependencies: []string{"user"}, initialized: &initialized}))
+
+		err := registry.Initialize(context.Background(), deps)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "user -> payment -> user")
+		assert.Empty(t, initialized)
+	})
+
+	t.Run("should reject dependencies on unregistered modules", func(t *testing.T) {
+		registry, deps := newTestRegistry()
+		var initialized []string
+
+		require.NoError(t, registry.Register(&fakeModule{name: "payment", dependencies: []string{"user"}, initialized: &initialized}))
+
+		err := registry.Initialize(context.Background(), deps)
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "module payment depends on unregistered module user")
+		assert.Empty(t, initialized)
+	})
+}
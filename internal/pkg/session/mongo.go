@@ -0,0 +1,76 @@
+package session
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const mongoCollection = "sessions"
+
+// MongoStore keeps sessions in the sessions collection. A TTL index on
+// expires_at lets MongoDB purge expired sessions on its own.
+type MongoStore struct {
+	collection *mongo.Collection
+}
+
+func NewMongoStore(db *mongo.Database) *MongoStore {
+	return &MongoStore{collection: db.Collection(mongoCollection)}
+}
+
+// EnsureIndexes creates the TTL index used to expire sessions
+func (s *MongoStore) EnsureIndexes(ctx context.Context) error {
+	_, err := s.collection.Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "expires_at", Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create session indexes: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStore) Create(ctx context.Context, session *Session) error {
+	if _, err := s.collection.InsertOne(ctx, session); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+	return nil
+}
+
+func (s *MongoStore) Get(ctx context.Context, id string) (*Session, error) {
+	filter := bson.M{"_id": id, "expires_at": bson.M{"$gt": time.Now()}}
+
+	var session Session
+	if err := s.collection.FindOne(ctx, filter).Decode(&session); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	return &session, nil
+}
+
+func (s *MongoStore) Update(ctx context.Context, session *Session) error {
+	result, err := s.collection.ReplaceOne(ctx, bson.M{"_id": session.ID}, session)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	if result.MatchedCount == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *MongoStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.collection.DeleteOne(ctx, bson.M{"_id": id}); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
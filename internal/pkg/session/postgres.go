@@ -0,0 +1,110 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+)
+
+// Querier runs the queries of a PostgresStore. *sql.DB implements it, as
+// does postgres.ManagedDB.
+type Querier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// PostgresStore keeps sessions in the sessions table.
+// Expired rows are ignored on read and can be purged with DeleteExpired.
+type PostgresStore struct {
+	db Querier
+}
+
+func NewPostgresStore(db Querier) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+func (s *PostgresStore) Create(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session data: %w", err)
+	}
+
+	query := `
+		INSERT INTO sessions (id, user_id, data, expires_at)
+		VALUES ($1, $2, $3, $4)`
+
+	if _, err := s.db.ExecContext(ctx, query, session.ID, session.UserID, data, session.ExpiresAt); err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Get(ctx context.Context, id string) (*Session, error) {
+	query := `
+		SELECT id, user_id, data, expires_at
+		FROM sessions
+		WHERE id = $1 AND expires_at > NOW()`
+
+	var session Session
+	var data []byte
+
+	err := s.db.QueryRowContext(ctx, query, id).Scan(&session.ID, &session.UserID, &data, &session.ExpiresAt)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, ErrSessionNotFound
+		}
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &session.Data); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session data: %w", err)
+	}
+
+	return &session, nil
+}
+
+func (s *PostgresStore) Update(ctx context.Context, session *Session) error {
+	data, err := json.Marshal(session.Data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session data: %w", err)
+	}
+
+	query := `
+		UPDATE sessions
+		SET user_id = $2, data = $3, expires_at = $4, updated_at = NOW()
+		WHERE id = $1`
+
+	result, err := s.db.ExecContext(ctx, query, session.ID, session.UserID, data, session.ExpiresAt)
+	if err != nil {
+		return fmt.Errorf("failed to update session: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+
+	return nil
+}
+
+func (s *PostgresStore) Delete(ctx context.Context, id string) error {
+	if _, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE id = $1", id); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// DeleteExpired removes sessions past their expiry time
+func (s *PostgresStore) DeleteExpired(ctx context.Context) (int64, error) {
+	result, err := s.db.ExecContext(ctx, "DELETE FROM sessions WHERE expires_at <= NOW()")
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete expired sessions: %w", err)
+	}
+	return result.RowsAffected()
+}
@@ -0,0 +1,100 @@
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CookieName is the cookie carrying the session ID in session-based auth
+const CookieName = "session_id"
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionExpired  = errors.New("session expired")
+)
+
+// Session is a server-side authenticated session.
+type Session struct {
+	ID        string                 `json:"id" bson:"_id"`
+	UserID    string                 `json:"user_id" bson:"user_id"`
+	Data      map[string]interface{} `json:"data" bson:"data"`
+	ExpiresAt time.Time              `json:"expires_at" bson:"expires_at"`
+}
+
+// IsExpired reports whether the session is past its expiry time
+func (s *Session) IsExpired() bool {
+	return time.Now().After(s.ExpiresAt)
+}
+
+// Store persists sessions in a shared backend so every instance sees them
+type Store interface {
+	Create(ctx context.Context, session *Session) error
+	Get(ctx context.Context, id string) (*Session, error)
+	Update(ctx context.Context, session *Session) error
+	Delete(ctx context.Context, id string) error
+}
+
+// Options carries the backend connections used by NewSessionStore.
+// Only the connection for the selected driver needs to be set.
+type Options struct {
+	Redis *redis.Client
+	DB    Querier
+	Mongo *mongo.Database
+}
+
+// NewSessionStore creates the store for the given driver (redis, postgres or mongodb)
+func NewSessionStore(driver string, opts Options) (Store, error) {
+	switch driver {
+	case "redis":
+		if opts.Redis == nil {
+			return nil, fmt.Errorf("redis session store requires a redis client")
+		}
+		return NewRedisStore(opts.Redis), nil
+	case "postgres":
+		if opts.DB == nil {
+			return nil, fmt.Errorf("postgres session store requires a database connection")
+		}
+		return NewPostgresStore(opts.DB), nil
+	case "mongodb":
+		if opts.Mongo == nil {
+			return nil, fmt.Errorf("mongodb session store requires a database")
+		}
+		return NewMongoStore(opts.Mongo), nil
+	default:
+		return nil, fmt.Errorf("unsupported session driver: %s", driver)
+	}
+}
+
+// NewSession creates a session with a random ID that expires after ttl
+func NewSession(userID string, data map[string]interface{}, ttl time.Duration) (*Session, error) {
+	id, err := generateID()
+	if err != nil {
+		return nil, err
+	}
+
+	if data == nil {
+		data = make(map[string]interface{})
+	}
+
+	return &Session{
+		ID:        id,
+		UserID:    userID,
+		Data:      data,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+func generateID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate session id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
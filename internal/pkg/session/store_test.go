@@ -0,0 +1,189 @@
+package session
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcmongodb "github.com/testcontainers/testcontainers-go/modules/mongodb"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestSessionJSON(t *testing.T) {
+	t.Run("should round-trip through JSON", func(t *testing.T) {
+		expiresAt := time.Now().Add(time.Hour).UTC().Truncate(time.Second)
+		session := &Session{
+			ID:        "abc",
+			UserID:    "user-1",
+			Data:      map[string]interface{}{"role": "admin"},
+			ExpiresAt: expiresAt,
+		}
+
+		data, err := json.Marshal(session)
+		require.NoError(t, err)
+		assert.Contains(t, string(data), `"user_id":"user-1"`)
+
+		var decoded Session
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, session.ID, decoded.ID)
+		assert.Equal(t, session.Data, decoded.Data)
+		assert.True(t, expiresAt.Equal(decoded.ExpiresAt))
+	})
+}
+
+func TestNewSession(t *testing.T) {
+	t.Run("should generate unique IDs and expiry", func(t *testing.T) {
+		s1, err := NewSession("user-1", nil, time.Hour)
+		require.NoError(t, err)
+		s2, err := NewSession("user-1", nil, time.Hour)
+		require.NoError(t, err)
+
+		assert.Len(t, s1.ID, 64)
+		assert.NotEqual(t, s1.ID, s2.ID)
+		assert.NotNil(t, s1.Data)
+		assert.False(t, s1.IsExpired())
+	})
+}
+
+func TestNewSessionStore(t *testing.T) {
+	t.Run("should reject unknown drivers", func(t *testing.T) {
+		_, err := NewSessionStore("memcached", Options{})
+		assert.Error(t, err)
+	})
+
+	t.Run("should require a connection for the driver", func(t *testing.T) {
+		for _, driver := range []string{"redis", "postgres", "mongodb"} {
+			_, err := NewSessionStore(driver, Options{})
+			assert.Error(t, err, driver)
+		}
+	})
+}
+
+// Backend tests start each backend in a container and are skipped when
+// Docker is not available.
+
+func TestRedisStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping backend tests in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := tcredis.Run(ctx, "redis:7-alpine")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	uri, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	opts, err := redis.ParseURL(uri)
+	require.NoError(t, err)
+	client := redis.NewClient(opts)
+	defer client.Close()
+
+	store, err := NewSessionStore("redis", Options{Redis: client})
+	require.NoError(t, err)
+	runStoreTests(t, store)
+}
+
+func TestPostgresStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping backend tests in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := tcpostgres.Run(ctx, "postgres:16-alpine",
+		tcpostgres.WithDatabase("go_template_test"),
+		tcpostgres.WithInitScripts("../../../migrations/postgres/002_create_sessions_table.up.sql"),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	dsn, err := container.ConnectionString(ctx, "sslmode=disable")
+	require.NoError(t, err)
+	db, err := sql.Open("postgres", dsn)
+	require.NoError(t, err)
+	defer db.Close()
+
+	store, err := NewSessionStore("postgres", Options{DB: db})
+	require.NoError(t, err)
+	runStoreTests(t, store)
+}
+
+func TestMongoStore(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping backend tests in short mode")
+	}
+	testcontainers.SkipIfProviderIsNotHealthy(t)
+	ctx := context.Background()
+
+	container, err := tcmongodb.Run(ctx, "mongo:7")
+	testcontainers.CleanupContainer(t, container)
+	require.NoError(t, err)
+
+	uri, err := container.ConnectionString(ctx)
+	require.NoError(t, err)
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(uri))
+	require.NoError(t, err)
+	defer client.Disconnect(context.Background())
+
+	store, err := NewSessionStore("mongodb", Options{Mongo: client.Database("go_template_test")})
+	require.NoError(t, err)
+	require.NoError(t, store.(*MongoStore).EnsureIndexes(ctx))
+	runStoreTests(t, store)
+}
+
+func runStoreTests(t *testing.T, store Store) {
+	ctx := context.Background()
+
+	t.Run("should create and get session", func(t *testing.T) {
+		session, err := NewSession("user-1", map[string]interface{}{"role": "user"}, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, store.Create(ctx, session))
+		defer store.Delete(ctx, session.ID)
+
+		found, err := store.Get(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "user-1", found.UserID)
+		assert.Equal(t, "user", found.Data["role"])
+	})
+
+	t.Run("should update session", func(t *testing.T) {
+		session, err := NewSession("user-1", nil, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, store.Create(ctx, session))
+		defer store.Delete(ctx, session.ID)
+
+		session.Data["theme"] = "dark"
+		require.NoError(t, store.Update(ctx, session))
+
+		found, err := store.Get(ctx, session.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "dark", found.Data["theme"])
+	})
+
+	t.Run("should return not found for missing or deleted session", func(t *testing.T) {
+		session, err := NewSession("user-1", nil, time.Hour)
+		require.NoError(t, err)
+		require.NoError(t, store.Create(ctx, session))
+		require.NoError(t, store.Delete(ctx, session.ID))
+
+		_, err = store.Get(ctx, session.ID)
+		assert.ErrorIs(t, err, ErrSessionNotFound)
+
+		missing, err := NewSession("user-1", nil, time.Hour)
+		require.NoError(t, err)
+		assert.ErrorIs(t, store.Update(ctx, missing), ErrSessionNotFound)
+	})
+}
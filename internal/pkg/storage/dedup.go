@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// dedupKeyPrefix prefixes the Redis keys mapping a content hash to the path
+// it is stored at
+const dedupKeyPrefix = "storage_hashes:"
+
+// ErrDeduplicationDisabled is returned by the deduplication methods of a
+// manager without a deduplication index
+var ErrDeduplicationDisabled = errors.New("storage deduplication index is not configured")
+
+// deleteIfPath removes a hash's mapping only if it still points at the
+// path that was found missing, so a mapping re-pointed meanwhile survives
+var deleteIfPath = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// SetDeduplicationIndex enables PutDeduped, keeping the index of stored
+// content hashes in Redis
+func (m *Manager) SetDeduplicationIndex(client *redis.Client) {
+	m.dedupIndex = client
+}
+
+// PutDeduped stores content at path on the default disk unless identical
+// content is already stored, in which case nothing is uploaded and the
+// path of the existing copy is returned with deduplicated set.
+//
+// Deduplicated paths are shared by everyone who stored the same content,
+// so callers must not delete them while other references may remain.
+func (m *Manager) PutDeduped(ctx context.Context, path string, r io.Reader) (actualPath string, deduplicated bool, err error) {
+	if m.dedupIndex == nil {
+		return "", false, ErrDeduplicationDisabled
+	}
+
+	// Spool the content to hash it before deciding whether to upload it
+	spool, err := os.CreateTemp("", "storage-dedup-*")
+	if err != nil {
+		return "", false, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(spool, hasher), r); err != nil {
+		return "", false, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	key := dedupKeyPrefix + hex.EncodeToString(hasher.Sum(nil))
+
+	existing, err := m.dedupIndex.Get(ctx, key).Result()
+	switch {
+	case err == nil:
+		exists, err := m.Default().Exists(ctx, existing)
+		if err != nil {
+			return "", false, err
+		}
+		if exists {
+			return existing, true, nil
+		}
+	case !errors.Is(err, redis.Nil):
+		return "", false, fmt.Errorf("failed to look up content hash: %w", err)
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return "", false, fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	if err := m.Put(ctx, path, spool); err != nil {
+		return "", false, err
+	}
+
+	// The index is only an optimization: the file is stored either way
+	if err := m.dedupIndex.Set(ctx, key, path, 0).Err(); err != nil {
+		return path, false, fmt.Errorf("stored %s but failed to index its content hash: %w", path, err)
+	}
+
+	return path, false, nil
+}
+
+// CleanDeduplicationIndex removes the index entries whose file is no longer
+// on the default disk and returns how many it removed
+func (m *Manager) CleanDeduplicationIndex(ctx context.Context) (removed int, err error) {
+	if m.dedupIndex == nil {
+		return 0, ErrDeduplicationDisabled
+	}
+
+	iter := m.dedupIndex.Scan(ctx, 0, dedupKeyPrefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		key := iter.Val()
+
+		path, err := m.dedupIndex.Get(ctx, key).Result()
+		if errors.Is(err, redis.Nil) {
+			continue
+		}
+		if err != nil {
+			return removed, fmt.Errorf("failed to read %s: %w", key, err)
+		}
+
+		exists, err := m.Default().Exists(ctx, path)
+		if err != nil {
+			return removed, fmt.Errorf("failed to check %s: %w", path, err)
+		}
+		if exists {
+			continue
+		}
+
+		deleted, err := deleteIfPath.Run(ctx, m.dedupIndex, []string{key}, path).Int()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", key, err)
+		}
+		removed += deleted
+	}
+	if err := iter.Err(); err != nil {
+		return removed, fmt.Errorf("failed to scan the deduplication index: %w", err)
+	}
+
+	return removed, nil
+}
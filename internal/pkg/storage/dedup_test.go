@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+func newDedupManager(client *redis.Client) (*Manager, *MockStorage) {
+	disk := NewMockStorage("local")
+	manager := &Manager{
+		drivers:     map[string]Storage{"local": disk},
+		defaultDisk: "local",
+		chunkSize:   DefaultChunkSize,
+	}
+	manager.SetDeduplicationIndex(client)
+	return manager, disk
+}
+
+func TestManagerPutDeduped(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should store identical content once", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		manager, disk := newDedupManager(client)
+
+		path, deduplicated, err := manager.PutDeduped(ctx, "avatars/alice.png", strings.NewReader("same avatar"))
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/alice.png", path)
+		assert.False(t, deduplicated)
+
+		path, deduplicated, err = manager.PutDeduped(ctx, "avatars/bob.png", strings.NewReader("same avatar"))
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/alice.png", path)
+		assert.True(t, deduplicated)
+
+		assert.Len(t, disk.files, 1)
+		assert.Equal(t, []byte("same avatar"), disk.files["avatars/alice.png"])
+	})
+
+	t.Run("should store different content separately", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		manager, disk := newDedupManager(client)
+
+		_, _, err := manager.PutDeduped(ctx, "avatars/alice.png", strings.NewReader("alice"))
+		require.NoError(t, err)
+		path, deduplicated, err := manager.PutDeduped(ctx, "avatars/bob.png", strings.NewReader("bob"))
+		require.NoError(t, err)
+
+		assert.Equal(t, "avatars/bob.png", path)
+		assert.False(t, deduplicated)
+		assert.Len(t, disk.files, 2)
+	})
+
+	t.Run("should store again when the indexed file is gone", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		manager, disk := newDedupManager(client)
+		_, _, err := manager.PutDeduped(ctx, "avatars/alice.png", strings.NewReader("same avatar"))
+		require.NoError(t, err)
+		require.NoError(t, disk.Delete(ctx, "avatars/alice.png"))
+
+		path, deduplicated, err := manager.PutDeduped(ctx, "avatars/bob.png", strings.NewReader("same avatar"))
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/bob.png", path)
+		assert.False(t, deduplicated)
+
+		path, deduplicated, err = manager.PutDeduped(ctx, "avatars/carol.png", strings.NewReader("same avatar"))
+		require.NoError(t, err)
+		assert.Equal(t, "avatars/bob.png", path)
+		assert.True(t, deduplicated)
+	})
+}
+
+func TestManagerCleanDeduplicationIndex(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should remove entries of deleted files", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		manager, disk := newDedupManager(client)
+		for _, name := range []string{"a", "b", "c"} {
+			_, _, err := manager.PutDeduped(ctx, "files/"+name, strings.NewReader(name))
+			require.NoError(t, err)
+		}
+		require.NoError(t, disk.Delete(ctx, "files/a"))
+		require.NoError(t, disk.Delete(ctx, "files/c"))
+
+		removed, err := manager.CleanDeduplicationIndex(ctx)
+		require.NoError(t, err)
+		assert.Equal(t, 2, removed)
+
+		keys, err := client.Keys(ctx, dedupKeyPrefix+"*").Result()
+		require.NoError(t, err)
+		assert.Len(t, keys, 1)
+	})
+}
+
+func TestManagerDeduplicationDisabled(t *testing.T) {
+	manager := &Manager{drivers: map[string]Storage{"local": NewMockStorage("local")}, defaultDisk: "local"}
+
+	_, _, err := manager.PutDeduped(context.Background(), "a", strings.NewReader("a"))
+	assert.ErrorIs(t, err, ErrDeduplicationDisabled)
+
+	_, err = manager.CleanDeduplicationIndex(context.Background())
+	assert.ErrorIs(t, err, ErrDeduplicationDisabled)
+}
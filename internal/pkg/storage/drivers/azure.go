@@ -0,0 +1,379 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/bloberror"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blockblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/container"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// azureCopyPollInterval is how often Copy checks on a copy the service has
+// not finished synchronously
+const azureCopyPollInterval = 500 * time.Millisecond
+
+// AzureDriver implements the Storage interface for Azure Blob Storage
+type AzureDriver struct {
+	client *container.Client
+}
+
+// NewAzureDriver creates a new Azure Blob Storage driver for the container
+// of the config, authenticated with the storage account's shared key
+func NewAzureDriver(cfg *config.AzureConfig) (*AzureDriver, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("Azure config cannot be nil")
+	}
+	if cfg.Account == "" || cfg.Key == "" {
+		return nil, fmt.Errorf("account and key are required for Azure Blob Storage")
+	}
+	if cfg.Container == "" {
+		return nil, fmt.Errorf("container is required for Azure Blob Storage")
+	}
+
+	credential, err := container.NewSharedKeyCredential(cfg.Account, cfg.Key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Azure storage credentials: %w", err)
+	}
+
+	containerURL := fmt.Sprintf("https://%s.blob.core.windows.net/%s", cfg.Account, cfg.Container)
+	client, err := container.NewClientWithSharedKeyCredential(containerURL, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Azure Blob Storage client: %w", err)
+	}
+
+	return &AzureDriver{client: client}, nil
+}
+
+// Put stores content at the given path
+func (d *AzureDriver) Put(ctx context.Context, path string, content io.Reader) error {
+	if err := d.write(ctx, path, content, "", 0); err != nil {
+		return storage.NewStorageError("put", path, err)
+	}
+
+	return nil
+}
+
+// PutChunked stores content as a block blob staged chunkSize bytes per block
+func (d *AzureDriver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = storage.DefaultChunkSize
+	}
+
+	if err := d.write(ctx, path, content, "", chunkSize); err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	return nil
+}
+
+// PutFile stores an uploaded file at the given path
+func (d *AzureDriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return storage.NewStorageError("putFile", path, err)
+	}
+	defer src.Close()
+
+	if err := d.write(ctx, path, src, fileHeader.Header.Get("Content-Type"), 0); err != nil {
+		return storage.NewStorageError("putFile", path, err)
+	}
+
+	return nil
+}
+
+// write uploads content as a block blob, sniffing the content type when none
+// is given. A chunkSize of 0 keeps the client's default block size.
+func (d *AzureDriver) write(ctx context.Context, path string, content io.Reader, contentType string, chunkSize int64) error {
+	if contentType == "" {
+		buffer := make([]byte, 512)
+		n, err := io.ReadFull(content, buffer)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		contentType = http.DetectContentType(buffer[:n])
+		content = io.MultiReader(bytes.NewReader(buffer[:n]), content)
+	}
+
+	_, err := d.blockBlob(path).UploadStream(ctx, content, &blockblob.UploadStreamOptions{
+		BlockSize:   chunkSize,
+		HTTPHeaders: &blob.HTTPHeaders{BlobContentType: &contentType},
+	})
+	return err
+}
+
+// Get retrieves content from the given path
+func (d *AzureDriver) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	resp, err := d.blob(path).DownloadStream(ctx, nil)
+	if err != nil {
+		return nil, storage.NewStorageError("get", path, azureError(err))
+	}
+
+	return resp.Body, nil
+}
+
+// Delete removes the file at the given path
+func (d *AzureDriver) Delete(ctx context.Context, path string) error {
+	if _, err := d.blob(path).Delete(ctx, nil); err != nil {
+		return storage.NewStorageError("delete", path, azureError(err))
+	}
+
+	return nil
+}
+
+// Exists checks if file exists at the given path
+func (d *AzureDriver) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := d.blob(path).GetProperties(ctx, nil)
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, storage.NewStorageError("exists", path, err)
+	}
+
+	return true, nil
+}
+
+// Size returns the size of the file at the given path
+func (d *AzureDriver) Size(ctx context.Context, path string) (int64, error) {
+	props, err := d.blob(path).GetProperties(ctx, nil)
+	if err != nil {
+		return 0, storage.NewStorageError("size", path, azureError(err))
+	}
+
+	if props.ContentLength == nil {
+		return 0, nil
+	}
+	return *props.ContentLength, nil
+}
+
+// LastModified returns the last modification time of the file
+func (d *AzureDriver) LastModified(ctx context.Context, path string) (time.Time, error) {
+	props, err := d.blob(path).GetProperties(ctx, nil)
+	if err != nil {
+		return time.Time{}, storage.NewStorageError("lastModified", path, azureError(err))
+	}
+
+	if props.LastModified == nil {
+		return time.Time{}, nil
+	}
+	return *props.LastModified, nil
+}
+
+// MimeType returns the MIME type of the file
+func (d *AzureDriver) MimeType(ctx context.Context, path string) (string, error) {
+	props, err := d.blob(path).GetProperties(ctx, nil)
+	if err != nil {
+		return "", storage.NewStorageError("mimeType", path, azureError(err))
+	}
+
+	if props.ContentType != nil && *props.ContentType != "" {
+		return *props.ContentType, nil
+	}
+
+	return "application/octet-stream", nil
+}
+
+// Files returns all files in the given directory
+func (d *AzureDriver) Files(ctx context.Context, directory string) ([]string, error) {
+	files, _, err := d.listHierarchy(ctx, directory)
+	if err != nil {
+		return nil, storage.NewStorageError("files", directory, err)
+	}
+
+	return files, nil
+}
+
+// AllFiles returns all files in the directory recursively
+func (d *AzureDriver) AllFiles(ctx context.Context, directory string) ([]string, error) {
+	prefix := azurePrefix(directory)
+
+	var files []string
+	pager := d.client.NewListBlobsFlatPager(&container.ListBlobsFlatOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, storage.NewStorageError("allFiles", directory, err)
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil && *item.Name != prefix { // Exclude directory itself
+				files = append(files, *item.Name)
+			}
+		}
+	}
+
+	return files, nil
+}
+
+// Directories returns all directories in the given path
+func (d *AzureDriver) Directories(ctx context.Context, directory string) ([]string, error) {
+	_, directories, err := d.listHierarchy(ctx, directory)
+	if err != nil {
+		return nil, storage.NewStorageError("directories", directory, err)
+	}
+
+	return directories, nil
+}
+
+// listHierarchy lists the blobs directly under the directory prefix and its
+// subdirectories, using "/" as the delimiter
+func (d *AzureDriver) listHierarchy(ctx context.Context, directory string) (files, directories []string, err error) {
+	prefix := azurePrefix(directory)
+
+	pager := d.client.NewListBlobsHierarchyPager("/", &container.ListBlobsHierarchyOptions{Prefix: &prefix})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		for _, item := range page.Segment.BlobItems {
+			if item.Name != nil && *item.Name != prefix { // Exclude directory itself
+				files = append(files, *item.Name)
+			}
+		}
+		for _, item := range page.Segment.BlobPrefixes {
+			if item.Name != nil {
+				directories = append(directories, strings.TrimSuffix(*item.Name, "/"))
+			}
+		}
+	}
+
+	return files, directories, nil
+}
+
+// MakeDirectory creates a directory at the given path (Azure doesn't have real directories)
+func (d *AzureDriver) MakeDirectory(ctx context.Context, path string) error {
+	// Azure doesn't have real directories, they're created implicitly when blobs are uploaded
+	return nil
+}
+
+// DeleteDirectory removes the directory at the given path
+func (d *AzureDriver) DeleteDirectory(ctx context.Context, directory string) error {
+	files, err := d.AllFiles(ctx, directory)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := d.Delete(ctx, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// URL returns the public URL for the given path
+func (d *AzureDriver) URL(ctx context.Context, path string) (string, error) {
+	segments := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+
+	return d.client.URL() + "/" + strings.Join(segments, "/"), nil
+}
+
+// TemporaryURL returns a read-only URL carrying a SAS token signed with the
+// account key that expires after the given duration
+func (d *AzureDriver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
+	signed, err := d.blob(path).GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiration), nil)
+	if err != nil {
+		return "", storage.NewStorageError("temporaryURL", path, err)
+	}
+
+	return signed, nil
+}
+
+// Copy copies a file from source to destination, waiting for the service to
+// finish the copy
+func (d *AzureDriver) Copy(ctx context.Context, from, to string) error {
+	resp, err := d.blob(to).StartCopyFromURL(ctx, d.blob(from).URL(), nil)
+	if err != nil {
+		return storage.NewStorageError("copy", from, azureError(err))
+	}
+
+	status := resp.CopyStatus
+	for status != nil && *status == blob.CopyStatusTypePending {
+		select {
+		case <-ctx.Done():
+			return storage.NewStorageError("copy", from, ctx.Err())
+		case <-time.After(azureCopyPollInterval):
+		}
+
+		props, err := d.blob(to).GetProperties(ctx, nil)
+		if err != nil {
+			return storage.NewStorageError("copy", from, err)
+		}
+		status = props.CopyStatus
+	}
+
+	if status != nil && *status != blob.CopyStatusTypeSuccess {
+		return storage.NewStorageError("copy", from, fmt.Errorf("copy ended with status %s", *status))
+	}
+
+	return nil
+}
+
+// Move moves a file from source to destination
+func (d *AzureDriver) Move(ctx context.Context, from, to string) error {
+	if err := d.Copy(ctx, from, to); err != nil {
+		return err
+	}
+
+	if err := d.Delete(ctx, from); err != nil {
+		// If delete fails, try to clean up the copied file
+		d.Delete(ctx, to)
+		return err
+	}
+
+	return nil
+}
+
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *AzureDriver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
+// Driver returns the driver name
+func (d *AzureDriver) Driver() string {
+	return "azure"
+}
+
+func (d *AzureDriver) blob(path string) *blob.Client {
+	return d.client.NewBlobClient(strings.TrimPrefix(path, "/"))
+}
+
+func (d *AzureDriver) blockBlob(path string) *blockblob.Client {
+	return d.client.NewBlockBlobClient(strings.TrimPrefix(path, "/"))
+}
+
+// azurePrefix turns a directory into the blob name prefix listing it
+func azurePrefix(directory string) string {
+	if directory == "" || directory == "." {
+		return ""
+	}
+	return strings.Trim(directory, "/") + "/"
+}
+
+// azureError reports missing blobs the way the other drivers do
+func azureError(err error) error {
+	if bloberror.HasCode(err, bloberror.BlobNotFound) {
+		return fmt.Errorf("file not found: %w", err)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package drivers
+
+import (
+	"context"
+	"encoding/base64"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+func TestAzureDriver(t *testing.T) {
+	ctx := context.Background()
+	validConfig := func() *config.AzureConfig {
+		return &config.AzureConfig{
+			Account:   "myaccount",
+			Key:       base64.StdEncoding.EncodeToString([]byte("account-key")),
+			Container: "uploads",
+		}
+	}
+
+	t.Run("should reject incomplete config", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(cfg *config.AzureConfig)
+			err    string
+		}{
+			{"missing account", func(cfg *config.AzureConfig) { cfg.Account = "" }, "account and key are required"},
+			{"missing key", func(cfg *config.AzureConfig) { cfg.Key = "" }, "account and key are required"},
+			{"missing container", func(cfg *config.AzureConfig) { cfg.Container = "" }, "container is required"},
+			{"malformed key", func(cfg *config.AzureConfig) { cfg.Key = "not base64!" }, "invalid Azure storage credentials"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := validConfig()
+				tt.modify(cfg)
+
+				_, err := NewAzureDriver(cfg)
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("should build blob URLs in the container", func(t *testing.T) {
+		driver, err := NewAzureDriver(validConfig())
+		require.NoError(t, err)
+
+		url, err := driver.URL(ctx, "/avatars/me.png")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://myaccount.blob.core.windows.net/uploads/avatars/me.png", url)
+		assert.Equal(t, "azure", driver.Driver())
+	})
+
+	t.Run("should sign read-only SAS URLs with the requested expiry", func(t *testing.T) {
+		driver, err := NewAzureDriver(validConfig())
+		require.NoError(t, err)
+
+		expiry := time.Now().Add(15 * time.Minute).UTC()
+		signed, err := driver.TemporaryURL(ctx, "avatars/me.png", 15*time.Minute)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "/uploads/avatars/me.png", parsed.Path)
+
+		query := parsed.Query()
+		assert.Equal(t, "r", query.Get("sp"))
+		assert.NotEmpty(t, query.Get("sig"))
+
+		expiresAt, err := time.Parse(time.RFC3339, query.Get("se"))
+		require.NoError(t, err)
+		assert.WithinDuration(t, expiry, expiresAt, 2*time.Second)
+	})
+
+	t.Run("should list directories by prefix", func(t *testing.T) {
+		assert.Equal(t, "", azurePrefix(""))
+		assert.Equal(t, "", azurePrefix("."))
+		assert.Equal(t, "avatars/", azurePrefix("avatars"))
+		assert.Equal(t, "avatars/2024/", azurePrefix("/avatars/2024/"))
+	})
+}
@@ -1,428 +1,279 @@
 package drivers
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
+	"sync"
 	"time"
 
-	"github.com/aws/aws-sdk-go/aws"
-	"github.com/aws/aws-sdk-go/aws/awserr"
-	"github.com/aws/aws-sdk-go/aws/credentials"
-	"github.com/aws/aws-sdk-go/aws/session"
-	"github.com/aws/aws-sdk-go/service/s3"
-	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+const (
+	// b2AuthorizeURL authorizes the application key against the B2 native
+	// API, which issues the download authorizations of temporary URLs
+	b2AuthorizeURL = "https://api.backblazeb2.com/b2api/v2/b2_authorize_account"
+
+	// b2AuthorizationTTL is how long an account authorization is reused, B2
+	// expires them after 24 hours
+	b2AuthorizationTTL = 23 * time.Hour
+
+	// b2MaxDownloadAuthorization is the longest download authorization B2
+	// issues
+	b2MaxDownloadAuthorization = 7 * 24 * time.Hour
 )
 
 // BackblazeB2Driver implements the Storage interface for Backblaze B2
-// Backblaze B2 offers 10GB of free storage + 1GB free download per day
-// Compatible with S3 API through their S3-compatible endpoint
+// through its S3-compatible API at s3.<region>.backblazeb2.com. Temporary
+// URLs are signed with download authorizations of the B2 native API.
 type BackblazeB2Driver struct {
-	client     *s3.S3
-	uploader   *s3manager.Uploader
-	downloader *s3manager.Downloader
+	*S3Driver
+	keyID      string
+	keySecret  string
 	bucket     string
-	region     string
-	publicURL  string
-}
+	authURL    string
+	httpClient *http.Client
 
-// BackblazeB2Config holds the configuration for Backblaze B2 driver
-type BackblazeB2Config struct {
-	Region    string // B2 region (e.g., "us-west-000", "eu-central-003")
-	KeyID     string // Application Key ID
-	KeySecret string // Application Key
-	Bucket    string // Bucket name
-	PublicURL string // Custom domain for B2 bucket (optional)
+	mu            sync.Mutex
+	authorization *b2Authorization
 }
 
-// NewBackblazeB2Driver creates a new Backblaze B2 storage driver
-func NewBackblazeB2Driver(config BackblazeB2Config) (*BackblazeB2Driver, error) {
-	if config.Region == "" {
-		return nil, fmt.Errorf("region is required for Backblaze B2")
-	}
-
-	// Backblaze B2 S3-compatible endpoint format
-	endpoint := fmt.Sprintf("https://s3.%s.backblazeb2.com", config.Region)
-
-	// Create AWS session configured for Backblaze B2
-	sess, err := session.NewSession(&aws.Config{
-		Region:           aws.String(config.Region),
-		Credentials:      credentials.NewStaticCredentials(config.KeyID, config.KeySecret, ""),
-		Endpoint:         aws.String(endpoint),
-		S3ForcePathStyle: aws.Bool(false), // B2 uses virtual-hosted style
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create Backblaze B2 session: %w", err)
-	}
-
-	// Create S3 client
-	client := s3.New(sess)
-
-	// Generate public URL
-	publicURL := config.PublicURL
-	if publicURL == "" {
-		// Default B2 public URL format (when bucket is set to public)
-		publicURL = fmt.Sprintf("https://f000.backblazeb2.com/file/%s", config.Bucket)
-	}
-
-	driver := &BackblazeB2Driver{
-		client:     client,
-		uploader:   s3manager.NewUploader(sess),
-		downloader: s3manager.NewDownloader(sess),
-		bucket:     config.Bucket,
-		region:     config.Region,
-		publicURL:  publicURL,
-	}
-
-	return driver, nil
+// b2Authorization is the result of b2_authorize_account
+type b2Authorization struct {
+	AccountID          string `json:"accountId"`
+	AuthorizationToken string `json:"authorizationToken"`
+	APIURL             string `json:"apiUrl"`
+	DownloadURL        string `json:"downloadUrl"`
+	Allowed            struct {
+		BucketID   string `json:"bucketId"`
+		BucketName string `json:"bucketName"`
+	} `json:"allowed"`
+
+	bucketID  string
+	expiresAt time.Time
 }
 
-// Put stores content at the given path
-func (d *BackblazeB2Driver) Put(ctx context.Context, path string, content io.Reader) error {
-	var contentType string
-	if seeker, ok := content.(io.ReadSeeker); ok {
-		buffer := make([]byte, 512)
-		n, _ := seeker.Read(buffer)
-		contentType = http.DetectContentType(buffer[:n])
-		seeker.Seek(0, io.SeekStart)
-	}
-
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-
-	input := &s3manager.UploadInput{
-		Bucket:      aws.String(d.bucket),
-		Key:         aws.String(path),
-		Body:        content,
-		ContentType: aws.String(contentType),
-		ACL:         aws.String("public-read"), // Make files publicly accessible
-	}
-
-	_, err := d.uploader.UploadWithContext(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed to put file %s: %w", path, err)
-	}
+// b2Error is the body of a failed B2 native API call
+type b2Error struct {
+	Status  int    `json:"status"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
 
-	return nil
+func (e *b2Error) Error() string {
+	return fmt.Sprintf("b2 %s (%d): %s", e.Code, e.Status, e.Message)
 }
 
-// PutFile stores an uploaded file at the given path
-func (d *BackblazeB2Driver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
-	src, err := fileHeader.Open()
-	if err != nil {
-		return fmt.Errorf("failed to putFile %s: %w", path, err)
+// NewBackblazeB2Driver creates a new Backblaze B2 storage driver
+// authenticated with the application key of the config
+func NewBackblazeB2Driver(cfg *config.BackblazeB2Config) (*BackblazeB2Driver, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("Backblaze B2 config cannot be nil")
 	}
-	defer src.Close()
-
-	contentType := fileHeader.Header.Get("Content-Type")
-	if contentType == "" {
-		buffer := make([]byte, 512)
-		n, _ := src.Read(buffer)
-		contentType = http.DetectContentType(buffer[:n])
-		src.Close()
-		src, _ = fileHeader.Open()
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("region is required for Backblaze B2")
 	}
-
-	input := &s3manager.UploadInput{
-		Bucket:      aws.String(d.bucket),
-		Key:         aws.String(path),
-		Body:        src,
-		ContentType: aws.String(contentType),
-		ACL:         aws.String("public-read"),
+	if cfg.KeyID == "" || cfg.KeySecret == "" {
+		return nil, fmt.Errorf("key ID and key secret are required for Backblaze B2")
 	}
-
-	_, err = d.uploader.UploadWithContext(ctx, input)
-	if err != nil {
-		return fmt.Errorf("failed to putFile %s: %w", path, err)
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required for Backblaze B2")
 	}
 
-	return nil
-}
-
-// Get retrieves content from the given path
-func (d *BackblazeB2Driver) Get(ctx context.Context, path string) (io.ReadCloser, error) {
-	input := &s3.GetObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
+	// Files of public buckets are served from the virtual-hosted endpoint
+	publicURL := cfg.PublicURL
+	if publicURL == "" {
+		publicURL = fmt.Sprintf("https://%s.s3.%s.backblazeb2.com", cfg.Bucket, cfg.Region)
 	}
 
-	result, err := d.client.GetObjectWithContext(ctx, input)
+	s3Driver, err := NewS3Driver(S3Config{
+		Region:    cfg.Region,
+		Bucket:    cfg.Bucket,
+		AccessKey: cfg.KeyID,
+		SecretKey: cfg.KeySecret,
+		UseSSL:    true,
+		Endpoint:  fmt.Sprintf("s3.%s.backblazeb2.com", cfg.Region),
+		PublicURL: publicURL,
+	})
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == s3.ErrCodeNoSuchKey {
-			return nil, storage.NewStorageError("get", path, fmt.Errorf("file not found"))
-		}
-		return nil, storage.NewStorageError("get", path, err)
+		return nil, fmt.Errorf("failed to create Backblaze B2 client: %w", err)
 	}
 
-	return result.Body, nil
+	return &BackblazeB2Driver{
+		S3Driver:   s3Driver,
+		keyID:      cfg.KeyID,
+		keySecret:  cfg.KeySecret,
+		bucket:     cfg.Bucket,
+		authURL:    b2AuthorizeURL,
+		httpClient: &http.Client{Timeout: 30 * time.Second},
+	}, nil
 }
 
-// Delete removes the file at the given path
-func (d *BackblazeB2Driver) Delete(ctx context.Context, path string) error {
-	input := &s3.DeleteObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
+// TemporaryURL returns a download URL carrying a B2 download authorization
+// for the file that expires after the given duration, at most a week
+func (d *BackblazeB2Driver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
+	if expiration < time.Second || expiration > b2MaxDownloadAuthorization {
+		return "", storage.NewStorageError("temporaryURL", path, fmt.Errorf("expiration must be between 1s and %s", b2MaxDownloadAuthorization))
 	}
 
-	_, err := d.client.DeleteObjectWithContext(ctx, input)
+	fileName := strings.TrimPrefix(path, "/")
+	auth, token, err := d.downloadAuthorization(ctx, fileName, expiration)
 	if err != nil {
-		return storage.NewStorageError("delete", path, err)
+		return "", storage.NewStorageError("temporaryURL", path, err)
 	}
 
-	return nil
+	return fmt.Sprintf("%s/file/%s/%s?Authorization=%s",
+		strings.TrimSuffix(auth.DownloadURL, "/"), d.bucket, escapeFileName(fileName), url.QueryEscape(token)), nil
 }
 
-// Exists checks if file exists at the given path
-func (d *BackblazeB2Driver) Exists(ctx context.Context, path string) (bool, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
-	}
-
-	_, err := d.client.HeadObjectWithContext(ctx, input)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
-			return false, nil
-		}
-		return false, storage.NewStorageError("exists", path, err)
-	}
-
-	return true, nil
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *BackblazeB2Driver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
 }
 
-// Size returns the size of the file at the given path
-func (d *BackblazeB2Driver) Size(ctx context.Context, path string) (int64, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
-	}
-
-	result, err := d.client.HeadObjectWithContext(ctx, input)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
-			return 0, storage.NewStorageError("size", path, fmt.Errorf("file not found"))
-		}
-		return 0, storage.NewStorageError("size", path, err)
-	}
-
-	if result.ContentLength != nil {
-		return *result.ContentLength, nil
-	}
-
-	return 0, nil
+// Driver returns the driver name
+func (d *BackblazeB2Driver) Driver() string {
+	return "b2"
 }
 
-// LastModified returns the last modification time of the file
-func (d *BackblazeB2Driver) LastModified(ctx context.Context, path string) (time.Time, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
+// downloadAuthorization asks B2 for a token allowing downloads of fileName,
+// authorizing the account again once if the cached authorization expired
+func (d *BackblazeB2Driver) downloadAuthorization(ctx context.Context, fileName string, expiration time.Duration) (*b2Authorization, string, error) {
+	request := map[string]interface{}{
+		"fileNamePrefix":         fileName,
+		"validDurationInSeconds": int(expiration.Seconds()),
 	}
 
-	result, err := d.client.HeadObjectWithContext(ctx, input)
-	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok && aerr.Code() == "NotFound" {
-			return time.Time{}, storage.NewStorageError("lastModified", path, fmt.Errorf("file not found"))
+	for attempt := 0; ; attempt++ {
+		auth, err := d.authorize(ctx)
+		if err != nil {
+			return nil, "", err
 		}
-		return time.Time{}, storage.NewStorageError("lastModified", path, err)
-	}
+		request["bucketId"] = auth.bucketID
 
-	if result.LastModified != nil {
-		return *result.LastModified, nil
-	}
-
-	return time.Time{}, nil
-}
-
-// MimeType returns the MIME type of the file
-func (d *BackblazeB2Driver) MimeType(ctx context.Context, path string) (string, error) {
-	input := &s3.HeadObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
-	}
-
-	result, err := d.client.HeadObjectWithContext(ctx, input)
-	if err != nil {
-		return "", storage.NewStorageError("mimeType", path, err)
-	}
-
-	if result.ContentType != nil {
-		return *result.ContentType, nil
+		var response struct {
+			AuthorizationToken string `json:"authorizationToken"`
+		}
+		err = d.call(ctx, auth.APIURL+"/b2api/v2/b2_get_download_authorization", auth.AuthorizationToken, request, &response)
+		if b2Err, ok := err.(*b2Error); ok && b2Err.Status == http.StatusUnauthorized && attempt == 0 {
+			d.resetAuthorization(auth)
+			continue
+		}
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to get download authorization: %w", err)
+		}
+		return auth, response.AuthorizationToken, nil
 	}
-
-	return "application/octet-stream", nil
 }
 
-// Files returns all files in the given directory
-func (d *BackblazeB2Driver) Files(ctx context.Context, directory string) ([]string, error) {
-	prefix := strings.TrimSuffix(directory, "/") + "/"
-	if directory == "" || directory == "." {
-		prefix = ""
-	}
+// authorize returns the cached account authorization, authorizing the
+// application key and looking up the bucket ID when it expired
+func (d *BackblazeB2Driver) authorize(ctx context.Context) (*b2Authorization, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	input := &s3.ListObjectsV2Input{
-		Bucket:    aws.String(d.bucket),
-		Prefix:    aws.String(prefix),
-		Delimiter: aws.String("/"),
+	if d.authorization != nil && time.Now().Before(d.authorization.expiresAt) {
+		return d.authorization, nil
 	}
 
-	var files []string
-	err := d.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		for _, obj := range page.Contents {
-			if obj.Key != nil && *obj.Key != prefix {
-				files = append(files, *obj.Key)
-			}
-		}
-		return !lastPage
-	})
-
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.authURL, nil)
 	if err != nil {
-		return nil, storage.NewStorageError("files", directory, err)
-	}
-
-	return files, nil
-}
-
-// AllFiles returns all files in the directory recursively
-func (d *BackblazeB2Driver) AllFiles(ctx context.Context, directory string) ([]string, error) {
-	prefix := strings.TrimSuffix(directory, "/") + "/"
-	if directory == "" || directory == "." {
-		prefix = ""
+		return nil, err
 	}
+	req.SetBasicAuth(d.keyID, d.keySecret)
 
-	input := &s3.ListObjectsV2Input{
-		Bucket: aws.String(d.bucket),
-		Prefix: aws.String(prefix),
+	var auth b2Authorization
+	if err := d.do(req, &auth); err != nil {
+		return nil, fmt.Errorf("failed to authorize Backblaze B2 account: %w", err)
 	}
+	auth.expiresAt = time.Now().Add(b2AuthorizationTTL)
 
-	var files []string
-	err := d.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		for _, obj := range page.Contents {
-			if obj.Key != nil && *obj.Key != prefix {
-				files = append(files, *obj.Key)
-			}
+	// Keys restricted to a bucket name it, others have to look it up
+	auth.bucketID = auth.Allowed.BucketID
+	if auth.bucketID == "" || auth.Allowed.BucketName != d.bucket {
+		var response struct {
+			Buckets []struct {
+				BucketID string `json:"bucketId"`
+			} `json:"buckets"`
 		}
-		return !lastPage
-	})
-
-	if err != nil {
-		return nil, storage.NewStorageError("allFiles", directory, err)
+		err := d.call(ctx, auth.APIURL+"/b2api/v2/b2_list_buckets", auth.AuthorizationToken, map[string]string{
+			"accountId":  auth.AccountID,
+			"bucketName": d.bucket,
+		}, &response)
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up bucket %s: %w", d.bucket, err)
+		}
+		if len(response.Buckets) == 0 {
+			return nil, fmt.Errorf("bucket %s not found", d.bucket)
+		}
+		auth.bucketID = response.Buckets[0].BucketID
 	}
 
-	return files, nil
+	d.authorization = &auth
+	return d.authorization, nil
 }
 
-// Directories returns all directories in the given path
-func (d *BackblazeB2Driver) Directories(ctx context.Context, directory string) ([]string, error) {
-	prefix := strings.TrimSuffix(directory, "/") + "/"
-	if directory == "" || directory == "." {
-		prefix = ""
-	}
-
-	input := &s3.ListObjectsV2Input{
-		Bucket:    aws.String(d.bucket),
-		Prefix:    aws.String(prefix),
-		Delimiter: aws.String("/"),
-	}
-
-	var directories []string
-	err := d.client.ListObjectsV2PagesWithContext(ctx, input, func(page *s3.ListObjectsV2Output, lastPage bool) bool {
-		for _, commonPrefix := range page.CommonPrefixes {
-			if commonPrefix.Prefix != nil {
-				dir := strings.TrimSuffix(*commonPrefix.Prefix, "/")
-				directories = append(directories, dir)
-			}
-		}
-		return !lastPage
-	})
+// resetAuthorization drops auth from the cache unless it was replaced already
+func (d *BackblazeB2Driver) resetAuthorization(auth *b2Authorization) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
 
-	if err != nil {
-		return nil, storage.NewStorageError("directories", directory, err)
+	if d.authorization == auth {
+		d.authorization = nil
 	}
-
-	return directories, nil
 }
 
-// MakeDirectory creates a directory at the given path
-func (d *BackblazeB2Driver) MakeDirectory(ctx context.Context, path string) error {
-	return nil // B2 creates directories implicitly
-}
-
-// DeleteDirectory removes the directory at the given path
-func (d *BackblazeB2Driver) DeleteDirectory(ctx context.Context, directory string) error {
-	files, err := d.AllFiles(ctx, directory)
+// call posts body as JSON to a B2 native API endpoint
+func (d *BackblazeB2Driver) call(ctx context.Context, endpoint, token string, body, result interface{}) error {
+	payload, err := json.Marshal(body)
 	if err != nil {
 		return err
 	}
 
-	for _, file := range files {
-		if err := d.Delete(ctx, file); err != nil {
-			return err
-		}
-	}
-
-	return nil
-}
-
-// URL returns the public URL for the given path
-func (d *BackblazeB2Driver) URL(ctx context.Context, path string) (string, error) {
-	cleanPath := strings.TrimPrefix(path, "/")
-	return fmt.Sprintf("%s/%s", strings.TrimSuffix(d.publicURL, "/"), cleanPath), nil
-}
-
-// TemporaryURL returns a temporary signed URL
-func (d *BackblazeB2Driver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
-	req, _ := d.client.GetObjectRequest(&s3.GetObjectInput{
-		Bucket: aws.String(d.bucket),
-		Key:    aws.String(path),
-	})
-
-	url, err := req.Presign(expiration)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
 	if err != nil {
-		return "", storage.NewStorageError("temporaryURL", path, err)
+		return err
 	}
+	req.Header.Set("Authorization", token)
+	req.Header.Set("Content-Type", "application/json")
 
-	return url, nil
+	return d.do(req, result)
 }
 
-// Copy copies a file from source to destination
-func (d *BackblazeB2Driver) Copy(ctx context.Context, from, to string) error {
-	source := fmt.Sprintf("%s/%s", d.bucket, from)
-
-	input := &s3.CopyObjectInput{
-		Bucket:     aws.String(d.bucket),
-		CopySource: aws.String(source),
-		Key:        aws.String(to),
-		ACL:        aws.String("public-read"),
-	}
-
-	_, err := d.client.CopyObjectWithContext(ctx, input)
+// do sends req and decodes the JSON response into result, or the B2 error
+func (d *BackblazeB2Driver) do(req *http.Request, result interface{}) error {
+	resp, err := d.httpClient.Do(req)
 	if err != nil {
-		return storage.NewStorageError("copy", from, err)
-	}
-
-	return nil
-}
-
-// Move moves a file from source to destination
-func (d *BackblazeB2Driver) Move(ctx context.Context, from, to string) error {
-	if err := d.Copy(ctx, from, to); err != nil {
 		return err
 	}
+	defer resp.Body.Close()
 
-	if err := d.Delete(ctx, from); err != nil {
-		d.Delete(ctx, to) // Cleanup on failure
-		return err
+	if resp.StatusCode != http.StatusOK {
+		b2Err := &b2Error{Status: resp.StatusCode, Code: "unknown"}
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		if err := json.Unmarshal(body, b2Err); err != nil {
+			b2Err.Message = strings.TrimSpace(string(body))
+		}
+		b2Err.Status = resp.StatusCode
+		return b2Err
 	}
 
-	return nil
+	return json.NewDecoder(resp.Body).Decode(result)
 }
 
-// Driver returns the driver name
-func (d *BackblazeB2Driver) Driver() string {
-	return "backblaze_b2"
+// escapeFileName escapes each segment of a B2 file name for a download URL
+func escapeFileName(name string) string {
+	segments := strings.Split(name, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
 }
@@ -0,0 +1,164 @@
+package drivers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// fakeB2 serves the B2 native API calls TemporaryURL makes
+type fakeB2 struct {
+	*httptest.Server
+	authorizations int
+	expired        bool
+	lastRequest    map[string]interface{}
+}
+
+func newFakeB2(t *testing.T) *fakeB2 {
+	b2 := &fakeB2{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/b2api/v2/b2_authorize_account", func(w http.ResponseWriter, r *http.Request) {
+		if id, secret, ok := r.BasicAuth(); !ok || id != "key-id" || secret != "key-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(b2Error{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "bad key"})
+			return
+		}
+		b2.authorizations++
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"accountId":          "account",
+			"authorizationToken": "account-token",
+			"apiUrl":             b2.URL,
+			"downloadUrl":        "https://f003.backblazeb2.com",
+		})
+	})
+	mux.HandleFunc("/b2api/v2/b2_list_buckets", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"buckets": []map[string]string{{"bucketId": "bucket-id"}},
+		})
+	})
+	mux.HandleFunc("/b2api/v2/b2_get_download_authorization", func(w http.ResponseWriter, r *http.Request) {
+		if b2.expired {
+			b2.expired = false
+			w.WriteHeader(http.StatusUnauthorized)
+			json.NewEncoder(w).Encode(b2Error{Status: http.StatusUnauthorized, Code: "expired_auth_token", Message: "expired"})
+			return
+		}
+		require.Equal(t, "account-token", r.Header.Get("Authorization"))
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&b2.lastRequest))
+		json.NewEncoder(w).Encode(map[string]string{"authorizationToken": "download+token"})
+	})
+	b2.Server = httptest.NewServer(mux)
+	t.Cleanup(b2.Close)
+
+	return b2
+}
+
+func TestBackblazeB2Driver(t *testing.T) {
+	ctx := context.Background()
+	validConfig := func() *config.BackblazeB2Config {
+		return &config.BackblazeB2Config{
+			Region:    "us-west-004",
+			KeyID:     "key-id",
+			KeySecret: "key-secret",
+			Bucket:    "uploads",
+		}
+	}
+
+	t.Run("should reject incomplete config", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(cfg *config.BackblazeB2Config)
+			err    string
+		}{
+			{"missing region", func(cfg *config.BackblazeB2Config) { cfg.Region = "" }, "region is required"},
+			{"missing key ID", func(cfg *config.BackblazeB2Config) { cfg.KeyID = "" }, "key ID and key secret are required"},
+			{"missing key secret", func(cfg *config.BackblazeB2Config) { cfg.KeySecret = "" }, "key ID and key secret are required"},
+			{"missing bucket", func(cfg *config.BackblazeB2Config) { cfg.Bucket = "" }, "bucket is required"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := validConfig()
+				tt.modify(cfg)
+
+				_, err := NewBackblazeB2Driver(cfg)
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("should use the regional S3-compatible endpoint", func(t *testing.T) {
+		driver, err := NewBackblazeB2Driver(validConfig())
+		require.NoError(t, err)
+
+		url, err := driver.URL(ctx, "/avatars/me.png")
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://uploads.s3.us-west-004.backblazeb2.com/avatars/me.png", url)
+		assert.Equal(t, "https://s3.us-west-004.backblazeb2.com", driver.client.Endpoint)
+		assert.Equal(t, "b2", driver.Driver())
+	})
+
+	t.Run("should sign temporary URLs with a download authorization", func(t *testing.T) {
+		b2 := newFakeB2(t)
+		driver, err := NewBackblazeB2Driver(validConfig())
+		require.NoError(t, err)
+		driver.authURL = b2.URL + "/b2api/v2/b2_authorize_account"
+
+		url, err := driver.TemporaryURL(ctx, "/reports/q1 2024.pdf", time.Hour)
+
+		require.NoError(t, err)
+		assert.Equal(t, "https://f003.backblazeb2.com/file/uploads/reports/q1%202024.pdf?Authorization=download%2Btoken", url)
+		assert.Equal(t, "bucket-id", b2.lastRequest["bucketId"])
+		assert.Equal(t, "reports/q1 2024.pdf", b2.lastRequest["fileNamePrefix"])
+		assert.Equal(t, float64(3600), b2.lastRequest["validDurationInSeconds"])
+
+		_, err = driver.TemporaryURL(ctx, "reports/q2.pdf", time.Hour)
+		require.NoError(t, err)
+		assert.Equal(t, 1, b2.authorizations)
+	})
+
+	t.Run("should authorize again when the account token expired", func(t *testing.T) {
+		b2 := newFakeB2(t)
+		driver, err := NewBackblazeB2Driver(validConfig())
+		require.NoError(t, err)
+		driver.authURL = b2.URL + "/b2api/v2/b2_authorize_account"
+
+		_, err = driver.TemporaryURL(ctx, "a.txt", time.Minute)
+		require.NoError(t, err)
+		b2.expired = true
+
+		_, err = driver.TemporaryURL(ctx, "a.txt", time.Minute)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, b2.authorizations)
+	})
+
+	t.Run("should reject expirations B2 does not issue", func(t *testing.T) {
+		driver, err := NewBackblazeB2Driver(validConfig())
+		require.NoError(t, err)
+
+		_, err = driver.TemporaryURL(ctx, "a.txt", 8*24*time.Hour)
+		assert.ErrorContains(t, err, "expiration must be between")
+	})
+
+	t.Run("should fail with a rejected application key", func(t *testing.T) {
+		b2 := newFakeB2(t)
+		cfg := validConfig()
+		cfg.KeySecret = "wrong"
+		driver, err := NewBackblazeB2Driver(cfg)
+		require.NoError(t, err)
+		driver.authURL = b2.URL + "/b2api/v2/b2_authorize_account"
+
+		_, err = driver.TemporaryURL(ctx, "a.txt", time.Minute)
+		assert.ErrorContains(t, err, "failed to authorize Backblaze B2 account")
+	})
+}
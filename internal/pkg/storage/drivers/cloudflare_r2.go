@@ -112,6 +112,12 @@ func (d *CloudflareR2Driver) Put(ctx context.Context, path string, content io.Re
 	return nil
 }
 
+// PutChunked stores content in parts with the S3 multipart upload API R2 implements,
+// holding one chunk of chunkSize bytes in memory at a time
+func (d *CloudflareR2Driver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	return putMultipart(ctx, d.client, d.bucket, path, content, chunkSize, "public-read")
+}
+
 // PutFile stores an uploaded file at the given path
 func (d *CloudflareR2Driver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
 	src, err := fileHeader.Open()
@@ -424,6 +430,12 @@ func (d *CloudflareR2Driver) Move(ctx context.Context, from, to string) error {
 	return nil
 }
 
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *CloudflareR2Driver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
 // Driver returns the driver name
 func (d *CloudflareR2Driver) Driver() string {
 	return "cloudflare_r2"
@@ -0,0 +1,356 @@
+package drivers
+
+import (
+	"bufio"
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+const (
+	// MasterKeySize is the AES-256 key length in bytes
+	MasterKeySize = 32
+
+	// encryptedChunkSize is the plaintext size of each sealed chunk
+	encryptedChunkSize = 64 * 1024
+)
+
+var (
+	// ErrInvalidMasterKey is returned for keys that are not 32 bytes
+	ErrInvalidMasterKey = errors.New("storage master key must be 32 bytes")
+
+	// ErrEncryptedContent is returned when stored content was tampered
+	// with, truncated or encrypted with another key
+	ErrEncryptedContent = errors.New("encrypted content is corrupt or was encrypted with another key")
+
+	// ErrEncryptedURL is returned for URL requests, since a URL would serve
+	// the ciphertext
+	ErrEncryptedURL = errors.New("encrypted files cannot be served by URL")
+)
+
+// EncryptedDriver wraps any Storage and encrypts content with AES-256-GCM
+// before it reaches the underlying driver.
+//
+// Content is sealed in 64KB chunks so it can be streamed: the random IV is
+// written first, then every chunk with its own nonce (IV xor chunk index).
+// The last chunk is authenticated as final, so truncated files fail to
+// decrypt instead of silently returning less data.
+type EncryptedDriver struct {
+	storage.Storage
+	aead cipher.AEAD
+}
+
+// NewEncryptedDriver wraps underlying with transparent encryption
+func NewEncryptedDriver(underlying storage.Storage, masterKey []byte) (*EncryptedDriver, error) {
+	if len(masterKey) != MasterKeySize {
+		return nil, ErrInvalidMasterKey
+	}
+
+	block, err := aes.NewCipher(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCM: %w", err)
+	}
+
+	return &EncryptedDriver{
+		Storage: underlying,
+		aead:    aead,
+	}, nil
+}
+
+// Put encrypts content while the underlying driver stores it
+func (d *EncryptedDriver) Put(ctx context.Context, path string, content io.Reader) error {
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(d.encrypt(writer, content))
+	}()
+
+	err := d.Storage.Put(ctx, path, reader)
+	// Unblock the encrypting goroutine if the driver stopped reading early
+	reader.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+// PutChunked encrypts content while the underlying driver stores it in
+// chunks
+func (d *EncryptedDriver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	reader, writer := io.Pipe()
+
+	go func() {
+		writer.CloseWithError(d.encrypt(writer, content))
+	}()
+
+	err := d.Storage.PutChunked(ctx, path, reader, chunkSize)
+	// Unblock the encrypting goroutine if the driver stopped reading early
+	reader.CloseWithError(io.ErrClosedPipe)
+	return err
+}
+
+// PutFile encrypts and stores an uploaded file
+func (d *EncryptedDriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
+	file, err := fileHeader.Open()
+	if err != nil {
+		return storage.NewStorageError("putFile", path, err)
+	}
+	defer file.Close()
+
+	return d.Put(ctx, path, file)
+}
+
+// Get returns a reader that decrypts the stored content
+func (d *EncryptedDriver) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	stored, err := d.Storage.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+
+	src := bufio.NewReaderSize(stored, encryptedChunkSize+d.aead.Overhead())
+	iv := make([]byte, d.aead.NonceSize())
+	if _, err := io.ReadFull(src, iv); err != nil {
+		stored.Close()
+		return nil, storage.NewStorageError("get", path, ErrEncryptedContent)
+	}
+
+	return &decryptReader{
+		aead:    d.aead,
+		iv:      iv,
+		src:     src,
+		closer:  stored,
+		segment: make([]byte, encryptedChunkSize+d.aead.Overhead()),
+	}, nil
+}
+
+// Size returns the plaintext size. The Storage interface has no object
+// metadata, so it is derived from the stored size, which the chunk layout
+// fixes exactly.
+func (d *EncryptedDriver) Size(ctx context.Context, path string) (int64, error) {
+	stored, err := d.Storage.Size(ctx, path)
+	if err != nil {
+		return 0, err
+	}
+
+	size, ok := d.plaintextSize(stored)
+	if !ok {
+		return 0, storage.NewStorageError("size", path, ErrEncryptedContent)
+	}
+	return size, nil
+}
+
+// URL is not supported: the underlying driver would serve ciphertext
+func (d *EncryptedDriver) URL(ctx context.Context, path string) (string, error) {
+	return "", storage.NewStorageError("url", path, ErrEncryptedURL)
+}
+
+// TemporaryURL is not supported: the underlying driver would serve ciphertext
+func (d *EncryptedDriver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
+	return "", storage.NewStorageError("temporaryUrl", path, ErrEncryptedURL)
+}
+
+// Transform decrypts the source image, transforms it and stores the result
+// encrypted. The underlying driver would only see ciphertext.
+func (d *EncryptedDriver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
+// Driver returns the underlying driver name marked as encrypted
+func (d *EncryptedDriver) Driver() string {
+	return "encrypted:" + d.Storage.Driver()
+}
+
+func (d *EncryptedDriver) encrypt(dst io.Writer, src io.Reader) error {
+	iv := make([]byte, d.aead.NonceSize())
+	if _, err := rand.Read(iv); err != nil {
+		return fmt.Errorf("failed to generate IV: %w", err)
+	}
+	if _, err := dst.Write(iv); err != nil {
+		return err
+	}
+
+	current := make([]byte, encryptedChunkSize)
+	next := make([]byte, encryptedChunkSize)
+	sealed := make([]byte, 0, encryptedChunkSize+d.aead.Overhead())
+
+	n, err := io.ReadFull(src, current)
+	for index := uint32(0); ; index++ {
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return err
+		}
+
+		// A short read ends the stream; a full chunk needs a look ahead
+		final := err != nil
+		var nextN int
+		var nextErr error
+		if !final {
+			nextN, nextErr = io.ReadFull(src, next)
+			final = nextN == 0 && nextErr == io.EOF
+		}
+
+		sealed = d.aead.Seal(sealed[:0], chunkNonce(iv, index), current[:n], chunkAAD(final))
+		if _, err := dst.Write(sealed); err != nil {
+			return err
+		}
+
+		if final {
+			return nil
+		}
+
+		current, next = next, current
+		n, err = nextN, nextErr
+	}
+}
+
+// plaintextSize inverts the chunk layout: every chunk adds the GCM tag
+func (d *EncryptedDriver) plaintextSize(stored int64) (int64, bool) {
+	overhead := int64(d.aead.Overhead())
+	body := stored - int64(d.aead.NonceSize())
+	if body < overhead {
+		return 0, false
+	}
+
+	chunks := (body + encryptedChunkSize + overhead - 1) / (encryptedChunkSize + overhead)
+	return body - chunks*overhead, true
+}
+
+// chunkNonce derives a unique nonce per chunk from the file IV
+func chunkNonce(iv []byte, index uint32) []byte {
+	nonce := make([]byte, len(iv))
+	copy(nonce, iv)
+
+	var counter [4]byte
+	binary.BigEndian.PutUint32(counter[:], index)
+	for i := range counter {
+		nonce[len(nonce)-4+i] ^= counter[i]
+	}
+	return nonce
+}
+
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// decryptReader opens chunks as they are read
+type decryptReader struct {
+	aead    cipher.AEAD
+	iv      []byte
+	src     *bufio.Reader
+	closer  io.Closer
+	segment []byte
+	plain   []byte
+	index   uint32
+	done    bool
+}
+
+func (r *decryptReader) Read(p []byte) (int, error) {
+	for len(r.plain) == 0 {
+		if r.done {
+			return 0, io.EOF
+		}
+		if err := r.openChunk(); err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(p, r.plain)
+	r.plain = r.plain[n:]
+	return n, nil
+}
+
+func (r *decryptReader) openChunk() error {
+	n, err := io.ReadFull(r.src, r.segment)
+	switch {
+	case err == io.EOF:
+		// The stream ended without a final chunk
+		return ErrEncryptedContent
+	case err != nil && err != io.ErrUnexpectedEOF:
+		return err
+	}
+
+	final := err == io.ErrUnexpectedEOF
+	if !final {
+		if _, peekErr := r.src.Peek(1); peekErr == io.EOF {
+			final = true
+		} else if peekErr != nil {
+			return peekErr
+		}
+	}
+
+	plain, openErr := r.aead.Open(r.segment[:0], chunkNonce(r.iv, r.index), r.segment[:n], chunkAAD(final))
+	if openErr != nil {
+		return ErrEncryptedContent
+	}
+
+	r.plain = plain
+	r.index++
+	r.done = final
+	return nil
+}
+
+func (r *decryptReader) Close() error {
+	return r.closer.Close()
+}
+
+// MasterKeyFromFile reads a master key stored as 32 raw bytes or as hex or
+// base64 text
+func MasterKeyFromFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read master key file: %w", err)
+	}
+
+	if len(data) == MasterKeySize {
+		return data, nil
+	}
+
+	text := strings.TrimSpace(string(data))
+	if key, err := hex.DecodeString(text); err == nil && len(key) == MasterKeySize {
+		return key, nil
+	}
+	if key, err := base64.StdEncoding.DecodeString(text); err == nil && len(key) == MasterKeySize {
+		return key, nil
+	}
+
+	return nil, ErrInvalidMasterKey
+}
+
+// MasterKeyFromKMS decrypts a data key created with KMS GenerateDataKey
+// (AES_256). encryptedKey is the base64 encoded CiphertextBlob.
+func MasterKeyFromKMS(ctx context.Context, client kmsiface.KMSAPI, encryptedKey string) ([]byte, error) {
+	blob, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encryptedKey))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted master key: %w", err)
+	}
+
+	output, err := client.DecryptWithContext(ctx, &kms.DecryptInput{CiphertextBlob: blob})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt master key with KMS: %w", err)
+	}
+
+	if len(output.Plaintext) != MasterKeySize {
+		return nil, ErrInvalidMasterKey
+	}
+	return output.Plaintext, nil
+}
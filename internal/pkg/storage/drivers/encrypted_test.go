@@ -0,0 +1,240 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestMasterKey(t *testing.T) []byte {
+	key := make([]byte, MasterKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	return key
+}
+
+func TestEncryptedDriver(t *testing.T) {
+	tempDir := t.TempDir()
+	local := NewLocalDriver(tempDir, "http://localhost", "/storage")
+	driver, err := NewEncryptedDriver(local, newTestMasterKey(t))
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	readAll := func(t *testing.T, path string) []byte {
+		reader, err := driver.Get(ctx, path)
+		require.NoError(t, err)
+		defer reader.Close()
+
+		data, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		return data
+	}
+
+	sizes := map[string]int{
+		"empty":          0,
+		"small":          17,
+		"exact chunk":    encryptedChunkSize,
+		"multiple chunk": 3*encryptedChunkSize + 123,
+	}
+
+	for name, size := range sizes {
+		t.Run("should round trip "+name+" content", func(t *testing.T) {
+			content := make([]byte, size)
+			_, err := rand.Read(content)
+			require.NoError(t, err)
+			path := "roundtrip/" + strings.ReplaceAll(name, " ", "-") + ".bin"
+
+			require.NoError(t, driver.Put(ctx, path, bytes.NewReader(content)))
+
+			assert.Equal(t, content, readAll(t, path))
+
+			storedSize, err := local.Size(ctx, path)
+			require.NoError(t, err)
+			assert.Greater(t, storedSize, int64(size))
+
+			plainSize, err := driver.Size(ctx, path)
+			require.NoError(t, err)
+			assert.Equal(t, int64(size), plainSize)
+		})
+	}
+
+	t.Run("should round trip content stored in chunks", func(t *testing.T) {
+		content := make([]byte, 2*encryptedChunkSize+7)
+		_, err := rand.Read(content)
+		require.NoError(t, err)
+
+		require.NoError(t, driver.PutChunked(ctx, "chunked/file.bin", bytes.NewReader(content), 1024))
+
+		assert.Equal(t, content, readAll(t, "chunked/file.bin"))
+	})
+
+	t.Run("should not store readable content", func(t *testing.T) {
+		secret := strings.Repeat("credit card 4111-1111-1111-1111 ", 10)
+		require.NoError(t, driver.Put(ctx, "secret.txt", strings.NewReader(secret)))
+
+		raw, err := os.ReadFile(filepath.Join(tempDir, "secret.txt"))
+		require.NoError(t, err)
+
+		assert.NotContains(t, string(raw), "4111-1111")
+		assert.Equal(t, secret, string(readAll(t, "secret.txt")))
+	})
+
+	t.Run("should use a fresh IV for every file", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "a.txt", strings.NewReader("same content")))
+		require.NoError(t, driver.Put(ctx, "b.txt", strings.NewReader("same content")))
+
+		a, err := os.ReadFile(filepath.Join(tempDir, "a.txt"))
+		require.NoError(t, err)
+		b, err := os.ReadFile(filepath.Join(tempDir, "b.txt"))
+		require.NoError(t, err)
+
+		assert.NotEqual(t, a, b)
+	})
+
+	t.Run("should detect tampered content", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "tampered.txt", strings.NewReader("original content")))
+		fullPath := filepath.Join(tempDir, "tampered.txt")
+		raw, err := os.ReadFile(fullPath)
+		require.NoError(t, err)
+		raw[len(raw)-1] ^= 0xff
+		require.NoError(t, os.WriteFile(fullPath, raw, 0644))
+
+		reader, err := driver.Get(ctx, "tampered.txt")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrEncryptedContent)
+	})
+
+	t.Run("should detect truncated content", func(t *testing.T) {
+		content := make([]byte, 2*encryptedChunkSize+10)
+		require.NoError(t, driver.Put(ctx, "truncated.bin", bytes.NewReader(content)))
+		fullPath := filepath.Join(tempDir, "truncated.bin")
+		raw, err := os.ReadFile(fullPath)
+		require.NoError(t, err)
+		// Drop the final chunk, leaving only whole non-final chunks
+		require.NoError(t, os.WriteFile(fullPath, raw[:len(raw)-26], 0644))
+
+		reader, err := driver.Get(ctx, "truncated.bin")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrEncryptedContent)
+	})
+
+	t.Run("should not decrypt with another key", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "other.txt", strings.NewReader("content")))
+		other, err := NewEncryptedDriver(local, newTestMasterKey(t))
+		require.NoError(t, err)
+
+		reader, err := other.Get(ctx, "other.txt")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		_, err = io.ReadAll(reader)
+		assert.ErrorIs(t, err, ErrEncryptedContent)
+	})
+
+	t.Run("should delegate file information and refuse URLs", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "doc.pdf", strings.NewReader("pdf")))
+
+		mimeType, err := driver.MimeType(ctx, "doc.pdf")
+		require.NoError(t, err)
+		assert.Equal(t, "application/pdf", mimeType)
+
+		_, err = driver.LastModified(ctx, "doc.pdf")
+		assert.NoError(t, err)
+
+		_, err = driver.URL(ctx, "doc.pdf")
+		assert.ErrorIs(t, err, ErrEncryptedURL)
+		assert.Equal(t, "encrypted:local", driver.Driver())
+	})
+
+	t.Run("should reject keys of the wrong size", func(t *testing.T) {
+		_, err := NewEncryptedDriver(local, []byte("too short"))
+		assert.ErrorIs(t, err, ErrInvalidMasterKey)
+	})
+}
+
+func TestMasterKeyFromFile(t *testing.T) {
+	key := make([]byte, MasterKeySize)
+	_, err := rand.Read(key)
+	require.NoError(t, err)
+	dir := t.TempDir()
+
+	formats := map[string][]byte{
+		"raw":    key,
+		"hex":    []byte(hex.EncodeToString(key) + "\n"),
+		"base64": []byte(base64.StdEncoding.EncodeToString(key) + "\n"),
+	}
+
+	for name, data := range formats {
+		t.Run("should read "+name+" keys", func(t *testing.T) {
+			path := filepath.Join(dir, name+".key")
+			require.NoError(t, os.WriteFile(path, data, 0600))
+
+			loaded, err := MasterKeyFromFile(path)
+
+			require.NoError(t, err)
+			assert.Equal(t, key, loaded)
+		})
+	}
+
+	t.Run("should reject keys of the wrong size", func(t *testing.T) {
+		path := filepath.Join(dir, "short.key")
+		require.NoError(t, os.WriteFile(path, []byte("abcd"), 0600))
+
+		_, err := MasterKeyFromFile(path)
+
+		assert.ErrorIs(t, err, ErrInvalidMasterKey)
+	})
+}
+
+// fakeKMS decrypts by returning a fixed plaintext for a known blob
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	blob      []byte
+	plaintext []byte
+}
+
+func (f *fakeKMS) DecryptWithContext(ctx aws.Context, input *kms.DecryptInput, opts ...request.Option) (*kms.DecryptOutput, error) {
+	if !bytes.Equal(input.CiphertextBlob, f.blob) {
+		return nil, assert.AnError
+	}
+	return &kms.DecryptOutput{Plaintext: f.plaintext}, nil
+}
+
+func TestMasterKeyFromKMS(t *testing.T) {
+	key := newTestMasterKey(t)
+	client := &fakeKMS{blob: []byte("encrypted-data-key"), plaintext: key}
+	ctx := context.Background()
+
+	t.Run("should decrypt the data key", func(t *testing.T) {
+		loaded, err := MasterKeyFromKMS(ctx, client, base64.StdEncoding.EncodeToString(client.blob))
+
+		require.NoError(t, err)
+		assert.Equal(t, key, loaded)
+	})
+
+	t.Run("should report KMS failures", func(t *testing.T) {
+		_, err := MasterKeyFromKMS(ctx, client, base64.StdEncoding.EncodeToString([]byte("unknown")))
+
+		assert.ErrorContains(t, err, "failed to decrypt master key with KMS")
+	})
+}
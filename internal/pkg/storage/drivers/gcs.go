@@ -0,0 +1,361 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// GCSDriver implements the Storage interface for Google Cloud Storage
+type GCSDriver struct {
+	client      *gcs.Client
+	bucket      *gcs.BucketHandle
+	bucketName  string
+	publicURL   string
+	credentials gcsServiceAccount
+}
+
+// gcsServiceAccount holds the fields of a service account key file that
+// signed URLs are created with
+type gcsServiceAccount struct {
+	Type        string `json:"type"`
+	ClientEmail string `json:"client_email"`
+	PrivateKey  string `json:"private_key"`
+}
+
+// NewGCSDriver creates a new Google Cloud Storage driver authenticated with
+// the service account key file of the config
+func NewGCSDriver(cfg *config.GCSConfig) (*GCSDriver, error) {
+	if cfg == nil {
+		return nil, fmt.Errorf("GCS config cannot be nil")
+	}
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("bucket is required for Google Cloud Storage")
+	}
+	if cfg.CredentialsFile == "" {
+		return nil, fmt.Errorf("credentials file is required for Google Cloud Storage")
+	}
+
+	credentialsJSON, err := os.ReadFile(cfg.CredentialsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read GCS credentials file: %w", err)
+	}
+
+	var credentials gcsServiceAccount
+	if err := json.Unmarshal(credentialsJSON, &credentials); err != nil {
+		return nil, fmt.Errorf("failed to parse GCS credentials file: %w", err)
+	}
+	if credentials.Type != "service_account" || credentials.ClientEmail == "" || credentials.PrivateKey == "" {
+		return nil, fmt.Errorf("GCS credentials file %s is not a service account key", cfg.CredentialsFile)
+	}
+
+	client, err := gcs.NewClient(context.Background(), option.WithCredentialsJSON(credentialsJSON))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create GCS client: %w", err)
+	}
+
+	return &GCSDriver{
+		client:      client,
+		bucket:      client.Bucket(cfg.Bucket),
+		bucketName:  cfg.Bucket,
+		publicURL:   fmt.Sprintf("https://storage.googleapis.com/%s", cfg.Bucket),
+		credentials: credentials,
+	}, nil
+}
+
+// Put stores content at the given path
+func (d *GCSDriver) Put(ctx context.Context, path string, content io.Reader) error {
+	if err := d.write(ctx, path, content, "", 0); err != nil {
+		return storage.NewStorageError("put", path, err)
+	}
+
+	return nil
+}
+
+// PutChunked stores content with a resumable upload that sends chunkSize
+// bytes per request
+func (d *GCSDriver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = storage.DefaultChunkSize
+	}
+
+	if err := d.write(ctx, path, content, "", chunkSize); err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	return nil
+}
+
+// PutFile stores an uploaded file at the given path
+func (d *GCSDriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
+	src, err := fileHeader.Open()
+	if err != nil {
+		return storage.NewStorageError("putFile", path, err)
+	}
+	defer src.Close()
+
+	if err := d.write(ctx, path, src, fileHeader.Header.Get("Content-Type"), 0); err != nil {
+		return storage.NewStorageError("putFile", path, err)
+	}
+
+	return nil
+}
+
+// write uploads content, sniffing the content type when none is given. A
+// chunkSize of 0 keeps the client's default buffering.
+func (d *GCSDriver) write(ctx context.Context, path string, content io.Reader, contentType string, chunkSize int64) error {
+	if contentType == "" {
+		buffer := make([]byte, 512)
+		n, err := io.ReadFull(content, buffer)
+		if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+			return err
+		}
+		contentType = http.DetectContentType(buffer[:n])
+		content = io.MultiReader(bytes.NewReader(buffer[:n]), content)
+	}
+
+	writer := d.bucket.Object(path).NewWriter(ctx)
+	writer.ContentType = contentType
+	if chunkSize > 0 {
+		writer.ChunkSize = int(chunkSize)
+	}
+
+	if _, err := io.Copy(writer, content); err != nil {
+		writer.Close()
+		return err
+	}
+
+	return writer.Close()
+}
+
+// Get retrieves content from the given path
+func (d *GCSDriver) Get(ctx context.Context, path string) (io.ReadCloser, error) {
+	reader, err := d.bucket.Object(path).NewReader(ctx)
+	if err != nil {
+		return nil, storage.NewStorageError("get", path, gcsError(err))
+	}
+
+	return reader, nil
+}
+
+// Delete removes the file at the given path
+func (d *GCSDriver) Delete(ctx context.Context, path string) error {
+	if err := d.bucket.Object(path).Delete(ctx); err != nil {
+		return storage.NewStorageError("delete", path, gcsError(err))
+	}
+
+	return nil
+}
+
+// Exists checks if file exists at the given path
+func (d *GCSDriver) Exists(ctx context.Context, path string) (bool, error) {
+	_, err := d.bucket.Object(path).Attrs(ctx)
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, storage.NewStorageError("exists", path, err)
+	}
+
+	return true, nil
+}
+
+// Size returns the size of the file at the given path
+func (d *GCSDriver) Size(ctx context.Context, path string) (int64, error) {
+	attrs, err := d.bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		return 0, storage.NewStorageError("size", path, gcsError(err))
+	}
+
+	return attrs.Size, nil
+}
+
+// LastModified returns the last modification time of the file
+func (d *GCSDriver) LastModified(ctx context.Context, path string) (time.Time, error) {
+	attrs, err := d.bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		return time.Time{}, storage.NewStorageError("lastModified", path, gcsError(err))
+	}
+
+	return attrs.Updated, nil
+}
+
+// MimeType returns the MIME type of the file
+func (d *GCSDriver) MimeType(ctx context.Context, path string) (string, error) {
+	attrs, err := d.bucket.Object(path).Attrs(ctx)
+	if err != nil {
+		return "", storage.NewStorageError("mimeType", path, gcsError(err))
+	}
+
+	if attrs.ContentType != "" {
+		return attrs.ContentType, nil
+	}
+
+	return "application/octet-stream", nil
+}
+
+// Files returns all files in the given directory
+func (d *GCSDriver) Files(ctx context.Context, directory string) ([]string, error) {
+	files, _, err := d.list(ctx, directory, "/")
+	if err != nil {
+		return nil, storage.NewStorageError("files", directory, err)
+	}
+
+	return files, nil
+}
+
+// AllFiles returns all files in the directory recursively
+func (d *GCSDriver) AllFiles(ctx context.Context, directory string) ([]string, error) {
+	files, _, err := d.list(ctx, directory, "")
+	if err != nil {
+		return nil, storage.NewStorageError("allFiles", directory, err)
+	}
+
+	return files, nil
+}
+
+// Directories returns all directories in the given path
+func (d *GCSDriver) Directories(ctx context.Context, directory string) ([]string, error) {
+	_, directories, err := d.list(ctx, directory, "/")
+	if err != nil {
+		return nil, storage.NewStorageError("directories", directory, err)
+	}
+
+	return directories, nil
+}
+
+// list lists the objects under the directory prefix. With a delimiter the
+// listing stops at the directory and returns its subdirectories as well.
+func (d *GCSDriver) list(ctx context.Context, directory, delimiter string) (files, directories []string, err error) {
+	prefix := strings.TrimSuffix(directory, "/") + "/"
+	if directory == "" || directory == "." {
+		prefix = ""
+	}
+
+	objects := d.bucket.Objects(ctx, &gcs.Query{Prefix: prefix, Delimiter: delimiter})
+	for {
+		attrs, err := objects.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		if attrs.Prefix != "" {
+			directories = append(directories, strings.TrimSuffix(attrs.Prefix, "/"))
+		} else if attrs.Name != prefix { // Exclude directory itself
+			files = append(files, attrs.Name)
+		}
+	}
+
+	return files, directories, nil
+}
+
+// MakeDirectory creates a directory at the given path (GCS doesn't have real directories)
+func (d *GCSDriver) MakeDirectory(ctx context.Context, path string) error {
+	// GCS doesn't have real directories, they're created implicitly when files are uploaded
+	return nil
+}
+
+// DeleteDirectory removes the directory at the given path
+func (d *GCSDriver) DeleteDirectory(ctx context.Context, directory string) error {
+	files, err := d.AllFiles(ctx, directory)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		if err := d.Delete(ctx, file); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// URL returns the public URL for the given path
+func (d *GCSDriver) URL(ctx context.Context, path string) (string, error) {
+	cleanPath := strings.TrimPrefix(path, "/")
+	return fmt.Sprintf("%s/%s", d.publicURL, cleanPath), nil
+}
+
+// TemporaryURL returns a V4 signed URL signed with the service account key
+func (d *GCSDriver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
+	url, err := gcs.SignedURL(d.bucketName, strings.TrimPrefix(path, "/"), &gcs.SignedURLOptions{
+		GoogleAccessID: d.credentials.ClientEmail,
+		PrivateKey:     []byte(d.credentials.PrivateKey),
+		Method:         http.MethodGet,
+		Expires:        time.Now().Add(expiration),
+		Scheme:         gcs.SigningSchemeV4,
+	})
+	if err != nil {
+		return "", storage.NewStorageError("temporaryURL", path, err)
+	}
+
+	return url, nil
+}
+
+// Copy copies a file from source to destination
+func (d *GCSDriver) Copy(ctx context.Context, from, to string) error {
+	if _, err := d.bucket.Object(to).CopierFrom(d.bucket.Object(from)).Run(ctx); err != nil {
+		return storage.NewStorageError("copy", from, gcsError(err))
+	}
+
+	return nil
+}
+
+// Move moves a file from source to destination
+func (d *GCSDriver) Move(ctx context.Context, from, to string) error {
+	if err := d.Copy(ctx, from, to); err != nil {
+		return err
+	}
+
+	if err := d.Delete(ctx, from); err != nil {
+		// If delete fails, try to clean up the copied file
+		d.Delete(ctx, to)
+		return err
+	}
+
+	return nil
+}
+
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *GCSDriver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
+// Driver returns the driver name
+func (d *GCSDriver) Driver() string {
+	return "gcs"
+}
+
+// Close releases the GCS client
+func (d *GCSDriver) Close() error {
+	return d.client.Close()
+}
+
+// gcsError reports missing objects the way the other drivers do while
+// keeping gcs.ErrObjectNotExist matchable
+func gcsError(err error) error {
+	if errors.Is(err, gcs.ErrObjectNotExist) {
+		return fmt.Errorf("file not found: %w", err)
+	}
+	return err
+}
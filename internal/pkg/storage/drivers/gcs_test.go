@@ -0,0 +1,256 @@
+package drivers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	gcs "cloud.google.com/go/storage"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// gcsObject is an object stored by fakeGCS
+type gcsObject struct {
+	contentType string
+	data        []byte
+}
+
+// fakeGCS serves the JSON API uploads and deletes and the XML API reads the
+// GCS client makes against STORAGE_EMULATOR_HOST
+type fakeGCS struct {
+	*httptest.Server
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string]gcsObject
+}
+
+func newFakeGCS(t *testing.T, bucket string) *fakeGCS {
+	f := &fakeGCS{bucket: bucket, objects: make(map[string]gcsObject)}
+	f.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/upload/storage/v1/b/"+bucket+"/o":
+			f.upload(t, w, r)
+		case r.Method == http.MethodDelete && strings.HasPrefix(r.URL.Path, "/storage/v1/b/"+bucket+"/o/"):
+			f.delete(w, strings.TrimPrefix(r.URL.Path, "/storage/v1/b/"+bucket+"/o/"))
+		case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/"+bucket+"/"):
+			f.read(w, strings.TrimPrefix(r.URL.Path, "/"+bucket+"/"))
+		default:
+			t.Errorf("unexpected GCS request %s %s", r.Method, r.URL)
+			w.WriteHeader(http.StatusNotImplemented)
+		}
+	}))
+	t.Cleanup(f.Close)
+	t.Setenv("STORAGE_EMULATOR_HOST", f.URL)
+
+	return f
+}
+
+// upload stores a multipart upload: the object metadata, then its content
+func (f *fakeGCS) upload(t *testing.T, w http.ResponseWriter, r *http.Request) {
+	_, params, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	reader := multipart.NewReader(r.Body, params["boundary"])
+
+	metadataPart, err := reader.NextPart()
+	require.NoError(t, err)
+	var metadata struct {
+		Name        string `json:"name"`
+		ContentType string `json:"contentType"`
+	}
+	require.NoError(t, json.NewDecoder(metadataPart).Decode(&metadata))
+
+	mediaPart, err := reader.NextPart()
+	require.NoError(t, err)
+	data, err := io.ReadAll(mediaPart)
+	require.NoError(t, err)
+
+	f.mu.Lock()
+	f.objects[metadata.Name] = gcsObject{contentType: metadata.ContentType, data: data}
+	f.mu.Unlock()
+
+	json.NewEncoder(w).Encode(map[string]string{
+		"bucket":      f.bucket,
+		"name":        metadata.Name,
+		"contentType": metadata.ContentType,
+		"size":        strconv.Itoa(len(data)),
+	})
+}
+
+func (f *fakeGCS) read(w http.ResponseWriter, name string) {
+	f.mu.Lock()
+	object, ok := f.objects[name]
+	f.mu.Unlock()
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", object.contentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(object.data)))
+	w.Write(object.data)
+}
+
+func (f *fakeGCS) delete(w http.ResponseWriter, escapedName string) {
+	name, _ := url.PathUnescape(escapedName)
+
+	f.mu.Lock()
+	_, ok := f.objects[name]
+	delete(f.objects, name)
+	f.mu.Unlock()
+
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"error": map[string]interface{}{"code": http.StatusNotFound, "message": "No such object"},
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (f *fakeGCS) object(name string) (gcsObject, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	object, ok := f.objects[name]
+	return object, ok
+}
+
+// writeGCSCredentials writes a service account key file with a fresh RSA key
+func writeGCSCredentials(t *testing.T) string {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+
+	credentials, err := json.Marshal(map[string]string{
+		"type":         "service_account",
+		"project_id":   "test-project",
+		"client_email": "uploader@test-project.iam.gserviceaccount.com",
+		"private_key":  string(keyPEM),
+	})
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "credentials.json")
+	require.NoError(t, os.WriteFile(path, credentials, 0600))
+	return path
+}
+
+func TestGCSDriver(t *testing.T) {
+	ctx := context.Background()
+	credentialsFile := writeGCSCredentials(t)
+	validConfig := func() *config.GCSConfig {
+		return &config.GCSConfig{
+			Bucket:          "uploads",
+			ProjectID:       "test-project",
+			CredentialsFile: credentialsFile,
+		}
+	}
+
+	newDriver := func(t *testing.T) (*GCSDriver, *fakeGCS) {
+		fake := newFakeGCS(t, "uploads")
+		driver, err := NewGCSDriver(validConfig())
+		require.NoError(t, err)
+		t.Cleanup(func() { driver.Close() })
+		return driver, fake
+	}
+
+	t.Run("should reject incomplete config", func(t *testing.T) {
+		tests := []struct {
+			name   string
+			modify func(cfg *config.GCSConfig)
+			err    string
+		}{
+			{"missing bucket", func(cfg *config.GCSConfig) { cfg.Bucket = "" }, "bucket is required"},
+			{"missing credentials", func(cfg *config.GCSConfig) { cfg.CredentialsFile = "" }, "credentials file is required"},
+			{"unreadable credentials", func(cfg *config.GCSConfig) { cfg.CredentialsFile = "missing.json" }, "failed to read GCS credentials file"},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				cfg := validConfig()
+				tt.modify(cfg)
+
+				_, err := NewGCSDriver(cfg)
+				assert.ErrorContains(t, err, tt.err)
+			})
+		}
+	})
+
+	t.Run("should put and get objects", func(t *testing.T) {
+		driver, fake := newDriver(t)
+
+		require.NoError(t, driver.Put(ctx, "docs/readme.txt", strings.NewReader("hello gcs")))
+
+		object, ok := fake.object("docs/readme.txt")
+		require.True(t, ok)
+		assert.Equal(t, "text/plain; charset=utf-8", object.contentType)
+
+		reader, err := driver.Get(ctx, "docs/readme.txt")
+		require.NoError(t, err)
+		defer reader.Close()
+
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		assert.Equal(t, "hello gcs", string(content))
+	})
+
+	t.Run("should report missing objects on get", func(t *testing.T) {
+		driver, _ := newDriver(t)
+
+		_, err := driver.Get(ctx, "missing.txt")
+
+		assert.ErrorIs(t, err, gcs.ErrObjectNotExist)
+		assert.ErrorContains(t, err, "file not found")
+	})
+
+	t.Run("should delete objects", func(t *testing.T) {
+		driver, fake := newDriver(t)
+		require.NoError(t, driver.Put(ctx, "docs/old.txt", strings.NewReader("old")))
+
+		require.NoError(t, driver.Delete(ctx, "docs/old.txt"))
+
+		_, ok := fake.object("docs/old.txt")
+		assert.False(t, ok)
+		assert.ErrorIs(t, driver.Delete(ctx, "docs/old.txt"), gcs.ErrObjectNotExist)
+	})
+
+	t.Run("should sign V4 temporary URLs with the service account", func(t *testing.T) {
+		driver, _ := newDriver(t)
+
+		signed, err := driver.TemporaryURL(ctx, "/reports/q1.pdf", 15*time.Minute)
+		require.NoError(t, err)
+
+		parsed, err := url.Parse(signed)
+		require.NoError(t, err)
+		assert.Equal(t, "/uploads/reports/q1.pdf", parsed.Path)
+
+		query := parsed.Query()
+		assert.Equal(t, "GOOG4-RSA-SHA256", query.Get("X-Goog-Algorithm"))
+		assert.True(t, strings.HasPrefix(query.Get("X-Goog-Credential"), "uploader@test-project.iam.gserviceaccount.com/"))
+		expires, err := strconv.Atoi(query.Get("X-Goog-Expires"))
+		require.NoError(t, err)
+		assert.InDelta(t, 900, expires, 2)
+		assert.NotEmpty(t, query.Get("X-Goog-Signature"))
+	})
+}
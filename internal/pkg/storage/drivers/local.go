@@ -2,37 +2,67 @@ package drivers
 
 import (
 	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"os"
+	pathpkg "path"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/gin-gonic/gin"
+
 	"github.com/VeRJiL/go-template/internal/pkg/storage"
 )
 
 // LocalDriver implements the Storage interface for local file system
 type LocalDriver struct {
-	rootPath  string
-	baseURL   string
-	urlPrefix string
+	virusScanner
+	rootPath   string
+	baseURL    string
+	urlPrefix  string
+	signingKey []byte // HMAC key of temporary URLs
 }
 
-// NewLocalDriver creates a new local storage driver
+// NewLocalDriver creates a new local storage driver. Temporary URLs are
+// signed with a random key, valid until the process exits, unless one is
+// set with SetSigningKey.
 func NewLocalDriver(rootPath, baseURL, urlPrefix string) *LocalDriver {
+	signingKey := make([]byte, 32)
+	if _, err := rand.Read(signingKey); err != nil {
+		panic(fmt.Sprintf("failed to generate local storage signing key: %v", err))
+	}
+
 	return &LocalDriver{
-		rootPath:  rootPath,
-		baseURL:   baseURL,
-		urlPrefix: urlPrefix,
+		rootPath:   rootPath,
+		baseURL:    baseURL,
+		urlPrefix:  urlPrefix,
+		signingKey: signingKey,
 	}
 }
 
-// Put stores content at the given path
+// SetSigningKey sets the key temporary URLs are signed with, so they stay
+// valid across restarts and instances
+func (d *LocalDriver) SetSigningKey(key []byte) {
+	d.signingKey = key
+}
+
+// Put stores content at the given path, rejecting it when the scanner set
+// with SetScanner finds a threat
 func (d *LocalDriver) Put(ctx context.Context, path string, content io.Reader) error {
+	return d.scanWhile(ctx, "put", path, content, d.put, d.promoteFunc(path), d.remove)
+}
+
+func (d *LocalDriver) put(path string, content io.Reader) error {
 	fullPath := d.getFullPath(path)
 
 	// Create directory if it doesn't exist
@@ -57,16 +87,67 @@ func (d *LocalDriver) Put(ctx context.Context, path string, content io.Reader) e
 	return nil
 }
 
-// PutFile stores an uploaded file at the given path
-func (d *LocalDriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
+// PutChunked copies content chunkSize bytes at a time into a temporary file
+// next to the target and renames it into place once complete, so a failed
+// or cancelled upload never leaves a partial file at path
+func (d *LocalDriver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	return d.scanWhile(ctx, "putChunked", path, content, func(key string, content io.Reader) error {
+		return d.putChunked(ctx, key, content, chunkSize)
+	}, d.promoteFunc(path), d.remove)
+}
+
+func (d *LocalDriver) putChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = storage.DefaultChunkSize
+	}
+
 	fullPath := d.getFullPath(path)
 
 	// Create directory if it doesn't exist
 	dir := filepath.Dir(fullPath)
 	if err := os.MkdirAll(dir, 0755); err != nil {
-		return storage.NewStorageError("putFile", path, err)
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".upload-*")
+	if err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return storage.NewStorageError("putChunked", path, err)
+		}
+
+		n, err := io.Copy(tmp, io.LimitReader(content, chunkSize))
+		if err != nil {
+			return storage.NewStorageError("putChunked", path, err)
+		}
+		if n < chunkSize {
+			break
+		}
+	}
+
+	if err := tmp.Close(); err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	// CreateTemp restricts the file to its owner, Put creates it with 0644
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return storage.NewStorageError("putChunked", path, err)
 	}
 
+	return nil
+}
+
+// PutFile stores an uploaded file at the given path
+func (d *LocalDriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
 	// Open the uploaded file
 	src, err := fileHeader.Open()
 	if err != nil {
@@ -74,19 +155,47 @@ func (d *LocalDriver) PutFile(ctx context.Context, path string, fileHeader *mult
 	}
 	defer src.Close()
 
-	// Create destination file
-	dst, err := os.Create(fullPath)
-	if err != nil {
-		return storage.NewStorageError("putFile", path, err)
-	}
-	defer dst.Close()
+	return d.scanWhile(ctx, "putFile", path, src, func(key string, src io.Reader) error {
+		fullPath := d.getFullPath(key)
 
-	// Copy file content
-	_, err = io.Copy(dst, src)
-	if err != nil {
-		return storage.NewStorageError("putFile", path, err)
+		// Create directory if it doesn't exist
+		if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+			return storage.NewStorageError("putFile", path, err)
+		}
+
+		// Create destination file
+		dst, err := os.Create(fullPath)
+		if err != nil {
+			return storage.NewStorageError("putFile", path, err)
+		}
+		defer dst.Close()
+
+		// Copy file content
+		_, err = io.Copy(dst, src)
+		if err != nil {
+			return storage.NewStorageError("putFile", path, err)
+		}
+
+		return nil
+	}, d.promoteFunc(path), d.remove)
+}
+
+// promoteFunc moves content that scanned clean from its temporary key to
+// path
+func (d *LocalDriver) promoteFunc(path string) func(tmpKey string) error {
+	return func(tmpKey string) error {
+		if err := os.Rename(d.getFullPath(tmpKey), d.getFullPath(path)); err != nil {
+			return storage.NewStorageError("promote", path, err)
+		}
+		return nil
 	}
+}
 
+// remove deletes the file stored at key for scanning, if any
+func (d *LocalDriver) remove(key string) error {
+	if err := os.Remove(d.getFullPath(key)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
 	return nil
 }
 
@@ -291,10 +400,71 @@ func (d *LocalDriver) URL(ctx context.Context, path string) (string, error) {
 	return fmt.Sprintf("/%s/%s", strings.Trim(d.urlPrefix, "/"), cleanPath), nil
 }
 
-// TemporaryURL returns a temporary URL (not supported for local storage)
+// TemporaryURL returns the public URL of the file signed with HMAC-SHA256
+// and valid for expiration, to be served by ServeSignedFile
 func (d *LocalDriver) TemporaryURL(ctx context.Context, path string, expiration time.Duration) (string, error) {
-	// Local storage doesn't support temporary URLs, return regular URL
-	return d.URL(ctx, path)
+	if _, err := os.Stat(d.getFullPath(path)); err != nil {
+		if os.IsNotExist(err) {
+			return "", storage.NewStorageError("temporaryURL", path, fmt.Errorf("file not found"))
+		}
+		return "", storage.NewStorageError("temporaryURL", path, err)
+	}
+
+	publicURL, err := d.URL(ctx, path)
+	if err != nil {
+		return "", err
+	}
+
+	expires := strconv.FormatInt(time.Now().Add(expiration).Unix(), 10)
+	query := url.Values{}
+	query.Set("expires", expires)
+	query.Set("sig", d.sign(cleanStoragePath(path), expires))
+
+	return publicURL + "?" + query.Encode(), nil
+}
+
+// ServeSignedFile serves the file named by the path parameter of a route
+// like <urlPrefix>/*path, answering 403 unless the URL was generated by
+// TemporaryURL and has not expired
+func (d *LocalDriver) ServeSignedFile(c *gin.Context) {
+	path := cleanStoragePath(c.Param("path"))
+	expires := c.Query("expires")
+
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || !hmac.Equal([]byte(d.sign(path, expires)), []byte(c.Query("sig"))) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "invalid signature"})
+		return
+	}
+	if time.Now().Unix() > expiresAt {
+		c.JSON(http.StatusForbidden, gin.H{"error": "URL expired"})
+		return
+	}
+
+	file, err := os.Open(d.getFullPath(path))
+	if err != nil {
+		if os.IsNotExist(err) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to open file"})
+		return
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil || info.IsDir() {
+		c.JSON(http.StatusNotFound, gin.H{"error": "file not found"})
+		return
+	}
+
+	http.ServeContent(c.Writer, c.Request, info.Name(), info.ModTime(), file)
+}
+
+// sign returns the hex HMAC-SHA256 of the path and expiry of a temporary URL
+func (d *LocalDriver) sign(path, expires string) string {
+	mac := hmac.New(sha256.New, d.signingKey)
+	mac.Write([]byte(path + "\n" + expires))
+	return hex.EncodeToString(mac.Sum(nil))
 }
 
 // Copy copies a file from source to destination
@@ -351,6 +521,51 @@ func (d *LocalDriver) Move(ctx context.Context, from, to string) error {
 	return nil
 }
 
+// Transform resizes and re-encodes the source image into a temporary file
+// next to dstPath and renames it into place, so readers never see a partial
+// image
+func (d *LocalDriver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	src, err := os.Open(d.getFullPath(srcPath))
+	if err != nil {
+		return storage.NewStorageError("transform", srcPath, err)
+	}
+	defer src.Close()
+
+	fullPath := d.getFullPath(dstPath)
+
+	// Create directory if it doesn't exist
+	dir := filepath.Dir(fullPath)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return storage.NewStorageError("transform", dstPath, err)
+	}
+
+	tmp, err := os.CreateTemp(dir, ".transform-*")
+	if err != nil {
+		return storage.NewStorageError("transform", dstPath, err)
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if err := storage.TransformImage(tmp, src, dstPath, opts); err != nil {
+		return storage.NewStorageError("transform", srcPath, err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return storage.NewStorageError("transform", dstPath, err)
+	}
+
+	// CreateTemp restricts the file to its owner, Put creates it with 0644
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return storage.NewStorageError("transform", dstPath, err)
+	}
+
+	if err := os.Rename(tmp.Name(), fullPath); err != nil {
+		return storage.NewStorageError("transform", dstPath, err)
+	}
+
+	return nil
+}
+
 // Driver returns the driver name
 func (d *LocalDriver) Driver() string {
 	return "local"
@@ -360,3 +575,9 @@ func (d *LocalDriver) Driver() string {
 func (d *LocalDriver) getFullPath(path string) string {
 	return filepath.Join(d.rootPath, path)
 }
+
+// cleanStoragePath normalizes a storage path to the form it is signed in,
+// without a leading slash and with any .. resolved inside the root
+func cleanStoragePath(path string) string {
+	return strings.TrimPrefix(pathpkg.Clean("/"+filepath.ToSlash(path)), "/")
+}
@@ -3,16 +3,23 @@ package drivers
 import (
 	"bytes"
 	"context"
+	"image"
+	"image/png"
 	"io"
 	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
 )
 
 func TestNewLocalDriver(t *testing.T) {
@@ -107,6 +114,71 @@ func TestLocalDriverPut(t *testing.T) {
 	})
 }
 
+// failingReader returns its content and then fails
+type failingReader struct {
+	content io.Reader
+}
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	n, err := r.content.Read(p)
+	if err == io.EOF {
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
+
+func TestLocalDriverPutChunked(t *testing.T) {
+	tempDir := t.TempDir()
+	driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
+	ctx := context.Background()
+
+	t.Run("should store content spanning several chunks", func(t *testing.T) {
+		content := strings.Repeat("0123456789", 100)
+
+		err := driver.PutChunked(ctx, "chunks/file.txt", strings.NewReader(content), 64)
+
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(tempDir, "chunks/file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+
+		info, err := os.Stat(filepath.Join(tempDir, "chunks/file.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, os.FileMode(0644), info.Mode().Perm())
+	})
+
+	t.Run("should store content that is an exact multiple of the chunk size", func(t *testing.T) {
+		content := strings.Repeat("a", 128)
+
+		require.NoError(t, driver.PutChunked(ctx, "chunks/exact.txt", strings.NewReader(content), 64))
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "chunks/exact.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("should leave no partial file when the upload fails", func(t *testing.T) {
+		reader := &failingReader{content: strings.NewReader(strings.Repeat("a", 200))}
+
+		err := driver.PutChunked(ctx, "failed/file.txt", reader, 64)
+
+		assert.Error(t, err)
+		entries, err := os.ReadDir(filepath.Join(tempDir, "failed"))
+		require.NoError(t, err)
+		assert.Empty(t, entries)
+	})
+
+	t.Run("should stop when the context is cancelled", func(t *testing.T) {
+		cancelled, cancel := context.WithCancel(ctx)
+		cancel()
+
+		err := driver.PutChunked(cancelled, "cancelled/file.txt", strings.NewReader("content"), 64)
+
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.NoFileExists(t, filepath.Join(tempDir, "cancelled/file.txt"))
+	})
+}
+
 func TestLocalDriverPutFile(t *testing.T) {
 	tempDir := t.TempDir()
 	driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
@@ -563,11 +635,9 @@ func TestLocalDriverTemporaryURL(t *testing.T) {
 		url, err := driver.TemporaryURL(ctx, path, expiration)
 
 		assert.NoError(t, err)
-		assert.Contains(t, url, "http://example.com/storage/test/temp-file.txt")
+		assert.Contains(t, url, "http://example.com/storage/test/temp-file.txt?")
 		assert.Contains(t, url, "expires=")
-
-		// Note: LocalDriver doesn't implement true temporary URLs with validation,
-		// it just appends an expiration parameter for compatibility
+		assert.Contains(t, url, "sig=")
 	})
 
 	t.Run("should return error for non-existent file", func(t *testing.T) {
@@ -582,6 +652,66 @@ func TestLocalDriverTemporaryURL(t *testing.T) {
 	})
 }
 
+func TestLocalDriverServeSignedFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	tempDir := t.TempDir()
+	driver := NewLocalDriver(tempDir, "", "/storage")
+	ctx := context.Background()
+	require.NoError(t, driver.Put(ctx, "docs/report.txt", strings.NewReader("signed content")))
+
+	router := gin.New()
+	router.GET("/storage/*path", driver.ServeSignedFile)
+
+	get := func(target string) *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, target, nil))
+		return w
+	}
+
+	t.Run("should serve a file with a valid signature", func(t *testing.T) {
+		url, err := driver.TemporaryURL(ctx, "docs/report.txt", time.Minute)
+		require.NoError(t, err)
+
+		w := get(url)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "signed content", w.Body.String())
+	})
+
+	t.Run("should reject tampered and unsigned URLs", func(t *testing.T) {
+		url, err := driver.TemporaryURL(ctx, "docs/report.txt", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, get(url[:len(url)-1]+"0").Code)
+		assert.Equal(t, http.StatusForbidden, get(strings.Replace(url, "report", "other", 1)).Code)
+		assert.Equal(t, http.StatusForbidden, get("/storage/docs/report.txt").Code)
+	})
+
+	t.Run("should reject expired URLs", func(t *testing.T) {
+		url, err := driver.TemporaryURL(ctx, "docs/report.txt", -time.Minute)
+		require.NoError(t, err)
+
+		w := get(url)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		assert.Contains(t, w.Body.String(), "expired")
+	})
+
+	t.Run("should reject URLs signed with another key", func(t *testing.T) {
+		other := NewLocalDriver(tempDir, "", "/storage")
+		url, err := other.TemporaryURL(ctx, "docs/report.txt", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusForbidden, get(url).Code)
+
+		other.SetSigningKey(driver.signingKey)
+		url, err = other.TemporaryURL(ctx, "docs/report.txt", time.Minute)
+		require.NoError(t, err)
+
+		assert.Equal(t, http.StatusOK, get(url).Code)
+	})
+}
+
 func TestLocalDriverCopyAndMove(t *testing.T) {
 	tempDir := t.TempDir()
 	driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
@@ -670,4 +800,49 @@ func TestLocalDriverCopyAndMove(t *testing.T) {
 		assert.Error(t, err)
 		assert.Contains(t, err.Error(), "file not found")
 	})
-}
\ No newline at end of file
+}
+
+func TestLocalDriverTransform(t *testing.T) {
+	ctx := context.Background()
+	tempDir := t.TempDir()
+	driver := NewLocalDriver(tempDir, "", "")
+
+	img := image.NewRGBA(image.Rect(0, 0, 80, 40))
+	var source bytes.Buffer
+	require.NoError(t, png.Encode(&source, img))
+	require.NoError(t, driver.Put(ctx, "images/photo.png", &source))
+
+	t.Run("should write the transformed image to the destination", func(t *testing.T) {
+		err := driver.Transform(ctx, "images/photo.png", "variants/photo-small.jpg", storage.TransformOptions{Width: 20})
+		require.NoError(t, err)
+
+		file, err := os.Open(filepath.Join(tempDir, "variants/photo-small.jpg"))
+		require.NoError(t, err)
+		defer file.Close()
+
+		cfg, format, err := image.DecodeConfig(file)
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, 20, cfg.Width)
+		assert.Equal(t, 10, cfg.Height)
+
+		entries, err := os.ReadDir(filepath.Join(tempDir, "variants"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary file should be renamed into place")
+	})
+
+	t.Run("should not leave a file behind when the source is not an image", func(t *testing.T) {
+		require.NoError(t, driver.Put(ctx, "notes.txt", strings.NewReader("not an image")))
+
+		err := driver.Transform(ctx, "notes.txt", "variants/notes.png", storage.TransformOptions{Width: 20})
+		assert.Error(t, err)
+
+		_, err = os.Stat(filepath.Join(tempDir, "variants/notes.png"))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("should fail for a missing source", func(t *testing.T) {
+		err := driver.Transform(ctx, "missing.png", "variants/missing.png", storage.TransformOptions{})
+		assert.Error(t, err)
+	})
+}
@@ -156,6 +156,12 @@ func (d *MinIODriver) Put(ctx context.Context, path string, content io.Reader) e
 	return nil
 }
 
+// PutChunked stores content in parts with the S3 multipart upload API MinIO implements,
+// holding one chunk of chunkSize bytes in memory at a time
+func (d *MinIODriver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	return putMultipart(ctx, d.client, d.bucket, path, content, chunkSize, "")
+}
+
 // PutFile stores an uploaded file at the given path
 func (d *MinIODriver) PutFile(ctx context.Context, path string, fileHeader *multipart.FileHeader) error {
 	src, err := fileHeader.Open()
@@ -466,6 +472,12 @@ func (d *MinIODriver) Move(ctx context.Context, from, to string) error {
 	return nil
 }
 
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *MinIODriver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
 // Driver returns the driver name
 func (d *MinIODriver) Driver() string {
 	return "minio"
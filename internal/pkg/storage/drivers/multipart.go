@@ -0,0 +1,113 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// multipartAPI is the part of the S3 client a multipart upload uses
+type multipartAPI interface {
+	CreateMultipartUploadWithContext(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error)
+	UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error)
+	CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error)
+	AbortMultipartUploadWithContext(ctx aws.Context, input *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error)
+}
+
+// putMultipart streams content to an S3 compatible bucket one part at a
+// time, so at most one chunk is held in memory. Chunks below the S3 minimum
+// part size are raised to it. The upload is aborted if any part fails so
+// the bucket is not left holding orphaned parts.
+func putMultipart(ctx context.Context, client multipartAPI, bucket, path string, content io.Reader, chunkSize int64, acl string) error {
+	if chunkSize < storage.DefaultChunkSize {
+		chunkSize = storage.DefaultChunkSize
+	}
+
+	buffer := make([]byte, chunkSize)
+	n, err := readChunk(content, buffer)
+	if err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	input := &s3.CreateMultipartUploadInput{
+		Bucket:      aws.String(bucket),
+		Key:         aws.String(path),
+		ContentType: aws.String(http.DetectContentType(buffer[:n])),
+	}
+	if acl != "" {
+		input.ACL = aws.String(acl)
+	}
+
+	upload, err := client.CreateMultipartUploadWithContext(ctx, input)
+	if err != nil {
+		return storage.NewStorageError("putChunked", path, err)
+	}
+
+	var parts []*s3.CompletedPart
+	for partNumber := int64(1); ; partNumber++ {
+		part, err := client.UploadPartWithContext(ctx, &s3.UploadPartInput{
+			Bucket:     aws.String(bucket),
+			Key:        aws.String(path),
+			UploadId:   upload.UploadId,
+			PartNumber: aws.Int64(partNumber),
+			Body:       bytes.NewReader(buffer[:n]),
+		})
+		if err != nil {
+			return abortMultipart(client, bucket, path, upload.UploadId, err)
+		}
+		parts = append(parts, &s3.CompletedPart{ETag: part.ETag, PartNumber: aws.Int64(partNumber)})
+
+		if n < len(buffer) {
+			break
+		}
+
+		n, err = readChunk(content, buffer)
+		if err != nil {
+			return abortMultipart(client, bucket, path, upload.UploadId, err)
+		}
+		if n == 0 {
+			break
+		}
+	}
+
+	_, err = client.CompleteMultipartUploadWithContext(ctx, &s3.CompleteMultipartUploadInput{
+		Bucket:          aws.String(bucket),
+		Key:             aws.String(path),
+		UploadId:        upload.UploadId,
+		MultipartUpload: &s3.CompletedMultipartUpload{Parts: parts},
+	})
+	if err != nil {
+		return abortMultipart(client, bucket, path, upload.UploadId, err)
+	}
+
+	return nil
+}
+
+// readChunk fills buffer from content, returning fewer bytes only at the
+// end of the stream
+func readChunk(content io.Reader, buffer []byte) (int, error) {
+	n, err := io.ReadFull(content, buffer)
+	if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return n, nil
+	}
+	return n, err
+}
+
+// abortMultipart discards the uploaded parts and reports the upload error.
+// It runs without the upload's context, which may be the one cancelled.
+func abortMultipart(client multipartAPI, bucket, path string, uploadID *string, cause error) error {
+	client.AbortMultipartUploadWithContext(context.Background(), &s3.AbortMultipartUploadInput{
+		Bucket:   aws.String(bucket),
+		Key:      aws.String(path),
+		UploadId: uploadID,
+	})
+	return storage.NewStorageError("putChunked", path, cause)
+}
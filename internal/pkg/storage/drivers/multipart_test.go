@@ -0,0 +1,120 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// fakeMultipart records a multipart upload in memory
+type fakeMultipart struct {
+	created   *s3.CreateMultipartUploadInput
+	parts     [][]byte
+	completed *s3.CompleteMultipartUploadInput
+	aborted   bool
+	failPart  int
+}
+
+func (f *fakeMultipart) CreateMultipartUploadWithContext(ctx aws.Context, input *s3.CreateMultipartUploadInput, opts ...request.Option) (*s3.CreateMultipartUploadOutput, error) {
+	f.created = input
+	return &s3.CreateMultipartUploadOutput{UploadId: aws.String("upload-1")}, nil
+}
+
+func (f *fakeMultipart) UploadPartWithContext(ctx aws.Context, input *s3.UploadPartInput, opts ...request.Option) (*s3.UploadPartOutput, error) {
+	if int(*input.PartNumber) == f.failPart {
+		return nil, errors.New("part rejected")
+	}
+
+	data, err := io.ReadAll(input.Body)
+	if err != nil {
+		return nil, err
+	}
+	f.parts = append(f.parts, data)
+	return &s3.UploadPartOutput{ETag: aws.String(fmt.Sprintf("etag-%d", *input.PartNumber))}, nil
+}
+
+func (f *fakeMultipart) CompleteMultipartUploadWithContext(ctx aws.Context, input *s3.CompleteMultipartUploadInput, opts ...request.Option) (*s3.CompleteMultipartUploadOutput, error) {
+	f.completed = input
+	return &s3.CompleteMultipartUploadOutput{}, nil
+}
+
+func (f *fakeMultipart) AbortMultipartUploadWithContext(ctx aws.Context, input *s3.AbortMultipartUploadInput, opts ...request.Option) (*s3.AbortMultipartUploadOutput, error) {
+	f.aborted = true
+	return &s3.AbortMultipartUploadOutput{}, nil
+}
+
+func TestPutMultipart(t *testing.T) {
+	ctx := context.Background()
+	chunk := int(storage.DefaultChunkSize)
+
+	t.Run("should upload the content in parts of the chunk size", func(t *testing.T) {
+		client := &fakeMultipart{}
+		content := bytes.Repeat([]byte("a"), 2*chunk+100)
+
+		err := putMultipart(ctx, client, "bucket", "videos/clip.mp4", bytes.NewReader(content), storage.DefaultChunkSize, "public-read")
+
+		require.NoError(t, err)
+		require.Len(t, client.parts, 3)
+		assert.Len(t, client.parts[0], chunk)
+		assert.Len(t, client.parts[1], chunk)
+		assert.Len(t, client.parts[2], 100)
+		assert.Equal(t, content, bytes.Join(client.parts, nil))
+
+		assert.Equal(t, "videos/clip.mp4", *client.created.Key)
+		assert.Equal(t, "public-read", *client.created.ACL)
+		require.NotNil(t, client.completed)
+		assert.Len(t, client.completed.MultipartUpload.Parts, 3)
+		assert.Equal(t, "etag-3", *client.completed.MultipartUpload.Parts[2].ETag)
+		assert.False(t, client.aborted)
+	})
+
+	t.Run("should raise chunks to the minimum part size", func(t *testing.T) {
+		client := &fakeMultipart{}
+		content := bytes.Repeat([]byte("a"), chunk+1)
+
+		require.NoError(t, putMultipart(ctx, client, "bucket", "file.bin", bytes.NewReader(content), 1024, ""))
+
+		require.Len(t, client.parts, 2)
+		assert.Len(t, client.parts[0], chunk)
+		assert.Nil(t, client.created.ACL)
+	})
+
+	t.Run("should not upload an empty part after an exact multiple", func(t *testing.T) {
+		client := &fakeMultipart{}
+
+		require.NoError(t, putMultipart(ctx, client, "bucket", "file.bin", bytes.NewReader(make([]byte, chunk)), 0, ""))
+
+		assert.Len(t, client.parts, 1)
+	})
+
+	t.Run("should detect the content type from the first chunk", func(t *testing.T) {
+		client := &fakeMultipart{}
+
+		require.NoError(t, putMultipart(ctx, client, "bucket", "page.html", strings.NewReader("<html><body>hi</body></html>"), 0, ""))
+
+		assert.Equal(t, "text/html; charset=utf-8", *client.created.ContentType)
+	})
+
+	t.Run("should abort the upload when a part fails", func(t *testing.T) {
+		client := &fakeMultipart{failPart: 2}
+		content := bytes.Repeat([]byte("a"), 2*chunk)
+
+		err := putMultipart(ctx, client, "bucket", "file.bin", bytes.NewReader(content), 0, "")
+
+		assert.ErrorContains(t, err, "part rejected")
+		assert.True(t, client.aborted)
+		assert.Nil(t, client.completed)
+	})
+}
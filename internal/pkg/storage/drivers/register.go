@@ -0,0 +1,111 @@
+package drivers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/kms"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// init registers the built-in drivers and encryption with the storage
+// manager
+func init() {
+	storage.RegisterDriver("local", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "local" || cfg.Local.Path != ""
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		localDriver := NewLocalDriver(
+			cfg.Local.Path,
+			"", // Base URL (will be set from server config)
+			cfg.Local.URLPrefix,
+		)
+		if cfg.Local.SigningKey != "" {
+			localDriver.SetSigningKey([]byte(cfg.Local.SigningKey))
+		}
+		return localDriver, nil
+	})
+
+	storage.RegisterDriver("s3", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "s3" || (cfg.S3.Bucket != "" && cfg.S3.AccessKey != "")
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewS3Driver(S3Config{
+			Region:         cfg.S3.Region,
+			Bucket:         cfg.S3.Bucket,
+			AccessKey:      cfg.S3.AccessKey,
+			SecretKey:      cfg.S3.SecretKey,
+			UseSSL:         cfg.S3.UseSSL,
+			ForcePathStyle: cfg.S3.ForcePathStyle,
+			PublicURL:      "", // Can be configured if needed
+		})
+	})
+
+	storage.RegisterDriver("minio", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "minio" || (cfg.MinIO.Endpoint != "" && cfg.MinIO.AccessKey != "")
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewMinIODriver(MinIOConfig{
+			Endpoint:  cfg.MinIO.Endpoint,
+			AccessKey: cfg.MinIO.AccessKey,
+			SecretKey: cfg.MinIO.SecretKey,
+			Bucket:    cfg.MinIO.Bucket,
+			UseSSL:    cfg.MinIO.UseSSL,
+			PublicURL: cfg.MinIO.PublicURL,
+		})
+	})
+
+	storage.RegisterDriver("cloudflare_r2", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "cloudflare_r2" || (cfg.CloudflareR2.AccountID != "" && cfg.CloudflareR2.AccessKey != "")
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewCloudflareR2Driver(CloudflareR2Config{
+			AccountID: cfg.CloudflareR2.AccountID,
+			AccessKey: cfg.CloudflareR2.AccessKey,
+			SecretKey: cfg.CloudflareR2.SecretKey,
+			Bucket:    cfg.CloudflareR2.Bucket,
+			PublicURL: cfg.CloudflareR2.PublicURL,
+		})
+	})
+
+	storage.RegisterDriver("b2", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "b2" || (cfg.BackblazeB2.KeyID != "" && cfg.BackblazeB2.Bucket != "")
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewBackblazeB2Driver(&cfg.BackblazeB2)
+	})
+
+	storage.RegisterDriver("gcs", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "gcs" || (cfg.GCS.Bucket != "" && cfg.GCS.ProjectID != "")
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewGCSDriver(&cfg.GCS)
+	})
+
+	storage.RegisterDriver("azure", func(cfg *config.StorageConfig) bool {
+		return cfg.Provider == "azure" || cfg.Azure.Account != ""
+	}, func(cfg *config.StorageConfig) (storage.Storage, error) {
+		return NewAzureDriver(&cfg.Azure)
+	})
+
+	storage.RegisterEncryption(loadMasterKey, func(disk storage.Storage, masterKey []byte) (storage.Storage, error) {
+		return NewEncryptedDriver(disk, masterKey)
+	})
+}
+
+// loadMasterKey decrypts the master key with KMS when configured and reads
+// the local key file otherwise
+func loadMasterKey(cfg config.StorageEncryptionConfig) ([]byte, error) {
+	if cfg.KMSEncryptedKey == "" {
+		return MasterKeyFromFile(cfg.KeyFile)
+	}
+
+	sess, err := session.NewSession(&aws.Config{Region: aws.String(cfg.KMSRegion)})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create AWS session: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return MasterKeyFromKMS(ctx, kms.New(sess), cfg.KMSEncryptedKey)
+}
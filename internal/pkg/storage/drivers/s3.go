@@ -6,6 +6,7 @@ import (
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
 	"strings"
 	"time"
 
@@ -21,6 +22,7 @@ import (
 
 // S3Driver implements the Storage interface for Amazon S3
 type S3Driver struct {
+	virusScanner
 	client     *s3.S3
 	uploader   *s3manager.Uploader
 	downloader *s3manager.Downloader
@@ -99,20 +101,30 @@ func (d *S3Driver) Put(ctx context.Context, path string, content io.Reader) erro
 		contentType = "application/octet-stream"
 	}
 
-	input := &s3manager.UploadInput{
-		Bucket:      aws.String(d.bucket),
-		Key:         aws.String(path),
-		Body:        content,
-		ContentType: aws.String(contentType),
-		ACL:         aws.String("public-read"), // Make files publicly accessible
-	}
+	return d.scanWhile(ctx, "put", path, content, func(key string, content io.Reader) error {
+		input := &s3manager.UploadInput{
+			Bucket:      aws.String(d.bucket),
+			Key:         aws.String(key),
+			Body:        content,
+			ContentType: aws.String(contentType),
+			ACL:         aws.String(scanACL(path, key)), // Make files publicly accessible
+		}
 
-	_, err := d.uploader.UploadWithContext(ctx, input)
-	if err != nil {
-		return storage.NewStorageError("put", path, err)
-	}
+		_, err := d.uploader.UploadWithContext(ctx, input)
+		if err != nil {
+			return storage.NewStorageError("put", path, err)
+		}
 
-	return nil
+		return nil
+	}, d.promoteFunc(ctx, path), d.removeFunc(ctx))
+}
+
+// PutChunked stores content in parts with the S3 multipart upload API,
+// holding one chunk of chunkSize bytes in memory at a time
+func (d *S3Driver) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	return d.scanWhile(ctx, "putChunked", path, content, func(key string, content io.Reader) error {
+		return putMultipart(ctx, d.client, d.bucket, key, content, chunkSize, scanACL(path, key))
+	}, d.promoteFunc(ctx, path), d.removeFunc(ctx))
 }
 
 // PutFile stores an uploaded file at the given path
@@ -137,20 +149,56 @@ func (d *S3Driver) PutFile(ctx context.Context, path string, fileHeader *multipa
 		src, _ = fileHeader.Open()
 	}
 
-	input := &s3manager.UploadInput{
-		Bucket:      aws.String(d.bucket),
-		Key:         aws.String(path),
-		Body:        src,
-		ContentType: aws.String(contentType),
-		ACL:         aws.String("public-read"),
+	return d.scanWhile(ctx, "putFile", path, src, func(key string, src io.Reader) error {
+		input := &s3manager.UploadInput{
+			Bucket:      aws.String(d.bucket),
+			Key:         aws.String(key),
+			Body:        src,
+			ContentType: aws.String(contentType),
+			ACL:         aws.String(scanACL(path, key)),
+		}
+
+		_, err := d.uploader.UploadWithContext(ctx, input)
+		if err != nil {
+			return storage.NewStorageError("putFile", path, err)
+		}
+
+		return nil
+	}, d.promoteFunc(ctx, path), d.removeFunc(ctx))
+}
+
+// scanACL keeps objects private while they are stored under a temporary
+// key for scanning; they are made public once promoted to path
+func scanACL(path, key string) string {
+	if key != path {
+		return "private"
 	}
+	return "public-read"
+}
 
-	_, err = d.uploader.UploadWithContext(ctx, input)
-	if err != nil {
-		return storage.NewStorageError("putFile", path, err)
+// promoteFunc copies an object that scanned clean from its temporary key to
+// path, making it public, and deletes the temporary object
+func (d *S3Driver) promoteFunc(ctx context.Context, path string) func(tmpKey string) error {
+	return func(tmpKey string) error {
+		_, err := d.client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+			Bucket:     aws.String(d.bucket),
+			Key:        aws.String(path),
+			CopySource: aws.String(url.PathEscape(d.bucket + "/" + tmpKey)),
+			ACL:        aws.String("public-read"),
+		})
+		if err != nil {
+			return storage.NewStorageError("promote", path, err)
+		}
+
+		return d.Delete(context.WithoutCancel(ctx), tmpKey)
 	}
+}
 
-	return nil
+// removeFunc deletes the object stored at key for scanning
+func (d *S3Driver) removeFunc(ctx context.Context) func(key string) error {
+	return func(key string) error {
+		return d.Delete(context.WithoutCancel(ctx), key)
+	}
 }
 
 // Get retrieves content from the given path
@@ -439,6 +487,12 @@ func (d *S3Driver) Move(ctx context.Context, from, to string) error {
 	return nil
 }
 
+// Transform streams the source image down, transforms it in memory and
+// uploads the result to dstPath
+func (d *S3Driver) Transform(ctx context.Context, srcPath, dstPath string, opts storage.TransformOptions) error {
+	return transformObject(ctx, d, srcPath, dstPath, opts)
+}
+
 // Driver returns the driver name
 func (d *S3Driver) Driver() string {
 	return "s3"
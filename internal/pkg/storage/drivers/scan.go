@@ -0,0 +1,92 @@
+package drivers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	pathpkg "path"
+
+	"github.com/google/uuid"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+	"github.com/VeRJiL/go-template/internal/pkg/storage/scanner"
+)
+
+// virusScanner scans the content a driver stores, embedded by the drivers
+// supporting it
+type virusScanner struct {
+	scanner *config.ScannerConfig
+}
+
+// SetScanner scans everything stored from now on, rejecting infected
+// content with storage.ErrVirusDetected
+func (v *virusScanner) SetScanner(cfg config.ScannerConfig) {
+	v.scanner = &cfg
+}
+
+type scanResult struct {
+	clean  bool
+	threat string
+	err    error
+}
+
+// scanWhile stores content with store while the scanner reads a copy of it
+// in its own goroutine, so large files are never buffered whole. With a
+// scanner set, content is stored under a temporary key next to path and
+// only moved to path with promote once it scanned clean; content that is
+// infected, or could not be scanned, is deleted with remove and never
+// replaces what path held before.
+func (v *virusScanner) scanWhile(ctx context.Context, operation, path string, content io.Reader, store func(key string, content io.Reader) error, promote func(tmpKey string) error, remove func(key string) error) error {
+	if v.scanner == nil || !v.scanner.Enabled {
+		return store(path, content)
+	}
+
+	tmpKey := scanKey(path)
+	scanned, copied := io.Pipe()
+	results := make(chan scanResult, 1)
+	go func() {
+		clean, threat, err := scanner.ScanReader(ctx, scanned, *v.scanner)
+		// Drain what the scanner left so storing never blocks on the pipe
+		io.Copy(io.Discard, scanned)
+		results <- scanResult{clean: clean, threat: threat, err: err}
+	}()
+
+	err := store(tmpKey, io.TeeReader(content, copied))
+	copied.CloseWithError(err)
+	result := <-results
+	if err != nil {
+		return errors.Join(err, cleanupError(tmpKey, remove(tmpKey)))
+	}
+
+	switch {
+	case result.err != nil:
+		err = fmt.Errorf("failed to scan content: %w", result.err)
+	case !result.clean:
+		err = fmt.Errorf("%w: %s", storage.ErrVirusDetected, result.threat)
+	default:
+		if err := promote(tmpKey); err != nil {
+			return errors.Join(err, cleanupError(tmpKey, remove(tmpKey)))
+		}
+		return nil
+	}
+
+	return storage.NewStorageError(operation, path, errors.Join(err, cleanupError(tmpKey, remove(tmpKey))))
+}
+
+// scanKey returns the temporary key content for path is stored under while
+// it is scanned. It lives in the same directory so promoting it is a rename.
+func scanKey(path string) string {
+	dir, name := pathpkg.Split(path)
+	return dir + ".scanning-" + uuid.NewString() + "-" + name
+}
+
+// cleanupError describes a failure to delete content stored for scanning,
+// which is left behind under its temporary key
+func cleanupError(tmpKey string, err error) error {
+	if err == nil {
+		return nil
+	}
+	return fmt.Errorf("failed to remove %s: %w", tmpKey, err)
+}
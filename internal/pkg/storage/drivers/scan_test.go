@@ -0,0 +1,119 @@
+package drivers
+
+import (
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// fakeClamd answers INSTREAM scans over TCP with reply once the whole
+// stream has been read, and returns a scanner config using it
+func fakeClamd(t *testing.T, reply string) config.ScannerConfig {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				if _, err := io.CopyN(io.Discard, conn, int64(len("zINSTREAM\x00"))); err != nil {
+					return
+				}
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(io.Discard, conn, int64(size)); err != nil {
+						return
+					}
+				}
+				conn.Write([]byte(reply + "\x00"))
+			}(conn)
+		}
+	}()
+
+	return config.ScannerConfig{Enabled: true, Engine: "clamav", ClamAVSocket: ln.Addr().String(), Timeout: 5 * time.Second}
+}
+
+func TestLocalDriverScan(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should store clean content", func(t *testing.T) {
+		tempDir := t.TempDir()
+		driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
+		driver.SetScanner(fakeClamd(t, "stream: OK"))
+
+		content := strings.Repeat("clean ", 100000)
+		require.NoError(t, driver.Put(ctx, "docs/a.txt", strings.NewReader(content)))
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "docs/a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, content, string(data))
+	})
+
+	t.Run("should delete infected content", func(t *testing.T) {
+		tempDir := t.TempDir()
+		driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
+		driver.SetScanner(fakeClamd(t, "stream: Eicar-Signature FOUND"))
+
+		err := driver.Put(ctx, "docs/a.txt", strings.NewReader("infected"))
+
+		assert.ErrorIs(t, err, storage.ErrVirusDetected)
+		assert.ErrorContains(t, err, "Eicar-Signature")
+		assert.NoFileExists(t, filepath.Join(tempDir, "docs/a.txt"))
+	})
+
+	t.Run("should delete content that could not be scanned", func(t *testing.T) {
+		tempDir := t.TempDir()
+		driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
+		driver.SetScanner(fakeClamd(t, "INSTREAM size limit exceeded. ERROR"))
+
+		err := driver.PutChunked(ctx, "docs/a.txt", strings.NewReader("content"), 2)
+
+		assert.ErrorContains(t, err, "failed to scan content")
+		assert.NotErrorIs(t, err, storage.ErrVirusDetected)
+		assert.NoFileExists(t, filepath.Join(tempDir, "docs/a.txt"))
+	})
+
+	t.Run("should keep the file an infected upload would replace", func(t *testing.T) {
+		tempDir := t.TempDir()
+		driver := NewLocalDriver(tempDir, "http://localhost", "/storage")
+		require.NoError(t, driver.Put(ctx, "docs/a.txt", strings.NewReader("original")))
+		driver.SetScanner(fakeClamd(t, "stream: Eicar-Signature FOUND"))
+
+		err := driver.Put(ctx, "docs/a.txt", strings.NewReader("infected"))
+		assert.ErrorIs(t, err, storage.ErrVirusDetected)
+
+		data, err := os.ReadFile(filepath.Join(tempDir, "docs/a.txt"))
+		require.NoError(t, err)
+		assert.Equal(t, "original", string(data))
+
+		entries, err := os.ReadDir(filepath.Join(tempDir, "docs"))
+		require.NoError(t, err)
+		assert.Len(t, entries, 1, "temporary scan files must be removed")
+	})
+}
@@ -0,0 +1,25 @@
+package drivers
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/VeRJiL/go-template/internal/pkg/storage"
+)
+
+// transformObject downloads the source image, transforms it in memory and
+// uploads the result, for drivers without direct access to the file
+func transformObject(ctx context.Context, s storage.Storage, srcPath, dstPath string, opts storage.TransformOptions) error {
+	src, err := s.Get(ctx, srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	var transformed bytes.Buffer
+	if err := storage.TransformImage(&transformed, src, dstPath, opts); err != nil {
+		return storage.NewStorageError("transform", srcPath, err)
+	}
+
+	return s.Put(ctx, dstPath, &transformed)
+}
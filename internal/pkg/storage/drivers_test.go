@@ -0,0 +1,5 @@
+package storage_test
+
+// The manager tests create disks of the built-in drivers, which register
+// themselves when the drivers package is imported
+import _ "github.com/VeRJiL/go-template/internal/pkg/storage/drivers"
@@ -6,16 +6,23 @@ import (
 	"io"
 	"mime/multipart"
 	"strings"
+	"sync"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+
 	"github.com/VeRJiL/go-template/internal/config"
-	"github.com/VeRJiL/go-template/internal/pkg/storage/drivers"
 )
 
 // Manager manages multiple storage drivers similar to Laravel's Storage facade
 type Manager struct {
 	drivers    map[string]Storage
 	defaultDisk string
+	chunkSize   int64
+	dedupIndex  *redis.Client         // content hashes for PutDeduped, nil when disabled
+	quotas      *QuotaManager         // usage accounting for PutWithQuota, nil when disabled
+	scanner     *config.ScannerConfig // virus scan of uploads, nil when disabled
 }
 
 // NewManager creates a new storage manager
@@ -23,89 +30,52 @@ func NewManager(cfg *config.StorageConfig) (*Manager, error) {
 	manager := &Manager{
 		drivers:     make(map[string]Storage),
 		defaultDisk: cfg.Provider,
+		chunkSize:   cfg.ChunkSize,
 	}
-
-	// Initialize local driver
-	if cfg.Provider == "local" || cfg.Local.Path != "" {
-		localDriver := drivers.NewLocalDriver(
-			cfg.Local.Path,
-			"", // Base URL (will be set from server config)
-			cfg.Local.URLPrefix,
-		)
-		manager.drivers["local"] = localDriver
+	if manager.chunkSize <= 0 {
+		manager.chunkSize = DefaultChunkSize
 	}
 
-	// Initialize S3 driver
-	if cfg.Provider == "s3" || (cfg.S3.Bucket != "" && cfg.S3.AccessKey != "") {
-		s3Config := drivers.S3Config{
-			Region:         cfg.S3.Region,
-			Bucket:         cfg.S3.Bucket,
-			AccessKey:      cfg.S3.AccessKey,
-			SecretKey:      cfg.S3.SecretKey,
-			UseSSL:         cfg.S3.UseSSL,
-			ForcePathStyle: cfg.S3.ForcePathStyle,
-			PublicURL:      "", // Can be configured if needed
-		}
+	// Initialize every registered driver the config sets up
+	driversMu.RLock()
+	registrations := append([]driverRegistration(nil), registeredDrivers...)
+	wrapEncrypted, loadMasterKey := encryptDisk, masterKeyLoader
+	driversMu.RUnlock()
 
-		s3Driver, err := drivers.NewS3Driver(s3Config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize S3 driver: %w", err)
+	for _, driver := range registrations {
+		if !driver.configured(cfg) {
+			continue
 		}
-		manager.drivers["s3"] = s3Driver
-	}
-
-	// Initialize MinIO driver
-	if cfg.Provider == "minio" || (cfg.MinIO.Endpoint != "" && cfg.MinIO.AccessKey != "") {
-		minioConfig := drivers.MinIOConfig{
-			Endpoint:  cfg.MinIO.Endpoint,
-			AccessKey: cfg.MinIO.AccessKey,
-			SecretKey: cfg.MinIO.SecretKey,
-			Bucket:    cfg.MinIO.Bucket,
-			UseSSL:    cfg.MinIO.UseSSL,
-			PublicURL: cfg.MinIO.PublicURL,
-		}
-
-		minioDriver, err := drivers.NewMinIODriver(minioConfig)
+		disk, err := driver.factory(cfg)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize MinIO driver: %w", err)
+			return nil, fmt.Errorf("failed to initialize %s driver: %w", driver.name, err)
 		}
-		manager.drivers["minio"] = minioDriver
+		manager.drivers[driver.name] = disk
 	}
 
-	// Initialize Cloudflare R2 driver
-	if cfg.Provider == "cloudflare_r2" || (cfg.CloudflareR2.AccountID != "" && cfg.CloudflareR2.AccessKey != "") {
-		r2Config := drivers.CloudflareR2Config{
-			AccountID: cfg.CloudflareR2.AccountID,
-			AccessKey: cfg.CloudflareR2.AccessKey,
-			SecretKey: cfg.CloudflareR2.SecretKey,
-			Bucket:    cfg.CloudflareR2.Bucket,
-			PublicURL: cfg.CloudflareR2.PublicURL,
+	// Wrap every disk with transparent encryption
+	if cfg.Encryption.Enabled {
+		if wrapEncrypted == nil {
+			return nil, fmt.Errorf("storage encryption is not registered")
 		}
-
-		r2Driver, err := drivers.NewCloudflareR2Driver(r2Config)
+		masterKey, err := loadMasterKey(cfg.Encryption)
 		if err != nil {
-			return nil, fmt.Errorf("failed to initialize Cloudflare R2 driver: %w", err)
-		}
-		manager.drivers["cloudflare_r2"] = r2Driver
-	}
-
-	// Initialize Backblaze B2 driver
-	if cfg.Provider == "backblaze_b2" || (cfg.BackblazeB2.Region != "" && cfg.BackblazeB2.KeyID != "") {
-		b2Config := drivers.BackblazeB2Config{
-			Region:    cfg.BackblazeB2.Region,
-			KeyID:     cfg.BackblazeB2.KeyID,
-			KeySecret: cfg.BackblazeB2.KeySecret,
-			Bucket:    cfg.BackblazeB2.Bucket,
-			PublicURL: cfg.BackblazeB2.PublicURL,
+			return nil, fmt.Errorf("failed to load storage master key: %w", err)
 		}
 
-		b2Driver, err := drivers.NewBackblazeB2Driver(b2Config)
-		if err != nil {
-			return nil, fmt.Errorf("failed to initialize Backblaze B2 driver: %w", err)
+		for name, driver := range manager.drivers {
+			encrypted, err := wrapEncrypted(driver, masterKey)
+			if err != nil {
+				return nil, fmt.Errorf("failed to initialize encryption for %s: %w", name, err)
+			}
+			manager.drivers[name] = encrypted
 		}
-		manager.drivers["backblaze_b2"] = b2Driver
 	}
 
+	// Scan uploads for viruses. Encrypted disks only see ciphertext, so
+	// PutFile scans their files before storing them.
+	manager.SetScanner(cfg.Scanner)
+
 	// Validate default driver exists
 	if _, exists := manager.drivers[manager.defaultDisk]; !exists {
 		return nil, fmt.Errorf("default storage driver '%s' not configured", manager.defaultDisk)
@@ -114,6 +84,56 @@ func NewManager(cfg *config.StorageConfig) (*Manager, error) {
 	return manager, nil
 }
 
+// DriverFactory creates the disk of a driver from the storage config
+type DriverFactory func(cfg *config.StorageConfig) (Storage, error)
+
+// EncryptionFactory wraps a disk with transparent encryption under masterKey
+type EncryptionFactory func(disk Storage, masterKey []byte) (Storage, error)
+
+// MasterKeyLoader reads the master key of storage encryption
+type MasterKeyLoader func(cfg config.StorageEncryptionConfig) ([]byte, error)
+
+// driverRegistration is a driver NewManager creates when configured
+// reports the config sets it up
+type driverRegistration struct {
+	name       string
+	configured func(cfg *config.StorageConfig) bool
+	factory    DriverFactory
+}
+
+var (
+	driversMu         sync.RWMutex
+	registeredDrivers []driverRegistration
+	encryptDisk       EncryptionFactory
+	masterKeyLoader   MasterKeyLoader
+)
+
+// RegisterDriver makes a driver available to NewManager under name, created
+// when configured returns true for the storage config. The built-in drivers
+// register themselves when the drivers package is imported, which keeps
+// this package free of an import of its drivers.
+func RegisterDriver(name string, configured func(cfg *config.StorageConfig) bool, factory DriverFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+
+	for i, driver := range registeredDrivers {
+		if driver.name == name {
+			registeredDrivers[i] = driverRegistration{name, configured, factory}
+			return
+		}
+	}
+	registeredDrivers = append(registeredDrivers, driverRegistration{name, configured, factory})
+}
+
+// RegisterEncryption sets how NewManager encrypts its disks when
+// STORAGE_ENCRYPTION_ENABLED is set
+func RegisterEncryption(loadKey MasterKeyLoader, wrap EncryptionFactory) {
+	driversMu.Lock()
+	defer driversMu.Unlock()
+	masterKeyLoader = loadKey
+	encryptDisk = wrap
+}
+
 // Disk returns a storage driver by name (similar to Laravel's Storage::disk())
 func (m *Manager) Disk(name string) Storage {
 	if driver, exists := m.drivers[name]; exists {
@@ -122,6 +142,12 @@ func (m *Manager) Disk(name string) Storage {
 	return m.drivers[m.defaultDisk] // Fallback to default
 }
 
+// SignedFileServer is implemented by disks that serve the temporary URLs
+// they generate themselves, like the local driver
+type SignedFileServer interface {
+	ServeSignedFile(c *gin.Context)
+}
+
 // Default returns the default storage driver
 func (m *Manager) Default() Storage {
 	return m.drivers[m.defaultDisk]
@@ -132,7 +158,21 @@ func (m *Manager) Put(ctx context.Context, path string, content io.Reader) error
 	return m.Default().Put(ctx, path, content)
 }
 
+// PutChunked stores content in parts on the default disk. A chunkSize of
+// zero uses the configured chunk size.
+func (m *Manager) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	if chunkSize <= 0 {
+		chunkSize = m.chunkSize
+	}
+	return m.Default().PutChunked(ctx, path, content, chunkSize)
+}
+
+// PutFile stores an uploaded file on the default disk, failing with
+// ErrVirusDetected when the scanner finds a threat in it
 func (m *Manager) PutFile(ctx context.Context, path string, file *multipart.FileHeader) error {
+	if err := m.scanFile(ctx, path, file); err != nil {
+		return err
+	}
 	return m.Default().PutFile(ctx, path, file)
 }
 
@@ -176,6 +216,23 @@ func (m *Manager) Move(ctx context.Context, from, to string) error {
 	return m.Default().Move(ctx, from, to)
 }
 
+// Transform writes a resized and re-encoded copy of the image at srcPath
+// to dstPath on the default disk. It returns the source file's information
+// with the new copy listed under its variant name.
+func (m *Manager) Transform(ctx context.Context, srcPath, dstPath string, opts TransformOptions) (*FileInfo, error) {
+	if err := m.Default().Transform(ctx, srcPath, dstPath, opts); err != nil {
+		return nil, err
+	}
+
+	info, err := m.GetFileInfo(ctx, srcPath)
+	if err != nil {
+		return nil, err
+	}
+	info.Variants = map[string]string{opts.Variant(): dstPath}
+
+	return info, nil
+}
+
 // Advanced methods for file uploads and management
 
 // StoreUploadedFile stores an uploaded file with automatic path generation
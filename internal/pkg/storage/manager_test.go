@@ -22,6 +22,7 @@ type MockStorage struct {
 	driver     string
 	files      map[string][]byte
 	lastAccess map[string]time.Time
+	chunkSizes map[string]int64
 }
 
 func NewMockStorage(driver string) *MockStorage {
@@ -29,6 +30,7 @@ func NewMockStorage(driver string) *MockStorage {
 		driver:     driver,
 		files:      make(map[string][]byte),
 		lastAccess: make(map[string]time.Time),
+		chunkSizes: make(map[string]int64),
 	}
 }
 
@@ -42,6 +44,11 @@ func (m *MockStorage) Put(ctx context.Context, path string, content io.Reader) e
 	return nil
 }
 
+func (m *MockStorage) PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error {
+	m.chunkSizes[path] = chunkSize
+	return m.Put(ctx, path, content)
+}
+
 func (m *MockStorage) PutFile(ctx context.Context, path string, file *multipart.FileHeader) error {
 	src, err := file.Open()
 	if err != nil {
@@ -175,6 +182,19 @@ func (m *MockStorage) Move(ctx context.Context, from, to string) error {
 	return m.Delete(ctx, from)
 }
 
+func (m *MockStorage) Transform(ctx context.Context, srcPath, dstPath string, opts TransformOptions) error {
+	data, exists := m.files[srcPath]
+	if !exists {
+		return NewStorageError("transform", srcPath, os.ErrNotExist)
+	}
+
+	var transformed bytes.Buffer
+	if err := TransformImage(&transformed, bytes.NewReader(data), dstPath, opts); err != nil {
+		return NewStorageError("transform", srcPath, err)
+	}
+	return m.Put(ctx, dstPath, &transformed)
+}
+
 func (m *MockStorage) Driver() string {
 	return m.driver
 }
@@ -225,7 +245,7 @@ func TestNewManager(t *testing.T) {
 				Path: tempDir,
 			},
 			// S3 not configured (empty bucket/access key)
-			S3: config.S3StorageConfig{},
+			S3: config.S3Config{},
 		}
 
 		manager, err := NewManager(cfg)
@@ -245,7 +265,7 @@ func TestNewManager(t *testing.T) {
 				Path: tempDir,
 			},
 			// MinIO not configured
-			MinIO: config.MinIOStorageConfig{},
+			MinIO: config.MinIOConfig{},
 		}
 
 		manager, err := NewManager(cfg)
@@ -255,6 +275,31 @@ func TestNewManager(t *testing.T) {
 		assert.Contains(t, drivers, "local")
 		assert.NotContains(t, drivers, "minio")
 	})
+
+	t.Run("should default the chunk size to 5 MB", func(t *testing.T) {
+		cfg := &config.StorageConfig{
+			Provider: "local",
+			Local:    config.LocalStorageConfig{Path: t.TempDir()},
+		}
+
+		manager, err := NewManager(cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(5*1024*1024), manager.chunkSize)
+	})
+
+	t.Run("should use the configured chunk size", func(t *testing.T) {
+		cfg := &config.StorageConfig{
+			Provider:  "local",
+			Local:     config.LocalStorageConfig{Path: t.TempDir()},
+			ChunkSize: 8 * 1024 * 1024,
+		}
+
+		manager, err := NewManager(cfg)
+
+		require.NoError(t, err)
+		assert.Equal(t, int64(8*1024*1024), manager.chunkSize)
+	})
 }
 
 func TestManagerWithMockDrivers(t *testing.T) {
@@ -265,6 +310,7 @@ func TestManagerWithMockDrivers(t *testing.T) {
 			"s3":    NewMockStorage("s3"),
 		},
 		defaultDisk: "local",
+		chunkSize:   DefaultChunkSize,
 	}
 
 	t.Run("should return correct default driver", func(t *testing.T) {
@@ -296,6 +342,18 @@ func TestManagerWithMockDrivers(t *testing.T) {
 		assert.True(t, exists)
 	})
 
+	t.Run("should delegate PutChunked with the configured chunk size", func(t *testing.T) {
+		ctx := context.Background()
+		local := manager.Default().(*MockStorage)
+
+		require.NoError(t, manager.PutChunked(ctx, "test/default.bin", strings.NewReader("large content"), 0))
+		require.NoError(t, manager.PutChunked(ctx, "test/custom.bin", strings.NewReader("large content"), 10*1024*1024))
+
+		assert.Equal(t, DefaultChunkSize, local.chunkSizes["test/default.bin"])
+		assert.Equal(t, int64(10*1024*1024), local.chunkSizes["test/custom.bin"])
+		assert.Equal(t, []byte("large content"), local.files["test/default.bin"])
+	})
+
 	t.Run("should delegate Get to default driver", func(t *testing.T) {
 		ctx := context.Background()
 		content := "test content"
@@ -577,6 +635,38 @@ func TestManagerFileOperations(t *testing.T) {
 	})
 }
 
+func TestManagerTransform(t *testing.T) {
+	ctx := context.Background()
+	manager := &Manager{
+		drivers: map[string]Storage{
+			"local": NewMockStorage("local"),
+		},
+		defaultDisk: "local",
+	}
+	require.NoError(t, manager.Put(ctx, "images/photo.png", bytes.NewReader(testPNG(t, 64, 32))))
+
+	t.Run("should store the variant and list it on the source", func(t *testing.T) {
+		opts := TransformOptions{Width: 16, Height: 16, Crop: true, Format: "webp"}
+
+		info, err := manager.Transform(ctx, "images/photo.png", "images/photo-thumb.webp", opts)
+		require.NoError(t, err)
+
+		assert.Equal(t, "images/photo.png", info.Path)
+		assert.Equal(t, map[string]string{"16x16-crop.webp": "images/photo-thumb.webp"}, info.Variants)
+
+		exists, err := manager.Exists(ctx, "images/photo-thumb.webp")
+		require.NoError(t, err)
+		assert.True(t, exists)
+	})
+
+	t.Run("should fail for a missing source", func(t *testing.T) {
+		info, err := manager.Transform(ctx, "images/missing.png", "images/missing-thumb.png", TransformOptions{Width: 16})
+
+		assert.Error(t, err)
+		assert.Nil(t, info)
+	})
+}
+
 func TestManagerAvailableDrivers(t *testing.T) {
 	manager := &Manager{
 		drivers: map[string]Storage{
@@ -0,0 +1,242 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// usageKeyPrefix prefixes the Redis hashes holding the bytes stored by
+	// each owner
+	usageKeyPrefix = "storage_usage:"
+	// usageRankingKey is a sorted set of owners by bytes stored, kept in step
+	// with the usage hashes so the heaviest owners can be listed cheaply
+	usageRankingKey = "storage_usage_ranking"
+	// usageField is the field of a usage hash holding the bytes stored
+	usageField = "bytes"
+	// topUsageCount is how many owners the quotas endpoint lists
+	topUsageCount = 10
+)
+
+var (
+	// ErrQuotaExceeded is returned when storing a file would take its owner
+	// over their quota
+	ErrQuotaExceeded = errors.New("storage quota exceeded")
+	// ErrQuotasDisabled is returned by PutWithQuota on a manager without a
+	// quota manager
+	ErrQuotasDisabled = errors.New("storage quotas are not configured")
+)
+
+// reserveUsage adds ARGV[1] bytes to an owner's usage unless that takes it
+// over the limit in ARGV[2] (none when not positive), returning -1 instead
+var reserveUsage = redis.NewScript(`
+local used = tonumber(redis.call("HGET", KEYS[1], "bytes") or "0")
+local size = tonumber(ARGV[1])
+local limit = tonumber(ARGV[2])
+if limit > 0 and used + size > limit then
+	return -1
+end
+used = redis.call("HINCRBY", KEYS[1], "bytes", size)
+redis.call("ZADD", KEYS[2], used, ARGV[3])
+return used
+`)
+
+// addUsage adds ARGV[1] bytes to an owner's usage, never going below zero
+var addUsage = redis.NewScript(`
+local used = redis.call("HINCRBY", KEYS[1], "bytes", ARGV[1])
+if used < 0 then
+	used = 0
+	redis.call("HSET", KEYS[1], "bytes", 0)
+end
+redis.call("ZADD", KEYS[2], used, ARGV[2])
+return used
+`)
+
+// OwnerUsage is the number of bytes an owner has stored
+type OwnerUsage struct {
+	OwnerID string `json:"owner_id"`
+	Bytes   int64  `json:"bytes"`
+}
+
+// QuotaManager tracks the bytes stored by each owner (a user or a tenant)
+// in Redis hashes at storage_usage:<ownerID> and enforces a limit on them.
+//
+// Usage counts the bytes written through PutWithQuota and whatever is passed
+// to RecordUsage; callers deleting or replacing files record the negative
+// delta themselves.
+type QuotaManager struct {
+	client *redis.Client
+	limit  int64
+}
+
+// NewQuotaManager creates a quota manager limiting every owner to limit
+// bytes. A limit of zero or less only tracks usage.
+func NewQuotaManager(client *redis.Client, limit int64) *QuotaManager {
+	return &QuotaManager{
+		client: client,
+		limit:  limit,
+	}
+}
+
+// CheckQuota returns ErrQuotaExceeded when storing fileSize more bytes would
+// take the owner over the limit
+func (q *QuotaManager) CheckQuota(ctx context.Context, ownerID string, fileSize int64) error {
+	used, err := q.GetUsage(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	return quotaError(used, fileSize, q.limit)
+}
+
+// RecordUsage adds delta bytes, negative for removed files, to the owner's
+// usage
+func (q *QuotaManager) RecordUsage(ctx context.Context, ownerID string, delta int64) error {
+	keys := []string{usageKeyPrefix + ownerID, usageRankingKey}
+	if err := addUsage.Run(ctx, q.client, keys, delta, ownerID).Err(); err != nil {
+		return fmt.Errorf("failed to record storage usage of %s: %w", ownerID, err)
+	}
+	return nil
+}
+
+// GetUsage returns the bytes the owner has stored
+func (q *QuotaManager) GetUsage(ctx context.Context, ownerID string) (int64, error) {
+	used, err := q.client.HGet(ctx, usageKeyPrefix+ownerID, usageField).Int64()
+	if errors.Is(err, redis.Nil) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read storage usage of %s: %w", ownerID, err)
+	}
+	return used, nil
+}
+
+// TopUsage returns the n owners storing the most bytes, heaviest first
+func (q *QuotaManager) TopUsage(ctx context.Context, n int) ([]OwnerUsage, error) {
+	ranked, err := q.client.ZRevRangeWithScores(ctx, usageRankingKey, 0, int64(n-1)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rank storage usage: %w", err)
+	}
+
+	usage := make([]OwnerUsage, 0, len(ranked))
+	for _, entry := range ranked {
+		owner, _ := entry.Member.(string)
+		usage = append(usage, OwnerUsage{OwnerID: owner, Bytes: int64(entry.Score)})
+	}
+	return usage, nil
+}
+
+// reserve atomically adds size bytes to the owner's usage if that stays
+// within limit
+func (q *QuotaManager) reserve(ctx context.Context, ownerID string, size, limit int64) error {
+	keys := []string{usageKeyPrefix + ownerID, usageRankingKey}
+	used, err := reserveUsage.Run(ctx, q.client, keys, size, limit, ownerID).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to reserve storage quota of %s: %w", ownerID, err)
+	}
+	if used < 0 {
+		current, err := q.GetUsage(ctx, ownerID)
+		if err != nil {
+			return fmt.Errorf("%w: %d more bytes requested", ErrQuotaExceeded, size)
+		}
+		return quotaError(current, size, limit)
+	}
+	return nil
+}
+
+func quotaError(used, size, limit int64) error {
+	if limit > 0 && used+size > limit {
+		return fmt.Errorf("%w: %d of %d bytes used, %d more requested", ErrQuotaExceeded, used, limit, size)
+	}
+	return nil
+}
+
+// SetQuotaManager enables PutWithQuota, accounting usage with quotas
+func (m *Manager) SetQuotaManager(quotas *QuotaManager) {
+	m.quotas = quotas
+}
+
+// Quotas returns the quota manager, nil when quotas are disabled
+func (m *Manager) Quotas() *QuotaManager {
+	return m.quotas
+}
+
+// PutWithQuota stores content at path on the default disk and adds its size
+// to the owner's usage. It fails with ErrQuotaExceeded, without writing,
+// when the owner would go over maxBytes, or over the quota manager's limit
+// when maxBytes is zero. The usage is rolled back if the write fails.
+func (m *Manager) PutWithQuota(ctx context.Context, ownerID, path string, r io.Reader, maxBytes int64) error {
+	if m.quotas == nil {
+		return ErrQuotasDisabled
+	}
+	if maxBytes <= 0 {
+		maxBytes = m.quotas.limit
+	}
+
+	used, err := m.quotas.GetUsage(ctx, ownerID)
+	if err != nil {
+		return err
+	}
+	if err := quotaError(used, 0, maxBytes); err != nil {
+		return err
+	}
+
+	// Spool the content to learn its size, reading no more than one byte past
+	// what the quota leaves
+	spool, err := os.CreateTemp("", "storage-quota-*")
+	if err != nil {
+		return fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	defer func() {
+		spool.Close()
+		os.Remove(spool.Name())
+	}()
+
+	content := r
+	if maxBytes > 0 {
+		content = io.LimitReader(r, maxBytes-used+1)
+	}
+	size, err := io.Copy(spool, content)
+	if err != nil {
+		return fmt.Errorf("failed to buffer upload: %w", err)
+	}
+	if err := quotaError(used, size, maxBytes); err != nil {
+		return err
+	}
+
+	// Reserving checks the limit again, against uploads that ran meanwhile
+	if err := m.quotas.reserve(ctx, ownerID, size, maxBytes); err != nil {
+		return err
+	}
+
+	if _, err := spool.Seek(0, io.SeekStart); err != nil {
+		return errors.Join(fmt.Errorf("failed to buffer upload: %w", err), m.quotas.RecordUsage(ctx, ownerID, -size))
+	}
+	if err := m.Put(ctx, path, spool); err != nil {
+		return errors.Join(err, m.quotas.RecordUsage(ctx, ownerID, -size))
+	}
+
+	return nil
+}
+
+// NewQuotaHandler serves the owners storing the most bytes
+func NewQuotaHandler(quotas *QuotaManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		usage, err := quotas.TopUsage(c.Request.Context(), topUsageCount)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to list storage usage"})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"quotas":      usage,
+			"limit_bytes": quotas.limit,
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/pkg/containertest"
+)
+
+// failingPutStorage is a MockStorage whose writes always fail
+type failingPutStorage struct {
+	*MockStorage
+	err error
+}
+
+func (f *failingPutStorage) Put(ctx context.Context, path string, content io.Reader) error {
+	return f.err
+}
+
+func newQuotaManager(client *redis.Client, disk Storage, limit int64) *Manager {
+	manager := &Manager{
+		drivers:     map[string]Storage{"local": disk},
+		defaultDisk: "local",
+		chunkSize:   DefaultChunkSize,
+	}
+	manager.SetQuotaManager(NewQuotaManager(client, limit))
+	return manager
+}
+
+func TestQuotaManager(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should track usage per owner", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		quotas := NewQuotaManager(client, 100)
+
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", 60))
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", -20))
+		require.NoError(t, quotas.RecordUsage(ctx, "bob", 10))
+
+		used, err := quotas.GetUsage(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(40), used)
+
+		used, err = quotas.GetUsage(ctx, "carol")
+		require.NoError(t, err)
+		assert.Zero(t, used)
+	})
+
+	t.Run("should not go below zero", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		quotas := NewQuotaManager(client, 100)
+
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", 10))
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", -50))
+
+		used, err := quotas.GetUsage(ctx, "alice")
+		require.NoError(t, err)
+		assert.Zero(t, used)
+	})
+
+	t.Run("should check files against the limit", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		quotas := NewQuotaManager(client, 100)
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", 90))
+
+		assert.NoError(t, quotas.CheckQuota(ctx, "alice", 10))
+		assert.ErrorIs(t, quotas.CheckQuota(ctx, "alice", 11), ErrQuotaExceeded)
+		assert.NoError(t, NewQuotaManager(client, 0).CheckQuota(ctx, "alice", 1<<40))
+	})
+
+	t.Run("should rank owners by usage", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		quotas := NewQuotaManager(client, 0)
+		require.NoError(t, quotas.RecordUsage(ctx, "alice", 10))
+		require.NoError(t, quotas.RecordUsage(ctx, "bob", 30))
+		require.NoError(t, quotas.RecordUsage(ctx, "carol", 20))
+
+		top, err := quotas.TopUsage(ctx, 2)
+		require.NoError(t, err)
+		assert.Equal(t, []OwnerUsage{{OwnerID: "bob", Bytes: 30}, {OwnerID: "carol", Bytes: 20}}, top)
+	})
+}
+
+func TestManagerPutWithQuota(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+
+	t.Run("should store the file and record its size", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		disk := NewMockStorage("local")
+		manager := newQuotaManager(client, disk, 100)
+
+		require.NoError(t, manager.PutWithQuota(ctx, "alice", "docs/a.txt", strings.NewReader("hello"), 0))
+
+		assert.Equal(t, []byte("hello"), disk.files["docs/a.txt"])
+		used, err := manager.Quotas().GetUsage(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(5), used)
+	})
+
+	t.Run("should reject files over the quota without writing", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		disk := NewMockStorage("local")
+		manager := newQuotaManager(client, disk, 100)
+		require.NoError(t, manager.Quotas().RecordUsage(ctx, "alice", 8))
+
+		err := manager.PutWithQuota(ctx, "alice", "docs/a.txt", strings.NewReader("hello"), 10)
+
+		assert.ErrorIs(t, err, ErrQuotaExceeded)
+		assert.Empty(t, disk.files)
+		used, err := manager.Quotas().GetUsage(ctx, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, int64(8), used)
+	})
+
+	t.Run("should roll back the usage when the write fails", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		errDisk := errors.New("disk full")
+		manager := newQuotaManager(client, &failingPutStorage{MockStorage: NewMockStorage("local"), err: errDisk}, 100)
+
+		err := manager.PutWithQuota(ctx, "alice", "docs/a.txt", strings.NewReader("hello"), 0)
+
+		assert.ErrorIs(t, err, errDisk)
+		used, err := manager.Quotas().GetUsage(ctx, "alice")
+		require.NoError(t, err)
+		assert.Zero(t, used)
+	})
+}
+
+func TestQuotaHandler(t *testing.T) {
+	client := containertest.NewRedis(t)
+	ctx := context.Background()
+	gin.SetMode(gin.TestMode)
+
+	t.Run("should list the top ten owners by usage", func(t *testing.T) {
+		require.NoError(t, client.FlushDB(ctx).Err())
+		quotas := NewQuotaManager(client, 1000)
+		for i, owner := range []string{"a", "b", "c", "d", "e", "f", "g", "h", "i", "j", "k", "l"} {
+			require.NoError(t, quotas.RecordUsage(ctx, owner, int64(i+1)))
+		}
+
+		router := gin.New()
+		router.GET("/admin/storage/quotas", NewQuotaHandler(quotas))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/admin/storage/quotas", nil))
+
+		require.Equal(t, http.StatusOK, w.Code)
+		var body struct {
+			Quotas     []OwnerUsage `json:"quotas"`
+			LimitBytes int64        `json:"limit_bytes"`
+		}
+		require.NoError(t, json.Unmarshal(w.Body.Bytes(), &body))
+		require.Len(t, body.Quotas, 10)
+		assert.Equal(t, OwnerUsage{OwnerID: "l", Bytes: 12}, body.Quotas[0])
+		assert.Equal(t, int64(1000), body.LimitBytes)
+	})
+}
+
+func TestManagerQuotasDisabled(t *testing.T) {
+	manager := &Manager{drivers: map[string]Storage{"local": NewMockStorage("local")}, defaultDisk: "local"}
+
+	err := manager.PutWithQuota(context.Background(), "alice", "a", strings.NewReader("a"), 10)
+	assert.ErrorIs(t, err, ErrQuotasDisabled)
+}
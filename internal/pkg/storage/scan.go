@@ -0,0 +1,62 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"mime/multipart"
+
+	"github.com/VeRJiL/go-template/internal/config"
+	"github.com/VeRJiL/go-template/internal/pkg/storage/scanner"
+)
+
+// ErrVirusDetected is returned when the virus scanner finds a threat in
+// content being stored
+var ErrVirusDetected = errors.New("virus detected")
+
+// ScannerSetter is implemented by drivers scanning the content they store
+// as it is written, the local and S3 drivers
+type ScannerSetter interface {
+	SetScanner(cfg config.ScannerConfig)
+}
+
+// SetScanner scans uploads with cfg. Disks that scan as they write are set
+// up to do so; PutFile scans files for the others before storing them.
+func (m *Manager) SetScanner(cfg config.ScannerConfig) {
+	if !cfg.Enabled {
+		return
+	}
+
+	m.scanner = &cfg
+	for _, driver := range m.drivers {
+		if setter, ok := driver.(ScannerSetter); ok {
+			setter.SetScanner(cfg)
+		}
+	}
+}
+
+// scanFile scans an uploaded file before it is stored on a disk that does
+// not scan by itself
+func (m *Manager) scanFile(ctx context.Context, path string, file *multipart.FileHeader) error {
+	if m.scanner == nil {
+		return nil
+	}
+	if _, ok := m.Default().(ScannerSetter); ok {
+		return nil
+	}
+
+	src, err := file.Open()
+	if err != nil {
+		return NewStorageError("putFile", path, err)
+	}
+	defer src.Close()
+
+	clean, threat, err := scanner.ScanReader(ctx, src, *m.scanner)
+	if err != nil {
+		return NewStorageError("putFile", path, fmt.Errorf("failed to scan content: %w", err))
+	}
+	if !clean {
+		return NewStorageError("putFile", path, fmt.Errorf("%w: %s", ErrVirusDetected, threat))
+	}
+	return nil
+}
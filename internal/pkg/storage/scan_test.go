@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"mime/multipart"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// scanningStorage is a MockStorage scanning what it stores by itself
+type scanningStorage struct {
+	*MockStorage
+	scanner *config.ScannerConfig
+}
+
+func (s *scanningStorage) SetScanner(cfg config.ScannerConfig) {
+	s.scanner = &cfg
+}
+
+// fakeClamd answers INSTREAM scans over TCP with reply once the whole
+// stream has been read, and returns a scanner config using it
+func fakeClamd(t *testing.T, reply string) config.ScannerConfig {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				if _, err := io.CopyN(io.Discard, conn, int64(len("zINSTREAM\x00"))); err != nil {
+					return
+				}
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(io.Discard, conn, int64(size)); err != nil {
+						return
+					}
+				}
+				conn.Write([]byte(reply + "\x00"))
+			}(conn)
+		}
+	}()
+
+	return config.ScannerConfig{Enabled: true, Engine: "clamav", ClamAVSocket: ln.Addr().String(), Timeout: 5 * time.Second}
+}
+
+func newFileHeader(t *testing.T, content string) *multipart.FileHeader {
+	t.Helper()
+
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	part, err := writer.CreateFormFile("file", "upload.txt")
+	require.NoError(t, err)
+	_, err = part.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	form, err := multipart.NewReader(body, writer.Boundary()).ReadForm(10 << 20)
+	require.NoError(t, err)
+	t.Cleanup(func() { form.RemoveAll() })
+	return form.File["file"][0]
+}
+
+func TestManagerScanner(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("should hand the scanner to disks scanning by themselves", func(t *testing.T) {
+		scanning := &scanningStorage{MockStorage: NewMockStorage("local")}
+		manager := &Manager{drivers: map[string]Storage{"local": scanning, "s3": NewMockStorage("s3")}, defaultDisk: "local"}
+
+		manager.SetScanner(config.ScannerConfig{Enabled: true, Engine: "clamav", ClamAVSocket: "/var/run/clamav/clamd.ctl"})
+
+		require.NotNil(t, scanning.scanner)
+		assert.Equal(t, "/var/run/clamav/clamd.ctl", scanning.scanner.ClamAVSocket)
+	})
+
+	t.Run("should ignore a disabled scanner", func(t *testing.T) {
+		scanning := &scanningStorage{MockStorage: NewMockStorage("local")}
+		manager := &Manager{drivers: map[string]Storage{"local": scanning}, defaultDisk: "local"}
+
+		manager.SetScanner(config.ScannerConfig{Engine: "clamav"})
+
+		assert.Nil(t, scanning.scanner)
+		assert.Nil(t, manager.scanner)
+	})
+
+	t.Run("should reject infected files before storing them", func(t *testing.T) {
+		disk := NewMockStorage("local")
+		manager := &Manager{drivers: map[string]Storage{"local": disk}, defaultDisk: "local"}
+		manager.SetScanner(fakeClamd(t, "stream: Eicar-Signature FOUND"))
+
+		err := manager.PutFile(ctx, "uploads/a.txt", newFileHeader(t, "infected"))
+
+		assert.ErrorIs(t, err, ErrVirusDetected)
+		assert.ErrorContains(t, err, "Eicar-Signature")
+		assert.Empty(t, disk.files)
+	})
+
+	t.Run("should store clean files", func(t *testing.T) {
+		disk := NewMockStorage("local")
+		manager := &Manager{drivers: map[string]Storage{"local": disk}, defaultDisk: "local"}
+		manager.SetScanner(fakeClamd(t, "stream: OK"))
+
+		require.NoError(t, manager.PutFile(ctx, "uploads/a.txt", newFileHeader(t, "clean")))
+
+		assert.Equal(t, []byte("clean"), disk.files["uploads/a.txt"])
+	})
+}
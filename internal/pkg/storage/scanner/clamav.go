@@ -0,0 +1,86 @@
+package scanner
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+)
+
+// clamAVChunkSize is the size of the chunks streamed to clamd, well under
+// its default StreamMaxLength
+const clamAVChunkSize = 64 * 1024
+
+// scanClamAV streams r to clamd with the INSTREAM command: chunks prefixed
+// with their length in 4 bytes, ended by a zero length chunk
+func scanClamAV(ctx context.Context, r io.Reader, address string) (bool, string, error) {
+	network := "unix"
+	if !strings.HasPrefix(address, "/") {
+		network = "tcp"
+	}
+
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, network, address)
+	if err != nil {
+		return false, "", fmt.Errorf("failed to connect to clamd: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblock reads and writes when the context ends
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	if _, err := conn.Write([]byte("zINSTREAM\x00")); err != nil {
+		return false, "", fmt.Errorf("failed to send scan command: %w", err)
+	}
+
+	chunk := make([]byte, clamAVChunkSize)
+	size := make([]byte, 4)
+	for {
+		n, readErr := r.Read(chunk)
+		if n > 0 {
+			binary.BigEndian.PutUint32(size, uint32(n))
+			if _, err := conn.Write(size); err != nil {
+				return false, "", fmt.Errorf("failed to stream content to clamd: %w", err)
+			}
+			if _, err := conn.Write(chunk[:n]); err != nil {
+				return false, "", fmt.Errorf("failed to stream content to clamd: %w", err)
+			}
+		}
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return false, "", fmt.Errorf("failed to read content: %w", readErr)
+		}
+	}
+	if _, err := conn.Write([]byte{0, 0, 0, 0}); err != nil {
+		return false, "", fmt.Errorf("failed to stream content to clamd: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && reply == "" {
+		if ctx.Err() != nil {
+			return false, "", fmt.Errorf("clamd scan timed out: %w", ctx.Err())
+		}
+		return false, "", fmt.Errorf("failed to read clamd reply: %w", err)
+	}
+	return parseClamAVReply(strings.TrimRight(reply, "\x00\n"))
+}
+
+// parseClamAVReply reads "stream: OK", "stream: <threat> FOUND" or
+// "<message> ERROR"
+func parseClamAVReply(reply string) (bool, string, error) {
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return true, "", nil
+	case strings.HasSuffix(result, " FOUND"):
+		return false, strings.TrimSuffix(result, " FOUND"), nil
+	default:
+		return false, "", fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
@@ -0,0 +1,41 @@
+// Package scanner checks uploaded content for viruses with ClamAV or
+// VirusTotal before storage accepts it
+package scanner
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// Supported scan engines
+const (
+	EngineClamAV     = "clamav"
+	EngineVirusTotal = "virustotal"
+)
+
+// ScanReader reads r to its end, unless the scan fails, and reports whether
+// it is clean, naming the threat found otherwise. The scan is bounded by cfg.Timeout. A disabled
+// scanner reports everything clean without reading r.
+func ScanReader(ctx context.Context, r io.Reader, cfg config.ScannerConfig) (clean bool, threat string, err error) {
+	if !cfg.Enabled {
+		return true, "", nil
+	}
+
+	if cfg.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cfg.Timeout)
+		defer cancel()
+	}
+
+	switch cfg.Engine {
+	case EngineClamAV:
+		return scanClamAV(ctx, r, cfg.ClamAVSocket)
+	case EngineVirusTotal:
+		return scanVirusTotal(ctx, r, cfg.VirusTotalAPIKey)
+	default:
+		return false, "", fmt.Errorf("unknown scan engine %q", cfg.Engine)
+	}
+}
@@ -0,0 +1,217 @@
+package scanner
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/VeRJiL/go-template/internal/config"
+)
+
+// infected marks content the fake engines flag. The EICAR test string is
+// not used so antivirus software leaves this file alone.
+const infected = "FAKE-ANTIVIRUS-TEST-SIGNATURE"
+
+// fakeClamd answers INSTREAM scans over TCP, flagging content holding the
+// infected marker, and returns its address
+func fakeClamd(t *testing.T) string {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func(conn net.Conn) {
+				defer conn.Close()
+
+				command := make([]byte, len("zINSTREAM\x00"))
+				if _, err := io.ReadFull(conn, command); err != nil || string(command) != "zINSTREAM\x00" {
+					conn.Write([]byte("UNKNOWN COMMAND ERROR\x00"))
+					return
+				}
+
+				var content bytes.Buffer
+				for {
+					var size uint32
+					if err := binary.Read(conn, binary.BigEndian, &size); err != nil {
+						return
+					}
+					if size == 0 {
+						break
+					}
+					if _, err := io.CopyN(&content, conn, int64(size)); err != nil {
+						return
+					}
+				}
+
+				if strings.Contains(content.String(), infected) {
+					conn.Write([]byte("stream: Eicar-Signature FOUND\x00"))
+					return
+				}
+				conn.Write([]byte("stream: OK\x00"))
+			}(conn)
+		}
+	}()
+
+	return ln.Addr().String()
+}
+
+func TestScanReaderClamAV(t *testing.T) {
+	cfg := config.ScannerConfig{Enabled: true, Engine: EngineClamAV, ClamAVSocket: fakeClamd(t), Timeout: 5 * time.Second}
+
+	t.Run("should report clean content", func(t *testing.T) {
+		clean, threat, err := ScanReader(context.Background(), strings.NewReader(strings.Repeat("a", 3*clamAVChunkSize+1)), cfg)
+
+		require.NoError(t, err)
+		assert.True(t, clean)
+		assert.Empty(t, threat)
+	})
+
+	t.Run("should name the threat found", func(t *testing.T) {
+		clean, threat, err := ScanReader(context.Background(), strings.NewReader("header "+infected), cfg)
+
+		require.NoError(t, err)
+		assert.False(t, clean)
+		assert.Equal(t, "Eicar-Signature", threat)
+	})
+
+	t.Run("should fail when clamd is unreachable", func(t *testing.T) {
+		unreachable := cfg
+		unreachable.ClamAVSocket = "/nonexistent/clamd.ctl"
+
+		clean, _, err := ScanReader(context.Background(), strings.NewReader("data"), unreachable)
+
+		assert.Error(t, err)
+		assert.False(t, clean)
+	})
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	tests := []struct {
+		reply  string
+		clean  bool
+		threat string
+		err    bool
+	}{
+		{"stream: OK", true, "", false},
+		{"stream: Win.Test.EICAR_HDB-1 FOUND", false, "Win.Test.EICAR_HDB-1", false},
+		{"INSTREAM size limit exceeded. ERROR", false, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.reply, func(t *testing.T) {
+			clean, threat, err := parseClamAVReply(tt.reply)
+
+			assert.Equal(t, tt.clean, clean)
+			assert.Equal(t, tt.threat, threat)
+			assert.Equal(t, tt.err, err != nil)
+		})
+	}
+}
+
+func TestScanReaderVirusTotal(t *testing.T) {
+	originalURL, originalInterval := virusTotalURL, virusTotalPollInterval
+	t.Cleanup(func() { virusTotalURL, virusTotalPollInterval = originalURL, originalInterval })
+	virusTotalPollInterval = time.Millisecond
+
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-apikey") != "test-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/files":
+			file, _, err := r.FormFile("file")
+			require.NoError(t, err)
+			content, _ := io.ReadAll(file)
+			id := "clean"
+			if strings.Contains(string(content), infected) {
+				id = "infected"
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]string{"id": id}})
+
+		case r.URL.Path == "/analyses/clean":
+			polls++
+			status := "queued"
+			if polls > 1 {
+				status = "completed"
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"attributes": map[string]interface{}{"status": status, "stats": map[string]int{"malicious": 0}},
+			}})
+
+		case r.URL.Path == "/analyses/infected":
+			json.NewEncoder(w).Encode(map[string]interface{}{"data": map[string]interface{}{
+				"attributes": map[string]interface{}{
+					"status": "completed",
+					"stats":  map[string]int{"malicious": 2},
+					"results": map[string]interface{}{
+						"Kaspersky": map[string]string{"category": "malicious", "result": "EICAR-Test-File"},
+						"ClamAV":    map[string]string{"category": "malicious", "result": "Win.Test.EICAR_HDB-1"},
+						"Avast":     map[string]string{"category": "undetected", "result": ""},
+					},
+				},
+			}})
+
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	virusTotalURL = server.URL
+
+	cfg := config.ScannerConfig{Enabled: true, Engine: EngineVirusTotal, VirusTotalAPIKey: "test-key", Timeout: 5 * time.Second}
+
+	t.Run("should poll the analysis until completed", func(t *testing.T) {
+		clean, threat, err := ScanReader(context.Background(), strings.NewReader("hello"), cfg)
+
+		require.NoError(t, err)
+		assert.True(t, clean)
+		assert.Empty(t, threat)
+		assert.Equal(t, 2, polls)
+	})
+
+	t.Run("should name the threat after the first engine flagging it", func(t *testing.T) {
+		clean, threat, err := ScanReader(context.Background(), strings.NewReader(infected), cfg)
+
+		require.NoError(t, err)
+		assert.False(t, clean)
+		assert.Equal(t, "Win.Test.EICAR_HDB-1", threat)
+	})
+
+	t.Run("should fail on rejected API keys", func(t *testing.T) {
+		wrongKey := cfg
+		wrongKey.VirusTotalAPIKey = "wrong"
+
+		_, _, err := ScanReader(context.Background(), strings.NewReader("hello"), wrongKey)
+
+		assert.ErrorContains(t, err, "VirusTotal returned status 401")
+	})
+}
+
+func TestScanReaderDisabled(t *testing.T) {
+	clean, threat, err := ScanReader(context.Background(), strings.NewReader(infected), config.ScannerConfig{})
+
+	require.NoError(t, err)
+	assert.True(t, clean)
+	assert.Empty(t, threat)
+}
@@ -17,6 +17,7 @@ import (
 type Storage interface {
 	// Core operations
 	Put(ctx context.Context, path string, content io.Reader) error
+	PutChunked(ctx context.Context, path string, content io.Reader, chunkSize int64) error
 	PutFile(ctx context.Context, path string, file *multipart.FileHeader) error
 	Get(ctx context.Context, path string) (io.ReadCloser, error)
 	Delete(ctx context.Context, path string) error
@@ -41,11 +42,18 @@ type Storage interface {
 	// Utility methods
 	Copy(ctx context.Context, from, to string) error
 	Move(ctx context.Context, from, to string) error
+
+	// Image processing
+	Transform(ctx context.Context, srcPath, dstPath string, opts TransformOptions) error
 	
 	// Driver information
 	Driver() string
 }
 
+// DefaultChunkSize is the part size PutChunked uses when none is given.
+// It is also the smallest part S3 compatible multipart uploads accept.
+const DefaultChunkSize int64 = 5 << 20
+
 // FileInfo represents information about a stored file
 type FileInfo struct {
 	Path         string    `json:"path"`
@@ -56,6 +64,8 @@ type FileInfo struct {
 	LastModified time.Time `json:"last_modified"`
 	URL          string    `json:"url,omitempty"`
 	Driver       string    `json:"driver"`
+	// Variants maps the variant names of transformed copies to their paths
+	Variants map[string]string `json:"variants,omitempty"`
 }
 
 // UploadedFile represents an uploaded file with metadata
@@ -108,6 +118,10 @@ func (e *StorageError) Error() string {
 	return fmt.Sprintf("storage %s operation failed for path %s: %v", e.Operation, e.Path, e.Err)
 }
 
+func (e *StorageError) Unwrap() error {
+	return e.Err
+}
+
 func NewStorageError(operation, path string, err error) *StorageError {
 	return &StorageError{
 		Operation: operation,
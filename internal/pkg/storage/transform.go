@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"fmt"
+	"image"
+	"image/jpeg"
+	"io"
+	"strings"
+
+	"github.com/HugoSmits86/nativewebp"
+	"github.com/disintegration/imaging"
+
+	// Registers the WebP decoder with image.Decode
+	_ "golang.org/x/image/webp"
+)
+
+// DefaultTransformQuality is the JPEG quality used when none is given
+const DefaultTransformQuality = 85
+
+// TransformOptions describes how Transform resizes and re-encodes an image
+type TransformOptions struct {
+	// Width and Height bound the result. A zero dimension keeps the aspect
+	// ratio and both zero keeps the original size.
+	Width  int
+	Height int
+	// Format is jpeg, png or webp. Empty keeps the format of the destination
+	// path's extension, falling back to the source format.
+	Format string
+	// Quality is the JPEG quality from 1 to 100. WebP is always lossless.
+	Quality int
+	// Crop fills Width x Height exactly, cropping around the center, instead
+	// of fitting the image inside it
+	Crop bool
+}
+
+// Variant names the variant the options produce, e.g. "300x200-crop.webp"
+func (o TransformOptions) Variant() string {
+	name := fmt.Sprintf("%dx%d", o.Width, o.Height)
+	if o.Crop {
+		name += "-crop"
+	}
+	if o.Format != "" {
+		name += "." + o.Format
+	}
+	return name
+}
+
+// TransformImage decodes the image read from src, resizes it per opts and
+// encodes it to dst. dstPath picks the format when opts.Format is empty.
+func TransformImage(dst io.Writer, src io.Reader, dstPath string, opts TransformOptions) error {
+	img, sourceFormat, err := image.Decode(src)
+	if err != nil {
+		return fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	format, err := transformFormat(opts.Format, dstPath, sourceFormat)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case opts.Crop && opts.Width > 0 && opts.Height > 0:
+		img = imaging.Fill(img, opts.Width, opts.Height, imaging.Center, imaging.Lanczos)
+	case opts.Width > 0 && opts.Height > 0:
+		img = imaging.Fit(img, opts.Width, opts.Height, imaging.Lanczos)
+	case opts.Width > 0 || opts.Height > 0:
+		img = imaging.Resize(img, opts.Width, opts.Height, imaging.Lanczos)
+	}
+
+	switch format {
+	case "jpeg":
+		quality := opts.Quality
+		if quality <= 0 || quality > 100 {
+			quality = DefaultTransformQuality
+		}
+		err = jpeg.Encode(dst, img, &jpeg.Options{Quality: quality})
+	case "png":
+		err = imaging.Encode(dst, img, imaging.PNG)
+	case "webp":
+		err = nativewebp.Encode(dst, img, nil)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to encode %s image: %w", format, err)
+	}
+
+	return nil
+}
+
+// transformFormat resolves the output format of a transformation
+func transformFormat(format, dstPath, sourceFormat string) (string, error) {
+	if format == "" {
+		format = GetFileExtension(dstPath)
+	}
+	if format == "" {
+		format = sourceFormat
+	}
+
+	switch strings.ToLower(format) {
+	case "jpeg", "jpg":
+		return "jpeg", nil
+	case "png":
+		return "png", nil
+	case "webp":
+		return "webp", nil
+	default:
+		return "", fmt.Errorf("unsupported image format: %s", format)
+	}
+}
@@ -0,0 +1,90 @@
+package storage
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testPNG encodes a solid width x height PNG
+func testPNG(t *testing.T, width, height int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			img.Set(x, y, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+		}
+	}
+
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func TestTransformImage(t *testing.T) {
+	transform := func(t *testing.T, dstPath string, opts TransformOptions) (image.Config, string) {
+		var out bytes.Buffer
+		require.NoError(t, TransformImage(&out, bytes.NewReader(testPNG(t, 400, 200)), dstPath, opts))
+
+		cfg, format, err := image.DecodeConfig(&out)
+		require.NoError(t, err)
+		return cfg, format
+	}
+
+	t.Run("should fit the image inside the bounds", func(t *testing.T) {
+		cfg, format := transform(t, "thumb.png", TransformOptions{Width: 100, Height: 100})
+
+		assert.Equal(t, "png", format)
+		assert.Equal(t, 100, cfg.Width)
+		assert.Equal(t, 50, cfg.Height)
+	})
+
+	t.Run("should crop to the exact bounds", func(t *testing.T) {
+		cfg, _ := transform(t, "thumb.png", TransformOptions{Width: 100, Height: 100, Crop: true})
+
+		assert.Equal(t, 100, cfg.Width)
+		assert.Equal(t, 100, cfg.Height)
+	})
+
+	t.Run("should keep the aspect ratio for a single dimension", func(t *testing.T) {
+		cfg, _ := transform(t, "thumb.png", TransformOptions{Height: 50})
+
+		assert.Equal(t, 100, cfg.Width)
+		assert.Equal(t, 50, cfg.Height)
+	})
+
+	t.Run("should encode the requested format", func(t *testing.T) {
+		_, format := transform(t, "thumb", TransformOptions{Width: 50, Format: "jpeg", Quality: 70})
+		assert.Equal(t, "jpeg", format)
+
+		_, format = transform(t, "thumb", TransformOptions{Width: 50, Format: "webp"})
+		assert.Equal(t, "webp", format)
+	})
+
+	t.Run("should take the format from the destination path", func(t *testing.T) {
+		_, format := transform(t, "thumb.jpg", TransformOptions{Width: 50})
+		assert.Equal(t, "jpeg", format)
+
+		_, format = transform(t, "thumb", TransformOptions{Width: 50})
+		assert.Equal(t, "png", format)
+	})
+
+	t.Run("should reject unsupported formats and non-images", func(t *testing.T) {
+		var out bytes.Buffer
+
+		err := TransformImage(&out, bytes.NewReader(testPNG(t, 10, 10)), "thumb.gif", TransformOptions{})
+		assert.ErrorContains(t, err, "unsupported image format")
+
+		err = TransformImage(&out, bytes.NewReader([]byte("not an image")), "thumb.png", TransformOptions{})
+		assert.ErrorContains(t, err, "failed to decode image")
+	})
+}
+
+func TestTransformOptionsVariant(t *testing.T) {
+	assert.Equal(t, "300x200", TransformOptions{Width: 300, Height: 200}.Variant())
+	assert.Equal(t, "300x200-crop.webp", TransformOptions{Width: 300, Height: 200, Crop: true, Format: "webp"}.Variant())
+}
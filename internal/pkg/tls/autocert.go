@@ -0,0 +1,76 @@
+package tls
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+
+	"golang.org/x/crypto/acme/autocert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// NewAutoCertManager creates a Let's Encrypt certificate manager for the given
+// domain. Issued certificates are cached on disk and renewed automatically.
+func NewAutoCertManager(domain, cacheDir string) (*autocert.Manager, error) {
+	if domain == "" {
+		return nil, fmt.Errorf("domain is required for automatic certificates")
+	}
+	if cacheDir == "" {
+		return nil, fmt.Errorf("cache directory is required for automatic certificates")
+	}
+
+	if err := os.MkdirAll(cacheDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create certificate cache directory: %w", err)
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domain),
+		Cache:      autocert.DirCache(cacheDir),
+	}, nil
+}
+
+// NewStaticConfig builds a server TLS config from a manually provisioned
+// certificate and key, for domains that ACME cannot validate.
+func NewStaticConfig(certFile, keyFile string) (*tls.Config, error) {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	return &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}, nil
+}
+
+// GRPCServerOption makes a gRPC server present the certificate of the HTTPS
+// server configured with cfg. Certificates renewed by an autocert manager are
+// picked up on the next handshake, as they are for HTTPS.
+func GRPCServerOption(cfg *tls.Config) grpc.ServerOption {
+	return grpc.Creds(credentials.NewTLS(cfg))
+}
+
+// RedirectHandler redirects every plain HTTP request to its HTTPS equivalent.
+func RedirectHandler() http.Handler {
+	return RedirectToPort("")
+}
+
+// RedirectToPort redirects every plain HTTP request to its HTTPS equivalent
+// served on port, the default 443 when empty.
+func RedirectToPort(port string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if port != "" && port != "443" {
+			host = net.JoinHostPort(host, port)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusMovedPermanently)
+	})
+}
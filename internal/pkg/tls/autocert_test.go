@@ -0,0 +1,182 @@
+package tls
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func writeSelfSignedCert(t *testing.T) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "127.0.0.1"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile := filepath.Join(dir, "cert.pem")
+	keyFile := filepath.Join(dir, "key.pem")
+
+	require.NoError(t, os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	return certFile, keyFile
+}
+
+func TestNewAutoCertManager(t *testing.T) {
+	t.Run("should create manager with cache directory", func(t *testing.T) {
+		cacheDir := filepath.Join(t.TempDir(), "certs")
+
+		manager, err := NewAutoCertManager("example.com", cacheDir)
+
+		require.NoError(t, err)
+		assert.NotNil(t, manager.Cache)
+		assert.NoError(t, manager.HostPolicy(nil, "example.com"))
+		assert.Error(t, manager.HostPolicy(nil, "other.com"))
+		assert.DirExists(t, cacheDir)
+	})
+
+	t.Run("should reject missing domain or cache directory", func(t *testing.T) {
+		_, err := NewAutoCertManager("", t.TempDir())
+		assert.Error(t, err)
+
+		_, err = NewAutoCertManager("example.com", "")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewStaticConfig(t *testing.T) {
+	t.Run("should serve HTTPS with a manual certificate", func(t *testing.T) {
+		certFile, keyFile := writeSelfSignedCert(t)
+
+		cfg, err := NewStaticConfig(certFile, keyFile)
+		require.NoError(t, err)
+
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("secure"))
+		}))
+		server.TLS = cfg
+		server.StartTLS()
+		defer server.Close()
+
+		client := server.Client()
+		client.Transport.(*http.Transport).TLSClientConfig.InsecureSkipVerify = true
+
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		defer resp.Body.Close()
+
+		body, _ := io.ReadAll(resp.Body)
+		assert.Equal(t, "secure", string(body))
+	})
+
+	t.Run("should fail when certificate files are missing", func(t *testing.T) {
+		_, err := NewStaticConfig("missing.pem", "missing.key")
+		assert.Error(t, err)
+	})
+}
+
+func TestGRPCServerOption(t *testing.T) {
+	t.Run("should serve gRPC with the HTTPS certificate", func(t *testing.T) {
+		certFile, keyFile := writeSelfSignedCert(t)
+
+		cfg, err := NewStaticConfig(certFile, keyFile)
+		require.NoError(t, err)
+
+		server := grpc.NewServer(GRPCServerOption(cfg))
+		healthpb.RegisterHealthServer(server, health.NewServer())
+
+		lis, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		go server.Serve(lis)
+		defer server.Stop()
+
+		var peerCert []byte
+		creds := credentials.NewTLS(&tls.Config{
+			InsecureSkipVerify: true,
+			VerifyConnection: func(state tls.ConnectionState) error {
+				peerCert = state.PeerCertificates[0].Raw
+				return nil
+			},
+		})
+		conn, err := grpc.NewClient(lis.Addr().String(), grpc.WithTransportCredentials(creds))
+		require.NoError(t, err)
+		defer conn.Close()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		resp, err := healthpb.NewHealthClient(conn).Check(ctx, &healthpb.HealthCheckRequest{}, grpc.WaitForReady(true))
+		require.NoError(t, err)
+
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+		assert.Equal(t, cfg.Certificates[0].Certificate[0], peerCert)
+	})
+}
+
+func TestRedirectHandler(t *testing.T) {
+	t.Run("should redirect HTTP requests to HTTPS", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com:80/api/v1/users?page=2", nil)
+		w := httptest.NewRecorder()
+
+		RedirectHandler().ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://example.com/api/v1/users?page=2", w.Header().Get("Location"))
+	})
+}
+
+func TestRedirectToPort(t *testing.T) {
+	t.Run("should redirect HTTP requests to the HTTPS port", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/docs?q=1", nil)
+		w := httptest.NewRecorder()
+
+		RedirectToPort("8443").ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusMovedPermanently, w.Code)
+		assert.Equal(t, "https://example.com:8443/docs?q=1", w.Header().Get("Location"))
+	})
+
+	t.Run("should leave out the default HTTPS port", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "http://example.com:8080/docs", nil)
+		w := httptest.NewRecorder()
+
+		RedirectToPort("443").ServeHTTP(w, req)
+
+		assert.Equal(t, "https://example.com/docs", w.Header().Get("Location"))
+	})
+}
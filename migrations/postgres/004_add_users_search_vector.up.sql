@@ -0,0 +1,7 @@
+-- Full-text search over user names and emails
+ALTER TABLE users ADD COLUMN IF NOT EXISTS search_vector tsvector
+    GENERATED ALWAYS AS (
+        to_tsvector('english', coalesce(first_name, '') || ' ' || coalesce(last_name, '') || ' ' || email)
+    ) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_users_search_vector ON users USING GIN (search_vector);
@@ -0,0 +1,2 @@
+-- Optimistic locking, every update increments the version
+ALTER TABLE users ADD COLUMN IF NOT EXISTS version INT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Two-factor authentication, the secret is set once the user confirms enrolment
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_secret VARCHAR(64);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS idx_users_oauth;
+ALTER TABLE users DROP COLUMN IF EXISTS oauth_sub;
+ALTER TABLE users DROP COLUMN IF EXISTS oauth_provider;
@@ -0,0 +1,5 @@
+-- Accounts created or linked through Google and GitHub sign-in
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oauth_provider VARCHAR(20);
+ALTER TABLE users ADD COLUMN IF NOT EXISTS oauth_sub VARCHAR(255);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_users_oauth ON users(oauth_provider, oauth_sub) WHERE oauth_provider IS NOT NULL;
@@ -41,15 +41,19 @@ func TestDatabaseMigrations(t *testing.T) {
 			isNullable string
 			hasDefault bool
 		}{
-			"id":            {"uuid", "NO", true},
-			"email":         {"character varying", "NO", false},
-			"password_hash": {"character varying", "NO", false},
-			"first_name":    {"character varying", "NO", false},
-			"last_name":     {"character varying", "NO", false},
-			"role":          {"character varying", "NO", true},
-			"is_active":     {"boolean", "NO", true},
-			"created_at":    {"timestamp with time zone", "NO", true},
-			"updated_at":    {"timestamp with time zone", "NO", true},
+			"id":             {"uuid", "NO", true},
+			"email":          {"character varying", "NO", false},
+			"password_hash":  {"character varying", "NO", false},
+			"first_name":     {"character varying", "NO", false},
+			"last_name":      {"character varying", "NO", false},
+			"role":           {"character varying", "NO", true},
+			"is_active":      {"boolean", "NO", true},
+			"totp_secret":    {"character varying", "YES", false},
+			"totp_enabled":   {"boolean", "NO", true},
+			"oauth_provider": {"character varying", "YES", false},
+			"oauth_sub":      {"character varying", "YES", false},
+			"created_at":     {"timestamp with time zone", "NO", true},
+			"updated_at":     {"timestamp with time zone", "NO", true},
 		}
 
 		columnCount := 0
@@ -85,6 +89,7 @@ func TestDatabaseMigrations(t *testing.T) {
 			"idx_users_is_active",  // Active users index
 			"idx_users_created_at", // Created at index
 			"idx_users_role",       // Role index
+			"idx_users_oauth",      // OAuth account index
 		}
 
 		for _, indexName := range expectedIndexes {
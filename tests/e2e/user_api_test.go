@@ -44,7 +44,7 @@ func SetupTestApp(t *testing.T) *TestApp {
 	jwtService := auth.NewJWTService(env.Config.Auth.JWT.Secret, int(env.Config.Auth.JWT.Expiration.Minutes()))
 
 	// Initialize repositories
-	userRepo := postgres.NewUserRepository(env.DB)
+	userRepo := postgres.NewUserRepository(postgres.NewTimeoutDB(env.DB, 0, nil))
 
 	// Initialize Redis (optional for tests)
 	var cacheRepo repositories.UserCacheRepository
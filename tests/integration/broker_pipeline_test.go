@@ -0,0 +1,319 @@
+//go:build integration
+
+package integration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/testcontainers/testcontainers-go"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+	tcredis "github.com/testcontainers/testcontainers-go/modules/redis"
+
+	"github.com/VeRJiL/go-template/internal/app"
+	"github.com/VeRJiL/go-template/internal/domain/entities"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker/events"
+	"github.com/VeRJiL/go-template/internal/pkg/messagebroker/jobs"
+)
+
+// pipelineTimeout is how long a created user may take to come out of the
+// job queue or the dead-letter queue
+const pipelineTimeout = 10 * time.Second
+
+// services are the Postgres and Redis containers the application runs against
+type services struct {
+	dbHost, dbPort       string
+	redisHost, redisPort string
+}
+
+// startServices starts Postgres with the schema migrations applied and Redis
+func startServices(t *testing.T) *services {
+	t.Helper()
+	ctx := context.Background()
+
+	migrations, err := filepath.Glob("../../migrations/postgres/*.up.sql")
+	require.NoError(t, err)
+	sort.Strings(migrations)
+
+	pg, err := tcpostgres.Run(ctx, "postgres:16-alpine",
+		tcpostgres.WithDatabase("go_template_test"),
+		tcpostgres.WithUsername("postgres"),
+		tcpostgres.WithPassword("postgres"),
+		tcpostgres.WithInitScripts(migrations...),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	testcontainers.CleanupContainer(t, pg)
+	require.NoError(t, err)
+
+	rd, err := tcredis.Run(ctx, "redis:7-alpine")
+	testcontainers.CleanupContainer(t, rd)
+	require.NoError(t, err)
+
+	s := &services{}
+	s.dbHost, err = pg.Host(ctx)
+	require.NoError(t, err)
+	dbPort, err := pg.MappedPort(ctx, "5432/tcp")
+	require.NoError(t, err)
+	s.dbPort = dbPort.Port()
+
+	s.redisHost, err = rd.Host(ctx)
+	require.NoError(t, err)
+	redisPort, err := rd.MappedPort(ctx, "6379/tcp")
+	require.NoError(t, err)
+	s.redisPort = redisPort.Port()
+
+	return s
+}
+
+// pipeline is the application as deployed with the Redis message broker:
+// users created through the HTTP API are published to the broker by the
+// application itself, a consumer turns them into welcome email jobs and a
+// worker processes the jobs, storing each result in Redis.
+type pipeline struct {
+	server    *httptest.Server
+	broker    *messagebroker.Manager
+	redis     *redis.Client
+	processed chan *messagebroker.Job
+	attempts  atomic.Int32
+}
+
+// jobResultKey is where the worker stores the result of a processed job
+func jobResultKey(job *messagebroker.Job) string {
+	return "pipeline:result:" + job.ID
+}
+
+// startPipeline starts the application against the containers. consume is
+// called for every delivery of a user created event with the attempt
+// number; returning an error makes the broker redeliver it.
+func startPipeline(t *testing.T, svc *services, consume func(attempt int) error) *pipeline {
+	t.Helper()
+
+	setApplicationEnv(t, svc)
+	application, err := app.New()
+	require.NoError(t, err)
+	require.NotNil(t, application.Broker(), "MESSAGE_BROKER_ENABLED should connect the broker")
+	t.Cleanup(func() { application.Broker().Close() })
+
+	server := httptest.NewServer(application.Handler())
+	t.Cleanup(server.Close)
+
+	redisClient := redis.NewClient(&redis.Options{Addr: svc.redisHost + ":" + svc.redisPort})
+	t.Cleanup(func() { redisClient.Close() })
+
+	p := &pipeline{
+		server:    server,
+		broker:    application.Broker(),
+		redis:     redisClient,
+		processed: make(chan *messagebroker.Job, 1),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	p.consumeUserEvents(ctx, t, consume)
+	p.processWelcomeEmails(ctx, t)
+
+	return p
+}
+
+// setApplicationEnv points the application at the containers. A topic
+// prefix per test keeps the subtests from seeing each other's events.
+func setApplicationEnv(t *testing.T, svc *services) {
+	t.Helper()
+
+	t.Setenv("DB_HOST", svc.dbHost)
+	t.Setenv("DB_PORT", svc.dbPort)
+	t.Setenv("DB_USER", "postgres")
+	t.Setenv("DB_PASSWORD", "postgres")
+	t.Setenv("DB_NAME", "go_template_test")
+	t.Setenv("REDIS_HOST", svc.redisHost)
+	t.Setenv("REDIS_PORT", svc.redisPort)
+	t.Setenv("JWT_SECRET", "integration-test-secret-key-0123456789")
+	t.Setenv("LOG_LEVEL", "error")
+
+	t.Setenv("MESSAGE_BROKER_ENABLED", "true")
+	t.Setenv("MESSAGE_BROKER_DRIVER", "redis")
+	t.Setenv("MESSAGE_BROKER_REDIS_HOST", svc.redisHost)
+	t.Setenv("MESSAGE_BROKER_REDIS_PORT", svc.redisPort)
+	t.Setenv("MESSAGE_BROKER_TOPIC_PREFIX", fmt.Sprintf("pipeline-%d.", time.Now().UnixNano()))
+	t.Setenv("MESSAGE_BROKER_DLQ_ENABLED", "true")
+	t.Setenv("MESSAGE_BROKER_DLQ_TOPIC", "dead-letters")
+}
+
+// consumeUserEvents enqueues a welcome email for every created user
+func (p *pipeline) consumeUserEvents(ctx context.Context, t *testing.T, consume func(attempt int) error) {
+	enqueuer := jobs.NewUserJobEnqueuer(p.broker)
+
+	err := p.broker.Subscribe(ctx, events.UserCreatedEvent, func(ctx context.Context, msg *messagebroker.Message) error {
+		if err := consume(int(p.attempts.Add(1))); err != nil {
+			return err
+		}
+
+		var data events.UserCreatedData
+		if err := json.Unmarshal(msg.Payload, &data); err != nil {
+			return err
+		}
+
+		return enqueuer.EnqueueWelcomeEmail(ctx, jobs.WelcomeEmailJobData{
+			EmailJobData: jobs.EmailJobData{Email: data.Email, TemplateID: "welcome"},
+		})
+	})
+	require.NoError(t, err)
+}
+
+// processWelcomeEmails stores the result of each job and hands it to the test
+func (p *pipeline) processWelcomeEmails(ctx context.Context, t *testing.T) {
+	err := p.broker.ProcessJobs(ctx, jobs.EmailQueue, func(ctx context.Context, job *messagebroker.Job) error {
+		if err := p.redis.Set(ctx, jobResultKey(job), "sent", time.Minute).Err(); err != nil {
+			return err
+		}
+
+		select {
+		case p.processed <- job:
+		default:
+		}
+		return nil
+	})
+	require.NoError(t, err)
+}
+
+// deadLetters subscribes to the dead-letter topic and returns the messages
+// routed to it
+func (p *pipeline) deadLetters(t *testing.T) <-chan *messagebroker.Message {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t.Cleanup(cancel)
+
+	dead := make(chan *messagebroker.Message, 1)
+	err := p.broker.ProcessDeadLetters(ctx, func(ctx context.Context, msg *messagebroker.Message) error {
+		select {
+		case dead <- msg:
+		default:
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	return dead
+}
+
+// createUser registers a user through the public API
+func (p *pipeline) createUser(t *testing.T, email string) {
+	t.Helper()
+
+	body, err := json.Marshal(entities.CreateUserRequest{
+		Email:     email,
+		Password:  "Password123!",
+		FirstName: "Pipeline",
+		LastName:  "Test",
+		Role:      "user",
+	})
+	require.NoError(t, err)
+
+	resp, err := http.Post(p.server.URL+"/api/v1/auth/register", "application/json", bytes.NewReader(body))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	require.Equal(t, http.StatusCreated, resp.StatusCode)
+}
+
+// awaitJob waits for the worker to process the next welcome email
+func (p *pipeline) awaitJob(t *testing.T) *messagebroker.Job {
+	t.Helper()
+
+	select {
+	case job := <-p.processed:
+		return job
+	case <-time.After(pipelineTimeout):
+		t.Fatalf("no job processed within %s", pipelineTimeout)
+		return nil
+	}
+}
+
+func welcomeEmail(t *testing.T, job *messagebroker.Job) jobs.WelcomeEmailJobData {
+	t.Helper()
+
+	var data jobs.WelcomeEmailJobData
+	require.NoError(t, json.Unmarshal(job.Payload, &data))
+	return data
+}
+
+func TestBrokerPipeline(t *testing.T) {
+	if testing.Short() {
+		t.Skip("Skipping integration tests in short mode")
+	}
+
+	svc := startServices(t)
+
+	t.Run("should process a welcome email for a created user", func(t *testing.T) {
+		p := startPipeline(t, svc, func(int) error { return nil })
+
+		p.createUser(t, "pipeline-happy@example.com")
+		job := p.awaitJob(t)
+
+		assert.Equal(t, jobs.SendWelcomeEmailJob, job.Handler)
+		assert.Equal(t, "pipeline-happy@example.com", welcomeEmail(t, job).Email)
+
+		result, err := p.redis.Get(context.Background(), jobResultKey(job)).Result()
+		require.NoError(t, err)
+		assert.Equal(t, "sent", result)
+	})
+
+	t.Run("should redeliver the event until the consumer succeeds", func(t *testing.T) {
+		p := startPipeline(t, svc, func(attempt int) error {
+			if attempt <= 2 {
+				return errors.New("temporary failure")
+			}
+			return nil
+		})
+
+		p.createUser(t, "pipeline-retry@example.com")
+		job := p.awaitJob(t)
+
+		assert.Equal(t, "pipeline-retry@example.com", welcomeEmail(t, job).Email)
+		assert.EqualValues(t, 3, p.attempts.Load())
+	})
+
+	t.Run("should route the event to the dead letter queue after max retries", func(t *testing.T) {
+		p := startPipeline(t, svc, func(int) error { return errors.New("permanent failure") })
+		dead := p.deadLetters(t)
+
+		p.createUser(t, "pipeline-dead@example.com")
+
+		var msg *messagebroker.Message
+		select {
+		case msg = <-dead:
+		case <-time.After(pipelineTimeout):
+			t.Fatalf("no dead letter within %s", pipelineTimeout)
+		}
+
+		assert.Equal(t, events.UserCreatedEvent, msg.Headers[messagebroker.DeadLetterTopicHeader])
+		assert.Contains(t, msg.Headers[messagebroker.DeadLetterErrorHeader], "permanent failure")
+		assert.Len(t, msg.RetryHistory(), msg.MaxRetries+1)
+		assert.EqualValues(t, msg.MaxRetries+1, p.attempts.Load())
+
+		var data events.UserCreatedData
+		require.NoError(t, json.Unmarshal(msg.Payload, &data))
+		assert.Equal(t, "pipeline-dead@example.com", data.Email)
+
+		select {
+		case job := <-p.processed:
+			t.Fatalf("unexpected job %s for a dead-lettered event", job.ID)
+		default:
+		}
+	})
+}